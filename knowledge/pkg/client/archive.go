@@ -0,0 +1,371 @@
+package client
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultArchiveMaxSize  int64 = 1 << 30 // 1 GiB
+	DefaultArchiveMaxFiles       = 10000
+	DefaultArchiveMaxDepth       = 3
+)
+
+// ArchiveOpts configure how archives (zip, tar, tar.gz, tar.bz2, 7z) are unpacked during IngestPaths.
+// The limits apply to the sum of all (nested) archives in an archive file and protect against zip bombs.
+type ArchiveOpts struct {
+	Disable  bool  // ingest archive files as they are (usually unsupported) instead of unpacking them
+	MaxSize  int64 // maximum total size of the unpacked files in bytes (default: 1 GiB)
+	MaxFiles int   // maximum number of unpacked files (default: 10000)
+	MaxDepth int   // maximum nesting depth of archives in archives (default: 3)
+}
+
+// ArchiveLimitError is returned when an archive exceeds the configured limits
+type ArchiveLimitError struct {
+	Archive string
+	Reason  string
+}
+
+func (e *ArchiveLimitError) Error() string {
+	return fmt.Sprintf("archive %q exceeds limits: %s", e.Archive, e.Reason)
+}
+
+// sevenZipBinaries are tried in order if KNOW_7Z_PATH is not set
+var sevenZipBinaries = []string{"7z", "7zz", "7za"}
+
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tbz", ".7z"}
+
+// IsArchive returns true if the file is an archive that is unpacked during ingestion
+func IsArchive(path string) bool {
+	return archiveExtension(path) != ""
+}
+
+func archiveExtension(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// archiveMember is a file unpacked from an archive
+type archiveMember struct {
+	path    string // location of the unpacked file
+	relPath string // path inside the archive, nested archives are treated like directories, e.g. "docs.zip/a/b.md"
+}
+
+type archiveExtractor struct {
+	opts  ArchiveOpts
+	root  string // the archive file that is being ingested
+	size  int64
+	files int
+}
+
+// unpackArchive unpacks the archive (and any nested archives) into a new temporary directory, which has to be removed by the caller
+func unpackArchive(ctx context.Context, path string, opts ArchiveOpts) (string, []archiveMember, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultArchiveMaxSize
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultArchiveMaxFiles
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = DefaultArchiveMaxDepth
+	}
+
+	tmpDir, err := os.MkdirTemp("", "knowledge-archive-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory for archive %q: %w", path, err)
+	}
+
+	x := &archiveExtractor{opts: opts, root: path}
+	members, err := x.unpack(ctx, path, tmpDir, "", 1)
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", nil, err
+	}
+	return tmpDir, members, nil
+}
+
+func (x *archiveExtractor) unpack(ctx context.Context, path, dest, relBase string, depth int) ([]archiveMember, error) {
+	if err := os.MkdirAll(dest, 0o700); err != nil {
+		return nil, err
+	}
+
+	var err error
+	switch archiveExtension(path) {
+	case ".zip":
+		err = x.unpackZip(path, dest)
+	case ".7z":
+		err = x.unpack7z(ctx, path, dest)
+	default:
+		err = x.unpackTar(path, dest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var members []archiveMember
+	err = filepath.WalkDir(dest, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			slog.Debug("Skipping non-regular file in archive", "archive", path, "file", p)
+			return nil
+		}
+		rel, err := filepath.Rel(dest, p)
+		if err != nil {
+			return err
+		}
+		relPath := filepath.ToSlash(filepath.Join(relBase, rel))
+
+		if IsArchive(p) {
+			if depth >= x.opts.MaxDepth {
+				return &ArchiveLimitError{Archive: x.root, Reason: fmt.Sprintf("archives nested deeper than %d levels", x.opts.MaxDepth)}
+			}
+			nested, err := x.unpack(ctx, p, p+".unpacked", relPath, depth+1)
+			if err != nil {
+				return err
+			}
+			members = append(members, nested...)
+			return nil
+		}
+
+		members = append(members, archiveMember{path: p, relPath: relPath})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// hasHiddenElement returns true if any element of the slash-separated path is hidden, e.g. "a/.git/config"
+func hasHiddenElement(relPath string) bool {
+	for _, elem := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(elem, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// addFile checks the limits before a file of the given (uncompressed) size is unpacked
+func (x *archiveExtractor) addFile(size int64) error {
+	x.files++
+	x.size += size
+	if x.files > x.opts.MaxFiles {
+		return &ArchiveLimitError{Archive: x.root, Reason: fmt.Sprintf("more than %d files", x.opts.MaxFiles)}
+	}
+	if x.size > x.opts.MaxSize {
+		return &ArchiveLimitError{Archive: x.root, Reason: fmt.Sprintf("more than %d bytes unpacked", x.opts.MaxSize)}
+	}
+	return nil
+}
+
+// target returns the destination of an archive entry, rejecting entries that would be written outside of dest
+func target(dest, name string) (string, error) {
+	name = filepath.FromSlash(strings.TrimPrefix(name, "./"))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid file path %q in archive", name)
+	}
+	return filepath.Join(dest, name), nil
+}
+
+// writeFile writes at most the declared size, as the headers of malicious archives may lie about it.
+// The modification time is kept, so that unchanged files in updated archives are not re-ingested.
+func (x *archiveExtractor) writeFile(path string, r io.Reader, size int64, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, io.LimitReader(r, size+1))
+	if err != nil {
+		return err
+	}
+	if n > size {
+		return &ArchiveLimitError{Archive: x.root, Reason: fmt.Sprintf("file %q is larger than declared", filepath.Base(path))}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if !modTime.IsZero() {
+		return os.Chtimes(path, modTime, modTime)
+	}
+	return nil
+}
+
+func (x *archiveExtractor) unpackZip(path, dest string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("failed to open zip archive %q: %w", path, err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		t, err := target(dest, f.Name)
+		if err != nil {
+			return err
+		}
+		if err := x.addFile(int64(f.UncompressedSize64)); err != nil {
+			return err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %q in zip archive %q: %w", f.Name, path, err)
+		}
+		err = x.writeFile(t, rc, int64(f.UncompressedSize64), f.Modified)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *archiveExtractor) unpackTar(path, dest string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var r io.Reader = bufio.NewReader(file)
+	switch archiveExtension(path) {
+	case ".tar.gz", ".tgz":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip archive %q: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	case ".tar.bz2", ".tbz2", ".tbz":
+		r = bzip2.NewReader(r)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar archive %q: %w", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue // directories are created as needed, links and devices are skipped
+		}
+		t, err := target(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if err := x.addFile(hdr.Size); err != nil {
+			return err
+		}
+		if err := x.writeFile(t, tr, hdr.Size, hdr.ModTime); err != nil {
+			return err
+		}
+	}
+}
+
+func sevenZipBinary() (string, error) {
+	if p := os.Getenv("KNOW_7Z_PATH"); p != "" {
+		return p, nil
+	}
+	for _, name := range sevenZipBinaries {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("7z archives require the 7z binary (one of %v in $PATH or set via $KNOW_7Z_PATH)", sevenZipBinaries)
+}
+
+// unpack7z lists the archive first to check the limits and then unpacks it using the 7z binary
+func (x *archiveExtractor) unpack7z(ctx context.Context, path, dest string) error {
+	bin, err := sevenZipBinary()
+	if err != nil {
+		return err
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "l", "-slt", "-ba", "-p", path)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to list 7z archive %q: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	for _, entry := range parse7zListing(out.String()) {
+		if strings.Contains(entry["Attributes"], "D") {
+			continue
+		}
+		if _, err := target(dest, entry["Path"]); err != nil {
+			return err
+		}
+		size, _ := strconv.ParseInt(entry["Size"], 10, 64)
+		if err := x.addFile(size); err != nil {
+			return err
+		}
+	}
+
+	stderr.Reset()
+	cmd = exec.CommandContext(ctx, bin, "x", "-y", "-p", "-o"+dest, path)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to unpack 7z archive %q: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// parse7zListing parses the technical listing (7z l -slt) into one map of properties per entry
+func parse7zListing(listing string) []map[string]string {
+	var entries []map[string]string
+	var entry map[string]string
+	for _, line := range strings.Split(listing, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			entry = nil
+			continue
+		}
+		k, v, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		if entry == nil {
+			entry = map[string]string{}
+			entries = append(entries, entry)
+		}
+		entry[k] = v
+	}
+	return entries
+}
@@ -7,6 +7,7 @@ import (
 	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/flows"
 	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vstypes "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
 type IngestWorkspaceOpts struct {
@@ -19,6 +20,11 @@ type SharedIngestionOpts struct {
 	Metadata            map[string]string
 	ReuseEmbeddings     bool
 	ReuseFiles          bool
+	FilePassword        string   // Password used for encrypted files, unless set per file in the metadata
+	IndexContent        bool     // Store document contents in the Index for keyword search
+	BuildVocabulary     bool     // Build the dataset vocabulary in the Index, e.g. for spell correction
+	Checkpoints         bool     // Record the ingestion progress of files in the Index, so that an interrupted ingestion resumes where it stopped
+	Tags                []string // Tags of the ingested files
 }
 
 type IngestPathsOpts struct {
@@ -31,7 +37,10 @@ type IngestPathsOpts struct {
 	NoCreateDataset      bool
 	Prune                bool // Prune deleted files
 	ErrOnUnsupportedFile bool
+	ErrOnEncryptedFile   bool
 	ExitOnFailedFile     bool
+	Archive              ArchiveOpts               // unpacking of archives (zip, tar, 7z, ...)
+	Include              func(absPath string) bool // if set, only files for which it returns true are ingested, e.g. the changed files in watch mode
 }
 
 type Client interface {
@@ -42,7 +51,7 @@ type Client interface {
 	DeleteFile(ctx context.Context, datasetID, fileID string) error
 	ListDatasets(ctx context.Context) ([]types2.Dataset, error)
 	Ingest(ctx context.Context, datasetID string, name string, data []byte, opts datastore.IngestOpts) ([]string, error)
-	IngestPaths(ctx context.Context, datasetID string, opts *IngestPathsOpts, paths ...string) (int, int, error) // returns number of files ingested, number of files skipped and first encountered error
+	IngestPaths(ctx context.Context, datasetID string, opts *IngestPathsOpts, paths ...string) (int, int, error) // returns number of files ingested, number of files skipped as unsupported and first encountered error - skipped encrypted files are logged separately
 	AskDirectory(ctx context.Context, path string, query string, opts *IngestPathsOpts, ropts *datastore.RetrieveOpts) (*dstypes.RetrievalResponse, error)
 	PrunePath(ctx context.Context, datasetID string, path string, keep []string) ([]types2.File, error)
 	DeleteDocuments(ctx context.Context, datasetID string, documentIDs ...string) error
@@ -50,5 +59,11 @@ type Client interface {
 	ExportDatasets(ctx context.Context, path string, datasets ...string) error
 	ImportDatasets(ctx context.Context, path string, datasets ...string) error
 	UpdateDataset(ctx context.Context, dataset types2.Dataset, opts *datastore.UpdateDatasetOpts) (*types2.Dataset, error)
+	RebuildVectorIndexes(ctx context.Context) error
+	ReEmbedDataset(ctx context.Context, datasetID string, opts datastore.ReEmbedOpts) (int, error) // returns number of re-embedded documents
+	Stats(ctx context.Context, datasetIDs ...string) ([]vstypes.CollectionStats, error)
+	GarbageCollect(ctx context.Context, opts datastore.GCOpts, datasetIDs ...string) (*datastore.GCReport, error)
+	TagFile(ctx context.Context, datasetID, fileID string, tags ...string) (*types2.File, error)
+	UntagFile(ctx context.Context, datasetID, fileID string, tags ...string) (*types2.File, error)
 	Close() error
 }
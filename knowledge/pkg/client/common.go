@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore"
@@ -22,7 +23,9 @@ import (
 	"gorm.io/gorm"
 )
 
-func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID string, ingestionFunc func(path string, metadata map[string]any) error, paths ...string) (int, int, error) {
+// ingestPaths ingests the files at the given paths using the ingestionFunc, which receives the location of the file and
+// its absolute path to be stored in the index - these differ for files unpacked from archives, e.g. /data/docs.zip/a.md
+func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID string, ingestionFunc func(path string, absPath string, metadata map[string]any) error, paths ...string) (int, int, error) {
 	ingestedFilesCount := 0
 	skippedUnsupportedFilesCount := 0
 
@@ -52,9 +55,97 @@ func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID
 
 	g, ctx := errgroup.WithContext(ctx)
 
+	skippedEncryptedFilesCount := 0
+	var countLock sync.Mutex
+
+	// countIngestResult counts ingested and skipped files, returning nil for errors that only lead to the file being skipped
+	countIngestResult := func(err error) error {
+		countLock.Lock()
+		defer countLock.Unlock()
+		switch {
+		case err == nil:
+			ingestedFilesCount++
+		case !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}):
+			skippedUnsupportedFilesCount++
+			return nil
+		case !opts.ErrOnEncryptedFile && errors.Is(err, &documentloader.EncryptedFileError{}):
+			skippedEncryptedFilesCount++
+			return nil
+		}
+		return err
+	}
+
 	// Stack to store metadata when entering nested directories
 	var metadataStack []Metadata
 
+	// Temporary directories of unpacked archives, removed once all files are ingested
+	var archiveDirs []string
+	defer func() {
+		for _, dir := range archiveDirs {
+			if err := os.RemoveAll(dir); err != nil {
+				slog.Warn("Failed to remove unpacked archive", "dir", dir, "error", err)
+			}
+		}
+	}()
+
+	// ingestFile ingests the file asynchronously - archiveAbsPath is set for files unpacked from archives,
+	// whose metadata is merged with the metadata of the archive
+	ingestFile := func(path, absPath, archiveAbsPath string) {
+		g.Go(func() error {
+			if err := sem.Acquire(ctx, 1); err != nil {
+				return err
+			}
+			defer sem.Release(1)
+
+			fileMeta, err := findMetadata(absPath, metadataStack, opts.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to find metadata for %s: %w", absPath, err)
+			}
+			if archiveAbsPath != "" {
+				archiveMeta, err := findMetadata(archiveAbsPath, metadataStack, opts.Metadata)
+				if err != nil {
+					return fmt.Errorf("failed to find metadata for %s: %w", archiveAbsPath, err)
+				}
+				for k, v := range archiveMeta {
+					if _, ok := fileMeta[k]; !ok {
+						fileMeta[k] = v
+					}
+				}
+			}
+
+			slog.Debug("Ingesting file", "absPath", absPath, "metadata", fileMeta)
+
+			err = ingestionFunc(path, absPath, fileMeta)
+			return countIngestResult(err)
+		})
+	}
+
+	// ingestArchive unpacks the archive and ingests its files, returning their absolute paths as stored in the index
+	ingestArchive := func(absPath string, ignore gitignore.Matcher) ([]string, error) {
+		dir, members, err := unpackArchive(ctx, absPath, opts.Archive)
+		if err != nil {
+			return nil, err
+		}
+		archiveDirs = append(archiveDirs, dir)
+		slog.Info("Unpacked archive", "path", absPath, "files", len(members))
+
+		var memberPaths []string
+		for _, m := range members {
+			if isIgnored(ignore, filepath.FromSlash(m.relPath)) {
+				slog.Debug("Ignoring file in archive", "archive", absPath, "path", m.relPath)
+				continue
+			}
+			if !opts.IncludeHidden && hasHiddenElement(m.relPath) {
+				slog.Debug("Ignoring hidden file in archive", "archive", absPath, "path", m.relPath)
+				continue
+			}
+			memberAbsPath := filepath.Join(absPath, filepath.FromSlash(m.relPath))
+			memberPaths = append(memberPaths, memberAbsPath)
+			ingestFile(m.path, memberAbsPath, absPath)
+		}
+		return memberPaths, nil
+	}
+
 	for _, p := range paths {
 		path := p
 
@@ -145,30 +236,21 @@ func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID
 				if err != nil {
 					return fmt.Errorf("failed to get absolute path for %s: %w", sp, err)
 				}
-				touchedFilePaths = append(touchedFilePaths, absPath)
-
-				g.Go(func() error {
-					if err := sem.Acquire(ctx, 1); err != nil {
-						return err
-					}
-					defer sem.Release(1)
+				if opts.Include != nil && !opts.Include(absPath) {
+					return nil
+				}
 
-					fileMeta, err := findMetadata(absPath, metadataStack, opts.Metadata)
+				if !opts.Archive.Disable && IsArchive(absPath) {
+					memberPaths, err := ingestArchive(absPath, ignore)
 					if err != nil {
-						return fmt.Errorf("failed to find metadata for %s: %w", absPath, err)
+						return err
 					}
+					touchedFilePaths = append(touchedFilePaths, memberPaths...)
+					return nil
+				}
 
-					slog.Debug("Ingesting file", "absPath", absPath, "metadata", fileMeta)
-
-					err = ingestionFunc(sp, fileMeta)
-					if err != nil && !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}) {
-						skippedUnsupportedFilesCount++
-						err = nil
-					} else if err == nil {
-						ingestedFilesCount++
-					}
-					return err
-				})
+				touchedFilePaths = append(touchedFilePaths, absPath)
+				ingestFile(sp, absPath, "")
 				return nil
 			})
 			if err != nil {
@@ -183,33 +265,26 @@ func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID
 			if err != nil {
 				return ingestedFilesCount, skippedUnsupportedFilesCount, fmt.Errorf("failed to get absolute path for %s: %w", path, err)
 			}
-			touchedFilePaths = append(touchedFilePaths, absPath)
-
-			// Process a file directly
-			g.Go(func() error {
-				if err := sem.Acquire(ctx, 1); err != nil {
-					return err
-				}
-				defer sem.Release(1)
+			if opts.Include != nil && !opts.Include(absPath) {
+				continue
+			}
 
-				fileMeta, err := findMetadata(absPath, metadataStack, opts.Metadata)
+			if !opts.Archive.Disable && IsArchive(absPath) {
+				memberPaths, err := ingestArchive(absPath, ignore)
 				if err != nil {
-					return fmt.Errorf("failed to find metadata for %s: %w", absPath, err)
+					return ingestedFilesCount, skippedUnsupportedFilesCount, err
 				}
+				touchedFilePaths = append(touchedFilePaths, memberPaths...)
+			} else {
+				touchedFilePaths = append(touchedFilePaths, absPath)
 
-				err = ingestionFunc(path, fileMeta)
-				if err != nil && !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}) {
-					skippedUnsupportedFilesCount++
-					err = nil
-				} else if err == nil {
-					ingestedFilesCount++
-				}
-				return err
-			})
+				// Process a file directly
+				ingestFile(path, absPath, "")
+			}
 		}
 
-		// Prune files for this basePath
-		if opts.Prune && fileInfo.IsDir() {
+		// Prune files for this basePath - not if only some files are included, as the others were not touched
+		if opts.Prune && opts.Include == nil && fileInfo.IsDir() {
 			g.Go(func() error {
 				pruned, err := c.PrunePath(ctx, datasetID, path, touchedFilePaths)
 				if err != nil {
@@ -222,7 +297,13 @@ func ingestPaths(ctx context.Context, c Client, opts *IngestPathsOpts, datasetID
 	}
 
 	// Wait for all goroutines to finish
-	return ingestedFilesCount, skippedUnsupportedFilesCount, g.Wait()
+	err = g.Wait()
+
+	if skippedEncryptedFilesCount > 0 {
+		slog.Warn("Skipped encrypted files without password", "count", skippedEncryptedFilesCount)
+	}
+
+	return ingestedFilesCount, skippedUnsupportedFilesCount, err
 }
 
 func HashPath(path string) string {
@@ -0,0 +1,224 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors"
+	ctypes "github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
+	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// metadataKeySyncState is the prefix of the dataset metadata keys of the connectors' sync states, followed by the source URL
+const metadataKeySyncState = "syncState:"
+
+// IngestConnector ingests the documents of the connector's source URL, e.g. gdrive://<folder-id> (see connectors.New),
+// with the source URL and the document ID as absolute path, e.g. gdrive://<folder-id>/<file-id>.
+// The connector's sync state is stored in the dataset metadata, so that subsequent runs only ingest the documents changed since the
+// last successful run. Unless another deduplication function is set, documents are deduplicated by their version.
+// With the prune option, removed documents are deleted as well.
+// The ignore and concurrency options apply as for local paths. It returns the number of ingested and skipped
+// (unchanged or unsupported) documents and the first encountered error.
+func IngestConnector(ctx context.Context, c Client, datasetID string, rawURL string, opts *IngestPathsOpts) (int, int, error) {
+	conn, err := connectors.New(ctx, rawURL)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ds, err := getOrCreateDataset(ctx, c, datasetID, !opts.NoCreateDataset)
+	if err != nil {
+		return 0, 0, err
+	}
+	ds, err = c.GetDataset(ctx, ds.ID, &types2.DatasetGetOpts{IncludeFiles: true})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	baseURL := strings.TrimSuffix(rawURL, "/") + "/"
+	existing := map[string]types2.File{}
+	for _, f := range ds.Files {
+		if strings.HasPrefix(f.AbsolutePath, baseURL) {
+			existing[f.AbsolutePath] = f
+		}
+	}
+
+	dedupeFuncName := opts.IsDuplicateFuncName
+	if dedupeFuncName == "" {
+		dedupeFuncName = dedupeFuncETag
+	}
+
+	ignore, err := newIgnoreMatcher(opts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 10
+	}
+	sem := semaphore.NewWeighted(int64(opts.Concurrency))
+
+	var ingested, unchanged, skipped, encrypted int
+	var countLock sync.Mutex
+
+	// run syncs from the state and returns the next state and the absolute paths of the listed documents
+	run := func(state string) (string, map[string]struct{}, error) {
+		g, gctx := errgroup.WithContext(ctx)
+		listed := map[string]struct{}{}
+
+		changed := func(doc ctypes.Document) error {
+			absPath := baseURL + doc.ID
+			if ignore.Match([]string{doc.Name}, false) || (!opts.IncludeHidden && hasHiddenElement(doc.Name)) {
+				slog.Debug("Ignoring document", "url", absPath, "name", doc.Name)
+				return nil
+			}
+			if opts.Include != nil && !opts.Include(absPath) {
+				return nil
+			}
+			listed[absPath] = struct{}{}
+
+			if f, ok := existing[absPath]; ok && dedupeFuncName == dedupeFuncETag && doc.Version != "" && f.ETag == doc.Version {
+				slog.Debug("Skipping unchanged document", "url", absPath, "version", doc.Version)
+				countLock.Lock()
+				unchanged++
+				countLock.Unlock()
+				return nil
+			}
+
+			g.Go(func() error {
+				if err := sem.Acquire(gctx, 1); err != nil {
+					return err
+				}
+				defer sem.Release(1)
+
+				err := ingestDocument(gctx, c, conn, datasetID, doc, absPath, dedupeFuncName, opts)
+
+				countLock.Lock()
+				defer countLock.Unlock()
+				switch {
+				case err == nil:
+					ingested++
+				case !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}):
+					skipped++
+				case !opts.ErrOnEncryptedFile && errors.Is(err, &documentloader.EncryptedFileError{}):
+					encrypted++
+				default:
+					return err
+				}
+				return nil
+			})
+			return nil
+		}
+
+		removed := func(id string) error {
+			absPath := baseURL + id
+			delete(listed, absPath)
+			f, ok := existing[absPath]
+			if !ok || !opts.Prune {
+				return nil
+			}
+			if err := c.DeleteFile(gctx, datasetID, f.ID); err != nil {
+				return fmt.Errorf("failed to delete removed file %q: %w", absPath, err)
+			}
+			delete(existing, absPath)
+			slog.Info("Deleted removed document", "url", absPath)
+			return nil
+		}
+
+		next, err := conn.Sync(gctx, state, changed, removed)
+		if werr := g.Wait(); err == nil {
+			err = werr
+		}
+		return next, listed, err
+	}
+
+	stateKey := metadataKeySyncState + rawURL
+	state, _ := ds.Metadata[stateKey].(string)
+	next, listed, err := run(state)
+	if errors.Is(err, ctypes.ErrStateExpired) {
+		slog.Info("Sync state expired, syncing all documents", "url", rawURL)
+		state = ""
+		next, listed, err = run(state)
+	}
+	if err != nil {
+		return ingested, unchanged + skipped, err
+	}
+	if unchanged > 0 {
+		slog.Info("Skipped unchanged documents", "count", unchanged)
+	}
+	if encrypted > 0 {
+		slog.Warn("Skipped encrypted documents without password", "count", encrypted)
+	}
+
+	// Prune documents that are gone after a full sync - not if only some documents are included, as the others were not listed
+	if state == "" && opts.Prune && opts.Include == nil {
+		var pruned int
+		for absPath, f := range existing {
+			if _, ok := listed[absPath]; ok {
+				continue
+			}
+			if err := c.DeleteFile(ctx, datasetID, f.ID); err != nil {
+				return ingested, unchanged + skipped, fmt.Errorf("failed to prune file %q: %w", absPath, err)
+			}
+			pruned++
+		}
+		slog.Info("Pruned files", "count", pruned, "basePath", baseURL)
+	}
+
+	// Only store the state after a successful sync, so that failed documents are synced again next time
+	if _, err := c.UpdateDataset(ctx, types2.Dataset{ID: ds.ID, Metadata: map[string]any{stateKey: next}}, nil); err != nil {
+		return ingested, unchanged + skipped, fmt.Errorf("failed to store sync state: %w", err)
+	}
+
+	return ingested, unchanged + skipped, nil
+}
+
+func ingestDocument(ctx context.Context, c Client, conn ctypes.Connector, datasetID string, doc ctypes.Document, absPath string, dedupeFuncName string, opts *IngestPathsOpts) error {
+	content, err := conn.Load(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]any, len(opts.Metadata)+len(doc.Metadata))
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+
+	size := doc.Size
+	if size == 0 {
+		size = int64(len(content))
+	}
+
+	iopts := datastore.IngestOpts{
+		FileMetadata: &types2.FileMetadata{
+			Name:         doc.Name,
+			AbsolutePath: absPath,
+			Size:         size,
+			ModifiedAt:   doc.ModifiedAt,
+			ETag:         doc.Version,
+		},
+		IsDuplicateFuncName: dedupeFuncName,
+		ExtraMetadata:       metadata,
+		IngestionFlows:      opts.IngestionFlows,
+		ReuseEmbeddings:     opts.ReuseEmbeddings,
+		ReuseFiles:          opts.ReuseFiles,
+		Password:            opts.FilePassword,
+		IndexContent:        opts.IndexContent,
+		BuildVocabulary:     opts.BuildVocabulary,
+		Tags:                opts.Tags,
+	}
+
+	_, err = c.Ingest(log.ToCtx(ctx, log.FromCtx(ctx).With("url", absPath).With("absolute_path", absPath)), datasetID, doc.Name, content, iopts)
+	return err
+}
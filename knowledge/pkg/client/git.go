@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	remotes "github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/remote"
+	"github.com/obot-platform/tools/knowledge/pkg/objectstore"
+)
+
+const (
+	MetadataKeyGitRepository = "gitRepository" // URL of the repository a file was ingested from
+	MetadataKeyGitCommit     = "gitCommit"     // SHA of the commit a file was ingested at
+	MetadataKeyGitPath       = "gitPath"       // path of a file in the repository
+)
+
+// IsGitURL reports whether the path is a git repository URL, e.g. git+https://github.com/org/repo
+func IsGitURL(path string) bool {
+	return strings.HasPrefix(path, "git+")
+}
+
+// parseGitURL splits a git+<url>[@<ref>] URL into the repository URL and the ref (branch, tag or commit)
+func parseGitURL(rawURL string) (string, string, error) {
+	u, err := url.Parse(strings.TrimPrefix(rawURL, "git+"))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid git URL %q: %w", rawURL, err)
+	}
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return "", "", fmt.Errorf("invalid git URL %q: expected git+<scheme>://<host>/<repository>[@<ref>]", rawURL)
+	}
+	var ref string
+	if i := strings.LastIndex(u.Path, "@"); i >= 0 {
+		ref = u.Path[i+1:]
+		u.Path = u.Path[:i]
+	}
+	return u.String(), ref, nil
+}
+
+// IngestGitRepo ingests the files at the paths (default: all files) of the git repository at the git+<url>[@<ref>] URL,
+// e.g. git+https://github.com/org/repo@v1.2.0. The repository is cloned into the cache directory once and fetched incrementally afterwards.
+// Files are ingested with the repository URL and their path as absolute path, e.g. git+https://github.com/org/repo/docs/setup.md,
+// and the repository URL, commit SHA and path in the metadata. Files are deduplicated by their blob hash, so only files changed since
+// the last ingested commit are ingested again, and files removed since then are pruned.
+func IngestGitRepo(ctx context.Context, c Client, datasetID string, rawURL string, paths []string, opts *IngestPathsOpts) (int, int, error) {
+	repoURL, ref, err := parseGitURL(rawURL)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	dir, err := xdg.CacheFile("gptscript/knowledge/git/" + HashPath(repoURL))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get cache directory for repo %q: %w", repoURL, err)
+	}
+	commit, err := remotes.SyncRepo(ctx, repoURL, ref, dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get tree of commit %s: %w", commit.Hash, err)
+	}
+
+	// key prefixes of the paths - with a trailing slash for directories, so that e.g. "docs" doesn't match "docs2/"
+	prefixes := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		if p == "" {
+			prefixes = append(prefixes, "")
+			continue
+		}
+		if _, err := tree.Tree(p); err == nil {
+			p += "/"
+		} else if _, err := tree.File(p); err != nil {
+			return 0, 0, fmt.Errorf("path %q not found in repo %q at commit %s", p, repoURL, commit.Hash)
+		}
+		prefixes = append(prefixes, p)
+	}
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	gopts := *opts
+	gopts.Prune = true
+
+	baseURL := "git+" + repoURL + "/"
+	sha := commit.Hash.String()
+	return ingestObjects(ctx, c, datasetID, &gitTree{tree: tree, modifiedAt: commit.Committer.When}, baseURL, prefixes, &gopts, func(obj objectstore.Object) map[string]any {
+		return map[string]any{
+			MetadataKeyGitRepository: repoURL,
+			MetadataKeyGitCommit:     sha,
+			MetadataKeyGitPath:       obj.Key,
+		}
+	})
+}
+
+// gitTree is a commit's tree as an objectstore.Bucket, with the file paths as keys and the blob hashes as ETags
+type gitTree struct {
+	tree       *object.Tree
+	modifiedAt time.Time
+	lock       sync.Mutex // the repository storage is not safe for concurrent reads
+}
+
+// List calls fn for the regular files with the path prefix
+func (t *gitTree) List(_ context.Context, prefix string, fn func(objectstore.Object) error) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.tree.Files().ForEach(func(f *object.File) error {
+		if !strings.HasPrefix(f.Name, prefix) {
+			return nil
+		}
+		if f.Mode == filemode.Symlink || f.Mode == filemode.Submodule {
+			return nil
+		}
+		return fn(objectstore.Object{
+			Key:        f.Name,
+			Size:       f.Size,
+			ModifiedAt: t.modifiedAt,
+			ETag:       f.Hash.String(),
+		})
+	})
+}
+
+func (t *gitTree) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	f, err := t.tree.File(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file %q from git tree: %w", key, err)
+	}
+	content, err := f.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %q from git tree: %w", key, err)
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (t *gitTree) Close() error {
+	return nil
+}
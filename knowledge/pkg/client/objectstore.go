@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
+	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/obot-platform/tools/knowledge/pkg/objectstore"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// dedupeFuncETag is the default deduplication function for objects
+const dedupeFuncETag = "etag"
+
+// IngestObjects ingests the objects under the object store URL, e.g. s3://bucket/prefix, gs://bucket/prefix or az://container/prefix,
+// with their URL as absolute path and their ETag in the file metadata (see objectstore.Open for the credentials).
+// Unless another deduplication function is set, objects are deduplicated by their ETag: unchanged objects are skipped without downloading them
+// and changed objects replace the previously ingested version.
+// The ignore, hidden files, recursion, concurrency and prune options apply as for local paths. It returns the number of ingested and
+// skipped (unchanged or unsupported) objects and the first encountered error.
+func IngestObjects(ctx context.Context, c Client, datasetID string, rawURL string, opts *IngestPathsOpts) (int, int, error) {
+	loc, err := objectstore.ParseURL(rawURL)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	bucket, err := objectstore.Open(ctx, loc)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer bucket.Close()
+
+	return ingestObjects(ctx, c, datasetID, bucket, loc.URL(""), []string{loc.Prefix}, opts, nil)
+}
+
+// ingestObjects ingests the objects with the key prefixes from the bucket, with baseURL + key as absolute path.
+// If set, metadataFunc returns additional metadata for an object.
+func ingestObjects(ctx context.Context, c Client, datasetID string, bucket objectstore.Bucket, baseURL string, prefixes []string, opts *IngestPathsOpts, metadataFunc func(objectstore.Object) map[string]any) (int, int, error) {
+	ds, err := getOrCreateDataset(ctx, c, datasetID, !opts.NoCreateDataset)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	dedupeFuncName := opts.IsDuplicateFuncName
+	if dedupeFuncName == "" {
+		dedupeFuncName = dedupeFuncETag
+	}
+
+	// Previously ingested objects, to skip unchanged objects before downloading them and to prune deleted ones
+	existing := map[string]types2.File{}
+	if dedupeFuncName == dedupeFuncETag || opts.Prune {
+		ds, err = c.GetDataset(ctx, ds.ID, &types2.DatasetGetOpts{IncludeFiles: true})
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, f := range ds.Files {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(f.AbsolutePath, baseURL+prefix) {
+					existing[f.AbsolutePath] = f
+					break
+				}
+			}
+		}
+	}
+
+	ignore, err := newIgnoreMatcher(opts)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 10
+	}
+	sem := semaphore.NewWeighted(int64(opts.Concurrency))
+	g, gctx := errgroup.WithContext(ctx)
+
+	var ingested, unchanged, skipped, encrypted int
+	var countLock sync.Mutex
+	listed := map[string]struct{}{}
+
+	listObject := func(prefix string, obj objectstore.Object) error {
+		if strings.HasSuffix(obj.Key, "/") {
+			return nil // directory marker
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(obj.Key, prefix), "/")
+		if rel == "" {
+			rel = path.Base(obj.Key) // the URL points to a single object
+		}
+		if !opts.Recursive && strings.Contains(rel, "/") {
+			return nil
+		}
+		if ignore.Match(strings.Split(rel, "/"), false) || (!opts.IncludeHidden && hasHiddenElement(rel)) {
+			slog.Debug("Ignoring object", "key", obj.Key)
+			return nil
+		}
+		absPath := baseURL + obj.Key
+		if opts.Include != nil && !opts.Include(absPath) {
+			return nil
+		}
+		listed[absPath] = struct{}{}
+
+		if f, ok := existing[absPath]; ok && dedupeFuncName == dedupeFuncETag && obj.ETag != "" && f.ETag == obj.ETag {
+			slog.Debug("Skipping unchanged object", "url", absPath, "etag", obj.ETag)
+			countLock.Lock()
+			unchanged++
+			countLock.Unlock()
+			return nil
+		}
+
+		g.Go(func() error {
+			if err := sem.Acquire(gctx, 1); err != nil {
+				return err
+			}
+			defer sem.Release(1)
+
+			err := ingestObject(gctx, c, bucket, datasetID, obj, absPath, dedupeFuncName, opts, metadataFunc)
+
+			countLock.Lock()
+			defer countLock.Unlock()
+			switch {
+			case err == nil:
+				ingested++
+			case !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}):
+				skipped++
+			case !opts.ErrOnEncryptedFile && errors.Is(err, &documentloader.EncryptedFileError{}):
+				encrypted++
+			default:
+				return err
+			}
+			return nil
+		})
+		return nil
+	}
+	for _, prefix := range prefixes {
+		if err := bucket.List(gctx, prefix, func(obj objectstore.Object) error { return listObject(prefix, obj) }); err != nil {
+			_ = g.Wait()
+			return ingested, unchanged + skipped, err
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return ingested, unchanged + skipped, err
+	}
+	if unchanged > 0 {
+		slog.Info("Skipped unchanged objects", "count", unchanged)
+	}
+	if encrypted > 0 {
+		slog.Warn("Skipped encrypted objects without password", "count", encrypted)
+	}
+
+	// Prune deleted objects - not if only some objects are included, as the others were not listed
+	if opts.Prune && opts.Include == nil {
+		var pruned int
+		for absPath, f := range existing {
+			if _, ok := listed[absPath]; ok {
+				continue
+			}
+			if err := c.DeleteFile(ctx, datasetID, f.ID); err != nil {
+				return ingested, unchanged + skipped, fmt.Errorf("failed to prune file %q: %w", absPath, err)
+			}
+			pruned++
+		}
+		slog.Info("Pruned files", "count", pruned, "basePath", baseURL)
+	}
+
+	return ingested, unchanged + skipped, nil
+}
+
+// newIgnoreMatcher returns the matcher of the default ignore patterns, the patterns of the ignore file and the ignored extensions
+func newIgnoreMatcher(opts *IngestPathsOpts) (gitignore.Matcher, error) {
+	ignorePatterns := append([]gitignore.Pattern{}, DefaultIgnorePatterns...)
+	if opts.IgnoreFile != "" {
+		p, err := readIgnoreFile(opts.IgnoreFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ignore file %q: %w", opts.IgnoreFile, err)
+		}
+		ignorePatterns = append(ignorePatterns, p...)
+	}
+	for _, ext := range opts.IgnoreExtensions {
+		if ext != "" {
+			ignorePatterns = append(ignorePatterns, gitignore.ParsePattern("*."+strings.TrimPrefix(ext, "."), nil))
+		}
+	}
+	return gitignore.NewMatcher(ignorePatterns), nil
+}
+
+func ingestObject(ctx context.Context, c Client, bucket objectstore.Bucket, datasetID string, obj objectstore.Object, absPath string, dedupeFuncName string, opts *IngestPathsOpts, metadataFunc func(objectstore.Object) map[string]any) error {
+	r, err := bucket.Open(ctx, obj.Key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read object %q: %w", absPath, err)
+	}
+
+	metadata := make(map[string]any, len(opts.Metadata))
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	if metadataFunc != nil {
+		for k, v := range metadataFunc(obj) {
+			metadata[k] = v
+		}
+	}
+
+	filename := path.Base(obj.Key)
+	iopts := datastore.IngestOpts{
+		FileMetadata: &types2.FileMetadata{
+			Name:         filename,
+			AbsolutePath: absPath,
+			Size:         obj.Size,
+			ModifiedAt:   obj.ModifiedAt,
+			ETag:         obj.ETag,
+		},
+		IsDuplicateFuncName: dedupeFuncName,
+		ExtraMetadata:       metadata,
+		IngestionFlows:      opts.IngestionFlows,
+		ReuseEmbeddings:     opts.ReuseEmbeddings,
+		ReuseFiles:          opts.ReuseFiles,
+		Password:            opts.FilePassword,
+		IndexContent:        opts.IndexContent,
+		BuildVocabulary:     opts.BuildVocabulary,
+		Tags:                opts.Tags,
+	}
+
+	_, err = c.Ingest(log.ToCtx(ctx, log.FromCtx(ctx).With("url", absPath).With("absolute_path", absPath)), datasetID, filename, content, iopts)
+	return err
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/crawler"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
+	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+type IngestSitemapOpts struct {
+	IngestURLOpts
+	Prune bool // remove pages of the sitemap's site from the dataset that are no longer listed in the sitemap (or don't match the patterns anymore)
+}
+
+// IsSitemap reports whether the URL looks like a sitemap, e.g. https://example.com/sitemap.xml or sitemap_index.xml.gz
+func IsSitemap(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	p := strings.ToLower(u.Path)
+	return strings.Contains(p[strings.LastIndex(p, "/")+1:], "sitemap") && (strings.HasSuffix(p, ".xml") || strings.HasSuffix(p, ".xml.gz"))
+}
+
+// IngestSitemap ingests the pages listed in the sitemap (see IngestURL for how pages are ingested), matching the include and exclude patterns
+// of the crawl options. Pages with a lastmod in the sitemap that's not newer than the modification time of the already ingested page
+// are skipped without fetching them, so that large sites can be kept in sync efficiently. The ingested pages get the lastmod as modification time.
+// It returns the number of ingested and skipped (unchanged or unsupported) pages and the first ingestion error.
+func IngestSitemap(ctx context.Context, c Client, datasetID string, sitemapURL string, opts *IngestSitemapOpts) (int, int, error) {
+	ds, err := getOrCreateDataset(ctx, c, datasetID, !opts.NoCreateDataset)
+	if err != nil {
+		return 0, 0, err
+	}
+	ds, err = c.GetDataset(ctx, ds.ID, &types2.DatasetGetOpts{IncludeFiles: true})
+	if err != nil {
+		return 0, 0, err
+	}
+	existing := make(map[string]types2.File, len(ds.Files))
+	for _, f := range ds.Files {
+		existing[f.AbsolutePath] = f
+	}
+
+	crawl := crawler.New(opts.Crawl)
+	pages, err := crawl.Sitemap(ctx, sitemapURL)
+	if err != nil {
+		return 0, 0, err
+	}
+	slog.Info("Loaded sitemap", "sitemap", sitemapURL, "pages", len(pages))
+
+	listed := make(map[string]struct{}, len(pages))
+	for _, sp := range pages {
+		listed[sp.Loc] = struct{}{}
+	}
+
+	var ingested, unchanged, skipped int
+	var firstErr error
+	for _, sp := range pages {
+		if opts.Crawl.MaxPages > 0 && ingested+skipped >= opts.Crawl.MaxPages {
+			slog.Info("Reached maximum number of pages, stopping", "maxPages", opts.Crawl.MaxPages)
+			break
+		}
+
+		if f, ok := existing[sp.Loc]; ok && !sp.LastMod.IsZero() && !sp.LastMod.After(f.ModifiedAt) {
+			unchanged++
+			continue
+		}
+
+		page, err := crawl.Fetch(ctx, sp.Loc)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ingested, unchanged + skipped, ctx.Err()
+			}
+			if errors.Is(err, crawler.ErrDisallowed) {
+				slog.Debug("Skipping page disallowed by robots.txt", "url", sp.Loc)
+			} else {
+				slog.Warn("Failed to fetch page, skipping", "url", sp.Loc, "error", err)
+			}
+			skipped++
+			continue
+		}
+		// the page is stored by its sitemap URL (not a redirect target), so that it's found on the next run
+		page.URL = sp.Loc
+		if !sp.LastMod.IsZero() {
+			page.LastModified = sp.LastMod
+		}
+
+		err = ingestPage(ctx, c, datasetID, *page, &opts.IngestURLOpts)
+		switch {
+		case err == nil:
+			ingested++
+		case !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}):
+			slog.Debug("Skipping page with unsupported content type", "url", page.URL, "contentType", page.ContentType)
+			skipped++
+		default:
+			slog.Error("Failed to ingest page", "url", page.URL, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if unchanged > 0 {
+		slog.Info("Skipped unchanged pages", "count", unchanged)
+	}
+
+	if opts.Prune && firstErr == nil {
+		if err := pruneSitemapPages(ctx, c, datasetID, sitemapURL, existing, listed); err != nil {
+			return ingested, unchanged + skipped, err
+		}
+	}
+
+	return ingested, unchanged + skipped, firstErr
+}
+
+// pruneSitemapPages removes the pages of the sitemap's site (same scheme and host) that were not listed in the sitemap
+func pruneSitemapPages(ctx context.Context, c Client, datasetID, sitemapURL string, existing map[string]types2.File, listed map[string]struct{}) error {
+	u, err := url.Parse(sitemapURL)
+	if err != nil {
+		return err
+	}
+	site := u.Scheme + "://" + u.Host + "/"
+
+	var pruned int
+	for absPath, f := range existing {
+		if !strings.HasPrefix(absPath, site) {
+			continue
+		}
+		if _, ok := listed[absPath]; ok {
+			continue
+		}
+		if err := c.DeleteFile(ctx, datasetID, f.ID); err != nil {
+			return fmt.Errorf("failed to prune page %q: %w", absPath, err)
+		}
+		pruned++
+	}
+	slog.Info("Pruned pages", "count", pruned, "site", site)
+	return nil
+}
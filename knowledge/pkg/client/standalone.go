@@ -9,6 +9,7 @@ import (
 
 	"github.com/gptscript-ai/go-gptscript"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	remotes "github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/remote"
 	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
@@ -114,6 +115,10 @@ func (c *StandaloneClient) IngestFromWorkspace(ctx context.Context, datasetID st
 		IngestionFlows:      opts.IngestionFlows,
 		ReuseEmbeddings:     opts.ReuseEmbeddings,
 		ReuseFiles:          opts.ReuseFiles,
+		Password:            opts.FilePassword,
+		IndexContent:        opts.IndexContent,
+		BuildVocabulary:     opts.BuildVocabulary,
+		Tags:                opts.Tags,
 	}
 
 	_, err = c.Ingest(log.ToCtx(ctx, log.FromCtx(ctx).With("filepath", file).With("absolute_path", iopts.FileMetadata.AbsolutePath)), datasetID, finfo.Name, fileContent, iopts)
@@ -137,18 +142,13 @@ func (c *StandaloneClient) IngestPaths(ctx context.Context, datasetID string, op
 		return 0, 0, err
 	}
 
-	ingestFile := func(path string, extraMetadata map[string]any) error {
+	ingestFile := func(path string, abspath string, extraMetadata map[string]any) error {
 		// Gather metadata
 		finfo, err := os.Stat(path)
 		if err != nil {
 			return fmt.Errorf("failed to stat file %s: %w", path, err)
 		}
 
-		abspath, err := filepath.Abs(path)
-		if err != nil {
-			return fmt.Errorf("failed to get absolute path for %s: %w", path, err)
-		}
-
 		file, err := os.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to open file %s: %w", path, err)
@@ -167,6 +167,11 @@ func (c *StandaloneClient) IngestPaths(ctx context.Context, datasetID string, op
 			ExtraMetadata:       extraMetadata,
 			ReuseEmbeddings:     opts.ReuseEmbeddings,
 			ReuseFiles:          opts.ReuseFiles,
+			IndexContent:        opts.IndexContent,
+			BuildVocabulary:     opts.BuildVocabulary,
+			Password:            opts.FilePassword,
+			Checkpoints:         opts.Checkpoints,
+			Tags:                opts.Tags,
 		}
 
 		if opts != nil {
@@ -178,7 +183,25 @@ func (c *StandaloneClient) IngestPaths(ctx context.Context, datasetID string, op
 		return err
 	}
 
-	return ingestPaths(ctx, c, opts, datasetID, ingestFile, paths...)
+	ingested, skipped, err := ingestPaths(ctx, c, opts, datasetID, ingestFile, paths...)
+	if err != nil || !opts.Checkpoints {
+		return ingested, skipped, err
+	}
+
+	// All files were ingested, so the next ingestion starts over instead of resuming
+	for _, path := range paths {
+		if remotes.IsRemote(path) {
+			continue
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return ingested, skipped, fmt.Errorf("failed to get absolute path for %s: %w", path, err)
+		}
+		if err := c.Datastore.DeleteIngestionCheckpoints(ctx, datasetID, abs); err != nil {
+			return ingested, skipped, err
+		}
+	}
+	return ingested, skipped, nil
 }
 
 func (c *StandaloneClient) PrunePath(ctx context.Context, datasetID string, path string, keep []string) ([]types2.File, error) {
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/crawler"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/readability"
+	types2 "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+)
+
+const (
+	MetadataKeyURL       = "url"       // source URL of a crawled page
+	MetadataKeyCrawledAt = "crawledAt" // time a page was crawled (RFC 3339)
+)
+
+type IngestURLOpts struct {
+	SharedIngestionOpts
+	Crawl                crawler.Options
+	NoCreateDataset      bool
+	NoCleanHTML          bool     // ingest HTML pages as they are, instead of removing scripts, navigation, footers etc. first
+	RemoveSelectors      []string // CSS selectors of additional elements to remove from HTML pages, e.g. site-specific banners
+	ErrOnUnsupportedFile bool
+}
+
+// IngestURL crawls the website from the start URL (see crawler.Crawler) and ingests the fetched pages, with their URL as path,
+// so that re-crawling a site updates the pages. HTML pages are cleaned before ingestion. Linked documents of other types (e.g. PDFs)
+// are ingested as well, if supported. It returns the number of ingested and skipped pages and the first ingestion error.
+func IngestURL(ctx context.Context, c Client, datasetID string, startURL string, opts *IngestURLOpts) (int, int, error) {
+	if _, err := getOrCreateDataset(ctx, c, datasetID, !opts.NoCreateDataset); err != nil {
+		return 0, 0, err
+	}
+
+	var ingested, skipped int
+	var firstErr error
+	_, err := crawler.New(opts.Crawl).Crawl(ctx, startURL, func(ctx context.Context, page crawler.Page) error {
+		err := ingestPage(ctx, c, datasetID, page, opts)
+		switch {
+		case err == nil:
+			ingested++
+		case !opts.ErrOnUnsupportedFile && errors.Is(err, &documentloader.UnsupportedFileTypeError{}):
+			slog.Debug("Skipping page with unsupported content type", "url", page.URL, "contentType", page.ContentType)
+			skipped++
+		default:
+			slog.Error("Failed to ingest page", "url", page.URL, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ingested, skipped, err
+	}
+	return ingested, skipped, firstErr
+}
+
+func ingestPage(ctx context.Context, c Client, datasetID string, page crawler.Page, opts *IngestURLOpts) error {
+	content := page.Content
+	if page.ContentType == "text/html" && !opts.NoCleanHTML {
+		cleaned, err := readability.Clean(content, opts.RemoveSelectors...)
+		if err != nil {
+			slog.Warn("Failed to clean HTML, ingesting it as it is", "url", page.URL, "error", err)
+		} else {
+			content = cleaned
+		}
+	}
+
+	metadata := make(map[string]any, len(opts.Metadata)+2)
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata[MetadataKeyURL] = page.URL
+	metadata[MetadataKeyCrawledAt] = page.FetchedAt.UTC().Format(time.RFC3339)
+
+	modifiedAt := page.LastModified
+	if modifiedAt.IsZero() {
+		modifiedAt = page.FetchedAt
+	}
+
+	filename := pageFilename(page)
+	iopts := datastore.IngestOpts{
+		FileMetadata: &types2.FileMetadata{
+			Name:         filename,
+			AbsolutePath: page.URL,
+			Size:         int64(len(content)),
+			ModifiedAt:   modifiedAt,
+		},
+		IsDuplicateFuncName: opts.IsDuplicateFuncName,
+		ExtraMetadata:       metadata,
+		IngestionFlows:      opts.IngestionFlows,
+		ReuseEmbeddings:     opts.ReuseEmbeddings,
+		ReuseFiles:          opts.ReuseFiles,
+		Password:            opts.FilePassword,
+		IndexContent:        opts.IndexContent,
+		BuildVocabulary:     opts.BuildVocabulary,
+		Tags:                opts.Tags,
+	}
+
+	_, err := c.Ingest(log.ToCtx(ctx, log.FromCtx(ctx).With("url", page.URL).With("absolute_path", page.URL)), datasetID, filename, content, iopts)
+	return err
+}
+
+// pageFilename derives a filename from the last element of the URL path, with the file extension of the page's content type,
+// e.g. "configure.html" for https://docs.example.com/widgets/configure
+func pageFilename(page crawler.Page) string {
+	name := ""
+	if u, err := url.Parse(page.URL); err == nil {
+		name = path.Base(u.Path)
+		if name == "/" || name == "." {
+			name = u.Hostname()
+		}
+	}
+	if name == "" {
+		name = "index"
+	}
+
+	var ext string
+	switch page.ContentType {
+	case "text/html", "application/xhtml+xml":
+		ext = ".html"
+	case "text/markdown":
+		ext = ".md"
+	case "text/plain":
+		ext = ".txt"
+	default:
+		if exts, _ := mime.ExtensionsByType(page.ContentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	if ext != "" && !strings.EqualFold(path.Ext(name), ext) {
+		name += ext
+	}
+	return name
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+const DefaultWatchDebounce = time.Second
+
+// WatchPath keeps the dataset in sync with the directory at the given path until the context is canceled:
+// changed files are (re-)ingested and deleted files are removed from the dataset.
+// Changes are collected until no further change happened for the debounce duration, so that rapid edits of a file
+// result in a single ingestion. The directory itself is not ingested initially - use IngestPaths with Prune for that.
+func WatchPath(ctx context.Context, c Client, datasetID string, path string, opts *IngestPathsOpts, debounce time.Duration) error {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path for %s: %w", path, err)
+	}
+	finfo, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("failed to get file info for %s: %w", root, err)
+	}
+	if !finfo.IsDir() {
+		return fmt.Errorf("path %q is not a directory", root)
+	}
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchDir(watcher, root, opts.Recursive); err != nil {
+		return err
+	}
+	slog.Info("Watching directory for changes", "path", root, "dataset", datasetID, "debounce", debounce)
+
+	changes := map[string]struct{}{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue // content didn't change
+			}
+			slog.Debug("File change detected", "path", event.Name, "op", event.Op.String())
+
+			// directories created (or moved) in the watched tree have to be watched as well
+			if event.Has(fsnotify.Create) && opts.Recursive {
+				if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+					if err := watchDir(watcher, event.Name, true); err != nil {
+						slog.Warn("Failed to watch new directory", "path", event.Name, "error", err)
+					}
+				}
+			}
+
+			changes[event.Name] = struct{}{}
+			timer.Reset(debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("File watcher error", "error", err)
+		case <-timer.C:
+			paths := make([]string, 0, len(changes))
+			for p := range changes {
+				paths = append(paths, p)
+			}
+			clear(changes)
+
+			if err := syncChanges(ctx, c, datasetID, root, opts, paths); err != nil {
+				if opts.ExitOnFailedFile {
+					return err
+				}
+				slog.Error("Failed to sync changes", "error", err, "path", root, "dataset", datasetID)
+			}
+		}
+	}
+}
+
+// watchDir adds the directory and, if recursive, all its subdirectories to the watcher
+func watchDir(watcher *fsnotify.Watcher, dir string, recursive bool) error {
+	if !recursive {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch directory %s: %w", dir, err)
+		}
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("failed to watch directory %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// changeSet is a batch of changed paths, split into the ones that still exist and the ones that were deleted
+type changeSet struct {
+	changed []string
+	deleted []string
+	resync  bool // a metadata or ignore file changed, which may affect any file
+}
+
+func newChangeSet(paths []string) changeSet {
+	var cs changeSet
+	for _, p := range paths {
+		// the existence at sync time is what matters, e.g. editors often replace a file by deleting or renaming it first
+		if _, err := os.Lstat(p); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Failed to get file info", "path", p, "error", err)
+				continue
+			}
+			cs.deleted = append(cs.deleted, p)
+		} else {
+			cs.changed = append(cs.changed, p)
+		}
+		if name := filepath.Base(p); name == MetadataFilename || name == DefaultIgnoreFile {
+			cs.resync = true
+		}
+	}
+	slices.Sort(cs.changed)
+	slices.Sort(cs.deleted)
+	return cs
+}
+
+// includes returns true if the file is one of the changed paths or inside of a changed directory
+func (cs changeSet) includes(absPath string) bool {
+	return withinAny(cs.changed, absPath)
+}
+
+// deletes returns true if the file is one of the deleted paths or inside of a deleted directory or archive
+func (cs changeSet) deletes(absPath string) bool {
+	return withinAny(cs.deleted, absPath)
+}
+
+func withinAny(paths []string, absPath string) bool {
+	for _, p := range paths {
+		if absPath == p || strings.HasPrefix(absPath, p+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func syncChanges(ctx context.Context, c Client, datasetID string, root string, opts *IngestPathsOpts, paths []string) error {
+	cs := newChangeSet(paths)
+
+	if cs.resync {
+		// metadata and ignore rules may have changed for any file, so sync the whole directory
+		slog.Info("Metadata or ignore file changed, syncing the whole directory", "path", root)
+		o := *opts
+		o.Prune = true
+		o.Include = nil
+		ingested, skipped, err := c.IngestPaths(ctx, datasetID, &o, root)
+		slog.Info("Synced directory", "path", root, "ingested", ingested, "skipped", skipped)
+		return err
+	}
+
+	if len(cs.deleted) > 0 {
+		ds, err := c.GetDataset(ctx, datasetID, &types.DatasetGetOpts{IncludeFiles: true})
+		if err != nil {
+			return fmt.Errorf("failed to get dataset %q: %w", datasetID, err)
+		}
+		if ds == nil {
+			return fmt.Errorf("dataset %q not found", datasetID)
+		}
+		for _, f := range ds.Files {
+			if !cs.deletes(f.AbsolutePath) {
+				continue
+			}
+			if err := c.DeleteFile(ctx, datasetID, f.ID); err != nil {
+				return fmt.Errorf("failed to delete file %s: %w", f.AbsolutePath, err)
+			}
+			slog.Info("Removed deleted file from dataset", "path", f.AbsolutePath, "dataset", datasetID)
+		}
+	}
+
+	if len(cs.changed) > 0 {
+		// ingest through the directory, so that the ignore rules and directory metadata apply as usual
+		o := *opts
+		o.Prune = false
+		o.Include = cs.includes
+		ingested, skipped, err := c.IngestPaths(ctx, datasetID, &o, root)
+		slog.Info("Ingested changed files", "path", root, "ingested", ingested, "skipped", skipped)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchTestClient records the files ingested and deleted by WatchPath
+type watchTestClient struct {
+	Client
+	lock     sync.Mutex
+	files    []types.File
+	ingested []string
+	deleted  []string
+}
+
+func (c *watchTestClient) GetDataset(_ context.Context, datasetID string, _ *types.DatasetGetOpts) (*types.Dataset, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return &types.Dataset{ID: datasetID, Files: c.files}, nil
+}
+
+func (c *watchTestClient) DeleteFile(_ context.Context, _, fileID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deleted = append(c.deleted, fileID)
+	return nil
+}
+
+func (c *watchTestClient) IngestPaths(_ context.Context, _ string, opts *IngestPathsOpts, paths ...string) (int, int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if opts.Include == nil || opts.Include(path) {
+				c.ingested = append(c.ingested, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(c.ingested), 0, nil
+}
+
+func (c *watchTestClient) results() ([]string, []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.ingested...), append([]string(nil), c.deleted...)
+}
+
+func TestWatchPath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	unchanged := filepath.Join(dir, "unchanged.md")
+	deleted := filepath.Join(dir, "sub", "deleted.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(deleted), 0755))
+	require.NoError(t, os.WriteFile(unchanged, []byte("foo"), 0644))
+	require.NoError(t, os.WriteFile(deleted, []byte("foo"), 0644))
+
+	c := &watchTestClient{files: []types.File{
+		{ID: "unchanged", FileMetadata: types.FileMetadata{AbsolutePath: unchanged}},
+		{ID: "deleted", FileMetadata: types.FileMetadata{AbsolutePath: deleted}},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- WatchPath(ctx, c, "foo", dir, &IngestPathsOpts{Recursive: true}, 100*time.Millisecond)
+	}()
+	time.Sleep(200 * time.Millisecond) // let the watcher start
+
+	// rapid edits of the same file are synced once
+	changed := filepath.Join(dir, "sub", "changed.md")
+	for range 5 {
+		require.NoError(t, os.WriteFile(changed, []byte("bar"), 0644))
+	}
+	require.NoError(t, os.Remove(deleted))
+
+	// files in new directories are picked up
+	added := filepath.Join(dir, "new", "added.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(added), 0755))
+	time.Sleep(50 * time.Millisecond) // the new directory is watched once its creation was seen
+	require.NoError(t, os.WriteFile(added, []byte("baz"), 0644))
+
+	assert.Eventually(t, func() bool {
+		ingested, deletedIDs := c.results()
+		return assert.ObjectsAreEqual([]string{"deleted"}, deletedIDs) && len(ingested) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	ingested, _ := c.results()
+	assert.ElementsMatch(t, []string{changed, added}, ingested)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
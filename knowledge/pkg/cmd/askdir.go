@@ -11,6 +11,7 @@ import (
 	"github.com/acorn-io/z"
 	"github.com/obot-platform/tools/knowledge/pkg/client"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
 	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
 	"github.com/spf13/cobra"
 )
@@ -48,6 +49,9 @@ func (s *ClientAskDir) Run(cmd *cobra.Command, args []string) error {
 			IsDuplicateFuncName: s.DeduplicationFuncName,
 			ReuseEmbeddings:     true,
 			ReuseFiles:          true,
+			FilePassword:        s.FilePassword,
+			IndexContent:        s.IndexContent,
+			BuildVocabulary:     s.BuildVocabulary,
 		},
 		IgnoreExtensions:     strings.Split(s.IgnoreExtensions, ","),
 		Concurrency:          s.Concurrency,
@@ -56,11 +60,30 @@ func (s *ClientAskDir) Run(cmd *cobra.Command, args []string) error {
 		IncludeHidden:        s.IncludeHidden,
 		Prune:                !s.NoPrune,
 		ErrOnUnsupportedFile: s.ErrOnUnsupportedFile,
+		ErrOnEncryptedFile:   s.ErrOnEncryptedFile,
+		Archive:              s.archiveOpts(),
 	}
 
 	retrieveOpts := &datastore.RetrieveOpts{
 		TopK:     s.TopK,
 		Keywords: s.Keywords,
+		Offset:   s.Offset,
+	}
+
+	history, err := querymodifiers.ParseConversationHistory(s.History)
+	if err != nil {
+		return err
+	}
+	retrieveOpts.History = history
+
+	retrieveOpts.Filters, err = parseFilters(s.Filters)
+	if err != nil {
+		return err
+	}
+
+	retrieveOpts.Where, err = parseWhere(s.Where)
+	if err != nil {
+		return err
 	}
 
 	if s.FlowsFile != "" {
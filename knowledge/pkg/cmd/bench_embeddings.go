@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/config"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+type ClientBenchEmbeddings struct {
+	EmbeddingModelProvider string `usage:"Embedding model provider" env:"KNOW_EMBEDDING_MODEL_PROVIDER" name:"embedding-model-provider" default:"openai" koanf:"provider"`
+	ConfigFile             string `usage:"Path to the configuration file" env:"KNOW_CONFIG_FILE" default:"" short:"c"`
+
+	Count       int `usage:"Number of synthetic texts to embed" short:"n" default:"100"`
+	Concurrency int `usage:"Number of concurrent embedding requests" short:"C" default:"10"`
+	TextSize    int `usage:"Approximate number of words per synthetic text" default:"200"`
+}
+
+type BenchEmbeddingsResult struct {
+	Provider          string           `json:"provider"`
+	Model             string           `json:"model"`
+	Count             int              `json:"count"`
+	Concurrency       int              `json:"concurrency"`
+	Succeeded         int              `json:"succeeded"`
+	Failed            int              `json:"failed"`
+	RateLimitErrors   int              `json:"rateLimitErrors"`
+	Retries           int64            `json:"retries"`          // retries of all requests, including those that succeeded eventually
+	RateLimitRetries  int64            `json:"rateLimitRetries"` // retries after rate limit errors
+	Errors            []string         `json:"errors,omitempty"`
+	DurationSeconds   float64          `json:"durationSeconds"`
+	RequestsPerSecond float64          `json:"requestsPerSecond"`
+	TokensPerSecond   float64          `json:"tokensPerSecond"`
+	TotalTokens       int              `json:"totalTokens"`
+	LatencyMillis     map[string]int64 `json:"latencyMillis"`
+}
+
+// maxReportedErrors limits the number of distinct error messages included in the result
+const maxReportedErrors = 10
+
+var benchWords = strings.Fields("the quick brown fox jumps over lazy dog knowledge retrieval embedding vector database document chunk query model token latency throughput search index dataset ingestion pipeline context answer question semantic similarity")
+
+func (s *ClientBenchEmbeddings) Customize(cmd *cobra.Command) {
+	cmd.Use = "bench-embeddings"
+	cmd.Short = "Benchmark the configured embedding model provider"
+	cmd.Long = "Embed a number of synthetic texts at a given concurrency and report throughput, latency percentiles, retries and rate-limit errors. Useful to size VS_PGVECTOR_EMBEDDING_CONCURRENCY."
+	cmd.Args = cobra.NoArgs
+}
+
+func (s *ClientBenchEmbeddings) Run(cmd *cobra.Command, _ []string) error {
+	if s.Count <= 0 {
+		return fmt.Errorf("count must be greater than 0")
+	}
+	if s.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be greater than 0")
+	}
+	if s.TextSize <= 0 {
+		return fmt.Errorf("text-size must be greater than 0")
+	}
+
+	ctx := cmd.Context()
+
+	cfg, err := config.LoadConfig(s.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	provider, err := embeddings.GetSelectedEmbeddingsModelProvider(s.EmbeddingModelProvider, cfg.EmbeddingsConfig)
+	if err != nil {
+		return err
+	}
+
+	embeddingFunc, err := provider.EmbeddingFunc()
+	if err != nil {
+		return fmt.Errorf("failed to get embedding function: %w", err)
+	}
+
+	tk, err := tiktoken.GetEncoding(defaults.TokenEncoding)
+	if err != nil {
+		return fmt.Errorf("failed to get tokenizer: %w", err)
+	}
+
+	texts := make([]string, s.Count)
+	totalTokens := 0
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := range texts {
+		words := make([]string, s.TextSize)
+		for j := range words {
+			words[j] = benchWords[rng.Intn(len(benchWords))]
+		}
+		// prefix with the index so that no two texts are identical (avoids provider-side caching)
+		texts[i] = strconv.Itoa(i) + " " + strings.Join(words, " ")
+		totalTokens += len(tk.Encode(texts[i], []string{}, []string{"all"}))
+	}
+
+	result := BenchEmbeddingsResult{
+		Provider:    provider.Name(),
+		Model:       provider.EmbeddingModelName(),
+		Count:       s.Count,
+		Concurrency: s.Concurrency,
+	}
+
+	var (
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, s.Count)
+		succeeded int
+		tokensOK  int
+	)
+
+	// count the retries of providers using openai.RequestWithExponentialBackoff
+	var retryStats openai.RetryStats
+	ctx = openai.RetryStatsToCtx(ctx, &retryStats)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.Concurrency)
+
+	start := time.Now()
+	for i, text := range texts {
+		g.Go(func() error {
+			t := time.Now()
+			_, err := embeddingFunc(gctx, text)
+			d := time.Since(t)
+
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, d)
+			if err != nil {
+				result.Failed++
+				if isRateLimitError(err) {
+					result.RateLimitErrors++
+				}
+				if len(result.Errors) < maxReportedErrors {
+					result.Errors = append(result.Errors, fmt.Sprintf("text #%d: %v", i, err))
+				}
+				return nil
+			}
+			succeeded++
+			tokensOK += len(tk.Encode(text, []string{}, []string{"all"}))
+			return nil
+		})
+	}
+	_ = g.Wait()
+	elapsed := time.Since(start)
+
+	result.Succeeded = succeeded
+	result.Retries = retryStats.Retries.Load()
+	result.RateLimitRetries = retryStats.RateLimitRetries.Load()
+	result.TotalTokens = totalTokens
+	result.DurationSeconds = elapsed.Seconds()
+	if elapsed > 0 {
+		result.RequestsPerSecond = float64(succeeded) / elapsed.Seconds()
+		result.TokensPerSecond = float64(tokensOK) / elapsed.Seconds()
+	}
+
+	slices.Sort(latencies)
+	result.LatencyMillis = map[string]int64{
+		"min": percentile(latencies, 0).Milliseconds(),
+		"p50": percentile(latencies, 50).Milliseconds(),
+		"p90": percentile(latencies, 90).Milliseconds(),
+		"p95": percentile(latencies, 95).Milliseconds(),
+		"p99": percentile(latencies, 99).Milliseconds(),
+		"max": percentile(latencies, 100).Milliseconds(),
+	}
+
+	jsonOutput, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal benchmark result: %w", err)
+	}
+
+	fmt.Println(string(jsonOutput))
+
+	return nil
+}
+
+// percentile returns the p-th percentile of the given sorted durations using the nearest-rank method
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	rank := int(math.Ceil(p*float64(len(sorted))/100)) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// isRateLimitError checks whether the (retried) request failed due to a rate limit, i.e. HTTP 429, based on the status code
+// of the provider's error (see openai.RequestError - the errors of the AWS SDK used for Bedrock have the same method)
+func isRateLimitError(err error) bool {
+	var statusErr interface{ HTTPStatusCode() int }
+	return errors.As(err, &statusErr) && statusErr.HTTPStatusCode() == http.StatusTooManyRequests
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := func(n int) []time.Duration {
+		d := make([]time.Duration, n)
+		for i := range d {
+			d[i] = time.Duration(i + 1)
+		}
+		return d
+	}
+
+	for _, tc := range []struct {
+		n             int
+		p50, p95, p99 time.Duration
+	}{
+		{n: 1, p50: 1, p95: 1, p99: 1},
+		{n: 2, p50: 1, p95: 2, p99: 2},
+		{n: 3, p50: 2, p95: 3, p99: 3},
+		{n: 4, p50: 2, p95: 4, p99: 4},
+		{n: 10, p50: 5, p95: 10, p99: 10},
+		{n: 20, p50: 10, p95: 19, p99: 20},
+		{n: 100, p50: 50, p95: 95, p99: 99},
+	} {
+		sorted := durations(tc.n)
+		assert.Equal(t, tc.p50, percentile(sorted, 50), "p50 of %d", tc.n)
+		assert.Equal(t, tc.p95, percentile(sorted, 95), "p95 of %d", tc.n)
+		assert.Equal(t, tc.p99, percentile(sorted, 99), "p99 of %d", tc.n)
+		assert.Equal(t, time.Duration(1), percentile(sorted, 0), "min of %d", tc.n)
+		assert.Equal(t, time.Duration(tc.n), percentile(sorted, 100), "max of %d", tc.n)
+	}
+
+	assert.Zero(t, percentile(nil, 50))
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	rateLimited := &openai.RequestError{StatusCode: http.StatusTooManyRequests, Tries: 5, Failure: "#5/5: 429 <slow down>"}
+	assert.True(t, isRateLimitError(fmt.Errorf("error sending request(s): %w", rateLimited)))
+	assert.True(t, isRateLimitError(&smithy.OperationError{
+		ServiceID: "Bedrock Runtime",
+		Err:       &smithyhttp.ResponseError{Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusTooManyRequests}}},
+	}))
+
+	assert.False(t, isRateLimitError(&openai.RequestError{StatusCode: http.StatusServiceUnavailable, Failure: "#5/5: 503 <rate limit of the upstream>"}))
+	assert.False(t, isRateLimitError(&openai.RequestError{Failure: "#1/5: failed to send request: connection refused"}))
+	assert.False(t, isRateLimitError(errors.New("rate limit: 429")), "error messages are not parsed")
+}
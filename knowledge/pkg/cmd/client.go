@@ -15,20 +15,25 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/datastore"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/encryption"
 )
 
 type Client struct {
 	datastoreArchive string
 
-	EmbeddingModelProvider string `usage:"Embedding model provider" env:"KNOW_EMBEDDING_MODEL_PROVIDER" name:"embedding-model-provider" default:"openai" koanf:"provider"`
-	ConfigFile             string `usage:"Path to the configuration file" env:"KNOW_CONFIG_FILE" default:"" short:"c"`
+	EmbeddingModelProvider  string   `usage:"Embedding model provider" env:"KNOW_EMBEDDING_MODEL_PROVIDER" name:"embedding-model-provider" default:"openai" koanf:"provider"`
+	EmbeddingModelFallbacks []string `usage:"Fallback embedding model providers, tried in order if the embedding model provider fails - they must serve the same model with the same dimensions" env:"KNOW_EMBEDDING_MODEL_PROVIDER_FALLBACKS" name:"embedding-model-provider-fallback"`
+	ConfigFile              string   `usage:"Path to the configuration file" env:"KNOW_CONFIG_FILE" default:"" short:"c"`
+	EmbeddingCache          bool     `usage:"Cache embeddings by content hash in the index database, so that re-ingesting unchanged content doesn't call the embedding model provider again" default:"false" env:"KNOW_EMBEDDING_CACHE"`
+	EmbeddingRPM            int      `usage:"Maximum number of requests per minute to the embedding model provider (0 = unlimited)" default:"0" env:"KNOW_EMBEDDING_REQUESTS_PER_MINUTE" name:"embedding-requests-per-minute"`
 
 	config.DatabaseConfig
 	config.VectorDBConfig
+	config.EncryptionConfig
 }
 
 type ClientFlowsConfig struct {
-	FlowsFile string `usage:"Path to a YAML/JSON file containing ingestion/retrieval flows" env:"KNOW_FLOWS_FILE" default:"blueprint:default"`
+	FlowsFile string `usage:"Path to a YAML/JSON file containing ingestion/retrieval flows or blueprint:<name> (see knowledge flows list-blueprints)" env:"KNOW_FLOWS_FILE" default:"blueprint:default"`
 	Flow      string `usage:"Flow name" env:"KNOW_FLOW"`
 }
 
@@ -112,23 +117,32 @@ func (s *Client) getClient(ctx context.Context) (client.Client, error) {
 		return nil, err
 	}
 
-	cfg, err := config.LoadConfig(s.ConfigFile)
+	ds, err := s.getDatastore(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+		return nil, err
 	}
-
-	provider, err := embeddings.GetSelectedEmbeddingsModelProvider(s.EmbeddingModelProvider, cfg.EmbeddingsConfig)
+	c, err := client.NewStandaloneClient(ctx, ds)
 	if err != nil {
 		return nil, err
 	}
+	return c, nil
+}
 
-	ds, err := datastore.NewDatastore(ctx, s.DatabaseConfig.DSN, s.AutoMigrate == "true", s.VectorDBConfig.DSN, provider)
+func (s *Client) getDatastore(ctx context.Context) (*datastore.Datastore, error) {
+	cfg, err := config.LoadConfig(s.ConfigFile)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
-	c, err := client.NewStandaloneClient(ctx, ds)
+
+	provider, err := embeddings.GetSelectedEmbeddingsModelProviderWithFallbacks(s.EmbeddingModelProvider, s.EmbeddingModelFallbacks, cfg.EmbeddingsConfig)
 	if err != nil {
 		return nil, err
 	}
-	return c, nil
+
+	cipher, err := encryption.NewCipherFromConfig(ctx, s.EncryptionConfig.Key, s.EncryptionConfig.KeyCommand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up encryption: %w", err)
+	}
+
+	return datastore.NewDatastore(ctx, s.DatabaseConfig.DSN, s.AutoMigrate == "true", s.VectorDBConfig.DSN, provider, datastore.DatastoreOpts{EmbeddingCache: s.EmbeddingCache, EmbeddingRequestsPerMinute: s.EmbeddingRPM, Encryption: cipher})
 }
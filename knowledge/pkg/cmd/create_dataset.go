@@ -9,7 +9,8 @@ import (
 
 type ClientCreateDataset struct {
 	Client
-	ErrOnExists bool `usage:"Return an error if the dataset already exists"`
+	ErrOnExists      bool   `usage:"Return an error if the dataset already exists"`
+	DistanceFunction string `usage:"Distance function for similarity search (pgvector only: cosine, l2, inner_product) - defaults to the vector store's default" name:"distance-function"`
 }
 
 func (s *ClientCreateDataset) Customize(cmd *cobra.Command) {
@@ -27,7 +28,7 @@ func (s *ClientCreateDataset) Run(cmd *cobra.Command, args []string) error {
 
 	datasetID := args[0]
 
-	ds, err := c.CreateDataset(cmd.Context(), datasetID, &types.DatasetCreateOpts{ErrOnExists: s.ErrOnExists})
+	ds, err := c.CreateDataset(cmd.Context(), datasetID, &types.DatasetCreateOpts{ErrOnExists: s.ErrOnExists, DistanceFunction: s.DistanceFunction})
 	if err != nil {
 		return err
 	}
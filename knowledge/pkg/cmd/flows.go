@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/spf13/cobra"
+)
+
+type Flows struct{}
+
+func (s *Flows) Customize(cmd *cobra.Command) {
+	cmd.Use = "flows"
+	cmd.Short = "Manage ingestion and retrieval flows"
+	cmd.Args = cobra.NoArgs
+}
+
+func (s *Flows) Run(cmd *cobra.Command, _ []string) error {
+	return cmd.Help()
+}
+
+type FlowsListBlueprints struct {
+	JSON bool `usage:"Output as JSON"`
+}
+
+func (s *FlowsListBlueprints) Customize(cmd *cobra.Command) {
+	cmd.Use = "list-blueprints"
+	cmd.Short = "List the embedded flow blueprints and the custom ones from the directories in " + config.BlueprintDirsEnv
+	cmd.Long = `List the flow blueprints, which can be used as flows file via --flows-file blueprint:<name>.
+
+Custom blueprints are YAML or JSON files in the directories listed in ` + config.BlueprintDirsEnv + ` (separated like PATH entries).
+They're named by their directory and their path within it without extension, e.g. with
+` + config.BlueprintDirsEnv + `=/etc/knowledge/myteam, the file /etc/knowledge/myteam/default.yaml is the blueprint myteam/default.`
+	cmd.Args = cobra.NoArgs
+}
+
+func (s *FlowsListBlueprints) Run(cmd *cobra.Command, _ []string) error {
+	blueprints, err := config.ListBlueprints()
+	if err != nil {
+		return fmt.Errorf("failed to list blueprints: %w", err)
+	}
+
+	if s.JSON {
+		jsonOutput, err := json.Marshal(blueprints)
+		if err != nil {
+			return fmt.Errorf("failed to marshal blueprints: %w", err)
+		}
+		fmt.Println(string(jsonOutput))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSOURCE\tDESCRIPTION")
+	for _, bp := range blueprints {
+		fmt.Fprintf(w, "blueprint:%s\t%s\t%s\n", bp.Name, bp.Source, bp.Description)
+	}
+	return w.Flush()
+}
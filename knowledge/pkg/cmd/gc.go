@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/spf13/cobra"
+)
+
+type ClientGC struct {
+	Client
+	DryRun bool `usage:"Only report the orphaned data, don't remove it" name:"dry-run"`
+	JSON   bool `usage:"Output as JSON"`
+}
+
+func (s *ClientGC) Customize(cmd *cobra.Command) {
+	cmd.Use = "gc [<dataset-id>...]"
+	cmd.Short = "Remove orphaned data that's only present in either the vector store or the index"
+	cmd.Long = `Remove orphaned data that's only present in either the vector store or the index, for all or the given datasets:
+  - documents in the vector store without record in the index, e.g. left behind by failed ingestions
+  - files in the index with documents missing from the vector store, so that they're ingested again
+  - vector store collections without dataset in the index (only if no datasets are given)
+Don't run it while ingesting, as the documents of files being ingested are not recorded in the index yet.`
+	cmd.Args = cobra.ArbitraryArgs
+}
+
+func (s *ClientGC) Run(cmd *cobra.Command, args []string) error {
+	c, err := s.getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	report, err := c.GarbageCollect(cmd.Context(), datastore.GCOpts{DryRun: s.DryRun}, args...)
+	if err != nil {
+		return fmt.Errorf("failed to collect garbage: %w", err)
+	}
+
+	if s.JSON {
+		jsonOutput, err := json.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		fmt.Println(string(jsonOutput))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATASET\tORPHANED DOCUMENTS\tINCOMPLETE FILES")
+	for _, ds := range report.Datasets {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", ds.Dataset, len(ds.OrphanedDocuments), len(ds.IncompleteFiles))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, collection := range report.OrphanedCollections {
+		fmt.Printf("orphaned collection: %s\n", collection)
+	}
+	if s.DryRun {
+		fmt.Println("dry run - nothing was removed")
+	}
+	return nil
+}
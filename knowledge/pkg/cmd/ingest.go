@@ -7,12 +7,14 @@ import (
 	"log/slog"
 	"maps"
 	"os"
-	"path"
 	"strings"
 	"time"
 
 	"github.com/acorn-io/z"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/obot-platform/tools/knowledge/pkg/objectstore"
+	"github.com/obot-platform/tools/knowledge/pkg/progress"
 	"github.com/spf13/cobra"
 
 	"github.com/obot-platform/tools/knowledge/pkg/client"
@@ -21,8 +23,10 @@ import (
 
 type ClientIngest struct {
 	Client
-	Dataset string `usage:"Target Dataset ID" short:"d" env:"KNOW_DATASET"`
-	Prune   bool   `usage:"Prune deleted files" env:"KNOW_INGEST_PRUNE"`
+	Dataset  string   `usage:"Target Dataset ID" short:"d" env:"KNOW_DATASET"`
+	Prune    bool     `usage:"Prune deleted files" env:"KNOW_INGEST_PRUNE"`
+	Tags     []string `usage:"Tags to add to the ingested files, e.g. to filter retrieval by them (see tag-file)" name:"tag" env:"KNOW_INGEST_TAGS"`
+	GitPaths []string `usage:"Paths in the git repository to ingest (default: all files), e.g. docs or README.md" name:"git-path" env:"KNOW_INGEST_GIT_PATHS"`
 	ClientIngestOpts
 	ClientFlowsConfig
 }
@@ -36,9 +40,45 @@ type ClientIngestOpts struct {
 	NoCreateDataset       bool              `usage:"Do NOT create the dataset if it doesn't exist" default:"true" env:"KNOW_INGEST_NO_CREATE_DATASET"`
 	DeduplicationFuncName string            `usage:"Name of the deduplication function to use" name:"dedupe-func" env:"KNOW_INGEST_DEDUPE_FUNC"`
 	ErrOnUnsupportedFile  bool              `usage:"Error on unsupported file types" default:"false" env:"KNOW_INGEST_ERR_ON_UNSUPPORTED_FILE"`
+	ErrOnEncryptedFile    bool              `usage:"Error on encrypted (password-protected) files for which no password was provided, instead of skipping them" default:"false" env:"KNOW_INGEST_ERR_ON_ENCRYPTED_FILE"`
+	FilePassword          string            `usage:"Password for encrypted PDF files (can be overridden per file via the 'filePassword' key in .knowledge.json metadata) - encrypted Office and OpenDocument files are not supported" env:"KNOW_INGEST_FILE_PASSWORD"`
 	ExitOnFailedFile      bool              `usage:"Exit directly on failed file" default:"false" env:"KNOW_INGEST_EXIT_ON_FAILED_FILE"`
+	IndexContent          bool              `usage:"Store document contents in the index database to enable keyword search (e.g. via the keyword retriever)" default:"false" env:"KNOW_INGEST_INDEX_CONTENT"`
+	BuildVocabulary       bool              `usage:"Build the dataset vocabulary in the index database (e.g. for the spellcorrect query modifier)" default:"false" env:"KNOW_INGEST_BUILD_VOCABULARY"`
+	NoCheckpoints         bool              `usage:"Don't record the ingestion progress of files, which is used to resume an interrupted ingestion where it stopped" default:"false" env:"KNOW_INGEST_NO_CHECKPOINTS"`
 	Metadata              map[string]string `usage:"Metadata to attach to the ingested files" env:"KNOW_INGEST_METADATA"`
 	MetadataJSON          string            `usage:"Metadata to attach to the loaded files in JSON format" env:"METADATA_JSON"`
+	NoArchives            bool              `usage:"Don't unpack archives (zip, tar, tar.gz, tar.bz2, 7z) but treat them as unsupported files" default:"false" env:"KNOW_INGEST_NO_ARCHIVES"`
+	ArchiveMaxSizeMB      int               `usage:"Maximum total size of the files unpacked from an archive in MB" default:"1024" env:"KNOW_INGEST_ARCHIVE_MAX_SIZE_MB"`
+	ArchiveMaxFiles       int               `usage:"Maximum number of files unpacked from an archive" default:"10000" env:"KNOW_INGEST_ARCHIVE_MAX_FILES"`
+	ArchiveMaxDepth       int               `usage:"Maximum nesting depth of archives in archives" default:"3" env:"KNOW_INGEST_ARCHIVE_MAX_DEPTH"`
+	ProgressFormat        string            `usage:"Emit machine-readable progress events per file (started, chunks produced, embedded, stored, skipped, failed) in this format: json (NDJSON)" env:"KNOW_INGEST_PROGRESS_FORMAT"`
+	ProgressFD            int               `usage:"File descriptor to write the progress events to, e.g. 3 to keep them apart from other output" default:"1" env:"KNOW_INGEST_PROGRESS_FD" name:"progress-fd"`
+}
+
+func (s *ClientIngestOpts) archiveOpts() client.ArchiveOpts {
+	return client.ArchiveOpts{
+		Disable:  s.NoArchives,
+		MaxSize:  int64(s.ArchiveMaxSizeMB) << 20,
+		MaxFiles: s.ArchiveMaxFiles,
+		MaxDepth: s.ArchiveMaxDepth,
+	}
+}
+
+// progressToCtx adds the progress reporter for the configured progress format to the context
+func (s *ClientIngestOpts) progressToCtx(ctx context.Context) (context.Context, error) {
+	switch s.ProgressFormat {
+	case "":
+		return ctx, nil
+	case "json":
+		f := os.NewFile(uintptr(s.ProgressFD), "progress")
+		if f == nil {
+			return nil, fmt.Errorf("invalid progress file descriptor %d", s.ProgressFD)
+		}
+		return progress.ToCtx(ctx, progress.NewJSONReporter(f)), nil
+	default:
+		return nil, fmt.Errorf("unsupported progress format %q (supported: json)", s.ProgressFormat)
+	}
 }
 
 func (s *ClientIngest) Customize(cmd *cobra.Command) {
@@ -46,12 +86,67 @@ func (s *ClientIngest) Customize(cmd *cobra.Command) {
 	cmd.Short = "Ingest a file/directory into a dataset"
 	cmd.Long = `Ingest a file or directory into a dataset.
 
+## Object Stores
+
+Objects in S3, Google Cloud Storage and Azure Blob Storage can be ingested directly by URL, e.g. s3://bucket/prefix, gs://bucket/prefix or az://container/prefix,
+using the standard credential chain of the provider (for Azure, AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT must be set).
+The objects are deduplicated by their ETag (--dedupe-func=etag): unchanged objects are skipped without downloading them.
+
+## Git Repositories
+
+Files in git repositories can be ingested by URL, e.g. git+https://github.com/org/repo or git+https://github.com/org/repo@v1.2.0 for a branch, tag or commit,
+optionally only those under --git-path. The repository is cloned into the cache directory once and fetched incrementally afterwards.
+The repository URL, commit SHA and path of the files are stored in the metadata (gitRepository, gitCommit, gitPath).
+Only files changed since the last ingested commit are ingested again and files removed since then are pruned.
+
+## Google Drive
+
+Files in a Google Drive folder and its subfolders can be ingested by URL, e.g. gdrive://<folder-id> or gdrive://root for "My Drive".
+Set GOOGLE_OAUTH_TOKEN to an OAuth access token with the drive.readonly scope, otherwise the application default credentials are used,
+e.g. a service account key file in GOOGLE_APPLICATION_CREDENTIALS (share the folder with the service account).
+Google Docs are exported to Markdown, Sheets to XLSX, Slides to PPTX and Drawings to PDF; other Google Workspace files are skipped.
+The Drive file ID, link and path are stored in the metadata (driveFileId, driveUrl, drivePath).
+After the first run, only files changed since the last run are ingested again (via the Drive changes feed) and, with --prune, removed files are deleted.
+
+## Notion
+
+Pages shared with the Notion integration can be ingested by URL: notion://workspace for all of them or notion://<database-id> for the pages of a database.
+Set NOTION_TOKEN to the integration token or an OAuth access token. Pages are converted to Markdown, with the properties of database pages
+listed below the title. The page ID, link, title, parent and the titles of its ancestors are stored in the metadata
+(notionPageId, notionUrl, notionTitle, notionParentType, notionParentId, notionPath).
+After the first run, only pages edited since the last run (by their last_edited_time) are ingested again and, with --prune, removed pages are deleted.
+
+## Confluence
+
+Confluence Cloud pages can be ingested by URL: confluence://<site>/<space-key> for the pages of a space, e.g. confluence://acme.atlassian.net/ENG,
+or confluence://<site>/<space-key>/<page-id> for a page and its descendants.
+Set ATLASSIAN_OAUTH_TOKEN to an OAuth access token, or ATLASSIAN_EMAIL and ATLASSIAN_API_TOKEN to the email and API token of an Atlassian account.
+Pages are converted from the storage format to Markdown. The page ID, link, title, space, parent and the titles of its ancestors are stored in the metadata
+(confluencePageId, confluenceUrl, confluenceTitle, confluenceSpace, confluenceParentId, confluencePath).
+After the first run, only pages modified since the last run are ingested again (found by a CQL query) and, with --prune, removed pages are deleted.
+
+## OneDrive and SharePoint
+
+Files in OneDrive and SharePoint document libraries can be ingested by URL: onedrive://me[/<folder-path>] for your OneDrive,
+onedrive://<drive-id>[/<folder-path>] for any drive, or sharepoint://<hostname>/sites/<site>[/<library>[/<folder-path>]] for a SharePoint document library,
+e.g. sharepoint://acme.sharepoint.com/sites/eng/Shared%20Documents/Specs. The site's default library is used if none is given.
+Set GPTSCRIPT_GRAPH_MICROSOFT_COM_BEARER_TOKEN to a Microsoft Graph access token, as for the Outlook and Word tools, or configure an Azure credential (e.g. AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET).
+The item ID, drive ID, link and path in the folder are stored in the metadata (oneDriveItemId, oneDriveDriveId, oneDriveUrl, oneDrivePath).
+After the first run, only changes are ingested (using delta queries) and, with --prune, deleted files and files moved out of the folder are deleted.
+
 ## Important Note
 
 The first time you ingest something into a dataset, the embedding function (model provider) you chose will be attached to that dataset.
 After that, the client must always use that same embedding function to ingest into this dataset.
 Usually, this only concerns the choice of the model, as that commonly defines the embedding dimensionality.
 This is a constraint of the Vector Database and Similarity Search, as different models yield differently sized embedding vectors and also represent the semantics differently.
+
+## Resuming an Interrupted Ingestion
+
+The ingestion progress of each file (pending, loaded, embedded, indexed) is recorded in the index database.
+If the ingestion is interrupted, ingesting the same path again resumes where it stopped: files that were already indexed are skipped
+and files that were already loaded or embedded continue from there, as long as their content didn't change.
+Once all files were ingested, the progress records are removed. Use --no-checkpoints to disable this.
 `
 	cmd.Args = cobra.ExactArgs(1)
 }
@@ -77,12 +172,15 @@ func (s *ClientIngest) run(ctx context.Context, filePath string) error {
 		exitErr0(fmt.Errorf("no dataset specified for ingestion"))
 	}
 
-	if !strings.HasPrefix(filePath, "ws://") {
+	isObjectStore := objectstore.IsURL(filePath)
+	isGit := client.IsGitURL(filePath)
+	isConnector := connectors.IsURL(filePath)
+	if !strings.HasPrefix(filePath, "ws://") && !isObjectStore && !isGit && !isConnector {
 		finfo, err := os.Stat(filePath)
 		if err != nil {
 			return err
 		}
-		if !finfo.IsDir() && path.Ext(filePath) != ".zip" {
+		if !finfo.IsDir() && (s.NoArchives || !client.IsArchive(filePath)) {
 			slog.Debug("ingesting single file, setting err-on-unsupported-file to true", "file", filePath)
 			s.ErrOnUnsupportedFile = true
 		}
@@ -90,10 +188,47 @@ func (s *ClientIngest) run(ctx context.Context, filePath string) error {
 		s.ErrOnUnsupportedFile = true
 	}
 
+	ingestOpts, err := s.ingestPathsOpts(datasetID, s.ClientFlowsConfig)
+	if err != nil {
+		return err
+	}
+	ingestOpts.Prune = s.Prune
+	ingestOpts.Tags = s.Tags
+
+	ctx, err = s.progressToCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = log.ToCtx(ctx, slog.With("flow", "ingestion").With("rootPath", filePath))
+	startTime := time.Now()
+
+	var filesIngested, skipped int
+	switch {
+	case isObjectStore:
+		filesIngested, skipped, err = client.IngestObjects(ctx, c, datasetID, filePath, ingestOpts)
+	case isGit:
+		filesIngested, skipped, err = client.IngestGitRepo(ctx, c, datasetID, filePath, s.GitPaths, ingestOpts)
+	case isConnector:
+		filesIngested, skipped, err = client.IngestConnector(ctx, c, datasetID, filePath, ingestOpts)
+	default:
+		filesIngested, skipped, err = c.IngestPaths(ctx, datasetID, ingestOpts, filePath)
+	}
+	if err != nil {
+		slog.Error("Failed to ingest files", "error", err, "succeeded", filesIngested, "skipped", skipped)
+		return fmt.Errorf("ingestion failed for at least one file: %w", err)
+	}
+
+	slog.Info("Ingested files into dataset", "ingested", filesIngested, "source", filePath, "dataset", datasetID, "skipped", skipped, "took", time.Since(startTime))
+	return nil
+}
+
+// ingestPathsOpts builds the options for ingesting paths into the dataset, including the ingestion flows from the flows config
+func (s *ClientIngestOpts) ingestPathsOpts(datasetID string, flowsConfig ClientFlowsConfig) (*client.IngestPathsOpts, error) {
 	metadata := map[string]string{}
 	if s.MetadataJSON != "" {
 		if err := json.Unmarshal([]byte(s.MetadataJSON), &metadata); err != nil {
-			return fmt.Errorf("failed to unmarshal metadata JSON: %w", err)
+			return nil, fmt.Errorf("failed to unmarshal metadata JSON: %w", err)
 		}
 	}
 	maps.Copy(metadata, s.Metadata)
@@ -104,58 +239,53 @@ func (s *ClientIngest) run(ctx context.Context, filePath string) error {
 			Metadata:            metadata,
 			ReuseEmbeddings:     true,
 			ReuseFiles:          true,
+			FilePassword:        s.FilePassword,
+			IndexContent:        s.IndexContent,
+			BuildVocabulary:     s.BuildVocabulary,
+			Checkpoints:         !s.NoCheckpoints,
 		},
 		IgnoreExtensions:     strings.Split(s.IgnoreExtensions, ","),
 		Concurrency:          s.Concurrency,
 		Recursive:            !s.NoRecursive,
 		IgnoreFile:           s.IgnoreFile,
 		IncludeHidden:        s.IncludeHidden,
-		Prune:                s.Prune,
 		ErrOnUnsupportedFile: s.ErrOnUnsupportedFile,
+		ErrOnEncryptedFile:   s.ErrOnEncryptedFile,
 		ExitOnFailedFile:     s.ExitOnFailedFile,
+		Archive:              s.archiveOpts(),
 	}
 
-	if s.FlowsFile != "" {
-		slog.Debug("Loading ingestion flows from config", "flows_file", s.FlowsFile, "dataset", datasetID)
+	if flowsConfig.FlowsFile != "" {
+		slog.Debug("Loading ingestion flows from config", "flows_file", flowsConfig.FlowsFile, "dataset", datasetID)
 
-		flowCfg, err := flowconfig.Load(s.FlowsFile)
+		flowCfg, err := flowconfig.Load(flowsConfig.FlowsFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		var flow *flowconfig.FlowConfigEntry
-		if s.Flow != "" {
-			flow, err = flowCfg.GetFlow(s.Flow)
+		if flowsConfig.Flow != "" {
+			flow, err = flowCfg.GetFlow(flowsConfig.Flow)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		} else {
 			flow, err = flowCfg.ForDataset(datasetID) // get flow for the dataset
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
 		for _, ingestionFlowConfig := range flow.Ingestion {
 			ingestionFlow, err := ingestionFlowConfig.AsIngestionFlow(&flow.Globals.Ingestion)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			ingestOpts.IngestionFlows = append(ingestOpts.IngestionFlows, z.Dereference(ingestionFlow))
 		}
 
-		slog.Debug("Loaded ingestion flows from config", "flows_file", s.FlowsFile, "dataset", datasetID, "flows", len(ingestOpts.IngestionFlows))
-	}
-
-	ctx = log.ToCtx(ctx, slog.With("flow", "ingestion").With("rootPath", filePath))
-	startTime := time.Now()
-
-	filesIngested, skippedUnsupported, err := c.IngestPaths(ctx, datasetID, ingestOpts, filePath)
-	if err != nil {
-		slog.Error("Failed to ingest files", "error", err, "succeeded", filesIngested, "skippedUnsupported", skippedUnsupported)
-		return fmt.Errorf("ingestion failed for at least one file: %w", err)
+		slog.Debug("Loaded ingestion flows from config", "flows_file", flowsConfig.FlowsFile, "dataset", datasetID, "flows", len(ingestOpts.IngestionFlows))
 	}
 
-	slog.Info("Ingested files into dataset", "ingested", filesIngested, "source", filePath, "dataset", datasetID, "skippedUnsupported", skippedUnsupported, "took", time.Since(startTime))
-	return nil
+	return ingestOpts, nil
 }
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/client"
+	"github.com/obot-platform/tools/knowledge/pkg/crawler"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+type ClientIngestURL struct {
+	Client
+	Dataset        string   `usage:"Target Dataset ID" short:"d" env:"KNOW_DATASET"`
+	Tags           []string `usage:"Tags to add to the ingested pages, e.g. to filter retrieval by them (see tag-file)" name:"tag" env:"KNOW_INGEST_TAGS"`
+	MaxDepth       int      `usage:"Maximum number of links to follow from the start URL (0 = only the start page)" default:"2" env:"KNOW_INGEST_URL_MAX_DEPTH"`
+	MaxPages       int      `usage:"Maximum number of pages to crawl (0 = unlimited)" default:"500" env:"KNOW_INGEST_URL_MAX_PAGES"`
+	Domains        []string `usage:"Domains to follow links to, e.g. docs.example.com or *.example.com (default: the domain of the start URL)" name:"domain" env:"KNOW_INGEST_URL_DOMAINS"`
+	PathPrefixes   []string `usage:"Only follow links to URLs with one of these path prefixes, e.g. /docs/" name:"path-prefix" env:"KNOW_INGEST_URL_PATH_PREFIXES"`
+	IgnoreRobots   bool     `usage:"Ignore robots.txt and robots meta tags" default:"false" env:"KNOW_INGEST_URL_IGNORE_ROBOTS"`
+	UserAgent      string   `usage:"User agent sent with the requests and matched against robots.txt" env:"KNOW_INGEST_URL_USER_AGENT"`
+	DelayMs        int      `usage:"Minimum delay in milliseconds between requests to the same host (a longer robots.txt crawl-delay takes precedence)" default:"250" env:"KNOW_INGEST_URL_DELAY_MS" name:"delay-ms"`
+	NoCleanHTML    bool     `usage:"Ingest HTML pages as they are, without removing scripts, navigation, footers and other boilerplate" name:"no-clean-html" default:"false" env:"KNOW_INGEST_URL_NO_CLEAN_HTML"`
+	RemoveSelector []string `usage:"CSS selectors of additional elements to remove from HTML pages, e.g. site-specific banners" name:"remove-selector" env:"KNOW_INGEST_URL_REMOVE_SELECTORS"`
+	Include        []string `usage:"Only ingest URLs matching one of these regular expressions" name:"include" env:"KNOW_INGEST_URL_INCLUDE"`
+	Exclude        []string `usage:"Don't ingest URLs matching one of these regular expressions" name:"exclude" env:"KNOW_INGEST_URL_EXCLUDE"`
+	Sitemap        bool     `usage:"Ingest the pages listed in the sitemap at the URL instead of crawling (default: true for URLs like .../sitemap.xml)" default:"false" env:"KNOW_INGEST_URL_SITEMAP"`
+	Prune          bool     `usage:"With a sitemap, remove pages of the site from the dataset that are no longer listed in it" default:"false" env:"KNOW_INGEST_URL_PRUNE"`
+	ClientIngestOpts
+	ClientFlowsConfig
+}
+
+func (s *ClientIngestURL) Customize(cmd *cobra.Command) {
+	cmd.Use = "ingest-url [--dataset <dataset-id>] <url>"
+	cmd.Short = "Crawl a website from a URL and ingest its pages into a dataset"
+	cmd.Long = `Crawl a website from a URL and ingest its pages into a dataset, e.g. a product documentation site.
+
+Links are followed breadth-first up to --max-depth, only to the domain of the start URL (or the --domain list) and, if given, to URLs under the --path-prefix list.
+The robots.txt of each host and robots meta tags are respected, unless --ignore-robots is set.
+Before ingestion, scripts, navigation, footers and similar boilerplate are removed from HTML pages. Linked documents of other supported types, e.g. PDFs, are ingested as well.
+
+The pages are ingested with their URL as path and their source URL ("url") and crawl time ("crawledAt") in the metadata,
+so crawling the site again updates pages that changed (by their Last-Modified header, if sent).
+
+With a sitemap URL (e.g. https://docs.example.com/sitemap.xml, or any URL with --sitemap), the pages listed in the sitemap and its nested sitemaps
+are ingested instead of crawling. Pages whose <lastmod> is not newer than the already ingested page are skipped without fetching them,
+so large sites can be kept in sync efficiently, and --prune removes pages of the site that are no longer listed.
+--include and --exclude patterns filter the ingested URLs in both modes.`
+	cmd.Args = cobra.ExactArgs(1)
+}
+
+func (s *ClientIngestURL) Run(cmd *cobra.Command, args []string) error {
+	err := s.run(cmd.Context(), args[0])
+	if err != nil {
+		exitErr0(err, "cmd=ingest-url")
+	}
+	return nil
+}
+
+func (s *ClientIngestURL) run(ctx context.Context, startURL string) error {
+	datasetID := s.Dataset
+	if datasetID == "" {
+		return fmt.Errorf("no dataset specified for ingestion")
+	}
+
+	c, err := s.getClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ingestPathsOpts, err := s.ingestPathsOpts(datasetID, s.ClientFlowsConfig)
+	if err != nil {
+		return err
+	}
+	include, err := compilePatterns(s.Include)
+	if err != nil {
+		return fmt.Errorf("invalid include pattern: %w", err)
+	}
+	exclude, err := compilePatterns(s.Exclude)
+	if err != nil {
+		return fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	ingestOpts := &client.IngestURLOpts{
+		SharedIngestionOpts: ingestPathsOpts.SharedIngestionOpts,
+		Crawl: crawler.Options{
+			MaxDepth:       s.MaxDepth,
+			MaxPages:       s.MaxPages,
+			AllowedDomains: s.Domains,
+			PathPrefixes:   s.PathPrefixes,
+			Include:        include,
+			Exclude:        exclude,
+			IgnoreRobots:   s.IgnoreRobots,
+			UserAgent:      s.UserAgent,
+			Delay:          time.Duration(s.DelayMs) * time.Millisecond,
+		},
+		NoCleanHTML:          s.NoCleanHTML,
+		RemoveSelectors:      s.RemoveSelector,
+		ErrOnUnsupportedFile: s.ErrOnUnsupportedFile,
+	}
+	ingestOpts.Tags = s.Tags
+
+	ctx, err = s.progressToCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = log.ToCtx(ctx, slog.With("flow", "ingestion").With("startURL", startURL))
+	startTime := time.Now()
+
+	var ingested, skipped int
+	if s.Sitemap || client.IsSitemap(startURL) {
+		ingested, skipped, err = client.IngestSitemap(ctx, c, datasetID, startURL, &client.IngestSitemapOpts{IngestURLOpts: *ingestOpts, Prune: s.Prune})
+	} else {
+		if s.Prune {
+			slog.Warn("--prune is only supported with sitemaps, ignoring it")
+		}
+		ingested, skipped, err = client.IngestURL(ctx, c, datasetID, startURL, ingestOpts)
+	}
+	if err != nil {
+		slog.Error("Failed to ingest pages", "error", err, "succeeded", ingested, "skipped", skipped)
+		return fmt.Errorf("ingestion failed for at least one page: %w", err)
+	}
+
+	slog.Info("Ingested pages into dataset", "ingested", ingested, "source", startURL, "dataset", datasetID, "skipped", skipped, "took", time.Since(startTime))
+	return nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
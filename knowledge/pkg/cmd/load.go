@@ -130,7 +130,7 @@ func (s *ClientLoad) run(ctx context.Context, input, output string) error {
 				if err != nil {
 					return err
 				}
-				if flow.SupportsFiletype(filetype) {
+				if flow.SupportsFiletype(filetype) && len(flow.Languages) == 0 {
 					ingestionFlow = flow
 					break
 				}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/spf13/cobra"
+)
+
+type ClientReEmbed struct {
+	Client
+	ClientFlowsConfig
+}
+
+func (s *ClientReEmbed) Customize(cmd *cobra.Command) {
+	cmd.Use = "re-embed <dataset-id>"
+	cmd.Short = "Re-embed all documents of a dataset with the configured embedding model provider"
+	cmd.Long = `Re-embed all documents of a dataset with the configured embedding model provider, e.g. to migrate the dataset to another embedding model
+without re-ingesting the source files. The documents are read back from the vector store and the new embeddings replace the old ones
+once all documents were embedded (atomically for sqlite-vec and pgvector), then the dataset's embeddings config is updated.
+The embedding input preprocessing of the flow's global ingestion options is applied, like during ingestion.`
+	cmd.Args = cobra.ExactArgs(1)
+}
+
+func (s *ClientReEmbed) Run(cmd *cobra.Command, args []string) error {
+	datasetID := args[0]
+
+	opts := datastore.ReEmbedOpts{}
+	if s.FlowsFile != "" {
+		flowCfg, err := flowconfig.Load(s.FlowsFile)
+		if err != nil {
+			return err
+		}
+
+		var flow *flowconfig.FlowConfigEntry
+		if s.Flow != "" {
+			flow, err = flowCfg.GetFlow(s.Flow)
+		} else {
+			flow, err = flowCfg.ForDataset(datasetID)
+		}
+		if err != nil {
+			return err
+		}
+		opts.EmbeddingPreprocessing = flow.Globals.Ingestion.EmbeddingPreprocessing
+	}
+
+	c, err := s.getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	n, err := c.ReEmbedDataset(cmd.Context(), datasetID, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Re-embedded %d documents of dataset %q\n", n, datasetID)
+	return nil
+}
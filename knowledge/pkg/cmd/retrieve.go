@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 
 	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
 	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/obot-platform/tools/knowledge/pkg/output"
 	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/spf13/cobra"
 )
 
@@ -17,13 +22,51 @@ type ClientRetrieve struct {
 	Client
 	Datasets []string `usage:"Target Dataset IDs" short:"d" env:"KNOW_DATASETS" name:"dataset"`
 	Archive  string   `usage:"Path to the archive file"`
+	Format   string   `usage:"Output format: json (full response), markdown (chunks with their sources and a numbered citation list, e.g. as context block of a prompt) or xml" default:"json" env:"KNOW_RETRIEVE_FORMAT"`
+	Tags     []string `usage:"Tags the files of the retrieved sources must all have (see tag-file)" name:"tag" env:"KNOW_RETRIEVE_TAGS"`
 	ClientRetrieveOpts
 	ClientFlowsConfig
 }
 
 type ClientRetrieveOpts struct {
 	TopK     int      `usage:"Number of sources to retrieve" short:"k" default:"10"`
+	Offset   int      `usage:"Number of most similar sources to skip, to page through the results beyond the top k (e.g. --offset 10 with -k 10 for the second page)" env:"KNOW_RETRIEVE_OFFSET"`
 	Keywords []string `usage:"Keywords that retrieved documents must contain" short:"w" name:"keyword" env:"KNOW_RETRIEVE_KEYWORDS"`
+	History  string   `usage:"Prior conversation turns for history-aware query modifiers, as JSON array of strings or role/content objects" env:"KNOW_RETRIEVE_HISTORY"`
+	Filters  []string `usage:"Metadata filters as key=value, e.g. tags=kubernetes from markdown front matter - repeated keys match any of the values" name:"filter" env:"KNOW_RETRIEVE_FILTERS"`
+	Where    string   `usage:"Metadata filter with operators as JSON, e.g. '{\"year\": {\"$gte\": 2020}, \"$or\": [{\"tags\": \"k8s\"}, {\"draft\": {\"$exists\": false}}]}' - supports $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $contains, $exists, $and and $or" env:"KNOW_RETRIEVE_WHERE"`
+}
+
+// parseWhere parses the metadata filter with operators, so that syntax errors are reported before retrieving anything
+func parseWhere(where string) (map[string]any, error) {
+	if strings.TrimSpace(where) == "" {
+		return nil, nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(where), &m); err != nil {
+		return nil, fmt.Errorf("invalid --where filter, expected a JSON object: %w", err)
+	}
+	if _, err := vs.MetadataFilterFromMap(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseFilters parses the key=value metadata filters
+func parseFilters(filters []string) (map[string][]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[string][]string, len(filters))
+	for _, f := range filters {
+		k, v, ok := strings.Cut(f, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid filter %q, expected key=value", f)
+		}
+		parsed[k] = append(parsed[k], strings.TrimSpace(v))
+	}
+	return parsed, nil
 }
 
 func (s *ClientRetrieve) Customize(cmd *cobra.Command) {
@@ -45,6 +88,11 @@ func (s *ClientRetrieve) Run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	format, err := output.ParseFormat(s.Format)
+	if err != nil {
+		return err
+	}
+
 	datasetIDs := s.Datasets
 	if len(datasetIDs) == 0 {
 		exitErr0(fmt.Errorf("no dataset specified for retrieval - probably there was nothing ingested yet"))
@@ -60,6 +108,24 @@ func (s *ClientRetrieve) Run(cmd *cobra.Command, args []string) error {
 	retrieveOpts := datastore.RetrieveOpts{
 		TopK:     s.TopK,
 		Keywords: s.Keywords,
+		Offset:   s.Offset,
+		Tags:     s.Tags,
+	}
+
+	history, err := querymodifiers.ParseConversationHistory(s.History)
+	if err != nil {
+		return err
+	}
+	retrieveOpts.History = history
+
+	retrieveOpts.Filters, err = parseFilters(s.Filters)
+	if err != nil {
+		return err
+	}
+
+	retrieveOpts.Where, err = parseWhere(s.Where)
+	if err != nil {
+		return err
 	}
 
 	if s.FlowsFile != "" {
@@ -110,14 +176,14 @@ func (s *ClientRetrieve) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	jsonSources, err := json.Marshal(retrievalResp)
-	if err != nil {
-		return err
-	}
-
 	slog.Info("Retrieved sources", "num_sources", len(retrievalResp.Responses), "query", query, "datasets", datasetIDs)
 
-	fmt.Println(string(jsonSources))
+	if format != output.FormatJSON && len(retrievalResp.Citations) == 0 {
+		// number the sources for the citation list, unless the retrieval flow did already
+		if err := (&postprocessors.CitationsPostprocessor{GroupBySource: true}).Transform(cmd.Context(), retrievalResp); err != nil {
+			return err
+		}
+	}
 
-	return nil
+	return output.WriteRetrievalResponse(os.Stdout, retrievalResp, format)
 }
@@ -23,15 +23,26 @@ func New() *cobra.Command {
 		new(ClientGetDataset),
 		new(ClientListDatasets),
 		new(ClientIngest),
+		new(ClientSync),
+		new(ClientIngestURL),
 		new(ClientDeleteDataset),
 		new(ClientDeleteFile),
 		new(ClientGetFile),
+		new(ClientTagFile),
+		new(ClientUntagFile),
 		new(ClientRetrieve),
 		new(ClientAskDir),
 		new(ClientExportDatasets),
 		new(ClientImportDatasets),
 		new(ClientEditDataset),
 		new(ClientLoad),
+		new(ClientBenchEmbeddings),
+		new(ClientRebuildIndexes),
+		new(ClientReEmbed),
+		new(Server),
+		new(ClientStats),
+		new(ClientGC),
+		cmd.Command(&Flows{}, new(FlowsListBlueprints)),
 		new(Version),
 	)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os/signal"
+	"syscall"
+
+	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/obot-platform/tools/knowledge/pkg/server"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+type Server struct {
+	Client
+	ClientFlowsConfig
+
+	ServerAddress        string `usage:"Address to listen on - listening on other than loopback addresses requires a token or --insecure" env:"KNOW_SERVER_ADDRESS" default:"127.0.0.1:8000" name:"address"`
+	ServerGRPCAddress    string `usage:"Address to serve the gRPC API on, e.g. 127.0.0.1:8001 (empty = disabled)" env:"KNOW_SERVER_GRPC_ADDRESS" name:"grpc-address"`
+	ServerMetricsAddress string `usage:"Address to serve Prometheus metrics on (under /metrics), e.g. :9090 (empty = disabled)" env:"KNOW_SERVER_METRICS_ADDRESS" name:"metrics-address"`
+	ServerAPIBase        string `usage:"Path prefix of the API routes" env:"KNOW_SERVER_API_BASE" default:"/v1" name:"api-base"`
+	MaxRequestSizeMB     int    `usage:"Maximum size of a request body in MB, e.g. of a (base64 encoded) file to ingest (0 = unlimited)" env:"KNOW_SERVER_MAX_REQUEST_SIZE_MB" default:"100" name:"max-request-size-mb"`
+	ServerToken          string `usage:"Bearer token required by all REST and gRPC requests, except for the health check (empty = no authentication)" env:"KNOW_SERVER_TOKEN" name:"token"`
+	ServerInsecure       bool   `usage:"Allow serving the API without a token on non-loopback addresses, where anyone on the network can modify the datasets" env:"KNOW_SERVER_INSECURE" name:"insecure"`
+}
+
+func (s *Server) Customize(cmd *cobra.Command) {
+	cmd.Use = "server"
+	cmd.Short = "Run the knowledge REST (and gRPC) server"
+	cmd.Long = `Serve datasets, ingestion and retrieval via a JSON REST API, sharing one datastore between all clients:
+  GET    /v1/datasets
+  POST   /v1/datasets                               {"id": "foobar"}
+  GET    /v1/datasets/{id}[?files=true]
+  DELETE /v1/datasets/{id}
+  POST   /v1/datasets/{id}/ingest                   {"filename": "README.md", "content": "<base64>", "metadata": {...}}
+  DELETE /v1/datasets/{id}/files/{fileID}
+  DELETE /v1/datasets/{id}/documents/{documentID}
+  POST   /v1/datasets/{id}/retrieve                 {"query": "...", "topK": 10}
+  POST   /v1/retrieve                               {"datasets": ["foo", "bar"], "query": "..."}
+  GET    /v1/healthz
+The server listens on localhost only, unless a token (--token, sent as "Authorization: Bearer <token>") is set or --insecure is passed.
+Ingestion and retrieval use the flows assigned to the dataset in the flows file, unless a flow is specified in the request.
+With --grpc-address, the same operations are served via gRPC, with files streamed in chunks (see pkg/server/knowledgepb/knowledge.proto).
+With --metrics-address, ingestion, embedding, retrieval and vector store metrics are exposed for Prometheus under /metrics.`
+	cmd.Args = cobra.NoArgs
+}
+
+func (s *Server) Run(cmd *cobra.Command, _ []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg := server.Config{
+		Address:         s.ServerAddress,
+		GRPCAddress:     s.ServerGRPCAddress,
+		MetricsAddress:  s.ServerMetricsAddress,
+		APIBase:         s.ServerAPIBase,
+		MaxRequestBytes: int64(s.MaxRequestSizeMB) << 20,
+		Token:           s.ServerToken,
+		Insecure:        s.ServerInsecure,
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	var flowCfg *flowconfig.FlowConfig
+	if s.FlowsFile != "" {
+		var err error
+		flowCfg, err = flowconfig.Load(s.FlowsFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	ds, err := s.getDatastore(ctx)
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+
+	srv := server.NewServer(ds, flowCfg, cfg)
+
+	// If one of the servers fails, the other one is shut down as well
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error { return srv.Start(ctx) })
+	if s.ServerGRPCAddress != "" {
+		g.Go(func() error { return srv.StartGRPC(ctx) })
+	}
+	if s.ServerMetricsAddress != "" {
+		g.Go(func() error { return srv.StartMetrics(ctx) })
+	}
+	return g.Wait()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+type ClientStats struct {
+	Client
+	JSON bool `usage:"Output as JSON"`
+}
+
+func (s *ClientStats) Customize(cmd *cobra.Command) {
+	cmd.Use = "stats [<dataset-id>...]"
+	cmd.Short = "Show vector store statistics (document count, size, embedding dimensions) for all or the given datasets"
+	cmd.Args = cobra.ArbitraryArgs
+}
+
+func (s *ClientStats) Run(cmd *cobra.Command, args []string) error {
+	c, err := s.getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	stats, err := c.Stats(cmd.Context(), args...)
+	if err != nil {
+		return fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	if s.JSON {
+		jsonOutput, err := json.Marshal(stats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+		fmt.Println(string(jsonOutput))
+		return nil
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("no datasets found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATASET\tDOCUMENTS\tSIZE\tDIMENSIONS")
+	for _, st := range stats {
+		dims := make([]string, 0, len(st.Dimensions))
+		for _, d := range st.Dimensions {
+			dims = append(dims, fmt.Sprint(d))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", st.Name, st.Documents, humanBytes(st.SizeBytes), strings.Join(dims, ","))
+	}
+	return w.Flush()
+}
+
+func humanBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
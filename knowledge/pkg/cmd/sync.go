@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/client"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+type ClientSync struct {
+	Client
+	Dataset    string `usage:"Target Dataset ID" short:"d" env:"KNOW_DATASET"`
+	Watch      bool   `usage:"Keep watching the directory and sync changes continuously" short:"w" env:"KNOW_SYNC_WATCH"`
+	DebounceMs int    `usage:"Time in milliseconds without further changes before the changes are synced (with --watch)" default:"1000" env:"KNOW_SYNC_DEBOUNCE_MS" name:"debounce-ms"`
+	ClientIngestOpts
+	ClientFlowsConfig
+}
+
+func (s *ClientSync) Customize(cmd *cobra.Command) {
+	cmd.Use = "sync [--dataset <dataset-id>] [--watch] <dir>"
+	cmd.Short = "Sync a directory into a dataset, optionally watching it for changes"
+	cmd.Long = `Sync a directory into a dataset: new and changed files are ingested and files that were deleted from the directory are removed from the dataset.
+
+With --watch, the directory keeps being watched after the initial sync and changes are synced continuously until the command is interrupted,
+so the dataset tracks the directory without re-ingesting it periodically.
+Rapid changes (e.g. an editor saving a file multiple times) are debounced: changes are synced once nothing changed for --debounce-ms.
+Changing a metadata (.knowledge.json) or ignore file (.knowignore) syncs the whole directory again.`
+	cmd.Args = cobra.ExactArgs(1)
+}
+
+func (s *ClientSync) Run(cmd *cobra.Command, args []string) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := s.run(ctx, args[0])
+	if err != nil {
+		exitErr0(err, "cmd=sync")
+	}
+	return nil
+}
+
+func (s *ClientSync) run(ctx context.Context, dirPath string) error {
+	datasetID := s.Dataset
+	if datasetID == "" {
+		return fmt.Errorf("no dataset specified for sync")
+	}
+
+	finfo, err := os.Stat(dirPath)
+	if err != nil {
+		return err
+	}
+	if !finfo.IsDir() {
+		return fmt.Errorf("path %q is not a directory", dirPath)
+	}
+
+	c, err := s.getClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ingestOpts, err := s.ingestPathsOpts(datasetID, s.ClientFlowsConfig)
+	if err != nil {
+		return err
+	}
+	ingestOpts.Prune = true
+
+	ctx, err = s.progressToCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx = log.ToCtx(ctx, slog.With("flow", "ingestion").With("rootPath", dirPath))
+	startTime := time.Now()
+
+	filesIngested, skipped, err := c.IngestPaths(ctx, datasetID, ingestOpts, dirPath)
+	if err != nil {
+		slog.Error("Failed to ingest files", "error", err, "succeeded", filesIngested, "skipped", skipped)
+		return fmt.Errorf("ingestion failed for at least one file: %w", err)
+	}
+	slog.Info("Synced directory into dataset", "ingested", filesIngested, "source", dirPath, "dataset", datasetID, "skipped", skipped, "took", time.Since(startTime))
+
+	if !s.Watch {
+		return nil
+	}
+
+	ingestOpts.NoCreateDataset = true // created by the initial sync
+	return client.WatchPath(ctx, c, datasetID, dirPath, ingestOpts, time.Duration(s.DebounceMs)*time.Millisecond)
+}
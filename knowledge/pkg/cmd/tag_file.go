@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/spf13/cobra"
+)
+
+type ClientTagFile struct {
+	Client
+	Dataset string `usage:"Target Dataset ID" short:"d"`
+}
+
+func (s *ClientTagFile) Customize(cmd *cobra.Command) {
+	cmd.Use = "tag-file <file-id|file-abs-path> <tag>..."
+	cmd.Short = "Add tags to a file in a dataset, e.g. to filter retrieval by them (retrieve --tag)"
+	cmd.Long = `Add tags to a file in a dataset. The tags are stored with the file in the index and in the metadata of its documents (key "fileTags"),
+so that retrieval can be limited to files with certain tags, e.g. knowledge retrieve -d foobar --tag runbook "How to restart the database?".
+Tags are kept when the file is re-ingested.`
+	cmd.Args = cobra.MinimumNArgs(2)
+}
+
+func (s *ClientTagFile) Run(cmd *cobra.Command, args []string) error {
+	return runFileTagging(cmd, &s.Client, s.Dataset, args, true)
+}
+
+type ClientUntagFile struct {
+	Client
+	Dataset string `usage:"Target Dataset ID" short:"d"`
+}
+
+func (s *ClientUntagFile) Customize(cmd *cobra.Command) {
+	cmd.Use = "untag-file <file-id|file-abs-path> <tag>..."
+	cmd.Short = "Remove tags from a file in a dataset"
+	cmd.Args = cobra.MinimumNArgs(2)
+}
+
+func (s *ClientUntagFile) Run(cmd *cobra.Command, args []string) error {
+	return runFileTagging(cmd, &s.Client, s.Dataset, args, false)
+}
+
+func runFileTagging(cmd *cobra.Command, clientCfg *Client, datasetID string, args []string, add bool) error {
+	if datasetID == "" {
+		exitErr0(fmt.Errorf("no dataset specified"))
+	}
+
+	searchFile, err := searchFileRef(datasetID, args[0])
+	if err != nil {
+		return err
+	}
+
+	c, err := clientCfg.getClient(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	file, err := c.FindFile(cmd.Context(), searchFile)
+	if err != nil {
+		return fmt.Errorf("failed to find file %s: %w", args[0], err)
+	}
+
+	if add {
+		file, err = c.TagFile(cmd.Context(), datasetID, file.ID, args[1:]...)
+	} else {
+		file, err = c.UntagFile(cmd.Context(), datasetID, file.ID, args[1:]...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tags of file %s: %w", args[0], err)
+	}
+
+	jsonOutput, err := json.Marshal(map[string]any{"id": file.ID, "absolute_path": file.AbsolutePath, "tags": file.Tags})
+	if err != nil {
+		return fmt.Errorf("failed to marshal file: %w", err)
+	}
+	fmt.Println(string(jsonOutput))
+	return nil
+}
+
+// searchFileRef returns the search for the file referenced by its ID or (absolute or relative) path
+func searchFileRef(datasetID, fileRef string) (types.File, error) {
+	searchFile := types.File{
+		Dataset: datasetID,
+	}
+
+	if strings.HasPrefix(fileRef, "/") || strings.HasPrefix(fileRef, "ws://") {
+		searchFile.AbsolutePath = fileRef
+	} else if _, err := uuid.Parse(fileRef); err == nil {
+		searchFile.ID = fileRef
+	} else {
+		finfo, err := os.Stat(fileRef)
+		if err != nil {
+			return searchFile, fmt.Errorf("fileref is not a valid filepath or UUID - failed to stat relative path: %w", err)
+		}
+		if finfo.IsDir() {
+			return searchFile, fmt.Errorf("fileref is a directory, not a file")
+		}
+		searchFile.AbsolutePath, err = filepath.Abs(fileRef)
+		if err != nil {
+			return searchFile, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+	}
+	return searchFile, nil
+}
@@ -26,7 +26,7 @@ type ModelProviderConfig struct {
 }
 
 type DatabaseConfig struct {
-	DSN         string `name:"index-dsn" usage:"Index Database Connection string (relational DB) (default \"sqlite://$XDG_DATA_HOME/gptscript/knowledge/knowledge.db\")" default:"" env:"KNOW_INDEX_DSN"`
+	DSN         string `name:"index-dsn" usage:"Index Database Connection string (relational DB: sqlite://, postgres:// or mysql://) (default \"sqlite://$XDG_DATA_HOME/gptscript/knowledge/knowledge.db\")" default:"" env:"KNOW_INDEX_DSN"`
 	AutoMigrate string `usage:"Auto migrate database" default:"true" env:"KNOW_DB_AUTO_MIGRATE"`
 }
 
@@ -34,6 +34,11 @@ type VectorDBConfig struct {
 	DSN string `name:"vector-dsn" usage:"DSN to the vector database (default \"sqlite-vec://$XDG_DATA_HOME/gptscript/knowledge/vector.db\")" default:"" env:"KNOW_VECTOR_DSN"`
 }
 
+type EncryptionConfig struct {
+	Key        string `name:"encryption-key" usage:"Base64 encoded AES key (16, 24 or 32 bytes) to encrypt document contents and metadata at rest with AES-GCM" default:"" env:"KNOW_ENCRYPTION_KEY"`
+	KeyCommand string `name:"encryption-key-command" usage:"Command printing the base64 encoded encryption key, e.g. a KMS plugin decrypting a data key (instead of --encryption-key)" default:"" env:"KNOW_ENCRYPTION_KEY_COMMAND"`
+}
+
 func LoadConfig(configFile string) (*Config, error) {
 	cfg := &Config{}
 	if configFile == "" {
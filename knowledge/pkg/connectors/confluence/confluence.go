@@ -0,0 +1,293 @@
+package confluence
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+)
+
+const (
+	Scheme = "confluence"
+
+	// Credentials, as for the Jira tool: an OAuth access token, or the email and API token of an Atlassian account
+	OAuthTokenEnv = "ATLASSIAN_OAUTH_TOKEN"
+	EmailEnv      = "ATLASSIAN_EMAIL"
+	APITokenEnv   = "ATLASSIAN_API_TOKEN"
+
+	// lastModifiedMargin is subtracted from the last modification time of the previous sync in CQL queries,
+	// as CQL dates are interpreted in the user's time zone
+	lastModifiedMargin = 24 * time.Hour
+	batchSize          = 100
+)
+
+const (
+	MetadataKeyPageID   = "confluencePageId"   // ID of the page
+	MetadataKeyURL      = "confluenceUrl"      // link to the page in Confluence
+	MetadataKeyTitle    = "confluenceTitle"    // title of the page
+	MetadataKeySpace    = "confluenceSpace"    // key of the page's space
+	MetadataKeyPath     = "confluencePath"     // titles of the page's ancestors and the page, e.g. "Engineering/Specs/Roadmap"
+	MetadataKeyParentID = "confluenceParentId" // ID of the page's parent page, if any
+)
+
+// Connector syncs the pages of a Confluence Cloud space (confluence://<site>/<space-key>) or a page and its descendants
+// (confluence://<site>/<space-key>/<page-id>), converted to markdown. After the first sync, only pages modified since the previous sync
+// are synced, found by a CQL query, and pages that aren't in the space (or under the page) anymore are removed.
+type Connector struct {
+	client  *http.Client
+	baseURL string // e.g. https://acme.atlassian.net/wiki
+	auth    string // value of the Authorization header
+	space   string
+	pageID  string // empty for the whole space
+}
+
+// state is the sync state: the latest modification time and the IDs of the synced pages
+type state struct {
+	LastModified time.Time `json:"lastModified"`
+	Pages        []string  `json:"pages"`
+}
+
+type page struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version struct {
+		Number int       `json:"number"`
+		When   time.Time `json:"when"`
+	} `json:"version"`
+	Ancestors []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"ancestors"`
+	Links struct {
+		WebUI string `json:"webui"`
+	} `json:"_links"`
+	Body struct {
+		Storage struct {
+			Value string `json:"value"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+func New(ctx context.Context, u *url.URL) (*Connector, error) {
+	elems := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "" || elems[0] == "" || len(elems) > 2 {
+		return nil, fmt.Errorf("invalid Confluence URL %q: expected confluence://<site>/<space-key>[/<page-id>]", u)
+	}
+	c := &Connector{client: http.DefaultClient, space: elems[0]}
+	if len(elems) == 2 {
+		if _, err := strconv.ParseUint(elems[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid Confluence page ID %q", elems[1])
+		}
+		c.pageID = elems[1]
+	}
+
+	switch {
+	case os.Getenv(EmailEnv) != "" && os.Getenv(APITokenEnv) != "":
+		c.auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(os.Getenv(EmailEnv)+":"+strings.TrimSpace(os.Getenv(APITokenEnv))))
+		c.baseURL = "https://" + u.Host + "/wiki"
+	case os.Getenv(OAuthTokenEnv) != "":
+		// OAuth requests go through the Atlassian API gateway, by the site's cloud ID
+		c.auth = "Bearer " + os.Getenv(OAuthTokenEnv)
+		var tenant struct {
+			CloudID string `json:"cloudId"`
+		}
+		if err := c.get(ctx, "https://"+u.Host+"/_edge/tenant_info", &tenant); err != nil {
+			return nil, fmt.Errorf("failed to get cloud ID of site %q: %w", u.Host, err)
+		}
+		c.baseURL = "https://api.atlassian.com/ex/confluence/" + tenant.CloudID + "/wiki"
+	default:
+		return nil, fmt.Errorf("%s or both %s and %s are required", OAuthTokenEnv, EmailEnv, APITokenEnv)
+	}
+	return c, nil
+}
+
+func (c *Connector) Sync(ctx context.Context, rawState string, changed func(types.Document) error, removed func(id string) error) (string, error) {
+	scope := fmt.Sprintf("type = page and space = %q", c.space)
+	if c.pageID != "" {
+		scope += fmt.Sprintf(" and (id = %s or ancestor = %s)", c.pageID, c.pageID)
+	}
+
+	var next state
+	synced := map[string]struct{}{}
+	report := func(p page, base string) error {
+		synced[p.ID] = struct{}{}
+		if p.Version.When.After(next.LastModified) {
+			next.LastModified = p.Version.When
+		}
+		return changed(c.document(p, base))
+	}
+
+	if rawState == "" {
+		if err := c.search(ctx, scope, "version,ancestors", report); err != nil {
+			return "", err
+		}
+		return encode(next, synced)
+	}
+
+	var st state
+	if err := json.Unmarshal([]byte(rawState), &st); err != nil {
+		return "", types.ErrStateExpired
+	}
+	next.LastModified = st.LastModified
+
+	// all pages in scope, to find removed pages and pages moved into the scope
+	current := map[string]struct{}{}
+	if err := c.search(ctx, scope, "", func(p page, _ string) error {
+		current[p.ID] = struct{}{}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	since := st.LastModified.Add(-lastModifiedMargin).Format("2006-01-02")
+	if err := c.search(ctx, fmt.Sprintf("%s and lastmodified >= %q", scope, since), "version,ancestors", report); err != nil {
+		return "", err
+	}
+
+	previous := make(map[string]struct{}, len(st.Pages))
+	for _, id := range st.Pages {
+		previous[id] = struct{}{}
+	}
+	var added []string
+	for id := range current {
+		_, wasPrevious := previous[id]
+		_, wasSynced := synced[id]
+		if !wasPrevious && !wasSynced {
+			added = append(added, id)
+		}
+	}
+	for i := 0; i < len(added); i += batchSize {
+		ids := added[i:min(i+batchSize, len(added))]
+		if err := c.search(ctx, fmt.Sprintf("id in (%s)", strings.Join(ids, ",")), "version,ancestors", report); err != nil {
+			return "", err
+		}
+	}
+
+	for id := range previous {
+		if _, ok := current[id]; ok {
+			synced[id] = struct{}{}
+		} else if _, ok := synced[id]; !ok {
+			if err := removed(id); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return encode(next, synced)
+}
+
+func encode(st state, pages map[string]struct{}) (string, error) {
+	for id := range pages {
+		st.Pages = append(st.Pages, id)
+	}
+	b, err := json.Marshal(st)
+	return string(b), err
+}
+
+// search calls fn for the pages found by the CQL query, with the expanded fields, and the base URL of the web UI links
+func (c *Connector) search(ctx context.Context, cql, expand string, fn func(p page, base string) error) error {
+	q := url.Values{"cql": {cql + " order by lastmodified"}, "limit": {strconv.Itoa(batchSize)}}
+	if expand != "" {
+		q.Set("expand", expand)
+	}
+	next := c.baseURL + "/rest/api/content/search?" + q.Encode()
+	for next != "" {
+		var resp struct {
+			Results []page `json:"results"`
+			Links   struct {
+				Base string `json:"base"`
+				Next string `json:"next"`
+			} `json:"_links"`
+		}
+		if err := c.get(ctx, next, &resp); err != nil {
+			return fmt.Errorf("failed to search pages (%s): %w", cql, err)
+		}
+		for _, p := range resp.Results {
+			if err := fn(p, resp.Links.Base); err != nil {
+				return err
+			}
+		}
+		next = ""
+		if resp.Links.Next != "" {
+			next = c.baseURL + resp.Links.Next
+		}
+	}
+	return nil
+}
+
+func (c *Connector) Load(ctx context.Context, doc types.Document) ([]byte, error) {
+	var p page
+	if err := c.get(ctx, c.baseURL+"/rest/api/content/"+url.PathEscape(doc.ID)+"?expand=body.storage", &p); err != nil {
+		return nil, fmt.Errorf("failed to get page %q: %w", doc.ID, err)
+	}
+	md, err := storageToMarkdown(p.Body.Storage.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert page %q to markdown: %w", doc.ID, err)
+	}
+	return []byte("# " + p.Title + "\n\n" + md + "\n"), nil
+}
+
+func (c *Connector) document(p page, base string) types.Document {
+	elems := make([]string, 0, len(p.Ancestors)+1)
+	for _, a := range p.Ancestors {
+		elems = append(elems, a.Title)
+	}
+	elems = append(elems, p.Title)
+
+	metadata := map[string]any{
+		MetadataKeyPageID: p.ID,
+		MetadataKeyURL:    base + p.Links.WebUI,
+		MetadataKeyTitle:  p.Title,
+		MetadataKeySpace:  c.space,
+		MetadataKeyPath:   strings.Join(elems, "/"),
+	}
+	if len(p.Ancestors) > 0 {
+		metadata[MetadataKeyParentID] = p.Ancestors[len(p.Ancestors)-1].ID
+	}
+
+	return types.Document{
+		ID:         p.ID,
+		Name:       strings.ReplaceAll(p.Title, "/", "-") + ".md",
+		ModifiedAt: p.Version.When,
+		Version:    strconv.Itoa(p.Version.Number),
+		Metadata:   metadata,
+	}
+}
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+func (c *Connector) get(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.auth)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,141 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageToMarkdown(t *testing.T) {
+	storage := `<h1>Setup</h1>
+<p>See <ac:link><ri:page ri:content-title="Install guide" /></ac:link> and <ac:link><ri:page ri:content-title="FAQ" /><ac:plain-text-link-body><![CDATA[the FAQ]]></ac:plain-text-link-body></ac:link>, updated <time datetime="2024-05-01" /> by <strong>ops</strong>.</p>
+<ac:structured-macro ac:name="info"><ac:parameter ac:name="title">Note</ac:parameter><ac:rich-text-body><p>Requires admin rights.</p></ac:rich-text-body></ac:structured-macro>
+<ac:structured-macro ac:name="code"><ac:parameter ac:name="language">go</ac:parameter><ac:plain-text-body><![CDATA[fmt.Println("<hi>")]]></ac:plain-text-body></ac:structured-macro>
+<ac:task-list><ac:task><ac:task-status>complete</ac:task-status><ac:task-body>Install</ac:task-body></ac:task><ac:task><ac:task-status>incomplete</ac:task-status><ac:task-body>Configure</ac:task-body></ac:task></ac:task-list>
+<table><tbody><tr><th>Key</th><th>Value</th></tr><tr><td>port</td><td>8080</td></tr></tbody></table>
+<p><ac:image><ri:attachment ri:filename="diagram.png" /></ac:image>End</p>`
+
+	md, err := storageToMarkdown(storage)
+	require.NoError(t, err)
+	assert.Equal(t, "# Setup\n\n"+
+		"See Install guide and the FAQ, updated 2024-05-01 by **ops**.\n\n"+
+		"> Requires admin rights.\n\n"+
+		"```go\nfmt.Println(\"<hi>\")\n```\n\n"+
+		"- \\[x] Install\n- \\[ ] Configure\n\n"+
+		"| Key  | Value |\n|------|-------|\n| port | 8080  |\n\n"+
+		"End", md)
+}
+
+func TestSync(t *testing.T) {
+	pageJSON := func(id, title string, version int, when string, ancestors ...string) string {
+		var as []string
+		for _, a := range ancestors {
+			as = append(as, fmt.Sprintf(`{"id": %q, "title": %q}`, a, strings.ToUpper(a)))
+		}
+		return fmt.Sprintf(`{"id": %q, "title": %q, "version": {"number": %d, "when": %q}, "ancestors": [%s], "_links": {"webui": "/spaces/ENG/pages/%s"}}`,
+			id, title, version, when, strings.Join(as, ","), id)
+	}
+	// results by CQL query
+	results := map[string][]string{
+		`type = page and space = "ENG" order by lastmodified`: {
+			pageJSON("1", "Home", 1, "2024-05-01T10:00:00.000Z"),
+			pageJSON("2", "Setup/Install", 3, "2024-05-02T10:00:00.000Z", "1"),
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Basic token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/wiki/rest/api/content/search":
+			pages := results[r.URL.Query().Get("cql")]
+			// paginated: one page per response
+			i := 0
+			if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+				_, _ = fmt.Sscan(cursor, &i)
+			}
+			var next string
+			if i+1 < len(pages) {
+				next = fmt.Sprintf("/rest/api/content/search?cql=%s&cursor=%d", url.QueryEscape(r.URL.Query().Get("cql")), i+1)
+			}
+			var page []string
+			if i < len(pages) {
+				page = pages[i : i+1]
+			}
+			resp := map[string]any{"results": json.RawMessage("[" + strings.Join(page, ",") + "]"), "_links": map[string]string{"base": "https://acme.atlassian.net/wiki", "next": next}}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/wiki/rest/api/content/2":
+			assert.Equal(t, "body.storage", r.URL.Query().Get("expand"))
+			_, _ = w.Write([]byte(`{"id": "2", "title": "Setup/Install", "body": {"storage": {"value": "<p>Run <code>make</code>.</p>"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Connector{client: srv.Client(), baseURL: srv.URL + "/wiki", auth: "Basic token", space: "ENG"}
+
+	var docs []types.Document
+	var removed []string
+	collect := func(doc types.Document) error {
+		docs = append(docs, doc)
+		return nil
+	}
+	remove := func(id string) error {
+		removed = append(removed, id)
+		return nil
+	}
+
+	// full sync
+	st, err := c.Sync(ctx, "", collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "Setup-Install.md", docs[1].Name)
+	assert.Equal(t, "3", docs[1].Version)
+	assert.Equal(t, "https://acme.atlassian.net/wiki/spaces/ENG/pages/2", docs[1].Metadata[MetadataKeyURL])
+	assert.Equal(t, "1/Setup/Install", docs[1].Metadata[MetadataKeyPath])
+	assert.Equal(t, "1", docs[1].Metadata[MetadataKeyParentID])
+	assert.Equal(t, "ENG", docs[1].Metadata[MetadataKeySpace])
+
+	content, err := c.Load(ctx, docs[1])
+	require.NoError(t, err)
+	assert.Equal(t, "# Setup/Install\n\nRun `make`.\n", string(content))
+
+	// incremental sync: page 1 removed, page 2 modified, page 3 moved into the space
+	results = map[string][]string{
+		`type = page and space = "ENG" order by lastmodified`: {
+			pageJSON("2", "", 0, "0001-01-01T00:00:00Z"),
+			pageJSON("3", "", 0, "0001-01-01T00:00:00Z"),
+		},
+		`type = page and space = "ENG" and lastmodified >= "2024-05-01" order by lastmodified`: {
+			pageJSON("2", "Setup/Install", 4, "2024-05-03T10:00:00.000Z", "1"),
+		},
+		`id in (3) order by lastmodified`: {
+			pageJSON("3", "Moved", 7, "2024-01-01T10:00:00.000Z"),
+		},
+	}
+	docs = nil
+	st, err = c.Sync(ctx, st, collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "4", docs[0].Version)
+	assert.Equal(t, "Moved.md", docs[1].Name)
+	assert.Equal(t, []string{"1"}, removed)
+
+	var s state
+	require.NoError(t, json.Unmarshal([]byte(st), &s))
+	assert.ElementsMatch(t, []string{"2", "3"}, s.Pages)
+	assert.Equal(t, "2024-05-03T10:00:00Z", s.LastModified.Format("2006-01-02T15:04:05Z07:00"))
+
+	_, err = c.Sync(ctx, "invalid", collect, remove)
+	assert.ErrorIs(t, err, types.ErrStateExpired)
+}
@@ -0,0 +1,208 @@
+package confluence
+
+import (
+	"regexp"
+	"strings"
+
+	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/strikethrough"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var converter = mdconv.NewConverter(mdconv.WithPlugins(base.NewBasePlugin(), commonmark.NewCommonmarkPlugin(), strikethrough.NewStrikethroughPlugin(), table.NewTablePlugin()))
+
+// selfClosingTagRegex matches the self-closing Confluence tags, e.g. <ri:page ri:content-title="Setup" />, which the HTML parser
+// would treat as start tags
+var selfClosingTagRegex = regexp.MustCompile(`<((?:ac|ri):[\w-]+|time)(\s[^>]*?)?\s*/>`)
+
+// cdataRegex matches CDATA sections, e.g. of code macros, which the HTML parser doesn't support
+var cdataRegex = regexp.MustCompile(`(?s)<!\[CDATA\[(.*?)\]\]>`)
+
+// storageToMarkdown converts a page body in Confluence storage format (XHTML with Confluence-specific elements) to markdown
+func storageToMarkdown(storage string) (string, error) {
+	storage = selfClosingTagRegex.ReplaceAllString(storage, "<$1$2></$1>")
+	storage = cdataRegex.ReplaceAllStringFunc(storage, func(cdata string) string {
+		return html.EscapeString(cdataRegex.FindStringSubmatch(cdata)[1])
+	})
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(storage), body)
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	rewrite(body)
+
+	md, err := converter.ConvertNode(body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(md)), nil
+}
+
+// rewrite replaces the Confluence-specific elements under the node by their HTML equivalent
+func rewrite(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			if r := replacement(c); r != c {
+				if r != nil {
+					n.InsertBefore(r, c)
+				}
+				n.RemoveChild(c)
+				if r == nil || r.Type != html.ElementNode {
+					c = next
+					continue
+				}
+				c = r
+			}
+			rewrite(c)
+		}
+		c = next
+	}
+}
+
+// replacement returns the replacement of the element, the element itself if it's not replaced or nil if it's removed
+func replacement(n *html.Node) *html.Node {
+	switch n.Data {
+	case "ac:structured-macro":
+		switch name := attr(n, "ac:name"); name {
+		case "code", "noformat":
+			code := element("code", textNode(text(child(n, "ac:plain-text-body"))))
+			if lang := parameter(n, "language"); lang != "" {
+				code.Attr = []html.Attribute{{Key: "class", Val: "language-" + lang}}
+			}
+			return element("pre", code)
+		case "info", "note", "warning", "tip", "panel":
+			return moveChildren(child(n, "ac:rich-text-body"), element("blockquote"))
+		default:
+			// e.g. expand: keep the content, drop the parameters
+			if body := child(n, "ac:rich-text-body"); body != nil {
+				return moveChildren(body, element("div"))
+			}
+			return nil
+		}
+	case "ac:link":
+		if body := child(n, "ac:link-body"); body != nil {
+			return moveChildren(body, element("span"))
+		}
+		if body := child(n, "ac:plain-text-link-body"); body != nil {
+			return textNode(text(body))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			for _, key := range []string{"ri:content-title", "ri:filename", "ri:value"} {
+				if v := attr(c, key); v != "" {
+					return textNode(v)
+				}
+			}
+		}
+		return nil
+	case "ac:task-list":
+		list := element("ul")
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Data != "ac:task" {
+				continue
+			}
+			check := "[ ] "
+			if text(child(c, "ac:task-status")) == "complete" {
+				check = "[x] "
+			}
+			item := moveChildren(child(c, "ac:task-body"), element("li"))
+			item.InsertBefore(textNode(check), item.FirstChild)
+			list.AppendChild(item)
+		}
+		return list
+	case "ac:emoticon":
+		if fallback := attr(n, "ac:emoji-fallback"); fallback != "" {
+			return textNode(fallback)
+		}
+		return nil
+	case "time":
+		return textNode(attr(n, "datetime"))
+	case "ac:image", "ac:placeholder", "ac:parameter":
+		return nil
+	}
+	if strings.HasPrefix(n.Data, "ac:") || strings.HasPrefix(n.Data, "ri:") {
+		// unknown Confluence element: keep the content
+		return moveChildren(n, element("span"))
+	}
+	return n
+}
+
+func element(tag string, children ...*html.Node) *html.Node {
+	n := &html.Node{Type: html.ElementNode, Data: tag, DataAtom: atom.Lookup([]byte(tag))}
+	for _, c := range children {
+		n.AppendChild(c)
+	}
+	return n
+}
+
+func textNode(s string) *html.Node {
+	return &html.Node{Type: html.TextNode, Data: s}
+}
+
+// moveChildren moves the children of from, if not nil, to to and returns to
+func moveChildren(from, to *html.Node) *html.Node {
+	if from == nil {
+		return to
+	}
+	for c := from.FirstChild; c != nil; c = from.FirstChild {
+		from.RemoveChild(c)
+		to.AppendChild(c)
+	}
+	return to
+}
+
+// child returns the first child element with the tag, or nil
+func child(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// parameter returns the value of the macro's parameter
+func parameter(macro *html.Node, name string) string {
+	for c := macro.FirstChild; c != nil; c = c.NextSibling {
+		if c.Data == "ac:parameter" && attr(c, "ac:name") == name {
+			return text(c)
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// text returns the text content of the node
+func text(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
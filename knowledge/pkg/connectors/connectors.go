@@ -0,0 +1,47 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/confluence"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/gdrive"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/notion"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/onedrive"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+)
+
+// IsURL reports whether the path is the source URL of a connector, e.g. gdrive://<folder-id> or notion://workspace
+func IsURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case gdrive.Scheme, notion.Scheme, confluence.Scheme, onedrive.SchemeOneDrive, onedrive.SchemeSharePoint:
+		return true
+	default:
+		return false
+	}
+}
+
+// New creates the connector for the source URL
+func New(ctx context.Context, rawURL string) (types.Connector, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connector URL %q: %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case gdrive.Scheme:
+		return gdrive.New(ctx, u)
+	case notion.Scheme:
+		return notion.New(ctx, u)
+	case confluence.Scheme:
+		return confluence.New(ctx, u)
+	case onedrive.SchemeOneDrive, onedrive.SchemeSharePoint:
+		return onedrive.New(ctx, u)
+	default:
+		return nil, fmt.Errorf("unsupported connector URL %q", rawURL)
+	}
+}
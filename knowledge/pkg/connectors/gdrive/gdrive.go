@@ -0,0 +1,358 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	Scheme = "gdrive"
+
+	// TokenEnv is the environment variable of an OAuth access token - if not set, the application default credentials are used,
+	// e.g. a service account key file in GOOGLE_APPLICATION_CREDENTIALS
+	TokenEnv = "GOOGLE_OAUTH_TOKEN"
+
+	defaultBaseURL = "https://www.googleapis.com/drive/v3"
+	scope          = "https://www.googleapis.com/auth/drive.readonly"
+	mimeTypeFolder = "application/vnd.google-apps.folder"
+	fileFields     = "id,name,mimeType,size,modifiedTime,version,trashed,parents,webViewLink"
+)
+
+const (
+	MetadataKeyFileID   = "driveFileId"   // ID of the Drive file
+	MetadataKeyURL      = "driveUrl"      // link to the file in Drive
+	MetadataKeyPath     = "drivePath"     // path of the file in the synced folder, e.g. "Specs/Roadmap"
+	MetadataKeyMimeType = "driveMimeType" // MIME type of the Drive file, e.g. of a Google Doc rather than the exported format
+)
+
+// exportFormats are the formats Google Workspace files are exported to, by their MIME type - other Google Workspace files (forms, sites, ...) are skipped
+var exportFormats = map[string]struct{ mimeType, ext string }{
+	"application/vnd.google-apps.document":     {"text/markdown", ".md"},
+	"application/vnd.google-apps.spreadsheet":  {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"},
+	"application/vnd.google-apps.presentation": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", ".pptx"},
+	"application/vnd.google-apps.drawing":      {"application/pdf", ".pdf"},
+}
+
+// Connector syncs the files in a Google Drive folder and its subfolders, for gdrive://<folder-id> URLs (gdrive://root for "My Drive").
+// After the first sync, only changes are synced via the Drive changes feed.
+type Connector struct {
+	client   *http.Client
+	baseURL  string
+	folderID string
+}
+
+// state is the sync state: the page token of the changes feed and the synced folders, by ID
+type state struct {
+	PageToken string            `json:"pageToken"`
+	DriveID   string            `json:"driveId,omitempty"` // ID of the shared drive of the folder
+	Folders   map[string]string `json:"folders"`           // paths of the folders in the synced folder
+}
+
+type file struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	MimeType     string    `json:"mimeType"`
+	Size         string    `json:"size"`
+	ModifiedTime time.Time `json:"modifiedTime"`
+	Version      string    `json:"version"`
+	Trashed      bool      `json:"trashed"`
+	Parents      []string  `json:"parents"`
+	WebViewLink  string    `json:"webViewLink"`
+	DriveID      string    `json:"driveId"`
+}
+
+type change struct {
+	FileID  string `json:"fileId"`
+	Removed bool   `json:"removed"`
+	File    *file  `json:"file"`
+}
+
+func New(ctx context.Context, u *url.URL) (*Connector, error) {
+	folderID := u.Host
+	if folderID == "" {
+		return nil, fmt.Errorf("invalid Google Drive URL %q: expected gdrive://<folder-id>", u)
+	}
+
+	var client *http.Client
+	if token := os.Getenv(TokenEnv); token != "" {
+		client = oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	} else {
+		var err error
+		client, err = google.DefaultClient(ctx, scope)
+		if err != nil {
+			return nil, fmt.Errorf("no Google credentials found (set %s or GOOGLE_APPLICATION_CREDENTIALS): %w", TokenEnv, err)
+		}
+	}
+
+	return &Connector{client: client, baseURL: defaultBaseURL, folderID: folderID}, nil
+}
+
+func (c *Connector) Sync(ctx context.Context, rawState string, changed func(types.Document) error, removed func(id string) error) (string, error) {
+	if rawState == "" {
+		return c.fullSync(ctx, changed)
+	}
+
+	var st state
+	if err := json.Unmarshal([]byte(rawState), &st); err != nil || st.PageToken == "" {
+		return "", types.ErrStateExpired
+	}
+
+	var changes []change
+	q := url.Values{
+		"pageToken":                 {st.PageToken},
+		"includeRemoved":            {"true"},
+		"supportsAllDrives":         {"true"},
+		"includeItemsFromAllDrives": {"true"},
+		"pageSize":                  {"1000"},
+		"fields":                    {"nextPageToken,newStartPageToken,changes(fileId,removed,file(" + fileFields + "))"},
+	}
+	if st.DriveID != "" {
+		q.Set("driveId", st.DriveID)
+	}
+	for {
+		var resp struct {
+			NextPageToken     string   `json:"nextPageToken"`
+			NewStartPageToken string   `json:"newStartPageToken"`
+			Changes           []change `json:"changes"`
+		}
+		if err := c.get(ctx, "/changes", q, &resp); err != nil {
+			var se *statusError
+			if errors.As(err, &se) && (se.status == http.StatusNotFound || se.status == http.StatusGone) {
+				return "", types.ErrStateExpired
+			}
+			return "", err
+		}
+		changes = append(changes, resp.Changes...)
+		if resp.NewStartPageToken != "" {
+			st.PageToken = resp.NewStartPageToken
+			break
+		}
+		q.Set("pageToken", resp.NextPageToken)
+	}
+
+	// Folders first, as files may be listed before the new folders they're in.
+	// Folders are added until no more are found, as subfolders may be listed before their parent.
+	for added := true; added; {
+		added = false
+		for _, ch := range changes {
+			f := ch.File
+			if ch.Removed || f == nil || f.Trashed || f.MimeType != mimeTypeFolder {
+				continue
+			}
+			if _, ok := st.Folders[f.ID]; ok {
+				continue
+			}
+			if parent, ok := st.parent(f); ok {
+				st.Folders[f.ID] = path.Join(st.Folders[parent], f.Name)
+				added = true
+			}
+		}
+	}
+
+	for _, ch := range changes {
+		f := ch.File
+		if ch.Removed || f == nil || f.Trashed {
+			delete(st.Folders, ch.FileID)
+			if err := removed(ch.FileID); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if f.MimeType == mimeTypeFolder {
+			continue
+		}
+		parent, ok := st.parent(f)
+		if !ok {
+			// moved out of the synced folder
+			if err := removed(f.ID); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if doc, ok := document(f, st.Folders[parent]); ok {
+			if err := changed(doc); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return st.encode()
+}
+
+// fullSync lists all files in the folder and its subfolders. The start page token of the changes feed is fetched first,
+// so that no changes during the listing are missed.
+func (c *Connector) fullSync(ctx context.Context, changed func(types.Document) error) (string, error) {
+	var root file
+	if err := c.get(ctx, "/files/"+url.PathEscape(c.folderID), url.Values{"supportsAllDrives": {"true"}, "fields": {"id,mimeType,driveId"}}, &root); err != nil {
+		return "", fmt.Errorf("failed to get folder %q: %w", c.folderID, err)
+	}
+	if root.MimeType != mimeTypeFolder {
+		return "", fmt.Errorf("%q is not a folder", c.folderID)
+	}
+
+	st := state{DriveID: root.DriveID, Folders: map[string]string{root.ID: ""}}
+	q := url.Values{"supportsAllDrives": {"true"}}
+	if st.DriveID != "" {
+		q.Set("driveId", st.DriveID)
+	}
+	var start struct {
+		StartPageToken string `json:"startPageToken"`
+	}
+	if err := c.get(ctx, "/changes/startPageToken", q, &start); err != nil {
+		return "", fmt.Errorf("failed to get start page token: %w", err)
+	}
+	st.PageToken = start.StartPageToken
+
+	pending := []string{root.ID}
+	for len(pending) > 0 {
+		folderID := pending[0]
+		pending = pending[1:]
+
+		q := url.Values{
+			"q":                         {fmt.Sprintf("'%s' in parents and trashed = false", folderID)},
+			"supportsAllDrives":         {"true"},
+			"includeItemsFromAllDrives": {"true"},
+			"pageSize":                  {"1000"},
+			"fields":                    {"nextPageToken,files(" + fileFields + ")"},
+		}
+		for {
+			var resp struct {
+				NextPageToken string `json:"nextPageToken"`
+				Files         []file `json:"files"`
+			}
+			if err := c.get(ctx, "/files", q, &resp); err != nil {
+				return "", fmt.Errorf("failed to list folder %q: %w", folderID, err)
+			}
+			for _, f := range resp.Files {
+				if f.MimeType == mimeTypeFolder {
+					st.Folders[f.ID] = path.Join(st.Folders[folderID], f.Name)
+					pending = append(pending, f.ID)
+					continue
+				}
+				if doc, ok := document(&f, st.Folders[folderID]); ok {
+					if err := changed(doc); err != nil {
+						return "", err
+					}
+				}
+			}
+			if resp.NextPageToken == "" {
+				break
+			}
+			q.Set("pageToken", resp.NextPageToken)
+		}
+	}
+
+	return st.encode()
+}
+
+func (c *Connector) Load(ctx context.Context, doc types.Document) ([]byte, error) {
+	p := "/files/" + url.PathEscape(doc.ID)
+	q := url.Values{"supportsAllDrives": {"true"}, "alt": {"media"}}
+	if mimeType, ok := doc.Metadata[MetadataKeyMimeType].(string); ok {
+		if format, ok := exportFormats[mimeType]; ok {
+			p += "/export"
+			q = url.Values{"mimeType": {format.mimeType}}
+		}
+	}
+
+	resp, err := c.do(ctx, p, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %q: %w", doc.ID, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// parent returns the first parent of the file in the synced folders
+func (s *state) parent(f *file) (string, bool) {
+	i := slices.IndexFunc(f.Parents, func(p string) bool {
+		_, ok := s.Folders[p]
+		return ok
+	})
+	if i < 0 {
+		return "", false
+	}
+	return f.Parents[i], true
+}
+
+func (s *state) encode() (string, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// document returns the document for the file in the folder with the given path, or false if the file type can't be synced
+func document(f *file, folderPath string) (types.Document, bool) {
+	name := f.Name
+	if strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+		format, ok := exportFormats[f.MimeType]
+		if !ok {
+			return types.Document{}, false
+		}
+		name += format.ext
+	}
+	size, _ := strconv.ParseInt(f.Size, 10, 64)
+
+	return types.Document{
+		ID:         f.ID,
+		Name:       name,
+		Size:       size,
+		ModifiedAt: f.ModifiedTime,
+		Version:    f.Version,
+		Metadata: map[string]any{
+			MetadataKeyFileID:   f.ID,
+			MetadataKeyURL:      f.WebViewLink,
+			MetadataKeyPath:     path.Join(folderPath, f.Name),
+			MetadataKeyMimeType: f.MimeType,
+		},
+	}, true
+}
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+func (c *Connector) get(ctx context.Context, p string, q url.Values, v any) error {
+	resp, err := c.do(ctx, p, q)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Connector) do(ctx context.Context, p string, q url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+p+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &statusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
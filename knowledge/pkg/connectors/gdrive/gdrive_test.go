@@ -0,0 +1,129 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mimeTypeDoc  = "application/vnd.google-apps.document"
+	mimeTypeForm = "application/vnd.google-apps.form"
+)
+
+func TestSync(t *testing.T) {
+	listings := map[string][]file{
+		"'root-folder' in parents and trashed = false": {
+			{ID: "doc", Name: "Roadmap", MimeType: mimeTypeDoc, Version: "3", WebViewLink: "https://docs.google.com/document/d/doc"},
+			{ID: "specs", Name: "Specs", MimeType: mimeTypeFolder},
+			{ID: "form", Name: "Survey", MimeType: mimeTypeForm},
+		},
+		"'specs' in parents and trashed = false": {
+			{ID: "pdf", Name: "spec.pdf", MimeType: "application/pdf", Size: "4", Version: "1"},
+		},
+	}
+	changes := []change{
+		{FileID: "doc", File: &file{ID: "doc", Name: "Roadmap", MimeType: mimeTypeDoc, Version: "4", Parents: []string{"root-folder"}}},
+		{FileID: "pdf", Removed: true},
+		// file listed before its new folder
+		{FileID: "notes", File: &file{ID: "notes", Name: "notes.txt", MimeType: "text/plain", Version: "1", Parents: []string{"new"}}},
+		{FileID: "new", File: &file{ID: "new", Name: "New", MimeType: mimeTypeFolder, Parents: []string{"specs"}}},
+		{FileID: "moved", File: &file{ID: "moved", Name: "moved.txt", MimeType: "text/plain", Version: "2", Parents: []string{"elsewhere"}}},
+		{FileID: "other", File: &file{ID: "other", Name: "other.txt", MimeType: "text/plain", Version: "1", Parents: []string{"elsewhere"}, Trashed: true}},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var resp any
+		switch r.URL.Path {
+		case "/files/root-folder":
+			resp = file{ID: "root-folder", MimeType: mimeTypeFolder}
+		case "/changes/startPageToken":
+			resp = map[string]string{"startPageToken": "1"}
+		case "/files":
+			resp = map[string]any{"files": listings[q.Get("q")]}
+		case "/changes":
+			switch q.Get("pageToken") {
+			case "1":
+				resp = map[string]any{"changes": changes[:3], "nextPageToken": "1b"}
+			case "1b":
+				resp = map[string]any{"changes": changes[3:], "newStartPageToken": "2"}
+			default:
+				http.NotFound(w, r)
+				return
+			}
+		case "/files/doc/export":
+			assert.Equal(t, "text/markdown", q.Get("mimeType"))
+			_, _ = w.Write([]byte("# Roadmap"))
+			return
+		case "/files/pdf":
+			assert.Equal(t, "media", q.Get("alt"))
+			_, _ = w.Write([]byte("%PDF"))
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Connector{client: srv.Client(), baseURL: srv.URL, folderID: "root-folder"}
+
+	var docs []types.Document
+	var removed []string
+	collect := func(doc types.Document) error {
+		docs = append(docs, doc)
+		return nil
+	}
+	remove := func(id string) error {
+		removed = append(removed, id)
+		return nil
+	}
+
+	// full sync
+	st, err := c.Sync(ctx, "", collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "Roadmap.md", docs[0].Name)
+	assert.Equal(t, "3", docs[0].Version)
+	assert.Equal(t, "Roadmap", docs[0].Metadata[MetadataKeyPath])
+	assert.Equal(t, "https://docs.google.com/document/d/doc", docs[0].Metadata[MetadataKeyURL])
+	assert.Equal(t, "spec.pdf", docs[1].Name)
+	assert.Equal(t, int64(4), docs[1].Size)
+	assert.Equal(t, "Specs/spec.pdf", docs[1].Metadata[MetadataKeyPath])
+	assert.Empty(t, removed)
+
+	content, err := c.Load(ctx, docs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "# Roadmap", string(content))
+	content, err = c.Load(ctx, docs[1])
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF", string(content))
+
+	// incremental sync
+	docs = nil
+	st, err = c.Sync(ctx, st, collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "4", docs[0].Version)
+	assert.Equal(t, "notes", docs[1].ID)
+	assert.Equal(t, "Specs/New/notes.txt", docs[1].Metadata[MetadataKeyPath])
+	assert.Equal(t, []string{"pdf", "moved", "other"}, removed)
+
+	var s state
+	require.NoError(t, json.Unmarshal([]byte(st), &s))
+	assert.Equal(t, "2", s.PageToken)
+	assert.Contains(t, s.Folders, "new")
+
+	// expired page token
+	_, err = c.Sync(ctx, st, collect, remove)
+	assert.ErrorIs(t, err, types.ErrStateExpired)
+}
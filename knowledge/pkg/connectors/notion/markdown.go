@@ -0,0 +1,341 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type richText struct {
+	PlainText   string `json:"plain_text"`
+	Href        string `json:"href"`
+	Annotations struct {
+		Bold          bool `json:"bold"`
+		Italic        bool `json:"italic"`
+		Strikethrough bool `json:"strikethrough"`
+		Code          bool `json:"code"`
+	} `json:"annotations"`
+}
+
+type option struct {
+	Name string `json:"name"`
+}
+
+type date struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (d *date) String() string {
+	if d == nil {
+		return ""
+	}
+	if d.End != "" {
+		return d.Start + " - " + d.End
+	}
+	return d.Start
+}
+
+type property struct {
+	Type        string     `json:"type"`
+	Title       []richText `json:"title"`
+	RichText    []richText `json:"rich_text"`
+	Number      *float64   `json:"number"`
+	Select      *option    `json:"select"`
+	Status      *option    `json:"status"`
+	MultiSelect []option   `json:"multi_select"`
+	Date        *date      `json:"date"`
+	Checkbox    bool       `json:"checkbox"`
+	URL         string     `json:"url"`
+	Email       string     `json:"email"`
+	PhoneNumber string     `json:"phone_number"`
+	People      []option   `json:"people"`
+	Formula     struct {
+		Type    string   `json:"type"`
+		String  string   `json:"string"`
+		Number  *float64 `json:"number"`
+		Boolean bool     `json:"boolean"`
+		Date    *date    `json:"date"`
+	} `json:"formula"`
+}
+
+// String returns the value of the property, or an empty string for unsupported property types
+func (p property) String() string {
+	switch p.Type {
+	case "rich_text":
+		return plainText(p.RichText)
+	case "number":
+		if p.Number != nil {
+			return strconv.FormatFloat(*p.Number, 'f', -1, 64)
+		}
+	case "select":
+		if p.Select != nil {
+			return p.Select.Name
+		}
+	case "status":
+		if p.Status != nil {
+			return p.Status.Name
+		}
+	case "multi_select":
+		return joinNames(p.MultiSelect)
+	case "people":
+		return joinNames(p.People)
+	case "date":
+		return p.Date.String()
+	case "checkbox":
+		return strconv.FormatBool(p.Checkbox)
+	case "url":
+		return p.URL
+	case "email":
+		return p.Email
+	case "phone_number":
+		return p.PhoneNumber
+	case "formula":
+		switch p.Formula.Type {
+		case "string":
+			return p.Formula.String
+		case "number":
+			if p.Formula.Number != nil {
+				return strconv.FormatFloat(*p.Formula.Number, 'f', -1, 64)
+			}
+		case "boolean":
+			return strconv.FormatBool(p.Formula.Boolean)
+		case "date":
+			return p.Formula.Date.String()
+		}
+	}
+	return ""
+}
+
+func joinNames(options []option) string {
+	names := make([]string, 0, len(options))
+	for _, o := range options {
+		names = append(names, o.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// blockContent is the content of any block type - only the fields of the block's type are set
+type blockContent struct {
+	RichText   []richText `json:"rich_text"`
+	Caption    []richText `json:"caption"`
+	Checked    bool       `json:"checked"`
+	Language   string     `json:"language"`
+	Expression string     `json:"expression"`
+	Title      string     `json:"title"`
+	URL        string     `json:"url"`
+	Name       string     `json:"name"`
+	External   struct {
+		URL string `json:"url"`
+	} `json:"external"`
+	Cells      [][]richText `json:"cells"`
+	SyncedFrom *struct {
+		BlockID string `json:"block_id"`
+	} `json:"synced_from"`
+}
+
+type block struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	HasChildren bool   `json:"has_children"`
+	Content     blockContent
+}
+
+func (b *block) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	type plain block
+	if err := json.Unmarshal(data, (*plain)(b)); err != nil {
+		return err
+	}
+	if content, ok := raw[b.Type]; ok {
+		return json.Unmarshal(content, &b.Content)
+	}
+	return nil
+}
+
+// writePageHeader writes the page's title and, for database pages, its properties
+func writePageHeader(buf *bytes.Buffer, page object) {
+	fmt.Fprintf(buf, "# %s\n\n", page.title())
+
+	names := make([]string, 0, len(page.Properties))
+	for name, p := range page.Properties {
+		if p.Type != "title" && p.String() != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(buf, "- %s: %s\n", name, page.Properties[name].String())
+	}
+	buf.WriteString("\n")
+}
+
+// writeBlocks writes the child blocks of the block or page as markdown, with each line indented by indent
+func (c *Connector) writeBlocks(ctx context.Context, buf *bytes.Buffer, id string, indent string) error {
+	blocks, err := c.listBlocks(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var inList bool
+	for _, b := range blocks {
+		content := b.Content
+		text := markdown(content.RichText)
+
+		isListItem := b.Type == "bulleted_list_item" || b.Type == "numbered_list_item" || b.Type == "to_do" || b.Type == "toggle"
+		if inList && !isListItem {
+			buf.WriteString("\n") // end the list
+		}
+		inList = isListItem
+
+		var line string
+		switch b.Type {
+		case "paragraph":
+			line = text
+		case "heading_1", "heading_2", "heading_3":
+			level, _ := strconv.Atoi(strings.TrimPrefix(b.Type, "heading_"))
+			line = strings.Repeat("#", level+1) + " " + text // the page title is the only level 1 heading
+		case "bulleted_list_item", "toggle":
+			line = "- " + text
+		case "numbered_list_item":
+			line = "1. " + text
+		case "to_do":
+			if content.Checked {
+				line = "- [x] " + text
+			} else {
+				line = "- [ ] " + text
+			}
+		case "quote", "callout":
+			line = "> " + text
+		case "code":
+			line = "```" + content.Language + "\n" + plainText(content.RichText) + "\n```"
+		case "equation":
+			line = "$$\n" + content.Expression + "\n$$"
+		case "divider":
+			line = "---"
+		case "child_page", "child_database":
+			line = "**" + content.Title + "**"
+		case "bookmark", "embed", "link_preview":
+			line = link(markdown(content.Caption), content.URL)
+		case "image", "video", "audio", "file", "pdf":
+			// uploaded files have expiring URLs, so only external ones are linked
+			name := markdown(content.Caption)
+			if name == "" {
+				name = content.Name
+			}
+			line = link(name, content.External.URL)
+		case "table":
+			if err := c.writeTable(ctx, buf, b, indent); err != nil {
+				return err
+			}
+		case "column_list", "column", "synced_block":
+			// containers, see below
+		default:
+			continue
+		}
+		if line != "" {
+			buf.WriteString(indent + strings.ReplaceAll(line, "\n", "\n"+indent) + "\n")
+			if !isListItem {
+				buf.WriteString("\n")
+			}
+		}
+
+		switch {
+		case b.Type == "synced_block" && content.SyncedFrom != nil:
+			if err := c.writeBlocks(ctx, buf, content.SyncedFrom.BlockID, indent); err != nil {
+				return err
+			}
+		case !b.HasChildren || b.Type == "table" || b.Type == "child_page" || b.Type == "child_database":
+		case b.Type == "column_list" || b.Type == "column" || b.Type == "synced_block":
+			if err := c.writeBlocks(ctx, buf, b.ID, indent); err != nil {
+				return err
+			}
+		default:
+			if err := c.writeBlocks(ctx, buf, b.ID, indent+"  "); err != nil {
+				return err
+			}
+		}
+	}
+	if inList {
+		buf.WriteString("\n")
+	}
+	return nil
+}
+
+// writeTable writes the table's rows as markdown table, with the first row as header, as markdown tables require one
+func (c *Connector) writeTable(ctx context.Context, buf *bytes.Buffer, table block, indent string) error {
+	rows, err := c.listBlocks(ctx, table.ID)
+	if err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cells := make([]string, 0, len(row.Content.Cells))
+		for _, cell := range row.Content.Cells {
+			cells = append(cells, strings.ReplaceAll(markdown(cell), "|", `\|`))
+		}
+		buf.WriteString(indent + "| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			buf.WriteString(indent + strings.Repeat("| --- ", len(cells)) + "|\n")
+		}
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+func link(text, url string) string {
+	switch {
+	case url == "":
+		return text
+	case text == "":
+		return "<" + url + ">"
+	default:
+		return "[" + text + "](" + url + ")"
+	}
+}
+
+// markdown returns the rich text as markdown, with its annotations and links
+func markdown(rt []richText) string {
+	var sb strings.Builder
+	for _, t := range rt {
+		s := t.PlainText
+		if strings.TrimSpace(s) == "" {
+			sb.WriteString(s)
+			continue
+		}
+		if t.Annotations.Code {
+			s = "`" + s + "`"
+		}
+		if t.Annotations.Bold {
+			s = "**" + s + "**"
+		}
+		if t.Annotations.Italic {
+			s = "_" + s + "_"
+		}
+		if t.Annotations.Strikethrough {
+			s = "~~" + s + "~~"
+		}
+		if t.Href != "" {
+			s = "[" + s + "](" + t.Href + ")"
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+func plainText(rt []richText) string {
+	var sb strings.Builder
+	for _, t := range rt {
+		sb.WriteString(t.PlainText)
+	}
+	return sb.String()
+}
@@ -0,0 +1,347 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+)
+
+const (
+	Scheme = "notion"
+
+	// Workspace is the host of the URL of all pages shared with the integration, i.e. notion://workspace
+	Workspace = "workspace"
+
+	// TokenEnv is the environment variable of the integration token or OAuth access token
+	TokenEnv = "NOTION_TOKEN"
+
+	defaultBaseURL = "https://api.notion.com/v1"
+	apiVersion     = "2022-06-28"
+	maxRetries     = 5
+)
+
+const (
+	MetadataKeyPageID     = "notionPageId"     // ID of the page
+	MetadataKeyURL        = "notionUrl"        // link to the page in Notion
+	MetadataKeyTitle      = "notionTitle"      // title of the page
+	MetadataKeyPath       = "notionPath"       // titles of the page's ancestors and the page, e.g. "Engineering/Specs/Roadmap"
+	MetadataKeyParentType = "notionParentType" // type of the page's parent: workspace, page_id, database_id or block_id
+	MetadataKeyParentID   = "notionParentId"   // ID of the page's parent, if it's not the workspace
+)
+
+// Connector syncs the pages shared with the integration (notion://workspace) or the pages of a database (notion://<database-id>).
+// Pages are converted to markdown. As Notion has no changes feed, all pages are listed on every sync, but only pages edited since the
+// previous sync (by their last_edited_time) and pages that weren't listed before are synced, and pages that aren't listed anymore are removed.
+type Connector struct {
+	client     *http.Client
+	baseURL    string
+	token      string
+	databaseID string // empty for the workspace
+	now        func() time.Time
+}
+
+// state is the sync state: the latest last_edited_time and the IDs of the listed pages
+type state struct {
+	LastEditedTime time.Time `json:"lastEditedTime"`
+	Pages          []string  `json:"pages"`
+}
+
+type object struct {
+	Object         string              `json:"object"` // page or database
+	ID             string              `json:"id"`
+	URL            string              `json:"url"`
+	LastEditedTime time.Time           `json:"last_edited_time"`
+	Archived       bool                `json:"archived"`
+	InTrash        bool                `json:"in_trash"`
+	Parent         parent              `json:"parent"`
+	Properties     map[string]property `json:"properties"`
+	Title          []richText          `json:"title"` // of databases
+}
+
+type parent struct {
+	Type       string `json:"type"`
+	PageID     string `json:"page_id"`
+	DatabaseID string `json:"database_id"`
+	BlockID    string `json:"block_id"`
+}
+
+func (p parent) id() string {
+	switch p.Type {
+	case "page_id":
+		return p.PageID
+	case "database_id":
+		return p.DatabaseID
+	case "block_id":
+		return p.BlockID
+	default:
+		return ""
+	}
+}
+
+type list[T any] struct {
+	Results    []T    `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func New(_ context.Context, u *url.URL) (*Connector, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Notion URL %q: expected notion://%s or notion://<database-id>", u, Workspace)
+	}
+	token := os.Getenv(TokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set", TokenEnv)
+	}
+
+	c := &Connector{client: http.DefaultClient, baseURL: defaultBaseURL, token: token, now: time.Now}
+	if u.Host != Workspace {
+		c.databaseID = u.Host
+	}
+	return c, nil
+}
+
+func (c *Connector) Sync(ctx context.Context, rawState string, changed func(types.Document) error, removed func(id string) error) (string, error) {
+	var st state
+	if rawState != "" {
+		if err := json.Unmarshal([]byte(rawState), &st); err != nil {
+			return "", types.ErrStateExpired
+		}
+	}
+	start := c.now()
+
+	objects, err := c.listObjects(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	byID := make(map[string]object, len(objects))
+	for _, o := range objects {
+		byID[o.ID] = o
+	}
+	if c.databaseID != "" {
+		var db object
+		if err := c.do(ctx, http.MethodGet, "/databases/"+url.PathEscape(c.databaseID), nil, &db); err != nil {
+			return "", fmt.Errorf("failed to get database %q: %w", c.databaseID, err)
+		}
+		byID[db.ID] = db
+	}
+
+	previous := make(map[string]struct{}, len(st.Pages))
+	for _, id := range st.Pages {
+		previous[id] = struct{}{}
+	}
+
+	next := state{LastEditedTime: st.LastEditedTime}
+	for _, o := range objects {
+		if o.Object != "page" {
+			continue
+		}
+		next.Pages = append(next.Pages, o.ID)
+		if o.LastEditedTime.After(next.LastEditedTime) {
+			next.LastEditedTime = o.LastEditedTime
+		}
+		_, listedBefore := previous[o.ID]
+		delete(previous, o.ID)
+
+		// Pages edited at the state's last_edited_time are synced again, as they may have been edited again in the same minute.
+		// Pages that weren't listed before are synced regardless, as they may have been shared with the integration since then.
+		if listedBefore && o.LastEditedTime.Before(st.LastEditedTime) {
+			continue
+		}
+		if err := changed(document(o, byID, start)); err != nil {
+			return "", err
+		}
+	}
+
+	for id := range previous {
+		if err := removed(id); err != nil {
+			return "", err
+		}
+	}
+
+	b, err := json.Marshal(next)
+	return string(b), err
+}
+
+// listObjects lists the pages of the database or, for the workspace, all pages and databases shared with the integration
+func (c *Connector) listObjects(ctx context.Context) ([]object, error) {
+	p := "/search"
+	if c.databaseID != "" {
+		p = "/databases/" + url.PathEscape(c.databaseID) + "/query"
+	}
+
+	var objects []object
+	body := map[string]any{"page_size": 100}
+	for {
+		var resp list[object]
+		if err := c.do(ctx, http.MethodPost, p, body, &resp); err != nil {
+			return nil, fmt.Errorf("failed to list pages: %w", err)
+		}
+		for _, o := range resp.Results {
+			if !o.Archived && !o.InTrash {
+				objects = append(objects, o)
+			}
+		}
+		if !resp.HasMore {
+			return objects, nil
+		}
+		body["start_cursor"] = resp.NextCursor
+	}
+}
+
+func (c *Connector) Load(ctx context.Context, doc types.Document) ([]byte, error) {
+	var page object
+	if err := c.do(ctx, http.MethodGet, "/pages/"+url.PathEscape(doc.ID), nil, &page); err != nil {
+		return nil, fmt.Errorf("failed to get page %q: %w", doc.ID, err)
+	}
+
+	var buf bytes.Buffer
+	writePageHeader(&buf, page)
+	if err := c.writeBlocks(ctx, &buf, doc.ID, ""); err != nil {
+		return nil, fmt.Errorf("failed to convert page %q to markdown: %w", doc.ID, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// listBlocks lists the child blocks of the block or page
+func (c *Connector) listBlocks(ctx context.Context, id string) ([]block, error) {
+	var blocks []block
+	q := url.Values{"page_size": {"100"}}
+	for {
+		var resp list[block]
+		if err := c.do(ctx, http.MethodGet, "/blocks/"+url.PathEscape(id)+"/children?"+q.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, resp.Results...)
+		if !resp.HasMore {
+			return blocks, nil
+		}
+		q.Set("start_cursor", resp.NextCursor)
+	}
+}
+
+// document returns the document of the page. Its version is the page's last_edited_time, which only has minute precision:
+// for pages edited less than a minute before the sync started, it's marked as tentative, so that they're synced again
+// on the next sync in case they were edited again in the same minute.
+func document(page object, byID map[string]object, start time.Time) types.Document {
+	title := page.title()
+	version := page.LastEditedTime.UTC().Format(time.RFC3339)
+	if !page.LastEditedTime.Before(start.Add(-time.Minute)) {
+		version += "~"
+	}
+
+	// the ancestors' titles, as far as they're listed
+	elems := []string{title}
+	seen := map[string]struct{}{page.ID: {}}
+	for p := page.Parent; ; {
+		o, ok := byID[p.id()]
+		if _, cycle := seen[o.ID]; !ok || cycle {
+			break
+		}
+		seen[o.ID] = struct{}{}
+		elems = append([]string{o.title()}, elems...)
+		p = o.Parent
+	}
+
+	metadata := map[string]any{
+		MetadataKeyPageID:     page.ID,
+		MetadataKeyURL:        page.URL,
+		MetadataKeyTitle:      title,
+		MetadataKeyPath:       strings.Join(elems, "/"),
+		MetadataKeyParentType: page.Parent.Type,
+	}
+	if id := page.Parent.id(); id != "" {
+		metadata[MetadataKeyParentID] = id
+	}
+
+	return types.Document{
+		ID:         page.ID,
+		Name:       strings.ReplaceAll(title, "/", "-") + ".md",
+		ModifiedAt: page.LastEditedTime,
+		Version:    version,
+		Metadata:   metadata,
+	}
+}
+
+// title returns the title of the page or database
+func (o object) title() string {
+	t := o.Title
+	for _, p := range o.Properties {
+		if p.Type == "title" {
+			t = p.Title
+			break
+		}
+	}
+	if s := strings.TrimSpace(plainText(t)); s != "" {
+		return s
+	}
+	return "Untitled"
+}
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+// do sends the request with the JSON-encoded body, if not nil, and decodes the response into v.
+// Rate-limited requests are retried after the time given by the API.
+func (c *Connector) do(ctx context.Context, method, p string, body any, v any) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+p, bytes.NewReader(reqBody))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		req.Header.Set("Notion-Version", apiVersion)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRetries {
+			resp.Body.Close()
+			wait := time.Second
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return &statusError{status: resp.StatusCode, body: strings.TrimSpace(string(b))}
+		}
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+}
@@ -0,0 +1,147 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	titleProp := func(title string) string {
+		return `{"title": {"type": "title", "title": [{"plain_text": "` + title + `"}]}}`
+	}
+	pages := map[string]string{
+		"eng":     `{"object": "page", "id": "eng", "url": "https://www.notion.so/eng", "last_edited_time": "2024-05-01T10:00:00.000Z", "parent": {"type": "workspace", "workspace": true}, "properties": ` + titleProp("Engineering") + `}`,
+		"roadmap": `{"object": "page", "id": "roadmap", "url": "https://www.notion.so/roadmap", "last_edited_time": "2024-05-02T10:00:00.000Z", "parent": {"type": "page_id", "page_id": "eng"}, "properties": ` + titleProp("Q3/Q4 Roadmap") + `}`,
+		"old":     `{"object": "page", "id": "old", "last_edited_time": "2024-04-01T10:00:00.000Z", "parent": {"type": "database_id", "database_id": "tasks"}, "properties": {"Name": {"type": "title", "title": [{"plain_text": "Old task"}]}, "Status": {"type": "status", "status": {"name": "Done"}}, "Estimate": {"type": "number", "number": 3}}}`,
+		"tasks":   `{"object": "database", "id": "tasks", "last_edited_time": "2024-04-01T10:00:00.000Z", "parent": {"type": "page_id", "page_id": "eng"}, "title": [{"plain_text": "Tasks"}]}`,
+		"new":     `{"object": "page", "id": "new", "last_edited_time": "2024-04-01T10:00:00.000Z", "parent": {"type": "workspace", "workspace": true}, "properties": ` + titleProp("Shared later") + `}`,
+	}
+	search := [][]string{{"eng", "roadmap", "old", "tasks"}}
+
+	blocks := map[string]string{
+		"roadmap": `[
+			{"id": "h", "type": "heading_1", "heading_1": {"rich_text": [{"plain_text": "Goals"}]}},
+			{"id": "p", "type": "paragraph", "paragraph": {"rich_text": [{"plain_text": "Ship "}, {"plain_text": "connectors", "annotations": {"bold": true}}, {"plain_text": " faster", "href": "https://example.com"}]}},
+			{"id": "l1", "type": "bulleted_list_item", "has_children": true, "bulleted_list_item": {"rich_text": [{"plain_text": "Drive"}]}},
+			{"id": "l2", "type": "to_do", "to_do": {"rich_text": [{"plain_text": "Notion"}], "checked": true}},
+			{"id": "c", "type": "code", "code": {"rich_text": [{"plain_text": "knowledge ingest notion://workspace"}], "language": "shell"}},
+			{"id": "t", "type": "table", "has_children": true, "table": {"table_width": 2}},
+			{"id": "s", "type": "synced_block", "synced_block": {"synced_from": {"block_id": "orig"}}},
+			{"id": "cp", "type": "child_page", "has_children": true, "child_page": {"title": "Sub page"}}
+		]`,
+		"l1": `[{"id": "l1a", "type": "bulleted_list_item", "bulleted_list_item": {"rich_text": [{"plain_text": "folders"}]}}]`,
+		"t": `[
+			{"id": "r1", "type": "table_row", "table_row": {"cells": [[{"plain_text": "Source"}], [{"plain_text": "Status"}]]}},
+			{"id": "r2", "type": "table_row", "table_row": {"cells": [[{"plain_text": "Drive"}], [{"plain_text": "a|b"}]]}}
+		]`,
+		"orig": `[{"id": "q", "type": "quote", "quote": {"rich_text": [{"plain_text": "synced"}]}}]`,
+		"old":  `[]`,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, apiVersion, r.Header.Get("Notion-Version"))
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/search":
+			var req map[string]any
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			// paginated: two pages of results
+			results := search[0]
+			var resp string
+			if req["start_cursor"] == nil {
+				resp = `{"results": [` + pages[results[0]] + `], "has_more": true, "next_cursor": "c1"}`
+			} else {
+				var items []string
+				for _, id := range results[1:] {
+					items = append(items, pages[id])
+				}
+				resp = `{"results": [` + strings.Join(items, ",") + `], "has_more": false}`
+			}
+			_, _ = w.Write([]byte(resp))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/pages/"):
+			_, _ = w.Write([]byte(pages[strings.TrimPrefix(r.URL.Path, "/pages/")]))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/blocks/"):
+			id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/blocks/"), "/children")
+			_, _ = w.Write([]byte(`{"results": ` + blocks[id] + `, "has_more": false}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	now := time.Date(2024, 5, 2, 10, 0, 30, 0, time.UTC)
+	c := &Connector{client: srv.Client(), baseURL: srv.URL, token: "token", now: func() time.Time { return now }}
+
+	var docs []types.Document
+	var removed []string
+	collect := func(doc types.Document) error {
+		docs = append(docs, doc)
+		return nil
+	}
+	remove := func(id string) error {
+		removed = append(removed, id)
+		return nil
+	}
+
+	// full sync
+	st, err := c.Sync(ctx, "", collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, "Engineering.md", docs[0].Name)
+	assert.Equal(t, "2024-05-01T10:00:00Z", docs[0].Version)
+	assert.Equal(t, "Q3-Q4 Roadmap.md", docs[1].Name)
+	assert.Equal(t, "2024-05-02T10:00:00Z~", docs[1].Version, "edited less than a minute before the sync")
+	assert.Equal(t, "Engineering/Q3/Q4 Roadmap", docs[1].Metadata[MetadataKeyPath])
+	assert.Equal(t, "eng", docs[1].Metadata[MetadataKeyParentID])
+	assert.Equal(t, "page_id", docs[1].Metadata[MetadataKeyParentType])
+	assert.Equal(t, "Engineering/Tasks/Old task", docs[2].Metadata[MetadataKeyPath])
+	assert.Empty(t, removed)
+
+	content, err := c.Load(ctx, docs[1])
+	require.NoError(t, err)
+	assert.Equal(t, "# Q3/Q4 Roadmap\n\n"+
+		"## Goals\n\n"+
+		"Ship **connectors**[ faster](https://example.com)\n\n"+
+		"- Drive\n"+
+		"  - folders\n\n"+
+		"- [x] Notion\n\n"+
+		"```shell\nknowledge ingest notion://workspace\n```\n\n"+
+		"| Source | Status |\n| --- | --- |\n| Drive | a\\|b |\n\n"+
+		"> synced\n\n"+
+		"**Sub page**\n\n", string(content))
+
+	content, err = c.Load(ctx, docs[2])
+	require.NoError(t, err)
+	assert.Equal(t, "# Old task\n\n- Estimate: 3\n- Status: Done\n\n", string(content))
+
+	// incremental sync: only pages edited at or after the last sync's last_edited_time and newly listed pages
+	search[0] = []string{"eng", "roadmap", "new"}
+	now = now.Add(time.Hour)
+	docs = nil
+	st, err = c.Sync(ctx, st, collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "roadmap", docs[0].ID)
+	assert.Equal(t, "2024-05-02T10:00:00Z", docs[0].Version)
+	assert.Equal(t, "new", docs[1].ID)
+	assert.Equal(t, []string{"old"}, removed)
+
+	var s state
+	require.NoError(t, json.Unmarshal([]byte(st), &s))
+	assert.Equal(t, []string{"eng", "roadmap", "new"}, s.Pages)
+	assert.Equal(t, time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC), s.LastEditedTime.UTC())
+
+	_, err = c.Sync(ctx, "invalid", collect, remove)
+	assert.ErrorIs(t, err, types.ErrStateExpired)
+}
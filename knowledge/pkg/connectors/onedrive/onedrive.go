@@ -0,0 +1,368 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+)
+
+const (
+	// SchemeOneDrive is the scheme of onedrive://me[/<folder-path>] URLs for the user's OneDrive
+	// and onedrive://<drive-id>[/<folder-path>] URLs for any drive, e.g. a SharePoint document library
+	SchemeOneDrive = "onedrive"
+	// SchemeSharePoint is the scheme of sharepoint://<hostname>/sites/<site>[/<library>[/<folder-path>]] URLs for SharePoint document libraries
+	SchemeSharePoint = "sharepoint"
+
+	// CredentialEnv is the environment variable of a Microsoft Graph access token, as for the Outlook and Word tools.
+	// If not set, the default Azure credential is used, e.g. an app registration in AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET.
+	CredentialEnv = "GPTSCRIPT_GRAPH_MICROSOFT_COM_BEARER_TOKEN"
+
+	defaultBaseURL = "https://graph.microsoft.com/v1.0"
+	scope          = "https://graph.microsoft.com/.default"
+	maxRetries     = 5
+	itemFields     = "id,name,size,lastModifiedDateTime,eTag,cTag,webUrl,file,folder,package,deleted,root,parentReference"
+)
+
+const (
+	MetadataKeyItemID  = "oneDriveItemId"  // ID of the drive item
+	MetadataKeyDriveID = "oneDriveDriveId" // ID of the drive
+	MetadataKeyURL     = "oneDriveUrl"     // link to the file in OneDrive or SharePoint
+	MetadataKeyPath    = "oneDrivePath"    // path of the file in the synced folder, e.g. "Specs/Roadmap.docx"
+)
+
+// StaticTokenCredential is an azcore.TokenCredential with a fixed access token
+type StaticTokenCredential struct {
+	token string
+}
+
+func (s StaticTokenCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: s.token}, nil
+}
+
+// Connector syncs the files in a folder of a OneDrive or SharePoint drive and its subfolders.
+// It uses delta queries, so after the first sync only changes are synced.
+type Connector struct {
+	client     *http.Client
+	baseURL    string
+	credential azcore.TokenCredential
+	drive      string // API path of the drive, e.g. /me/drive, empty for a SharePoint site
+	site       string // API path of the SharePoint site, e.g. /sites/<hostname>:/sites/<site>:
+	library    string // name of the SharePoint document library, empty for the site's default one
+	folder     string // path of the folder in the drive, empty for the root folder
+}
+
+// state is the sync state: the delta link and the synced folders, by ID
+type state struct {
+	DeltaLink string            `json:"deltaLink"`
+	Folders   map[string]string `json:"folders"` // paths of the folders in the synced folder
+}
+
+type item struct {
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name"`
+	Size                 int64           `json:"size"`
+	LastModifiedDateTime time.Time       `json:"lastModifiedDateTime"`
+	ETag                 string          `json:"eTag"`
+	CTag                 string          `json:"cTag"`
+	WebURL               string          `json:"webUrl"`
+	File                 json.RawMessage `json:"file"`
+	Folder               json.RawMessage `json:"folder"`
+	Package              json.RawMessage `json:"package"`
+	Deleted              json.RawMessage `json:"deleted"`
+	Root                 json.RawMessage `json:"root"`
+	ParentReference      struct {
+		DriveID string `json:"driveId"`
+		ID      string `json:"id"`
+	} `json:"parentReference"`
+}
+
+func New(_ context.Context, u *url.URL) (*Connector, error) {
+	c := &Connector{client: http.DefaultClient, baseURL: defaultBaseURL}
+
+	elems := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch u.Scheme {
+	case SchemeOneDrive:
+		switch u.Host {
+		case "":
+			return nil, fmt.Errorf("invalid OneDrive URL %q: expected onedrive://me[/<folder-path>] or onedrive://<drive-id>[/<folder-path>]", u)
+		case "me":
+			c.drive = "/me/drive"
+		default:
+			c.drive = "/drives/" + url.PathEscape(u.Host)
+		}
+		c.folder = strings.Join(elems, "/")
+	case SchemeSharePoint:
+		if u.Host == "" || len(elems) < 2 || elems[0] != "sites" {
+			return nil, fmt.Errorf("invalid SharePoint URL %q: expected sharepoint://<hostname>/sites/<site>[/<library>[/<folder-path>]]", u)
+		}
+		c.site = "/sites/" + u.Host + ":/sites/" + url.PathEscape(elems[1]) + ":"
+		if len(elems) > 2 {
+			c.library = elems[2]
+			c.folder = strings.Join(elems[3:], "/")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported URL %q", u)
+	}
+
+	if token := os.Getenv(CredentialEnv); token != "" {
+		c.credential = StaticTokenCredential{token: token}
+	} else {
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("no Microsoft Graph credentials found (set %s or the Azure credential environment variables): %w", CredentialEnv, err)
+		}
+		c.credential = cred
+	}
+	return c, nil
+}
+
+func (c *Connector) Sync(ctx context.Context, rawState string, changed func(types.Document) error, removed func(id string) error) (string, error) {
+	var st state
+	var link string
+	if rawState == "" {
+		driveID, err := c.driveID(ctx)
+		if err != nil {
+			return "", err
+		}
+		folderPath := "/drives/" + url.PathEscape(driveID) + "/root"
+		if c.folder != "" {
+			folderPath += ":/" + pathEscape(c.folder) + ":"
+		}
+		var folder item
+		if err := c.get(ctx, c.baseURL+folderPath, &folder); err != nil {
+			return "", fmt.Errorf("failed to get folder %q: %w", c.folder, err)
+		}
+		if folder.Folder == nil {
+			return "", fmt.Errorf("%q is not a folder", c.folder)
+		}
+		st.Folders = map[string]string{folder.ID: ""}
+
+		// Delta queries are only supported on the root folder of OneDrive for Business and SharePoint drives,
+		// so the changes are filtered by the folders in the synced folder
+		link = c.baseURL + "/drives/" + url.PathEscape(driveID) + "/root/delta?" + url.Values{"$select": {itemFields}}.Encode()
+	} else {
+		if err := json.Unmarshal([]byte(rawState), &st); err != nil || st.DeltaLink == "" {
+			return "", types.ErrStateExpired
+		}
+		link = st.DeltaLink
+	}
+
+	var items []item
+	for link != "" {
+		var resp struct {
+			Value     []item `json:"value"`
+			NextLink  string `json:"@odata.nextLink"`
+			DeltaLink string `json:"@odata.deltaLink"`
+		}
+		if err := c.get(ctx, link, &resp); err != nil {
+			var se *statusError
+			if errors.As(err, &se) && se.status == http.StatusGone {
+				return "", types.ErrStateExpired
+			}
+			return "", fmt.Errorf("failed to get changes: %w", err)
+		}
+		items = append(items, resp.Value...)
+		link = resp.NextLink
+		st.DeltaLink = resp.DeltaLink
+	}
+
+	// Folders first, as files may be listed before the new folders they're in. Changed folders are resolved again,
+	// as they may have been renamed or moved, until no more are found, as subfolders may be listed before their parent.
+	for _, it := range items {
+		if p, ok := st.Folders[it.ID]; ok && p != "" && it.Folder != nil {
+			delete(st.Folders, it.ID)
+		}
+	}
+	for added := true; added; {
+		added = false
+		for _, it := range items {
+			if it.Deleted != nil || it.Folder == nil || it.Root != nil {
+				continue
+			}
+			if _, ok := st.Folders[it.ID]; ok {
+				continue
+			}
+			if parent, ok := st.Folders[it.ParentReference.ID]; ok {
+				st.Folders[it.ID] = path.Join(parent, it.Name)
+				added = true
+			}
+		}
+	}
+
+	for _, it := range items {
+		if it.Deleted != nil {
+			delete(st.Folders, it.ID)
+			if err := removed(it.ID); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if it.File == nil || it.Package != nil {
+			continue // folders and packages, e.g. OneNote notebooks
+		}
+		folderPath, ok := st.Folders[it.ParentReference.ID]
+		if !ok {
+			// outside of or moved out of the synced folder
+			if err := removed(it.ID); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := changed(document(it, folderPath)); err != nil {
+			return "", err
+		}
+	}
+
+	b, err := json.Marshal(st)
+	return string(b), err
+}
+
+// driveID returns the ID of the drive, resolving the document library of the SharePoint site
+func (c *Connector) driveID(ctx context.Context) (string, error) {
+	type drive struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		WebURL string `json:"webUrl"`
+	}
+
+	if c.site == "" || c.library == "" {
+		u := c.baseURL + c.drive
+		if c.site != "" {
+			u = c.baseURL + c.site + "/drive"
+		}
+		var d drive
+		if err := c.get(ctx, u, &d); err != nil {
+			return "", fmt.Errorf("failed to get drive: %w", err)
+		}
+		return d.ID, nil
+	}
+
+	var drives struct {
+		Value []drive `json:"value"`
+	}
+	if err := c.get(ctx, c.baseURL+c.site+"/drives", &drives); err != nil {
+		return "", fmt.Errorf("failed to list document libraries: %w", err)
+	}
+	// the library is given by its name, e.g. "Documents", or by its URL path, e.g. "Shared Documents"
+	i := slices.IndexFunc(drives.Value, func(d drive) bool {
+		return strings.EqualFold(d.Name, c.library) || strings.EqualFold(path.Base(d.WebURL), url.PathEscape(c.library)) ||
+			strings.EqualFold(path.Base(d.WebURL), c.library)
+	})
+	if i < 0 {
+		return "", fmt.Errorf("document library %q not found", c.library)
+	}
+	return drives.Value[i].ID, nil
+}
+
+func (c *Connector) Load(ctx context.Context, doc types.Document) ([]byte, error) {
+	driveID, _ := doc.Metadata[MetadataKeyDriveID].(string)
+	resp, err := c.do(ctx, c.baseURL+"/drives/"+url.PathEscape(driveID)+"/items/"+url.PathEscape(doc.ID)+"/content")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file %q: %w", doc.ID, err)
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func document(it item, folderPath string) types.Document {
+	// the cTag only changes when the content changes, unlike the eTag
+	version := it.CTag
+	if version == "" {
+		version = it.ETag
+	}
+	return types.Document{
+		ID:         it.ID,
+		Name:       it.Name,
+		Size:       it.Size,
+		ModifiedAt: it.LastModifiedDateTime,
+		Version:    version,
+		Metadata: map[string]any{
+			MetadataKeyItemID:  it.ID,
+			MetadataKeyDriveID: it.ParentReference.DriveID,
+			MetadataKeyURL:     it.WebURL,
+			MetadataKeyPath:    path.Join(folderPath, it.Name),
+		},
+	}
+}
+
+// pathEscape escapes the elements of the path
+func pathEscape(p string) string {
+	elems := strings.Split(p, "/")
+	for i, e := range elems {
+		elems[i] = url.PathEscape(e)
+	}
+	return strings.Join(elems, "/")
+}
+
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.status, e.body)
+}
+
+func (c *Connector) get(ctx context.Context, u string, v any) error {
+	resp, err := c.do(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// do sends a GET request with an access token. Throttled requests are retried after the time given by the API.
+func (c *Connector) do(ctx context.Context, u string) (*http.Response, error) {
+	token, err := c.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{scope}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Token)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) && attempt < maxRetries {
+			resp.Body.Close()
+			wait := time.Second << attempt
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(s) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return nil, &statusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+		}
+		return resp, nil
+	}
+}
@@ -0,0 +1,157 @@
+package onedrive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/connectors/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Setenv(CredentialEnv, "token")
+
+	for _, tc := range []struct {
+		url, drive, site, library, folder string
+	}{
+		{url: "onedrive://me", drive: "/me/drive"},
+		{url: "onedrive://me/Documents/Specs", drive: "/me/drive", folder: "Documents/Specs"},
+		{url: "onedrive://b!abc/Specs", drive: "/drives/b%21abc", folder: "Specs"},
+		{url: "sharepoint://acme.sharepoint.com/sites/eng", site: "/sites/acme.sharepoint.com:/sites/eng:"},
+		{url: "sharepoint://acme.sharepoint.com/sites/eng/Shared Documents/Specs/2024", site: "/sites/acme.sharepoint.com:/sites/eng:", library: "Shared Documents", folder: "Specs/2024"},
+	} {
+		u, err := url.Parse(tc.url)
+		require.NoError(t, err)
+		c, err := New(context.Background(), u)
+		require.NoError(t, err, tc.url)
+		assert.Equal(t, tc.drive, c.drive, tc.url)
+		assert.Equal(t, tc.site, c.site, tc.url)
+		assert.Equal(t, tc.library, c.library, tc.url)
+		assert.Equal(t, tc.folder, c.folder, tc.url)
+	}
+
+	for _, rawURL := range []string{"onedrive:///Specs", "sharepoint://acme.sharepoint.com", "sharepoint://acme.sharepoint.com/teams/eng"} {
+		u, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		_, err = New(context.Background(), u)
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestSync(t *testing.T) {
+	var srvURL string
+	// delta responses by delta token
+	delta := map[string][]string{
+		"": {
+			`{"id": "root", "name": "root", "root": {}, "folder": {}}`,
+			`{"id": "f2", "name": "Roadmap.docx", "size": 10, "cTag": "c1", "eTag": "e1", "webUrl": "https://acme.sharepoint.com/Specs/Q3/Roadmap.docx", "file": {}, "lastModifiedDateTime": "2024-05-01T10:00:00Z", "parentReference": {"driveId": "d1", "id": "q3"}}`,
+			`{"id": "q3", "name": "Q3", "folder": {}, "parentReference": {"driveId": "d1", "id": "specs"}}`,
+			`{"id": "specs", "name": "Specs", "folder": {}, "parentReference": {"driveId": "d1", "id": "root"}}`,
+		},
+		"page2": {
+			`{"id": "f1", "name": "Notes.txt", "size": 5, "cTag": "c1", "file": {}, "parentReference": {"driveId": "d1", "id": "specs"}}`,
+			`{"id": "other", "name": "Other.txt", "file": {}, "parentReference": {"driveId": "d1", "id": "root"}}`,
+			`{"id": "nb", "name": "Notebook", "package": {"type": "oneNote"}, "file": {}, "parentReference": {"driveId": "d1", "id": "specs"}}`,
+		},
+	}
+	expired := false
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/sites/acme.sharepoint.com:/sites/eng:/drives":
+			_, _ = w.Write([]byte(`{"value": [{"id": "d0", "name": "Archive"}, {"id": "d1", "name": "Documents", "webUrl": "https://acme.sharepoint.com/sites/eng/Shared%20Documents"}]}`))
+		case "/drives/d1/root:/Specs:":
+			_, _ = w.Write([]byte(`{"id": "specs", "name": "Specs", "folder": {}}`))
+		case "/drives/d1/root/delta":
+			if expired {
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+			token := r.URL.Query().Get("token")
+			resp := map[string]any{"value": json.RawMessage("[" + strings.Join(delta[token], ",") + "]")}
+			if token == "" {
+				assert.Equal(t, itemFields, r.URL.Query().Get("$select"))
+				resp["@odata.nextLink"] = srvURL + "/drives/d1/root/delta?token=page2"
+			} else {
+				resp["@odata.deltaLink"] = srvURL + "/drives/d1/root/delta?token=next" + token
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case "/drives/d1/items/f2/content":
+			_, _ = w.Write([]byte("roadmap"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	ctx := context.Background()
+	c := &Connector{
+		client:     srv.Client(),
+		baseURL:    srv.URL,
+		credential: StaticTokenCredential{token: "token"},
+		site:       "/sites/acme.sharepoint.com:/sites/eng:",
+		library:    "Shared Documents",
+		folder:     "Specs",
+	}
+
+	var docs []types.Document
+	var removed []string
+	collect := func(doc types.Document) error {
+		docs = append(docs, doc)
+		return nil
+	}
+	remove := func(id string) error {
+		removed = append(removed, id)
+		return nil
+	}
+
+	// full sync: files in the folder and its subfolders, listed before their folders
+	st, err := c.Sync(ctx, "", collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "Roadmap.docx", docs[0].Name)
+	assert.Equal(t, "c1", docs[0].Version)
+	assert.Equal(t, "Q3/Roadmap.docx", docs[0].Metadata[MetadataKeyPath])
+	assert.Equal(t, "d1", docs[0].Metadata[MetadataKeyDriveID])
+	assert.Equal(t, "https://acme.sharepoint.com/Specs/Q3/Roadmap.docx", docs[0].Metadata[MetadataKeyURL])
+	assert.Equal(t, "Notes.txt", docs[1].Name)
+	assert.Equal(t, []string{"other"}, removed)
+
+	content, err := c.Load(ctx, docs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "roadmap", string(content))
+
+	// incremental sync: Q3 renamed, a file deleted and a file moved out of the folder
+	delta["nextpage2"] = []string{
+		`{"id": "q3", "name": "Q3 2024", "folder": {}, "parentReference": {"driveId": "d1", "id": "specs"}}`,
+		`{"id": "f3", "name": "Plan.md", "cTag": "c1", "file": {}, "parentReference": {"driveId": "d1", "id": "q3"}}`,
+		`{"id": "f1", "deleted": {"state": "deleted"}, "parentReference": {"driveId": "d1"}}`,
+		`{"id": "f2", "name": "Roadmap.docx", "cTag": "c2", "file": {}, "parentReference": {"driveId": "d1", "id": "root"}}`,
+	}
+	docs, removed = nil, nil
+	st, err = c.Sync(ctx, st, collect, remove)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "Q3 2024/Plan.md", docs[0].Metadata[MetadataKeyPath])
+	assert.Equal(t, []string{"f1", "f2"}, removed)
+
+	var s state
+	require.NoError(t, json.Unmarshal([]byte(st), &s))
+	assert.Equal(t, srv.URL+"/drives/d1/root/delta?token=nextnextpage2", s.DeltaLink)
+	assert.Equal(t, map[string]string{"specs": "", "q3": "Q3 2024"}, s.Folders)
+
+	expired = true
+	_, err = c.Sync(ctx, st, collect, remove)
+	assert.ErrorIs(t, err, types.ErrStateExpired)
+
+	_, err = c.Sync(ctx, "invalid", collect, remove)
+	assert.ErrorIs(t, err, types.ErrStateExpired)
+}
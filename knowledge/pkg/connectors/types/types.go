@@ -0,0 +1,31 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrStateExpired is returned by Connector.Sync if the sync state can't be used anymore, e.g. an expired change token,
+// so that all documents are synced again
+var ErrStateExpired = errors.New("sync state expired")
+
+// Document is a document in a connector's source, e.g. a Google Doc
+type Document struct {
+	ID         string // ID in the source
+	Name       string // file name with the extension of the format returned by Connector.Load, e.g. "Roadmap.md"
+	Size       int64  // 0 if unknown
+	ModifiedAt time.Time
+	Version    string         // changes whenever the content changes, e.g. a revision ID, used to skip unchanged documents
+	Metadata   map[string]any // source-specific metadata, e.g. the URL of the document
+}
+
+// Connector syncs documents from a source, e.g. a Google Drive folder
+type Connector interface {
+	// Sync lists the documents changed since the state returned by the previous sync, or all documents if the state is empty.
+	// It calls changed for the changed documents and removed for the IDs of removed documents (possibly of documents that were never synced)
+	// and returns the state for the next sync.
+	Sync(ctx context.Context, state string, changed func(Document) error, removed func(id string) error) (string, error)
+	// Load returns the content of the document, in the format of its name's extension
+	Load(ctx context.Context, doc Document) ([]byte, error)
+}
@@ -0,0 +1,351 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	DefaultUserAgent   = "knowledge-crawler/1.0 (+https://github.com/obot-platform/tools)"
+	defaultMaxPageSize = 20 << 20
+)
+
+// ErrDisallowed is returned when fetching a URL disallowed by the robots.txt of its host
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+type Options struct {
+	MaxDepth       int              // maximum number of links followed from the start URL (0 = only the start page)
+	MaxPages       int              // maximum number of pages visited (0 = unlimited)
+	AllowedDomains []string         // hosts to follow links to, e.g. "docs.example.com" or "*.example.com" for all subdomains (default: the host of the start URL)
+	PathPrefixes   []string         // only follow links to URLs with one of these path prefixes, e.g. "/docs/" (default: all paths)
+	Include        []*regexp.Regexp // only visit URLs matching one of these patterns (default: all URLs) - the start URL is always visited
+	Exclude        []*regexp.Regexp // don't visit URLs matching any of these patterns
+	IgnoreRobots   bool             // ignore robots.txt and robots meta tags
+	UserAgent      string           // sent with all requests and matched against the robots.txt groups (default: DefaultUserAgent)
+	Delay          time.Duration    // minimum delay between requests to the same host - a longer robots.txt crawl-delay takes precedence
+	MaxPageSize    int64            // pages larger than this are skipped (default: 20MB)
+	HTTPClient     *http.Client     // default: http.DefaultClient
+}
+
+// Page is a fetched page (or any other document linked from a page, e.g. a PDF)
+type Page struct {
+	URL          string    // after redirects
+	Depth        int       // number of links followed from the start URL
+	ContentType  string    // media type without parameters, e.g. "text/html"
+	Content      []byte    // unmodified response body
+	FetchedAt    time.Time // time of the request
+	LastModified time.Time // from the Last-Modified header, zero if not sent
+}
+
+// VisitFunc is called for every fetched page - returning an error stops the crawl
+type VisitFunc func(ctx context.Context, page Page) error
+
+// Crawler crawls a website breadth-first from a start URL, following links within the allowed domains and path prefixes.
+// Requests are sequential and respect the robots.txt of each host, unless ignored.
+type Crawler struct {
+	opts        Options
+	client      *http.Client
+	robots      map[string]*robots   // by scheme and host
+	lastRequest map[string]time.Time // by host
+}
+
+func New(opts Options) *Crawler {
+	if opts.UserAgent == "" {
+		opts.UserAgent = DefaultUserAgent
+	}
+	if opts.MaxPageSize <= 0 {
+		opts.MaxPageSize = defaultMaxPageSize
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Crawler{
+		opts:        opts,
+		client:      client,
+		robots:      map[string]*robots{},
+		lastRequest: map[string]time.Time{},
+	}
+}
+
+type queued struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl visits the start URL and the pages linked from it and returns the number of visited pages.
+// Pages that can't be fetched are skipped with a warning, only failing to fetch the start URL is an error.
+func (c *Crawler) Crawl(ctx context.Context, startURL string, visit VisitFunc) (int, error) {
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid start URL %q: %w", startURL, err)
+	}
+	if start.Scheme != "http" && start.Scheme != "https" {
+		return 0, fmt.Errorf("invalid start URL %q: only http and https are supported", startURL)
+	}
+	start = normalize(start)
+
+	allowedDomains := c.opts.AllowedDomains
+	if len(allowedDomains) == 0 {
+		allowedDomains = []string{start.Hostname()}
+	}
+
+	seen := map[string]struct{}{start.String(): {}}
+	queue := []queued{{url: start}}
+	visited := 0
+
+	for len(queue) > 0 {
+		if c.opts.MaxPages > 0 && visited >= c.opts.MaxPages {
+			slog.Info("Reached maximum number of pages, stopping crawl", "maxPages", c.opts.MaxPages)
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return visited, err
+		}
+
+		next := queue[0]
+		queue = queue[1:]
+
+		page, err := c.fetch(ctx, next.url)
+		if errors.Is(err, ErrDisallowed) {
+			slog.Debug("Skipping URL disallowed by robots.txt", "url", next.url.String())
+			continue
+		}
+		if err != nil {
+			if next.depth == 0 {
+				return visited, err
+			}
+			slog.Warn("Failed to fetch page, skipping", "url", next.url.String(), "error", err)
+			continue
+		}
+		page.Depth = next.depth
+
+		final, _ := url.Parse(page.URL)
+		if final != nil {
+			seen[normalize(final).String()] = struct{}{}
+		}
+
+		var links []*url.URL
+		index, follow := true, true
+		if page.ContentType == "text/html" || page.ContentType == "application/xhtml+xml" {
+			links, index, follow = extractLinks(page.Content, final)
+			if c.opts.IgnoreRobots {
+				index, follow = true, true
+			}
+		}
+
+		if index {
+			visited++
+			if err := visit(ctx, *page); err != nil {
+				return visited, err
+			}
+		}
+
+		if !follow || next.depth >= c.opts.MaxDepth {
+			continue
+		}
+		for _, link := range links {
+			if _, ok := seen[link.String()]; ok || !c.inScope(link, allowedDomains) {
+				continue
+			}
+			seen[link.String()] = struct{}{}
+			queue = append(queue, queued{url: link, depth: next.depth + 1})
+		}
+	}
+
+	return visited, nil
+}
+
+func (c *Crawler) inScope(u *url.URL, allowedDomains []string) bool {
+	host := u.Hostname()
+	if !slices.ContainsFunc(allowedDomains, func(d string) bool {
+		d = strings.ToLower(d)
+		if suffix, ok := strings.CutPrefix(d, "*."); ok {
+			return host == suffix || strings.HasSuffix(host, "."+suffix)
+		}
+		return host == d
+	}) {
+		return false
+	}
+	if len(c.opts.PathPrefixes) > 0 && !slices.ContainsFunc(c.opts.PathPrefixes, func(p string) bool { return strings.HasPrefix(u.Path, p) }) {
+		return false
+	}
+	return c.opts.MatchesPatterns(u.String())
+}
+
+// MatchesPatterns reports whether the URL matches one of the Include patterns (if any) and none of the Exclude patterns
+func (o *Options) MatchesPatterns(rawURL string) bool {
+	if len(o.Include) > 0 && !slices.ContainsFunc(o.Include, func(re *regexp.Regexp) bool { return re.MatchString(rawURL) }) {
+		return false
+	}
+	return !slices.ContainsFunc(o.Exclude, func(re *regexp.Regexp) bool { return re.MatchString(rawURL) })
+}
+
+// Fetch fetches a single page, respecting the robots.txt (ErrDisallowed) and the delay between requests to the same host
+func (c *Crawler) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	return c.fetch(ctx, normalize(u))
+}
+
+// wait delays the request to the host until the configured delay (or the robots.txt crawl-delay) passed since the previous one
+func (c *Crawler) wait(ctx context.Context, u *url.URL) error {
+	delay := c.opts.Delay
+	if r := c.robots[u.Scheme+"://"+u.Host]; r != nil && !c.opts.IgnoreRobots {
+		delay = max(delay, r.crawlDelay)
+	}
+	if last, ok := c.lastRequest[u.Host]; ok && delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(last.Add(delay))):
+		}
+	}
+	c.lastRequest[u.Host] = time.Now()
+	return nil
+}
+
+func (c *Crawler) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	if err := c.wait(ctx, u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	return c.client.Do(req)
+}
+
+func (c *Crawler) fetch(ctx context.Context, u *url.URL) (*Page, error) {
+	if !c.opts.IgnoreRobots && !c.robotsFor(ctx, u).allowed(u.RequestURI()) {
+		return nil, fmt.Errorf("%w: %s", ErrDisallowed, u.String())
+	}
+
+	fetchedAt := time.Now()
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", u.String(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %q: %s", u.String(), resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, c.opts.MaxPageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", u.String(), err)
+	}
+	if int64(len(content)) > c.opts.MaxPageSize {
+		return nil, fmt.Errorf("page %q is larger than %d bytes", u.String(), c.opts.MaxPageSize)
+	}
+
+	page := &Page{
+		URL:       resp.Request.URL.String(),
+		Content:   content,
+		FetchedAt: fetchedAt,
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		page.ContentType = mediaType
+	} else {
+		page.ContentType = http.DetectContentType(content)
+		page.ContentType, _, _ = strings.Cut(page.ContentType, ";")
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		page.LastModified = lm
+	}
+	return page, nil
+}
+
+// robotsFor returns the robots.txt rules of the URL's host, fetching them on first use.
+// A missing or unreadable robots.txt allows everything.
+func (c *Crawler) robotsFor(ctx context.Context, u *url.URL) *robots {
+	key := u.Scheme + "://" + u.Host
+	if r, ok := c.robots[key]; ok {
+		return r
+	}
+
+	r := &robots{}
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	resp, err := c.get(ctx, robotsURL)
+	if err != nil {
+		slog.Debug("Failed to fetch robots.txt, allowing all", "url", robotsURL.String(), "error", err)
+	} else {
+		if resp.StatusCode == http.StatusOK {
+			r = parseRobots(io.LimitReader(resp.Body, 1<<20), c.opts.UserAgent)
+		}
+		resp.Body.Close()
+	}
+	c.robots[key] = r
+	return r
+}
+
+// extractLinks returns the absolute http(s) links of the HTML page and whether the robots meta tag allows indexing
+// the page and following its links
+func extractLinks(content []byte, pageURL *url.URL) (links []*url.URL, index bool, follow bool) {
+	index, follow = true, true
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(content))
+	if err != nil || pageURL == nil {
+		return nil, index, follow
+	}
+
+	doc.Find(`meta[name="robots" i]`).Each(func(_ int, s *goquery.Selection) {
+		for _, directive := range strings.Split(strings.ToLower(s.AttrOr("content", "")), ",") {
+			switch strings.TrimSpace(directive) {
+			case "noindex":
+				index = false
+			case "nofollow":
+				follow = false
+			case "none":
+				index, follow = false, false
+			}
+		}
+	})
+
+	base := pageURL
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if b, err := pageURL.Parse(strings.TrimSpace(href)); err == nil {
+			base = b
+		}
+	}
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		if strings.Contains(strings.ToLower(s.AttrOr("rel", "")), "nofollow") {
+			return
+		}
+		link, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			return
+		}
+		links = append(links, normalize(link))
+	})
+	return links, index, follow
+}
+
+// normalize drops the fragment and lowercases the scheme and host, so that links to the same page are only crawled once
+func normalize(u *url.URL) *url.URL {
+	n := *u
+	n.Fragment, n.RawFragment = "", ""
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return &n
+}
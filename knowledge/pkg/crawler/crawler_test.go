@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawl(t *testing.T) {
+	pages := map[string]string{
+		"/robots.txt":  "User-agent: *\nDisallow: /private\n",
+		"/docs/":       `<a href="/docs/a">A</a> <a href="b#section">B</a> <a href="/private/x">private</a> <a href="/blog/">blog</a> <a href="https://other.example/">other</a> <a href="mailto:x@example.com">mail</a>`,
+		"/docs/a":      `<a href="/docs/a/deep">deep</a> <a href="/docs/">back</a>`,
+		"/docs/b":      `<meta name="robots" content="noindex"><a href="/docs/c">C</a>`,
+		"/docs/c":      `<p>linked from a noindex page</p>`,
+		"/docs/a/deep": `<a href="/docs/too-deep">too deep</a>`,
+		"/private/x":   `secret`,
+		"/blog/":       `blog`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".txt") {
+			w.Header().Set("Content-Type", "text/plain")
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	crawl := func(opts Options) []string {
+		var visited []string
+		n, err := New(opts).Crawl(context.Background(), srv.URL+"/docs/", func(_ context.Context, page Page) error {
+			assert.Equal(t, "text/html", page.ContentType)
+			visited = append(visited, strings.TrimPrefix(page.URL, srv.URL))
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, len(visited), n)
+		return visited
+	}
+
+	assert.Equal(t, []string{"/docs/", "/docs/a", "/docs/a/deep", "/docs/c"}, crawl(Options{MaxDepth: 2, PathPrefixes: []string{"/docs/"}}))
+	assert.NotContains(t, requested, "/private/x")
+	assert.NotContains(t, requested, "/docs/too-deep")
+
+	assert.Equal(t, []string{"/docs/"}, crawl(Options{}))
+	assert.Equal(t, []string{"/docs/", "/docs/a"}, crawl(Options{MaxDepth: 2, MaxPages: 2}))
+	assert.Equal(t, []string{"/docs/", "/docs/a", "/docs/b", "/private/x", "/blog/"}, crawl(Options{MaxDepth: 1, IgnoreRobots: true}))
+
+	_, err := New(Options{}).Crawl(context.Background(), srv.URL+"/missing", func(context.Context, Page) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestRobots(t *testing.T) {
+	r := parseRobots(strings.NewReader(`
+# comment
+User-agent: *
+Disallow: /
+
+User-agent: knowledge-crawler
+User-agent: other-bot
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+`), DefaultUserAgent)
+
+	assert.True(t, r.allowed("/docs"))
+	assert.False(t, r.allowed("/private/x"))
+	assert.True(t, r.allowed("/private/public/x"))
+	assert.False(t, r.allowed("/files/doc.pdf"))
+	assert.True(t, r.allowed("/files/doc.pdf?download=1"))
+	assert.Equal(t, "2s", r.crawlDelay.String())
+
+	r = parseRobots(strings.NewReader("User-agent: *\nDisallow: /\n"), "some-bot/1.0")
+	assert.False(t, r.allowed("/docs"))
+	r = parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), "some-bot/1.0")
+	assert.True(t, r.allowed("/docs"))
+}
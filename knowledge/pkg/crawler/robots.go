@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robots are the rules of a robots.txt that apply to the crawler's user agent
+type robots struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses the robots.txt and returns the rules of the most specific group for the user agent, or those of the "*" group.
+// The longest matching rule wins (allow wins ties), patterns may contain "*" wildcards and a trailing "$" anchor.
+func parseRobots(r io.Reader, userAgent string) *robots {
+	var (
+		groups  []*robotsGroup
+		current *robotsGroup
+		inRules bool // a rule was read since the last user-agent line, so the next user-agent line starts a new group
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if value == "" {
+				continue // empty disallow allows everything
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				current.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i > 0 {
+		token = token[:i]
+	}
+
+	var match, wildcard *robotsGroup
+	matchLen := 0
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			switch {
+			case agent == "*":
+				if wildcard == nil {
+					wildcard = g
+				}
+			case token != "" && strings.Contains(token, agent) && len(agent) > matchLen:
+				match, matchLen = g, len(agent)
+			}
+		}
+	}
+	if match == nil {
+		match = wildcard
+	}
+	if match == nil {
+		return &robots{}
+	}
+	return &robots{rules: match.rules, crawlDelay: match.crawlDelay}
+}
+
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed reports whether the path (incl. query) may be crawled
+func (r *robots) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	allow, matchLen := true, -1
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if l := len(rule.pattern); l > matchLen || (l == matchLen && rule.allow) {
+			allow, matchLen = rule.allow, l
+		}
+	}
+	return allow
+}
@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxSitemapDepth limits the nesting of sitemap indexes
+const maxSitemapDepth = 3
+
+// SitemapURL is a page listed in a sitemap
+type SitemapURL struct {
+	Loc     string
+	LastMod time.Time // zero if not given
+}
+
+type sitemapXML struct {
+	XMLName  xml.Name
+	URLs     []sitemapEntryXML `xml:"url"`
+	Sitemaps []sitemapEntryXML `xml:"sitemap"`
+}
+
+type sitemapEntryXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Sitemap fetches the sitemap (a urlset or a sitemap index, optionally gzipped) and returns the pages listed in it
+// and its nested sitemaps which match the Include and Exclude patterns. Nested sitemaps that can't be fetched are skipped with a warning.
+func (c *Crawler) Sitemap(ctx context.Context, sitemapURL string) ([]SitemapURL, error) {
+	seen := map[string]struct{}{}
+	return c.sitemap(ctx, sitemapURL, 0, seen)
+}
+
+func (c *Crawler) sitemap(ctx context.Context, sitemapURL string, depth int, seen map[string]struct{}) ([]SitemapURL, error) {
+	if _, ok := seen[sitemapURL]; ok {
+		return nil, nil
+	}
+	seen[sitemapURL] = struct{}{}
+
+	page, err := c.Fetch(ctx, sitemapURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+	}
+
+	sm, err := parseSitemap(page.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %q: %w", sitemapURL, err)
+	}
+
+	var urls []SitemapURL
+	for _, u := range sm.URLs {
+		loc := strings.TrimSpace(u.Loc)
+		if loc == "" || !c.opts.MatchesPatterns(loc) {
+			continue
+		}
+		urls = append(urls, SitemapURL{Loc: loc, LastMod: parseLastMod(u.LastMod)})
+	}
+
+	for _, nested := range sm.Sitemaps {
+		loc := strings.TrimSpace(nested.Loc)
+		if loc == "" {
+			continue
+		}
+		if depth+1 > maxSitemapDepth {
+			slog.Warn("Skipping nested sitemap, too deeply nested", "sitemap", loc, "maxDepth", maxSitemapDepth)
+			continue
+		}
+		if base, err := url.Parse(sitemapURL); err == nil {
+			if u, err := base.Parse(loc); err == nil {
+				loc = u.String()
+			}
+		}
+		nestedURLs, err := c.sitemap(ctx, loc, depth+1, seen)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			slog.Warn("Failed to load nested sitemap, skipping", "sitemap", loc, "error", err)
+			continue
+		}
+		urls = append(urls, nestedURLs...)
+	}
+
+	return urls, nil
+}
+
+func parseSitemap(content []byte) (*sitemapXML, error) {
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		if content, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+
+	var sm sitemapXML
+	if err := xml.Unmarshal(content, &sm); err != nil {
+		return nil, err
+	}
+	if sm.XMLName.Local != "urlset" && sm.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("unexpected root element <%s>, expected <urlset> or <sitemapindex>", sm.XMLName.Local)
+	}
+	return &sm, nil
+}
+
+// parseLastMod parses the W3C datetime formats allowed in sitemaps, returning the zero time for invalid values
+func parseLastMod(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemap(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap_index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>/sitemap-docs.xml</loc></sitemap>
+  <sitemap><loc>%s/sitemap-blog.xml.gz</loc></sitemap>
+  <sitemap><loc>%s/missing.xml</loc></sitemap>
+  <sitemap><loc>%s/sitemap_index.xml</loc></sitemap>
+</sitemapindex>`, srv.URL, srv.URL, srv.URL)
+		case "/sitemap-docs.xml":
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> %s/docs/a </loc><lastmod>2024-05-01T10:00:00+02:00</lastmod></url>
+  <url><loc>%s/docs/b</loc><lastmod>2024-05-02</lastmod></url>
+  <url><loc>%s/docs/old</loc><lastmod>not a date</lastmod></url>
+</urlset>`, srv.URL, srv.URL, srv.URL)
+		case "/sitemap-blog.xml.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			zw := gzip.NewWriter(w)
+			fmt.Fprintf(zw, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%s/blog/post</loc></url>
+  <url><loc>%s/docs/c</loc><lastmod>2024-05</lastmod></url>
+</urlset>`, srv.URL, srv.URL)
+			_ = zw.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	urls, err := New(Options{}).Sitemap(context.Background(), srv.URL+"/sitemap_index.xml")
+	require.NoError(t, err)
+	var locs []string
+	for _, u := range urls {
+		locs = append(locs, strings.TrimPrefix(u.Loc, srv.URL))
+	}
+	assert.Equal(t, []string{"/docs/a", "/docs/b", "/docs/old", "/blog/post", "/docs/c"}, locs)
+	assert.True(t, urls[0].LastMod.Equal(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), urls[1].LastMod)
+	assert.True(t, urls[2].LastMod.IsZero())
+	assert.True(t, urls[3].LastMod.IsZero())
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), urls[4].LastMod)
+
+	urls, err = New(Options{
+		Include: []*regexp.Regexp{regexp.MustCompile(`/docs/`)},
+		Exclude: []*regexp.Regexp{regexp.MustCompile(`/old$`)},
+	}).Sitemap(context.Background(), srv.URL+"/sitemap_index.xml")
+	require.NoError(t, err)
+	assert.Len(t, urls, 3)
+
+	_, err = New(Options{}).Sitemap(context.Background(), srv.URL+"/missing.xml")
+	assert.Error(t, err)
+}
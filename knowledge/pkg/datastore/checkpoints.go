@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// loadCheckpoint returns the checkpoint of an earlier, interrupted ingestion of the file and the documents to resume from,
+// if the file content didn't change since then. Failing to load the checkpoint only means starting over.
+func (s *Datastore) loadCheckpoint(ctx context.Context, datasetID string, fileMetadata *types.FileMetadata) (*types.IngestionCheckpoint, []vs.Document) {
+	cp, err := s.Index.GetIngestionCheckpoint(ctx, datasetID, fileMetadata.AbsolutePath)
+	if err != nil {
+		slog.Warn("Failed to load ingestion checkpoint, starting over", "error", err, "absPath", fileMetadata.AbsolutePath)
+		return nil, nil
+	}
+	if cp == nil || cp.Checksum != fileMetadata.Checksum {
+		return nil, nil
+	}
+
+	var docs []vs.Document
+	if len(cp.Documents) > 0 {
+		b, err := s.decryptCheckpoint(cp.Documents)
+		if err != nil {
+			slog.Warn("Failed to decrypt documents of ingestion checkpoint, starting over", "error", err, "absPath", fileMetadata.AbsolutePath)
+			return nil, nil
+		}
+		if err := json.Unmarshal(b, &docs); err != nil {
+			slog.Warn("Failed to decode documents of ingestion checkpoint, starting over", "error", err, "absPath", fileMetadata.AbsolutePath)
+			return nil, nil
+		}
+	}
+	return cp, docs
+}
+
+// saveCheckpoint records that the ingestion of the file completed the stage, along with the documents to resume from.
+// Failing to save the checkpoint doesn't fail the ingestion, it can only not be resumed from this stage.
+func (s *Datastore) saveCheckpoint(ctx context.Context, datasetID string, fileMetadata *types.FileMetadata, stage types.IngestionStage, docs []vs.Document) {
+	cp := types.IngestionCheckpoint{
+		Dataset:      datasetID,
+		AbsolutePath: fileMetadata.AbsolutePath,
+		Checksum:     fileMetadata.Checksum,
+		Stage:        stage,
+	}
+	if len(docs) > 0 {
+		b, err := json.Marshal(docs)
+		if err != nil {
+			slog.Warn("Failed to encode documents for ingestion checkpoint", "error", err, "absPath", fileMetadata.AbsolutePath, "stage", stage)
+			return
+		}
+		if b, err = s.encryptCheckpoint(b); err != nil {
+			slog.Warn("Failed to encrypt documents for ingestion checkpoint", "error", err, "absPath", fileMetadata.AbsolutePath, "stage", stage)
+			return
+		}
+		cp.Documents = b
+	}
+	if err := s.Index.SaveIngestionCheckpoint(ctx, cp); err != nil {
+		slog.Warn("Failed to save ingestion checkpoint", "error", err, "absPath", fileMetadata.AbsolutePath, "stage", stage)
+	}
+}
+
+// DeleteIngestionCheckpoints deletes the ingestion checkpoints of the files under the path prefix, e.g. once all of them were ingested
+func (s *Datastore) DeleteIngestionCheckpoints(ctx context.Context, datasetID, pathPrefix string) error {
+	return s.Index.DeleteIngestionCheckpoints(ctx, datasetID, pathPrefix)
+}
@@ -0,0 +1,64 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCheckpointIndex only implements the ingestion checkpoints of the Index
+type testCheckpointIndex struct {
+	index.Index
+	checkpoints map[string]types.IngestionCheckpoint
+}
+
+func (i *testCheckpointIndex) GetIngestionCheckpoint(_ context.Context, datasetID, absPath string) (*types.IngestionCheckpoint, error) {
+	cp, ok := i.checkpoints[datasetID+absPath]
+	if !ok {
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+func (i *testCheckpointIndex) SaveIngestionCheckpoint(_ context.Context, cp types.IngestionCheckpoint) error {
+	i.checkpoints[cp.Dataset+cp.AbsolutePath] = cp
+	return nil
+}
+
+func TestCheckpoints(t *testing.T) {
+	s := &Datastore{Index: &testCheckpointIndex{checkpoints: map[string]types.IngestionCheckpoint{}}}
+	ctx := context.Background()
+	file := &types.FileMetadata{AbsolutePath: "/data/foo.md", Checksum: "abc"}
+
+	cp, docs := s.loadCheckpoint(ctx, "ds", file)
+	assert.Nil(t, cp)
+	assert.Empty(t, docs)
+
+	embedded := []vs.Document{
+		{ID: "1", Content: "foo", Metadata: map[string]any{"filename": "foo.md"}, Embedding: []float32{0.1, 0.2}},
+		{ID: "2", Content: "bar", Metadata: map[string]any{"filename": "foo.md"}, Embedding: []float32{0.3, 0.4}},
+	}
+	s.saveCheckpoint(ctx, "ds", file, types.IngestionStageEmbedded, embedded)
+
+	cp, docs = s.loadCheckpoint(ctx, "ds", file)
+	require.NotNil(t, cp)
+	assert.Equal(t, types.IngestionStageEmbedded, cp.Stage)
+	assert.Equal(t, embedded, docs)
+
+	// the checkpoint doesn't apply to other datasets or changed files
+	cp, _ = s.loadCheckpoint(ctx, "other", file)
+	assert.Nil(t, cp)
+	cp, _ = s.loadCheckpoint(ctx, "ds", &types.FileMetadata{AbsolutePath: "/data/foo.md", Checksum: "def"})
+	assert.Nil(t, cp)
+
+	s.saveCheckpoint(ctx, "ds", file, types.IngestionStageIndexed, nil)
+	cp, docs = s.loadCheckpoint(ctx, "ds", file)
+	require.NotNil(t, cp)
+	assert.Equal(t, types.IngestionStageIndexed, cp.Stage)
+	assert.Empty(t, docs)
+}
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
 )
 
 type UpdateDatasetOpts struct {
@@ -20,6 +21,7 @@ func (s *Datastore) CreateDataset(ctx context.Context, dataset types.Dataset, op
 
 	// Create collection
 	err := s.Vectorstore.CreateCollection(ctx, dataset.ID, opts)
+	metrics.ObserveVectorStoreError("create_collection", err)
 	if err != nil {
 		return err
 	}
@@ -35,6 +37,7 @@ func (s *Datastore) DeleteDataset(ctx context.Context, datasetID string) error {
 
 	// Delete collection
 	err := s.Vectorstore.RemoveCollection(ctx, datasetID)
+	metrics.ObserveVectorStoreError("remove_collection", err)
 	if err != nil {
 		return err
 	}
@@ -12,7 +12,10 @@ import (
 	"time"
 
 	"github.com/obot-platform/tools/knowledge/pkg/config"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
 	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/encryption"
 	"github.com/obot-platform/tools/knowledge/pkg/index/types"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
@@ -20,6 +23,7 @@ import (
 	"github.com/adrg/xdg"
 	"github.com/obot-platform/tools/knowledge/pkg/index"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/encrypted"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
@@ -28,6 +32,17 @@ type Datastore struct {
 	Vectorstore            vectorstore.VectorStore
 	EmbeddingConfig        config.EmbeddingsConfig
 	EmbeddingModelProvider etypes.EmbeddingModelProvider
+
+	// batchEmbeddingFunc embeds the documents of a file in batches of up to batchSize during ingestion, if supported by the embedding model provider
+	// or required by the encryption at rest
+	batchEmbeddingFunc etypes.BatchEmbeddingFunc
+	batchSize          int
+
+	// embeddingLimiter limits the rate of requests to the embedding model provider (nil = unlimited)
+	embeddingLimiter *ratelimit.Limiter
+
+	// encryption encrypts the document contents and metadata at rest (nil = unencrypted), see DatastoreOpts.Encryption
+	encryption *encryption.Cipher
 }
 
 // GetDefaultDSNs returns the paths for the datastore and vectorstore databases.
@@ -73,7 +88,16 @@ func LogEmbeddingFunc(embeddingFunc vs.EmbeddingFunc) vs.EmbeddingFunc {
 	}
 }
 
-func NewDatastore(ctx context.Context, indexDSN string, automigrate bool, vectorDSN string, embeddingProvider etypes.EmbeddingModelProvider) (*Datastore, error) {
+type DatastoreOpts struct {
+	EmbeddingCache             bool // Cache embeddings in the Index by text hash, so that unchanged content is not embedded again on re-ingestion
+	EmbeddingRequestsPerMinute int  // Maximum number of requests per minute to the embedding model provider (0 = unlimited)
+
+	// Encryption encrypts the contents and metadata of the documents in the vector store and the ingestion checkpoints in the Index.
+	// Document contents and vocabularies can't be stored in the Index then, as they'd have to be searchable.
+	Encryption *encryption.Cipher
+}
+
+func NewDatastore(ctx context.Context, indexDSN string, automigrate bool, vectorDSN string, embeddingProvider etypes.EmbeddingModelProvider, opts DatastoreOpts) (*Datastore, error) {
 	indexDSN, vectorDSN, isArchive, err := GetDefaultDSNs(indexDSN, vectorDSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine datastore paths: %w", err)
@@ -90,15 +114,57 @@ func NewDatastore(ctx context.Context, indexDSN string, automigrate bool, vector
 
 	slog.Debug("Using embedding model provider", "provider", embeddingProvider.Name(), "config", output.RedactSensitive(embeddingProvider.Config()))
 
-	vsdb, err := vectorstore.New(ctx, vectorDSN, embeddingProvider)
+	var vsEmbeddingProvider etypes.EmbeddingModelProvider = &metricsEmbeddingModelProvider{EmbeddingModelProvider: embeddingProvider}
+	var cacheModel string
+	if opts.EmbeddingCache {
+		cacheModel, err = EmbeddingCacheKey(embeddingProvider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up embedding cache: %w", err)
+		}
+		slog.Debug("Using embedding cache", "model", cacheModel)
+		vsEmbeddingProvider = &cachingEmbeddingModelProvider{EmbeddingModelProvider: vsEmbeddingProvider, cache: idx, model: cacheModel}
+	}
+
+	vsdb, err := vectorstore.New(ctx, vectorDSN, vsEmbeddingProvider)
 	if err != nil {
 		return nil, err
 	}
 
+	var embeddingFunc vs.EmbeddingFunc
+	if opts.Encryption != nil {
+		embeddingFunc, err = vsEmbeddingProvider.EmbeddingFunc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embedding function: %w", err)
+		}
+		slog.Debug("Encrypting documents at rest", "plaintextMetadataKeys", plaintextMetadataKeys)
+		vsdb = encrypted.New(vsdb, opts.Encryption, preprocessing.WrapEmbeddingFunc(embeddingFunc), plaintextMetadataKeys...)
+	}
+
 	ds := &Datastore{
 		Index:                  idx,
 		Vectorstore:            vsdb,
 		EmbeddingModelProvider: embeddingProvider,
+		embeddingLimiter:       ratelimit.New(opts.EmbeddingRequestsPerMinute),
+		encryption:             opts.Encryption,
+	}
+
+	if bp, ok := embeddingProvider.(etypes.BatchEmbeddingModelProvider); ok {
+		batchEmbeddingFunc, batchSize, err := bp.BatchEmbeddingFunc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get batch embedding function: %w", err)
+		}
+		if batchSize > 1 {
+			batchEmbeddingFunc = metricsBatchEmbeddingFunc(embeddingProvider.Name(), batchEmbeddingFunc)
+			if opts.EmbeddingCache {
+				batchEmbeddingFunc = CachedBatchEmbeddingFunc(batchEmbeddingFunc, idx, cacheModel)
+			}
+			slog.Debug("Using batch embedding", "batchSize", batchSize)
+			ds.batchEmbeddingFunc, ds.batchSize = batchEmbeddingFunc, batchSize
+		}
+	}
+	if ds.batchEmbeddingFunc == nil && embeddingFunc != nil {
+		// The encrypted vector store can't embed the documents itself, so they're always embedded before they're added
+		ds.batchEmbeddingFunc, ds.batchSize = singleEmbeddingBatchFunc(embeddingFunc, encryptedEmbeddingConcurrency), encryptedEmbeddingConcurrency
 	}
 
 	// If loaded from archive, do not create a default dataset
@@ -139,6 +205,20 @@ func (s *Datastore) Close() error {
 	return fmt.Errorf(strings.Join(errmsgs, ", "))
 }
 
+// RebuildVectorIndexes (re)builds the vector indexes of the vector store, if supported (e.g. after changing the index type or bulk ingestion)
+func (s *Datastore) RebuildVectorIndexes(ctx context.Context) error {
+	rebuilder, ok := vectorstore.As[vectorstore.IndexRebuilder](s.Vectorstore)
+	if !ok {
+		return fmt.Errorf("the configured vector store does not support rebuilding indexes")
+	}
+	return rebuilder.RebuildIndexes(ctx)
+}
+
+// Stats returns vector store statistics (document counts, storage size, embedding dimensions) for the given datasets or all datasets, if none are specified
+func (s *Datastore) Stats(ctx context.Context, datasetIDs ...string) ([]vs.CollectionStats, error) {
+	return s.Vectorstore.Stats(ctx, datasetIDs...)
+}
+
 func (s *Datastore) ExportDatasetsToFile(ctx context.Context, path string, datasets ...string) error {
 	tmpDir, err := os.MkdirTemp(os.TempDir(), "knowledge-export-")
 	if err != nil {
@@ -19,6 +19,7 @@ var IsDuplicateFuncs = map[string]IsDuplicateFunc{
 	"none":          DummyDedupe,
 	"ignore":        DummyDedupe,
 	"upsert":        DedupeUpsert,
+	"etag":          DedupeByETag,
 }
 
 // DedupeByFileMetadata is a deduplication function that checks if the document is a duplicate based on the file metadata.
@@ -70,6 +71,38 @@ func DedupeUpsert(ctx context.Context, d *Datastore, datasetID string, content [
 	return true, nil
 }
 
+// DedupeByETag is an upsert deduplication function for files with an ETag, e.g. from an object store: the document is a duplicate
+// if the file at the same absolute path has the same ETag, otherwise an existing file is replaced. Without an ETag, it falls back to DedupeUpsert.
+func DedupeByETag(ctx context.Context, d *Datastore, datasetID string, content []byte, opts IngestOpts) (bool, error) {
+	if opts.FileMetadata.ETag == "" {
+		return DedupeUpsert(ctx, d, datasetID, content, opts)
+	}
+
+	searchMeta := types.FileMetadata{
+		AbsolutePath: opts.FileMetadata.AbsolutePath,
+	}
+
+	res, err := d.Index.FindFileByMetadata(ctx, datasetID, searchMeta, false)
+	if err != nil && !errors.Is(err, types.ErrDBFileNotFound) {
+		return false, err
+	}
+
+	if res == nil || res.ID == "" {
+		return false, nil
+	}
+
+	if res.ETag == opts.FileMetadata.ETag {
+		slog.Debug("Not upserting: ETag unchanged", "file", res.ID, "absPath", res.AbsolutePath, "etag", res.ETag)
+		return true, nil
+	}
+
+	slog.Debug("Upserting by deleting existing file", "file", res.ID, "absPath", res.AbsolutePath, "etag", res.ETag, "new_etag", opts.FileMetadata.ETag)
+	if err := d.DeleteFile(ctx, datasetID, res.ID); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
 // DummyDedupe is a dummy deduplication function that always returns false (i.e. "No Duplicate").
 func DummyDedupe(ctx context.Context, d *Datastore, datasetID string, content []byte, opts IngestOpts) (bool, error) {
 	return false, nil
@@ -21,4 +21,10 @@ var (
 
 	// ModelAPIRequestTimeoutSeconds is the timeout for each individual request to the model API
 	ModelAPIRequestTimeoutSeconds = env.GetIntFromEnvOrDefault("KNOW_MODEL_API_REQUEST_TIMEOUT_SECONDS", 120)
+
+	// ModelAPIMaxRetries overrides the number of tries for failed requests to the model API, if set (> 0)
+	ModelAPIMaxRetries = env.GetIntFromEnvOrDefault("KNOW_MODEL_API_MAX_RETRIES", 0)
+
+	// ModelAPIMaxRetryDelaySeconds caps the exponential backoff between retries - delays requested by the API via Retry-After are not capped
+	ModelAPIMaxRetryDelaySeconds = env.GetIntFromEnvOrDefault("KNOW_MODEL_API_MAX_RETRY_DELAY_SECONDS", 30)
 )
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
@@ -15,12 +17,35 @@ func (s *Datastore) DeleteDocument(ctx context.Context, documentID, datasetID st
 
 	// Remove from VectorStore
 	if err := s.Vectorstore.RemoveDocument(ctx, documentID, datasetID, nil, nil); err != nil {
+		metrics.ObserveVectorStoreError("remove_documents", err)
 		return fmt.Errorf("failed to remove document from VectorStore: %w", err)
 	}
 
 	return nil
 }
 
+// GetDocuments returns the documents of the dataset matching the filters - a page of them, if types.ListOptions are set in the context
 func (s *Datastore) GetDocuments(ctx context.Context, datasetID string, where map[string]string, whereDocument []types.WhereDocument) ([]types.Document, error) {
-	return s.Vectorstore.GetDocuments(ctx, datasetID, where, whereDocument)
+	docs, err := s.Vectorstore.GetDocuments(ctx, datasetID, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	if pager, ok := vectorstore.As[vectorstore.Pager](s.Vectorstore); ok && pager.SupportsPaging() {
+		return docs, nil
+	}
+	return types.ListOptionsFromCtx(ctx).Apply(docs), nil
+}
+
+// GetVocabulary returns the term frequencies of the dataset, built at ingestion time (IngestOpts.BuildVocabulary)
+func (s *Datastore) GetVocabulary(ctx context.Context, datasetID string) (map[string]int, error) {
+	return s.Index.GetVocabulary(ctx, datasetID)
+}
+
+// UpdateDocumentMetadata updates the metadata of a single document (chunk) in the VectorStore without re-embedding it,
+// e.g. to change tags, labels or ACLs. Keys with a nil value are removed.
+func (s *Datastore) UpdateDocumentMetadata(ctx context.Context, documentID, datasetID string, patch types.MetadataPatch) error {
+	if err := s.Vectorstore.UpdateDocumentMetadata(ctx, documentID, datasetID, patch); err != nil {
+		return fmt.Errorf("failed to update document metadata in VectorStore: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,253 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure Transcription satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Transcription)(nil)
+
+var TranscriptionAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_TRANSCRIPTION_API_TIMEOUT_SECONDS", defaults.ModelAPITimeoutSeconds)) * time.Second
+var TranscriptionAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_TRANSCRIPTION_API_REQUEST_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+
+const defaultSegmentDuration = 120 // seconds
+
+type TranscriptionOptions struct {
+	// BaseURL of the Whisper-compatible API (default: $OPENAI_BASE_URL or https://api.openai.com/v1)
+	BaseURL string `mapstructure:"baseURL" json:"baseURL,omitempty"`
+
+	// APIKey for the transcription API (default: $OPENAI_API_KEY)
+	APIKey string `mapstructure:"apiKey" json:"apiKey,omitempty"`
+
+	// Model is the transcription model (default: whisper-1)
+	Model string `mapstructure:"model" json:"model,omitempty"`
+
+	// Language is the ISO-639-1 language of the audio, e.g. "en" - improves accuracy and latency (default: auto-detect)
+	Language string `mapstructure:"language" json:"language,omitempty"`
+
+	// Prompt guides the style of the transcript or provides the spelling of uncommon words, e.g. product names
+	Prompt string `mapstructure:"prompt" json:"prompt,omitempty"`
+
+	// SegmentDuration is the maximum duration of consecutive transcript segments merged into one document in seconds (default: 120).
+	// Set to -1 to emit the whole transcript as a single document.
+	SegmentDuration float64 `mapstructure:"segmentDuration" json:"segmentDuration,omitempty"`
+
+	// TimestampsInContent prefixes each segment in the content with its start time, e.g. "[01:23] ..."
+	TimestampsInContent bool `mapstructure:"timestampsInContent" json:"timestampsInContent,omitempty"`
+}
+
+// WithConfig sets the transcription configuration.
+func WithConfig(config TranscriptionOptions) func(o *TranscriptionOptions) {
+	return func(o *TranscriptionOptions) {
+		*o = config
+	}
+}
+
+// Transcription is a document loader for audio files (mp3, wav, m4a, ...) that implements the DocumentLoader interface.
+// The audio is transcribed by a Whisper-compatible API (POST <baseURL>/audio/transcriptions) and the transcript
+// segments are grouped into documents with their start and end times in the metadata.
+type Transcription struct {
+	reader io.Reader
+	opts   TranscriptionOptions
+}
+
+type transcriptionResponse struct {
+	Text     string                 `json:"text"`
+	Language string                 `json:"language"`
+	Duration float64                `json:"duration"`
+	Segments []transcriptionSegment `json:"segments"`
+}
+
+type transcriptionSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// NewTranscription creates a new audio transcription loader with the given options.
+func NewTranscription(reader io.Reader, optFns ...func(o *TranscriptionOptions)) (*Transcription, error) {
+	var opts TranscriptionOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.BaseURL == "" {
+		opts.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://api.openai.com/v1"
+	}
+	if opts.APIKey == "" {
+		opts.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if opts.Model == "" {
+		opts.Model = "whisper-1"
+	}
+	if opts.SegmentDuration == 0 {
+		opts.SegmentDuration = defaultSegmentDuration
+	}
+
+	return &Transcription{
+		reader: reader,
+		opts:   opts,
+	}, nil
+}
+
+// Load transcribes the audio and returns the transcript documents.
+func (l *Transcription) Load(ctx context.Context) ([]vs.Document, error) {
+	data, err := io.ReadAll(l.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	transcript, err := l.transcribe(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := transcript.Segments
+	if len(segments) == 0 && strings.TrimSpace(transcript.Text) != "" {
+		// e.g. APIs that don't support segment timestamps
+		segments = []transcriptionSegment{{Start: 0, End: transcript.Duration, Text: transcript.Text}}
+	}
+
+	var docs []vs.Document
+	var current []transcriptionSegment
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		var content strings.Builder
+		for _, s := range current {
+			text := strings.TrimSpace(s.Text)
+			if text == "" {
+				continue
+			}
+			if content.Len() > 0 {
+				content.WriteString("\n")
+			}
+			if l.opts.TimestampsInContent {
+				fmt.Fprintf(&content, "[%s] ", formatTimestamp(s.Start))
+			}
+			content.WriteString(text)
+		}
+		start, end := current[0].Start, current[len(current)-1].End
+		if content.Len() > 0 {
+			metadata := map[string]any{
+				"start":          start,
+				"end":            end,
+				"startTimestamp": formatTimestamp(start),
+				"endTimestamp":   formatTimestamp(end),
+			}
+			if transcript.Language != "" {
+				metadata["language"] = transcript.Language
+			}
+			if transcript.Duration > 0 {
+				metadata["duration"] = transcript.Duration
+			}
+			docs = append(docs, vs.Document{Content: content.String(), Metadata: metadata})
+		}
+		current = nil
+	}
+
+	for _, s := range segments {
+		if len(current) > 0 && l.opts.SegmentDuration > 0 && s.End-current[0].Start > l.opts.SegmentDuration {
+			flush()
+		}
+		current = append(current, s)
+	}
+	flush()
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+func (l *Transcription) transcribe(ctx context.Context, data []byte) (*transcriptionResponse, error) {
+	ctx = log.ToCtx(ctx, log.FromCtx(ctx).With("tool", "audio-transcription").With("ctxTimeout", TranscriptionAPITimeout))
+
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithTimeout(ctx, TranscriptionAPITimeout)
+	defer cancel()
+
+	// the API detects the audio format by the file extension
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "audio"+mimetype.Detect(data).Extension())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	fields := [][2]string{
+		{"model", l.opts.Model},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"language", l.opts.Language},
+		{"prompt", l.opts.Prompt},
+	}
+	for _, f := range fields {
+		if f[1] == "" {
+			continue
+		}
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(l.opts.BaseURL, "/")+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if l.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.opts.APIKey)
+	}
+
+	client := &http.Client{
+		Timeout: TranscriptionAPIRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+	respBody, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+	if err != nil {
+		return nil, fmt.Errorf("transcription error sending request(s): %w", err)
+	}
+
+	var result transcriptionResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("error unmarshaling transcription response: %w", err)
+	}
+	return &result, nil
+}
+
+// formatTimestamp formats seconds as [hh:]mm:ss
+func formatTimestamp(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if h > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
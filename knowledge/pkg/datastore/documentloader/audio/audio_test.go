@@ -0,0 +1,65 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		assert.Equal(t, "whisper-1", r.FormValue("model"))
+		_, fh, err := r.FormFile("file")
+		require.NoError(t, err)
+		assert.Equal(t, "audio.wav", fh.Filename)
+
+		_, _ = w.Write([]byte(`{
+			"text": "Welcome everyone. Let's start with the roadmap. First item is the launch.",
+			"language": "english",
+			"duration": 75.5,
+			"segments": [
+				{"start": 0.0, "end": 2.4, "text": " Welcome everyone."},
+				{"start": 2.4, "end": 31.0, "text": " Let's start with the roadmap."},
+				{"start": 62.0, "end": 75.5, "text": " First item is the launch."}
+			]
+		}`))
+	}))
+	defer srv.Close()
+
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt "), make([]byte, 32)...)
+
+	l, err := NewTranscription(bytes.NewReader(wav), WithConfig(TranscriptionOptions{
+		BaseURL:             srv.URL + "/v1",
+		APIKey:              "secret",
+		SegmentDuration:     60,
+		TimestampsInContent: true,
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "[00:00] Welcome everyone.\n[00:02] Let's start with the roadmap.", docs[0].Content)
+	assert.Equal(t, 0.0, docs[0].Metadata["start"])
+	assert.Equal(t, 31.0, docs[0].Metadata["end"])
+	assert.Equal(t, "english", docs[0].Metadata["language"])
+
+	assert.Equal(t, "[01:02] First item is the launch.", docs[1].Content)
+	assert.Equal(t, "01:02", docs[1].Metadata["startTimestamp"])
+	assert.Equal(t, 75.5, docs[1].Metadata["duration"])
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	assert.Equal(t, "00:59", formatTimestamp(59.9))
+	assert.Equal(t, "01:01:05", formatTimestamp(3665))
+}
@@ -0,0 +1,394 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure Code satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Code)(nil)
+
+const defaultMaxChunkLines = 150
+
+type CodeOptions struct {
+	// Language of the source code, e.g. "go" or "python" - set from the file extension by default.
+	// Unknown languages are chunked along blank lines.
+	Language string `mapstructure:"language" json:"language,omitempty"`
+
+	// MaxChunkLines is the size above which classes (and similar containers) are split into their members,
+	// and code outside of declarations is split at blank lines (default: 150)
+	MaxChunkLines int `mapstructure:"maxChunkLines" json:"maxChunkLines,omitempty"`
+}
+
+// WithConfig sets the code loader configuration, keeping the detected language if none is configured.
+func WithConfig(config CodeOptions) func(o *CodeOptions) {
+	return func(o *CodeOptions) {
+		language := o.Language
+		*o = config
+		if o.Language == "" {
+			o.Language = language
+		}
+	}
+}
+
+// WithLanguage sets the programming language.
+func WithLanguage(language string) func(o *CodeOptions) {
+	return func(o *CodeOptions) {
+		o.Language = language
+	}
+}
+
+// Code is a document loader for source code that implements the DocumentLoader interface.
+// It emits one document per top-level declaration (function, type, class, ...), found heuristically,
+// with the symbol name and line range in the metadata, so that retrieval returns whole functions.
+type Code struct {
+	reader io.Reader
+	opts   CodeOptions
+}
+
+type chunk struct {
+	start, end int // line indices, inclusive
+	symbol     string
+	kind       string
+}
+
+// NewCode creates a new source code loader with the given options.
+func NewCode(reader io.Reader, optFns ...func(o *CodeOptions)) *Code {
+	var opts CodeOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	opts.Language = strings.ToLower(opts.Language)
+	if opts.MaxChunkLines <= 0 {
+		opts.MaxChunkLines = defaultMaxChunkLines
+	}
+
+	return &Code{
+		reader: reader,
+		opts:   opts,
+	}
+}
+
+// Load reads the source code and returns one document per chunk.
+func (l *Code) Load(_ context.Context) ([]vs.Document, error) {
+	data, err := io.ReadAll(l.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source code: %w", err)
+	}
+
+	var docs []vs.Document
+	for _, seg := range Segments(string(data), l.opts.Language, l.opts.MaxChunkLines) {
+		metadata := map[string]any{
+			"startLine": seg.StartLine,
+			"endLine":   seg.EndLine,
+		}
+		if l.opts.Language != "" {
+			metadata["language"] = l.opts.Language
+		}
+		if seg.Symbol != "" {
+			metadata["symbol"] = seg.Symbol
+			metadata["symbolKind"] = seg.Kind
+		}
+		docs = append(docs, vs.Document{Content: seg.Content, Metadata: metadata})
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+// Segment is a part of source code, e.g. a function including its doc comment
+type Segment struct {
+	StartLine int // 1-based
+	EndLine   int // inclusive
+	Symbol    string
+	Kind      string
+	Content   string
+}
+
+// Segments splits the source code along its top-level declarations (see Code), where containers (e.g. classes)
+// with more than maxChunkLines lines are split into their members. Unknown languages are split along blank lines.
+func Segments(source string, language string, maxChunkLines int) []Segment {
+	if maxChunkLines <= 0 {
+		maxChunkLines = defaultMaxChunkLines
+	}
+
+	text := strings.ReplaceAll(source, "\r\n", "\n")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	c := &chunker{
+		lines:         lines,
+		maxChunkLines: maxChunkLines,
+	}
+	if lang, ok := languages[strings.ToLower(language)]; ok {
+		c.lang = lang
+		c.info = scan(lang, lines)
+		c.chunkRange(0, len(lines), 0, chunk{})
+	} else {
+		c.chunkPlain()
+	}
+
+	var segments []Segment
+	for _, ch := range c.chunks {
+		// trim blank lines
+		for ch.start < ch.end && strings.TrimSpace(lines[ch.start]) == "" {
+			ch.start++
+		}
+		for ch.end > ch.start && strings.TrimSpace(lines[ch.end]) == "" {
+			ch.end--
+		}
+		content := strings.Join(lines[ch.start:ch.end+1], "\n")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		segments = append(segments, Segment{
+			StartLine: ch.start + 1,
+			EndLine:   ch.end + 1,
+			Symbol:    ch.symbol,
+			Kind:      ch.kind,
+			Content:   content,
+		})
+	}
+	return segments
+}
+
+type chunker struct {
+	lang          *language
+	lines         []string
+	info          []lineInfo
+	maxChunkLines int
+	chunks        []chunk
+}
+
+// chunkRange chunks the lines [from, to) along the declarations at the given base depth (braces) or indentation.
+// Lines outside of declarations are attributed to the parent, e.g. the fields of a class.
+func (c *chunker) chunkRange(from, to, base int, parent chunk) {
+	pending := -1 // start of the pending lines outside of declarations
+	floor := from // first line after the previous declaration
+	flush := func(end int) {
+		if pending >= 0 && pending <= end {
+			c.chunks = append(c.chunks, chunk{start: pending, end: end, symbol: parent.symbol, kind: parent.kind})
+		}
+		pending = -1
+	}
+
+	for i := from; i < to; i++ {
+		decl, ok := c.declAt(i, base, parent.symbol != "")
+		if !ok {
+			if pending < 0 {
+				pending = i
+			}
+			if c.info[i].blank && i-pending >= c.maxChunkLines {
+				flush(i)
+			}
+			continue
+		}
+
+		end := c.blockEnd(i, base)
+		if end >= to {
+			end = to - 1
+		}
+
+		// attach leading comments, annotations and decorators
+		start := i
+		for start > floor && c.info[start-1].attachable {
+			start--
+		}
+		flush(start - 1)
+
+		if parent.symbol != "" {
+			decl.symbol = parent.symbol + "." + decl.symbol
+		}
+		decl.start, decl.end = start, end
+
+		if slices.Contains(c.lang.containers, decl.kind) && end-start+1 > c.maxChunkLines {
+			c.chunkContainer(decl, i)
+		} else {
+			c.chunks = append(c.chunks, decl)
+		}
+		i = end
+		floor = end + 1
+	}
+	flush(to - 1)
+}
+
+// chunkContainer splits a container (class, ...) into its members - the declaration line and any other lines
+// outside of the members (e.g. fields) are kept as chunks of the container itself
+func (c *chunker) chunkContainer(decl chunk, declLine int) {
+	bodyStart := declLine + 1
+	var base int
+	if c.lang.style == blockIndent {
+		for bodyStart <= decl.end && c.info[bodyStart].continuation {
+			bodyStart++ // multi-line class signature
+		}
+		base = -1
+		for j := bodyStart; j <= decl.end; j++ {
+			if !c.info[j].blank && !c.info[j].continuation {
+				base = c.info[j].indent
+				break
+			}
+		}
+		if base < 0 {
+			c.chunks = append(c.chunks, decl)
+			return
+		}
+	} else {
+		base = c.info[declLine].depth + 1
+		for bodyStart <= decl.end && c.info[bodyStart].depth < base {
+			bodyStart++ // the opening brace may be on a later line
+		}
+	}
+
+	c.chunks = append(c.chunks, chunk{start: decl.start, end: bodyStart - 1, symbol: decl.symbol, kind: decl.kind})
+	c.chunkRange(bodyStart, decl.end+1, base, decl)
+}
+
+// declAt returns the declaration starting at line i, if any
+func (c *chunker) declAt(i, base int, member bool) (chunk, bool) {
+	info := c.info[i]
+	if info.blank || info.continuation || info.attachable {
+		return chunk{}, false
+	}
+	if c.lang.style == blockIndent {
+		if info.indent != base {
+			return chunk{}, false
+		}
+	} else if info.depth != base {
+		return chunk{}, false
+	}
+
+	line := strings.TrimSpace(c.lines[i])
+	if first, _, _ := strings.Cut(line, " "); isControlKeyword(strings.TrimRight(first, "({")) {
+		return chunk{}, false
+	}
+
+	patterns := c.lang.decls
+	if member {
+		patterns = append(slices.Clone(c.lang.members), patterns...)
+	}
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name := m[p.name]
+		if isControlKeyword(name) {
+			continue
+		}
+		kind := p.kind
+		if kind == "" {
+			kind = m[1]
+		}
+		if name == "" {
+			name = kind // e.g. Swift init
+		}
+		if p.receiver > 0 {
+			name = m[p.receiver] + "." + name
+		}
+		return chunk{symbol: name, kind: kind}, true
+	}
+	return chunk{}, false
+}
+
+// blockEnd returns the last line of the declaration starting at line i
+func (c *chunker) blockEnd(i, base int) int {
+	if c.lang.style == blockIndent {
+		end := i
+		for j := i + 1; j < len(c.lines); j++ {
+			info := c.info[j]
+			if info.blank {
+				continue
+			}
+			if !info.continuation && info.indent <= base {
+				break
+			}
+			end = j
+		}
+		return end
+	}
+
+	depth, paren, opened := base, 0, false
+	for j := i; j < len(c.lines); j++ {
+		for _, t := range c.info[j].tokens {
+			switch t {
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+				if opened && depth <= base {
+					return j
+				}
+			case '(', '[':
+				paren++
+			case ')', ']':
+				paren--
+			case ';':
+				if !opened && depth == base && paren <= 0 {
+					return j
+				}
+			}
+		}
+		if !opened && depth == base && paren <= 0 && !c.continues(j) {
+			return j
+		}
+	}
+	return len(c.lines) - 1
+}
+
+var continuationSuffixes = []string{",", "(", "[", "=", "=>", "->", "+", "-", "*", "/", "&&", "||", ".", ":", "?", "\\", "|", "&"}
+var continuationPrefixes = []string{"{", ".", ")", "throws", "where", ":", "extends", "implements", "->", "=>", "=", "&&", "||"}
+
+// continues reports whether the statement on line j continues on the next line (for declarations without block)
+func (c *chunker) continues(j int) bool {
+	line := strings.TrimSpace(c.lines[j])
+	if c.info[j].blank {
+		return true // e.g. empty lines between a doc comment and the declaration
+	}
+	for _, s := range continuationSuffixes {
+		if strings.HasSuffix(line, s) {
+			return true
+		}
+	}
+	for k := j + 1; k < len(c.lines); k++ {
+		if c.info[k].blank {
+			continue
+		}
+		next := strings.TrimSpace(c.lines[k])
+		for _, p := range continuationPrefixes {
+			if strings.HasPrefix(next, p) {
+				return true
+			}
+		}
+		break
+	}
+	return false
+}
+
+// chunkPlain chunks code of unknown languages along blank lines
+func (c *chunker) chunkPlain() {
+	start := 0
+	for i, line := range c.lines {
+		if strings.TrimSpace(line) == "" && i-start >= c.maxChunkLines/3 {
+			c.chunks = append(c.chunks, chunk{start: start, end: i})
+			start = i + 1
+		}
+	}
+	if start < len(c.lines) {
+		c.chunks = append(c.chunks, chunk{start: start, end: len(c.lines) - 1})
+	}
+}
+
+func isControlKeyword(s string) bool {
+	_, ok := controlKeywords[s]
+	return ok
+}
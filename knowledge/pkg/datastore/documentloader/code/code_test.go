@@ -0,0 +1,195 @@
+package code
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type symbolRange struct {
+	symbol     string
+	start, end int
+}
+
+func loadSymbols(t *testing.T, src string, optFns ...func(o *CodeOptions)) []symbolRange {
+	t.Helper()
+	docs, err := NewCode(strings.NewReader(src), optFns...).Load(context.Background())
+	require.NoError(t, err)
+
+	res := make([]symbolRange, len(docs))
+	for i, d := range docs {
+		symbol, _ := d.Metadata["symbol"].(string)
+		res[i] = symbolRange{symbol, d.Metadata["startLine"].(int), d.Metadata["endLine"].(int)}
+	}
+	return res
+}
+
+const goSrc = `package server
+
+import (
+	"fmt"
+)
+
+// Server serves things.
+type Server struct {
+	addr string // "}" in a comment
+}
+
+type ID int
+
+// Start starts
+// the server.
+func (s *Server) Start() error {
+	if s.addr == "" {
+		return fmt.Errorf("missing addr: %s", "{")
+	}
+	return nil
+}
+
+func New[T any](addr string,
+	opts ...T) *Server {
+	return &Server{addr: ` + "`{`" + `}
+}
+`
+
+func TestGo(t *testing.T) {
+	assert.Equal(t, []symbolRange{
+		{"", 1, 5},
+		{"Server", 7, 10},
+		{"ID", 12, 12},
+		{"Server.Start", 14, 21},
+		{"New", 23, 26},
+	}, loadSymbols(t, goSrc, WithLanguage("go")))
+}
+
+const pySrc = `import os
+
+
+@dataclass
+class Config:
+    """Config holds
+the settings."""
+    name: str
+
+    def path(self,
+             base: str) -> str:
+        return os.path.join(base, self.name)
+
+    async def load(self):
+        pass
+
+
+def main():
+    cfg = Config("x")
+
+    print(cfg)
+`
+
+func TestPython(t *testing.T) {
+	assert.Equal(t, []symbolRange{
+		{"", 1, 1},
+		{"Config", 4, 15},
+		{"main", 18, 21},
+	}, loadSymbols(t, pySrc, WithLanguage("python")))
+
+	// split the class into its members
+	assert.Equal(t, []symbolRange{
+		{"", 1, 1},
+		{"Config", 4, 5},
+		{"Config", 6, 8},
+		{"Config.path", 10, 12},
+		{"Config.load", 14, 15},
+		{"main", 18, 21},
+	}, loadSymbols(t, pySrc, WithLanguage("python"), WithConfig(CodeOptions{MaxChunkLines: 5})))
+}
+
+const javaSrc = `package demo;
+
+import java.util.List;
+
+/**
+ * Greeter greets.
+ */
+public class Greeter {
+    private final String name;
+
+    public Greeter(String name) {
+        this.name = name;
+    }
+
+    @Override
+    public String toString()
+        throws IllegalStateException
+    {
+        return "Greeter{" + name + "}";
+    }
+
+    public abstract List<String> names();
+}
+`
+
+func TestJava(t *testing.T) {
+	assert.Equal(t, []symbolRange{
+		{"", 1, 3},
+		{"Greeter", 5, 23},
+	}, loadSymbols(t, javaSrc, WithLanguage("java")))
+
+	assert.Equal(t, []symbolRange{
+		{"", 1, 3},
+		{"Greeter", 5, 8},
+		{"Greeter", 9, 9},
+		{"Greeter.Greeter", 11, 13},
+		{"Greeter.toString", 15, 20},
+		{"Greeter", 22, 23}, // abstract methods without body stay with the class
+	}, loadSymbols(t, javaSrc, WithLanguage("java"), WithConfig(CodeOptions{MaxChunkLines: 10})))
+}
+
+const tsSrc = `import { x } from "y";
+
+export const handler = async (event: Event): Promise<void> => {
+  await x(event);
+};
+
+export interface Options {
+  verbose?: boolean;
+}
+
+export default class Client {
+  #token = "";
+
+  constructor(private url: string) {}
+
+  async get<T>(path: string): Promise<T> {
+    return fetch(this.url + path).then((r) => r.json());
+  }
+}
+`
+
+func TestTypeScript(t *testing.T) {
+	assert.Equal(t, []symbolRange{
+		{"", 1, 1},
+		{"handler", 3, 5},
+		{"Options", 7, 9},
+		{"Client", 11, 11},
+		{"Client", 12, 12},
+		{"Client.constructor", 14, 14},
+		{"Client.get", 16, 18},
+		{"Client", 19, 19},
+	}, loadSymbols(t, tsSrc, WithLanguage("typescript"), WithConfig(CodeOptions{MaxChunkLines: 5})))
+}
+
+func TestUnknownLanguage(t *testing.T) {
+	docs, err := NewCode(strings.NewReader("a\nb\n\nc\n")).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "a\nb\n\nc", docs[0].Content)
+	assert.NotContains(t, docs[0].Metadata, "language")
+}
+
+func TestLanguageFromFilename(t *testing.T) {
+	assert.Equal(t, "typescript", LanguageFromFilename("src/App.TSX"))
+	assert.Equal(t, "", LanguageFromFilename("README.md"))
+}
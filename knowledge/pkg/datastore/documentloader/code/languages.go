@@ -0,0 +1,225 @@
+package code
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+type blockStyle int
+
+const (
+	blockBraces blockStyle = iota // blocks are delimited by { and }
+	blockIndent                   // blocks are delimited by indentation (python)
+)
+
+type declPattern struct {
+	re   *regexp.Regexp
+	kind string // function, method, class, ... - the first submatch is the kind, if empty
+	// name is the index of the submatch holding the symbol name; receiver, if > 0, the index of the receiver type (Go methods)
+	name     int
+	receiver int
+}
+
+type language struct {
+	name          string
+	style         blockStyle
+	lineComment   string
+	blockComments bool     // /* ... */
+	containers    []string // kinds that are split into their members if they're too long
+	decls         []declPattern
+	members       []declPattern // declarations inside containers, in addition to decls
+}
+
+var controlKeywords = map[string]struct{}{
+	"if": {}, "for": {}, "while": {}, "switch": {}, "catch": {}, "return": {}, "else": {}, "do": {}, "try": {},
+	"foreach": {}, "using": {}, "lock": {}, "synchronized": {}, "when": {}, "match": {}, "new": {}, "sizeof": {},
+}
+
+func d(pattern, kind string, name int) declPattern {
+	return declPattern{re: regexp.MustCompile(pattern), kind: kind, name: name}
+}
+
+var (
+	jsDecls = []declPattern{
+		d(`^(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(\w+)`, "function", 1),
+		d(`^(?:export\s+)?(?:default\s+)?(?:abstract\s+)?(?:declare\s+)?(class|interface|enum)\s+(\w+)`, "", 2),
+		d(`^(?:export\s+)?(?:declare\s+)?(?:namespace|module)\s+([\w.]+)`, "namespace", 1),
+		d(`^(?:export\s+)?type\s+(\w+)`, "type", 1),
+		d(`^(?:export\s+)?(?:const|let|var)\s+(\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*(?::[^=]+)?=>|\w+\s*=>)`, "function", 1),
+	}
+	jsMembers = []declPattern{
+		d(`^(?:(?:public|private|protected|static|async|readonly|override|abstract|get|set)\s+)*\*?(#?\w+)\s*(?:<[^>]*>)?\s*\([^;]*$`, "method", 1),
+	}
+
+	javaLikeDecls = []declPattern{
+		d(`^(?:@\w+(?:\([^)]*\))?\s+)*(?:(?:public|private|protected|internal|static|final|abstract|sealed|partial|data|open|inner|readonly|unsafe|companion)\s+)*(class|interface|enum|record|struct|object|namespace|trait)\s+([\w.]+)`, "", 2),
+		d(`^(?:(?:public|private|protected|internal|static|final|abstract|override|virtual|async|synchronized|open|suspend|inline|operator|extern|unsafe|new|sealed|native|default)\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(\w+)\s*\(`, "function", 1),
+		d(`^(?:(?:public|private|protected|internal|static|final|abstract|override|virtual|async|synchronized|extern|unsafe|new|sealed|native|default|partial)\s+)*(?:<[^>]*>\s*)?[\w<>\[\],.?]+(?:<[^;{]*>)?\s+(\w+)\s*(?:<[^>]*>)?\s*\([^;]*$`, "method", 1),
+	}
+
+	cDecls = []declPattern{
+		d(`^(?:typedef\s+)?(struct|class|union|enum|namespace)\s+(\w+)\s*(?:[:{]|$)`, "", 2),
+		d(`^template\s*<.*>\s*(class|struct)\s+(\w+)`, "", 2),
+		d(`^(?:[\w:*&<>,]+\s+)*?[*&]*(~?[\w:]+)\s*\([^;]*$`, "function", 1),
+	}
+
+	languages = map[string]*language{
+		"go": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			decls: []declPattern{
+				{re: regexp.MustCompile(`^func\s+\(\s*(?:\w+\s+)?\*?(\w+)(?:\[[^\]]*\])?\s*\)\s*(\w+)`), kind: "method", name: 2, receiver: 1},
+				d(`^func\s+(\w+)`, "function", 1),
+				d(`^type\s+(\w+)`, "type", 1),
+			},
+		},
+		"python": {
+			style:       blockIndent,
+			lineComment: "#",
+			containers:  []string{"class"},
+			decls: []declPattern{
+				d(`^(?:async\s+)?def\s+(\w+)`, "function", 1),
+				d(`^class\s+(\w+)`, "class", 1),
+			},
+		},
+		"javascript": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "namespace"},
+			decls:         jsDecls,
+			members:       jsMembers,
+		},
+		"typescript": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "namespace", "interface"},
+			decls:         jsDecls,
+			members:       jsMembers,
+		},
+		"java": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "interface", "enum", "record"},
+			decls:         javaLikeDecls,
+		},
+		"csharp": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "interface", "struct", "record", "namespace"},
+			decls:         javaLikeDecls,
+		},
+		"kotlin": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "interface", "object", "enum"},
+			decls:         javaLikeDecls,
+		},
+		"scala": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "object", "trait"},
+			decls: []declPattern{
+				d(`^(?:(?:case|abstract|sealed|final|implicit|private|protected)\s+)*(class|object|trait)\s+(\w+)`, "", 2),
+				d(`^(?:(?:override|private|protected|final|implicit)\s+)*def\s+(\w+)`, "function", 1),
+			},
+		},
+		"c": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			decls:         cDecls,
+		},
+		"cpp": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "struct", "namespace"},
+			decls:         cDecls,
+		},
+		"rust": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"impl", "trait", "mod"},
+			decls: []declPattern{
+				d(`^(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(\w+)`, "function", 1),
+				d(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(struct|enum|trait|mod|union)\s+(\w+)`, "", 2),
+				d(`^(?:unsafe\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?([\w:]+)`, "impl", 1),
+				d(`^macro_rules!\s*(\w+)`, "macro", 1),
+			},
+		},
+		"php": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "interface", "trait"},
+			decls: []declPattern{
+				d(`^(?:(?:abstract|final|readonly)\s+)*(class|interface|trait|enum)\s+(\w+)`, "", 2),
+				d(`^(?:(?:public|private|protected|static|abstract|final)\s+)*function\s+&?(\w+)`, "function", 1),
+			},
+		},
+		"swift": {
+			style:         blockBraces,
+			lineComment:   "//",
+			blockComments: true,
+			containers:    []string{"class", "struct", "enum", "extension", "protocol", "actor"},
+			decls: []declPattern{
+				d(`^(?:@\w+\s+)*(?:(?:public|private|fileprivate|internal|open|final|indirect)\s+)*(class|struct|enum|extension|protocol|actor)\s+(\w+)`, "", 2),
+				d(`^(?:@\w+\s+)*(?:(?:public|private|fileprivate|internal|open|final|static|class|override|mutating|convenience|required)\s+)*(?:func|init)\s*(\w*)`, "function", 1),
+			},
+		},
+	}
+
+	extensions = map[string]string{
+		".go":    "go",
+		".py":    "python",
+		".js":    "javascript",
+		".jsx":   "javascript",
+		".mjs":   "javascript",
+		".cjs":   "javascript",
+		".ts":    "typescript",
+		".tsx":   "typescript",
+		".java":  "java",
+		".cs":    "csharp",
+		".kt":    "kotlin",
+		".kts":   "kotlin",
+		".scala": "scala",
+		".c":     "c",
+		".h":     "c",
+		".cpp":   "cpp",
+		".cc":    "cpp",
+		".cxx":   "cpp",
+		".hpp":   "cpp",
+		".rs":    "rust",
+		".php":   "php",
+		".swift": "swift",
+	}
+)
+
+func init() {
+	for name, l := range languages {
+		l.name = name
+	}
+}
+
+// LanguageFromFilename returns the programming language for the file extension, or "" if it's unknown.
+func LanguageFromFilename(filename string) string {
+	return extensions[strings.ToLower(path.Ext(filename))]
+}
+
+// Extensions returns the file extensions of all supported languages.
+func Extensions() []string {
+	exts := make([]string, 0, len(extensions))
+	for ext := range extensions {
+		exts = append(exts, ext)
+	}
+	return exts
+}
@@ -0,0 +1,115 @@
+package code
+
+import (
+	"strings"
+)
+
+type lineInfo struct {
+	tokens       []byte // brackets and semicolons outside of strings and comments
+	depth        int    // brace depth at the start of the line
+	indent       int
+	blank        bool
+	continuation bool // starts inside a multi-line string or comment, or inside parentheses (python)
+	attachable   bool // comment, annotation or decorator line that belongs to the following declaration
+}
+
+// scan tokenizes the lines just enough to find the block structure: strings and comments are skipped,
+// brackets and semicolons are recorded.
+func scan(lang *language, lines []string) []lineInfo {
+	infos := make([]lineInfo, len(lines))
+
+	singleQuoteStrings := lang.name == "python" || lang.name == "javascript" || lang.name == "typescript" || lang.name == "php"
+	backtickStrings := lang.name == "go" || lang.name == "javascript" || lang.name == "typescript"
+
+	var inBlockComment bool
+	var strDelim string // delimiter of the open multi-line string
+	depth, paren := 0, 0
+
+	for n, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		info := lineInfo{
+			depth:        depth,
+			indent:       len(line) - len(strings.TrimLeft(line, " \t")),
+			blank:        trimmed == "",
+			continuation: inBlockComment || strDelim != "" || (lang.style == blockIndent && paren > 0),
+		}
+		info.attachable = !info.blank && (inBlockComment ||
+			strings.HasPrefix(trimmed, lang.lineComment) ||
+			(lang.blockComments && (strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*"))) ||
+			strings.HasPrefix(trimmed, "@") ||
+			strings.HasPrefix(trimmed, "#[") ||
+			(lang.name == "csharp" && strings.HasPrefix(trimmed, "[")))
+
+		for i := 0; i < len(line); i++ {
+			ch := line[i]
+
+			if inBlockComment {
+				if strings.HasPrefix(line[i:], "*/") {
+					inBlockComment = false
+					i++
+				}
+				continue
+			}
+
+			if strDelim != "" {
+				if ch == '\\' && strDelim != "`" {
+					i++
+					continue
+				}
+				if strings.HasPrefix(line[i:], strDelim) {
+					i += len(strDelim) - 1
+					strDelim = ""
+				}
+				continue
+			}
+
+			switch {
+			case strings.HasPrefix(line[i:], lang.lineComment):
+				i = len(line)
+			case lang.blockComments && strings.HasPrefix(line[i:], "/*"):
+				inBlockComment = true
+				i++
+			case lang.style == blockIndent && (strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], `'''`)):
+				strDelim = line[i : i+3]
+				i += 2
+			case ch == '"' || (ch == '\'' && singleQuoteStrings) || (ch == '`' && backtickStrings):
+				strDelim = string(ch)
+			case ch == '\'':
+				// character literal, or e.g. a Rust lifetime
+				if i+1 < len(line) && line[i+1] == '\\' {
+					if end := strings.IndexByte(line[i+2:], '\''); end >= 0 {
+						i += end + 2
+					}
+				} else if i+2 < len(line) && line[i+2] == '\'' {
+					i += 2
+				}
+			case ch == '{':
+				depth++
+				info.tokens = append(info.tokens, ch)
+			case ch == '}':
+				depth--
+				info.tokens = append(info.tokens, ch)
+			case ch == '(' || ch == '[':
+				paren++
+				info.tokens = append(info.tokens, ch)
+			case ch == ')' || ch == ']':
+				paren--
+				info.tokens = append(info.tokens, ch)
+			case ch == ';':
+				info.tokens = append(info.tokens, ch)
+			}
+		}
+
+		// only raw strings, template literals and triple-quoted strings span multiple lines
+		if strDelim == `"` || strDelim == "'" {
+			strDelim = ""
+		}
+		if paren < 0 {
+			paren = 0
+		}
+
+		infos[n] = info
+	}
+
+	return infos
+}
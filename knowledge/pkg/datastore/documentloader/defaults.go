@@ -1,6 +1,7 @@
 package documentloader
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
 	"errors"
@@ -11,6 +12,14 @@ import (
 
 	"code.sajari.com/docconv/v2"
 	pdfdefaults "github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pdf/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/code"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/email"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/epub"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/jsonloader"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/markdown"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pptx"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/spreadsheet"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/filetypes"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	golcdocloaders "github.com/hupe1980/golc/documentloader"
 	"github.com/lu4p/cat/rtftxt"
@@ -53,7 +62,7 @@ func DefaultDocLoaderFunc(filetype string, opts DefaultDocLoaderFuncOpts) Loader
 		}
 	case ".md", "text/markdown":
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
-			return FromLangchain(lcgodocloaders.NewText(reader)).Load(ctx)
+			return markdown.NewMarkdown(reader).Load(ctx)
 		}
 	case ".txt", "text/plain":
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
@@ -75,14 +84,56 @@ func DefaultDocLoaderFunc(filetype string, opts DefaultDocLoaderFuncOpts) Loader
 			}
 			return docs, err
 		}
-	case ".json", "application/json":
+	case ".xlsx", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
-			return FromLangchain(lcgodocloaders.NewText(reader)).Load(ctx)
+			r, err := spreadsheet.NewSpreadsheetFromReader(reader, func(o *spreadsheet.SpreadsheetOptions) {
+				o.Format = spreadsheet.FormatXLSX
+			})
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}
+	case ".json", "application/json", ".jsonl", ".ndjson", "application/jsonl", "application/x-ndjson":
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := jsonloader.NewJSONFromReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
 		}
 	case ".ipynb":
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
 			return FromGolc(golcdocloaders.NewNotebook(reader)).Load(ctx)
 		}
+	case ".pptx", "application/vnd.openxmlformats-officedocument.presentationml.presentation":
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := pptx.NewPPTXFromReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}
+	case ".epub", "application/epub+zip":
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := epub.NewEPUBFromReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}
+	case ".eml", "message/rfc822", ".msg", "application/vnd.ms-outlook":
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := email.NewEmailFromReader(reader, email.WithAttachmentLoader(EmailAttachmentLoader(opts)))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}
+	case ".go", ".py", ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx", ".java", ".cs", ".kt", ".kts", ".scala", ".c", ".h", ".cpp", ".cc", ".cxx", ".hpp", ".rs", ".php", ".swift":
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			return code.NewCode(reader, code.WithLanguage(code.LanguageFromFilename(filetype))).Load(ctx)
+		}
 	case ".docx", ".odt", ".rtf", "text/rtf", "application/vnd.oasis.opendocument.text", "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
 			var text string
@@ -125,3 +176,18 @@ func DefaultDocLoaderFunc(filetype string, opts DefaultDocLoaderFuncOpts) Loader
 		return nil
 	}
 }
+
+// EmailAttachmentLoader returns a loader for email attachments, which uses the default document loader of their filetype
+func EmailAttachmentLoader(opts DefaultDocLoaderFuncOpts) email.AttachmentLoaderFunc {
+	return func(ctx context.Context, filename string, data []byte) ([]vs.Document, error) {
+		filetype, err := filetypes.GetFiletype(filename, data)
+		if err != nil {
+			return nil, err
+		}
+		loader := DefaultDocLoaderFunc(filetype, opts)
+		if loader == nil {
+			return nil, &UnsupportedFileTypeError{FileType: filetype}
+		}
+		return loader(ctx, bytes.NewReader(data))
+	}
+}
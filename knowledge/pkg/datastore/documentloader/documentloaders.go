@@ -9,7 +9,18 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/audio"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/code"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/email"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/epub"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/external"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/jsonloader"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/markdown"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/ocr/imageocr"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pdf/gopdf"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pptx"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/readability"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/spreadsheet"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/structured"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 
@@ -24,9 +35,11 @@ func GetDocumentLoaderConfig(name string) (any, error) {
 	case "plaintext":
 		return nil, nil
 	case "markdown":
-		return nil, nil
+		return markdown.MarkdownOptions{}, nil
 	case "html":
 		return nil, nil
+	case "readability":
+		return readability.ReadabilityOptions{}, nil
 	case "pdf", "gopdf":
 		return gopdf.PDFOptions{}, nil
 	case "ocr_openai":
@@ -34,6 +47,10 @@ func GetDocumentLoaderConfig(name string) (any, error) {
 			return nil, fmt.Errorf("OpenAI OCR is not available")
 		}
 		return OpenAIOCRConfig, nil
+	case "ocr_image":
+		return imageocr.ImageOCROptions{}, nil
+	case "transcription":
+		return audio.TranscriptionOptions{}, nil
 	case "mupdf":
 		if MuPDFConfig == nil {
 			return nil, fmt.Errorf("MuPDF is not available")
@@ -44,12 +61,26 @@ func GetDocumentLoaderConfig(name string) (any, error) {
 			return nil, fmt.Errorf("SmartPDF is not available")
 		}
 		return SmartPDFConfig, nil
+	case "pptx":
+		return pptx.PPTXOptions{}, nil
 	case "csv":
 		return golcdocloaders.CSVOptions{}, nil
+	case "spreadsheet":
+		return spreadsheet.SpreadsheetOptions{}, nil
 	case "notebook":
 		return golcdocloaders.NotebookOptions{}, nil
+	case "epub":
+		return epub.EPUBOptions{}, nil
+	case "json":
+		return jsonloader.JSONOptions{}, nil
+	case "code":
+		return code.CodeOptions{}, nil
+	case "email":
+		return email.EmailOptions{}, nil
 	case "structured":
 		return structured.Structured{}, nil
+	case "external":
+		return external.ExternalOptions{}, nil
 	default:
 		return nil, fmt.Errorf("unknown document loader %q", name)
 	}
@@ -68,13 +99,23 @@ var SmartPDFConfig any
 
 func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 	switch name {
-	case "plaintext", "markdown":
+	case "plaintext":
 		if config != nil {
-			return nil, fmt.Errorf("plaintext/markdown document loader does not accept configuration")
+			return nil, fmt.Errorf("plaintext document loader does not accept configuration")
 		}
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
 			return FromLangchain(lcgodocloaders.NewText(reader)).Load(ctx)
 		}, nil
+	case "markdown": // front matter is parsed into the metadata
+		var markdownConfig markdown.MarkdownOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &markdownConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode markdown document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			return markdown.NewMarkdown(reader, markdown.WithConfig(markdownConfig)).Load(ctx)
+		}, nil
 	case "html":
 		if config != nil {
 			return nil, fmt.Errorf("html document loader does not accept configuration")
@@ -82,11 +123,57 @@ func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
 			return FromLangchain(lcgodocloaders.NewHTML(reader)).Load(ctx)
 		}, nil
+	case "readability": // html with boilerplate removal
+		var readabilityConfig readability.ReadabilityOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &readabilityConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode readability document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := readability.NewReadabilityFromReader(reader, readability.WithConfig(readabilityConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	case "ocr_openai":
 		if OpenAIOCRGetter == nil {
 			return nil, fmt.Errorf("OpenAI OCR is not available")
 		}
 		return OpenAIOCRGetter(config)
+	case "ocr_image": // png, jpeg, tiff, ... via tesseract or an external OCR endpoint
+		var ocrConfig imageocr.ImageOCROptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &ocrConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode image OCR document loader configuration: %w", err)
+			}
+		}
+		// fail early on invalid configuration
+		if _, err := imageocr.NewImageOCR(nil, imageocr.WithConfig(ocrConfig)); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := imageocr.NewImageOCR(reader, imageocr.WithConfig(ocrConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
+	case "transcription": // mp3, wav, m4a, ... via a Whisper-compatible API
+		var transcriptionConfig audio.TranscriptionOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &transcriptionConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode transcription document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := audio.NewTranscription(reader, audio.WithConfig(transcriptionConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	case "mupdf":
 		if MuPDFGetter == nil {
 			return nil, fmt.Errorf("MuPDF is not available")
@@ -114,7 +201,20 @@ func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 			}
 			return r.Load(ctx)
 		}, nil
-
+	case "pptx":
+		var pptxConfig pptx.PPTXOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &pptxConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode PPTX document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := pptx.NewPPTXFromReader(reader, pptx.WithConfig(pptxConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	case "csv":
 		var csvConfig golcdocloaders.CSVOptions
 		if config != nil {
@@ -140,6 +240,20 @@ func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 			}
 			return docs, err
 		}, nil
+	case "spreadsheet": // csv, xlsx
+		var spreadsheetConfig spreadsheet.SpreadsheetOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &spreadsheetConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode spreadsheet document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := spreadsheet.NewSpreadsheetFromReader(reader, spreadsheet.WithConfig(spreadsheetConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	case "notebook":
 		var nbConfig golcdocloaders.NotebookOptions
 		if config != nil {
@@ -152,6 +266,62 @@ func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 				*o = nbConfig
 			})).Load(ctx)
 		}, nil
+	case "epub":
+		var epubConfig epub.EPUBOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &epubConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode EPUB document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := epub.NewEPUBFromReader(reader, epub.WithConfig(epubConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
+	case "json": // json, jsonl
+		var jsonConfig jsonloader.JSONOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &jsonConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode JSON document loader configuration: %w", err)
+			}
+		}
+		// fail early on invalid selectors
+		if _, err := jsonloader.NewJSON(nil, jsonloader.WithConfig(jsonConfig)); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := jsonloader.NewJSONFromReader(reader, jsonloader.WithConfig(jsonConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
+	case "code": // source code, chunked along declarations
+		var codeConfig code.CodeOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &codeConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode code document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			return code.NewCode(reader, code.WithConfig(codeConfig)).Load(ctx)
+		}, nil
+	case "email":
+		var emailConfig email.EmailOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &emailConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode email document loader configuration: %w", err)
+			}
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := email.NewEmailFromReader(reader, email.WithAttachmentLoader(EmailAttachmentLoader(DefaultDocLoaderFuncOpts{})), email.WithConfig(emailConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	case "document": // doc, docx, odt, rtf
 		if config != nil {
 			return nil, fmt.Errorf("'document' document loader does not accept configuration")
@@ -175,6 +345,24 @@ func GetDocumentLoaderFunc(name string, config any) (LoaderFunc, error) {
 			}
 		}
 		return structuredCfg.Load, nil
+	case "external": // external converter command or service returning JSON documents
+		var externalConfig external.ExternalOptions
+		if config != nil {
+			if err := mapstructure.Decode(config, &externalConfig); err != nil {
+				return nil, fmt.Errorf("failed to decode external document loader configuration: %w", err)
+			}
+		}
+		// fail early on invalid configuration
+		if _, err := external.NewExternal(nil, external.WithConfig(externalConfig)); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
+			r, err := external.NewExternal(reader, external.WithConfig(externalConfig))
+			if err != nil {
+				return nil, err
+			}
+			return r.Load(ctx)
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown document loader %q", name)
 	}
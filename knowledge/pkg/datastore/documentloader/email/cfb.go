@@ -0,0 +1,250 @@
+package email
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+/*
+ * Minimal read-only implementation of the Compound File Binary format (MS-CFB), the container format
+ * of Outlook .msg files. Only what's needed to read streams by their path is implemented.
+ */
+
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+const (
+	cfbEndOfChain = 0xFFFFFFFE
+	cfbNoStream   = 0xFFFFFFFF
+
+	cfbTypeStorage = 1
+	cfbTypeStream  = 2
+	cfbTypeRoot    = 5
+)
+
+type cfbEntry struct {
+	name    string
+	typ     byte
+	left    uint32
+	right   uint32
+	child   uint32
+	start   uint32
+	size    uint64
+	entries map[string]*cfbEntry // children of storages, keyed by name
+}
+
+type cfbFile struct {
+	data             []byte
+	sectorSize       int
+	miniSectorSize   int
+	miniStreamCutoff uint64
+	fat              []uint32
+	miniFAT          []uint32
+	miniStream       []byte
+	root             *cfbEntry
+}
+
+func isCFB(data []byte) bool {
+	return bytes.HasPrefix(data, cfbSignature)
+}
+
+func openCFB(data []byte) (*cfbFile, error) {
+	if len(data) < 512 || !isCFB(data) {
+		return nil, errors.New("not a compound file")
+	}
+
+	le := binary.LittleEndian
+	f := &cfbFile{
+		data:             data,
+		sectorSize:       1 << le.Uint16(data[0x1E:]),
+		miniSectorSize:   1 << le.Uint16(data[0x20:]),
+		miniStreamCutoff: uint64(le.Uint32(data[0x38:])),
+	}
+	if f.sectorSize != 512 && f.sectorSize != 4096 {
+		return nil, fmt.Errorf("invalid sector size %d", f.sectorSize)
+	}
+
+	numFATSectors := le.Uint32(data[0x2C:])
+	firstDirSector := le.Uint32(data[0x30:])
+	firstMiniFATSector := le.Uint32(data[0x3C:])
+	firstDIFATSector := le.Uint32(data[0x44:])
+
+	// the sector allocation table (FAT) is spread over the sectors listed in the DIFAT,
+	// the first 109 of which are stored in the header
+	var fatSectors []uint32
+	for i := 0; i < 109; i++ {
+		if s := le.Uint32(data[0x4C+4*i:]); s < cfbEndOfChain {
+			fatSectors = append(fatSectors, s)
+		}
+	}
+	for s, n := firstDIFATSector, 0; s < cfbEndOfChain && n < f.numSectors(); n++ {
+		sector, err := f.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		entries := f.sectorSize/4 - 1
+		for i := 0; i < entries; i++ {
+			if fs := le.Uint32(sector[4*i:]); fs < cfbEndOfChain {
+				fatSectors = append(fatSectors, fs)
+			}
+		}
+		s = le.Uint32(sector[4*entries:])
+	}
+	if uint32(len(fatSectors)) < numFATSectors {
+		return nil, fmt.Errorf("incomplete sector allocation table")
+	}
+
+	for _, s := range fatSectors[:numFATSectors] {
+		sector, err := f.sector(s)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < f.sectorSize/4; i++ {
+			f.fat = append(f.fat, le.Uint32(sector[4*i:]))
+		}
+	}
+
+	dir, err := f.chain(firstDirSector, f.fat, f.sectorSize, f.sector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	entries := make([]*cfbEntry, 0, len(dir)/128)
+	for off := 0; off+128 <= len(dir); off += 128 {
+		e := dir[off : off+128]
+		nameLen := min(int(le.Uint16(e[0x40:])), 64)
+		u16 := make([]uint16, 0, nameLen/2)
+		for i := 0; i+1 < nameLen; i += 2 {
+			if c := le.Uint16(e[i:]); c != 0 {
+				u16 = append(u16, c)
+			}
+		}
+		size := le.Uint64(e[0x78:])
+		if f.sectorSize == 512 {
+			size &= 0xFFFFFFFF // the high part is undefined in version 3 files
+		}
+		entries = append(entries, &cfbEntry{
+			name:  string(utf16.Decode(u16)),
+			typ:   e[0x42],
+			left:  le.Uint32(e[0x44:]),
+			right: le.Uint32(e[0x48:]),
+			child: le.Uint32(e[0x4C:]),
+			start: le.Uint32(e[0x74:]),
+			size:  size,
+		})
+	}
+	if len(entries) == 0 || entries[0].typ != cfbTypeRoot {
+		return nil, fmt.Errorf("root entry not found")
+	}
+	f.root = entries[0]
+
+	// build the storage tree - the children of a storage are kept in a binary tree via the sibling pointers
+	visited := make([]bool, len(entries))
+	var collect func(storage *cfbEntry, id uint32) error
+	collect = func(storage *cfbEntry, id uint32) error {
+		if id == cfbNoStream {
+			return nil
+		}
+		if int(id) >= len(entries) || visited[id] {
+			return fmt.Errorf("invalid directory entry %d", id)
+		}
+		visited[id] = true
+		e := entries[id]
+		storage.entries[e.name] = e
+		if e.typ == cfbTypeStorage {
+			e.entries = map[string]*cfbEntry{}
+			if err := collect(e, e.child); err != nil {
+				return err
+			}
+		}
+		if err := collect(storage, e.left); err != nil {
+			return err
+		}
+		return collect(storage, e.right)
+	}
+	visited[0] = true
+	f.root.entries = map[string]*cfbEntry{}
+	if err := collect(f.root, f.root.child); err != nil {
+		return nil, err
+	}
+
+	// small streams are stored in the mini stream, which is the stream of the root entry
+	if f.root.size > 0 {
+		if f.miniStream, err = f.chain(f.root.start, f.fat, f.sectorSize, f.sector); err != nil {
+			return nil, fmt.Errorf("failed to read mini stream: %w", err)
+		}
+		miniFAT, err := f.chain(firstMiniFATSector, f.fat, f.sectorSize, f.sector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mini sector allocation table: %w", err)
+		}
+		for i := 0; i+4 <= len(miniFAT); i += 4 {
+			f.miniFAT = append(f.miniFAT, le.Uint32(miniFAT[i:]))
+		}
+	}
+
+	return f, nil
+}
+
+func (f *cfbFile) numSectors() int {
+	return len(f.data)/f.sectorSize - 1
+}
+
+func (f *cfbFile) sector(s uint32) ([]byte, error) {
+	off := (int(s) + 1) * f.sectorSize
+	if int(s) >= f.numSectors() || off+f.sectorSize > len(f.data) {
+		return nil, fmt.Errorf("sector %d out of range", s)
+	}
+	return f.data[off : off+f.sectorSize], nil
+}
+
+func (f *cfbFile) miniSector(s uint32) ([]byte, error) {
+	off := int(s) * f.miniSectorSize
+	if off+f.miniSectorSize > len(f.miniStream) {
+		return nil, fmt.Errorf("mini sector %d out of range", s)
+	}
+	return f.miniStream[off : off+f.miniSectorSize], nil
+}
+
+// chain concatenates the sectors of a chain in the given allocation table
+func (f *cfbFile) chain(start uint32, table []uint32, sectorSize int, read func(uint32) ([]byte, error)) ([]byte, error) {
+	var buf []byte
+	for s, n := start, 0; s != cfbEndOfChain && s != cfbNoStream; n++ {
+		if n > len(table) || int(s) >= len(table) {
+			return nil, fmt.Errorf("invalid sector chain")
+		}
+		data, err := read(s)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data[:sectorSize]...)
+		s = table[s]
+	}
+	return buf, nil
+}
+
+// stream returns the content of the stream entry
+func (f *cfbFile) stream(e *cfbEntry) ([]byte, error) {
+	if e.typ != cfbTypeStream {
+		return nil, fmt.Errorf("%q is not a stream", e.name)
+	}
+	if e.size == 0 {
+		return nil, nil
+	}
+
+	var data []byte
+	var err error
+	if e.size < f.miniStreamCutoff {
+		data, err = f.chain(e.start, f.miniFAT, f.miniSectorSize, f.miniSector)
+	} else {
+		data, err = f.chain(e.start, f.fat, f.sectorSize, f.sector)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stream %q: %w", e.name, err)
+	}
+	if uint64(len(data)) < e.size {
+		return nil, fmt.Errorf("stream %q is truncated", e.name)
+	}
+	return data[:e.size], nil
+}
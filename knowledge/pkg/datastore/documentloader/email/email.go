@@ -0,0 +1,375 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/net/html/charset"
+)
+
+// Compile time check to ensure Email satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Email)(nil)
+
+const defaultMaxAttachmentSize = 20 * 1024 * 1024
+
+// AttachmentLoaderFunc loads the documents of an attachment, e.g. using the default document loader for its filetype
+type AttachmentLoaderFunc func(ctx context.Context, filename string, data []byte) ([]vs.Document, error)
+
+type EmailOptions struct {
+	// IncludeAttachments loads the attachments through the document loader of their filetype
+	IncludeAttachments bool `mapstructure:"includeAttachments" json:"includeAttachments,omitempty"`
+
+	// MaxAttachmentSize is the maximum size of attachments to load in bytes (default: 20MiB)
+	MaxAttachmentSize int64 `mapstructure:"maxAttachmentSize" json:"maxAttachmentSize,omitempty"`
+
+	// AttachmentLoader is used to load attachments - set by the document loader registry
+	AttachmentLoader AttachmentLoaderFunc `mapstructure:"-" json:"-"`
+}
+
+// WithConfig sets the email loader configuration, keeping an already configured attachment loader.
+func WithConfig(config EmailOptions) func(o *EmailOptions) {
+	return func(o *EmailOptions) {
+		loader := o.AttachmentLoader
+		*o = config
+		if o.AttachmentLoader == nil {
+			o.AttachmentLoader = loader
+		}
+	}
+}
+
+// WithAttachmentLoader sets the loader used for attachments.
+func WithAttachmentLoader(fn AttachmentLoaderFunc) func(o *EmailOptions) {
+	return func(o *EmailOptions) {
+		o.AttachmentLoader = fn
+	}
+}
+
+// Email is a document loader for RFC822 (.eml) and Outlook (.msg) files that implements the DocumentLoader interface.
+// It emits one document for the message body with the headers (from, to, cc, subject, date) in the metadata,
+// plus the documents of the attachments, if configured.
+type Email struct {
+	data      []byte
+	opts      EmailOptions
+	converter *mdconv.Converter
+}
+
+type message struct {
+	from        string
+	to          []string
+	cc          []string
+	subject     string
+	messageID   string
+	date        time.Time
+	text        string
+	html        string
+	attachments []attachment
+}
+
+type attachment struct {
+	filename string
+	data     []byte
+}
+
+func NewEmailFromReader(r io.Reader, optFns ...func(o *EmailOptions)) (*Email, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email data: %w", err)
+	}
+	return NewEmail(data, optFns...), nil
+}
+
+// NewEmail creates a new email loader with the given options.
+func NewEmail(data []byte, optFns ...func(o *EmailOptions)) *Email {
+	opts := EmailOptions{
+		MaxAttachmentSize: defaultMaxAttachmentSize,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.MaxAttachmentSize <= 0 {
+		opts.MaxAttachmentSize = defaultMaxAttachmentSize
+	}
+
+	return &Email{
+		data:      data,
+		opts:      opts,
+		converter: mdconv.NewConverter(mdconv.WithPlugins(base.NewBasePlugin(), commonmark.NewCommonmarkPlugin())),
+	}
+}
+
+// Load parses the email (.msg if it's a compound file, .eml otherwise) and returns its documents.
+func (l *Email) Load(ctx context.Context) ([]vs.Document, error) {
+	var msg *message
+	var err error
+	if isCFB(l.data) {
+		msg, err = parseMSG(l.data)
+	} else {
+		msg, err = parseEML(l.data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{}
+	if msg.subject != "" {
+		metadata["subject"] = msg.subject
+	}
+	if msg.from != "" {
+		metadata["from"] = msg.from
+	}
+	if len(msg.to) > 0 {
+		metadata["to"] = strings.Join(msg.to, ", ")
+	}
+	if len(msg.cc) > 0 {
+		metadata["cc"] = strings.Join(msg.cc, ", ")
+	}
+	if !msg.date.IsZero() {
+		metadata["date"] = msg.date.Format(time.RFC3339)
+	}
+	if msg.messageID != "" {
+		metadata["messageID"] = strings.Trim(msg.messageID, "<>")
+	}
+
+	body := strings.TrimSpace(msg.text)
+	if body == "" && msg.html != "" {
+		md, err := l.converter.ConvertString(msg.html)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert HTML body to markdown: %w", err)
+		}
+		body = strings.TrimSpace(md)
+	}
+
+	var names []string
+	for _, a := range msg.attachments {
+		if a.filename != "" {
+			names = append(names, a.filename)
+		}
+	}
+
+	bodyMetadata := copyMetadata(metadata)
+	if len(names) > 0 {
+		bodyMetadata["attachments"] = names
+	}
+
+	// the headers are part of the content, so that e.g. "the mail from Jane about the offsite" can be found
+	var header strings.Builder
+	for _, h := range []struct{ name, value string }{
+		{"Subject", msg.subject},
+		{"From", msg.from},
+		{"To", strings.Join(msg.to, ", ")},
+		{"Cc", strings.Join(msg.cc, ", ")},
+		{"Date", metadataString(metadata, "date")},
+	} {
+		if h.value != "" {
+			fmt.Fprintf(&header, "%s: %s\n", h.name, h.value)
+		}
+	}
+
+	docs := []vs.Document{{
+		Content:  strings.TrimSpace(header.String() + "\n" + body),
+		Metadata: bodyMetadata,
+	}}
+
+	if l.opts.IncludeAttachments {
+		if l.opts.AttachmentLoader == nil {
+			return nil, errors.New("no attachment loader configured")
+		}
+		for _, a := range msg.attachments {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log := slog.With("attachment", a.filename, "size", len(a.data))
+			if int64(len(a.data)) > l.opts.MaxAttachmentSize {
+				log.Warn("Skipping attachment exceeding the maximum size", "maxSize", l.opts.MaxAttachmentSize)
+				continue
+			}
+			attachmentDocs, err := l.opts.AttachmentLoader(ctx, a.filename, a.data)
+			if err != nil {
+				log.Warn("Skipping attachment that couldn't be loaded", "error", err)
+				continue
+			}
+			for _, d := range attachmentDocs {
+				md := copyMetadata(metadata)
+				for k, v := range d.Metadata {
+					md[k] = v
+				}
+				md["attachment"] = a.filename
+				d.Metadata = md
+				docs = append(docs, d)
+			}
+		}
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+func parseEML(data []byte) (*message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	dec := &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+	decodeHeader := func(name string) string {
+		v := m.Header.Get(name)
+		if d, err := dec.DecodeHeader(v); err == nil {
+			return d
+		}
+		return v
+	}
+	addresses := func(name string) []string {
+		list, err := (&mail.AddressParser{WordDecoder: dec}).ParseList(m.Header.Get(name))
+		if err != nil {
+			if v := decodeHeader(name); v != "" {
+				return []string{v}
+			}
+			return nil
+		}
+		res := make([]string, len(list))
+		for i, a := range list {
+			res[i] = formatAddress(a.Name, a.Address)
+		}
+		return res
+	}
+
+	msg := &message{
+		subject:   decodeHeader("Subject"),
+		messageID: m.Header.Get("Message-Id"),
+		to:        addresses("To"),
+		cc:        addresses("Cc"),
+	}
+	if from := addresses("From"); len(from) > 0 {
+		msg.from = strings.Join(from, ", ")
+	}
+	if d, err := m.Header.Date(); err == nil {
+		msg.date = d
+	}
+
+	if err := msg.walkPart(textproto.MIMEHeader(m.Header), m.Body, dec); err != nil {
+		return nil, fmt.Errorf("failed to parse email body: %w", err)
+	}
+	return msg, nil
+}
+
+// walkPart collects the text bodies and attachments of a MIME part and its sub-parts
+func (msg *message) walkPart(header textproto.MIMEHeader, body io.Reader, dec *mime.WordDecoder) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := msg.walkPart(p.Header, p, dec); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if d, err := dec.DecodeHeader(filename); err == nil {
+		filename = d
+	}
+
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if disposition == "attachment" || filename != "" || !isText {
+		if mediaType == "message/rfc822" && filename == "" {
+			filename = "attachment.eml"
+		}
+		msg.attachments = append(msg.attachments, attachment{filename: filename, data: content})
+		return nil
+	}
+
+	text := string(content)
+	if cs := params["charset"]; cs != "" && !strings.EqualFold(cs, "utf-8") && !strings.EqualFold(cs, "us-ascii") {
+		if r, err := charset.NewReaderLabel(cs, bytes.NewReader(content)); err == nil {
+			if decoded, err := io.ReadAll(r); err == nil {
+				text = string(decoded)
+			}
+		}
+	}
+
+	if mediaType == "text/html" {
+		msg.html += text
+	} else {
+		msg.text += text
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r) // line breaks are ignored by the decoder
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// formatAddress formats the address as "Name <address>" - unlike mail.Address.String, non-ASCII names are kept as is
+func formatAddress(name, address string) string {
+	switch {
+	case name == "" || name == address:
+		return address
+	case address == "":
+		return name
+	}
+	return fmt.Sprintf("%s <%s>", name, address)
+}
+
+func metadataString(metadata map[string]any, key string) string {
+	if v, ok := metadata[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func copyMetadata(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
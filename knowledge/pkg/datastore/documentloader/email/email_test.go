@@ -0,0 +1,255 @@
+package email
+
+import (
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEML = "From: =?utf-8?q?J=C3=BCrgen_M=C3=BCller?= <juergen@example.com>\r\n" +
+	"To: Jane Doe <jane@example.com>, bob@example.com\r\n" +
+	"Cc: team@example.com\r\n" +
+	"Subject: =?utf-8?q?Offsite_agenda_=E2=9C=93?=\r\n" +
+	"Date: Tue, 03 Sep 2024 10:15:00 +0200\r\n" +
+	"Message-ID: <abc123@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Hi all, the offsite starts at 9 in the caf=E9.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Hi all, the offsite starts at 9 in the café.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=\"agenda.txt\"\r\n" +
+	"Content-Disposition: attachment; filename=\"agenda.txt\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"MS4gV2VsY29tZQoyLiBSb2Fk\r\nbWFw\r\n" +
+	"--outer--\r\n"
+
+func testAttachmentLoader(_ context.Context, filename string, data []byte) ([]vs.Document, error) {
+	return []vs.Document{{Content: string(data), Metadata: map[string]any{"filename": filename}}}, nil
+}
+
+func TestLoadEML(t *testing.T) {
+	docs, err := NewEmail([]byte(testEML), WithAttachmentLoader(testAttachmentLoader), WithConfig(EmailOptions{IncludeAttachments: true})).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	body := docs[0]
+	assert.True(t, strings.HasPrefix(body.Content, "Subject: Offsite agenda ✓\nFrom: Jürgen Müller <juergen@example.com>\n"), body.Content)
+	assert.Contains(t, body.Content, "starts at 9 in the café.")
+	assert.NotContains(t, body.Content, "<p>")
+	assert.Equal(t, "Jürgen Müller <juergen@example.com>", body.Metadata["from"])
+	assert.Equal(t, "Jane Doe <jane@example.com>, bob@example.com", body.Metadata["to"])
+	assert.Equal(t, "team@example.com", body.Metadata["cc"])
+	assert.Equal(t, "Offsite agenda ✓", body.Metadata["subject"])
+	assert.Equal(t, "2024-09-03T10:15:00+02:00", body.Metadata["date"])
+	assert.Equal(t, "abc123@example.com", body.Metadata["messageID"])
+	assert.Equal(t, []string{"agenda.txt"}, body.Metadata["attachments"])
+
+	att := docs[1]
+	assert.Equal(t, "1. Welcome\n2. Roadmap", att.Content)
+	assert.Equal(t, "agenda.txt", att.Metadata["attachment"])
+	assert.Equal(t, "Offsite agenda ✓", att.Metadata["subject"])
+	assert.Equal(t, 1, att.Metadata["docIndex"])
+}
+
+func TestLoadEMLWithoutAttachments(t *testing.T) {
+	docs, err := NewEmail([]byte(testEML)).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+}
+
+func TestLoadMSG(t *testing.T) {
+	recipient := func(name, address string, typ uint32) cfbTestEntry {
+		props := make([]byte, 8+16)
+		binary.LittleEndian.PutUint32(props[8:], msgPropIDRecipientType<<16|0x0003)
+		binary.LittleEndian.PutUint32(props[16:], typ)
+		return cfbTestEntry{children: []cfbTestEntry{
+			{name: "__substg1.0_3001001F", data: utf16le(name)},
+			{name: "__substg1.0_39FE001F", data: utf16le(address)},
+			{name: "__properties_version1.0", data: props},
+		}}
+	}
+
+	rootProps := make([]byte, 32+16)
+	binary.LittleEndian.PutUint32(rootProps[32:], msgPropIDClientSubmitTime<<16|0x0040)
+	binary.LittleEndian.PutUint64(rootProps[40:], 133700000000000000) // 2024-09-05T08:53:20Z
+
+	to := recipient("Jane Doe", "jane@example.com", msgRecipientTo)
+	to.name = "__recip_version1.0_#00000000"
+	cc := recipient("", "team@example.com", msgRecipientCc)
+	cc.name = "__recip_version1.0_#00000001"
+
+	data := buildTestCFB([]cfbTestEntry{
+		{name: "__substg1.0_0037001F", data: utf16le("Quarterly numbers")},
+		{name: "__substg1.0_0C1A001F", data: utf16le("Bob")},
+		{name: "__substg1.0_5D01001F", data: utf16le("bob@example.com")},
+		{name: "__substg1.0_1000001F", data: utf16le("Numbers are attached.")},
+		{name: "__properties_version1.0", data: rootProps},
+		to,
+		cc,
+		{name: "__attach_version1.0_#00000000", children: []cfbTestEntry{
+			{name: "__substg1.0_3707001F", data: utf16le("numbers.csv")},
+			{name: "__substg1.0_37010102", data: []byte("q,revenue\nQ3,100\n")},
+		}},
+	})
+
+	docs, err := NewEmail(data, WithConfig(EmailOptions{IncludeAttachments: true}), WithAttachmentLoader(testAttachmentLoader)).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "Subject: Quarterly numbers\nFrom: Bob <bob@example.com>\nTo: Jane Doe <jane@example.com>\nCc: team@example.com\nDate: 2024-09-05T08:53:20Z\n\nNumbers are attached.", docs[0].Content)
+	assert.Equal(t, "Jane Doe <jane@example.com>", docs[0].Metadata["to"])
+	assert.Equal(t, []string{"numbers.csv"}, docs[0].Metadata["attachments"])
+	assert.Equal(t, "q,revenue\nQ3,100\n", docs[1].Content)
+	assert.Equal(t, "numbers.csv", docs[1].Metadata["attachment"])
+}
+
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+type cfbTestEntry struct {
+	name     string
+	data     []byte         // stream content
+	children []cfbTestEntry // storage children
+}
+
+// buildTestCFB builds a version 3 compound file with the given root entries.
+// The mini stream cutoff is set to 0, so all streams are stored in regular sectors.
+func buildTestCFB(rootEntries []cfbTestEntry) []byte {
+	const sectorSize = 512
+	le := binary.LittleEndian
+
+	type dirEntry struct {
+		name               string
+		typ                byte
+		left, right, child uint32
+		start              uint32
+		size               uint64
+		data               []byte
+	}
+	dir := []*dirEntry{{name: "Root Entry", typ: cfbTypeRoot, left: cfbNoStream, right: cfbNoStream, child: cfbNoStream, start: cfbEndOfChain}}
+
+	// children are linked as a chain of right siblings, which is a valid (if unbalanced) tree
+	var add func(parent *dirEntry, entries []cfbTestEntry)
+	add = func(parent *dirEntry, entries []cfbTestEntry) {
+		var prev *dirEntry
+		for _, e := range entries {
+			d := &dirEntry{name: e.name, typ: cfbTypeStream, left: cfbNoStream, right: cfbNoStream, child: cfbNoStream, data: e.data}
+			if e.children != nil {
+				d.typ = cfbTypeStorage
+			}
+			id := uint32(len(dir))
+			dir = append(dir, d)
+			if prev == nil {
+				parent.child = id
+			} else {
+				prev.right = id
+			}
+			prev = d
+			if e.children != nil {
+				add(d, e.children)
+			}
+		}
+	}
+	add(dir[0], rootEntries)
+
+	sectorsFor := func(n int) int { return (n + sectorSize - 1) / sectorSize }
+
+	// sector 0: FAT, then the directory, then the stream data
+	fat := []uint32{0xFFFFFFFD}
+	var sectors [][]byte
+
+	dirData := make([]byte, sectorsFor(len(dir)*128)*sectorSize)
+	dirStart := uint32(len(fat))
+	for i := 0; i < len(dirData)/sectorSize; i++ {
+		fat = append(fat, uint32(len(fat)+1))
+	}
+	fat[len(fat)-1] = cfbEndOfChain
+
+	for _, d := range dir {
+		if d.typ != cfbTypeStream || len(d.data) == 0 {
+			continue
+		}
+		d.start = uint32(len(fat))
+		d.size = uint64(len(d.data))
+		n := sectorsFor(len(d.data))
+		for i := 0; i < n; i++ {
+			fat = append(fat, uint32(len(fat)+1))
+		}
+		fat[len(fat)-1] = cfbEndOfChain
+		padded := make([]byte, n*sectorSize)
+		copy(padded, d.data)
+		sectors = append(sectors, padded)
+	}
+
+	for i, d := range dir {
+		e := dirData[i*128:]
+		name := utf16.Encode([]rune(d.name))
+		for j, c := range name {
+			le.PutUint16(e[2*j:], c)
+		}
+		le.PutUint16(e[0x40:], uint16(2*len(name)+2))
+		e[0x42] = d.typ
+		le.PutUint32(e[0x44:], d.left)
+		le.PutUint32(e[0x48:], d.right)
+		le.PutUint32(e[0x4C:], d.child)
+		le.PutUint32(e[0x74:], d.start)
+		le.PutUint64(e[0x78:], d.size)
+	}
+
+	fatSector := make([]byte, sectorSize)
+	for i := range sectorSize / 4 {
+		v := uint32(0xFFFFFFFF)
+		if i < len(fat) {
+			v = fat[i]
+		}
+		le.PutUint32(fatSector[4*i:], v)
+	}
+
+	header := make([]byte, sectorSize)
+	copy(header, cfbSignature)
+	le.PutUint16(header[0x1A:], 3)
+	le.PutUint16(header[0x1C:], 0xFFFE)
+	le.PutUint16(header[0x1E:], 9)
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1)
+	le.PutUint32(header[0x30:], dirStart)
+	le.PutUint32(header[0x38:], 0)
+	le.PutUint32(header[0x3C:], cfbEndOfChain)
+	le.PutUint32(header[0x44:], cfbEndOfChain)
+	for i := 0; i < 109; i++ {
+		le.PutUint32(header[0x4C+4*i:], 0xFFFFFFFF)
+	}
+	le.PutUint32(header[0x4C:], 0)
+
+	out := append(header, fatSector...)
+	out = append(out, dirData...)
+	for _, s := range sectors {
+		out = append(out, s...)
+	}
+	return out
+}
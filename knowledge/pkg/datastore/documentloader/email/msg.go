@@ -0,0 +1,194 @@
+package email
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/*
+ * Outlook .msg files store the MAPI properties of a message as streams in a compound file,
+ * see MS-OXMSG. Variable length properties are stored in streams named __substg1.0_<tag><type>,
+ * fixed length properties (like dates) in the __properties_version1.0 stream.
+ */
+
+const (
+	msgPropSubject          = "0037"
+	msgPropTransportHeaders = "007D"
+	msgPropSenderName       = "0C1A"
+	msgPropSenderEmail      = "0C1F"
+	msgPropSenderSMTP       = "5D01"
+	msgPropBody             = "1000"
+	msgPropHTML             = "1013"
+	msgPropInternetID       = "1035"
+	msgPropDisplayName      = "3001"
+	msgPropEmailAddress     = "3003"
+	msgPropSMTPAddress      = "39FE"
+	msgPropAttachData       = "3701"
+	msgPropAttachFilename   = "3704"
+	msgPropAttachLongName   = "3707"
+
+	msgTypeUnicode = "001F"
+	msgTypeString8 = "001E"
+	msgTypeBinary  = "0102"
+
+	msgPropIDClientSubmitTime = 0x0039
+	msgPropIDDeliveryTime     = 0x0E06
+	msgPropIDRecipientType    = 0x0C15
+
+	msgRecipientTo = 1
+	msgRecipientCc = 2
+)
+
+func parseMSG(data []byte) (*message, error) {
+	f, err := openCFB(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MSG file: %w", err)
+	}
+
+	root := f.root
+	msg := &message{
+		subject:   msgString(f, root, msgPropSubject),
+		messageID: msgString(f, root, msgPropInternetID),
+		text:      msgString(f, root, msgPropBody),
+		html:      msgString(f, root, msgPropHTML),
+	}
+
+	from := msgString(f, root, msgPropSenderSMTP)
+	if from == "" {
+		from = msgString(f, root, msgPropSenderEmail)
+	}
+	msg.from = formatAddress(msgString(f, root, msgPropSenderName), from)
+
+	// the transport headers hold the original RFC822 headers of received messages
+	if headers := msgString(f, root, msgPropTransportHeaders); headers != "" {
+		if m, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(headers, "\r\n") + "\r\n\r\n")); err == nil {
+			if d, err := m.Header.Date(); err == nil {
+				msg.date = d
+			}
+			if msg.messageID == "" {
+				msg.messageID = m.Header.Get("Message-Id")
+			}
+		}
+	}
+	if msg.date.IsZero() {
+		props := msgProperties(f, root, 32)
+		for _, id := range []uint16{msgPropIDClientSubmitTime, msgPropIDDeliveryTime} {
+			if v, ok := props[id]; ok && v != 0 {
+				msg.date = filetime(v)
+				break
+			}
+		}
+	}
+
+	// storage entries are unordered, so walk them by name to keep recipients and attachments in order
+	// (__recip_version1.0_#00000000, __attach_version1.0_#00000000, ...)
+	for _, name := range slices.Sorted(maps.Keys(root.entries)) {
+		e := root.entries[name]
+		switch {
+		case strings.HasPrefix(name, "__recip_version1.0_"):
+			address := msgString(f, e, msgPropSMTPAddress)
+			if address == "" {
+				address = msgString(f, e, msgPropEmailAddress)
+			}
+			recipient := formatAddress(msgString(f, e, msgPropDisplayName), address)
+			if recipient == "" {
+				continue
+			}
+			switch msgProperties(f, e, 8)[msgPropIDRecipientType] {
+			case msgRecipientCc:
+				msg.cc = append(msg.cc, recipient)
+			case msgRecipientTo:
+				msg.to = append(msg.to, recipient)
+			}
+		case strings.HasPrefix(name, "__attach_version1.0_"):
+			dataEntry, ok := e.entries["__substg1.0_"+msgPropAttachData+msgTypeBinary]
+			if !ok {
+				continue // e.g. embedded message or OLE object
+			}
+			content, err := f.stream(dataEntry)
+			if err != nil {
+				return nil, err
+			}
+			filename := msgString(f, e, msgPropAttachLongName)
+			if filename == "" {
+				filename = msgString(f, e, msgPropAttachFilename)
+			}
+			if filename == "" {
+				filename = msgString(f, e, msgPropDisplayName)
+			}
+			msg.attachments = append(msg.attachments, attachment{filename: filename, data: content})
+		}
+	}
+
+	return msg, nil
+}
+
+// msgString returns the string value of a variable length property, which may be stored as Unicode, 8-bit string or binary
+func msgString(f *cfbFile, storage *cfbEntry, tag string) string {
+	for _, typ := range []string{msgTypeUnicode, msgTypeString8, msgTypeBinary} {
+		e, ok := storage.entries["__substg1.0_"+tag+typ]
+		if !ok {
+			continue
+		}
+		data, err := f.stream(e)
+		if err != nil {
+			return ""
+		}
+		if typ == msgTypeUnicode {
+			u16 := make([]uint16, len(data)/2)
+			for i := range u16 {
+				u16[i] = binary.LittleEndian.Uint16(data[2*i:])
+			}
+			return strings.TrimRight(string(utf16.Decode(u16)), "\x00")
+		}
+		return strings.TrimRight(latin1ToUTF8(data), "\x00")
+	}
+	return ""
+}
+
+// msgProperties returns the fixed length properties (8 byte values) of a storage by property ID.
+// headerSize is 32 for the top level message, and 8 for recipients and attachments.
+func msgProperties(f *cfbFile, storage *cfbEntry, headerSize int) map[uint16]uint64 {
+	props := map[uint16]uint64{}
+	e, ok := storage.entries["__properties_version1.0"]
+	if !ok {
+		return props
+	}
+	data, err := f.stream(e)
+	if err != nil || len(data) < headerSize {
+		return props
+	}
+	for off := headerSize; off+16 <= len(data); off += 16 {
+		tag := binary.LittleEndian.Uint32(data[off:])
+		props[uint16(tag>>16)] = binary.LittleEndian.Uint64(data[off+8:])
+	}
+	return props
+}
+
+// filetime converts a Windows FILETIME (100ns intervals since 1601-01-01) to time.Time
+func filetime(v uint64) time.Time {
+	const epochDiff = 116444736000000000 // 1601-01-01 to 1970-01-01 in 100ns
+	if v < epochDiff {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(v-epochDiff)*100).UTC()
+}
+
+// latin1ToUTF8 returns the data as string, assuming Latin-1 if it's not valid UTF-8
+func latin1ToUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
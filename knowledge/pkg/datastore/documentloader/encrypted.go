@@ -0,0 +1,98 @@
+package documentloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/obot-platform/pdf-parser"
+)
+
+// MetadataKeyFilePassword is the (per-file) metadata key that can be used to provide the password for an encrypted file,
+// e.g. via the .knowledge.json metadata file. It is never stored alongside the documents.
+const MetadataKeyFilePassword = "filePassword"
+
+// EncryptedFileError is returned when a file is password-protected and no password was provided
+// (a wrong password is reported as a regular loading error)
+type EncryptedFileError struct {
+	FileType string
+}
+
+func (e *EncryptedFileError) Error() string {
+	return fmt.Sprintf("encrypted (password-protected) file of type %q", e.FileType)
+}
+
+func (e *EncryptedFileError) Is(err error) bool {
+	var encryptedFileError *EncryptedFileError
+	ok := errors.As(err, &encryptedFileError)
+	return ok
+}
+
+var (
+	cfbSignature          = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}                           // OLE Compound File Binary, used for encrypted OOXML files
+	cfbEncryptionInfo     = []byte("E\x00n\x00c\x00r\x00y\x00p\x00t\x00i\x00o\x00n\x00I\x00n\x00f\x00o\x00") // UTF-16LE "EncryptionInfo" stream name
+	zipSignature          = []byte("PK\x03\x04")
+	pdfSignature          = []byte("%PDF")
+	pdfEncryptKey         = []byte("/Encrypt")
+	odfManifestPath       = "META-INF/manifest.xml"
+	odfManifestEncryption = []byte("encryption-data")
+)
+
+// IsEncrypted detects whether the given file content is password-protected.
+// Supported are PDF, Office Open XML (docx, pptx, xlsx) and OpenDocument (odt, ods, odp) files.
+func IsEncrypted(content []byte) bool {
+	switch {
+	case isPDF(content):
+		return bytes.Contains(content, pdfEncryptKey) && isEncryptedPDF(content)
+	case bytes.HasPrefix(content, cfbSignature):
+		// Password-protected OOXML files are not zip archives, but CFB containers with an EncryptionInfo stream
+		return bytes.Contains(content, cfbEncryptionInfo)
+	case bytes.HasPrefix(content, zipSignature):
+		return isEncryptedODF(content)
+	default:
+		return false
+	}
+}
+
+// CanDecrypt reports whether an encrypted file can be decrypted with a password.
+// Only PDF files can, there's no document loader for encrypted Office Open XML and OpenDocument files.
+func CanDecrypt(content []byte) bool {
+	return isPDF(content)
+}
+
+func isPDF(content []byte) bool {
+	return bytes.Contains(content[:min(len(content), 1024)], pdfSignature) // PDF header may be preceded by some garbage bytes
+}
+
+// isEncryptedPDF tries to open the PDF with the empty user password, which many PDFs with only an owner password
+// (restricting e.g. printing or copying) use - those can be loaded without a password, so only a failing attempt counts.
+// Other errors (e.g. unsupported encryption) are left to the document loader.
+func isEncryptedPDF(content []byte) bool {
+	_, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	return errors.Is(err, pdf.ErrInvalidPassword)
+}
+
+func isEncryptedODF(content []byte) bool {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, f := range zr.File {
+		if f.Name != odfManifestPath {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return false
+		}
+		defer rc.Close()
+		manifest, err := io.ReadAll(rc)
+		if err != nil {
+			return false
+		}
+		return bytes.Contains(manifest, odfManifestEncryption)
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package documentloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/encrypted.pdf has a single page reading "Secret content", encrypted with the user password "secret" (RC4, 128 bit)
+const testPDFPassword = "secret"
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestIsEncrypted(t *testing.T) {
+	encryptedPDF, err := os.ReadFile("testdata/encrypted.pdf")
+	require.NoError(t, err)
+	encryptedOOXML := append(append(bytes.Clone(cfbSignature), make([]byte, 64)...), cfbEncryptionInfo...)
+	encryptedODF := testZip(t, map[string]string{odfManifestPath: `<manifest:file-entry><manifest:encryption-data/></manifest:file-entry>`})
+	plainODF := testZip(t, map[string]string{odfManifestPath: `<manifest:file-entry/>`})
+
+	assert.True(t, IsEncrypted(encryptedPDF))
+	assert.True(t, CanDecrypt(encryptedPDF))
+
+	assert.True(t, IsEncrypted(encryptedOOXML))
+	assert.False(t, CanDecrypt(encryptedOOXML))
+
+	assert.True(t, IsEncrypted(encryptedODF))
+	assert.False(t, CanDecrypt(encryptedODF))
+
+	assert.False(t, IsEncrypted(plainODF))
+	assert.False(t, IsEncrypted([]byte("plain text")))
+}
+
+func TestLoadEncryptedPDF(t *testing.T) {
+	data, err := os.ReadFile("testdata/encrypted.pdf")
+	require.NoError(t, err)
+	ctx := types.PasswordToCtx(context.Background(), testPDFPassword)
+
+	for _, name := range []string{"pdf", "gopdf"} {
+		t.Run(name, func(t *testing.T) {
+			loader, err := GetDocumentLoaderFunc(name, nil)
+			require.NoError(t, err)
+			docs, err := loader(ctx, bytes.NewReader(data))
+			require.NoError(t, err)
+			require.Len(t, docs, 1)
+			assert.Contains(t, docs[0].Content, "Secret content")
+
+			_, err = loader(types.PasswordToCtx(context.Background(), "wrong"), bytes.NewReader(data))
+			assert.ErrorContains(t, err, "invalid password")
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		// MuPDF (if available) can't decrypt, so the default PDF reader falls back to GoPDF
+		docs, err := DefaultDocLoaderFunc(".pdf", DefaultDocLoaderFuncOpts{})(ctx, bytes.NewReader(data))
+		require.NoError(t, err)
+		require.Len(t, docs, 1)
+		assert.Contains(t, docs[0].Content, "Secret content")
+	})
+
+	t.Run("mupdf", func(t *testing.T) {
+		loader, err := GetDocumentLoaderFunc("mupdf", nil)
+		require.NoError(t, err)
+		_, err = loader(ctx, bytes.NewReader(data))
+		assert.ErrorIs(t, err, &types.DecryptionNotSupportedError{})
+		assert.ErrorContains(t, err, "decryption not supported for the mupdf document loader")
+	})
+}
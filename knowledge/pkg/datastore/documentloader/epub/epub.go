@@ -0,0 +1,286 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure EPUB satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*EPUB)(nil)
+
+type EPUBOptions struct {
+	// IncludeNonLinear includes spine items marked as non-linear, e.g. footnotes or answer keys
+	IncludeNonLinear bool `mapstructure:"includeNonLinear" json:"includeNonLinear,omitempty"`
+}
+
+// WithConfig sets the EPUB loader configuration.
+func WithConfig(config EPUBOptions) func(o *EPUBOptions) {
+	return func(o *EPUBOptions) {
+		*o = config
+	}
+}
+
+// EPUB is an e-book document loader that implements the DocumentLoader interface.
+// It walks the spine (reading order) of the book and emits one document per chapter, converted to Markdown,
+// with the chapter title and order in the metadata.
+type EPUB struct {
+	r         io.ReaderAt
+	size      int64
+	opts      EPUBOptions
+	converter *mdconv.Converter
+}
+
+func NewEPUBFromReader(r io.Reader, optFns ...func(o *EPUBOptions)) (*EPUB, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EPUB data: %w", err)
+	}
+	return NewEPUB(bytes.NewReader(data), int64(len(data)), optFns...), nil
+}
+
+// NewEPUB creates a new EPUB loader with the given options.
+func NewEPUB(r io.ReaderAt, size int64, optFns ...func(o *EPUBOptions)) *EPUB {
+	opts := EPUBOptions{}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &EPUB{
+		r:         r,
+		size:      size,
+		opts:      opts,
+		converter: mdconv.NewConverter(mdconv.WithPlugins(base.NewBasePlugin(), commonmark.NewCommonmarkPlugin())),
+	}
+}
+
+// Load loads the EPUB document and returns a slice of vs.Document with one document per chapter.
+func (l *EPUB) Load(ctx context.Context) ([]vs.Document, error) {
+	zr, err := zip.NewReader(l.r, l.size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EPUB archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var container xmlContainer
+	if err := decodeXMLFile(files, "META-INF/container.xml", &container); err != nil {
+		return nil, fmt.Errorf("failed to parse EPUB container: %w", err)
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("no package document found in EPUB container")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+
+	var pkg xmlPackage
+	if err := decodeXMLFile(files, opfPath, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse EPUB package document: %w", err)
+	}
+
+	manifest := make(map[string]xmlManifestItem, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		item.Href = resolveHref(opfPath, item.Href)
+		manifest[item.ID] = item
+	}
+
+	titles := tocTitles(files, pkg, manifest)
+
+	bookMetadata := map[string]any{}
+	if title := strings.TrimSpace(pkg.Metadata.Title); title != "" {
+		bookMetadata["bookTitle"] = title
+	}
+	if len(pkg.Metadata.Creators) > 0 {
+		bookMetadata["author"] = strings.Join(pkg.Metadata.Creators, ", ")
+	}
+	if lang := strings.TrimSpace(pkg.Metadata.Language); lang != "" {
+		bookMetadata["language"] = lang
+	}
+
+	var docs []vs.Document
+	for _, ref := range pkg.Spine.ItemRefs {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if ref.Linear == "no" && !l.opts.IncludeNonLinear {
+			continue
+		}
+		item, ok := manifest[ref.IDRef]
+		if !ok {
+			return nil, fmt.Errorf("spine item %q not found in manifest", ref.IDRef)
+		}
+		if !strings.Contains(item.MediaType, "html") {
+			continue
+		}
+
+		content, heading, err := l.chapterContent(files, item.Href)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chapter %q: %w", item.Href, err)
+		}
+		if content == "" {
+			continue // e.g. cover page with just an image
+		}
+
+		metadata := make(map[string]any, len(bookMetadata)+3)
+		for k, v := range bookMetadata {
+			metadata[k] = v
+		}
+		metadata["chapter"] = len(docs) + 1
+		if title := titles[item.Href]; title != "" {
+			metadata["chapterTitle"] = title
+		} else if heading != "" {
+			metadata["chapterTitle"] = heading
+		}
+
+		docs = append(docs, vs.Document{
+			Content:  content,
+			Metadata: metadata,
+		})
+	}
+
+	for i := range docs {
+		docs[i].Metadata["totalChapters"] = len(docs)
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+// chapterContent returns the chapter body as Markdown and its first heading
+func (l *EPUB) chapterContent(files map[string]*zip.File, name string) (string, string, error) {
+	f, ok := files[name]
+	if !ok {
+		return "", "", fmt.Errorf("file %q not found in EPUB archive", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer rc.Close()
+
+	doc, err := goquery.NewDocumentFromReader(rc)
+	if err != nil {
+		return "", "", err
+	}
+
+	body := doc.Find("body")
+	body.Find("script, style, img, svg").Remove()
+	if strings.TrimSpace(body.Text()) == "" {
+		return "", "", nil
+	}
+
+	heading := strings.Join(strings.Fields(body.Find("h1, h2, h3").First().Text()), " ")
+
+	html, err := body.Html()
+	if err != nil {
+		return "", "", err
+	}
+	markdown, err := l.converter.ConvertString(html)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to convert chapter to markdown: %w", err)
+	}
+	return strings.TrimSpace(markdown), heading, nil
+}
+
+// tocTitles returns the chapter titles from the table of contents, keyed by archive path.
+// EPUB 3 navigation documents are preferred over the EPUB 2 NCX. The table of contents is optional,
+// so parsing errors just result in no titles.
+func tocTitles(files map[string]*zip.File, pkg xmlPackage, manifest map[string]xmlManifestItem) map[string]string {
+	titles := map[string]string{}
+	add := func(base, href, title string) {
+		title = strings.Join(strings.Fields(title), " ")
+		if href == "" || title == "" {
+			return
+		}
+		href, _, _ = strings.Cut(href, "#")
+		if p := resolveHref(base, href); titles[p] == "" {
+			titles[p] = title
+		}
+	}
+
+	for _, item := range manifest {
+		if !strings.Contains(" "+item.Properties+" ", " nav ") {
+			continue
+		}
+		f, ok := files[item.Href]
+		if !ok {
+			break
+		}
+		rc, err := f.Open()
+		if err != nil {
+			break
+		}
+		doc, err := goquery.NewDocumentFromReader(rc)
+		rc.Close()
+		if err != nil {
+			break
+		}
+		doc.Find("nav").FilterFunction(func(_ int, s *goquery.Selection) bool {
+			t, _ := s.Attr("epub:type")
+			return t == "" || strings.Contains(t, "toc")
+		}).First().Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+			add(item.Href, a.AttrOr("href", ""), a.Text())
+		})
+		if len(titles) > 0 {
+			return titles
+		}
+	}
+
+	if ncx, ok := manifest[pkg.Spine.TOC]; ok {
+		var toc xmlNCX
+		if err := decodeXMLFile(files, ncx.Href, &toc); err == nil {
+			var walk func(points []xmlNavPoint)
+			walk = func(points []xmlNavPoint) {
+				for _, p := range points {
+					add(ncx.Href, p.Content.Src, p.Label)
+					walk(p.Children)
+				}
+			}
+			walk(toc.NavPoints)
+		}
+	}
+
+	return titles
+}
+
+// resolveHref resolves a (URL-encoded) href relative to the file it's referenced in
+func resolveHref(base, href string) string {
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	if strings.HasPrefix(href, "/") {
+		return strings.TrimPrefix(href, "/")
+	}
+	return path.Join(path.Dir(base), href)
+}
+
+func decodeXMLFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("file %q not found in EPUB archive", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(rc)
+	d.Strict = false
+	return d.Decode(v)
+}
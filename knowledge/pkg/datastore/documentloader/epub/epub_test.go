@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEPUB(t *testing.T) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>The Widget Handbook</dc:title>
+    <dc:creator>Jane Doe</dc:creator>
+    <dc:creator>John Roe</dc:creator>
+    <dc:language>en</dc:language>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="cover" href="text/cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="text/chapter%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="text/notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="img" href="images/cover.png" media-type="image/png"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="ch1"/>
+    <itemref idref="ch2"/>
+    <itemref idref="notes" linear="no"/>
+  </spine>
+</package>`,
+		"OEBPS/nav.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+  <nav epub:type="toc"><ol>
+    <li><a href="text/chapter%201.xhtml#start">Chapter 1: Getting Started</a></li>
+  </ol></nav>
+</body></html>`,
+		"OEBPS/text/cover.xhtml":     `<html><body><img src="../images/cover.png"/></body></html>`,
+		"OEBPS/text/chapter 1.xhtml": `<html><head><title>ch1</title></head><body><h1>Getting Started</h1><p>Install the widget first.</p></body></html>`,
+		"OEBPS/text/ch2.xhtml":       `<html><body><h2>Advanced   Usage</h2><p>Combine widgets.</p></body></html>`,
+		"OEBPS/text/notes.xhtml":     `<html><body><p>Footnotes.</p></body></html>`,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestLoad(t *testing.T) {
+	data := testEPUB(t)
+
+	docs, err := NewEPUB(bytes.NewReader(data), int64(len(data))).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Contains(t, docs[0].Content, "Install the widget first.")
+	assert.Equal(t, 1, docs[0].Metadata["chapter"])
+	assert.Equal(t, "Chapter 1: Getting Started", docs[0].Metadata["chapterTitle"], "title from the table of contents")
+	assert.Equal(t, "The Widget Handbook", docs[0].Metadata["bookTitle"])
+	assert.Equal(t, "Jane Doe, John Roe", docs[0].Metadata["author"])
+	assert.Equal(t, "en", docs[0].Metadata["language"])
+	assert.Equal(t, 2, docs[0].Metadata["totalChapters"])
+
+	assert.Equal(t, 2, docs[1].Metadata["chapter"])
+	assert.Equal(t, "Advanced Usage", docs[1].Metadata["chapterTitle"], "title from the first heading")
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestLoadNonLinear(t *testing.T) {
+	data := testEPUB(t)
+
+	docs, err := NewEPUB(bytes.NewReader(data), int64(len(data)), WithConfig(EPUBOptions{IncludeNonLinear: true})).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Contains(t, docs[2].Content, "Footnotes.")
+}
@@ -0,0 +1,47 @@
+package epub
+
+/*
+ * Minimal subset of the EPUB (OCF, OPF and NCX) schemas required to walk the book.
+ * Element names are matched by their local name, so we don't need to care about namespace prefixes.
+ */
+
+type xmlContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type xmlPackage struct {
+	Metadata struct {
+		Title    string   `xml:"title"`
+		Creators []string `xml:"creator"`
+		Language string   `xml:"language"`
+	} `xml:"metadata"`
+	Manifest []xmlManifestItem `xml:"manifest>item"`
+	Spine    struct {
+		TOC      string `xml:"toc,attr"` // manifest ID of the EPUB 2 NCX
+		ItemRefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+type xmlManifestItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type xmlNCX struct {
+	NavPoints []xmlNavPoint `xml:"navMap>navPoint"`
+}
+
+type xmlNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []xmlNavPoint `xml:"navPoint"`
+}
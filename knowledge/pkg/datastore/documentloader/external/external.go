@@ -0,0 +1,236 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure External satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*External)(nil)
+
+var ExternalLoaderTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_EXTERNAL_LOADER_TIMEOUT_SECONDS", defaults.ModelAPITimeoutSeconds)) * time.Second
+var ExternalLoaderRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_EXTERNAL_LOADER_REQUEST_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+
+const (
+	// EnvFilename and EnvFileExtension are set for external commands, as the file content is passed via stdin
+	EnvFilename      = "KNOW_FILENAME"
+	EnvFileExtension = "KNOW_FILE_EXTENSION"
+)
+
+// ExternalOptions configure the external converter - either Command or URL must be set.
+//
+// Protocol: the converter receives the raw file and returns the documents as JSON, either as a list of documents
+// or wrapped in an object: {"documents": [{"content": "...", "metadata": {"page": 1}}, ...]}.
+// Documents without content are skipped.
+type ExternalOptions struct {
+	// Command is the executable to run. It receives the file via stdin and the filename via $KNOW_FILENAME
+	// and $KNOW_FILE_EXTENSION and must write the JSON documents to stdout.
+	Command string `mapstructure:"command" json:"command,omitempty"`
+
+	// Args are passed to the command
+	Args []string `mapstructure:"args" json:"args,omitempty"`
+
+	// Env are additional environment variables for the command, e.g. {"LANG": "C.UTF-8"}
+	Env map[string]string `mapstructure:"env" json:"env,omitempty"`
+
+	// URL is the endpoint of a converter service. It receives the file as multipart form field "file"
+	// (including the filename) and must respond with the JSON documents.
+	URL string `mapstructure:"url" json:"url,omitempty"`
+
+	// APIKey is sent as bearer token to the converter service
+	APIKey string `mapstructure:"apiKey" json:"apiKey,omitempty"`
+
+	// Headers are additional HTTP headers sent to the converter service
+	Headers map[string]string `mapstructure:"headers" json:"headers,omitempty"`
+}
+
+// WithConfig sets the external document loader configuration.
+func WithConfig(config ExternalOptions) func(o *ExternalOptions) {
+	return func(o *ExternalOptions) {
+		*o = config
+	}
+}
+
+// External is a document loader that hands the file to an external converter (a command or an HTTP service),
+// which allows adding support for additional (e.g. proprietary) file formats without changes to this package.
+type External struct {
+	reader io.Reader
+	opts   ExternalOptions
+}
+
+type document struct {
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+type documentsResponse struct {
+	Documents []document `json:"documents"`
+}
+
+// NewExternal creates a new external document loader with the given options.
+func NewExternal(reader io.Reader, optFns ...func(o *ExternalOptions)) (*External, error) {
+	var opts ExternalOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	switch {
+	case opts.Command == "" && opts.URL == "":
+		return nil, fmt.Errorf("external document loader requires either a command or a url")
+	case opts.Command != "" && opts.URL != "":
+		return nil, fmt.Errorf("external document loader accepts either a command or a url, not both")
+	}
+
+	return &External{
+		reader: reader,
+		opts:   opts,
+	}, nil
+}
+
+// Load sends the file to the external converter and returns the documents it responds with.
+func (l *External) Load(ctx context.Context) ([]vs.Document, error) {
+	data, err := io.ReadAll(l.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ExternalLoaderTimeout)
+	defer cancel()
+
+	filename := types.FilenameFromCtx(ctx)
+
+	var out []byte
+	if l.opts.URL != "" {
+		out, err = l.post(ctx, data, filename)
+	} else {
+		out, err = l.run(ctx, data, filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	extDocs, err := parseDocuments(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []vs.Document
+	for _, d := range extDocs {
+		if strings.TrimSpace(d.Content) == "" {
+			continue
+		}
+		if d.Metadata == nil {
+			d.Metadata = map[string]any{}
+		}
+		d.Metadata[vs.DocMetadataKeyDocIndex] = len(docs)
+		docs = append(docs, vs.Document{
+			Content:  d.Content,
+			Metadata: d.Metadata,
+		})
+	}
+
+	return docs, nil
+}
+
+func (l *External) run(ctx context.Context, data []byte, filename string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, l.opts.Command, l.opts.Args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), EnvFilename+"="+filename, EnvFileExtension+"="+strings.ToLower(path.Ext(filename)))
+	for k, v := range l.opts.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	logger := log.FromCtx(ctx)
+	logger.Debug("Running external document loader command", "command", cmd.String(), "filename", filename)
+
+	if err := cmd.Run(); err != nil {
+		logger.Error("Failed to run external document loader command", "error", err, "stderr", errb.String())
+		return nil, fmt.Errorf("failed to run external document loader command %q: %w", l.opts.Command, err)
+	}
+
+	return outb.Bytes(), nil
+}
+
+func (l *External) post(ctx context.Context, data []byte, filename string) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	name := path.Base(filename)
+	if filename == "" {
+		name = "file"
+	}
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.opts.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+	for k, v := range l.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if l.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.opts.APIKey)
+	}
+
+	client := &http.Client{
+		Timeout: ExternalLoaderRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+	respBody, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+	if err != nil {
+		return nil, fmt.Errorf("external document loader error sending request(s): %w", err)
+	}
+	return respBody, nil
+}
+
+// parseDocuments accepts either a list of documents or an object with a "documents" list
+func parseDocuments(data []byte) ([]document, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if trimmed[0] == '[' {
+		var docs []document
+		if err := json.Unmarshal(trimmed, &docs); err != nil {
+			return nil, fmt.Errorf("error unmarshaling external document loader output: %w", err)
+		}
+		return docs, nil
+	}
+
+	var resp documentsResponse
+	if err := json.Unmarshal(trimmed, &resp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling external document loader output: %w", err)
+	}
+	return resp.Documents, nil
+}
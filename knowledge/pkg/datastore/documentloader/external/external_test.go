@@ -0,0 +1,67 @@
+package external
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+		_, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		assert.Equal(t, "report.xyz", header.Filename)
+		_, _ = w.Write([]byte(`{"documents": [{"content": "Page one", "metadata": {"page": 1}}, {"content": " "}, {"content": "Page two"}]}`))
+	}))
+	defer srv.Close()
+
+	l, err := NewExternal(strings.NewReader("proprietary data"), WithConfig(ExternalOptions{
+		URL:     srv.URL,
+		APIKey:  "secret",
+		Headers: map[string]string{"X-Foo": "bar"},
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(types.FilenameToCtx(context.Background(), "/data/report.xyz"))
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "documents without content are skipped")
+	assert.Equal(t, "Page one", docs[0].Content)
+	assert.Equal(t, float64(1), docs[0].Metadata["page"])
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestLoadCommand(t *testing.T) {
+	// fake converter printing the input and the file extension as a list of documents
+	bin := filepath.Join(t.TempDir(), "converter")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\nprintf '[{\"content\": \"%s\", \"metadata\": {\"ext\": \"%s\", \"flag\": \"%s\"}}]' \"$(cat)\" \"$KNOW_FILE_EXTENSION\" \"$1\"\n"), 0o755))
+
+	l, err := NewExternal(strings.NewReader("hello"), WithConfig(ExternalOptions{
+		Command: bin,
+		Args:    []string{"--json"},
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(types.FilenameToCtx(context.Background(), "notes.XYZ"))
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "hello", docs[0].Content)
+	assert.Equal(t, map[string]any{"ext": ".xyz", "flag": "--json", "docIndex": 0}, docs[0].Metadata)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewExternal(nil)
+	assert.Error(t, err)
+
+	_, err = NewExternal(nil, WithConfig(ExternalOptions{Command: "convert", URL: "http://localhost"}))
+	assert.Error(t, err)
+}
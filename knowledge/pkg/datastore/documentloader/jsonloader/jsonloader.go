@@ -0,0 +1,340 @@
+package jsonloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure JSON satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*JSON)(nil)
+
+const (
+	FormatJSON  = "json"
+	FormatJSONL = "jsonl"
+)
+
+type JSONOptions struct {
+	// Format is either "json" or "jsonl" (one JSON value per line) - auto-detected if empty
+	Format string `mapstructure:"format" json:"format,omitempty"`
+
+	// RecordsPath selects the records in the JSON value, e.g. "$.items[*]" (default: the elements of a
+	// top-level array, or the value itself otherwise). For JSONL, it's applied to every line.
+	RecordsPath string `mapstructure:"recordsPath" json:"recordsPath,omitempty"`
+
+	// ContentPaths select the text of a record, e.g. ["$.title", "$.body"]. The selected values are joined by newlines.
+	// If empty, the content is the whole record as "key: value" lines.
+	ContentPaths []string `mapstructure:"contentPaths" json:"contentPaths,omitempty"`
+
+	// MetadataPaths map metadata keys to selectors, e.g. {"author": "$.author.name", "tags": "$.tags[*]"}
+	MetadataPaths map[string]string `mapstructure:"metadataPaths" json:"metadataPaths,omitempty"`
+
+	// SkipEmpty skips records without content (default: false)
+	SkipEmpty bool `mapstructure:"skipEmpty" json:"skipEmpty,omitempty"`
+}
+
+// WithConfig sets the JSON loader configuration.
+func WithConfig(config JSONOptions) func(o *JSONOptions) {
+	return func(o *JSONOptions) {
+		*o = config
+	}
+}
+
+// JSON is a document loader for JSON and JSONL files that implements the DocumentLoader interface.
+// It emits one document per record, with the content and metadata chosen by selectors.
+type JSON struct {
+	data []byte
+	opts JSONOptions
+
+	records  selector
+	content  []selector
+	metadata map[string]selector
+}
+
+func NewJSONFromReader(r io.Reader, optFns ...func(o *JSONOptions)) (*JSON, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON data: %w", err)
+	}
+	return NewJSON(data, optFns...)
+}
+
+// NewJSON creates a new JSON loader with the given options.
+func NewJSON(data []byte, optFns ...func(o *JSONOptions)) (*JSON, error) {
+	var opts JSONOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	l := &JSON{
+		data:     data,
+		opts:     opts,
+		metadata: map[string]selector{},
+	}
+
+	switch opts.Format {
+	case "", FormatJSON, FormatJSONL:
+	default:
+		return nil, fmt.Errorf("invalid format %q, must be one of %q or %q", opts.Format, FormatJSON, FormatJSONL)
+	}
+
+	var err error
+	if opts.RecordsPath != "" {
+		if l.records, err = parseSelector(opts.RecordsPath); err != nil {
+			return nil, fmt.Errorf("invalid recordsPath: %w", err)
+		}
+	}
+	for _, p := range opts.ContentPaths {
+		sel, err := parseSelector(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contentPaths: %w", err)
+		}
+		l.content = append(l.content, sel)
+	}
+	for k, p := range opts.MetadataPaths {
+		if l.metadata[k], err = parseSelector(p); err != nil {
+			return nil, fmt.Errorf("invalid metadataPaths[%q]: %w", k, err)
+		}
+	}
+
+	return l, nil
+}
+
+type record struct {
+	value any
+	line  int // line number in JSONL files
+}
+
+// Load parses the JSON data and returns one document per record.
+func (l *JSON) Load(_ context.Context) ([]vs.Document, error) {
+	format := l.opts.Format
+	if format == "" {
+		format = detectFormat(l.data)
+	}
+
+	var records []record
+	if format == FormatJSONL {
+		scanner := bufio.NewScanner(bytes.NewReader(l.data))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(l.data)+1)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := bytes.TrimSpace(scanner.Bytes())
+			if len(text) == 0 {
+				continue
+			}
+			v, err := decode(text)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse JSONL line %d: %w", line, err)
+			}
+			for _, r := range l.selectRecords(v, false) {
+				records = append(records, record{value: r, line: line})
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read JSONL data: %w", err)
+		}
+	} else {
+		v, err := decode(l.data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON: %w", err)
+		}
+		for _, r := range l.selectRecords(v, true) {
+			records = append(records, record{value: r})
+		}
+	}
+
+	docs := make([]vs.Document, 0, len(records))
+	for i, r := range records {
+		content := l.recordContent(r.value)
+		if l.opts.SkipEmpty && strings.TrimSpace(content) == "" {
+			continue
+		}
+
+		metadata := map[string]any{
+			"record": i + 1,
+		}
+		if r.line > 0 {
+			metadata["line"] = r.line
+		}
+		for k, sel := range l.metadata {
+			values := sel.eval(r.value)
+			switch len(values) {
+			case 0:
+			case 1:
+				metadata[k] = values[0]
+			default:
+				metadata[k] = values
+			}
+		}
+
+		docs = append(docs, vs.Document{
+			Content:  content,
+			Metadata: metadata,
+		})
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+func (l *JSON) selectRecords(v any, splitArray bool) []any {
+	if l.records != nil {
+		return l.records.eval(v)
+	}
+	if a, ok := v.([]any); ok && splitArray {
+		return a
+	}
+	return []any{v}
+}
+
+func (l *JSON) recordContent(v any) string {
+	if len(l.content) == 0 {
+		var lines []string
+		flatten("", v, &lines)
+		return strings.Join(lines, "\n")
+	}
+
+	var parts []string
+	for _, sel := range l.content {
+		for _, val := range sel.eval(v) {
+			if s := valueString(val); s != "" {
+				parts = append(parts, s)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// flatten renders the value as "key: value" lines, e.g. "author.name: Jane"
+func flatten(prefix string, v any, lines *[]string) {
+	switch t := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flatten(key, t[k], lines)
+		}
+	case []any:
+		scalars := true
+		for _, e := range t {
+			switch e.(type) {
+			case map[string]any, []any:
+				scalars = false
+			}
+		}
+		if scalars {
+			values := make([]string, len(t))
+			for i, e := range t {
+				values[i] = valueString(e)
+			}
+			appendLine(prefix, strings.Join(values, ", "), lines)
+			return
+		}
+		for i, e := range t {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), e, lines)
+		}
+	default:
+		appendLine(prefix, valueString(t), lines)
+	}
+}
+
+func appendLine(key, value string, lines *[]string) {
+	if value == "" {
+		return
+	}
+	if key == "" {
+		*lines = append(*lines, value)
+		return
+	}
+	*lines = append(*lines, key+": "+value)
+}
+
+func valueString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return fmt.Sprint(t)
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(b)
+	}
+}
+
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep large integers like IDs intact
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return numbers(v), nil
+}
+
+// numbers converts json.Number values to int64 or float64, so that they can be used as metadata
+func numbers(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = numbers(e)
+		}
+	case []any:
+		for i, e := range t {
+			t[i] = numbers(e)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+// detectFormat returns jsonl if the data is not a single JSON value
+func detectFormat(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var v json.RawMessage
+	if err := dec.Decode(&v); err != nil {
+		return FormatJSON // report the parse error later on
+	}
+	if dec.More() {
+		return FormatJSONL
+	}
+	return FormatJSON
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
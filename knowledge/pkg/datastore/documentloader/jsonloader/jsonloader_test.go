@@ -0,0 +1,91 @@
+package jsonloader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSON = `{
+  "meta": {"source": "helpdesk"},
+  "items": [
+    {"id": 9007199254740993, "title": "Reset password", "body": "Click 'forgot password'.", "author": {"name": "Jane"}, "tags": ["account", "login"]},
+    {"id": 2, "title": "Change email", "body": "", "author": {"name": "Bob"}, "tags": []}
+  ]
+}`
+
+func TestLoadWithSelectors(t *testing.T) {
+	l, err := NewJSON([]byte(testJSON), WithConfig(JSONOptions{
+		RecordsPath:   "$.items[*]",
+		ContentPaths:  []string{"$.title", ".body"},
+		MetadataPaths: map[string]string{"id": "id", "author": "$.author.name", "tags": "$.tags[*]", "firstTag": "$.tags[0]"},
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "Reset password\nClick 'forgot password'.", docs[0].Content)
+	assert.Equal(t, int64(9007199254740993), docs[0].Metadata["id"])
+	assert.Equal(t, "Jane", docs[0].Metadata["author"])
+	assert.Equal(t, []any{"account", "login"}, docs[0].Metadata["tags"])
+	assert.Equal(t, "account", docs[0].Metadata["firstTag"])
+	assert.Equal(t, 1, docs[0].Metadata["record"])
+
+	assert.Equal(t, "Change email", docs[1].Content)
+	assert.NotContains(t, docs[1].Metadata, "tags")
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestLoadJSONLDefaults(t *testing.T) {
+	data := `{"q": "What is RAG?", "a": {"text": "Retrieval augmented generation", "votes": 3}}
+
+{"q": "What is BM25?", "tags": ["ranking", "search"]}
+`
+	l, err := NewJSON([]byte(data))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "a.text: Retrieval augmented generation\na.votes: 3\nq: What is RAG?", docs[0].Content)
+	assert.Equal(t, 1, docs[0].Metadata["line"])
+	assert.Equal(t, "q: What is BM25?\ntags: ranking, search", docs[1].Content)
+	assert.Equal(t, 3, docs[1].Metadata["line"])
+}
+
+func TestLoadTopLevelArray(t *testing.T) {
+	l, err := NewJSON([]byte(`[{"name": "a"}, {"name": "b"}, {"other": 1}]`), WithConfig(JSONOptions{ContentPaths: []string{"name"}, SkipEmpty: true}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "b", docs[1].Content)
+}
+
+func TestParseSelector(t *testing.T) {
+	v := map[string]any{"first name": "Jane", "list": []any{"x", "y", "z"}, "obj": map[string]any{"b": 2, "a": 1}}
+
+	for sel, expected := range map[string][]any{
+		`$["first name"]`: {"Jane"},
+		`.list[]`:         {"x", "y", "z"},
+		`list[-1]`:        {"z"},
+		`$.obj.*`:         {1, 2},
+		`.`:               {v},
+		`$.missing.x`:     nil,
+	} {
+		s, err := parseSelector(sel)
+		require.NoError(t, err, sel)
+		assert.Equal(t, expected, s.eval(v), sel)
+	}
+
+	for _, sel := range []string{"$.a[", "$.a[x]", "$..a"} {
+		_, err := parseSelector(sel)
+		assert.Error(t, err, sel)
+	}
+}
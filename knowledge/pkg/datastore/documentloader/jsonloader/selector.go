@@ -0,0 +1,132 @@
+package jsonloader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Selectors are a subset of JSONPath, also accepting the jq notation:
+ *
+ *   $.items[*].title   .items[].title   items[*].title
+ *   $.authors[0].name  $["first name"]  $.tags.*
+ *
+ * Supported are child names (dot or bracket notation), array indices (negative ones count from the end)
+ * and wildcards, which select all elements of arrays and all values of objects.
+ */
+
+type selectorStep struct {
+	kind  stepKind
+	name  string
+	index int
+}
+
+type stepKind int
+
+const (
+	stepName stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type selector []selectorStep
+
+func parseSelector(s string) (selector, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "$")
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	var sel selector
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if s == "" {
+				// "." is the identity in jq
+				return sel, nil
+			}
+			if s[0] == '[' {
+				continue // jq: .[0], .[]
+			}
+			if s[0] == '*' {
+				sel = append(sel, selectorStep{kind: stepWildcard})
+				s = s[1:]
+				continue
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid selector %q: empty name", orig)
+			}
+			sel = append(sel, selectorStep{kind: stepName, name: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid selector %q: missing ']'", orig)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case inner == "" || inner == "*":
+				sel = append(sel, selectorStep{kind: stepWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				sel = append(sel, selectorStep{kind: stepName, name: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid selector %q: invalid index %q", orig, inner)
+				}
+				sel = append(sel, selectorStep{kind: stepIndex, index: i})
+			}
+		default:
+			return nil, fmt.Errorf("invalid selector %q: unexpected %q", orig, s[0])
+		}
+	}
+	return sel, nil
+}
+
+// eval returns all values matching the selector
+func (sel selector) eval(v any) []any {
+	current := []any{v}
+	for _, step := range sel {
+		var next []any
+		for _, c := range current {
+			switch step.kind {
+			case stepName:
+				if m, ok := c.(map[string]any); ok {
+					if val, ok := m[step.name]; ok {
+						next = append(next, val)
+					}
+				}
+			case stepIndex:
+				if a, ok := c.([]any); ok {
+					i := step.index
+					if i < 0 {
+						i += len(a)
+					}
+					if i >= 0 && i < len(a) {
+						next = append(next, a[i])
+					}
+				}
+			case stepWildcard:
+				switch t := c.(type) {
+				case []any:
+					next = append(next, t...)
+				case map[string]any:
+					for _, k := range sortedKeys(t) {
+						next = append(next, t[k])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
@@ -0,0 +1,290 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ParseFrontMatter splits the YAML (--- ... ---) or TOML (+++ ... +++) front matter off the markdown text.
+// It returns the front matter fields and the remaining content, or nil and the unchanged data if there is no front matter.
+func ParseFrontMatter(data []byte) (map[string]any, []byte, error) {
+	text := bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	firstLine, rest, ok := bytes.Cut(text, []byte("\n"))
+	if !ok {
+		return nil, data, nil
+	}
+	delim := string(bytes.TrimSpace(firstLine))
+	if delim != "---" && delim != "+++" {
+		return nil, data, nil
+	}
+
+	// find the closing delimiter - YAML documents may also end with "..."
+	var block []byte
+	var content []byte
+	found := false
+	for pos := 0; pos <= len(rest); {
+		end := bytes.IndexByte(rest[pos:], '\n')
+		var line []byte
+		next := len(rest) + 1
+		if end < 0 {
+			line = rest[pos:]
+		} else {
+			line = rest[pos : pos+end]
+			next = pos + end + 1
+		}
+		l := string(bytes.TrimSpace(line))
+		if l == delim || (delim == "---" && l == "...") {
+			block = rest[:pos]
+			if next < len(rest) {
+				content = rest[next:]
+			}
+			found = true
+			break
+		}
+		pos = next
+	}
+	if !found {
+		return nil, data, nil
+	}
+
+	var fields map[string]any
+	var err error
+	if delim == "---" {
+		fields, err = parseYAML(block)
+	} else {
+		fields, err = parseTOML(block)
+	}
+	if err != nil {
+		return nil, data, err
+	}
+	if fields == nil {
+		fields = map[string]any{}
+	}
+
+	return fields, content, nil
+}
+
+func parseYAML(block []byte) (map[string]any, error) {
+	var fields map[string]any
+	if err := yaml.Unmarshal(block, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML front matter: %w", err)
+	}
+	return fields, nil
+}
+
+// parseTOML parses the subset of TOML used in front matter: key/value pairs with strings, numbers, booleans,
+// dates and arrays, and [tables] whose keys are nested accordingly.
+func parseTOML(block []byte) (map[string]any, error) {
+	fields := map[string]any{}
+	table := fields
+
+	lines := strings.Split(strings.ReplaceAll(string(block), "\r\n", "\n"), "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[n]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("failed to parse TOML front matter: arrays of tables are not supported (line %d)", n+1)
+			}
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("failed to parse TOML front matter: invalid table header %q (line %d)", line, n+1)
+			}
+			table = fields
+			for _, key := range splitTOMLKey(line[1 : len(line)-1]) {
+				sub, ok := table[key].(map[string]any)
+				if !ok {
+					sub = map[string]any{}
+					table[key] = sub
+				}
+				table = sub
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("failed to parse TOML front matter: expected key = value (line %d)", n+1)
+		}
+		value = strings.TrimSpace(value)
+
+		// multi-line arrays and strings
+		for (strings.HasPrefix(value, "[") && !tomlArrayClosed(value)) ||
+			(strings.HasPrefix(value, `"""`) && (len(value) < 6 || !strings.HasSuffix(value, `"""`))) {
+			n++
+			if n >= len(lines) {
+				return nil, fmt.Errorf("failed to parse TOML front matter: unterminated value for %q", strings.TrimSpace(key))
+			}
+			if strings.HasPrefix(value, `"""`) {
+				value += "\n" + lines[n]
+			} else {
+				value += " " + strings.TrimSpace(stripTOMLComment(lines[n]))
+			}
+		}
+
+		v, err := parseTOMLValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TOML front matter: %w (line %d)", err, n+1)
+		}
+
+		keys := splitTOMLKey(key)
+		t := table
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := t[k].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				t[k] = sub
+			}
+			t = sub
+		}
+		t[keys[len(keys)-1]] = v
+	}
+
+	return fields, nil
+}
+
+func splitTOMLKey(key string) []string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return parts
+}
+
+func parseTOMLValue(value string) (any, error) {
+	switch {
+	case strings.HasPrefix(value, `"""`):
+		return strings.TrimPrefix(value[3:len(value)-3], "\n"), nil
+	case strings.HasPrefix(value, `"`):
+		if !strings.HasSuffix(value, `"`) || len(value) < 2 {
+			return nil, fmt.Errorf("unterminated string %s", value)
+		}
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s: %w", value, err)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if !strings.HasSuffix(value, "'") || len(value) < 2 {
+			return nil, fmt.Errorf("unterminated string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, "["):
+		var values []any
+		for _, elem := range splitTOMLArray(value[1 : len(value)-1]) {
+			v, err := parseTOMLValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	}
+
+	num := strings.ReplaceAll(value, "_", "")
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil {
+		return f, nil
+	}
+	if isTOMLDateTime(value) {
+		return value, nil // dates and times are kept as they are
+	}
+	return nil, fmt.Errorf("unsupported value %q", value)
+}
+
+func isTOMLDateTime(value string) bool {
+	return (len(value) >= 10 && value[4] == '-' && value[7] == '-') || (len(value) >= 8 && value[2] == ':' && value[5] == ':')
+}
+
+// splitTOMLArray splits the array elements at the top-level commas
+func splitTOMLArray(s string) []string {
+	var elems []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		case ch == ',' && depth == 0:
+			elems = append(elems, s[start:i])
+			start = i + 1
+		}
+	}
+	elems = append(elems, s[start:])
+
+	result := elems[:0]
+	for _, e := range elems {
+		if e = strings.TrimSpace(e); e != "" {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func tomlArrayClosed(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripTOMLComment removes a trailing # comment outside of strings
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,118 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure Markdown satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Markdown)(nil)
+
+type MarkdownOptions struct {
+	// FrontMatterKeys limits the front matter fields that are added to the metadata, e.g. ["title", "tags", "date"] (default: all).
+	// Nested fields are flattened to dotted keys, e.g. "author.name".
+	FrontMatterKeys []string `mapstructure:"frontMatterKeys" json:"frontMatterKeys,omitempty"`
+
+	// KeepFrontMatter keeps the front matter in the content, so that it's embedded as text as well (default: false)
+	KeepFrontMatter bool `mapstructure:"keepFrontMatter" json:"keepFrontMatter,omitempty"`
+
+	// IgnoreFrontMatter disables front matter parsing altogether (default: false)
+	IgnoreFrontMatter bool `mapstructure:"ignoreFrontMatter" json:"ignoreFrontMatter,omitempty"`
+}
+
+// WithConfig sets the markdown loader configuration.
+func WithConfig(config MarkdownOptions) func(o *MarkdownOptions) {
+	return func(o *MarkdownOptions) {
+		*o = config
+	}
+}
+
+// Markdown is a document loader for markdown files that implements the DocumentLoader interface.
+// YAML (---) or TOML (+++) front matter is parsed into the document metadata, which is inherited by all chunks
+// created by the text splitter, instead of being chunked as text.
+type Markdown struct {
+	reader io.Reader
+	opts   MarkdownOptions
+}
+
+// NewMarkdown creates a new markdown loader with the given options.
+func NewMarkdown(reader io.Reader, optFns ...func(o *MarkdownOptions)) *Markdown {
+	var opts MarkdownOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &Markdown{
+		reader: reader,
+		opts:   opts,
+	}
+}
+
+// Load reads the markdown text and returns a single document with the front matter fields as metadata.
+func (l *Markdown) Load(_ context.Context) ([]vs.Document, error) {
+	data, err := io.ReadAll(l.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read markdown: %w", err)
+	}
+
+	metadata := map[string]any{}
+	content := data
+
+	if !l.opts.IgnoreFrontMatter {
+		fields, rest, err := ParseFrontMatter(data)
+		if err != nil {
+			// e.g. a document starting with a horizontal rule - keep it as text
+			slog.Warn("Failed to parse markdown front matter, keeping it as content", "error", err)
+		} else if fields != nil {
+			flattenFields("", fields, metadata)
+			if len(l.opts.FrontMatterKeys) > 0 {
+				for k := range metadata {
+					if !slices.Contains(l.opts.FrontMatterKeys, k) {
+						delete(metadata, k)
+					}
+				}
+			}
+			if !l.opts.KeepFrontMatter {
+				content = rest
+			}
+		}
+	}
+
+	metadata[vs.DocMetadataKeyDocIndex] = 0
+
+	return []vs.Document{
+		{
+			Content:  string(content),
+			Metadata: metadata,
+		},
+	}, nil
+}
+
+// flattenFields adds the front matter fields to the metadata, flattening nested objects to dotted keys.
+// Lists are kept as they are, so that e.g. a document matches a tag filter if the tag is in its list of tags.
+func flattenFields(prefix string, fields map[string]any, metadata map[string]any) {
+	for k, v := range fields {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch t := v.(type) {
+		case map[string]any:
+			flattenFields(key, t, metadata)
+		case nil:
+		case string:
+			if s := strings.TrimSpace(t); s != "" {
+				metadata[key] = s
+			}
+		default:
+			metadata[key] = t
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T, text string, optFns ...func(o *MarkdownOptions)) vs.Document {
+	t.Helper()
+	docs, err := NewMarkdown(strings.NewReader(text), optFns...).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	return docs[0]
+}
+
+func TestYAMLFrontMatter(t *testing.T) {
+	doc := load(t, `---
+title: Deploying to Kubernetes
+tags: [kubernetes, deployment]
+date: 2024-05-01
+draft: false
+author:
+  name: Jane
+---
+# Deploying
+
+Some text.
+`)
+
+	require.Equal(t, "# Deploying\n\nSome text.\n", doc.Content)
+	require.Equal(t, "Deploying to Kubernetes", doc.Metadata["title"])
+	require.Equal(t, []any{"kubernetes", "deployment"}, doc.Metadata["tags"])
+	require.Equal(t, "2024-05-01", doc.Metadata["date"])
+	require.Equal(t, false, doc.Metadata["draft"])
+	require.Equal(t, "Jane", doc.Metadata["author.name"])
+}
+
+func TestTOMLFrontMatter(t *testing.T) {
+	doc := load(t, `+++
+title = "Release notes" # comment
+tags = [
+  "release",
+  'changelog',
+]
+date = 2024-05-01T10:00:00Z
+weight = 10
+
+[params]
+version = "1.2"
++++
+Content`)
+
+	require.Equal(t, "Content", doc.Content)
+	require.Equal(t, "Release notes", doc.Metadata["title"])
+	require.Equal(t, []any{"release", "changelog"}, doc.Metadata["tags"])
+	require.Equal(t, "2024-05-01T10:00:00Z", doc.Metadata["date"])
+	require.Equal(t, int64(10), doc.Metadata["weight"])
+	require.Equal(t, "1.2", doc.Metadata["params.version"])
+}
+
+func TestFrontMatterOptions(t *testing.T) {
+	text := "---\ntitle: Hello\ntags: [a]\n---\nBody\n"
+
+	doc := load(t, text, WithConfig(MarkdownOptions{FrontMatterKeys: []string{"title"}, KeepFrontMatter: true}))
+	require.Equal(t, text, doc.Content)
+	require.Equal(t, "Hello", doc.Metadata["title"])
+	require.NotContains(t, doc.Metadata, "tags")
+
+	doc = load(t, text, WithConfig(MarkdownOptions{IgnoreFrontMatter: true}))
+	require.Equal(t, text, doc.Content)
+	require.NotContains(t, doc.Metadata, "title")
+}
+
+func TestNoFrontMatter(t *testing.T) {
+	for _, text := range []string{
+		"# Title\n\nNo front matter\n",
+		"---\n\nA horizontal rule, but no closing delimiter\n",
+		"---\nnot: [valid yaml\n---\nText\n",
+	} {
+		doc := load(t, text)
+		require.Equal(t, text, doc.Content)
+		require.Equal(t, map[string]any{vs.DocMetadataKeyDocIndex: 0}, doc.Metadata)
+	}
+}
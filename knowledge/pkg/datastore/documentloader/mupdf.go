@@ -1,15 +1,19 @@
 package documentloader
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/ocr/openai"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pdf/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pdf/gopdf"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/pdf/mupdf"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/smartpdf"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/mitchellh/mapstructure"
@@ -18,7 +22,20 @@ import (
 func init() {
 	defaults.DefaultPDFReaderFunc = func(ctx context.Context, reader io.Reader) ([]vs.Document, error) {
 		slog.Debug("Default PDF Reader is MuPDF")
-		r, err := mupdf.NewPDF(reader)
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF data: %w", err)
+		}
+		r, err := mupdf.NewPDF(bytes.NewReader(data))
+		if errors.Is(err, &types.DecryptionNotSupportedError{}) && types.PasswordFromCtx(ctx) != "" {
+			// MuPDF can't decrypt PDFs, but GoPDF can
+			slog.Debug("Encrypted PDF, falling back to GoPDF")
+			g, err := gopdf.NewPDF(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				return nil, err
+			}
+			return g.Load(ctx)
+		}
 		if err != nil {
 			slog.Error("Failed to create MuPDF loader", "error", err)
 			return nil, err
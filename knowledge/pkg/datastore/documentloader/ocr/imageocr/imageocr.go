@@ -0,0 +1,247 @@
+package imageocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure ImageOCR satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*ImageOCR)(nil)
+
+var ImageOCRTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_IMAGE_OCR_TIMEOUT_SECONDS", defaults.ModelAPITimeoutSeconds)) * time.Second
+var ImageOCRRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_IMAGE_OCR_REQUEST_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+
+const (
+	EngineTesseract = "tesseract"
+	EngineEndpoint  = "endpoint"
+)
+
+// pageSeparator is the form feed that Tesseract puts after each page, e.g. of multi-page TIFFs
+const pageSeparator = "\f"
+
+type ImageOCROptions struct {
+	// Engine is either "tesseract" (default, requires the tesseract binary) or "endpoint" (external OCR service)
+	Engine string `mapstructure:"engine" json:"engine,omitempty"`
+
+	// Languages are the languages of the text in the image, e.g. ["eng", "deu"] (default: ["eng"])
+	Languages []string `mapstructure:"languages" json:"languages,omitempty"`
+
+	// DPI is the resolution of the image - useful for images without resolution information (e.g. screenshots)
+	DPI int `mapstructure:"dpi" json:"dpi,omitempty"`
+
+	// TesseractPath is the path to the tesseract binary (default: "tesseract" from $PATH)
+	TesseractPath string `mapstructure:"tesseractPath" json:"tesseractPath,omitempty"`
+
+	// PageSegmentationMode is passed to tesseract as --psm, e.g. 6 for a single uniform block of text
+	PageSegmentationMode int `mapstructure:"pageSegmentationMode" json:"pageSegmentationMode,omitempty"`
+
+	// EndpointURL is the URL of the external OCR service.
+	// The image is sent as multipart form (fields "file", "languages", "dpi"), the response is either
+	// plain text or JSON in the form {"text": "..."} or {"pages": ["...", ...]}.
+	EndpointURL string `mapstructure:"endpointURL" json:"endpointURL,omitempty"`
+
+	// APIKey is sent as bearer token to the external OCR service
+	APIKey string `mapstructure:"apiKey" json:"apiKey,omitempty"`
+}
+
+// WithConfig sets the image OCR configuration.
+func WithConfig(config ImageOCROptions) func(o *ImageOCROptions) {
+	return func(o *ImageOCROptions) {
+		*o = config
+	}
+}
+
+// ImageOCR is a document loader that extracts the text of images (PNG, JPEG, TIFF, ...) using OCR.
+// It emits one document per page (multi-page TIFFs have multiple pages).
+type ImageOCR struct {
+	reader io.Reader
+	opts   ImageOCROptions
+}
+
+type endpointResponse struct {
+	Text  string   `json:"text"`
+	Pages []string `json:"pages"`
+}
+
+// NewImageOCR creates a new image OCR loader with the given options.
+func NewImageOCR(reader io.Reader, optFns ...func(o *ImageOCROptions)) (*ImageOCR, error) {
+	var opts ImageOCROptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Engine == "" {
+		opts.Engine = EngineTesseract
+	}
+	if len(opts.Languages) == 0 {
+		opts.Languages = []string{"eng"}
+	}
+	if opts.DPI < 0 {
+		return nil, fmt.Errorf("invalid DPI %d", opts.DPI)
+	}
+
+	switch opts.Engine {
+	case EngineTesseract:
+		if opts.TesseractPath == "" {
+			opts.TesseractPath = "tesseract"
+		}
+	case EngineEndpoint:
+		if opts.EndpointURL == "" {
+			return nil, fmt.Errorf("endpointURL is required for the OCR endpoint engine")
+		}
+	default:
+		return nil, fmt.Errorf("unknown OCR engine %q, must be one of %q or %q", opts.Engine, EngineTesseract, EngineEndpoint)
+	}
+
+	return &ImageOCR{
+		reader: reader,
+		opts:   opts,
+	}, nil
+}
+
+// Load runs OCR on the image and returns one document per page.
+func (l *ImageOCR) Load(ctx context.Context) ([]vs.Document, error) {
+	data, err := io.ReadAll(l.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ImageOCRTimeout)
+	defer cancel()
+
+	var pages []string
+	switch l.opts.Engine {
+	case EngineEndpoint:
+		pages, err = l.ocrEndpoint(ctx, data)
+	default:
+		pages, err = l.ocrTesseract(ctx, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []vs.Document
+	for i, page := range pages {
+		page = strings.TrimSpace(page)
+		if page == "" {
+			continue // e.g. photos without any text
+		}
+		docs = append(docs, vs.Document{
+			Content: page,
+			Metadata: map[string]any{
+				"page":       i + 1,
+				"totalPages": len(pages),
+				"ocrEngine":  l.opts.Engine,
+				"language":   strings.Join(l.opts.Languages, "+"),
+			},
+		})
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+func (l *ImageOCR) ocrTesseract(ctx context.Context, data []byte) ([]string, error) {
+	if _, err := exec.LookPath(l.opts.TesseractPath); err != nil {
+		return nil, fmt.Errorf("tesseract binary not found: %w", err)
+	}
+
+	args := []string{"stdin", "stdout", "-l", strings.Join(l.opts.Languages, "+")}
+	if l.opts.DPI > 0 {
+		args = append(args, "--dpi", strconv.Itoa(l.opts.DPI))
+	}
+	if l.opts.PageSegmentationMode > 0 {
+		args = append(args, "--psm", strconv.Itoa(l.opts.PageSegmentationMode))
+	}
+
+	cmd := exec.CommandContext(ctx, l.opts.TesseractPath, args...)
+	cmd.Stdin = bytes.NewReader(data)
+
+	var outb, errb strings.Builder
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+
+	logger := log.FromCtx(ctx)
+	logger.Debug("Running tesseract command", "command", cmd.String())
+
+	if err := cmd.Run(); err != nil {
+		logger.Error("Failed to run tesseract command", "error", err, "stderr", errb.String())
+		return nil, fmt.Errorf("failed to run tesseract: %w", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(outb.String(), pageSeparator), pageSeparator), nil
+}
+
+func (l *ImageOCR) ocrEndpoint(ctx context.Context, data []byte) ([]string, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "image")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := mw.WriteField("languages", strings.Join(l.opts.Languages, ",")); err != nil {
+		return nil, err
+	}
+	if l.opts.DPI > 0 {
+		if err := mw.WriteField("dpi", strconv.Itoa(l.opts.DPI)); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.opts.EndpointURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json, text/plain")
+	if l.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.opts.APIKey)
+	}
+
+	client := &http.Client{
+		Timeout: ImageOCRRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+	respBody, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+	if err != nil {
+		return nil, fmt.Errorf("OCR endpoint error sending request(s): %w", err)
+	}
+
+	trimmed := bytes.TrimSpace(respBody)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return strings.Split(string(respBody), pageSeparator), nil
+	}
+
+	var resp endpointResponse
+	if err := json.Unmarshal(trimmed, &resp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling OCR endpoint response: %w", err)
+	}
+	if len(resp.Pages) > 0 {
+		return resp.Pages, nil
+	}
+	return strings.Split(resp.Text, pageSeparator), nil
+}
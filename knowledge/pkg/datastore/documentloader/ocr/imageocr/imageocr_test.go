@@ -0,0 +1,73 @@
+package imageocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		assert.Equal(t, "eng,deu", r.FormValue("languages"))
+		assert.Equal(t, "300", r.FormValue("dpi"))
+		_ = json.NewEncoder(w).Encode(endpointResponse{Pages: []string{"Invoice 42", "  ", "Total: 12 EUR"}})
+	}))
+	defer srv.Close()
+
+	l, err := NewImageOCR(bytes.NewReader([]byte("fake image")), WithConfig(ImageOCROptions{
+		Engine:      EngineEndpoint,
+		EndpointURL: srv.URL,
+		APIKey:      "secret",
+		Languages:   []string{"eng", "deu"},
+		DPI:         300,
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2, "blank pages are skipped")
+	assert.Equal(t, "Invoice 42", docs[0].Content)
+	assert.Equal(t, 3, docs[1].Metadata["page"])
+	assert.Equal(t, 3, docs[1].Metadata["totalPages"])
+	assert.Equal(t, "eng+deu", docs[1].Metadata["language"])
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestLoadTesseract(t *testing.T) {
+	// fake tesseract binary printing its arguments and two pages
+	bin := filepath.Join(t.TempDir(), "tesseract")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\necho \"$@\"\nprintf '\\fsecond page\\f'\n"), 0o755))
+
+	l, err := NewImageOCR(bytes.NewReader([]byte("fake image")), WithConfig(ImageOCROptions{
+		TesseractPath: bin,
+		DPI:           150,
+	}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "stdin stdout -l eng --dpi 150", docs[0].Content)
+	assert.Equal(t, "second page", docs[1].Content)
+	assert.Equal(t, "tesseract", docs[1].Metadata["ocrEngine"])
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewImageOCR(nil, WithConfig(ImageOCROptions{Engine: EngineEndpoint}))
+	assert.Error(t, err)
+
+	_, err = NewImageOCR(nil, WithConfig(ImageOCROptions{Engine: "foo"}))
+	assert.Error(t, err)
+}
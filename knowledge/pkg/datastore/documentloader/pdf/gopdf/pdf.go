@@ -135,9 +135,20 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 		err    error
 	)
 
-	if l.opts.Password != "" {
+	password := l.opts.Password
+	if password == "" {
+		password = types.PasswordFromCtx(ctx)
+	}
+
+	if password != "" {
+		// the reader keeps asking for passwords until it gets an empty one, so the password is only handed out once
+		tried := false
 		reader, err = pdf.NewReaderEncrypted(l.f, l.size, func() string {
-			return l.opts.Password
+			if tried {
+				return ""
+			}
+			tried = true
+			return password
 		})
 		if err != nil {
 			return nil, err
@@ -1,12 +1,17 @@
 package mupdf
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"unicode"
 
 	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
 	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
@@ -14,6 +19,8 @@ import (
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gen2brain/go-fitz"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/ocr/imageocr"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/tables"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/pkoukk/tiktoken-go"
@@ -26,9 +33,6 @@ var _ types.DocumentLoader = (*PDF)(nil)
 var MuPDFLock sync.Mutex
 
 type PDFOptions struct {
-	// Password for encrypted PDF files.
-	Password string
-
 	// Page number to start loading from (default is 1).
 	StartPage uint
 
@@ -48,6 +52,28 @@ type PDFOptions struct {
 
 	// Tokenizer - target model for Tokenizer to use for page merging
 	TokenModel string
+
+	// DetectTables - detect tables from the text layout and render them as markdown tables.
+	// This is enabled automatically if the ingestion flow extracts tables into dedicated documents.
+	DetectTables bool `mapstructure:"detectTables" json:"detectTables,omitempty"`
+
+	// OCRFallback - run pages without extractable text (e.g. scanned pages) through OCR
+	OCRFallback OCRFallbackOptions `mapstructure:"ocrFallback" json:"ocrFallback,omitempty"`
+}
+
+// defaultOCRFallbackDPI is the resolution at which pages are rendered for OCR
+const defaultOCRFallbackDPI = 300
+
+type OCRFallbackOptions struct {
+	// Enabled turns on the OCR fallback (default: false)
+	Enabled bool `mapstructure:"enabled" json:"enabled,omitempty"`
+
+	// MinTextLength - pages with fewer extracted characters (not counting whitespace) are run through OCR (default: 1, i.e. only empty pages)
+	MinTextLength int `mapstructure:"minTextLength" json:"minTextLength,omitempty"`
+
+	// OCR configures the OCR engine, i.e. local Tesseract (default) or an external endpoint.
+	// The pages are rendered at OCR.DPI (default: 300).
+	OCR imageocr.ImageOCROptions `mapstructure:"ocr" json:"ocr,omitempty"`
 }
 
 // WithConfig sets the PDF loader configuration.
@@ -75,6 +101,11 @@ type PDF struct {
 // NewPDF creates a new PDF loader with the given options.
 func NewPDF(r io.Reader, optFns ...func(o *PDFOptions)) (*PDF, error) {
 	doc, err := fitz.NewFromReader(r)
+	if errors.Is(err, fitz.ErrNeedsPassword) {
+		// go-fitz doesn't expose MuPDF's password authentication, so encrypted PDFs can't be opened
+		doc.Close()
+		return nil, fmt.Errorf("%w - use the pdf document loader for encrypted files", &types.DecryptionNotSupportedError{FileType: "the mupdf document loader"})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +140,22 @@ func NewPDF(r io.Reader, optFns ...func(o *PDFOptions)) (*PDF, error) {
 		opts.NumThread = 100
 	}
 
+	if opts.OCRFallback.Enabled {
+		if opts.OCRFallback.MinTextLength <= 0 {
+			opts.OCRFallback.MinTextLength = 1
+		}
+		if opts.OCRFallback.OCR.Engine == "" {
+			opts.OCRFallback.OCR.Engine = imageocr.EngineTesseract
+		}
+		if opts.OCRFallback.OCR.DPI == 0 {
+			opts.OCRFallback.OCR.DPI = defaultOCRFallbackDPI
+		}
+		// fail early on invalid OCR configuration
+		if _, err := imageocr.NewImageOCR(nil, imageocr.WithConfig(opts.OCRFallback.OCR)); err != nil {
+			return nil, fmt.Errorf("invalid OCR fallback configuration: %w", err)
+		}
+	}
+
 	return &PDF{
 		Opts:      opts,
 		Document:  doc,
@@ -127,6 +174,7 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 		docTokenCounts = make([]int, l.Document.NumPage())
 	}
 	numPages := l.Document.NumPage()
+	detectTables := l.Opts.DetectTables || types.DetectTablesFromCtx(ctx)
 
 	// We need a Lock here, since MuPDF is not thread-safe and there are some edge cases that can cause a CGO panic.
 	// See https://github.com/obot-platform/tools/knowledge/issues/135
@@ -150,6 +198,11 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 				}
 				htmlDoc.Find("img").Remove()
 
+				var pageTables []tables.Table
+				if detectTables {
+					pageTables = replaceLayoutTables(htmlDoc)
+				}
+
 				ret, err := htmlDoc.First().Html()
 				if err != nil {
 					return err
@@ -160,7 +213,7 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 					return err
 				}
 
-				content := strings.TrimSpace(markdown)
+				content := strings.TrimSpace(insertTables(markdown, pageTables))
 
 				doc := vs.Document{
 					Content: content,
@@ -171,6 +224,19 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 					},
 				}
 
+				if l.Opts.OCRFallback.Enabled && textLength(content) < l.Opts.OCRFallback.MinTextLength {
+					ocrContent, err := l.ocrPage(childCtx, pageNum)
+					if err != nil {
+						return fmt.Errorf("OCR fallback failed for page %d: %w", pageNum+1, err)
+					}
+					if ocrContent != "" {
+						slog.Debug("Extracted text of PDF page using OCR", "page", pageNum+1, "totalPages", numPages, "textLength", textLength(content))
+						content = ocrContent
+						doc.Content = content
+						doc.Metadata["ocrEngine"] = l.Opts.OCRFallback.OCR.Engine
+					}
+				}
+
 				l.Lock.Lock()
 				docs[pageNum] = doc
 				if l.Opts.EnablePageMerge {
@@ -190,6 +256,89 @@ func (l *PDF) Load(ctx context.Context) ([]vs.Document, error) {
 	return l.mergePages(docs, docTokenCounts, numPages), nil
 }
 
+var positionRegex = regexp.MustCompile(`top:\s*(-?[\d.]+)pt;\s*left:\s*(-?[\d.]+)pt`)
+
+// tablePlaceholder marks the position of a detected table in the HTML, to be replaced after the markdown conversion
+const tablePlaceholder = "KNOWLEDGETABLEPLACEHOLDER%dEND"
+
+// replaceLayoutTables detects tables in the positioned text lines of the page HTML and replaces them with placeholders
+func replaceLayoutTables(htmlDoc *goquery.Document) []tables.Table {
+	var lines []tables.TextLine
+	var sels []*goquery.Selection
+	htmlDoc.Find("p[style]").Each(func(_ int, sel *goquery.Selection) {
+		m := positionRegex.FindStringSubmatch(sel.AttrOr("style", ""))
+		if m == nil {
+			return
+		}
+		top, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return
+		}
+		left, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return
+		}
+		lines = append(lines, tables.TextLine{Top: top, Left: left, Text: sel.Text()})
+		sels = append(sels, sel)
+	})
+
+	found := tables.FindLayoutTables(lines)
+	tbls := make([]tables.Table, 0, len(found))
+	for n, t := range found {
+		for j, idx := range t.Lines {
+			if j == 0 {
+				sels[idx].ReplaceWithHtml("<p>" + fmt.Sprintf(tablePlaceholder, n) + "</p>")
+			} else {
+				sels[idx].Remove()
+			}
+		}
+		tbls = append(tbls, t.Table)
+	}
+	return tbls
+}
+
+// insertTables replaces the table placeholders in the markdown with the markdown tables
+func insertTables(markdown string, tbls []tables.Table) string {
+	for n, t := range tbls {
+		markdown = strings.Replace(markdown, fmt.Sprintf(tablePlaceholder, n), "\n"+t.Markdown()+"\n", 1)
+	}
+	return markdown
+}
+
+// ocrPage renders the page as image and runs it through OCR
+func (l *PDF) ocrPage(ctx context.Context, pageNum int) (string, error) {
+	img, err := l.Document.ImagePNG(pageNum, float64(l.Opts.OCRFallback.OCR.DPI))
+	if err != nil {
+		return "", fmt.Errorf("failed to render page: %w", err)
+	}
+
+	ocr, err := imageocr.NewImageOCR(bytes.NewReader(img), imageocr.WithConfig(l.Opts.OCRFallback.OCR))
+	if err != nil {
+		return "", err
+	}
+	docs, err := ocr.Load(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	texts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		texts = append(texts, doc.Content)
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n")), nil
+}
+
+// textLength returns the number of non-whitespace characters
+func textLength(s string) int {
+	n := 0
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
 func (l *PDF) mergePages(docs []vs.Document, docTokenCounts []int, totalPages int) []vs.Document {
 	if !l.Opts.EnablePageMerge {
 		return docs
@@ -0,0 +1,302 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure PPTX satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*PPTX)(nil)
+
+const (
+	// NotesModeInline appends the speaker notes to the slide document in a dedicated section
+	NotesModeInline = "inline"
+	// NotesModeSeparate emits the speaker notes as a separate document right after the slide document
+	NotesModeSeparate = "separate"
+	// NotesModeMetadata stores the speaker notes in the metadata of the slide document ("speakerNotes")
+	NotesModeMetadata = "metadata"
+	// NotesModeNone drops speaker notes
+	NotesModeNone = "none"
+
+	ContentTypeSlide        = "slide"
+	ContentTypeSpeakerNotes = "speakerNotes"
+
+	speakerNotesHeading = "### Speaker Notes"
+)
+
+type PPTXOptions struct {
+	// NotesMode defines how speaker notes are handled: inline (default), separate, metadata or none
+	NotesMode string `mapstructure:"notesMode" json:"notesMode,omitempty"`
+
+	// IncludeHidden includes slides that are hidden in the presentation
+	IncludeHidden bool `mapstructure:"includeHidden" json:"includeHidden,omitempty"`
+}
+
+// WithConfig sets the PPTX loader configuration.
+func WithConfig(config PPTXOptions) func(o *PPTXOptions) {
+	return func(o *PPTXOptions) {
+		*o = config
+	}
+}
+
+// PPTX represents a PowerPoint (Office Open XML) document loader that implements the DocumentLoader interface.
+type PPTX struct {
+	r    io.ReaderAt
+	size int64
+	opts PPTXOptions
+}
+
+func NewPPTXFromReader(r io.Reader, optFns ...func(o *PPTXOptions)) (*PPTX, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PPTX data: %w", err)
+	}
+	return NewPPTX(bytes.NewReader(data), int64(len(data)), optFns...)
+}
+
+// NewPPTX creates a new PPTX loader with the given options.
+func NewPPTX(r io.ReaderAt, size int64, optFns ...func(o *PPTXOptions)) (*PPTX, error) {
+	opts := PPTXOptions{
+		NotesMode: NotesModeInline,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	switch opts.NotesMode {
+	case "":
+		opts.NotesMode = NotesModeInline
+	case NotesModeInline, NotesModeSeparate, NotesModeMetadata, NotesModeNone:
+	default:
+		return nil, fmt.Errorf("invalid PPTX notesMode %q, must be one of %q, %q, %q or %q", opts.NotesMode, NotesModeInline, NotesModeSeparate, NotesModeMetadata, NotesModeNone)
+	}
+
+	return &PPTX{
+		r:    r,
+		size: size,
+		opts: opts,
+	}, nil
+}
+
+// Load loads the PPTX document and returns a slice of vs.Document with one document per slide (plus speaker notes, if configured).
+func (l *PPTX) Load(ctx context.Context) ([]vs.Document, error) {
+	zr, err := zip.NewReader(l.r, l.size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PPTX archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	slidePaths, err := slideOrder(files)
+	if err != nil {
+		return nil, err
+	}
+
+	deckTitle := presentationTitle(files)
+
+	totalSlides := len(slidePaths)
+	docs := make([]vs.Document, 0, totalSlides)
+
+	for i, slidePath := range slidePaths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		var slide xmlSlide
+		if err := decodeXMLFile(files, slidePath, &slide); err != nil {
+			return nil, fmt.Errorf("failed to parse slide %d: %w", i+1, err)
+		}
+
+		if slide.Show == "0" && !l.opts.IncludeHidden {
+			continue
+		}
+
+		title, content := slide.ShapeTree.render()
+
+		var notes string
+		if l.opts.NotesMode != NotesModeNone {
+			notes, err = slideNotes(files, slidePath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse speaker notes of slide %d: %w", i+1, err)
+			}
+		}
+
+		metadata := map[string]any{
+			"slide":           i + 1,
+			"totalSlides":     totalSlides,
+			"contentType":     ContentTypeSlide,
+			"hasSpeakerNotes": notes != "",
+		}
+		if title != "" {
+			metadata["slideTitle"] = title
+		}
+		if deckTitle != "" {
+			metadata["deckTitle"] = deckTitle
+		}
+		if slide.Show == "0" {
+			metadata["hidden"] = true
+		}
+		if notes != "" && l.opts.NotesMode == NotesModeMetadata {
+			metadata["speakerNotes"] = notes
+		}
+
+		if notes != "" && l.opts.NotesMode == NotesModeInline {
+			content = strings.TrimSpace(content + "\n\n" + speakerNotesHeading + "\n\n" + notes)
+		}
+
+		if content != "" {
+			docs = append(docs, vs.Document{
+				Content:  content,
+				Metadata: metadata,
+			})
+		}
+
+		if notes != "" && l.opts.NotesMode == NotesModeSeparate {
+			notesMetadata := map[string]any{
+				"slide":       i + 1,
+				"totalSlides": totalSlides,
+				"contentType": ContentTypeSpeakerNotes,
+			}
+			if title != "" {
+				notesMetadata["slideTitle"] = title
+			}
+			if deckTitle != "" {
+				notesMetadata["deckTitle"] = deckTitle
+			}
+			docs = append(docs, vs.Document{
+				Content:  notes,
+				Metadata: notesMetadata,
+			})
+		}
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+// slideOrder returns the archive paths of all slides in presentation order
+func slideOrder(files map[string]*zip.File) ([]string, error) {
+	var pres xmlPresentation
+	if err := decodeXMLFile(files, "ppt/presentation.xml", &pres); err != nil {
+		return nil, fmt.Errorf("failed to parse presentation: %w", err)
+	}
+
+	rels, err := relationships(files, "ppt/presentation.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	slidePaths := make([]string, 0, len(pres.SlideIDs))
+	for _, sld := range pres.SlideIDs {
+		target, ok := rels[sld.RelID]
+		if !ok {
+			return nil, fmt.Errorf("slide relationship %q not found in presentation", sld.RelID)
+		}
+		slidePaths = append(slidePaths, target)
+	}
+	return slidePaths, nil
+}
+
+// presentationTitle returns the title from the document properties, if set.
+// The properties are optional, so any error just results in an empty title.
+func presentationTitle(files map[string]*zip.File) string {
+	if _, ok := files["docProps/core.xml"]; !ok {
+		return ""
+	}
+	var props xmlCoreProperties
+	if err := decodeXMLFile(files, "docProps/core.xml", &props); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(props.Title)
+}
+
+// slideNotes returns the speaker notes text of the given slide, if any
+func slideNotes(files map[string]*zip.File, slidePath string) (string, error) {
+	rels, err := relationshipsByType(files, slidePath, "/notesSlide")
+	if err != nil || len(rels) == 0 {
+		return "", err
+	}
+
+	var notes xmlSlide
+	if err := decodeXMLFile(files, rels[0], &notes); err != nil {
+		return "", err
+	}
+
+	return notes.ShapeTree.notesText(), nil
+}
+
+// relationships returns the relationship targets of the given part, keyed by relationship ID
+func relationships(files map[string]*zip.File, part string) (map[string]string, error) {
+	rels, err := readRelationships(files, part)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		m[rel.ID] = resolveTarget(part, rel.Target)
+	}
+	return m, nil
+}
+
+// relationshipsByType returns the relationship targets of the given part with a type ending in typeSuffix
+func relationshipsByType(files map[string]*zip.File, part, typeSuffix string) ([]string, error) {
+	rels, err := readRelationships(files, part)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, rel := range rels.Relationships {
+		if strings.HasSuffix(rel.Type, typeSuffix) {
+			targets = append(targets, resolveTarget(part, rel.Target))
+		}
+	}
+	return targets, nil
+}
+
+func readRelationships(files map[string]*zip.File, part string) (*xmlRelationships, error) {
+	relsPath := path.Join(path.Dir(part), "_rels", path.Base(part)+".rels")
+	var rels xmlRelationships
+	if _, ok := files[relsPath]; !ok {
+		return &rels, nil
+	}
+	if err := decodeXMLFile(files, relsPath, &rels); err != nil {
+		return nil, fmt.Errorf("failed to parse relationships of %q: %w", part, err)
+	}
+	return &rels, nil
+}
+
+func resolveTarget(part, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return strings.TrimPrefix(target, "/")
+	}
+	return path.Join(path.Dir(part), target)
+}
+
+func decodeXMLFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("file %q not found in PPTX archive", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
@@ -0,0 +1,155 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nsPresentation = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	relsHeader = `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+)
+
+func testShape(placeholder, text string) string {
+	ph := ""
+	if placeholder != "" {
+		ph = `<p:ph type="` + placeholder + `"/>`
+	}
+	return `<p:sp><p:nvSpPr><p:cNvPr id="1" name="s"/><p:cNvSpPr/><p:nvPr>` + ph + `</p:nvPr></p:nvSpPr>` +
+		`<p:txBody>` + text + `</p:txBody></p:sp>`
+}
+
+func testSlide(attrs string, shapes ...string) string {
+	var tree string
+	for _, s := range shapes {
+		tree += s
+	}
+	return `<?xml version="1.0"?><p:sld ` + nsPresentation + attrs + `><p:cSld><p:spTree>` + tree + `</p:spTree></p:cSld></p:sld>`
+}
+
+// testPPTX returns a minimal presentation with three slides, listed in a different order than the slide file names:
+// a title slide with bullets and speaker notes, a hidden slide and a slide with a table
+func testPPTX(t *testing.T) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"ppt/presentation.xml": `<?xml version="1.0"?><p:presentation ` + nsPresentation + `><p:sldIdLst>` +
+			`<p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId1"/><p:sldId id="258" r:id="rId2"/>` +
+			`</p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels": relsHeader +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide2.xml"/>` +
+			`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide3.xml"/>` +
+			`</Relationships>`,
+		"docProps/core.xml": `<?xml version="1.0"?><cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+			`xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Widget Roadmap</dc:title></cp:coreProperties>`,
+
+		// first slide
+		"ppt/slides/slide3.xml": testSlide("",
+			testShape("title", `<a:p><a:r><a:t>Q3 Plans</a:t></a:r></a:p>`),
+			testShape("body", `<a:p><a:r><a:t>Ship the widget</a:t></a:r></a:p><a:p><a:pPr lvl="1"/><a:r><a:t>in </a:t></a:r><a:r><a:t>blue</a:t></a:r></a:p>`),
+			testShape("sldNum", `<a:p><a:r><a:t>1</a:t></a:r></a:p>`),
+		),
+		"ppt/slides/_rels/slide3.xml.rels": relsHeader +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide" Target="../notesSlides/notesSlide1.xml"/>` +
+			`</Relationships>`,
+		"ppt/notesSlides/notesSlide1.xml": `<?xml version="1.0"?><p:notes ` + nsPresentation + `><p:cSld><p:spTree>` +
+			testShape("sldImg", ``) +
+			testShape("body", `<a:p><a:r><a:t>Mention the launch date.</a:t></a:r></a:p>`) +
+			`</p:spTree></p:cSld></p:notes>`,
+
+		// second slide, hidden
+		"ppt/slides/slide1.xml": testSlide(` show="0"`, testShape("title", `<a:p><a:r><a:t>Backup</a:t></a:r></a:p>`)),
+
+		// third slide
+		"ppt/slides/slide2.xml": testSlide("",
+			testShape("title", `<a:p><a:r><a:t>Budget</a:t></a:r></a:p>`),
+			`<p:graphicFrame><a:graphic><a:graphicData><a:tbl>`+
+				`<a:tr><a:tc><a:txBody><a:p><a:r><a:t>Team</a:t></a:r></a:p></a:txBody></a:tc><a:tc><a:txBody><a:p><a:r><a:t>Cost</a:t></a:r></a:p></a:txBody></a:tc></a:tr>`+
+				`<a:tr><a:tc><a:txBody><a:p><a:r><a:t>Widgets</a:t></a:r></a:p></a:txBody></a:tc><a:tc><a:txBody><a:p><a:r><a:t>10</a:t></a:r></a:p></a:txBody></a:tc></a:tr>`+
+				`</a:tbl></a:graphicData></a:graphic></p:graphicFrame>`,
+		),
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func load(t *testing.T, opts PPTXOptions) []vs.Document {
+	t.Helper()
+	data := testPPTX(t)
+
+	l, err := NewPPTX(bytes.NewReader(data), int64(len(data)), WithConfig(opts))
+	require.NoError(t, err)
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	return docs
+}
+
+func TestLoad(t *testing.T) {
+	docs := load(t, PPTXOptions{})
+	require.Len(t, docs, 2, "one document per visible slide")
+
+	assert.Equal(t, "# Q3 Plans\n\n- Ship the widget\n  - in blue\n\n### Speaker Notes\n\nMention the launch date.", docs[0].Content)
+	assert.Equal(t, 1, docs[0].Metadata["slide"])
+	assert.Equal(t, 3, docs[0].Metadata["totalSlides"])
+	assert.Equal(t, "Q3 Plans", docs[0].Metadata["slideTitle"])
+	assert.Equal(t, "Widget Roadmap", docs[0].Metadata["deckTitle"])
+	assert.Equal(t, ContentTypeSlide, docs[0].Metadata["contentType"])
+	assert.Equal(t, true, docs[0].Metadata["hasSpeakerNotes"])
+	assert.Equal(t, 0, docs[0].Metadata["docIndex"])
+
+	assert.Equal(t, "# Budget\n\n| Team | Cost |\n| --- | --- |\n| Widgets | 10 |", docs[1].Content)
+	assert.Equal(t, 3, docs[1].Metadata["slide"])
+	assert.Equal(t, false, docs[1].Metadata["hasSpeakerNotes"])
+	assert.Equal(t, 1, docs[1].Metadata["docIndex"])
+}
+
+func TestLoadSpeakerNotes(t *testing.T) {
+	docs := load(t, PPTXOptions{NotesMode: NotesModeSeparate})
+	require.Len(t, docs, 3)
+	assert.Equal(t, "# Q3 Plans\n\n- Ship the widget\n  - in blue", docs[0].Content)
+	assert.Equal(t, "Mention the launch date.", docs[1].Content)
+	assert.Equal(t, ContentTypeSpeakerNotes, docs[1].Metadata["contentType"])
+	assert.Equal(t, 1, docs[1].Metadata["slide"])
+	assert.Equal(t, "Q3 Plans", docs[1].Metadata["slideTitle"])
+
+	docs = load(t, PPTXOptions{NotesMode: NotesModeMetadata})
+	require.Len(t, docs, 2)
+	assert.Equal(t, "Mention the launch date.", docs[0].Metadata["speakerNotes"])
+	assert.NotContains(t, docs[0].Content, "launch date")
+
+	docs = load(t, PPTXOptions{NotesMode: NotesModeNone})
+	require.Len(t, docs, 2)
+	assert.NotContains(t, docs[0].Content, "launch date")
+	assert.Equal(t, false, docs[0].Metadata["hasSpeakerNotes"])
+}
+
+func TestLoadHidden(t *testing.T) {
+	docs := load(t, PPTXOptions{IncludeHidden: true})
+	require.Len(t, docs, 3)
+	assert.Equal(t, "# Backup", docs[1].Content)
+	assert.Equal(t, 2, docs[1].Metadata["slide"])
+	assert.Equal(t, true, docs[1].Metadata["hidden"])
+}
+
+func TestNewPPTXInvalidNotesMode(t *testing.T) {
+	_, err := NewPPTX(bytes.NewReader(nil), 0, WithConfig(PPTXOptions{NotesMode: "footnotes"}))
+	assert.ErrorContains(t, err, "invalid PPTX notesMode")
+}
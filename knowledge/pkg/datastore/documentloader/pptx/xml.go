@@ -0,0 +1,249 @@
+package pptx
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Minimal subset of the PresentationML (ECMA-376) schema required to extract text.
+ * Element names are matched by their local name, so we don't need to care about namespace prefixes.
+ */
+
+type xmlPresentation struct {
+	SlideIDs []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sldIdLst>sldId"`
+}
+
+// xmlCoreProperties holds the document properties from docProps/core.xml
+type xmlCoreProperties struct {
+	Title string `xml:"title"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xmlSlide is used for both slides and notes slides, as they share the same structure
+type xmlSlide struct {
+	Show      string       `xml:"show,attr"`
+	ShapeTree xmlShapeTree `xml:"cSld>spTree"`
+}
+
+type xmlShapeTree struct {
+	Shapes        []xmlShape        `xml:"sp"`
+	Groups        []xmlShapeTree    `xml:"grpSp"`
+	GraphicFrames []xmlGraphicFrame `xml:"graphicFrame"`
+}
+
+type xmlShape struct {
+	Placeholder *struct {
+		Type string `xml:"type,attr"`
+	} `xml:"nvSpPr>nvPr>ph"`
+	TextBody *xmlTextBody `xml:"txBody"`
+}
+
+type xmlGraphicFrame struct {
+	Rows []struct {
+		Cells []struct {
+			TextBody xmlTextBody `xml:"txBody"`
+		} `xml:"tc"`
+	} `xml:"graphic>graphicData>tbl>tr"`
+}
+
+type xmlTextBody struct {
+	Paragraphs []xmlParagraph `xml:"p"`
+}
+
+type xmlParagraph struct {
+	Level int
+	Text  string
+}
+
+// UnmarshalXML collects the text runs, fields and line breaks of a paragraph in document order.
+func (p *xmlParagraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var sb strings.Builder
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "pPr":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "lvl" {
+						p.Level, _ = strconv.Atoi(attr.Value)
+					}
+				}
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case "t":
+				var s string
+				if err := d.DecodeElement(&s, &t); err != nil {
+					return err
+				}
+				sb.WriteString(s)
+			case "br":
+				sb.WriteString("\n")
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			default:
+				depth++
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				p.Text = strings.TrimSpace(sb.String())
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// placeholders that don't carry any useful content for retrieval
+var ignoredPlaceholders = map[string]struct{}{
+	"sldNum": {},
+	"dt":     {},
+	"ftr":    {},
+	"hdr":    {},
+	"sldImg": {},
+}
+
+// render returns the slide title (if any) and the markdown representation of the shape tree
+func (t xmlShapeTree) render() (string, string) {
+	var title string
+	var blocks []string
+
+	for _, sp := range t.Shapes {
+		if sp.TextBody == nil {
+			continue
+		}
+
+		phType := ""
+		isPlaceholder := sp.Placeholder != nil
+		if isPlaceholder {
+			phType = sp.Placeholder.Type
+		}
+		if _, ok := ignoredPlaceholders[phType]; ok {
+			continue
+		}
+
+		switch phType {
+		case "title", "ctrTitle":
+			text := sp.TextBody.plainText()
+			if text == "" {
+				continue
+			}
+			if title == "" {
+				title = strings.ReplaceAll(text, "\n", " ")
+			}
+			blocks = append(blocks, "# "+strings.ReplaceAll(text, "\n", " "))
+		case "subTitle":
+			if text := sp.TextBody.plainText(); text != "" {
+				blocks = append(blocks, "## "+strings.ReplaceAll(text, "\n", " "))
+			}
+		default:
+			// body and object placeholders (no type) hold bullet lists, other shapes are free text boxes
+			if text := sp.TextBody.markdown(isPlaceholder); text != "" {
+				blocks = append(blocks, text)
+			}
+		}
+	}
+
+	for _, gf := range t.GraphicFrames {
+		if text := gf.markdown(); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+
+	for _, g := range t.Groups {
+		groupTitle, text := g.render()
+		if title == "" {
+			title = groupTitle
+		}
+		if text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+
+	return title, strings.Join(blocks, "\n\n")
+}
+
+// notesText returns the plain text of a notes slide, i.e. everything but the slide image and header/footer placeholders
+func (t xmlShapeTree) notesText() string {
+	var blocks []string
+	for _, sp := range t.Shapes {
+		if sp.TextBody == nil {
+			continue
+		}
+		if sp.Placeholder != nil {
+			if _, ok := ignoredPlaceholders[sp.Placeholder.Type]; ok {
+				continue
+			}
+		}
+		if text := sp.TextBody.plainText(); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	for _, g := range t.Groups {
+		if text := g.notesText(); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (b xmlTextBody) plainText() string {
+	var lines []string
+	for _, p := range b.Paragraphs {
+		if p.Text != "" {
+			lines = append(lines, p.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b xmlTextBody) markdown(bullets bool) string {
+	if !bullets {
+		return b.plainText()
+	}
+	var lines []string
+	for _, p := range b.Paragraphs {
+		if p.Text == "" {
+			continue
+		}
+		lines = append(lines, strings.Repeat("  ", p.Level)+"- "+strings.ReplaceAll(p.Text, "\n", " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdown renders a table graphic frame as a markdown table - other graphic frames (charts, diagrams, ...) are ignored
+func (g xmlGraphicFrame) markdown() string {
+	if len(g.Rows) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	for i, row := range g.Rows {
+		cells := make([]string, len(row.Cells))
+		for j, cell := range row.Cells {
+			cells[j] = strings.ReplaceAll(strings.ReplaceAll(cell.TextBody.plainText(), "\n", " "), "|", "\\|")
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString(strings.Repeat("| --- ", len(cells)) + "|\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
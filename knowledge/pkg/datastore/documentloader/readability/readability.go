@@ -0,0 +1,381 @@
+package readability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure Readability satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Readability)(nil)
+
+const defaultMinContentLength = 250
+
+// boilerplateSelectors are removed before the main content is detected
+var boilerplateSelectors = []string{
+	"script", "style", "noscript", "template", "iframe", "svg", "canvas", "button", "input", "select", "textarea",
+	"nav", "aside", "footer", "dialog",
+	"[role=navigation]", "[role=banner]", "[role=contentinfo]", "[role=complementary]", "[role=search]", "[role=dialog]",
+	"[aria-hidden=true]", "[hidden]",
+}
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|\bads?\b|advert|banner|breadcrumb|combx|comment|community|cookie|consent|disqus|extra|footer|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tweet|widget`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|post|entry|story|text|blog`)
+	headingLine        = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+)
+
+type ReadabilityOptions struct {
+	// ContentSelector is a CSS selector for the main content - skips the content detection if it matches
+	ContentSelector string `mapstructure:"contentSelector" json:"contentSelector,omitempty"`
+
+	// RemoveSelectors are additional CSS selectors of elements to remove, e.g. site-specific banners
+	RemoveSelectors []string `mapstructure:"removeSelectors" json:"removeSelectors,omitempty"`
+
+	// MinContentLength is the minimum text length of the detected main content - if it's shorter,
+	// the whole (cleaned) body is used instead (default: 250)
+	MinContentLength int `mapstructure:"minContentLength" json:"minContentLength,omitempty"`
+
+	// SplitSections emits one document per heading section instead of one document per page
+	SplitSections bool `mapstructure:"splitSections" json:"splitSections,omitempty"`
+}
+
+// WithConfig sets the readability loader configuration.
+func WithConfig(config ReadabilityOptions) func(o *ReadabilityOptions) {
+	return func(o *ReadabilityOptions) {
+		*o = config
+	}
+}
+
+// Readability is an HTML document loader that strips boilerplate like navigation, footers and scripts
+// and only keeps the main content of the page (readability-style extraction), converted to Markdown.
+// The page title, canonical URL, description, language and headings are added to the metadata.
+type Readability struct {
+	data      []byte
+	opts      ReadabilityOptions
+	converter *mdconv.Converter
+}
+
+func NewReadabilityFromReader(r io.Reader, optFns ...func(o *ReadabilityOptions)) (*Readability, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML data: %w", err)
+	}
+	return NewReadability(data, optFns...), nil
+}
+
+// NewReadability creates a new readability loader with the given options.
+func NewReadability(data []byte, optFns ...func(o *ReadabilityOptions)) *Readability {
+	opts := ReadabilityOptions{
+		MinContentLength: defaultMinContentLength,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.MinContentLength <= 0 {
+		opts.MinContentLength = defaultMinContentLength
+	}
+
+	return &Readability{
+		data:      data,
+		opts:      opts,
+		converter: mdconv.NewConverter(mdconv.WithPlugins(base.NewBasePlugin(), commonmark.NewCommonmarkPlugin())),
+	}
+}
+
+// Load extracts the main content of the HTML page and returns it as one document (or one per section).
+func (l *Readability) Load(ctx context.Context) ([]vs.Document, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(l.data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	metadata := pageMetadata(doc)
+
+	content := l.extract(doc)
+
+	html, err := goquery.OuterHtml(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render main content: %w", err)
+	}
+
+	markdown, err := l.converter.ConvertString(html)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert main content to markdown: %w", err)
+	}
+	markdown = strings.TrimSpace(markdown)
+	if markdown == "" {
+		return nil, nil
+	}
+
+	var docs []vs.Document
+	if l.opts.SplitSections {
+		for _, s := range splitSections(markdown) {
+			md := copyMetadata(metadata)
+			if len(s.headings) > 0 {
+				md["heading"] = s.headings[len(s.headings)-1]
+				md["headingPath"] = strings.Join(s.headings, " > ")
+			}
+			docs = append(docs, vs.Document{
+				Content:  s.content,
+				Metadata: md,
+			})
+		}
+	} else {
+		if headings := markdownHeadings(markdown); len(headings) > 0 {
+			metadata["headings"] = headings
+		}
+		docs = append(docs, vs.Document{
+			Content:  markdown,
+			Metadata: metadata,
+		})
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+// extract removes the boilerplate from the document and returns the main content node
+func (l *Readability) extract(doc *goquery.Document) *goquery.Selection {
+	if l.opts.ContentSelector != "" {
+		if sel := doc.Find(l.opts.ContentSelector); sel.Length() > 0 {
+			removeBoilerplate(sel, l.opts.RemoveSelectors)
+			return sel.First()
+		}
+	}
+
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+	removeBoilerplate(body, l.opts.RemoveSelectors)
+
+	// page headers are boilerplate, but headers within an article usually hold its title
+	body.Find("header").Each(func(_ int, s *goquery.Selection) {
+		if s.Closest("article, main, [role=main]").Length() == 0 {
+			s.Remove()
+		}
+	})
+
+	// semantic markup wins over the heuristics, if it's unambiguous
+	for _, selector := range []string{"main", "[role=main]", "article"} {
+		if sel := body.Find(selector); sel.Length() == 1 && textLength(sel) >= l.opts.MinContentLength {
+			return sel
+		}
+	}
+
+	if candidate := bestCandidate(body); candidate != nil && textLength(candidate) >= l.opts.MinContentLength {
+		return candidate
+	}
+
+	return body
+}
+
+// Clean removes the boilerplate (scripts, styles, navigation, footers, banners, ...) and the elements matching the
+// extra selectors from the HTML page and returns the cleaned page, keeping its head for the page metadata.
+// Unlike Load, it doesn't detect the main content, so that the page can still be loaded by any HTML loader.
+func Clean(data []byte, extraSelectors ...string) ([]byte, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	doc.Find("head script, head style, head noscript").Remove()
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc.Selection
+	}
+	removeBoilerplate(body, extraSelectors)
+
+	html, err := doc.Html()
+	if err != nil {
+		return nil, fmt.Errorf("failed to render cleaned HTML: %w", err)
+	}
+	return []byte(html), nil
+}
+
+func removeBoilerplate(sel *goquery.Selection, extraSelectors []string) {
+	sel.Find(strings.Join(slices.Concat(boilerplateSelectors, extraSelectors), ", ")).Remove()
+
+	sel.Find("div, section, span, ul, table, p").Each(func(_ int, s *goquery.Selection) {
+		classAndID := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if strings.TrimSpace(classAndID) == "" {
+			return
+		}
+		if unlikelyCandidates.MatchString(classAndID) && !maybeCandidate.MatchString(classAndID) {
+			s.Remove()
+		}
+	})
+}
+
+// bestCandidate scores the parents of all paragraphs by their text and returns the highest scoring one,
+// similar to Mozilla's Readability
+func bestCandidate(body *goquery.Selection) *goquery.Selection {
+	// keyed by the underlying node, as the same element may be reached via different selections
+	scores := map[any]float64{}
+	var candidates []*goquery.Selection
+
+	addScore := func(s *goquery.Selection, score float64) {
+		n := s.Get(0)
+		if _, ok := scores[n]; !ok {
+			candidates = append(candidates, s)
+		}
+		scores[n] += score
+	}
+
+	body.Find("p, pre, td, blockquote").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		addScore(parent, score)
+		if grandparent := parent.Parent(); grandparent.Length() > 0 {
+			addScore(grandparent, score/2)
+		}
+	})
+
+	var best *goquery.Selection
+	var bestScore float64
+	for _, s := range candidates {
+		score := scores[s.Get(0)] * (1 - linkDensity(s))
+		if best == nil || score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := textLength(s)
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += textLength(a)
+	})
+	return float64(links) / float64(total)
+}
+
+func textLength(s *goquery.Selection) int {
+	return len(strings.Join(strings.Fields(s.Text()), " "))
+}
+
+func pageMetadata(doc *goquery.Document) map[string]any {
+	metadata := map[string]any{}
+
+	title := strings.TrimSpace(doc.Find("head title").First().Text())
+	if title == "" {
+		title = doc.Find(`meta[property="og:title"]`).AttrOr("content", "")
+	}
+	if title != "" {
+		metadata["title"] = title
+	}
+
+	canonical := strings.TrimSpace(doc.Find(`link[rel="canonical"]`).AttrOr("href", ""))
+	if canonical == "" {
+		canonical = strings.TrimSpace(doc.Find(`meta[property="og:url"]`).AttrOr("content", ""))
+	}
+	if canonical != "" {
+		metadata["canonicalURL"] = canonical
+	}
+
+	description := strings.TrimSpace(doc.Find(`meta[name="description"]`).AttrOr("content", ""))
+	if description == "" {
+		description = strings.TrimSpace(doc.Find(`meta[property="og:description"]`).AttrOr("content", ""))
+	}
+	if description != "" {
+		metadata["description"] = description
+	}
+
+	if lang := strings.TrimSpace(doc.Find("html").AttrOr("lang", "")); lang != "" {
+		metadata["language"] = lang
+	}
+
+	return metadata
+}
+
+type section struct {
+	headings []string // heading path, from the top-level heading down to the section heading
+	content  string
+}
+
+// splitSections splits the Markdown content at its headings (ignoring code blocks)
+func splitSections(markdown string) []section {
+	var sections []section
+	var path []string
+	var levels []int
+	var current []string
+	inCode := false
+
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(current, "\n"))
+		if content != "" {
+			sections = append(sections, section{headings: append([]string(nil), path...), content: content})
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+		}
+		if m := headingLine.FindStringSubmatch(line); m != nil && !inCode {
+			flush()
+			level := len(m[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+				path = path[:len(path)-1]
+			}
+			levels = append(levels, level)
+			path = append(path, m[2])
+		}
+		current = append(current, line)
+	}
+	flush()
+
+	return sections
+}
+
+func markdownHeadings(markdown string) []string {
+	var headings []string
+	for _, s := range splitSections(markdown) {
+		if len(s.headings) > 0 {
+			headings = append(headings, s.headings[len(s.headings)-1])
+		}
+	}
+	return headings
+}
+
+func copyMetadata(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
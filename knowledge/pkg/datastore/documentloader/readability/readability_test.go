@@ -0,0 +1,90 @@
+package readability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>Configuring Widgets | Acme Docs</title>
+  <link rel="canonical" href="https://docs.acme.example/widgets/configure">
+  <meta name="description" content="How to configure widgets.">
+  <script>var tracking = "do not index me";</script>
+</head>
+<body>
+  <header><a href="/">Acme</a> <a href="/docs">Docs</a> <a href="/blog">Blog</a></header>
+  <nav><ul><li><a href="/a">Getting started</a></li><li><a href="/b">Widgets</a></li></ul></nav>
+  <div class="layout">
+    <div id="sidebar-menu"><a href="/x">Related page one</a>, <a href="/y">Related page two</a></div>
+    <div class="docs-body">
+      <h1>Configuring Widgets</h1>
+      <p>Widgets are configured through a YAML file, which is read once on startup, merged with the defaults and validated before use.</p>
+      <h2>Options</h2>
+      <p>Each widget supports a name, a size and a color, and all of them can be overridden per environment using variables.</p>
+      <h3>Size</h3>
+      <p>The size is given in pixels, must be positive, and is clamped to the maximum size allowed by the surrounding layout.</p>
+      <h2>Troubleshooting</h2>
+      <p>If a widget doesn't show up, check the logs for validation errors, typos in option names, or missing permissions.</p>
+    </div>
+  </div>
+  <div class="cookie-banner">We use cookies, please accept them to continue browsing this website.</div>
+  <footer>Copyright Acme, all rights reserved. Imprint, privacy policy and terms of service.</footer>
+</body>
+</html>`
+
+func TestLoad(t *testing.T) {
+	docs, err := NewReadability([]byte(testPage)).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+
+	content := docs[0].Content
+	assert.Contains(t, content, "# Configuring Widgets")
+	assert.Contains(t, content, "clamped to the maximum size")
+	for _, boilerplate := range []string{"do not index me", "Getting started", "Related page", "cookies", "Copyright", "Blog"} {
+		assert.NotContains(t, content, boilerplate)
+	}
+
+	md := docs[0].Metadata
+	assert.Equal(t, "Configuring Widgets | Acme Docs", md["title"])
+	assert.Equal(t, "https://docs.acme.example/widgets/configure", md["canonicalURL"])
+	assert.Equal(t, "How to configure widgets.", md["description"])
+	assert.Equal(t, "en", md["language"])
+	assert.Equal(t, []string{"Configuring Widgets", "Options", "Size", "Troubleshooting"}, md["headings"])
+}
+
+func TestLoadSplitSections(t *testing.T) {
+	docs, err := NewReadability([]byte(testPage), WithConfig(ReadabilityOptions{SplitSections: true})).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 4)
+
+	assert.Equal(t, "Size", docs[2].Metadata["heading"])
+	assert.Equal(t, "Configuring Widgets > Options > Size", docs[2].Metadata["headingPath"])
+	assert.Equal(t, "Configuring Widgets > Troubleshooting", docs[3].Metadata["headingPath"])
+	assert.Equal(t, "https://docs.acme.example/widgets/configure", docs[3].Metadata["canonicalURL"])
+	assert.Equal(t, 3, docs[3].Metadata["docIndex"])
+}
+
+func TestLoadContentSelector(t *testing.T) {
+	docs, err := NewReadability([]byte(testPage), WithConfig(ReadabilityOptions{ContentSelector: "#sidebar-menu"})).Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Contains(t, docs[0].Content, "Related page one")
+	assert.NotContains(t, docs[0].Content, "Widgets are configured")
+}
+
+func TestClean(t *testing.T) {
+	cleaned, err := Clean([]byte(testPage), ".docs-body h3")
+	require.NoError(t, err)
+
+	html := string(cleaned)
+	assert.Contains(t, html, "<title>Configuring Widgets | Acme Docs</title>")
+	assert.Contains(t, html, "Widgets are configured through a YAML file")
+	for _, removed := range []string{"do not index me", "Getting started", "Related page one", "We use cookies", "Copyright Acme", "<h3>Size</h3>"} {
+		assert.NotContains(t, html, removed)
+	}
+}
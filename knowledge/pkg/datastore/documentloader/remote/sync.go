@@ -0,0 +1,62 @@
+package documentloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// SyncRepo clones the repository as bare repository into dir or, if it was cloned there before, fetches its updates,
+// and returns the commit of the ref - a branch, tag or commit hash, by default the remote's default branch
+func SyncRepo(ctx context.Context, repoURL, ref, dir string) (*object.Commit, error) {
+	r, err := git.PlainOpen(dir)
+	switch {
+	case errors.Is(err, git.ErrRepositoryNotExists):
+		slog.Info("Cloning repository", "repo", repoURL, "dir", dir)
+		r, err = git.PlainCloneContext(ctx, dir, true, &git.CloneOptions{URL: repoURL, Tags: git.AllTags})
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to clone repo %q: %w", repoURL, err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to open clone of repo %q in %s: %w", repoURL, dir, err)
+	default:
+		slog.Info("Fetching repository", "repo", repoURL, "dir", dir)
+		err = r.FetchContext(ctx, &git.FetchOptions{Tags: git.AllTags, Force: true})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil, fmt.Errorf("failed to fetch repo %q: %w", repoURL, err)
+		}
+	}
+
+	hash, err := resolveRef(r, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q in repo %q: %w", ref, repoURL, err)
+	}
+	return r.CommitObject(*hash)
+}
+
+// resolveRef resolves the ref to a commit, preferring the fetched remote branches over the local branches,
+// which are not updated by fetching
+func resolveRef(r *git.Repository, ref string) (*plumbing.Hash, error) {
+	if ref == "" {
+		head, err := r.Reference(plumbing.HEAD, false)
+		if err != nil {
+			return nil, err
+		}
+		if head.Type() != plumbing.SymbolicReference {
+			return r.ResolveRevision(plumbing.Revision(plumbing.HEAD))
+		}
+		ref = head.Target().Short()
+	}
+
+	if hash, err := r.ResolveRevision(plumbing.Revision(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, ref))); err == nil {
+		return hash, nil
+	}
+	return r.ResolveRevision(plumbing.Revision(ref))
+}
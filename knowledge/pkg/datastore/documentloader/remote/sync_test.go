@@ -0,0 +1,58 @@
+package documentloader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncRepo(t *testing.T) {
+	src := t.TempDir()
+	repo, err := git.PlainInit(src, false)
+	require.NoError(t, err)
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(file, content string) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(src, file), []byte(content), 0644))
+		_, err := w.Add(file)
+		require.NoError(t, err)
+		h, err := w.Commit("update "+file, &git.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+		require.NoError(t, err)
+		return h
+	}
+
+	first := commit("a.md", "first")
+	_, err = repo.CreateTag("v1", first, nil)
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "clone")
+	c, err := SyncRepo(context.Background(), src, "", dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, c.Hash)
+
+	// fetching updates the default branch
+	second := commit("b.md", "second")
+	c, err = SyncRepo(context.Background(), src, "", dir)
+	require.NoError(t, err)
+	assert.Equal(t, second, c.Hash)
+
+	c, err = SyncRepo(context.Background(), src, "v1", dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, c.Hash)
+
+	c, err = SyncRepo(context.Background(), src, first.String()[:10], dir)
+	require.NoError(t, err)
+	assert.Equal(t, first, c.Hash)
+
+	_, err = SyncRepo(context.Background(), src, "does-not-exist", dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,323 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure Spreadsheet satisfies the DocumentLoader interface.
+var _ types.DocumentLoader = (*Spreadsheet)(nil)
+
+const (
+	// ModeRow emits one document per row, with the cells keyed by column header in the metadata
+	ModeRow = "row"
+	// ModeBlock emits one document per block of BlockSize rows, rendered as a Markdown table with the header repeated
+	ModeBlock = "block"
+
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	defaultBlockSize = 50
+)
+
+type SpreadsheetOptions struct {
+	// Mode defines how rows are turned into documents: row (default) or block
+	Mode string `mapstructure:"mode" json:"mode,omitempty"`
+
+	// BlockSize is the number of rows per document in block mode (default: 50)
+	BlockSize int `mapstructure:"blockSize" json:"blockSize,omitempty"`
+
+	// NoHeader treats the first row as data - columns are then named by their letter (A, B, C, ...)
+	NoHeader bool `mapstructure:"noHeader" json:"noHeader,omitempty"`
+
+	// Sheets limits the XLSX sheets to load by name (default: all)
+	Sheets []string `mapstructure:"sheets" json:"sheets,omitempty"`
+
+	// Format is either csv or xlsx - detected from the content if empty
+	Format string `mapstructure:"format" json:"format,omitempty"`
+
+	// Delimiter is the CSV field delimiter (default: ",")
+	Delimiter string `mapstructure:"delimiter" json:"delimiter,omitempty"`
+}
+
+// WithConfig sets the spreadsheet loader configuration.
+func WithConfig(config SpreadsheetOptions) func(o *SpreadsheetOptions) {
+	return func(o *SpreadsheetOptions) {
+		*o = config
+	}
+}
+
+// Spreadsheet is a document loader for CSV and XLSX files that implements the DocumentLoader interface.
+// Unlike converting the file to text, it keeps the relation between cells and column headers, which is
+// lost when tables are flattened.
+type Spreadsheet struct {
+	data []byte
+	opts SpreadsheetOptions
+}
+
+// sheet is a parsed table, where rows[i] has the 1-based row number rowNumbers[i] in the original file
+type sheet struct {
+	name       string
+	rows       [][]string
+	rowNumbers []int
+}
+
+func NewSpreadsheetFromReader(r io.Reader, optFns ...func(o *SpreadsheetOptions)) (*Spreadsheet, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spreadsheet data: %w", err)
+	}
+	return NewSpreadsheet(data, optFns...)
+}
+
+// NewSpreadsheet creates a new spreadsheet loader with the given options.
+func NewSpreadsheet(data []byte, optFns ...func(o *SpreadsheetOptions)) (*Spreadsheet, error) {
+	opts := SpreadsheetOptions{
+		Mode:      ModeRow,
+		BlockSize: defaultBlockSize,
+	}
+
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeRow
+	case ModeRow, ModeBlock:
+	default:
+		return nil, fmt.Errorf("invalid spreadsheet mode %q, must be one of %q or %q", opts.Mode, ModeRow, ModeBlock)
+	}
+
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = defaultBlockSize
+	}
+
+	if opts.Format == "" {
+		opts.Format = FormatCSV
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			opts.Format = FormatXLSX
+		}
+	}
+	if opts.Format != FormatCSV && opts.Format != FormatXLSX {
+		return nil, fmt.Errorf("invalid spreadsheet format %q, must be one of %q or %q", opts.Format, FormatCSV, FormatXLSX)
+	}
+
+	if len([]rune(opts.Delimiter)) > 1 {
+		return nil, fmt.Errorf("invalid CSV delimiter %q, must be a single character", opts.Delimiter)
+	}
+
+	return &Spreadsheet{
+		data: data,
+		opts: opts,
+	}, nil
+}
+
+// Load loads the spreadsheet and returns a slice of vs.Document with one document per row or block of rows.
+func (l *Spreadsheet) Load(ctx context.Context) ([]vs.Document, error) {
+	var sheets []sheet
+	var err error
+	if l.opts.Format == FormatXLSX {
+		sheets, err = readXLSX(l.data)
+	} else {
+		sheets, err = l.readCSV()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []vs.Document
+	for _, sh := range sheets {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if len(l.opts.Sheets) > 0 && !slices.Contains(l.opts.Sheets, sh.name) {
+			continue
+		}
+		docs = append(docs, l.sheetDocuments(sh)...)
+	}
+
+	for i := range docs {
+		docs[i].Metadata[vs.DocMetadataKeyDocIndex] = i
+	}
+
+	return docs, nil
+}
+
+func (l *Spreadsheet) readCSV() ([]sheet, error) {
+	r := csv.NewReader(bytes.NewReader(l.data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if l.opts.Delimiter != "" {
+		r.Comma = []rune(l.opts.Delimiter)[0]
+	}
+
+	sh := sheet{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		sh.rows = append(sh.rows, record)
+		sh.rowNumbers = append(sh.rowNumbers, line)
+	}
+	return []sheet{sh}, nil
+}
+
+func (l *Spreadsheet) sheetDocuments(sh sheet) []vs.Document {
+	rows, rowNumbers := sh.rows, sh.rowNumbers
+
+	// drop empty rows
+	for i := len(rows) - 1; i >= 0; i-- {
+		if isEmptyRow(rows[i]) {
+			rows = slices.Delete(rows, i, i+1)
+			rowNumbers = slices.Delete(rowNumbers, i, i+1)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	var headers []string
+	if l.opts.NoHeader {
+		headers = make([]string, width)
+		for i := range headers {
+			headers[i] = columnName(i)
+		}
+	} else {
+		headers = make([]string, width)
+		for i := range headers {
+			if i < len(rows[0]) && strings.TrimSpace(rows[0][i]) != "" {
+				headers[i] = strings.TrimSpace(rows[0][i])
+			} else {
+				headers[i] = columnName(i)
+			}
+		}
+		rows, rowNumbers = rows[1:], rowNumbers[1:]
+	}
+
+	baseMetadata := func() map[string]any {
+		md := map[string]any{
+			"columns":   headers,
+			"totalRows": len(rows),
+		}
+		if sh.name != "" {
+			md["sheet"] = sh.name
+		}
+		return md
+	}
+
+	var docs []vs.Document
+	if l.opts.Mode == ModeRow {
+		for i, row := range rows {
+			var sb strings.Builder
+			cells := make(map[string]any, len(headers))
+			for j, h := range headers {
+				v := cell(row, j)
+				if v == "" {
+					continue
+				}
+				cells[h] = v
+				fmt.Fprintf(&sb, "%s: %s\n", h, v)
+			}
+			md := baseMetadata()
+			md["row"] = rowNumbers[i]
+			md["cells"] = cells
+			docs = append(docs, vs.Document{
+				Content:  strings.TrimSpace(sb.String()),
+				Metadata: md,
+			})
+		}
+		return docs
+	}
+
+	for start := 0; start < len(rows); start += l.opts.BlockSize {
+		end := min(start+l.opts.BlockSize, len(rows))
+		md := baseMetadata()
+		md["rowStart"] = rowNumbers[start]
+		md["rowEnd"] = rowNumbers[end-1]
+		docs = append(docs, vs.Document{
+			Content:  markdownTable(headers, rows[start:end]),
+			Metadata: md,
+		})
+	}
+	return docs
+}
+
+func markdownTable(headers []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(escapeCells(headers), " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat(" --- |", len(headers)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(headers))
+		for j := range headers {
+			cells[j] = cell(row, j)
+		}
+		sb.WriteString("| " + strings.Join(escapeCells(cells), " | ") + " |\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func escapeCells(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		c = strings.ReplaceAll(c, "|", "\\|")
+		escaped[i] = strings.Join(strings.Fields(c), " ")
+	}
+	return escaped
+}
+
+func cell(row []string, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func isEmptyRow(row []string) bool {
+	for _, c := range row {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// columnName returns the spreadsheet column name for the 0-based index, e.g. 0 -> A, 26 -> AA
+func columnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+// columnIndex returns the 0-based column index of a cell reference, e.g. "AB12" -> 27
+func columnIndex(ref string) int {
+	idx := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		idx = idx*26 + int(r-'A'+1)
+	}
+	return idx - 1
+}
@@ -0,0 +1,95 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCSV = `name,region,revenue
+Acme,EU,100
+
+Globex,US,250
+Initech,,75
+`
+
+func TestLoadCSVRows(t *testing.T) {
+	l, err := NewSpreadsheet([]byte(testCSV))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, "name: Acme\nregion: EU\nrevenue: 100", docs[0].Content)
+	assert.Equal(t, 2, docs[0].Metadata["row"])
+	assert.Equal(t, []string{"name", "region", "revenue"}, docs[0].Metadata["columns"])
+	assert.Equal(t, map[string]any{"name": "Acme", "region": "EU", "revenue": "100"}, docs[0].Metadata["cells"])
+
+	assert.Equal(t, 4, docs[1].Metadata["row"], "row numbers should refer to the original file")
+	assert.Equal(t, "name: Initech\nrevenue: 75", docs[2].Content)
+	assert.Equal(t, 2, docs[2].Metadata[vs.DocMetadataKeyDocIndex])
+}
+
+func TestLoadCSVBlocks(t *testing.T) {
+	l, err := NewSpreadsheet([]byte(testCSV), WithConfig(SpreadsheetOptions{Mode: ModeBlock, BlockSize: 2}))
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "| name | region | revenue |\n| --- | --- | --- |\n| Acme | EU | 100 |\n| Globex | US | 250 |", docs[0].Content)
+	assert.Equal(t, 2, docs[0].Metadata["rowStart"])
+	assert.Equal(t, 4, docs[0].Metadata["rowEnd"])
+	assert.Equal(t, "| name | region | revenue |\n| --- | --- | --- |\n| Initech |  | 75 |", docs[1].Content)
+}
+
+func TestLoadXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/><sheet name="Hidden" sheetId="2" state="hidden" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>product</t></si><si><t>units</t></si><si><r><t>Widget </t></r><r><t>Pro</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3"><v>42</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>Gadget</t></is></c><c r="B4" t="b"><v>1</v></c><c r="C4"><v>7</v></c></row>
+</sheetData></worksheet>`,
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	l, err := NewSpreadsheet(buf.Bytes())
+	require.NoError(t, err)
+
+	docs, err := l.Load(context.Background())
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	assert.Equal(t, "product: Widget Pro\nunits: 42", docs[0].Content)
+	assert.Equal(t, "Sales", docs[0].Metadata["sheet"])
+	assert.Equal(t, 3, docs[0].Metadata["row"])
+	assert.Equal(t, []string{"product", "B", "units"}, docs[0].Metadata["columns"])
+	assert.Equal(t, "product: Gadget\nB: true\nunits: 7", docs[1].Content)
+}
+
+func TestColumnNames(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, columnName(i))
+		assert.Equal(t, i, columnIndex(name+"12"))
+	}
+}
@@ -0,0 +1,180 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Minimal subset of the SpreadsheetML (ECMA-376) schema required to extract cell values.
+ * Element names are matched by their local name, so we don't need to care about namespace prefixes.
+ * Cell values are taken as stored, i.e. number formats (e.g. for dates) are not applied.
+ */
+
+type xmlWorkbook struct {
+	Sheets []struct {
+		Name  string `xml:"name,attr"`
+		State string `xml:"state,attr"`
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xmlRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xmlSharedStrings struct {
+	Items []xmlRichText `xml:"si"`
+}
+
+// xmlRichText is either a plain text (t) or a list of formatted runs (r>t)
+type xmlRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xmlRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+type xmlWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string      `xml:"r,attr"`
+			Type   string      `xml:"t,attr"`
+			Value  string      `xml:"v"`
+			Inline xmlRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([]sheet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var wb xmlWorkbook
+	if err := decodeXMLFile(files, "xl/workbook.xml", &wb); err != nil {
+		return nil, fmt.Errorf("failed to parse workbook: %w", err)
+	}
+
+	var rels xmlRelationships
+	if err := decodeXMLFile(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, fmt.Errorf("failed to parse workbook relationships: %w", err)
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if strings.HasPrefix(rel.Target, "/") {
+			targets[rel.ID] = strings.TrimPrefix(rel.Target, "/")
+		} else {
+			targets[rel.ID] = path.Join("xl", rel.Target)
+		}
+	}
+
+	var sharedStrings []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xmlSharedStrings
+		if err := decodeXMLFile(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, fmt.Errorf("failed to parse shared strings: %w", err)
+		}
+		sharedStrings = make([]string, len(sst.Items))
+		for i, si := range sst.Items {
+			sharedStrings[i] = si.String()
+		}
+	}
+
+	sheets := make([]sheet, 0, len(wb.Sheets))
+	for _, s := range wb.Sheets {
+		if s.State == "hidden" || s.State == "veryHidden" {
+			continue
+		}
+		target, ok := targets[s.RelID]
+		if !ok {
+			return nil, fmt.Errorf("sheet relationship %q not found in workbook", s.RelID)
+		}
+
+		var ws xmlWorksheet
+		if err := decodeXMLFile(files, target, &ws); err != nil {
+			return nil, fmt.Errorf("failed to parse sheet %q: %w", s.Name, err)
+		}
+
+		sh := sheet{name: s.Name}
+		for i, row := range ws.Rows {
+			var values []string
+			for j, c := range row.Cells {
+				col := j
+				if c.Ref != "" {
+					col = columnIndex(c.Ref)
+				}
+				if col < 0 || (c.Value == "" && c.Type != "inlineStr") {
+					continue
+				}
+				for len(values) <= col {
+					values = append(values, "")
+				}
+
+				switch c.Type {
+				case "s":
+					idx, err := strconv.Atoi(c.Value)
+					if err != nil || idx < 0 || idx >= len(sharedStrings) {
+						return nil, fmt.Errorf("invalid shared string reference %q in sheet %q", c.Value, s.Name)
+					}
+					values[col] = sharedStrings[idx]
+				case "inlineStr":
+					values[col] = c.Inline.String()
+				case "b":
+					values[col] = strconv.FormatBool(c.Value == "1")
+				default:
+					values[col] = c.Value
+				}
+			}
+
+			number := row.Number
+			if number == 0 {
+				number = i + 1
+			}
+			sh.rows = append(sh.rows, values)
+			sh.rowNumbers = append(sh.rowNumbers, number)
+		}
+		sheets = append(sheets, sh)
+	}
+
+	return sheets, nil
+}
+
+func decodeXMLFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("file %q not found in XLSX archive", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
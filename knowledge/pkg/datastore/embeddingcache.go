@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// EmbeddingCache stores embeddings keyed by embedding model and text hash, e.g. the Index
+type EmbeddingCache interface {
+	GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error)
+	AddCachedEmbeddings(ctx context.Context, model string, embeddings map[string][]float32) error
+}
+
+// EmbeddingCacheKey identifies the embedding model provider and its (exported) configuration,
+// so that embeddings of different models, dimensions, etc. are never mixed up in the cache.
+func EmbeddingCacheKey(provider etypes.EmbeddingModelProvider) (string, error) {
+	cfg, err := embeddings.ExportConfig(provider.Config())
+	if err != nil {
+		return "", fmt.Errorf("failed to export embedding model provider config: %w", err)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal embedding model provider config: %w", err)
+	}
+
+	sum := sha256.Sum256(append([]byte(provider.Name()+"\n"), data...))
+	return fmt.Sprintf("%s/%s/%s", provider.Name(), provider.EmbeddingModelName(), hex.EncodeToString(sum[:8])), nil
+}
+
+// CachedEmbeddingFunc returns an embedding function looking up the embedding of the text in the cache by the text's hash
+// before calling the embedding model provider, and adding new embeddings to the cache.
+// Search queries are neither looked up nor cached. Cache errors are logged, but don't fail the embedding.
+func CachedEmbeddingFunc(embeddingFunc vs.EmbeddingFunc, cache EmbeddingCache, model string) vs.EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		if etypes.IsQueryFromCtx(ctx) {
+			return embeddingFunc(ctx, text)
+		}
+
+		sum := sha256.Sum256([]byte(text))
+		hash := hex.EncodeToString(sum[:])
+
+		cached, err := cache.GetCachedEmbeddings(ctx, model, []string{hash})
+		if err != nil {
+			slog.Warn("Failed to look up embedding in cache", "error", err)
+		} else if emb, ok := cached[hash]; ok {
+			return emb, nil
+		}
+
+		emb, err := embeddingFunc(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := cache.AddCachedEmbeddings(ctx, model, map[string][]float32{hash: emb}); err != nil {
+			slog.Warn("Failed to add embedding to cache", "error", err)
+		}
+		return emb, nil
+	}
+}
+
+// CachedBatchEmbeddingFunc is the batch variant of CachedEmbeddingFunc: all texts are looked up in the cache at once
+// and only the missing embeddings are requested from the embedding model provider.
+func CachedBatchEmbeddingFunc(batchEmbeddingFunc etypes.BatchEmbeddingFunc, cache EmbeddingCache, model string) etypes.BatchEmbeddingFunc {
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		if etypes.IsQueryFromCtx(ctx) {
+			return batchEmbeddingFunc(ctx, texts)
+		}
+
+		hashes := make([]string, len(texts))
+		for i, text := range texts {
+			sum := sha256.Sum256([]byte(text))
+			hashes[i] = hex.EncodeToString(sum[:])
+		}
+
+		cached, err := cache.GetCachedEmbeddings(ctx, model, hashes)
+		if err != nil {
+			slog.Warn("Failed to look up embeddings in cache", "error", err)
+			cached = nil
+		}
+
+		embeddings := make([][]float32, len(texts))
+		var missing []int
+		var missingTexts []string
+		for i, hash := range hashes {
+			if emb, ok := cached[hash]; ok {
+				embeddings[i] = emb
+				continue
+			}
+			missing = append(missing, i)
+			missingTexts = append(missingTexts, texts[i])
+		}
+		if len(missing) == 0 {
+			return embeddings, nil
+		}
+
+		embedded, err := batchEmbeddingFunc(ctx, missingTexts)
+		if err != nil {
+			return nil, err
+		}
+		if len(embedded) != len(missingTexts) {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(embedded), len(missingTexts))
+		}
+
+		added := make(map[string][]float32, len(missing))
+		for j, i := range missing {
+			embeddings[i] = embedded[j]
+			added[hashes[i]] = embedded[j]
+		}
+		if err := cache.AddCachedEmbeddings(ctx, model, added); err != nil {
+			slog.Warn("Failed to add embeddings to cache", "error", err)
+		}
+		return embeddings, nil
+	}
+}
+
+// cachingEmbeddingModelProvider wraps the embedding function of the provider with the embedding cache
+type cachingEmbeddingModelProvider struct {
+	etypes.EmbeddingModelProvider
+	cache EmbeddingCache
+	model string
+}
+
+func (p *cachingEmbeddingModelProvider) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	embeddingFunc, err := p.EmbeddingModelProvider.EmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+	return CachedEmbeddingFunc(embeddingFunc, p.cache, p.model), nil
+}
@@ -0,0 +1,94 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEmbeddingCache map[string][]float32
+
+func (c testEmbeddingCache) GetCachedEmbeddings(_ context.Context, model string, hashes []string) (map[string][]float32, error) {
+	found := map[string][]float32{}
+	for _, h := range hashes {
+		if emb, ok := c[model+"/"+h]; ok {
+			found[h] = emb
+		}
+	}
+	return found, nil
+}
+
+func (c testEmbeddingCache) AddCachedEmbeddings(_ context.Context, model string, embeddings map[string][]float32) error {
+	for h, emb := range embeddings {
+		c[model+"/"+h] = emb
+	}
+	return nil
+}
+
+func TestCachedEmbeddingFunc(t *testing.T) {
+	var calls []string
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		calls = append(calls, text)
+		return []float32{float32(len(text))}, nil
+	}
+
+	cache := testEmbeddingCache{}
+	ef := CachedEmbeddingFunc(embeddingFunc, cache, "model-a")
+
+	ctx := context.Background()
+	for _, text := range []string{"foo", "barbaz", "foo"} {
+		emb, err := ef(ctx, text)
+		require.NoError(t, err)
+		assert.Equal(t, []float32{float32(len(text))}, emb)
+	}
+	assert.Equal(t, []string{"foo", "barbaz"}, calls)
+	assert.Len(t, cache, 2)
+
+	// embeddings of other models are not reused
+	_, err := CachedEmbeddingFunc(embeddingFunc, cache, "model-b")(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "barbaz", "foo"}, calls)
+
+	// queries are not cached
+	_, err = ef(etypes.QueryToCtx(ctx), "query")
+	require.NoError(t, err)
+	_, err = ef(etypes.QueryToCtx(ctx), "query")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "barbaz", "foo", "query", "query"}, calls)
+	assert.Len(t, cache, 3)
+}
+
+func TestCachedBatchEmbeddingFunc(t *testing.T) {
+	var calls [][]string
+	batchEmbeddingFunc := func(_ context.Context, texts []string) ([][]float32, error) {
+		calls = append(calls, texts)
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float32{float32(len(text))}
+		}
+		return embeddings, nil
+	}
+
+	cache := testEmbeddingCache{}
+	bef := CachedBatchEmbeddingFunc(batchEmbeddingFunc, cache, "model-a")
+
+	ctx := context.Background()
+	embeddings, err := bef(ctx, []string{"foo", "barbaz"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{3}, {6}}, embeddings)
+
+	// only the texts missing from the cache are embedded
+	embeddings, err = bef(ctx, []string{"barbaz", "x", "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{6}, {1}, {3}}, embeddings)
+	assert.Equal(t, [][]string{{"foo", "barbaz"}, {"x"}}, calls)
+
+	// no request at all if everything is cached
+	_, err = bef(ctx, []string{"x", "foo"})
+	require.NoError(t, err)
+	assert.Len(t, calls, 2)
+	assert.Len(t, cache, 3)
+}
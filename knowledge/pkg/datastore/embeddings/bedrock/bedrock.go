@@ -0,0 +1,257 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"dario.cat/mergo"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+var BedrockEmbeddingAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_BEDROCK_EMBEDDING_API_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+var BedrockEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_BEDROCK_EMBEDDING_API_REQUEST_TIMEOUT_SECONDS", 120)) * time.Second
+
+const EmbeddingModelProviderBedrockName string = "bedrock"
+
+// EmbeddingModelProviderBedrock embeds text using Amazon Titan or Cohere embedding models on AWS Bedrock.
+// Credentials and region are resolved by the AWS SDK's default chain (environment, shared config files, web identity,
+// container or instance roles).
+type EmbeddingModelProviderBedrock struct {
+	Region     string `usage:"AWS region (default: AWS_REGION or the region of the AWS profile)" default:"" env:"BEDROCK_REGION" koanf:"region"`
+	Profile    string `usage:"AWS profile from the shared config files to take credentials and region from (default: AWS_PROFILE)" default:"" env:"BEDROCK_PROFILE" koanf:"profile" export:"false"`
+	Endpoint   string `usage:"Bedrock runtime endpoint (default: regional endpoint)" default:"" env:"BEDROCK_ENDPOINT" koanf:"endpoint" export:"false"`
+	Model      string `usage:"Bedrock embedding model ID (Amazon Titan or Cohere)" default:"amazon.titan-embed-text-v2:0" env:"BEDROCK_MODEL" koanf:"model" export:"required"`
+	Dimensions int    `usage:"Output dimensions for Titan Text Embeddings V2 (256, 512 or 1024, 0 = model default)" default:"0" env:"BEDROCK_DIMENSIONS" koanf:"dimensions"`
+}
+
+type titanEmbeddingRequest struct {
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"`
+	Normalize  *bool  `json:"normalize,omitempty"`
+}
+
+type titanEmbeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+type cohereEmbeddingRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+	Truncate  string   `json:"truncate"`
+}
+
+type cohereEmbeddingResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func (p *EmbeddingModelProviderBedrock) UseEmbeddingModel(model string) {
+	p.Model = model
+}
+
+func (p *EmbeddingModelProviderBedrock) EmbeddingModelName() string {
+	return p.Model
+}
+
+func (p *EmbeddingModelProviderBedrock) Name() string {
+	return EmbeddingModelProviderBedrockName
+}
+
+func (p *EmbeddingModelProviderBedrock) Configure() error {
+	if err := load.FillConfigEnv("BEDROCK_", &p); err != nil {
+		return fmt.Errorf("failed to fill Bedrock config from environment: %w", err)
+	}
+
+	if err := p.fillDefaults(); err != nil {
+		return fmt.Errorf("failed to fill Bedrock defaults: %w", err)
+	}
+
+	if !isTitanModel(p.Model) && !isCohereModel(p.Model) {
+		return fmt.Errorf("unsupported Bedrock embedding model %q, only Amazon Titan and Cohere embedding models are supported", p.Model)
+	}
+	if p.Dimensions != 0 && !isTitanV2Model(p.Model) {
+		return fmt.Errorf("bedrock embedding model %q doesn't support setting the output dimensions", p.Model)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderBedrock) fillDefaults() error {
+	defaultConfig := EmbeddingModelProviderBedrock{
+		Model: "amazon.titan-embed-text-v2:0",
+	}
+
+	if err := mergo.Merge(p, defaultConfig); err != nil {
+		return fmt.Errorf("failed to merge Bedrock config: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderBedrock) Config() any {
+	return p
+}
+
+func (p *EmbeddingModelProviderBedrock) EmbeddingDimensions() int {
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderBedrock) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+// EmbeddingFunc returns a function embedding text using the Bedrock InvokeModel API. Credentials are resolved on the first request.
+func (p *EmbeddingModelProviderBedrock) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	cfg, err := p.awsConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	client := bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		if p.Endpoint != "" {
+			o.BaseEndpoint = aws.String(p.Endpoint)
+		}
+	})
+	var logOnce sync.Once
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, BedrockEmbeddingAPITimeout)
+		defer cancel()
+
+		reqBody, err := json.Marshal(p.request(ctx, text))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		resp, err := client.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+			ModelId:     aws.String(p.Model),
+			Body:        reqBody,
+			ContentType: aws.String("application/json"),
+			Accept:      aws.String("application/json"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error sending request(s) to Bedrock: %w", err)
+		}
+		logOnce.Do(func() {
+			slog.Info("Using Bedrock embedding model", "model", p.Model, "region", cfg.Region)
+		})
+
+		v, err := p.parseResponse(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, errors.New("no embeddings found in the response")
+		}
+		return normalizeVector(v), nil
+	}, nil
+}
+
+// awsConfig loads the AWS config via the SDK's default chain, honoring the configured region and profile
+func (p *EmbeddingModelProviderBedrock) awsConfig(ctx context.Context) (aws.Config, error) {
+	opts := []func(*config.LoadOptions) error{
+		config.WithRetryMaxAttempts(5),
+		config.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(BedrockEmbeddingAPIRequestTimeout)), // per request timeout - the overall timeout is set on the context
+	}
+	if p.Region != "" {
+		opts = append(opts, config.WithRegion(p.Region))
+	}
+	if p.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(p.Profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, errors.New("no AWS region configured for Bedrock - set it in the provider config (region), via AWS_REGION or in the AWS profile")
+	}
+	return cfg, nil
+}
+
+func (p *EmbeddingModelProviderBedrock) request(ctx context.Context, text string) any {
+	if isCohereModel(p.Model) {
+		inputType := "search_document"
+		if etypes.IsQueryFromCtx(ctx) {
+			inputType = "search_query"
+		}
+		return cohereEmbeddingRequest{
+			Texts:     []string{text},
+			InputType: inputType,
+			Truncate:  "END",
+		}
+	}
+
+	req := titanEmbeddingRequest{InputText: text}
+	if isTitanV2Model(p.Model) {
+		normalize := true
+		req.Normalize = &normalize
+		req.Dimensions = p.Dimensions
+	}
+	return req
+}
+
+func (p *EmbeddingModelProviderBedrock) parseResponse(body []byte) ([]float32, error) {
+	if isCohereModel(p.Model) {
+		var resp cohereEmbeddingResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+		if len(resp.Embeddings) == 0 {
+			return nil, nil
+		}
+		return resp.Embeddings[0], nil
+	}
+
+	var resp titanEmbeddingResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+	return resp.Embedding, nil
+}
+
+// isTitanModel also matches cross-region inference profile IDs, e.g. us.amazon.titan-embed-text-v2:0
+func isTitanModel(model string) bool {
+	return strings.Contains(model, "amazon.titan-embed")
+}
+
+func isTitanV2Model(model string) bool {
+	return strings.Contains(model, "amazon.titan-embed-text-v2")
+}
+
+func isCohereModel(model string) bool {
+	return strings.Contains(model, "cohere.embed")
+}
+
+func normalizeVector(v []float32) []float32 {
+	var norm float64
+	for _, val := range v {
+		norm += float64(val) * float64(val)
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return v
+	}
+
+	res := make([]float32, len(v))
+	for i, val := range v {
+		res[i] = float32(float64(val) / norm)
+	}
+	return res
+}
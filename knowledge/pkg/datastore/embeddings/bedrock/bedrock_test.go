@@ -0,0 +1,114 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddingFunc(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+
+	for _, tc := range []struct {
+		model    string
+		response string
+		check    func(t *testing.T, req map[string]any)
+	}{
+		{
+			model:    "amazon.titan-embed-text-v2:0",
+			response: `{"embedding":[0.6,0.8],"inputTextTokenCount":1}`,
+			check: func(t *testing.T, req map[string]any) {
+				assert.Equal(t, "hello", req["inputText"])
+				assert.Equal(t, true, req["normalize"])
+				assert.EqualValues(t, 256, req["dimensions"])
+			},
+		},
+		{
+			model:    "cohere.embed-english-v3",
+			response: `{"embeddings":[[3,4]],"id":"foo","texts":["hello"]}`,
+			check: func(t *testing.T, req map[string]any) {
+				assert.Equal(t, []any{"hello"}, req["texts"])
+				assert.Equal(t, "search_query", req["input_type"])
+			},
+		},
+	} {
+		t.Run(tc.model, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/model/"+tc.model+"/invoke", r.URL.Path)
+				assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+				assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/bedrock/aws4_request")
+				assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+
+				var req map[string]any
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				tc.check(t, req)
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer srv.Close()
+
+			p := &EmbeddingModelProviderBedrock{Region: "eu-west-1", Endpoint: srv.URL, Model: tc.model}
+			if isTitanV2Model(tc.model) {
+				p.Dimensions = 256
+			}
+
+			ef, err := p.EmbeddingFunc()
+			require.NoError(t, err)
+
+			v, err := ef(etypes.QueryToCtx(context.Background()), "hello")
+			require.NoError(t, err)
+			assert.InDeltaSlice(t, []float32{0.6, 0.8}, v, 1e-6)
+		})
+	}
+}
+
+func TestSharedConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config"), []byte("[default]\nregion = us-east-1\n\n[profile dev]\nregion = eu-central-1\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "credentials"), []byte("[dev]\naws_access_key_id = AKIDDEV\naws_secret_access_key = devsecret\n"), 0600))
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "envsecret")
+	t.Setenv("AWS_REGION", "")
+
+	p := &EmbeddingModelProviderBedrock{Profile: "dev"}
+	cfg, err := p.awsConfig(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "eu-central-1", cfg.Region)
+
+	creds, err := cfg.Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDDEV", creds.AccessKeyID)
+	assert.Equal(t, "devsecret", creds.SecretAccessKey)
+
+	t.Setenv("AWS_REGION", "us-west-2")
+	p = &EmbeddingModelProviderBedrock{}
+	cfg, err = p.awsConfig(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", cfg.Region)
+
+	p = &EmbeddingModelProviderBedrock{Region: "ap-south-1", Profile: "dev"}
+	cfg, err = p.awsConfig(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "ap-south-1", cfg.Region)
+}
+
+func TestConfigureRejectsUnsupportedModel(t *testing.T) {
+	p := &EmbeddingModelProviderBedrock{Model: "meta.llama3-8b-instruct-v1:0"}
+	assert.ErrorContains(t, p.Configure(), "unsupported Bedrock embedding model")
+
+	p = &EmbeddingModelProviderBedrock{Model: "cohere.embed-english-v3", Dimensions: 256}
+	assert.ErrorContains(t, p.Configure(), "doesn't support setting the output dimensions")
+}
@@ -7,8 +7,14 @@ import (
 	"strings"
 
 	"github.com/obot-platform/tools/knowledge/pkg/config"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/bedrock"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/fallback"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/jina"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ollama"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/tei"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/vertex"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -30,6 +36,28 @@ func GetSelectedEmbeddingsModelProvider(selected string, embeddingsConfig config
 	return provider, nil
 }
 
+// GetSelectedEmbeddingsModelProviderWithFallbacks returns the selected embedding model provider, falling back to the given providers in order, if it fails
+func GetSelectedEmbeddingsModelProviderWithFallbacks(selected string, fallbacks []string, embeddingsConfig config.EmbeddingsConfig) (types.EmbeddingModelProvider, error) {
+	provider, err := GetSelectedEmbeddingsModelProvider(selected, embeddingsConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(fallbacks) == 0 {
+		return provider, nil
+	}
+
+	fallbackProviders := make([]types.EmbeddingModelProvider, 0, len(fallbacks))
+	for _, name := range fallbacks {
+		fb, err := GetSelectedEmbeddingsModelProvider(name, embeddingsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get fallback embedding model provider %q: %w", name, err)
+		}
+		fallbackProviders = append(fallbackProviders, fb)
+	}
+
+	return fallback.New(provider, fallbackProviders...)
+}
+
 func ProviderFromConfig(providerConfig config.ModelProviderConfig) (types.EmbeddingModelProvider, error) {
 	provider, err := GetProviderConfig(providerConfig.Type)
 	if err != nil {
@@ -54,6 +82,16 @@ func GetProviderConfig(providerType string) (types.EmbeddingModelProvider, error
 	switch strings.ToLower(providerType) {
 	case openai.EmbeddingModelProviderOpenAIName:
 		return &openai.EmbeddingModelProviderOpenAI{}, nil
+	case ollama.EmbeddingModelProviderOllamaName:
+		return &ollama.EmbeddingModelProviderOllama{}, nil
+	case vertex.EmbeddingModelProviderVertexName:
+		return &vertex.EmbeddingModelProviderVertex{}, nil
+	case bedrock.EmbeddingModelProviderBedrockName:
+		return &bedrock.EmbeddingModelProviderBedrock{}, nil
+	case jina.EmbeddingModelProviderJinaName:
+		return &jina.EmbeddingModelProviderJina{}, nil
+	case tei.EmbeddingModelProviderTEIName:
+		return &tei.EmbeddingModelProviderTEI{}, nil
 	default:
 		return nil, fmt.Errorf("unknown embedding model provider %q", providerType)
 	}
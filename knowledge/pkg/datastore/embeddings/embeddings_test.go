@@ -6,6 +6,7 @@ import (
 
 	"github.com/obot-platform/tools/knowledge/pkg/config"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/vertex"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -37,6 +38,24 @@ func TestLoadConfOpenAI(t *testing.T) {
 	assert.Equal(t, "sk-1234567890abcdef", conf.APIKey)   // this should come from config
 }
 
+func TestLoadConfVertex(t *testing.T) {
+	dotenv := "test_assets/vertex_env"
+	require.NoError(t, godotenv.Load(dotenv))
+	defer os.Unsetenv("VERTEX_PROJECT")
+	defer os.Unsetenv("VERTEX_MODEL")
+
+	p, err := GetSelectedEmbeddingsModelProvider("vertex", config.EmbeddingsConfig{})
+	require.NoError(t, err)
+	require.Equal(t, "vertex", p.Name())
+
+	conf := p.Config().(*vertex.EmbeddingModelProviderVertex)
+
+	assert.Equal(t, "foo-project", conf.Project)
+	assert.Equal(t, "foo-embedding-001", conf.Model)
+	assert.Equal(t, "us-central1", conf.Location)
+	assert.Equal(t, "RETRIEVAL_QUERY", conf.QueryTaskType)
+}
+
 func TestExportConfigWithValidStruct(t *testing.T) {
 	type Config struct {
 		Field1 string `export:"true"`
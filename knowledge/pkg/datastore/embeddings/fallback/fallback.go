@@ -0,0 +1,258 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Cooldown is how long a failed provider is skipped before it's tried again
+var Cooldown = time.Duration(env.GetIntFromEnvOrDefault("KNOW_EMBEDDING_FALLBACK_COOLDOWN_SECONDS", 60)) * time.Second
+
+// Compile time check to ensure the fallback chain supports batching and configurable dimensions.
+var (
+	_ etypes.BatchEmbeddingModelProvider      = (*EmbeddingModelProviderFallback)(nil)
+	_ etypes.DimensionsEmbeddingModelProvider = (*EmbeddingModelProviderFallback)(nil)
+)
+
+// EmbeddingModelProviderFallback embeds text using the first provider of an ordered list of embedding model providers,
+// falling back to the next one if a provider fails. All providers must serve the same model with the same dimensions,
+// so that their embeddings can be mixed. It represents the primary (first) provider, e.g. in the dataset's embeddings config.
+// Late chunking is not supported, since the fallbacks' embeddings would not be comparable.
+type EmbeddingModelProviderFallback struct {
+	// Providers must be exported, so that the provider can be copied (e.g. when switching to a dataset's embedding model)
+	Providers []etypes.EmbeddingModelProvider
+
+	mu        sync.Mutex
+	downUntil map[int]time.Time
+	dims      int // dimensions of the first embedding served, which all providers must match
+}
+
+// New returns a fallback chain of the (configured) primary provider and the fallbacks, checking that they're compatible
+func New(primary etypes.EmbeddingModelProvider, fallbacks ...etypes.EmbeddingModelProvider) (*EmbeddingModelProviderFallback, error) {
+	p := &EmbeddingModelProviderFallback{Providers: append([]etypes.EmbeddingModelProvider{primary}, fallbacks...)}
+	if err := p.checkCompatible(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// checkCompatible makes sure the fallbacks serve the same model with the same dimensions as the primary provider
+func (p *EmbeddingModelProviderFallback) checkCompatible() error {
+	primary := p.Providers[0]
+	for _, fb := range p.Providers[1:] {
+		if modelID(fb.EmbeddingModelName()) != modelID(primary.EmbeddingModelName()) {
+			return fmt.Errorf("embedding model provider %q can't be used as fallback for %q: model %q doesn't match %q", fb.Name(), primary.Name(), fb.EmbeddingModelName(), primary.EmbeddingModelName())
+		}
+		if etypes.EmbeddingDimensions(fb) != etypes.EmbeddingDimensions(primary) {
+			return fmt.Errorf("embedding model provider %q can't be used as fallback for %q: dimensions %d don't match %d", fb.Name(), primary.Name(), etypes.EmbeddingDimensions(fb), etypes.EmbeddingDimensions(primary))
+		}
+	}
+	return nil
+}
+
+// modelID normalizes model names across providers, e.g. nomic-ai/nomic-embed-text (Hugging Face) and nomic-embed-text:latest (Ollama)
+func modelID(model string) string {
+	model = path.Base(strings.ToLower(model))
+	model, _, _ = strings.Cut(model, ":")
+	return model
+}
+
+func (p *EmbeddingModelProviderFallback) Name() string {
+	return p.Providers[0].Name()
+}
+
+func (p *EmbeddingModelProviderFallback) Configure() error {
+	for _, provider := range p.Providers {
+		if err := provider.Configure(); err != nil {
+			return fmt.Errorf("failed to configure embedding model provider %q: %w", provider.Name(), err)
+		}
+	}
+	return p.checkCompatible()
+}
+
+func (p *EmbeddingModelProviderFallback) Config() any {
+	return p.Providers[0].Config()
+}
+
+func (p *EmbeddingModelProviderFallback) EmbeddingModelName() string {
+	return p.Providers[0].EmbeddingModelName()
+}
+
+func (p *EmbeddingModelProviderFallback) UseEmbeddingModel(model string) {
+	for _, provider := range p.Providers {
+		provider.UseEmbeddingModel(model)
+	}
+}
+
+func (p *EmbeddingModelProviderFallback) EmbeddingDimensions() int {
+	return etypes.EmbeddingDimensions(p.Providers[0])
+}
+
+func (p *EmbeddingModelProviderFallback) UseEmbeddingDimensions(dimensions int) {
+	for _, provider := range p.Providers {
+		if dp, ok := provider.(etypes.DimensionsEmbeddingModelProvider); ok {
+			dp.UseEmbeddingDimensions(dimensions)
+		}
+	}
+}
+
+func (p *EmbeddingModelProviderFallback) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	batchEmbeddingFunc, _, err := p.BatchEmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := batchEmbeddingFunc(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}, nil
+}
+
+// BatchEmbeddingFunc returns a function embedding the texts with the first available provider, using the primary provider's batch size.
+// Providers without batch support embed the texts one by one.
+func (p *EmbeddingModelProviderFallback) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	funcs := make([]etypes.BatchEmbeddingFunc, len(p.Providers))
+	batchSizes := make([]int, len(p.Providers))
+	for i, provider := range p.Providers {
+		bf, batchSize, err := batchEmbeddingFunc(provider)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get embedding function of provider %q: %w", provider.Name(), err)
+		}
+		funcs[i], batchSizes[i] = bf, batchSize
+	}
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		logger := log.FromCtx(ctx).With("stage", "embedding")
+
+		var errs []error
+		for i, provider := range p.Providers {
+			last := i == len(p.Providers)-1
+			if !last && !p.available(i) {
+				logger.Debug("Skipping unavailable embedding model provider", "provider", provider.Name())
+				continue
+			}
+
+			embeddings, err := embedInBatches(ctx, funcs[i], batchSizes[i], texts)
+			if err == nil {
+				err = p.checkDimensions(provider, embeddings)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, err
+				}
+				errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+				p.markDown(i)
+				if !last {
+					logger.Warn("Embedding model provider failed, falling back to the next one", "provider", provider.Name(), "fallback", p.Providers[i+1].Name(), "error", err)
+				}
+				continue
+			}
+
+			p.markUp(i)
+			if i > 0 {
+				logger.Info("Embedded batch with fallback embedding model provider", "provider", provider.Name(), "fallbackIndex", i, "numTexts", len(texts))
+			} else {
+				logger.Debug("Embedded batch", "provider", provider.Name(), "numTexts", len(texts))
+			}
+			return embeddings, nil
+		}
+		return nil, fmt.Errorf("all embedding model providers failed: %w", errors.Join(errs...))
+	}, batchSizeOf(p.Providers[0], batchSizes[0]), nil
+}
+
+func batchEmbeddingFunc(provider etypes.EmbeddingModelProvider) (etypes.BatchEmbeddingFunc, int, error) {
+	if bp, ok := provider.(etypes.BatchEmbeddingModelProvider); ok {
+		return bp.BatchEmbeddingFunc()
+	}
+
+	ef, err := provider.EmbeddingFunc()
+	if err != nil {
+		return nil, 0, err
+	}
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			v, err := ef(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			embeddings[i] = v
+		}
+		return embeddings, nil
+	}, 1, nil
+}
+
+// batchSizeOf returns the batch size of the chain, which is that of the primary provider, if it supports batching
+func batchSizeOf(primary etypes.EmbeddingModelProvider, batchSize int) int {
+	if _, ok := primary.(etypes.BatchEmbeddingModelProvider); !ok {
+		return 1
+	}
+	return batchSize
+}
+
+// embedInBatches splits the texts into batches the provider can handle, e.g. if the fallback's batch size is smaller than the primary's
+func embedInBatches(ctx context.Context, bf etypes.BatchEmbeddingFunc, batchSize int, texts []string) ([][]float32, error) {
+	batchSize = max(batchSize, 1)
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		e, err := bf(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(e) != len(batch) {
+			return nil, fmt.Errorf("got %d embeddings for %d texts", len(e), len(batch))
+		}
+		embeddings = append(embeddings, e...)
+	}
+	return embeddings, nil
+}
+
+// checkDimensions makes sure all providers return embeddings of the same size, since they're stored in the same dataset
+func (p *EmbeddingModelProviderFallback) checkDimensions(provider etypes.EmbeddingModelProvider, embeddings [][]float32) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, v := range embeddings {
+		if p.dims == 0 {
+			p.dims = len(v)
+		}
+		if len(v) != p.dims {
+			return fmt.Errorf("embedding model provider %q returned embeddings with %d dimensions, expected %d", provider.Name(), len(v), p.dims)
+		}
+	}
+	return nil
+}
+
+func (p *EmbeddingModelProviderFallback) available(i int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().After(p.downUntil[i])
+}
+
+func (p *EmbeddingModelProviderFallback) markDown(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.downUntil == nil {
+		p.downUntil = map[int]time.Time{}
+	}
+	p.downUntil[i] = time.Now().Add(Cooldown)
+}
+
+func (p *EmbeddingModelProviderFallback) markUp(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.downUntil, i)
+}
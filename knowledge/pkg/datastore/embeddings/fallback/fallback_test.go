@@ -0,0 +1,89 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProvider struct {
+	Model string
+	Dims  int
+	Err   error
+	Calls int
+}
+
+func (p *testProvider) Name() string                   { return "test-" + p.Model }
+func (p *testProvider) Configure() error               { return nil }
+func (p *testProvider) Config() any                    { return p }
+func (p *testProvider) EmbeddingModelName() string     { return p.Model }
+func (p *testProvider) UseEmbeddingModel(model string) { p.Model = model }
+
+func (p *testProvider) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	return func(_ context.Context, _ string) ([]float32, error) {
+		p.Calls++
+		if p.Err != nil {
+			return nil, p.Err
+		}
+		return make([]float32, p.Dims), nil
+	}, nil
+}
+
+var _ etypes.EmbeddingModelProvider = (*testProvider)(nil)
+
+func TestFallback(t *testing.T) {
+	primary := &testProvider{Model: "nomic-ai/nomic-embed-text", Dims: 3, Err: errors.New("connection refused")}
+	secondary := &testProvider{Model: "nomic-embed-text:latest", Dims: 3}
+
+	p, err := New(primary, secondary)
+	require.NoError(t, err)
+	assert.Equal(t, "test-nomic-ai/nomic-embed-text", p.Name())
+
+	bf, batchSize, err := p.BatchEmbeddingFunc()
+	require.NoError(t, err)
+	assert.Equal(t, 1, batchSize)
+
+	embeddings, err := bf(context.Background(), []string{"foo", "bar"})
+	require.NoError(t, err)
+	assert.Len(t, embeddings, 2)
+	assert.Equal(t, 1, primary.Calls)
+	assert.Equal(t, 2, secondary.Calls)
+
+	// the failed primary provider is skipped until the cooldown has passed
+	_, err = bf(context.Background(), []string{"baz"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, primary.Calls)
+	assert.Equal(t, 3, secondary.Calls)
+
+	// all providers failing
+	secondary.Err = errors.New("unavailable")
+	_, err = bf(context.Background(), []string{"baz"})
+	assert.ErrorContains(t, err, "all embedding model providers failed")
+}
+
+func TestFallbackDimensionMismatch(t *testing.T) {
+	primary := &testProvider{Model: "m", Dims: 3}
+	secondary := &testProvider{Model: "m", Dims: 4}
+
+	p, err := New(primary, secondary)
+	require.NoError(t, err)
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	_, err = ef(context.Background(), "foo")
+	require.NoError(t, err)
+
+	primary.Err = errors.New("rate limited")
+	_, err = ef(context.Background(), "foo")
+	assert.ErrorContains(t, err, "returned embeddings with 4 dimensions, expected 3")
+}
+
+func TestFallbackIncompatibleModel(t *testing.T) {
+	_, err := New(&testProvider{Model: "text-embedding-3-small"}, &testProvider{Model: "text-embedding-3-large"})
+	assert.ErrorContains(t, err, "doesn't match")
+}
@@ -0,0 +1,232 @@
+package jina
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"dario.cat/mergo"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+var JinaEmbeddingAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_JINA_EMBEDDING_API_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+var JinaEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_JINA_EMBEDDING_API_REQUEST_TIMEOUT_SECONDS", 120)) * time.Second
+
+const EmbeddingModelProviderJinaName string = "jina"
+
+// Compile time check to ensure the Jina provider supports late chunking and batching.
+var (
+	_ etypes.LateChunkingEmbeddingModelProvider = (*EmbeddingModelProviderJina)(nil)
+	_ etypes.BatchEmbeddingModelProvider        = (*EmbeddingModelProviderJina)(nil)
+)
+
+type EmbeddingModelProviderJina struct {
+	BaseURL      string `usage:"Jina AI API base" default:"https://api.jina.ai/v1" env:"JINA_BASE_URL" koanf:"baseURL"`
+	APIKey       string `usage:"Jina AI API key" default:"" env:"JINA_API_KEY" koanf:"apiKey" mapstructure:"apiKey" export:"false"`
+	Model        string `usage:"Jina AI embedding model" default:"jina-embeddings-v3" env:"JINA_MODEL" koanf:"model" export:"required"`
+	Task         string `usage:"Task for embedding documents (jina-embeddings-v3), e.g. retrieval.passage, text-matching, classification, separation" default:"retrieval.passage" env:"JINA_TASK" koanf:"task" export:"required"`
+	QueryTask    string `usage:"Task for embedding search queries (jina-embeddings-v3)" default:"retrieval.query" env:"JINA_QUERY_TASK" koanf:"queryTask" export:"required"`
+	Dimensions   int    `usage:"Output dimensions (0 = model default)" default:"0" env:"JINA_DIMENSIONS" koanf:"dimensions"`
+	LateChunking bool   `usage:"Embed all chunks of a document together, so that chunk embeddings are derived from the context of the whole document" default:"false" env:"JINA_LATE_CHUNKING" koanf:"lateChunking"`
+	BatchSize    int    `usage:"Maximum number of texts embedded per request during ingestion" default:"128" env:"JINA_BATCH_SIZE" koanf:"batchSize" export:"false"`
+
+	// LateChunkingMaxChars limits the size of the chunks embedded together, since the concatenated input must fit
+	// into the model's context window (8192 tokens for jina-embeddings-v3) - longer documents are embedded in several parts
+	LateChunkingMaxChars int `usage:"Maximum number of characters embedded together with late chunking" default:"24000" env:"JINA_LATE_CHUNKING_MAX_CHARS" koanf:"lateChunkingMaxChars" export:"false"`
+}
+
+type JinaEmbeddingRequest struct {
+	Model         string   `json:"model"`
+	Input         []string `json:"input"`
+	Task          string   `json:"task,omitempty"`
+	Dimensions    int      `json:"dimensions,omitempty"`
+	LateChunking  bool     `json:"late_chunking,omitempty"`
+	EmbeddingType string   `json:"embedding_type,omitempty"`
+}
+
+type JinaEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (p *EmbeddingModelProviderJina) UseEmbeddingModel(model string) {
+	p.Model = model
+}
+
+func (p *EmbeddingModelProviderJina) EmbeddingModelName() string {
+	return p.Model
+}
+
+func (p *EmbeddingModelProviderJina) Name() string {
+	return EmbeddingModelProviderJinaName
+}
+
+func (p *EmbeddingModelProviderJina) Configure() error {
+	if err := load.FillConfigEnv("JINA_", &p); err != nil {
+		return fmt.Errorf("failed to fill Jina config from environment: %w", err)
+	}
+
+	if err := p.fillDefaults(); err != nil {
+		return fmt.Errorf("failed to fill Jina defaults: %w", err)
+	}
+
+	if p.APIKey == "" {
+		return errors.New("no Jina AI API key configured (apiKey or JINA_API_KEY)")
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderJina) fillDefaults() error {
+	defaultConfig := EmbeddingModelProviderJina{
+		BaseURL:              "https://api.jina.ai/v1",
+		Model:                "jina-embeddings-v3",
+		Task:                 "retrieval.passage",
+		QueryTask:            "retrieval.query",
+		BatchSize:            128,
+		LateChunkingMaxChars: 24000,
+	}
+
+	if err := mergo.Merge(p, defaultConfig); err != nil {
+		return fmt.Errorf("failed to merge Jina config: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderJina) Config() any {
+	return p
+}
+
+func (p *EmbeddingModelProviderJina) EmbeddingDimensions() int {
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderJina) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+func (p *EmbeddingModelProviderJina) LateChunkingEnabled() bool {
+	return p.LateChunking
+}
+
+func (p *EmbeddingModelProviderJina) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	if _, err := url.JoinPath(p.BaseURL, "embeddings"); err != nil {
+		return nil, fmt.Errorf("failed to parse Jina base URL %q: %w", p.BaseURL, err)
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := p.embed(ctx, []string{text}, false)
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}, nil
+}
+
+// BatchEmbeddingFunc returns a function embedding up to BatchSize texts per request
+func (p *EmbeddingModelProviderJina) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	if _, err := url.JoinPath(p.BaseURL, "embeddings"); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Jina base URL %q: %w", p.BaseURL, err)
+	}
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		return p.embed(ctx, texts, false)
+	}, p.BatchSize, nil
+}
+
+// EmbedChunks embeds the chunks of a document using late chunking, i.e. the chunks are concatenated and embedded together,
+// and the embedding of each chunk is pooled from the token embeddings of its span.
+// Chunks exceeding LateChunkingMaxChars in total are embedded in consecutive groups.
+func (p *EmbeddingModelProviderJina) EmbedChunks(ctx context.Context, chunks []string) ([][]float32, error) {
+	result := make([][]float32, 0, len(chunks))
+	for start := 0; start < len(chunks); {
+		end, size := start, 0
+		for end < len(chunks) && (end == start || size+utf8.RuneCountInString(chunks[end]) <= p.LateChunkingMaxChars) {
+			size += utf8.RuneCountInString(chunks[end])
+			end++
+		}
+
+		embeddings, err := p.embed(ctx, chunks[start:end], true)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, embeddings...)
+		start = end
+	}
+	return result, nil
+}
+
+func (p *EmbeddingModelProviderJina) embed(ctx context.Context, input []string, lateChunking bool) ([][]float32, error) {
+	task := p.Task
+	if etypes.IsQueryFromCtx(ctx) {
+		task = p.QueryTask
+	}
+
+	reqBody, err := json.Marshal(JinaEmbeddingRequest{
+		Model:         p.Model,
+		Input:         input,
+		Task:          task,
+		Dimensions:    p.Dimensions,
+		LateChunking:  lateChunking,
+		EmbeddingType: "float",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+	}
+
+	embeddingsURL, err := url.JoinPath(p.BaseURL, "embeddings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Jina base URL %q: %w", p.BaseURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, JinaEmbeddingAPITimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, embeddingsURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+
+	client := &http.Client{
+		Timeout: JinaEmbeddingAPIRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+	body, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request(s) to Jina AI: %w", err)
+	}
+
+	var resp JinaEmbeddingResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+	if len(resp.Data) != len(input) {
+		return nil, fmt.Errorf("expected %d embeddings in the response, got %d", len(input), len(resp.Data))
+	}
+
+	sort.Slice(resp.Data, func(i, j int) bool { return resp.Data[i].Index < resp.Data[j].Index })
+	embeddings := make([][]float32, len(resp.Data))
+	for i, d := range resp.Data {
+		if len(d.Embedding) == 0 {
+			return nil, errors.New("no embeddings found in the response")
+		}
+		embeddings[i] = d.Embedding // Jina returns normalized embeddings
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,79 @@
+package jina
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, requests *[]JinaEmbeddingRequest) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer jina_test", r.Header.Get("Authorization"))
+
+		var req JinaEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		*requests = append(*requests, req)
+
+		// respond in reverse order to check that embeddings are sorted by index
+		var data []string
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%d,1]}`, i, len(req.Input[i])))
+		}
+		_, _ = fmt.Fprintf(w, `{"model":%q,"data":[%s]}`, req.Model, strings.Join(data, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmbeddingFunc(t *testing.T) {
+	var requests []JinaEmbeddingRequest
+	srv := newTestServer(t, &requests)
+
+	p := &EmbeddingModelProviderJina{BaseURL: srv.URL, APIKey: "jina_test", Dimensions: 256}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(etypes.QueryToCtx(context.Background()), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{5, 1}, v)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, "jina-embeddings-v3", requests[0].Model)
+	assert.Equal(t, "retrieval.query", requests[0].Task)
+	assert.Equal(t, 256, requests[0].Dimensions)
+	assert.False(t, requests[0].LateChunking)
+}
+
+func TestEmbedChunks(t *testing.T) {
+	var requests []JinaEmbeddingRequest
+	srv := newTestServer(t, &requests)
+
+	p := &EmbeddingModelProviderJina{BaseURL: srv.URL, APIKey: "jina_test", LateChunking: true, LateChunkingMaxChars: 10}
+	require.NoError(t, p.fillDefaults())
+	require.True(t, p.LateChunkingEnabled())
+
+	embeddings, err := p.EmbedChunks(context.Background(), []string{"aaaa", "bbbbb", "ccc", "dddddddddddd"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{4, 1}, {5, 1}, {3, 1}, {12, 1}}, embeddings)
+
+	// chunks are grouped as long as they fit into the limit - a single chunk exceeding it is sent alone
+	require.Len(t, requests, 3)
+	assert.Equal(t, []string{"aaaa", "bbbbb"}, requests[0].Input)
+	assert.Equal(t, []string{"ccc"}, requests[1].Input)
+	assert.Equal(t, []string{"dddddddddddd"}, requests[2].Input)
+	for _, req := range requests {
+		assert.True(t, req.LateChunking)
+		assert.Equal(t, "retrieval.passage", req.Task)
+	}
+}
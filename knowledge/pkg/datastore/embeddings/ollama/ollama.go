@@ -0,0 +1,271 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"dario.cat/mergo"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+var OllamaEmbeddingAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_OLLAMA_EMBEDDING_API_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+var OllamaEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_OLLAMA_EMBEDDING_API_REQUEST_TIMEOUT_SECONDS", 120)) * time.Second
+
+// OllamaPullTimeout is the maximum time to wait for a model to be pulled, which may be several gigabytes
+var OllamaPullTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_OLLAMA_PULL_TIMEOUT_SECONDS", 1800)) * time.Second
+
+const EmbeddingModelProviderOllamaName string = "ollama"
+
+type EmbeddingModelProviderOllama struct {
+	BaseURL        string `usage:"Ollama API base" default:"http://localhost:11434" env:"OLLAMA_BASE_URL" koanf:"baseURL"`
+	EmbeddingModel string `usage:"Ollama Embedding model" default:"nomic-embed-text" env:"OLLAMA_EMBEDDING_MODEL" koanf:"model" export:"required"`
+	Dimensions     int    `usage:"Embedding dimensions - larger embeddings are truncated and normalized (for models trained with Matryoshka Representation Learning, e.g. nomic-embed-text), smaller ones are rejected (0 = model default)" default:"0" env:"OLLAMA_EMBEDDING_DIMENSIONS" koanf:"dimensions"`
+	Pull           bool   `usage:"Pull the embedding model if it's not available on the Ollama server yet" default:"false" env:"OLLAMA_PULL" koanf:"pull" export:"false"`
+	KeepAlive      string `usage:"How long Ollama keeps the model loaded after a request, e.g. 10m (default: server setting)" default:"" env:"OLLAMA_KEEP_ALIVE" koanf:"keepAlive" export:"false"`
+	BatchSize      int    `usage:"Maximum number of texts embedded per request during ingestion" default:"32" env:"OLLAMA_BATCH_SIZE" koanf:"batchSize" export:"false"`
+}
+
+type OllamaEmbedRequest struct {
+	Model     string   `json:"model"`
+	Input     []string `json:"input"`
+	KeepAlive string   `json:"keep_alive,omitempty"`
+}
+
+type OllamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+type ollamaModelRequest struct {
+	Model  string `json:"model"`
+	Stream *bool  `json:"stream,omitempty"`
+}
+
+func (p *EmbeddingModelProviderOllama) UseEmbeddingModel(model string) {
+	p.EmbeddingModel = model
+}
+
+func (p *EmbeddingModelProviderOllama) EmbeddingModelName() string {
+	return p.EmbeddingModel
+}
+
+func (p *EmbeddingModelProviderOllama) Name() string {
+	return EmbeddingModelProviderOllamaName
+}
+
+func (p *EmbeddingModelProviderOllama) Configure() error {
+	if err := load.FillConfigEnv("OLLAMA_", &p); err != nil {
+		return fmt.Errorf("failed to fill Ollama config from environment: %w", err)
+	}
+
+	if err := p.fillDefaults(); err != nil {
+		return fmt.Errorf("failed to fill Ollama defaults: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderOllama) fillDefaults() error {
+	defaultConfig := EmbeddingModelProviderOllama{
+		BaseURL:        "http://localhost:11434",
+		EmbeddingModel: "nomic-embed-text",
+		BatchSize:      32,
+	}
+
+	if err := mergo.Merge(p, defaultConfig); err != nil {
+		return fmt.Errorf("failed to merge Ollama config: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderOllama) Config() any {
+	return p
+}
+
+func (p *EmbeddingModelProviderOllama) EmbeddingDimensions() int {
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderOllama) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+// EmbeddingFunc returns a function embedding text using the Ollama server. It doesn't contact the server right away,
+// but on the first embedding request checks that the model is available (pulling it, if enabled) and reports its dimensions.
+func (p *EmbeddingModelProviderOllama) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	batchEmbeddingFunc, _, err := p.BatchEmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := batchEmbeddingFunc(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}, nil
+}
+
+// BatchEmbeddingFunc returns a function embedding up to BatchSize texts per request - see EmbeddingFunc.
+func (p *EmbeddingModelProviderOllama) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	embedURL, err := url.JoinPath(p.BaseURL, "api", "embed")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Ollama base URL %q: %w", p.BaseURL, err)
+	}
+
+	client := &http.Client{
+		Timeout: OllamaEmbeddingAPIRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+
+	var mu sync.Mutex
+	var ready bool
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		mu.Lock()
+		if !ready {
+			if err := p.ensureModel(ctx); err != nil {
+				mu.Unlock()
+				return nil, err
+			}
+		}
+		mu.Unlock()
+
+		reqBody, err := json.Marshal(OllamaEmbedRequest{
+			Model:     p.EmbeddingModel,
+			Input:     texts,
+			KeepAlive: p.KeepAlive,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, OllamaEmbeddingAPITimeout)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, embedURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		body, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+		if err != nil {
+			return nil, fmt.Errorf("error sending request(s) to Ollama: %w", err)
+		}
+
+		var embedResponse OllamaEmbedResponse
+		if err := json.Unmarshal(body, &embedResponse); err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+		if len(embedResponse.Embeddings) != len(texts) {
+			return nil, fmt.Errorf("expected %d embeddings in the response, got %d", len(texts), len(embedResponse.Embeddings))
+		}
+
+		for i, v := range embedResponse.Embeddings {
+			if len(v) == 0 {
+				return nil, errors.New("no embeddings found in the response")
+			}
+			if p.Dimensions > 0 && len(v) < p.Dimensions {
+				return nil, fmt.Errorf("ollama model %q returned embeddings with %d dimensions, expected %d", p.EmbeddingModel, len(v), p.Dimensions)
+			}
+			embedResponse.Embeddings[i] = etypes.TruncateEmbedding(v, p.Dimensions)
+		}
+
+		mu.Lock()
+		if !ready {
+			ready = true
+			slog.Info("Using Ollama embedding model", "model", p.EmbeddingModel, "dimensions", len(embedResponse.Embeddings[0]), "baseURL", p.BaseURL)
+		}
+		mu.Unlock()
+
+		return embedResponse.Embeddings, nil // Ollama returns normalized embeddings
+	}, p.BatchSize, nil
+}
+
+// ensureModel checks that the embedding model is available on the Ollama server, pulling it if enabled
+func (p *EmbeddingModelProviderOllama) ensureModel(ctx context.Context) error {
+	found, err := p.post(ctx, "show", ollamaModelRequest{Model: p.EmbeddingModel}, OllamaEmbeddingAPIRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to check Ollama model %q (is the Ollama server running at %s?): %w", p.EmbeddingModel, p.BaseURL, err)
+	}
+	if found {
+		return nil
+	}
+
+	if !p.Pull {
+		return fmt.Errorf("ollama model %q not found - pull it using `ollama pull %s` or enable pulling in the provider config (pull: true)", p.EmbeddingModel, p.EmbeddingModel)
+	}
+
+	slog.Info("Pulling Ollama embedding model", "model", p.EmbeddingModel, "baseURL", p.BaseURL)
+	stream := false
+	found, err = p.post(ctx, "pull", ollamaModelRequest{Model: p.EmbeddingModel, Stream: &stream}, OllamaPullTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to pull Ollama model %q: %w", p.EmbeddingModel, err)
+	}
+	if !found {
+		return fmt.Errorf("ollama model %q not found in the registry", p.EmbeddingModel)
+	}
+	slog.Info("Pulled Ollama embedding model", "model", p.EmbeddingModel)
+	return nil
+}
+
+// post sends a request to the Ollama API endpoint, returning false if the model was not found
+func (p *EmbeddingModelProviderOllama) post(ctx context.Context, endpoint string, payload any, timeout time.Duration) (bool, error) {
+	u, err := url.JoinPath(p.BaseURL, "api", endpoint)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse Ollama base URL %q: %w", p.BaseURL, err)
+	}
+
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return false, fmt.Errorf("couldn't marshal request body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(reqBody))
+	if err != nil {
+		return false, fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	// errors during a (non-streamed) pull are reported in the body
+	var status struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &status) == nil && status.Error != "" {
+		return false, errors.New(status.Error)
+	}
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, available bool) (*httptest.Server, *[]string) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "nomic-embed-text", req["model"])
+
+		switch r.URL.Path {
+		case "/api/show":
+			if !available {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":"model 'nomic-embed-text' not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{}`))
+		case "/api/pull":
+			assert.Equal(t, false, req["stream"])
+			available = true
+			_, _ = w.Write([]byte(`{"status":"success"}`))
+		case "/api/embed":
+			assert.Equal(t, []any{"hello"}, req["input"])
+			_, _ = w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.6,0.8,0]]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestEmbeddingFuncPullsMissingModel(t *testing.T) {
+	srv, calls := newTestServer(t, false)
+
+	p := &EmbeddingModelProviderOllama{BaseURL: srv.URL, Pull: true, Dimensions: 3}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.6, 0.8, 0}, v)
+
+	_, err = ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/api/show", "/api/pull", "/api/embed", "/api/embed"}, *calls)
+}
+
+func TestEmbeddingFuncMissingModel(t *testing.T) {
+	srv, calls := newTestServer(t, false)
+
+	p := &EmbeddingModelProviderOllama{BaseURL: srv.URL, EmbeddingModel: "nomic-embed-text"}
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	_, err = ef(context.Background(), "hello")
+	assert.ErrorContains(t, err, "ollama pull nomic-embed-text")
+	assert.Equal(t, []string{"/api/show"}, *calls)
+}
+
+func TestEmbeddingFuncDimensionMismatch(t *testing.T) {
+	srv, _ := newTestServer(t, true)
+
+	p := &EmbeddingModelProviderOllama{BaseURL: srv.URL, EmbeddingModel: "nomic-embed-text", Dimensions: 768}
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	_, err = ef(context.Background(), "hello")
+	assert.ErrorContains(t, err, "3 dimensions, expected 768")
+}
+
+func TestEmbeddingFuncTruncatesDimensions(t *testing.T) {
+	srv, _ := newTestServer(t, true)
+
+	p := &EmbeddingModelProviderOllama{BaseURL: srv.URL, EmbeddingModel: "nomic-embed-text", Dimensions: 1}
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{1}, v)
+}
@@ -12,13 +12,17 @@ import (
 	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"dario.cat/mergo"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
 	"github.com/obot-platform/tools/knowledge/pkg/env"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
@@ -29,6 +33,12 @@ var OpenAIEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault(
 
 const EmbeddingModelProviderOpenAIName string = "openai"
 
+// Compile time check to ensure the OpenAI provider supports batching and configurable dimensions.
+var (
+	_ etypes.BatchEmbeddingModelProvider      = (*EmbeddingModelProviderOpenAI)(nil)
+	_ etypes.DimensionsEmbeddingModelProvider = (*EmbeddingModelProviderOpenAI)(nil)
+)
+
 type EmbeddingModelProviderOpenAI struct {
 	BaseURL           string            `usage:"OpenAI API base" default:"https://api.openai.com/v1" env:"OPENAI_BASE_URL" koanf:"baseURL"`
 	APIKey            string            `usage:"OpenAI API key (not required if used with clicky-chats)" default:"sk-foo" env:"OPENAI_API_KEY" koanf:"apiKey" mapstructure:"apiKey" export:"false"`
@@ -38,6 +48,8 @@ type EmbeddingModelProviderOpenAI struct {
 	APIVersion        string            `usage:"OpenAI API version (for Azure)" default:"2024-02-01" env:"OPENAI_API_VERSION" koanf:"apiVersion"`
 	APIType           string            `usage:"OpenAI API type (OPEN_AI, AZURE, AZURE_AD, ...)" default:"OPEN_AI" env:"OPENAI_API_TYPE" koanf:"apiType"`
 	AzureOpenAIConfig AzureOpenAIConfig `koanf:"azure"`
+	BatchSize         int               `usage:"Maximum number of texts embedded per request during ingestion" default:"100" env:"OPENAI_EMBEDDING_BATCH_SIZE" koanf:"batchSize" export:"false"`
+	Dimensions        int               `usage:"Output dimensions - set via the API for text-embedding-3 models, otherwise embeddings are truncated and normalized (0 = model default)" default:"0" env:"OPENAI_EMBEDDING_DIMENSIONS" koanf:"dimensions"`
 }
 
 type OpenAIConfig struct {
@@ -52,7 +64,7 @@ type OpenAIConfig struct {
 }
 
 type OpenAIEmbeddingRequest struct {
-	Input          string `json:"input"`
+	Input          any    `json:"input"` // string or []string
 	Model          string `json:"model"`
 	EncodingFormat string `json:"encoding_format,omitempty"`
 	Dimensions     *int   `json:"dimensions,omitempty"`
@@ -60,6 +72,7 @@ type OpenAIEmbeddingRequest struct {
 
 type OpenAIResponse struct {
 	Data []struct {
+		Index     int       `json:"index"`
 		Embedding []float32 `json:"embedding"`
 	} `json:"data"`
 }
@@ -97,6 +110,10 @@ func (p *EmbeddingModelProviderOpenAI) Configure() error {
 		return fmt.Errorf("failed to fill OpenAI defaults: %w", err)
 	}
 
+	if p.Dimensions < 0 {
+		return fmt.Errorf("invalid OpenAI embedding dimensions %d", p.Dimensions)
+	}
+
 	return nil
 }
 
@@ -114,6 +131,7 @@ func (p *EmbeddingModelProviderOpenAI) fillDefaults() error {
 		APIVersion:        "2024-02-01",
 		APIType:           "OPEN_AI",
 		AzureOpenAIConfig: defaultAzureOpenAIConfig,
+		BatchSize:         100,
 	}
 
 	err := mergo.Merge(p, defaultConfig)
@@ -125,8 +143,23 @@ func (p *EmbeddingModelProviderOpenAI) fillDefaults() error {
 }
 
 func (p *EmbeddingModelProviderOpenAI) EmbeddingFunc() (vs.EmbeddingFunc, error) {
-	var embeddingFunc vs.EmbeddingFunc
+	cfg, err := p.compatConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewEmbeddingFuncOpenAICompat(cfg), nil
+}
+
+// BatchEmbeddingFunc returns a function embedding up to BatchSize texts per request
+func (p *EmbeddingModelProviderOpenAI) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	cfg, err := p.compatConfig()
+	if err != nil {
+		return nil, 0, err
+	}
+	return NewBatchEmbeddingFuncOpenAICompat(cfg), p.BatchSize, nil
+}
 
+func (p *EmbeddingModelProviderOpenAI) compatConfig() (*OpenAICompatConfig, error) {
 	switch strings.ToLower(p.APIType) {
 	// except for Azure, most other OpenAI API compatible providers only differ in the normalization of output vectors (apart from the obvious API endpoint, etc.)
 	case "azure", "azure_ad":
@@ -145,32 +178,47 @@ func (p *EmbeddingModelProviderOpenAI) EmbeddingFunc() (vs.EmbeddingFunc, error)
 
 		slog.Debug("Using Azure OpenAI API", "deploymentURL", deploymentURL.String(), "APIVersion", p.APIVersion)
 
-		embeddingFunc = NewEmbeddingFuncAzureOpenAI(
+		return NewAzureOpenAICompatConfig(
 			p.APIKey,
 			deploymentURL.String(),
 			p.APIVersion,
 			"",
-		)
+		).
+			WithDimensions(p.Dimensions, supportsDimensionsParam(p.EmbeddingModel)), nil
 	case "open_ai":
-		cfg := NewOpenAICompatConfig(
+		return NewOpenAICompatConfig(
 			p.BaseURL,
 			p.APIKey,
 			p.EmbeddingModel,
 		).
 			WithNormalized(true).
-			WithEmbeddingsEndpoint(p.EmbeddingEndpoint)
-		embeddingFunc = NewEmbeddingFuncOpenAICompat(cfg)
+			WithEmbeddingsEndpoint(p.EmbeddingEndpoint).
+			WithDimensions(p.Dimensions, supportsDimensionsParam(p.EmbeddingModel)), nil
 	default:
 		return nil, fmt.Errorf("unknown OpenAI API type: %q", p.APIType)
 	}
-
-	return embeddingFunc, nil
 }
 
 func (p *EmbeddingModelProviderOpenAI) Config() any {
 	return p
 }
 
+func (p *EmbeddingModelProviderOpenAI) EmbeddingDimensions() int {
+	if p.Dimensions == 0 && strings.EqualFold(p.APIType, "open_ai") && p.EmbeddingModel == "text-embedding-3-large" {
+		return legacyTextEmbedding3LargeDimensions
+	}
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderOpenAI) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+// supportsDimensionsParam reports whether the model supports setting the output dimensions via the API
+func supportsDimensionsParam(model string) bool {
+	return strings.HasPrefix(model, "text-embedding-3")
+}
+
 /*
  * NOTICE: The following was copied over from github.com/philippgille/chromem-go to lessen the changes to our fork at github.com/iwilltry42/chromem-go
  */
@@ -190,6 +238,19 @@ func (p *EmbeddingModelProviderOpenAI) Config() any {
 // The flag is optional. If it's nil, it will be autodetected on the first request
 // (which bears a small risk that the vector just happens to have a length of 1).
 func NewEmbeddingFuncOpenAICompat(config *OpenAICompatConfig) vs.EmbeddingFunc {
+	batchEmbeddingFunc := NewBatchEmbeddingFuncOpenAICompat(config)
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := batchEmbeddingFunc(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}
+}
+
+// NewBatchEmbeddingFuncOpenAICompat returns a function that creates embeddings for multiple texts
+// with a single request to an OpenAI compatible API - see NewEmbeddingFuncOpenAICompat.
+func NewBatchEmbeddingFuncOpenAICompat(config *OpenAICompatConfig) etypes.BatchEmbeddingFunc {
 	if config == nil {
 		panic("config must not be nil")
 	}
@@ -201,17 +262,23 @@ func NewEmbeddingFuncOpenAICompat(config *OpenAICompatConfig) vs.EmbeddingFunc {
 	var checkedNormalized bool
 	checkNormalized := sync.Once{}
 
-	return func(ctx context.Context, text string) ([]float32, error) {
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
 		// Create the OpenAI request payload
 		embedReq := OpenAIEmbeddingRequest{
-			Input:          text,
+			Input:          texts,
 			Model:          config.model,
 			EncodingFormat: "float",
 		}
+		if len(texts) == 1 {
+			embedReq.Input = texts[0]
+		}
 
-		// Only set dimensions for text-embedding-3-large
-		if config.model == "text-embedding-3-large" {
-			dims := 2000
+		// Only models supporting it get the dimensions via the API, other embeddings are truncated below
+		dims := config.dimensions
+		if dims == 0 && config.model == "text-embedding-3-large" {
+			dims = legacyTextEmbedding3LargeDimensions
+		}
+		if dims > 0 && (config.dimensionsParam || supportsDimensionsParam(config.model)) {
 			embedReq.Dimensions = &dims
 		}
 
@@ -263,30 +330,42 @@ func NewEmbeddingFuncOpenAICompat(config *OpenAICompatConfig) vs.EmbeddingFunc {
 			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
 		}
 
-		// Check if the response contains embeddings.
-		if len(embeddingResponse.Data) == 0 || len(embeddingResponse.Data[0].Embedding) == 0 {
-			return nil, errors.New("no embeddings found in the response")
+		// Check if the response contains all embeddings.
+		if len(embeddingResponse.Data) != len(texts) {
+			return nil, fmt.Errorf("expected %d embeddings in the response, got %d", len(texts), len(embeddingResponse.Data))
 		}
 
-		v := embeddingResponse.Data[0].Embedding
-		if config.normalized != nil {
-			if *config.normalized {
-				return v, nil
+		embeddings := make([][]float32, len(texts))
+		for _, d := range embeddingResponse.Data {
+			if d.Index < 0 || d.Index >= len(texts) || len(d.Embedding) == 0 {
+				return nil, errors.New("no embeddings found in the response")
 			}
-			return normalizeVector(v), nil
+			embeddings[d.Index] = d.Embedding
 		}
-		checkNormalized.Do(func() {
-			if isNormalized(v) {
-				checkedNormalized = true
-			} else {
-				checkedNormalized = false
+
+		for i, v := range embeddings {
+			if v == nil {
+				return nil, fmt.Errorf("no embedding found in the response for input %d", i)
+			}
+			if config.normalized != nil {
+				if !*config.normalized {
+					embeddings[i] = normalizeVector(v)
+				}
+				continue
 			}
-		})
-		if !checkedNormalized {
-			v = normalizeVector(v)
+			checkNormalized.Do(func() {
+				checkedNormalized = isNormalized(v)
+			})
+			if !checkedNormalized {
+				embeddings[i] = normalizeVector(v)
+			}
+		}
+
+		for i, v := range embeddings {
+			embeddings[i] = etypes.TruncateEmbedding(v, dims)
 		}
 
-		return v, nil
+		return embeddings, nil
 	}
 }
 
@@ -317,14 +396,27 @@ func isNormalized(v []float32) bool {
 	return math.Abs(magnitude-1) < isNormalizedPrecisionTolerance
 }
 
+// RequestWithExponentialBackoff sends the request, retrying on connection errors, server errors (5xx), request timeouts (408)
+// and, if handleRateLimit is true, rate limit errors (429) with exponential backoff and jitter.
+// Delays requested by the API via the Retry-After header are respected. If the context carries a rate limiter
+// (see ratelimit.ToCtx), every try waits for it and rate limit errors hold back all requests sharing the limiter.
 func RequestWithExponentialBackoff(ctx context.Context, client *http.Client, req *http.Request, maxRetries int, handleRateLimit bool) ([]byte, error) {
 	const baseDelay = time.Millisecond * 200
+	maxDelay := time.Duration(defaults.ModelAPIMaxRetryDelaySeconds) * time.Second
 	var resp *http.Response
 	var err error
 
+	if defaults.ModelAPIMaxRetries > 0 {
+		maxRetries = defaults.ModelAPIMaxRetries
+	}
+
 	logger := log.FromCtx(ctx)
+	limiter := ratelimit.FromCtx(ctx)
+	stats := RetryStatsFromCtx(ctx)
 
 	var failures []string
+	var tries, statusCode int
+	var rateLimited bool // whether the last try was rate limited
 
 	// Save the original request body
 	var bodyBytes []byte
@@ -336,17 +428,23 @@ func RequestWithExponentialBackoff(ctx context.Context, client *http.Client, req
 	}
 
 	for i := 0; i < maxRetries; i++ {
-		// Check if context was canceled (timeout) before retrying
-		if ctx.Err() != nil {
+		// Wait for the rate limiter - this also checks if the context was canceled (timeout) before retrying
+		if err := limiter.Wait(ctx); err != nil || ctx.Err() != nil {
 			failures = append(failures, fmt.Sprintf("[!] Stopped by canceled context after try #%d/%d: %v", i, maxRetries, ctx.Err()))
 			break
 		}
 
+		if i > 0 {
+			stats.count(rateLimited)
+		}
+		rateLimited = false
+
 		// Reset body to the original request body
 		if bodyBytes != nil {
 			req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 		}
 
+		tries++
 		resp, err = client.Do(req)
 		if err == nil && resp.StatusCode == http.StatusOK {
 			body, err := io.ReadAll(resp.Body)
@@ -355,6 +453,7 @@ func RequestWithExponentialBackoff(ctx context.Context, client *http.Client, req
 				msg := fmt.Sprintf("#%d/%d: failed to read response body: %v", i+1, maxRetries, err)
 				logger.Warn("Request failed - Retryable", "error", msg)
 				failures = append(failures, msg)
+				statusCode = 0
 				_ = resp.Body.Close()
 				continue
 			}
@@ -362,6 +461,9 @@ func RequestWithExponentialBackoff(ctx context.Context, client *http.Client, req
 			return body, resp.Body.Close()
 		}
 
+		// Exponential backoff with jitter, unless the API tells us how long to wait
+		delay := min(baseDelay*time.Duration(1<<min(i, 16)), maxDelay) + time.Duration(rand.Int63n(int64(baseDelay)))
+
 		if resp != nil {
 			var bodystr string
 			if resp.Body != nil {
@@ -374,32 +476,128 @@ func RequestWithExponentialBackoff(ctx context.Context, client *http.Client, req
 
 			msg := fmt.Sprintf("#%d/%d: %d <%s> (err: %v)", i+1, maxRetries, resp.StatusCode, bodystr, err)
 			failures = append(failures, msg)
+			statusCode = resp.StatusCode
 
-			if resp.StatusCode >= 500 || (handleRateLimit && resp.StatusCode == http.StatusTooManyRequests) {
-				logger.Warn("Request failed - Retryable", "error", msg)
-				// Retry for 5xx (Server Errors)
-				// We're also handling rate limit here (without checking the Retry-After header), if handleRateLimit is true,
-				// since it's what e.g. OpenAI recommends (see https://github.com/openai/openai-cookbook/blob/457f4310700f93e7018b1822213ca99c613dbd1b/examples/How_to_handle_rate_limits.ipynb).
-				delay := baseDelay * time.Duration(1<<i)
-				jitter := time.Duration(rand.Int63n(int64(baseDelay)))
-				time.Sleep(delay + jitter)
-				continue
-			} else {
+			rateLimited = resp.StatusCode == http.StatusTooManyRequests
+			if resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && !(handleRateLimit && rateLimited) {
 				// Non-retryable error
 				logger.Error("Request failed - Non-retryable", "error", msg)
 				break
 			}
+
+			// Retry for 5xx (Server Errors), request timeouts and, if handleRateLimit is true, rate limits,
+			// as e.g. OpenAI recommends (see https://github.com/openai/openai-cookbook/blob/457f4310700f93e7018b1822213ca99c613dbd1b/examples/How_to_handle_rate_limits.ipynb).
+			if retryAfter, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+				delay = retryAfter
+			}
+			if rateLimited {
+				// hold back other requests sharing the rate limiter, too
+				limiter.PauseUntil(time.Now().Add(delay))
+			}
+			logger.Warn("Request failed - Retryable", "error", msg, "retryIn", delay)
 		} else {
 			// Log connection errors (client.Do error) and retry if needed
 			msg := fmt.Sprintf("#%d/%d: failed to send request: %v", i+1, maxRetries, err)
 			logger.Warn("Request failed - Retryable", "error", msg)
 			failures = append(failures, msg)
+			statusCode = 0
+		}
+
+		if i == maxRetries-1 {
+			break
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			failures = append(failures, fmt.Sprintf("[!] Not retrying after try #%d/%d: retry delay of %s exceeds the remaining timeout", i+1, maxRetries, delay))
+			break
+		}
+		if err := sleep(ctx, delay); err != nil {
+			failures = append(failures, fmt.Sprintf("[!] Stopped by canceled context after try #%d/%d: %v", i+1, maxRetries, err))
+			break
 		}
 	}
 
 	logger.Error("request retry limit exceeded or failed with non-retryable error(s)", "request", req, "maxTries", maxRetries, "failures", strings.Join(failures, ";"))
 
-	return nil, fmt.Errorf("retry limit exceeded or request failed with non-retryable error: %v", failures[len(failures)-1])
+	if len(failures) == 0 {
+		return nil, errors.New("retry limit exceeded: no tries made")
+	}
+	return nil, &RequestError{StatusCode: statusCode, Tries: tries, Failure: failures[len(failures)-1]}
+}
+
+// RequestError is returned by RequestWithExponentialBackoff if the request failed for good
+type RequestError struct {
+	StatusCode int    // status code of the last response or 0, if the last try didn't get a response
+	Tries      int    // number of requests sent
+	Failure    string // description of the last failure
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("retry limit exceeded or request failed with non-retryable error: %s", e.Failure)
+}
+
+// HTTPStatusCode returns the status code of the last response, like the response errors of the AWS SDK, e.g. to detect rate limit errors
+func (e *RequestError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// RetryStats counts the retries of RequestWithExponentialBackoff, e.g. to report them in benchmarks
+type RetryStats struct {
+	Retries          atomic.Int64 // all retries
+	RateLimitRetries atomic.Int64 // retries after rate limit errors (429)
+}
+
+func (s *RetryStats) count(rateLimited bool) {
+	if s == nil {
+		return
+	}
+	s.Retries.Add(1)
+	if rateLimited {
+		s.RateLimitRetries.Add(1)
+	}
+}
+
+type retryStatsCtxKey struct{}
+
+// RetryStatsToCtx returns a context making RequestWithExponentialBackoff count its retries in the given stats
+func RetryStatsToCtx(ctx context.Context, stats *RetryStats) context.Context {
+	return context.WithValue(ctx, retryStatsCtxKey{}, stats)
+}
+
+// RetryStatsFromCtx returns the retry stats from the context or nil, if there are none
+func RetryStatsFromCtx(ctx context.Context) *RetryStats {
+	stats, _ := ctx.Value(retryStatsCtxKey{}).(*RetryStats)
+	return stats
+}
+
+// parseRetryAfter returns the delay requested by the API via the Retry-After header (seconds or HTTP date)
+// or the retry-after-ms header used by e.g. OpenAI and Azure OpenAI
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+
+	retryAfter := strings.TrimSpace(header.Get("Retry-After"))
+	if retryAfter == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(retryAfter, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if t, err := http.ParseTime(retryAfter); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 type OpenAICompatConfig struct {
@@ -412,6 +610,8 @@ type OpenAICompatConfig struct {
 	embeddingsEndpoint string
 	headers            map[string]string
 	queryParams        map[string]string
+	dimensions         int
+	dimensionsParam    bool
 }
 
 func NewOpenAICompatConfig(baseURL, apiKey, model string) *OpenAICompatConfig {
@@ -444,8 +644,19 @@ func (c *OpenAICompatConfig) WithNormalized(normalized bool) *OpenAICompatConfig
 	return c
 }
 
+// WithDimensions sets the output dimensions. If the model supports the `dimensions` request parameter (viaAPI),
+// they're requested from the API, otherwise the embeddings are truncated and normalized (for MRL-trained models).
+func (c *OpenAICompatConfig) WithDimensions(dimensions int, viaAPI bool) *OpenAICompatConfig {
+	c.dimensions = dimensions
+	c.dimensionsParam = viaAPI
+	return c
+}
+
 const (
 	azureDefaultAPIVersion = "2024-02-01"
+
+	// legacyTextEmbedding3LargeDimensions are the dimensions always requested for text-embedding-3-large before they were configurable
+	legacyTextEmbedding3LargeDimensions = 2000
 )
 
 // NewEmbeddingFuncAzureOpenAI returns a function that creates embeddings for a text
@@ -453,8 +664,13 @@ const (
 // The `deploymentURL` is the URL of the deployed model, e.g. "https://YOUR_RESOURCE_NAME.openai.azure.com/openai/deployments/YOUR_DEPLOYMENT_NAME"
 // See https://learn.microsoft.com/en-us/azure/ai-services/openai/how-to/embeddings?tabs=console#how-to-get-embeddings
 func NewEmbeddingFuncAzureOpenAI(apiKey string, deploymentURL string, apiVersion string, model string) vs.EmbeddingFunc {
+	return NewEmbeddingFuncOpenAICompat(NewAzureOpenAICompatConfig(apiKey, deploymentURL, apiVersion, model))
+}
+
+// NewAzureOpenAICompatConfig returns the OpenAI compatible API config for the Azure OpenAI API - see NewEmbeddingFuncAzureOpenAI.
+func NewAzureOpenAICompatConfig(apiKey string, deploymentURL string, apiVersion string, model string) *OpenAICompatConfig {
 	if apiVersion == "" {
 		apiVersion = azureDefaultAPIVersion
 	}
-	return NewEmbeddingFuncOpenAICompat(NewOpenAICompatConfig(deploymentURL, apiKey, model).WithHeaders(map[string]string{"api-key": apiKey}).WithQueryParams(map[string]string{"api-version": apiVersion}))
+	return NewOpenAICompatConfig(deploymentURL, apiKey, model).WithHeaders(map[string]string{"api-key": apiKey}).WithQueryParams(map[string]string{"api-version": apiVersion})
 }
@@ -0,0 +1,203 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchEmbeddingFuncOpenAICompat(t *testing.T) {
+	var inputs []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/embeddings", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var req OpenAIEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		inputs = append(inputs, req.Input)
+
+		// embeddings may be returned out of order
+		if texts, ok := req.Input.([]any); ok && len(texts) == 2 {
+			_, _ = w.Write([]byte(`{"data":[{"index":1,"embedding":[0,2]},{"index":0,"embedding":[3,4]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[3,4]}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := NewOpenAICompatConfig(srv.URL, "secret", "some-model").WithNormalized(false)
+
+	embeddings, err := NewBatchEmbeddingFuncOpenAICompat(cfg)(context.Background(), []string{"foo", "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.6, 0.8}, {0, 1}}, embeddings)
+
+	v, err := NewEmbeddingFuncOpenAICompat(cfg)(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.6, 0.8}, v)
+
+	// single texts are sent as plain string input for compatibility
+	assert.Equal(t, []any{[]any{"foo", "bar"}, "foo"}, inputs)
+}
+
+func TestBatchEmbeddingFuncOpenAICompatMissingEmbeddings(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[3,4]}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := NewOpenAICompatConfig(srv.URL, "secret", "some-model").WithNormalized(true)
+	_, err := NewBatchEmbeddingFuncOpenAICompat(cfg)(context.Background(), []string{"foo", "bar"})
+	assert.ErrorContains(t, err, "expected 2 embeddings in the response, got 1")
+}
+
+func TestRequestWithExponentialBackoffRetryAfter(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After-Ms", "10")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`ok`))
+		}
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{}`))
+	require.NoError(t, err)
+
+	limiter := ratelimit.New(60 * 1000)
+	var stats RetryStats
+	ctx := RetryStatsToCtx(ratelimit.ToCtx(context.Background(), limiter), &stats)
+	body, err := RequestWithExponentialBackoff(ctx, srv.Client(), req, 5, true)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, 3, calls)
+	assert.EqualValues(t, 2, stats.Retries.Load())
+	assert.EqualValues(t, 1, stats.RateLimitRetries.Load())
+}
+
+func TestRequestWithExponentialBackoffNonRetryable(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{}`))
+	require.NoError(t, err)
+
+	_, err = RequestWithExponentialBackoff(context.Background(), srv.Client(), req, 5, true)
+	assert.ErrorContains(t, err, "400")
+	assert.Equal(t, 1, calls)
+
+	var reqErr *RequestError
+	require.ErrorAs(t, fmt.Errorf("error sending request(s): %w", err), &reqErr)
+	assert.Equal(t, http.StatusBadRequest, reqErr.HTTPStatusCode())
+	assert.Equal(t, 1, reqErr.Tries)
+}
+
+func TestRequestWithExponentialBackoffRetryAfterExceedsTimeout(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{}`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	_, err = RequestWithExponentialBackoff(ctx, srv.Client(), req, 5, true)
+	assert.ErrorContains(t, err, "exceeds the remaining timeout")
+	assert.Equal(t, 1, calls)
+
+	var reqErr *RequestError
+	require.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusTooManyRequests, reqErr.StatusCode, "the status code of the last response is kept")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		header http.Header
+		delay  time.Duration
+		ok     bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Retry-After": {"2"}}, 2 * time.Second, true},
+		{http.Header{"Retry-After": {"1.5"}}, 1500 * time.Millisecond, true},
+		{http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute, true},
+		{http.Header{"Retry-After": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 0, true},
+		{http.Header{"Retry-After": {"soon"}}, 0, false},
+		{http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond, true},
+	} {
+		delay, ok := parseRetryAfter(tc.header, now)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.delay, delay, tc.header)
+	}
+}
+
+func TestEmbeddingFuncOpenAICompatDimensions(t *testing.T) {
+	var requests []OpenAIEmbeddingRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req OpenAIEmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		_, _ = w.Write([]byte(`{"data":[{"index":0,"embedding":[0.48,0.64,0.6]}]}`))
+	}))
+	defer srv.Close()
+
+	// text-embedding-3 models get the dimensions via the API
+	p := &EmbeddingModelProviderOpenAI{BaseURL: srv.URL, APIKey: "secret", EmbeddingModel: "text-embedding-3-small", Dimensions: 2}
+	require.NoError(t, p.fillDefaults())
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+	_, err = ef(context.Background(), "foo")
+	require.NoError(t, err)
+	require.NotNil(t, requests[0].Dimensions)
+	assert.Equal(t, 2, *requests[0].Dimensions)
+
+	// other models' embeddings are truncated and normalized
+	p = &EmbeddingModelProviderOpenAI{BaseURL: srv.URL, APIKey: "secret", EmbeddingModel: "nomic-embed-text-v1.5", Dimensions: 2}
+	require.NoError(t, p.fillDefaults())
+	ef, err = p.EmbeddingFunc()
+	require.NoError(t, err)
+	v, err := ef(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Nil(t, requests[1].Dimensions)
+	assert.InDeltaSlice(t, []float32{0.6, 0.8}, v, 1e-6)
+	assert.Equal(t, 2, p.EmbeddingDimensions())
+}
+
+func TestEmbeddingDimensionsLegacyDefault(t *testing.T) {
+	p := &EmbeddingModelProviderOpenAI{}
+	require.NoError(t, p.fillDefaults())
+	assert.Equal(t, "text-embedding-3-large", p.EmbeddingModel)
+	assert.Equal(t, 2000, p.EmbeddingDimensions())
+
+	p.UseEmbeddingDimensions(1024)
+	assert.Equal(t, 1024, p.EmbeddingDimensions())
+
+	p = &EmbeddingModelProviderOpenAI{APIType: "AZURE"}
+	require.NoError(t, p.fillDefaults())
+	assert.Equal(t, 0, p.EmbeddingDimensions())
+}
@@ -0,0 +1,112 @@
+package preprocessing
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Options configure the normalization of text before it is sent to the embedding model.
+// This only affects the computed embeddings - the stored document content stays untouched.
+type Options struct {
+	Lowercase          bool `json:"lowercase,omitempty" yaml:"lowercase" mapstructure:"lowercase"`
+	CollapseWhitespace bool `json:"collapseWhitespace,omitempty" yaml:"collapseWhitespace" mapstructure:"collapseWhitespace"`
+	StripMarkdown      bool `json:"stripMarkdown,omitempty" yaml:"stripMarkdown" mapstructure:"stripMarkdown"`
+	// MaxChars truncates the text to at most this many characters (0 = no limit)
+	MaxChars int `json:"maxChars,omitempty" yaml:"maxChars" mapstructure:"maxChars"`
+}
+
+func (o *Options) IsZero() bool {
+	return o == nil || *o == Options{}
+}
+
+var (
+	mdImageRegex      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkRegex       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdHeadingRegex    = regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s+`)
+	mdBlockquoteRegex = regexp.MustCompile(`(?m)^\s{0,3}>\s?`)
+	mdListRegex       = regexp.MustCompile(`(?m)^\s*(?:[-*+]|\d+[.)])\s+`)
+	mdRuleRegex       = regexp.MustCompile(`(?m)^\s{0,3}(?:[-*_]\s*){3,}$`)
+	mdTableSepRegex   = regexp.MustCompile(`(?m)^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	mdEmphasisRegex   = regexp.MustCompile(`(\*{1,3}|_{1,3}|~~)(\S(?:.*?\S)?)(\*{1,3}|_{1,3}|~~)`)
+	mdCodeFenceRegex  = regexp.MustCompile("(?m)^\\s*```.*$")
+	mdInlineCodeRegex = regexp.MustCompile("`([^`]*)`")
+	mdHTMLTagRegex    = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	whitespaceRegex   = regexp.MustCompile(`\s+`)
+)
+
+// Process applies the configured normalization steps to the given text.
+func (o *Options) Process(text string) string {
+	if o.IsZero() {
+		return text
+	}
+
+	if o.StripMarkdown {
+		text = stripMarkdown(text)
+	}
+
+	if o.Lowercase {
+		text = strings.ToLower(text)
+	}
+
+	if o.CollapseWhitespace {
+		text = strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+	}
+
+	if o.MaxChars > 0 {
+		if r := []rune(text); len(r) > o.MaxChars {
+			text = string(r[:o.MaxChars])
+		}
+	}
+
+	return text
+}
+
+func stripMarkdown(text string) string {
+	text = mdCodeFenceRegex.ReplaceAllString(text, "")
+	text = mdImageRegex.ReplaceAllString(text, "$1")
+	text = mdLinkRegex.ReplaceAllString(text, "$1")
+	text = mdHTMLTagRegex.ReplaceAllString(text, "")
+	text = mdTableSepRegex.ReplaceAllString(text, "")
+	text = mdRuleRegex.ReplaceAllString(text, "")
+	text = mdHeadingRegex.ReplaceAllString(text, "")
+	text = mdBlockquoteRegex.ReplaceAllString(text, "")
+	text = mdListRegex.ReplaceAllString(text, "")
+	text = mdEmphasisRegex.ReplaceAllString(text, "$2")
+	text = mdInlineCodeRegex.ReplaceAllString(text, "$1")
+	text = strings.ReplaceAll(text, "|", " ")
+	return text
+}
+
+type optionsCtxKey struct{}
+
+// ToCtx adds the preprocessing options to the context, so that they are picked up by the embedding function wrapped with WrapEmbeddingFunc
+func ToCtx(ctx context.Context, opts *Options) context.Context {
+	if opts.IsZero() {
+		return ctx
+	}
+	return context.WithValue(ctx, optionsCtxKey{}, opts)
+}
+
+// FromCtx returns the preprocessing options from the context, if any
+func FromCtx(ctx context.Context) *Options {
+	if opts, ok := ctx.Value(optionsCtxKey{}).(*Options); ok {
+		return opts
+	}
+	return nil
+}
+
+// WrapEmbeddingFunc returns an embedding function that preprocesses the input text according to the options found in the context.
+func WrapEmbeddingFunc(embeddingFunc vs.EmbeddingFunc) vs.EmbeddingFunc {
+	if embeddingFunc == nil {
+		return nil
+	}
+	return func(ctx context.Context, text string) ([]float32, error) {
+		if opts := FromCtx(ctx); opts != nil {
+			text = opts.Process(text)
+		}
+		return embeddingFunc(ctx, text)
+	}
+}
@@ -0,0 +1,35 @@
+package preprocessing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess(t *testing.T) {
+	opts := &Options{Lowercase: true, CollapseWhitespace: true, StripMarkdown: true}
+
+	input := "# Getting Started\n\nRead the **Install** [guide](https://example.com) and run `make`.\n\n- one\n-  two\n> quoted"
+	assert.Equal(t, "getting started read the install guide and run make. one two quoted", opts.Process(input))
+
+	var nilOpts *Options
+	assert.Equal(t, input, nilOpts.Process(input))
+}
+
+func TestWrapEmbeddingFunc(t *testing.T) {
+	var got string
+	ef := WrapEmbeddingFunc(func(_ context.Context, text string) ([]float32, error) {
+		got = text
+		return nil, nil
+	})
+
+	_, err := ef(context.Background(), "Hello  World")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello  World", got, "text must not be modified without options in context")
+
+	_, err = ef(ToCtx(context.Background(), &Options{Lowercase: true, CollapseWhitespace: true}), "Hello  World")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", got)
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of requests to a model API by spacing them evenly, e.g. to stay below
+// the requests-per-minute quota of an embedding model provider. A nil Limiter doesn't limit anything.
+type Limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// New returns a Limiter allowing the given number of requests per minute or nil, if requestsPerMinute is not positive
+func New(requestsPerMinute int) *Limiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+	return &Limiter{interval: time.Minute / time.Duration(requestsPerMinute)}
+}
+
+// Wait blocks until the next request may be sent or the context is canceled
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	at := l.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// PauseUntil holds back all requests until the given time, e.g. when the API asked to retry after some time
+func (l *Limiter) PauseUntil(t time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.After(l.next) {
+		l.next = t
+	}
+}
+
+type limiterKey struct{}
+
+// ToCtx returns a context carrying the limiter, which is applied to all model API requests made with that context
+func ToCtx(ctx context.Context, l *Limiter) context.Context {
+	if l == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+// FromCtx returns the limiter from the context or nil, if there is none
+func FromCtx(ctx context.Context) *Limiter {
+	l, _ := ctx.Value(limiterKey{}).(*Limiter)
+	return l
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(60 * 50) // one request every 20ms
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 4 {
+		require.NoError(t, l.Wait(ctx))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+
+	l.PauseUntil(time.Now().Add(50 * time.Millisecond))
+	start = time.Now()
+	require.NoError(t, l.Wait(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	l := New(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, l.Wait(ctx))
+
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
+
+func TestNilLimiter(t *testing.T) {
+	l := New(0)
+	assert.Nil(t, l)
+	assert.NoError(t, l.Wait(context.Background()))
+	l.PauseUntil(time.Now().Add(time.Hour))
+
+	ctx := ToCtx(context.Background(), l)
+	assert.Nil(t, FromCtx(ctx))
+	assert.NoError(t, FromCtx(ctx).Wait(ctx))
+}
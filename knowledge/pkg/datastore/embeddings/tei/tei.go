@@ -0,0 +1,264 @@
+package tei
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"dario.cat/mergo"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+var TEIEmbeddingAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_TEI_EMBEDDING_API_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+var TEIEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_TEI_EMBEDDING_API_REQUEST_TIMEOUT_SECONDS", 120)) * time.Second
+
+const EmbeddingModelProviderTEIName string = "tei"
+
+const (
+	TruncateRight = "right"
+	TruncateLeft  = "left"
+	TruncateNone  = "none"
+)
+
+// EmbeddingModelProviderTEI embeds text using a Hugging Face text-embeddings-inference (TEI) server
+// (https://github.com/huggingface/text-embeddings-inference), e.g. for self-hosted open-source embedding models.
+type EmbeddingModelProviderTEI struct {
+	BaseURL    string `usage:"TEI server base URL" default:"http://localhost:8080" env:"TEI_BASE_URL" koanf:"baseURL"`
+	APIKey     string `usage:"API key or token sent in the auth header (optional)" default:"" env:"TEI_API_KEY" koanf:"apiKey" mapstructure:"apiKey" export:"false"`
+	AuthHeader string `usage:"Header to send the API key in - for Authorization, it's sent as bearer token" default:"Authorization" env:"TEI_AUTH_HEADER" koanf:"authHeader" export:"false"`
+	Model      string `usage:"Model served by the TEI server (Hugging Face model ID) - checked against the server on first use" default:"" env:"TEI_MODEL" koanf:"model" export:"required"`
+	Truncate   string `usage:"Truncation of inputs exceeding the model's maximum input length: right, left or none (reject)" default:"right" env:"TEI_TRUNCATE" koanf:"truncate"`
+	PromptName string `usage:"Name of the prompt configured in the model's sentence-transformers config to prepend to all inputs (optional)" default:"" env:"TEI_PROMPT_NAME" koanf:"promptName"`
+	BatchSize  int    `usage:"Maximum number of texts embedded per request during ingestion - must not exceed the server's --max-client-batch-size" default:"32" env:"TEI_BATCH_SIZE" koanf:"batchSize" export:"false"`
+	Dimensions int    `usage:"Output dimensions - embeddings are truncated and normalized, for models trained with Matryoshka Representation Learning (0 = model default)" default:"0" env:"TEI_DIMENSIONS" koanf:"dimensions"`
+}
+
+type TEIEmbedRequest struct {
+	Inputs              []string `json:"inputs"`
+	Normalize           bool     `json:"normalize"`
+	Truncate            bool     `json:"truncate"`
+	TruncationDirection string   `json:"truncation_direction,omitempty"`
+	PromptName          string   `json:"prompt_name,omitempty"`
+}
+
+type teiInfo struct {
+	ModelID string `json:"model_id"`
+}
+
+func (p *EmbeddingModelProviderTEI) UseEmbeddingModel(model string) {
+	p.Model = model
+}
+
+func (p *EmbeddingModelProviderTEI) EmbeddingModelName() string {
+	return p.Model
+}
+
+func (p *EmbeddingModelProviderTEI) Name() string {
+	return EmbeddingModelProviderTEIName
+}
+
+func (p *EmbeddingModelProviderTEI) Configure() error {
+	if err := load.FillConfigEnv("TEI_", &p); err != nil {
+		return fmt.Errorf("failed to fill TEI config from environment: %w", err)
+	}
+
+	if err := p.fillDefaults(); err != nil {
+		return fmt.Errorf("failed to fill TEI defaults: %w", err)
+	}
+
+	if p.Model == "" {
+		return errors.New("no TEI model configured (model or TEI_MODEL) - set it to the model served by the TEI server")
+	}
+
+	switch strings.ToLower(p.Truncate) {
+	case TruncateRight, TruncateLeft, TruncateNone:
+	default:
+		return fmt.Errorf("invalid TEI truncate option %q, must be one of right, left or none", p.Truncate)
+	}
+
+	if p.Dimensions < 0 {
+		return fmt.Errorf("invalid TEI embedding dimensions %d", p.Dimensions)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderTEI) fillDefaults() error {
+	defaultConfig := EmbeddingModelProviderTEI{
+		BaseURL:    "http://localhost:8080",
+		AuthHeader: "Authorization",
+		Truncate:   TruncateRight,
+		BatchSize:  32,
+	}
+
+	if err := mergo.Merge(p, defaultConfig); err != nil {
+		return fmt.Errorf("failed to merge TEI config: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderTEI) Config() any {
+	return p
+}
+
+func (p *EmbeddingModelProviderTEI) EmbeddingDimensions() int {
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderTEI) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+// EmbeddingFunc returns a function embedding text using the TEI server. On the first request, it checks that the server
+// serves the configured model, so that embeddings of different models don't end up in the same dataset.
+func (p *EmbeddingModelProviderTEI) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	batchEmbeddingFunc, _, err := p.BatchEmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := batchEmbeddingFunc(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}, nil
+}
+
+// BatchEmbeddingFunc returns a function embedding up to BatchSize texts per request - see EmbeddingFunc.
+func (p *EmbeddingModelProviderTEI) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	embedURL, err := url.JoinPath(p.BaseURL, "embed")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse TEI base URL %q: %w", p.BaseURL, err)
+	}
+
+	client := &http.Client{
+		Timeout: TEIEmbeddingAPIRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+
+	truncate := strings.ToLower(p.Truncate)
+	embedReq := TEIEmbedRequest{
+		Normalize:  true,
+		Truncate:   truncate != TruncateNone,
+		PromptName: p.PromptName,
+	}
+	if embedReq.Truncate {
+		embedReq.TruncationDirection = strings.ToUpper(truncate[:1]) + truncate[1:] // Right or Left
+	}
+
+	var mu sync.Mutex
+	var checked bool
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, TEIEmbeddingAPITimeout)
+		defer cancel()
+
+		mu.Lock()
+		if !checked {
+			if err := p.checkModel(ctx, client); err != nil {
+				mu.Unlock()
+				return nil, err
+			}
+			checked = true
+		}
+		mu.Unlock()
+
+		r := embedReq
+		r.Inputs = texts
+		reqBody, err := json.Marshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, embedURL, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		p.setAuth(req)
+
+		body, err := openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+		if err != nil {
+			return nil, fmt.Errorf("error sending request(s) to TEI: %w", err)
+		}
+
+		var embeddings [][]float32
+		if err := json.Unmarshal(body, &embeddings); err != nil {
+			return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+		}
+		if len(embeddings) != len(texts) {
+			return nil, fmt.Errorf("expected %d embeddings in the response, got %d", len(texts), len(embeddings))
+		}
+		for i, v := range embeddings {
+			if len(v) == 0 {
+				return nil, errors.New("no embeddings found in the response")
+			}
+			embeddings[i] = etypes.TruncateEmbedding(v, p.Dimensions)
+		}
+		return embeddings, nil
+	}, p.BatchSize, nil
+}
+
+// checkModel verifies that the TEI server serves the configured model
+func (p *EmbeddingModelProviderTEI) checkModel(ctx context.Context, client *http.Client) error {
+	infoURL, err := url.JoinPath(p.BaseURL, "info")
+	if err != nil {
+		return fmt.Errorf("failed to parse TEI base URL %q: %w", p.BaseURL, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't create request: %w", err)
+	}
+	p.setAuth(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get TEI server info (is the server running at %s?): %w", p.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get TEI server info: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var info teiInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return fmt.Errorf("couldn't unmarshal TEI server info: %w", err)
+	}
+	if info.ModelID != "" && !strings.EqualFold(info.ModelID, p.Model) {
+		return fmt.Errorf("the TEI server at %s serves model %q, but model %q is configured", p.BaseURL, info.ModelID, p.Model)
+	}
+
+	slog.Info("Using TEI embedding model", "model", p.Model, "baseURL", p.BaseURL)
+	return nil
+}
+
+func (p *EmbeddingModelProviderTEI) setAuth(req *http.Request) {
+	if p.APIKey == "" {
+		return
+	}
+	if strings.EqualFold(p.AuthHeader, "Authorization") {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+		return
+	}
+	req.Header.Set(p.AuthHeader, p.APIKey)
+}
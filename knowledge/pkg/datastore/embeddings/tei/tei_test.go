@@ -0,0 +1,64 @@
+package tei
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, modelID string, requests *[]TEIEmbedRequest) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		switch r.URL.Path {
+		case "/info":
+			_, _ = w.Write([]byte(`{"model_id":"` + modelID + `","max_input_length":512}`))
+		case "/embed":
+			var req TEIEmbedRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			*requests = append(*requests, req)
+			_, _ = w.Write([]byte(`[[0.6,0.8]]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmbeddingFunc(t *testing.T) {
+	var requests []TEIEmbedRequest
+	srv := newTestServer(t, "BAAI/bge-small-en-v1.5", &requests)
+
+	p := &EmbeddingModelProviderTEI{BaseURL: srv.URL, APIKey: "secret", AuthHeader: "X-Api-Key", Model: "BAAI/bge-small-en-v1.5", Truncate: "left"}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.6, 0.8}, v)
+
+	require.Len(t, requests, 1)
+	assert.Equal(t, TEIEmbedRequest{Inputs: []string{"hello"}, Normalize: true, Truncate: true, TruncationDirection: "Left"}, requests[0])
+}
+
+func TestEmbeddingFuncRejectsOtherModel(t *testing.T) {
+	var requests []TEIEmbedRequest
+	srv := newTestServer(t, "intfloat/e5-large-v2", &requests)
+
+	p := &EmbeddingModelProviderTEI{BaseURL: srv.URL, APIKey: "secret", AuthHeader: "X-Api-Key", Model: "BAAI/bge-small-en-v1.5", Truncate: TruncateNone}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	_, err = ef(context.Background(), "hello")
+	assert.ErrorContains(t, err, `serves model "intfloat/e5-large-v2"`)
+	assert.Empty(t, requests)
+}
@@ -0,0 +1,48 @@
+package types
+
+import "math"
+
+// DimensionsEmbeddingModelProvider is implemented by embedding model providers whose output dimensions can be configured,
+// e.g. for models trained with Matryoshka Representation Learning (MRL), whose embeddings can be truncated.
+type DimensionsEmbeddingModelProvider interface {
+	EmbeddingModelProvider
+
+	// EmbeddingDimensions returns the effective output dimensions or 0, if the model's default dimensions are used
+	EmbeddingDimensions() int
+
+	// UseEmbeddingDimensions sets the output dimensions, e.g. to match those of an existing dataset
+	UseEmbeddingDimensions(dimensions int)
+}
+
+// EmbeddingDimensions returns the effective output dimensions of the provider or 0, if they're not configurable or the model's default
+func EmbeddingDimensions(provider EmbeddingModelProvider) int {
+	if dp, ok := provider.(DimensionsEmbeddingModelProvider); ok {
+		return dp.EmbeddingDimensions()
+	}
+	return 0
+}
+
+// TruncateEmbedding truncates the embedding to the given dimensions and normalizes it again,
+// which is how embeddings of MRL-trained models are shortened. Shorter embeddings are returned as is.
+func TruncateEmbedding(v []float32, dimensions int) []float32 {
+	if dimensions <= 0 || len(v) <= dimensions {
+		return v
+	}
+
+	v = v[:dimensions]
+	var norm float64
+	for _, val := range v {
+		norm += float64(val) * float64(val)
+	}
+	norm = math.Sqrt(norm)
+
+	res := make([]float32, dimensions)
+	for i, val := range v {
+		if norm == 0 {
+			res[i] = val
+			continue
+		}
+		res[i] = float32(float64(val) / norm)
+	}
+	return res
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateEmbedding(t *testing.T) {
+	v := []float32{0.6, 0.8, 0, 0}
+
+	assert.Equal(t, v, TruncateEmbedding(v, 0))
+	assert.Equal(t, v, TruncateEmbedding(v, 4))
+	assert.Equal(t, v, TruncateEmbedding(v, 8))
+
+	assert.Equal(t, []float32{1}, TruncateEmbedding(v, 1))
+	assert.Equal(t, []float32{0.6, 0.8}, TruncateEmbedding(v, 2))
+	assert.Equal(t, []float32{0}, TruncateEmbedding([]float32{0, 1}, 1))
+
+	// the original embedding is not modified
+	assert.Equal(t, []float32{0.6, 0.8, 0, 0}, v)
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"context"
+
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
@@ -12,3 +14,27 @@ type EmbeddingModelProvider interface {
 	EmbeddingModelName() string
 	UseEmbeddingModel(model string)
 }
+
+// LateChunkingEmbeddingModelProvider is implemented by embedding model providers supporting late chunking, where all chunks
+// of a document are embedded together, so that every chunk embedding is derived from the context of the whole document.
+type LateChunkingEmbeddingModelProvider interface {
+	EmbeddingModelProvider
+
+	// LateChunkingEnabled returns true if late chunking is enabled for the provider
+	LateChunkingEnabled() bool
+
+	// EmbedChunks returns the embeddings of the chunks of a document, in order
+	EmbedChunks(ctx context.Context, chunks []string) ([][]float32, error)
+}
+
+// BatchEmbeddingFunc returns the embeddings of multiple texts, in order
+type BatchEmbeddingFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// BatchEmbeddingModelProvider is implemented by embedding model providers that can embed multiple texts with a single request,
+// which is used during ingestion to avoid one request per chunk.
+type BatchEmbeddingModelProvider interface {
+	EmbeddingModelProvider
+
+	// BatchEmbeddingFunc returns the batch embedding function and the maximum number of texts per batch
+	BatchEmbeddingFunc() (BatchEmbeddingFunc, int, error)
+}
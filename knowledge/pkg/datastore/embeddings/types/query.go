@@ -0,0 +1,17 @@
+package types
+
+import "context"
+
+type queryCtxKey struct{}
+
+// QueryToCtx marks the texts embedded with this context as search queries (as opposed to documents),
+// for providers embedding them differently, e.g. using a different task type
+func QueryToCtx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryCtxKey{}, true)
+}
+
+// IsQueryFromCtx returns true if the texts embedded with this context are search queries
+func IsQueryFromCtx(ctx context.Context) bool {
+	isQuery, _ := ctx.Value(queryCtxKey{}).(bool)
+	return isQuery
+}
@@ -0,0 +1,400 @@
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"dario.cat/mergo"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/load"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+var VertexEmbeddingAPITimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_VERTEX_EMBEDDING_API_TIMEOUT_SECONDS", defaults.ModelAPIRequestTimeoutSeconds)) * time.Second
+var VertexEmbeddingAPIRequestTimeout = time.Duration(env.GetIntFromEnvOrDefault("KNOW_VERTEX_EMBEDDING_API_REQUEST_TIMEOUT_SECONDS", 120)) * time.Second
+
+const EmbeddingModelProviderVertexName string = "vertex"
+
+const (
+	geminiAPIEndpoint = "https://generativelanguage.googleapis.com"
+	vertexAPIEndpoint = "https://%s-aiplatform.googleapis.com"
+)
+
+// EmbeddingModelProviderVertex embeds text using Google's embedding models, either via Vertex AI (authenticated with
+// a service account key file) or via the Gemini API (authenticated with an API key).
+type EmbeddingModelProviderVertex struct {
+	APIKey          string `usage:"Gemini API key (used if no service account credentials are configured)" default:"" env:"VERTEX_API_KEY" koanf:"apiKey" mapstructure:"apiKey" export:"false"`
+	CredentialsFile string `usage:"Service account key file for Vertex AI (default: $GOOGLE_APPLICATION_CREDENTIALS)" default:"" env:"VERTEX_CREDENTIALS_FILE" koanf:"credentialsFile" export:"false"`
+	Project         string `usage:"Google Cloud project for Vertex AI (default: project of the service account)" default:"" env:"VERTEX_PROJECT" koanf:"project"`
+	Location        string `usage:"Google Cloud location for Vertex AI" default:"us-central1" env:"VERTEX_LOCATION" koanf:"location"`
+	APIEndpoint     string `usage:"API endpoint (default: regional Vertex AI endpoint or Gemini API)" default:"" env:"VERTEX_API_ENDPOINT" koanf:"apiEndpoint"`
+	Model           string `usage:"Embedding model" default:"text-embedding-004" env:"VERTEX_MODEL" koanf:"model" export:"required"`
+	TaskType        string `usage:"Task type for embedding documents, e.g. RETRIEVAL_DOCUMENT, SEMANTIC_SIMILARITY, CLASSIFICATION, CLUSTERING" default:"RETRIEVAL_DOCUMENT" env:"VERTEX_TASK_TYPE" koanf:"taskType" export:"required"`
+	QueryTaskType   string `usage:"Task type for embedding search queries, e.g. RETRIEVAL_QUERY, QUESTION_ANSWERING, FACT_VERIFICATION" default:"RETRIEVAL_QUERY" env:"VERTEX_QUERY_TASK_TYPE" koanf:"queryTaskType" export:"required"`
+	Dimensions      int    `usage:"Output dimensionality - embeddings are truncated to this size (0 = model default)" default:"0" env:"VERTEX_DIMENSIONS" koanf:"dimensions"`
+	BatchSize       int    `usage:"Maximum number of texts embedded per request during ingestion" default:"16" env:"VERTEX_BATCH_SIZE" koanf:"batchSize" export:"false"`
+}
+
+type vertexPredictRequest struct {
+	Instances  []vertexInstance `json:"instances"`
+	Parameters vertexParameters `json:"parameters"`
+}
+
+type vertexInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+type vertexParameters struct {
+	AutoTruncate         bool `json:"autoTruncate"`
+	OutputDimensionality int  `json:"outputDimensionality,omitempty"`
+}
+
+type vertexPredictResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
+
+type geminiEmbedRequest struct {
+	Content              geminiContent `json:"content"`
+	TaskType             string        `json:"taskType,omitempty"`
+	OutputDimensionality int           `json:"outputDimensionality,omitempty"`
+}
+
+type geminiContent struct {
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiEmbedResponse struct {
+	Embedding geminiEmbedding `json:"embedding"`
+}
+
+type geminiBatchEmbedRequest struct {
+	Requests []geminiBatchEmbedRequestItem `json:"requests"`
+}
+
+type geminiBatchEmbedRequestItem struct {
+	Model string `json:"model"`
+	geminiEmbedRequest
+}
+
+type geminiBatchEmbedResponse struct {
+	Embeddings []geminiEmbedding `json:"embeddings"`
+}
+
+type geminiEmbedding struct {
+	Values []float32 `json:"values"`
+}
+
+func (p *EmbeddingModelProviderVertex) UseEmbeddingModel(model string) {
+	p.Model = model
+}
+
+func (p *EmbeddingModelProviderVertex) EmbeddingModelName() string {
+	return p.Model
+}
+
+func (p *EmbeddingModelProviderVertex) Name() string {
+	return EmbeddingModelProviderVertexName
+}
+
+func (p *EmbeddingModelProviderVertex) Configure() error {
+	if err := load.FillConfigEnv("VERTEX_", &p); err != nil {
+		return fmt.Errorf("failed to fill Vertex config from environment: %w", err)
+	}
+
+	if err := p.fillDefaults(); err != nil {
+		return fmt.Errorf("failed to fill Vertex defaults: %w", err)
+	}
+
+	if p.Dimensions < 0 {
+		return fmt.Errorf("invalid Vertex output dimensionality %d", p.Dimensions)
+	}
+
+	for _, taskType := range []string{p.TaskType, p.QueryTaskType} {
+		if !validTaskType(taskType) {
+			return fmt.Errorf("invalid Vertex task type %q", taskType)
+		}
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderVertex) fillDefaults() error {
+	defaultConfig := EmbeddingModelProviderVertex{
+		APIKey:          os.Getenv("GOOGLE_API_KEY"),
+		CredentialsFile: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		Location:        "us-central1",
+		Model:           "text-embedding-004",
+		TaskType:        "RETRIEVAL_DOCUMENT",
+		QueryTaskType:   "RETRIEVAL_QUERY",
+		BatchSize:       16,
+	}
+
+	if err := mergo.Merge(p, defaultConfig); err != nil {
+		return fmt.Errorf("failed to merge Vertex config: %w", err)
+	}
+
+	return nil
+}
+
+func (p *EmbeddingModelProviderVertex) Config() any {
+	return p
+}
+
+func (p *EmbeddingModelProviderVertex) EmbeddingDimensions() int {
+	return p.Dimensions
+}
+
+func (p *EmbeddingModelProviderVertex) UseEmbeddingDimensions(dimensions int) {
+	p.Dimensions = dimensions
+}
+
+// EmbeddingFunc returns a function embedding text via Vertex AI, if service account credentials are configured,
+// or via the Gemini API, if an API key is configured. Search queries are embedded using the query task type.
+func (p *EmbeddingModelProviderVertex) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	batchEmbeddingFunc, _, err := p.BatchEmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, text string) ([]float32, error) {
+		embeddings, err := batchEmbeddingFunc(ctx, []string{text})
+		if err != nil {
+			return nil, err
+		}
+		return embeddings[0], nil
+	}, nil
+}
+
+// BatchEmbeddingFunc returns a function embedding up to BatchSize texts per request - see EmbeddingFunc.
+func (p *EmbeddingModelProviderVertex) BatchEmbeddingFunc() (etypes.BatchEmbeddingFunc, int, error) {
+	client := &http.Client{
+		Timeout: VertexEmbeddingAPIRequestTimeout, // per request timeout - the overall timeout is set on the context
+	}
+
+	var embed func(ctx context.Context, texts []string, taskType string) ([][]float32, error)
+	switch {
+	case p.CredentialsFile != "":
+		creds, err := loadServiceAccount(p.CredentialsFile)
+		if err != nil {
+			return nil, 0, err
+		}
+		project := p.Project
+		if project == "" {
+			project = creds.ProjectID
+		}
+		if project == "" {
+			return nil, 0, errors.New("no Google Cloud project configured for Vertex AI")
+		}
+
+		endpoint := p.APIEndpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf(vertexAPIEndpoint, p.Location)
+		}
+		predictURL, err := url.JoinPath(endpoint, "v1", "projects", project, "locations", p.Location, "publishers", "google", "models", p.Model+":predict")
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse Vertex API endpoint %q: %w", endpoint, err)
+		}
+
+		slog.Debug("Using Vertex AI embeddings", "model", p.Model, "project", project, "location", p.Location)
+		tokens := newTokenSource(creds, client)
+		embed = func(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+			return p.embedVertex(ctx, client, tokens, predictURL, texts, taskType)
+		}
+	case p.APIKey != "":
+		endpoint := p.APIEndpoint
+		if endpoint == "" {
+			endpoint = geminiAPIEndpoint
+		}
+		modelURL, err := url.JoinPath(endpoint, "v1beta", "models", p.Model)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse Gemini API endpoint %q: %w", endpoint, err)
+		}
+
+		slog.Debug("Using Gemini API embeddings", "model", p.Model)
+		embed = func(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+			if len(texts) == 1 {
+				v, err := p.embedGemini(ctx, client, modelURL+":embedContent", texts[0], taskType)
+				return [][]float32{v}, err
+			}
+			return p.batchEmbedGemini(ctx, client, modelURL+":batchEmbedContents", texts, taskType)
+		}
+	default:
+		return nil, 0, errors.New("no Google credentials configured - set a service account key file (credentialsFile or GOOGLE_APPLICATION_CREDENTIALS) or a Gemini API key (apiKey or GOOGLE_API_KEY)")
+	}
+
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		taskType := p.TaskType
+		if etypes.IsQueryFromCtx(ctx) {
+			taskType = p.QueryTaskType
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, VertexEmbeddingAPITimeout)
+		defer cancel()
+
+		embeddings, err := embed(ctx, texts, taskType)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) != len(texts) {
+			return nil, fmt.Errorf("expected %d embeddings in the response, got %d", len(texts), len(embeddings))
+		}
+
+		for i, v := range embeddings {
+			if len(v) == 0 {
+				return nil, errors.New("no embeddings found in the response")
+			}
+			// embeddings with reduced output dimensionality are not normalized
+			embeddings[i] = normalizeVector(v)
+		}
+		return embeddings, nil
+	}, p.BatchSize, nil
+}
+
+func (p *EmbeddingModelProviderVertex) embedVertex(ctx context.Context, client *http.Client, tokens *tokenSource, predictURL string, texts []string, taskType string) ([][]float32, error) {
+	token, err := tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]vertexInstance, len(texts))
+	for i, text := range texts {
+		instances[i] = vertexInstance{Content: text, TaskType: taskType}
+	}
+
+	body, err := post(ctx, client, predictURL, vertexPredictRequest{
+		Instances: instances,
+		Parameters: vertexParameters{
+			AutoTruncate:         true,
+			OutputDimensionality: p.Dimensions,
+		},
+	}, map[string]string{"Authorization": "Bearer " + token})
+	if err != nil {
+		return nil, fmt.Errorf("error sending request(s) to Vertex AI: %w", err)
+	}
+
+	var resp vertexPredictResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+
+	embeddings := make([][]float32, len(resp.Predictions))
+	for i, prediction := range resp.Predictions {
+		embeddings[i] = prediction.Embeddings.Values
+	}
+	return embeddings, nil
+}
+
+func (p *EmbeddingModelProviderVertex) embedGemini(ctx context.Context, client *http.Client, embedURL, text, taskType string) ([]float32, error) {
+	body, err := post(ctx, client, embedURL, p.geminiRequest(text, taskType), map[string]string{"x-goog-api-key": p.APIKey})
+	if err != nil {
+		return nil, fmt.Errorf("error sending request(s) to Gemini API: %w", err)
+	}
+
+	var resp geminiEmbedResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+	return resp.Embedding.Values, nil
+}
+
+func (p *EmbeddingModelProviderVertex) batchEmbedGemini(ctx context.Context, client *http.Client, batchEmbedURL string, texts []string, taskType string) ([][]float32, error) {
+	req := geminiBatchEmbedRequest{Requests: make([]geminiBatchEmbedRequestItem, len(texts))}
+	for i, text := range texts {
+		req.Requests[i] = geminiBatchEmbedRequestItem{
+			Model:              "models/" + p.Model,
+			geminiEmbedRequest: p.geminiRequest(text, taskType),
+		}
+	}
+
+	body, err := post(ctx, client, batchEmbedURL, req, map[string]string{"x-goog-api-key": p.APIKey})
+	if err != nil {
+		return nil, fmt.Errorf("error sending request(s) to Gemini API: %w", err)
+	}
+
+	var resp geminiBatchEmbedResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal response body: %w", err)
+	}
+
+	embeddings := make([][]float32, len(resp.Embeddings))
+	for i, e := range resp.Embeddings {
+		embeddings[i] = e.Values
+	}
+	return embeddings, nil
+}
+
+func (p *EmbeddingModelProviderVertex) geminiRequest(text, taskType string) geminiEmbedRequest {
+	return geminiEmbedRequest{
+		Content:              geminiContent{Parts: []geminiPart{{Text: text}}},
+		TaskType:             taskType,
+		OutputDimensionality: p.Dimensions,
+	}
+}
+
+func post(ctx context.Context, client *http.Client, u string, payload any, headers map[string]string) ([]byte, error) {
+	reqBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return openai.RequestWithExponentialBackoff(ctx, client, req, 5, true)
+}
+
+func normalizeVector(v []float32) []float32 {
+	var norm float64
+	for _, val := range v {
+		norm += float64(val) * float64(val)
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		return v
+	}
+
+	res := make([]float32, len(v))
+	for i, val := range v {
+		res[i] = float32(float64(val) / norm)
+	}
+	return res
+}
+
+// validTaskType reports whether the task type is one of the task types supported by Google's embedding models
+func validTaskType(taskType string) bool {
+	switch strings.ToUpper(taskType) {
+	case "RETRIEVAL_DOCUMENT", "RETRIEVAL_QUERY", "SEMANTIC_SIMILARITY", "CLASSIFICATION", "CLUSTERING",
+		"QUESTION_ANSWERING", "FACT_VERIFICATION", "CODE_RETRIEVAL_QUERY":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package vertex
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeServiceAccount(t *testing.T, tokenURI string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "foo-project",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email": "knowledge@foo-project.iam.gserviceaccount.com",
+		"token_uri":    tokenURI,
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestEmbeddingFuncVertexAI(t *testing.T) {
+	var tokenRequests int
+	var taskTypes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+			assert.Len(t, strings.Split(r.Form.Get("assertion"), "."), 3)
+			_, _ = w.Write([]byte(`{"access_token":"ya29.test","expires_in":3600,"token_type":"Bearer"}`))
+		case "/v1/projects/foo-project/locations/europe-west1/publishers/google/models/text-embedding-004:predict":
+			assert.Equal(t, "Bearer ya29.test", r.Header.Get("Authorization"))
+			var req vertexPredictRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Len(t, req.Instances, 1)
+			assert.Equal(t, "hello", req.Instances[0].Content)
+			assert.Equal(t, 2, req.Parameters.OutputDimensionality)
+			taskTypes = append(taskTypes, req.Instances[0].TaskType)
+			_, _ = w.Write([]byte(`{"predictions":[{"embeddings":{"values":[3,4]}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOOGLE_API_KEY", "")
+	p := &EmbeddingModelProviderVertex{
+		CredentialsFile: writeServiceAccount(t, srv.URL+"/token"),
+		Location:        "europe-west1",
+		APIEndpoint:     srv.URL,
+		Dimensions:      2,
+	}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.InDeltaSlice(t, []float32{0.6, 0.8}, v, 1e-6)
+
+	_, err = ef(etypes.QueryToCtx(context.Background()), "hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"RETRIEVAL_DOCUMENT", "RETRIEVAL_QUERY"}, taskTypes)
+	assert.Equal(t, 1, tokenRequests, "access token should be cached")
+}
+
+func TestEmbeddingFuncGeminiAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/models/text-embedding-004:embedContent", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-goog-api-key"))
+		var req geminiEmbedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "hello", req.Content.Parts[0].Text)
+		assert.Equal(t, "SEMANTIC_SIMILARITY", req.TaskType)
+		assert.Zero(t, req.OutputDimensionality)
+		_, _ = w.Write([]byte(`{"embedding":{"values":[0,2]}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	p := &EmbeddingModelProviderVertex{
+		APIKey:      "test-key",
+		APIEndpoint: srv.URL,
+		TaskType:    "SEMANTIC_SIMILARITY",
+	}
+	require.NoError(t, p.fillDefaults())
+
+	ef, err := p.EmbeddingFunc()
+	require.NoError(t, err)
+
+	v, err := ef(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0, 1}, v)
+}
+
+func TestConfigureRejectsInvalidTaskType(t *testing.T) {
+	p := &EmbeddingModelProviderVertex{TaskType: "SUMMARIZATION"}
+	assert.ErrorContains(t, p.Configure(), `invalid Vertex task type "SUMMARIZATION"`)
+}
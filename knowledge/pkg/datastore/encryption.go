@@ -0,0 +1,31 @@
+package datastore
+
+import (
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// plaintextMetadataKeys are the document metadata keys that are not encrypted at rest, as the vector store has to filter or sort by them.
+// They hold nothing that isn't stored unencrypted in the Index anyway.
+var plaintextMetadataKeys = []string{"absPath", vs.DocMetadataKeyDocIndex, vs.DocMetadataKeyDocsTotal, MetadataKeyFileTags}
+
+// encryptedEmbeddingConcurrency is the number of documents embedded in parallel before they're encrypted,
+// if the embedding model provider doesn't support batches (like the vector stores do when they embed the documents)
+const encryptedEmbeddingConcurrency = 100
+
+// encryptCheckpoint encrypts the encoded documents of an ingestion checkpoint, if encryption at rest is enabled
+func (s *Datastore) encryptCheckpoint(b []byte) ([]byte, error) {
+	if s.encryption == nil {
+		return b, nil
+	}
+	enc, err := s.encryption.EncryptString(string(b))
+	return []byte(enc), err
+}
+
+// decryptCheckpoint decrypts the encoded documents of an ingestion checkpoint - unencrypted ones are returned as they are
+func (s *Datastore) decryptCheckpoint(b []byte) ([]byte, error) {
+	if s.encryption == nil {
+		return b, nil
+	}
+	dec, err := s.encryption.DecryptString(string(b))
+	return []byte(dec), err
+}
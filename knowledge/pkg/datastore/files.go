@@ -6,11 +6,17 @@ import (
 	"fmt"
 
 	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
 // ErrDBFileNotFound is returned when a file is not found.
 var ErrDBFileNotFound = errors.New("file not found in database")
 
+// MetadataKeyFileTags is the document metadata key holding the tags of the document's file as a list.
+// It's separate from e.g. tags in markdown front matter, which are specific to the document.
+const MetadataKeyFileTags = "fileTags"
+
 func (s *Datastore) DeleteFile(ctx context.Context, datasetID, fileID string) error {
 	// Find file
 	search := types.File{ID: fileID, Dataset: datasetID}
@@ -22,6 +28,7 @@ func (s *Datastore) DeleteFile(ctx context.Context, datasetID, fileID string) er
 	// Remove owned documents from VectorStore and Database
 	for _, doc := range file.Documents {
 		if err := s.Vectorstore.RemoveDocument(ctx, doc.ID, datasetID, nil, nil); err != nil {
+			metrics.ObserveVectorStoreError("remove_documents", err)
 			return fmt.Errorf("failed to remove document from VectorStore: %w", err)
 		}
 	}
@@ -37,3 +44,38 @@ func (s *Datastore) PruneFiles(ctx context.Context, datasetID string, pathPrefix
 func (s *Datastore) FindFile(ctx context.Context, searchFile types.File) (*types.File, error) {
 	return s.Index.FindFile(ctx, searchFile)
 }
+
+// TagFile adds the tags to the file and the metadata of its documents, so that retrieval can filter by them (RetrieveOpts.Tags)
+func (s *Datastore) TagFile(ctx context.Context, datasetID, fileID string, tags ...string) (*types.File, error) {
+	return s.updateFileTags(ctx, datasetID, fileID, func(file *types.File) { file.AddTags(tags...) })
+}
+
+// UntagFile removes the tags from the file and the metadata of its documents
+func (s *Datastore) UntagFile(ctx context.Context, datasetID, fileID string, tags ...string) (*types.File, error) {
+	return s.updateFileTags(ctx, datasetID, fileID, func(file *types.File) { file.RemoveTags(tags...) })
+}
+
+func (s *Datastore) updateFileTags(ctx context.Context, datasetID, fileID string, update func(file *types.File)) (*types.File, error) {
+	file, err := s.Index.FindFile(ctx, types.File{ID: fileID, Dataset: datasetID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find file in DB: %w", err)
+	}
+	update(file)
+
+	// The documents are updated first, so that a failed update can simply be retried
+	patch := vs.MetadataPatch{MetadataKeyFileTags: nil}
+	if len(file.Tags) > 0 {
+		patch[MetadataKeyFileTags] = file.Tags
+	}
+	for _, doc := range file.Documents {
+		if err := s.Vectorstore.UpdateDocumentMetadata(ctx, doc.ID, datasetID, patch); err != nil {
+			metrics.ObserveVectorStoreError("update_document_metadata", err)
+			return nil, fmt.Errorf("failed to update tags of document %q in VectorStore: %w", doc.ID, err)
+		}
+	}
+
+	if err := s.Index.UpdateFileTags(ctx, datasetID, file.ID, file.Tags); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
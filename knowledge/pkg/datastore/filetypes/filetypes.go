@@ -18,12 +18,41 @@ var FirstclassFileExtensions = map[string]struct{}{
 	".odt":   {},
 	".rtf":   {},
 	".csv":   {},
+	".xlsx":  {},
 	".ipynb": {},
 	".json":  {},
-	".pptx":  {}, // via libreoffice conversion to pdf
+	".jsonl": {},
+	".epub":  {},
+	".eml":   {},
+	".msg":   {},
+	".pptx":  {}, // native loader or via libreoffice conversion to pdf
 	".doc":   {}, // via libreoffice conversion to pdf
 	".ppt":   {}, // via libreoffice conversion to pdf
 	".pages": {}, // Apple Pages - via libreoffice conversion to pdf
+
+	// source code - chunked along declarations
+	".go":    {},
+	".py":    {},
+	".js":    {},
+	".jsx":   {},
+	".mjs":   {},
+	".cjs":   {},
+	".ts":    {},
+	".tsx":   {},
+	".java":  {},
+	".cs":    {},
+	".kt":    {},
+	".kts":   {},
+	".scala": {},
+	".c":     {},
+	".h":     {},
+	".cpp":   {},
+	".cc":    {},
+	".cxx":   {},
+	".hpp":   {},
+	".rs":    {},
+	".php":   {},
+	".swift": {},
 }
 
 // GetFiletype returns the filetype of a file based on its filename or content.
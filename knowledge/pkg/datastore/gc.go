@@ -0,0 +1,176 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+)
+
+type GCOpts struct {
+	DryRun bool // only report the orphaned data, don't remove it
+}
+
+// GCReport lists the orphaned data found (and removed, unless it was a dry run) by a garbage collection
+type GCReport struct {
+	Datasets            []DatasetGCReport `json:"datasets"`
+	OrphanedCollections []string          `json:"orphanedCollections,omitempty"` // vector store collections without dataset in the index
+	DryRun              bool              `json:"dryRun,omitempty"`
+}
+
+type DatasetGCReport struct {
+	Dataset           string   `json:"dataset"`
+	OrphanedDocuments []string `json:"orphanedDocuments,omitempty"` // documents in the vector store without record in the index
+	IncompleteFiles   []string `json:"incompleteFiles,omitempty"`   // files in the index with documents missing from the vector store
+}
+
+func (r *DatasetGCReport) Empty() bool {
+	return len(r.OrphanedDocuments) == 0 && len(r.IncompleteFiles) == 0
+}
+
+// GarbageCollect finds and removes data that's only present in either the vector store or the index, e.g. embeddings left behind
+// by an ingestion that failed after writing to the vector store, but before recording the file in the index.
+// Files with documents missing from the vector store are removed from the index (with their remaining documents), so that they're
+// ingested again on the next run. If no datasets are given, all datasets are checked and vector store collections without dataset are removed.
+// It must not run concurrently with ingestions, as the documents of files being ingested are not in the index yet.
+func (s *Datastore) GarbageCollect(ctx context.Context, opts GCOpts, datasetIDs ...string) (*GCReport, error) {
+	report := &GCReport{DryRun: opts.DryRun}
+
+	datasets, err := s.Index.ListDatasets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list datasets: %w", err)
+	}
+	known := make([]string, 0, len(datasets))
+	for _, ds := range datasets {
+		known = append(known, ds.ID)
+	}
+
+	collect := datasetIDs
+	if len(collect) == 0 {
+		collect = known
+	}
+
+	for _, datasetID := range collect {
+		if !slices.Contains(known, datasetID) {
+			return nil, fmt.Errorf("dataset %q not found", datasetID)
+		}
+		dsReport, err := s.garbageCollectDataset(ctx, datasetID, opts)
+		if err != nil {
+			return nil, err
+		}
+		report.Datasets = append(report.Datasets, *dsReport)
+	}
+
+	if len(datasetIDs) > 0 {
+		return report, nil
+	}
+
+	stats, err := s.Vectorstore.Stats(ctx)
+	if errors.Is(err, vserr.ErrNotSupported) {
+		slog.Warn("Vector store can't list its collections, skipping detection of orphaned collections", "error", err)
+		return report, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vector store collections: %w", err)
+	}
+	for _, st := range stats {
+		if slices.Contains(known, st.Name) {
+			continue
+		}
+		// the shadow collection of a dataset holds its re-embedded documents during a re-embedding and is kept if replacing the collection failed
+		if datasetID, ok := strings.CutSuffix(st.Name, shadowCollectionSuffix); ok && slices.Contains(known, datasetID) {
+			continue
+		}
+		report.OrphanedCollections = append(report.OrphanedCollections, st.Name)
+		if opts.DryRun {
+			continue
+		}
+		slog.Info("Removing orphaned collection", "collection", st.Name, "documents", st.Documents)
+		err := s.Vectorstore.RemoveCollection(ctx, st.Name)
+		metrics.ObserveVectorStoreError("remove_collection", err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove orphaned collection %q: %w", st.Name, err)
+		}
+	}
+
+	return report, nil
+}
+
+func (s *Datastore) garbageCollectDataset(ctx context.Context, datasetID string, opts GCOpts) (*DatasetGCReport, error) {
+	report := &DatasetGCReport{Dataset: datasetID}
+
+	ds, err := s.Index.GetDataset(ctx, datasetID, &types.DatasetGetOpts{IncludeFiles: true})
+	if err != nil {
+		return nil, err
+	}
+	if ds == nil {
+		return nil, fmt.Errorf("dataset %q not found", datasetID)
+	}
+
+	docs, err := s.Vectorstore.GetDocuments(ctx, datasetID, nil, nil)
+	if err != nil && !errors.Is(err, vserr.ErrCollectionNotFound) {
+		return nil, fmt.Errorf("failed to get documents of dataset %q from vector store: %w", datasetID, err)
+	}
+	stored := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		stored[doc.ID] = struct{}{}
+	}
+
+	indexed := map[string]struct{}{}
+	var incomplete []types.File
+	for _, file := range ds.Files {
+		missing := false
+		for _, doc := range file.Documents {
+			indexed[doc.ID] = struct{}{}
+			if _, ok := stored[doc.ID]; !ok {
+				missing = true
+			}
+		}
+		if missing {
+			incomplete = append(incomplete, file)
+			report.IncompleteFiles = append(report.IncompleteFiles, file.ID)
+		}
+	}
+
+	for _, doc := range docs {
+		if _, ok := indexed[doc.ID]; !ok {
+			report.OrphanedDocuments = append(report.OrphanedDocuments, doc.ID)
+		}
+	}
+
+	if opts.DryRun || report.Empty() {
+		return report, nil
+	}
+
+	slog.Info("Removing orphaned data", "dataset", datasetID, "orphanedDocuments", len(report.OrphanedDocuments), "incompleteFiles", len(report.IncompleteFiles))
+
+	for _, docID := range report.OrphanedDocuments {
+		if err := s.Vectorstore.RemoveDocument(ctx, docID, datasetID, nil, nil); err != nil {
+			metrics.ObserveVectorStoreError("remove_documents", err)
+			return nil, fmt.Errorf("failed to remove orphaned document %q from vector store: %w", docID, err)
+		}
+	}
+
+	for _, file := range incomplete {
+		for _, doc := range file.Documents {
+			if _, ok := stored[doc.ID]; !ok {
+				continue
+			}
+			if err := s.Vectorstore.RemoveDocument(ctx, doc.ID, datasetID, nil, nil); err != nil {
+				metrics.ObserveVectorStoreError("remove_documents", err)
+				return nil, fmt.Errorf("failed to remove document %q of incomplete file %q from vector store: %w", doc.ID, file.ID, err)
+			}
+		}
+		if err := s.Index.DeleteFile(ctx, datasetID, file.ID); err != nil {
+			return nil, fmt.Errorf("failed to remove incomplete file %q from index: %w", file.ID, err)
+		}
+	}
+
+	return report, nil
+}
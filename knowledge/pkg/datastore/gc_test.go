@@ -0,0 +1,150 @@
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGCIndex only implements the dataset and file operations used by the garbage collection
+type testGCIndex struct {
+	index.Index
+	datasets map[string]*types.Dataset
+}
+
+func (i *testGCIndex) ListDatasets(_ context.Context) ([]types.Dataset, error) {
+	var datasets []types.Dataset
+	for _, ds := range i.datasets {
+		datasets = append(datasets, *ds)
+	}
+	return datasets, nil
+}
+
+func (i *testGCIndex) GetDataset(_ context.Context, datasetID string, _ *types.DatasetGetOpts) (*types.Dataset, error) {
+	return i.datasets[datasetID], nil
+}
+
+func (i *testGCIndex) DeleteFile(_ context.Context, datasetID, fileID string) error {
+	ds := i.datasets[datasetID]
+	for idx, f := range ds.Files {
+		if f.ID == fileID {
+			ds.Files = append(ds.Files[:idx], ds.Files[idx+1:]...)
+			return nil
+		}
+	}
+	return ErrDBFileNotFound
+}
+
+// testGCVectorStore holds the documents per collection, all other methods panic
+type testGCVectorStore struct {
+	vectorstore.VectorStore
+	collections map[string][]vs.Document
+}
+
+func (v *testGCVectorStore) Stats(_ context.Context, _ ...string) ([]vs.CollectionStats, error) {
+	var stats []vs.CollectionStats
+	for name, docs := range v.collections {
+		stats = append(stats, vs.CollectionStats{Name: name, Documents: len(docs)})
+	}
+	return stats, nil
+}
+
+func (v *testGCVectorStore) GetDocuments(_ context.Context, collection string, _ map[string]string, _ []vs.WhereDocument) ([]vs.Document, error) {
+	return v.collections[collection], nil
+}
+
+func (v *testGCVectorStore) RemoveDocument(_ context.Context, documentID string, collection string, _ map[string]string, _ []vs.WhereDocument) error {
+	docs := v.collections[collection]
+	for idx, doc := range docs {
+		if doc.ID == documentID {
+			v.collections[collection] = append(docs[:idx], docs[idx+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (v *testGCVectorStore) RemoveCollection(_ context.Context, collection string) error {
+	delete(v.collections, collection)
+	return nil
+}
+
+func TestGarbageCollect(t *testing.T) {
+	newDatastore := func() (*Datastore, *testGCIndex, *testGCVectorStore) {
+		idx := &testGCIndex{datasets: map[string]*types.Dataset{
+			"ds": {ID: "ds", Files: []types.File{
+				{ID: "complete", Dataset: "ds", Documents: []types.Document{{ID: "1"}, {ID: "2"}}},
+				{ID: "incomplete", Dataset: "ds", Documents: []types.Document{{ID: "3"}, {ID: "4"}}},
+			}},
+		}}
+		store := &testGCVectorStore{collections: map[string][]vs.Document{
+			"ds":         {{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "orphan"}},
+			"ds_reembed": {{ID: "1"}}, // shadow collection of a dataset being re-embedded
+			"gone":       {{ID: "5"}},
+		}}
+		return &Datastore{Index: idx, Vectorstore: store}, idx, store
+	}
+	ctx := context.Background()
+	expected := &GCReport{
+		Datasets:            []DatasetGCReport{{Dataset: "ds", OrphanedDocuments: []string{"orphan"}, IncompleteFiles: []string{"incomplete"}}},
+		OrphanedCollections: []string{"gone"},
+	}
+
+	s, idx, store := newDatastore()
+	report, err := s.GarbageCollect(ctx, GCOpts{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	report.DryRun = false
+	assert.Equal(t, expected, report)
+	assert.Len(t, store.collections, 3)
+	assert.Len(t, idx.datasets["ds"].Files, 2)
+
+	report, err = s.GarbageCollect(ctx, GCOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, report)
+	assert.Equal(t, map[string][]vs.Document{"ds": {{ID: "1"}, {ID: "2"}}, "ds_reembed": {{ID: "1"}}}, store.collections)
+	require.Len(t, idx.datasets["ds"].Files, 1)
+	assert.Equal(t, "complete", idx.datasets["ds"].Files[0].ID)
+
+	// nothing left to collect
+	report, err = s.GarbageCollect(ctx, GCOpts{}, "ds")
+	require.NoError(t, err)
+	assert.Equal(t, &GCReport{Datasets: []DatasetGCReport{{Dataset: "ds"}}}, report)
+
+	_, err = s.GarbageCollect(ctx, GCOpts{}, "unknown")
+	assert.Error(t, err)
+}
+
+// testGCVectorStoreWithoutStats can't list its collections
+type testGCVectorStoreWithoutStats struct {
+	*testGCVectorStore
+}
+
+func (v *testGCVectorStoreWithoutStats) Stats(_ context.Context, _ ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats", vserr.ErrNotSupported)
+}
+
+func TestGarbageCollectWithoutStats(t *testing.T) {
+	idx := &testGCIndex{datasets: map[string]*types.Dataset{
+		"ds": {ID: "ds", Files: []types.File{{ID: "complete", Dataset: "ds", Documents: []types.Document{{ID: "1"}}}}},
+	}}
+	store := &testGCVectorStore{collections: map[string][]vs.Document{
+		"ds":   {{ID: "1"}, {ID: "orphan"}},
+		"gone": {{ID: "2"}},
+	}}
+	s := &Datastore{Index: idx, Vectorstore: &testGCVectorStoreWithoutStats{store}}
+
+	// the datasets are still collected, only the detection of orphaned collections is skipped
+	report, err := s.GarbageCollect(context.Background(), GCOpts{})
+	require.NoError(t, err)
+	assert.Equal(t, &GCReport{Datasets: []DatasetGCReport{{Dataset: "ds", OrphanedDocuments: []string{"orphan"}}}}, report)
+	assert.Equal(t, map[string][]vs.Document{"ds": {{ID: "1"}}, "gone": {{ID: "2"}}}, store.collections)
+}
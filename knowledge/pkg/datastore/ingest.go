@@ -4,20 +4,28 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/filetypes"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/transformers"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/flows"
 	"github.com/obot-platform/tools/knowledge/pkg/index/types"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
+	"github.com/obot-platform/tools/knowledge/pkg/progress"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
@@ -29,16 +37,50 @@ type IngestOpts struct {
 	ExtraMetadata       map[string]any
 	ReuseEmbeddings     bool
 	ReuseFiles          bool
+	Password            string   // Password for encrypted files, unless set per file via the "filePassword" key in the ExtraMetadata
+	IndexContent        bool     // Store document contents in the Index, so they can be found via keyword search
+	BuildVocabulary     bool     // Add the words of the documents to the dataset vocabulary in the Index, e.g. for spell correction
+	Checkpoints         bool     // Record the ingestion progress of the file in the Index, so that an interrupted ingestion can be resumed
+	Tags                []string // Tags of the file, added to the tags of a previously ingested version of it (see TagFile)
 }
 
 // Ingest loads a document from a reader and adds it to the dataset.
 func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename string, content []byte, opts IngestOpts) ([]string, error) {
 	ingestionStart := time.Now()
+	reportProgress(ctx, datasetID, filename, opts, progress.EventFileStarted, 0, "")
+	docIDs, err := s.ingest(ctx, datasetID, filename, content, opts, ingestionStart)
+	metrics.ObserveIngestion(ingestionStart, len(docIDs), err)
+	switch {
+	case errors.Is(err, &documentloader.UnsupportedFileTypeError{}), errors.Is(err, &documentloader.EncryptedFileError{}):
+		reportProgress(ctx, datasetID, filename, opts, progress.EventSkipped, 0, err.Error())
+	case err != nil:
+		reportProgress(ctx, datasetID, filename, opts, progress.EventFailed, 0, err.Error())
+	}
+	return docIDs, err
+}
+
+// reportProgress reports a progress event of the ingestion of the file to the progress reporter from the context, if any
+func reportProgress(ctx context.Context, datasetID, filename string, opts IngestOpts, eventType progress.EventType, chunks int, reason string) {
+	file := filename
+	if opts.FileMetadata != nil && opts.FileMetadata.AbsolutePath != "" {
+		file = opts.FileMetadata.AbsolutePath
+	}
+	progress.Report(ctx, progress.Event{Type: eventType, Dataset: datasetID, File: file, Chunks: chunks, Reason: reason})
+}
+
+func (s *Datastore) ingest(ctx context.Context, datasetID string, filename string, content []byte, opts IngestOpts, ingestionStart time.Time) ([]string, error) {
 	if filename == "" {
 		return nil, fmt.Errorf("filename is required")
 	}
 
+	if s.encryption != nil && (opts.IndexContent || opts.BuildVocabulary) {
+		return nil, fmt.Errorf("storing document contents or vocabulary in the index is not supported with encryption at rest")
+	}
+
 	statusLog := log.FromCtx(ctx).With("phase", "store")
+	report := func(eventType progress.EventType, chunks int, reason string) {
+		reportProgress(ctx, datasetID, filename, opts, eventType, chunks, reason)
+	}
 
 	// Get dataset
 	ds, err := s.GetDataset(ctx, datasetID, nil)
@@ -85,6 +127,11 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 			}
 		}
 
+		// Embeddings of different dimensions can't be searched together, so the dataset's recorded dimensions must match
+		if dsDims, dims := etypes.EmbeddingDimensions(dsEmbeddingProvider), etypes.EmbeddingDimensions(s.EmbeddingModelProvider); s.EmbeddingModelProvider.Name() == ds.EmbeddingsProviderConfig.Type && dsDims != dims {
+			return nil, fmt.Errorf("embedding dimensions mismatch: dataset %q has embeddings with %s, but %s are configured", datasetID, dimensionsString(dsDims), dimensionsString(dims))
+		}
+
 		if os.Getenv("KNOW_STRICT_EMBEDDING_CONFIG_CHECK") != "" {
 			err = embeddings.CompareRequiredFields(s.EmbeddingModelProvider.Config(), dsEmbeddingProvider.Config())
 			if err != nil {
@@ -127,6 +174,19 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 
 	slog.Debug("Loading data", "type", filetype, "filename", filename, "size", len(content))
 
+	// Keep the tags of a previously ingested version of the file, which is replaced on upsert
+	tags := opts.Tags
+	if opts.FileMetadata.AbsolutePath != "" {
+		existing, err := s.Index.FindFileByMetadata(ctx, datasetID, types.FileMetadata{AbsolutePath: opts.FileMetadata.AbsolutePath}, false)
+		if err != nil && !errors.Is(err, types.ErrDBFileNotFound) {
+			return nil, fmt.Errorf("failed to look up existing file: %w", err)
+		}
+		if existing != nil {
+			tags = append(slices.Clone(existing.Tags), tags...)
+		}
+	}
+	tags = types.NormalizeTags(tags...)
+
 	/*
 	 * Exit early if the document is a duplicate
 	 */
@@ -137,19 +197,14 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 	}
 	if isDupe {
 		statusLog.With("status", "skipped").With("reason", "duplicate").Info("Ignoring duplicate document")
+		report(progress.EventSkipped, 0, "duplicate")
 		return nil, nil
 	}
 
 	/*
 	 * Load the ingestion flow - custom or default config or mixture of both
 	 */
-	ingestionFlow := flows.IngestionFlow{}
-	for _, flow := range opts.IngestionFlows {
-		if flow.SupportsFiletype(filetype) {
-			ingestionFlow = flow
-			break
-		}
-	}
+	ingestionFlow := flows.SelectIngestionFlow(opts.IngestionFlows, filetype)
 
 	if err := ingestionFlow.FillDefaults(filetype); err != nil {
 		return nil, err
@@ -160,6 +215,27 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 		return nil, fmt.Errorf("%w (file %q)", &documentloader.UnsupportedFileTypeError{FileType: filetype}, opts.FileMetadata.AbsolutePath)
 	}
 
+	/*
+	 * Handle encrypted (password-protected) files
+	 */
+	encrypted := documentloader.IsEncrypted(content)
+	if encrypted {
+		password := opts.Password
+		if pw, ok := opts.ExtraMetadata[documentloader.MetadataKeyFilePassword].(string); ok && pw != "" {
+			password = pw // per-file password takes precedence
+		}
+		if password == "" {
+			statusLog.With("status", "skipped").With("reason", "encrypted").Info("Encrypted file, skipped (no password provided)")
+			return nil, fmt.Errorf("%w (file %q)", &documentloader.EncryptedFileError{FileType: filetype}, opts.FileMetadata.AbsolutePath)
+		}
+		if !documentloader.CanDecrypt(content) {
+			statusLog.With("status", "failed").With("reason", "encrypted").Error("Encrypted file, decryption not supported for this file type")
+			return nil, fmt.Errorf("%w (file %q)", &dstypes.DecryptionNotSupportedError{FileType: filetype}, opts.FileMetadata.AbsolutePath)
+		}
+		slog.Debug("File is encrypted, using provided password", "filename", filename)
+		ctx = dstypes.PasswordToCtx(ctx, password)
+	}
+
 	start := time.Now()
 	checksum := sha256.Sum256(content)
 	slog.Debug("File checksum calculated", "size", len(content), "duration", time.Since(start))
@@ -168,15 +244,44 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 	// Mandatory Transformation: Add filename to metadata -> append extraMetadata, but do not override filename or absPath
 	metadata := map[string]any{"filename": filename, "absPath": opts.FileMetadata.AbsolutePath, "fileSize": opts.FileMetadata.Size, "embeddingModel": s.EmbeddingModelProvider.EmbeddingModelName(), "fileChecksum": fmt.Sprintf("%x", checksum)}
 	for k, v := range opts.ExtraMetadata {
+		if k == documentloader.MetadataKeyFilePassword {
+			continue // never store passwords
+		}
 		if _, ok := metadata[k]; !ok {
 			metadata[k] = v
 		}
 	}
 
+	if len(tags) > 0 {
+		metadata[MetadataKeyFileTags] = tags
+	}
+
+	// Resume an interrupted ingestion of the file from the last completed stage
+	var docs []vs.Document
+	var resumedStage types.IngestionStage
+	checkpoints := opts.Checkpoints && opts.FileMetadata.AbsolutePath != ""
+	if checkpoints {
+		if cp, cpDocs := s.loadCheckpoint(ctx, datasetID, opts.FileMetadata); cp != nil {
+			switch cp.Stage {
+			case types.IngestionStageIndexed:
+				statusLog.With("status", "skipped").With("reason", "checkpoint").Info("File was already ingested before the interruption")
+				report(progress.EventSkipped, 0, "already ingested before the interruption")
+				return nil, nil
+			case types.IngestionStageLoaded, types.IngestionStageEmbedded:
+				if len(cpDocs) > 0 {
+					docs, resumedStage = cpDocs, cp.Stage
+					statusLog.Info("Resuming ingestion from checkpoint", "stage", cp.Stage, "num_documents", len(docs))
+				}
+			}
+		}
+		if resumedStage == "" {
+			s.saveCheckpoint(ctx, datasetID, opts.FileMetadata, types.IngestionStagePending, nil)
+		}
+	}
+
 	// Reuse existing file if possible
 	// TODO: this should honor textsplitter and loading settings somehow to allow for changing them and not use the existing embeddings and documents data
-	var docs []vs.Document
-	if opts.ReuseFiles {
+	if opts.ReuseFiles && resumedStage == "" {
 		slog.Info("Checking if existing file can be reused", "checksum", opts.FileMetadata.Checksum)
 
 		fs, err := s.Index.FindFilesByMetadata(ctx, "", types.FileMetadata{Checksum: opts.FileMetadata.Checksum}, false)
@@ -238,10 +343,49 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 
 	em := &transformers.ExtraMetadata{Metadata: metadata}
 	ingestionFlow.Transformations = append(ingestionFlow.Transformations, em)
+	for i := range ingestionFlow.LanguageFlows {
+		ingestionFlow.LanguageFlows[i].Transformations = append(ingestionFlow.LanguageFlows[i].Transformations, em)
+	}
+
+	// Embedding input preprocessing only affects the text sent to the embedding model, not the stored content
+	ctx = preprocessing.ToCtx(ctx, ingestionFlow.EmbeddingPreprocessing)
+
+	// e.g. the contextual header transformer falls back to the filename as title
+	ctx = dstypes.FilenameToCtx(ctx, filename)
+
+	// All requests to the embedding model provider share the rate limit, including those of the ingestion flow
+	ctx = ratelimit.ToCtx(ctx, s.embeddingLimiter)
+
+	// e.g. the semantic text splitter embeds sentences to find the split points and the summarizer and contextual header transformers may embed the chunks themselves
+	if ingestionFlow.NeedsEmbeddingFunc() {
+		embeddingFunc, err := s.EmbeddingModelProvider.EmbeddingFunc()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embedding function for ingestion flow: %w", err)
+		}
+		embeddingFunc = metricsEmbeddingFunc(s.EmbeddingModelProvider.Name(), embeddingFunc)
+		ctx = dstypes.EmbeddingFuncToCtx(ctx, embeddingFunc)
+
+		batchEmbeddingFunc, batchSize := s.batchEmbeddingFunc, s.batchSize
+		if batchEmbeddingFunc == nil {
+			batchEmbeddingFunc, batchSize = singleEmbeddingBatchFunc(embeddingFunc, encryptedEmbeddingConcurrency), encryptedEmbeddingConcurrency
+		}
+		ctx = dstypes.EmbedDocumentsFuncToCtx(ctx, func(ctx context.Context, docs []vs.Document) error {
+			return embedBatches(ctx, batchEmbeddingFunc, batchSize, docs)
+		})
+	}
 
 	// Only run ingestion flow if we're not re-using the details of an existing file and its documents
 	if len(docs) == 0 {
 		docs, err = ingestionFlow.Run(ctx, bytes.NewReader(content), filename)
+		if errors.Is(err, &dstypes.DecryptionNotSupportedError{}) {
+			statusLog.With("status", "failed").With("reason", "encrypted").Error("Failed to load encrypted file", "error", err)
+			return nil, fmt.Errorf("failed to load encrypted file %q: %w", opts.FileMetadata.AbsolutePath, err)
+		}
+		if err != nil && encrypted {
+			// not an EncryptedFileError, as a password was provided, so the file must not be skipped silently
+			statusLog.With("status", "failed").With("reason", "encrypted").Error("Failed to load encrypted file - wrong password or unsupported encryption", "error", err)
+			return nil, fmt.Errorf("failed to load encrypted file %q - wrong password or unsupported encryption: %w", opts.FileMetadata.AbsolutePath, err)
+		}
 		if err != nil {
 			statusLog.With("status", "failed").Error("Ingestion Flow failed", "error", err)
 			return nil, fmt.Errorf("ingestion flow failed for file %q: %w", filename, err)
@@ -249,11 +393,12 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 
 		if len(docs) == 0 {
 			statusLog.With("status", "skipped").Info("Ingested document", "num_documents", 0)
+			report(progress.EventSkipped, 0, "no documents")
 			return nil, nil
 		}
-	} else {
+	} else if resumedStage == "" {
 		// We reused documents, so we only need to run the transformers on them, e.g. to add the metadata
-		docs, err = ingestionFlow.RunTransformers(ctx, docs, statusLog)
+		docs, err = ingestionFlow.ForLanguage(ctx, docs).RunTransformers(ctx, docs, statusLog)
 		if err != nil {
 			statusLog.With("status", "failed").Error("Failed to run transformers on reused documents", "error", err)
 			return nil, fmt.Errorf("failed to run transformers on reused documents: %w", err)
@@ -262,6 +407,11 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 
 	// Sort documents
 	vs.SortAndEnsureDocIndex(docs)
+	report(progress.EventChunksProduced, len(docs), "")
+
+	if checkpoints && resumedStage == "" {
+		s.saveCheckpoint(ctx, datasetID, opts.FileMetadata, types.IngestionStageLoaded, docs)
+	}
 
 	// Before adding doc, we need to remove the existing documents for duplicates or old contents
 	statusLog.With("component", "vectorstore").With("action", "remove").Debug("Removing existing documents")
@@ -269,6 +419,7 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 		"absPath": opts.FileMetadata.AbsolutePath,
 	}
 	if err := s.Vectorstore.RemoveDocument(ctx, "", datasetID, where, nil); err != nil {
+		metrics.ObserveVectorStoreError("remove_documents", err)
 		statusLog.With("status", "failed").With("component", "vectorstore").Error("Failed to remove existing documents", "error", err)
 		return nil, err
 	}
@@ -336,14 +487,45 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 		}
 	}
 
+	// With late chunking, all chunks of the file are embedded together before they're added to the vectorstore
+	if lc, ok := s.EmbeddingModelProvider.(etypes.LateChunkingEmbeddingModelProvider); ok && lc.LateChunkingEnabled() {
+		statusLog.Debug("Embedding documents with late chunking")
+		if err := embedLateChunks(ctx, lc, docs); err != nil {
+			statusLog.With("status", "failed").Error("Failed to embed documents with late chunking", "error", err)
+			return nil, fmt.Errorf("failed to embed documents from file %q with late chunking: %w", opts.FileMetadata.AbsolutePath, err)
+		}
+	} else if s.batchEmbeddingFunc != nil {
+		// Otherwise, the documents are embedded in batches instead of one request per document, if supported by the provider
+		statusLog.Debug("Embedding documents in batches", "batchSize", s.batchSize)
+		startTime := time.Now()
+		if err := embedBatches(ctx, s.batchEmbeddingFunc, s.batchSize, docs); err != nil {
+			statusLog.With("status", "failed").Error("Failed to embed documents in batches", "error", err)
+			return nil, fmt.Errorf("failed to embed documents from file %q: %w", opts.FileMetadata.AbsolutePath, err)
+		}
+		statusLog.Debug("Embedded documents in batches", "duration", time.Since(startTime))
+	}
+
+	// Without late chunking, batches or reused embeddings, the documents are only embedded when they're added to the vectorstore
+	embedded := !slices.ContainsFunc(docs, func(doc vs.Document) bool { return len(doc.Embedding) == 0 })
+	if embedded {
+		report(progress.EventEmbedded, len(docs), "")
+		if checkpoints && resumedStage != types.IngestionStageEmbedded {
+			s.saveCheckpoint(ctx, datasetID, opts.FileMetadata, types.IngestionStageEmbedded, docs)
+		}
+	}
+
 	statusLog.Debug("Adding documents to vectorstore")
 	startTime := time.Now()
 	docIDs, err := s.Vectorstore.AddDocuments(ctx, docs, datasetID)
+	metrics.ObserveVectorStoreError("add_documents", err)
 	if err != nil {
 		statusLog.With("component", "vectorstore").With("status", "failed").With("error", err.Error()).Error("Failed to add documents")
 		return nil, fmt.Errorf("failed to add documents from file %q: %w", opts.FileMetadata.AbsolutePath, err)
 	}
 	statusLog.Debug("Added documents to vectorstore", "duration", time.Since(startTime))
+	if !embedded {
+		report(progress.EventEmbedded, len(docIDs), "")
+	}
 
 	// Record file and documents in database
 	dbDocs := make([]types.Document, len(docIDs))
@@ -354,12 +536,17 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 			Dataset: datasetID,
 			Index:   idx,
 		}
+		if opts.IndexContent {
+			dbDocs[idx].Content = docs[idx].Content
+			dbDocs[idx].Metadata = docs[idx].Metadata
+		}
 	}
 
 	dbFile := types.File{
 		ID:        fileID,
 		Dataset:   datasetID,
 		Documents: dbDocs,
+		Tags:      tags,
 		FileMetadata: types.FileMetadata{
 			Name: filename,
 		},
@@ -370,6 +557,7 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 		dbFile.FileMetadata.Size = opts.FileMetadata.Size
 		dbFile.FileMetadata.ModifiedAt = opts.FileMetadata.ModifiedAt
 		dbFile.FileMetadata.Checksum = opts.FileMetadata.Checksum
+		dbFile.FileMetadata.ETag = opts.FileMetadata.ETag
 	}
 
 	iLog := statusLog.With("component", "index")
@@ -382,7 +570,93 @@ func (s *Datastore) Ingest(ctx context.Context, datasetID string, filename strin
 	}
 	iLog.Info("Created file in index", "duration", time.Since(startTime))
 
+	if opts.BuildVocabulary {
+		frequencies := map[string]int{}
+		for _, doc := range docs {
+			types.CountVocabularyTerms(doc.Content, frequencies)
+		}
+		if err := s.Index.AddVocabulary(ctx, datasetID, frequencies); err != nil {
+			iLog.With("status", "failed").With("error", err).Error("Failed to add vocabulary to Index")
+			return nil, err
+		}
+		iLog.Debug("Added vocabulary to index", "num_terms", len(frequencies))
+	}
+
+	if checkpoints {
+		s.saveCheckpoint(ctx, datasetID, opts.FileMetadata, types.IngestionStageIndexed, nil)
+	}
+
+	report(progress.EventStored, len(docIDs), "")
 	statusLog.With("status", "finished").Info("Ingested document", "num_documents", len(docIDs), "absolute_path", dbFile.FileMetadata.AbsolutePath, "ingestionTime", time.Since(ingestionStart))
 
 	return docIDs, nil
 }
+
+// embedLateChunks embeds the documents (chunks of a single file) which don't have an embedding yet all at once,
+// applying the embedding input preprocessing from the context
+func embedLateChunks(ctx context.Context, provider etypes.LateChunkingEmbeddingModelProvider, docs []vs.Document) error {
+	var idx []int
+	var chunks []string
+	opts := preprocessing.FromCtx(ctx)
+	for i, doc := range docs {
+		if len(doc.Embedding) > 0 {
+			continue
+		}
+		idx = append(idx, i)
+		chunks = append(chunks, opts.Process(doc.Content))
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	startTime := time.Now()
+	embeddings, err := provider.EmbedChunks(ctx, chunks)
+	metrics.ObserveEmbedding(provider.Name(), chunks, startTime, err)
+	if err != nil {
+		return err
+	}
+	if len(embeddings) != len(chunks) {
+		return fmt.Errorf("got %d embeddings for %d chunks", len(embeddings), len(chunks))
+	}
+	for j, i := range idx {
+		docs[i].Embedding = embeddings[j]
+	}
+	return nil
+}
+
+// embedBatches embeds the documents which don't have an embedding yet in batches of up to batchSize documents,
+// applying the embedding input preprocessing from the context
+func embedBatches(ctx context.Context, batchEmbeddingFunc etypes.BatchEmbeddingFunc, batchSize int, docs []vs.Document) error {
+	var idx []int
+	var texts []string
+	opts := preprocessing.FromCtx(ctx)
+	for i, doc := range docs {
+		if len(doc.Embedding) > 0 {
+			continue
+		}
+		idx = append(idx, i)
+		texts = append(texts, opts.Process(doc.Content))
+	}
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		embeddings, err := batchEmbeddingFunc(ctx, texts[start:end])
+		if err != nil {
+			return err
+		}
+		if len(embeddings) != end-start {
+			return fmt.Errorf("got %d embeddings for %d documents", len(embeddings), end-start)
+		}
+		for j, i := range idx[start:end] {
+			docs[i].Embedding = embeddings[j]
+		}
+	}
+	return nil
+}
+
+func dimensionsString(dimensions int) string {
+	if dimensions == 0 {
+		return "the model's default dimensions"
+	}
+	return fmt.Sprintf("%d dimensions", dimensions)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/transformers"
 	"github.com/obot-platform/tools/knowledge/pkg/flows"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -42,3 +44,29 @@ func TestExtractPDF(t *testing.T) {
 	})
 	require.NoError(t, err, "filepath.WalkDir() error = %v", err)
 }
+
+func TestEmbedBatches(t *testing.T) {
+	var batches [][]string
+	batchEmbeddingFunc := func(_ context.Context, texts []string) ([][]float32, error) {
+		batches = append(batches, texts)
+		embeddings := make([][]float32, len(texts))
+		for i, text := range texts {
+			embeddings[i] = []float32{float32(len(text))}
+		}
+		return embeddings, nil
+	}
+
+	docs := []vs.Document{
+		{Content: "a"},
+		{Content: "bb", Embedding: []float32{42}}, // already embedded, e.g. reused from an earlier ingestion
+		{Content: "ccc"},
+		{Content: "dddd"},
+		{Content: "eeeee"},
+	}
+
+	require.NoError(t, embedBatches(context.Background(), batchEmbeddingFunc, 2, docs))
+	assert.Equal(t, [][]string{{"a", "ccc"}, {"dddd", "eeeee"}}, batches)
+	for i, want := range [][]float32{{1}, {42}, {3}, {4}, {5}} {
+		assert.Equal(t, want, docs[i].Embedding)
+	}
+}
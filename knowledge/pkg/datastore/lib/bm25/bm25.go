@@ -1,6 +1,7 @@
 package bm25
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
@@ -13,19 +14,45 @@ const (
 	DefaultB  = 0.75
 )
 
-func BM25Run(docs []types.Document, query string, k1, b float64, cleanStopwords []string) ([]float64, error) {
-	return Score(BuildCorpus(docs, cleanStopwords), query, k1, b)
+func BM25Run(docs []types.Document, query string, k1, b float64, cleanStopwords, metadataFields []string) ([]float64, error) {
+	return Score(BuildCorpus(docs, cleanStopwords, metadataFields), query, k1, b)
 }
 
-func BuildCorpus(docs []types.Document, cleanStopwords []string) []string {
+// BuildCorpus returns the texts of the documents to score, i.e. their content followed by the values of the given metadata fields
+func BuildCorpus(docs []types.Document, cleanStopwords, metadataFields []string) []string {
 	corpus := make([]string, len(docs))
 	for i, doc := range docs {
 		content := doc.Content
+		for _, field := range metadataFields {
+			if v := metadataText(doc.Metadata[field]); v != "" {
+				content += " " + v
+			}
+		}
 		corpus[i] = CleanStopwords(content, cleanStopwords)
 	}
 	return corpus
 }
 
+// metadataText returns a metadata value as text - lists (e.g. the keyphrases) are joined by spaces
+func metadataText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, " ")
+	case []any:
+		s := make([]string, 0, len(v))
+		for _, e := range v {
+			s = append(s, metadataText(e))
+		}
+		return strings.Join(s, " ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 func CleanStopwords(content string, languages []string) string {
 	if len(languages) > 0 {
 		langCodes := languages
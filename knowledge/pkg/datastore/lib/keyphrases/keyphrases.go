@@ -0,0 +1,149 @@
+// Package keyphrases extracts the key phrases of a text with RAKE (Rapid Automatic Keyword Extraction),
+// a statistical method that needs neither a model nor training data, only a list of stop words.
+package keyphrases
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jmcarbo/stopwords"
+)
+
+// DefaultMaxWords is the default maximum number of words of a key phrase
+const DefaultMaxWords = 3
+
+// Keyphrase is a candidate phrase with its RAKE score
+type Keyphrase struct {
+	Phrase string
+	Score  float64
+}
+
+// Extract returns the (at most) limit highest scoring key phrases of the text, best first.
+// Candidate phrases are sequences of words that are delimited by stop words of the language (ISO 639-1 code) and punctuation.
+// Phrases with more than maxWords words are dropped, as they're rarely useful keywords.
+// The method relies on spaces between words, so it returns nothing for e.g. Chinese or Japanese texts.
+func Extract(text, language string, limit, maxWords int) []Keyphrase {
+	if maxWords <= 0 {
+		maxWords = DefaultMaxWords
+	}
+
+	isStopword := stopwordFunc(language)
+
+	var (
+		candidates [][]string
+		phrase     []string
+	)
+	endPhrase := func() {
+		if len(phrase) > 0 && len(phrase) <= maxWords {
+			candidates = append(candidates, phrase)
+		}
+		phrase = nil
+	}
+
+	var word strings.Builder
+	endWord := func() {
+		if word.Len() == 0 {
+			return
+		}
+		w := strings.Trim(word.String(), "-_'")
+		word.Reset()
+		if len([]rune(w)) < 2 || isNumber(w) || isStopword(w) {
+			endPhrase()
+			return
+		}
+		phrase = append(phrase, w)
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '-' || r == '_' || r == '\'':
+			word.WriteRune(r)
+		case unicode.IsSpace(r):
+			endWord()
+		default:
+			// punctuation delimits phrases
+			endWord()
+			endPhrase()
+		}
+	}
+	endWord()
+	endPhrase()
+
+	return score(candidates, limit)
+}
+
+// score ranks the unique candidates by the sum of their word scores, which are the ratio of a word's degree
+// (number of co-occurring words, including itself) to its frequency, so words that occur in longer phrases score higher
+func score(candidates [][]string, limit int) []Keyphrase {
+	freq := make(map[string]int)
+	degree := make(map[string]int)
+	for _, c := range candidates {
+		for _, w := range c {
+			freq[w]++
+			degree[w] += len(c)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(candidates))
+	var keyphrases []Keyphrase
+	for _, c := range candidates {
+		p := strings.Join(c, " ")
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+
+		var s float64
+		for _, w := range c {
+			s += float64(degree[w]) / float64(freq[w])
+		}
+		keyphrases = append(keyphrases, Keyphrase{Phrase: p, Score: s})
+	}
+
+	// stable, so equally scored phrases keep the order of their first occurrence
+	slices.SortStableFunc(keyphrases, func(a, b Keyphrase) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	if limit > 0 && len(keyphrases) > limit {
+		keyphrases = keyphrases[:limit]
+	}
+	return keyphrases
+}
+
+// languages with a stop words list in stopwords.CleanString
+var languages = []string{"ar", "bg", "ca", "cs", "da", "de", "el", "en", "es", "fa", "fr", "fi", "hu", "id", "it", "ja", "km", "lv", "nl", "no", "pl", "pt", "ro", "ru", "sk", "sv", "th", "tr"}
+
+// stopwordFunc returns a function that reports whether a (lowercase) word is a stop word of the language,
+// falling back to English for unsupported languages
+func stopwordFunc(language string) func(string) bool {
+	if !slices.Contains(languages, language) {
+		language = "en"
+	}
+	cache := make(map[string]bool)
+	return func(w string) bool {
+		if s, ok := cache[w]; ok {
+			return s
+		}
+		s := strings.TrimSpace(stopwords.CleanString(w, language, false)) == ""
+		cache[w] = s
+		return s
+	}
+}
+
+func isNumber(w string) bool {
+	for _, r := range w {
+		if !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
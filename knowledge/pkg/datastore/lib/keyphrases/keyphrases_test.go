@@ -0,0 +1,41 @@
+package keyphrases
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	text := `Compatibility of systems of linear constraints over the set of natural numbers.
+Criteria of compatibility of a system of linear Diophantine equations, strict inequations, and nonstrict inequations are considered.
+Upper bounds for components of a minimal set of solutions and algorithms of construction of minimal generating sets of solutions
+for all types of systems are given.`
+
+	keyphrases := Extract(text, "en", 5, 3)
+	require.Len(t, keyphrases, 5)
+
+	var phrases []string
+	for _, k := range keyphrases {
+		phrases = append(phrases, k.Phrase)
+	}
+	require.Equal(t, []string{"linear diophantine equations", "minimal generating sets", "linear constraints", "natural numbers", "strict inequations"}, phrases)
+	require.Equal(t, 8.5, keyphrases[0].Score)
+
+	// phrases with too many words are dropped
+	for _, k := range Extract(text, "en", 0, 2) {
+		require.NotEqual(t, "linear diophantine equations", k.Phrase)
+	}
+}
+
+func TestExtractLanguage(t *testing.T) {
+	text := "Die Installation des Kubernetes Clusters dauert etwa zehn Minuten. Danach ist der Kubernetes Cluster einsatzbereit."
+	var phrases []string
+	for _, k := range Extract(text, "de", 0, 0) {
+		phrases = append(phrases, k.Phrase)
+	}
+	require.Equal(t, []string{"kubernetes clusters dauert", "kubernetes cluster einsatzbereit", "installation", "minuten"}, phrases)
+
+	require.Empty(t, Extract("", "en", 10, 0))
+	require.Empty(t, Extract("the and of 12345", "xx", 10, 0))
+}
@@ -0,0 +1,116 @@
+// Package language detects the language of a text by its script and, for scripts shared by multiple languages, its stop words
+package language
+
+import (
+	"unicode"
+
+	"github.com/jmcarbo/stopwords"
+)
+
+// Undetermined is returned if the language of a text could not be detected
+const Undetermined = "und"
+
+// sampleSize is the number of runes of the text that are considered, which is plenty to detect the language
+const sampleSize = 8192
+
+// Languages that are detected by their stop words, by script - in order of preference if multiple languages match equally well
+var (
+	latinLanguages    = []string{"en", "de", "fr", "es", "it", "pt", "nl", "sv", "no", "da", "fi", "pl", "cs", "sk", "hu", "ro", "tr", "ca", "id", "lv", "zu"}
+	cyrillicLanguages = []string{"ru", "bg"}
+	arabicLanguages   = []string{"ar", "fa"}
+)
+
+// scripts whose languages are identified by the script alone
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Khmer, "km"},
+}
+
+// Detect returns the ISO 639-1 code of the language of the text, e.g. "en" or "ja", or Undetermined
+func Detect(text string) string {
+	var letters, latin, cyrillic, arabic, han, kana int
+	scripts := make([]int, len(scriptLanguages))
+
+	n := 0
+	for _, r := range text {
+		if n++; n > sampleSize {
+			break
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		default:
+			for i, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					scripts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return Undetermined
+	}
+
+	// Chinese, Japanese and Korean texts often contain some latin words, e.g. names or code, so they win at a lower share
+	cjk := han + kana + scripts[0]
+	if cjk*3 >= letters {
+		switch {
+		case scripts[0] >= han+kana:
+			return "ko"
+		case kana*10 >= han+kana:
+			// Japanese texts are written in kanji (han) and kana, Chinese texts in han only
+			return "ja"
+		default:
+			return "zh"
+		}
+	}
+
+	best, bestCount := "", 0
+	for i, s := range scriptLanguages[1:] {
+		if scripts[i+1] > bestCount {
+			best, bestCount = s.language, scripts[i+1]
+		}
+	}
+	switch {
+	case latin >= bestCount && latin >= cyrillic && latin >= arabic:
+		return byStopwords(text, latinLanguages)
+	case cyrillic >= bestCount && cyrillic >= arabic:
+		return byStopwords(text, cyrillicLanguages)
+	case arabic >= bestCount:
+		return byStopwords(text, arabicLanguages)
+	default:
+		return best
+	}
+}
+
+// byStopwords returns the language with the most stop words in the text, or Undetermined if no stop words are found
+func byStopwords(text string, languages []string) string {
+	if r := []rune(text); len(r) > sampleSize {
+		text = string(r[:sampleSize])
+	}
+	_, guessed, count, _ := stopwords.GetLanguage([]byte(text), languages)
+	if count == 0 || len(guessed) == 0 {
+		return Undetermined
+	}
+	return guessed[0]
+}
@@ -0,0 +1,26 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	for text, expected := range map[string]string{
+		"The quick brown fox jumps over the lazy dog, and then it runs into the forest.":    "en",
+		"Der schnelle braune Fuchs springt über den faulen Hund und läuft in den Wald.":     "de",
+		"Le renard brun rapide saute par-dessus le chien paresseux et court dans la forêt.": "fr",
+		"El rápido zorro marrón salta sobre el perro perezoso y corre hacia el bosque.":     "es",
+		"Быстрая коричневая лиса прыгает через ленивую собаку и убегает в лес.":             "ru",
+		"素早い茶色の狐がのろまな犬を飛び越えて、森の中へ走っていきます。":                                                  "ja",
+		"敏捷的棕色狐狸跳过了懒狗，然后跑进了森林。":                                                             "zh",
+		"빠른 갈색 여우가 게으른 개를 뛰어넘어 숲으로 달려갑니다.":                                                  "ko",
+		"Η γρήγορη καφέ αλεπού πηδά πάνω από τον τεμπέλη σκύλο.":                            "el",
+		"knowledge ingest に Kubernetes の設定ファイルを渡してください。":                                    "ja",
+		"12345 67890": Undetermined,
+		"":            Undetermined,
+	} {
+		require.Equal(t, expected, Detect(text), text)
+	}
+}
@@ -0,0 +1,141 @@
+// Package redact detects and masks personally identifiable information (PII) in text
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	TypeEmail      = "email"
+	TypePhone      = "phone"
+	TypeCreditCard = "credit_card"
+)
+
+var DefaultTypes = []string{TypeEmail, TypePhone, TypeCreditCard}
+
+var builtinPatterns = map[string]*regexp.Regexp{
+	TypeEmail: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	// international or local phone numbers with optional separators, e.g. +1 (555) 123-4567, 0049 30 1234567
+	TypePhone: regexp.MustCompile(`(?:\+|\b00)?\d{1,3}?[\s.\-]?\(?\d{2,4}\)?(?:[\s.\-]?\d{2,4}){2,4}\b`),
+	// 13-19 digits, optionally grouped by spaces or dashes - validated via Luhn checksum
+	TypeCreditCard: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+}
+
+// phone numbers have 7-15 digits (E.164) - shorter ones are likely years or amounts
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+type pattern struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// Redactor masks all matches of its patterns in a text
+type Redactor struct {
+	patterns    []pattern
+	replacement string
+}
+
+// New creates a Redactor for the given builtin types (email, phone, credit_card) and custom patterns (name -> regex).
+// The replacement may contain %s, which is replaced by the uppercased pattern name, e.g. [REDACTED_EMAIL].
+func New(types []string, custom map[string]string, replacement string) (*Redactor, error) {
+	r := &Redactor{replacement: replacement}
+	if r.replacement == "" {
+		r.replacement = "[REDACTED_%s]"
+	}
+
+	// credit cards first, as they would otherwise be (partially) matched as phone numbers
+	for _, t := range []string{TypeCreditCard, TypeEmail, TypePhone} {
+		if !slices.Contains(types, t) {
+			continue
+		}
+		p := pattern{name: t, re: builtinPatterns[t]}
+		switch t {
+		case TypeCreditCard:
+			p.valid = luhnValid
+		case TypePhone:
+			p.valid = phoneValid
+		}
+		r.patterns = append(r.patterns, p)
+	}
+	for _, t := range types {
+		if _, ok := builtinPatterns[t]; !ok {
+			return nil, fmt.Errorf("unknown PII type %q, must be one of %s", t, strings.Join(DefaultTypes, ", "))
+		}
+	}
+
+	for name, expr := range custom {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", name, err)
+		}
+		r.patterns = append(r.patterns, pattern{name: name, re: re})
+	}
+
+	return r, nil
+}
+
+// Redact returns the text with all PII masked and the number of replacements per pattern name
+func (r *Redactor) Redact(text string) (string, map[string]int) {
+	counts := map[string]int{}
+	for _, p := range r.patterns {
+		text = p.re.ReplaceAllStringFunc(text, func(m string) string {
+			if p.valid != nil && !p.valid(m) {
+				return m
+			}
+			counts[p.name]++
+			if strings.Contains(r.replacement, "%s") {
+				return fmt.Sprintf(r.replacement, strings.ToUpper(p.name))
+			}
+			return r.replacement
+		})
+	}
+	return text, counts
+}
+
+// phoneValid rejects plain digit sequences (e.g. order numbers) - phone numbers must have an international prefix or separators
+func phoneValid(s string) bool {
+	digits := countDigits(s)
+	if digits < minPhoneDigits || digits > maxPhoneDigits {
+		return false
+	}
+	return strings.HasPrefix(s, "+") || strings.HasPrefix(s, "00") || strings.ContainsAny(s, " .-()")
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// luhnValid checks the Luhn checksum of the digits in s
+func luhnValid(s string) bool {
+	sum := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,20 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := New(DefaultTypes, map[string]string{"employee_id": `EMP-\d{6}`}, "")
+	require.NoError(t, err)
+
+	text := "Contact john.doe@example.com or +1 (555) 123-4567. Card: 4111 1111 1111 1111, order 1234567890123 from 2024. Employee EMP-123456."
+	redacted, counts := r.Redact(text)
+	require.Equal(t, "Contact [REDACTED_EMAIL] or [REDACTED_PHONE]. Card: [REDACTED_CREDIT_CARD], order 1234567890123 from 2024. Employee [REDACTED_EMPLOYEE_ID].", redacted)
+	require.Equal(t, map[string]int{TypeEmail: 1, TypePhone: 1, TypeCreditCard: 1, "employee_id": 1}, counts)
+
+	_, err = New([]string{"ssn"}, nil, "")
+	require.Error(t, err)
+}
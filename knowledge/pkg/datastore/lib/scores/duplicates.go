@@ -0,0 +1,60 @@
+package scores
+
+import (
+	"crypto/sha256"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// ContentHash returns a hash of the document content, ignoring case and whitespace differences
+func ContentHash(content string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.Join(strings.Fields(strings.ToLower(content)), " ")))
+}
+
+// RemoveNearDuplicates removes documents that are exact (by content hash) or near duplicates of a document earlier in the list,
+// so the first (usually best ranked) occurrence is kept.
+// Two documents are near duplicates if the cosine similarity of their embeddings is at least embeddingThreshold
+// or, if any of them has no embedding, if the Jaccard similarity of their tokens is at least tokenThreshold.
+// A threshold <= 0 disables the respective check.
+func RemoveNearDuplicates(docs []vs.Document, embeddingThreshold, tokenThreshold float32) []vs.Document {
+	seen := make(map[[sha256.Size]byte]struct{}, len(docs))
+	kept := make([]vs.Document, 0, len(docs))
+	keptTokens := make([]map[string]struct{}, 0, len(docs))
+
+	for _, doc := range docs {
+		hash := ContentHash(doc.Content)
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+
+		var tokens map[string]struct{}
+		if tokenThreshold > 0 {
+			tokens = Tokens(doc.Content)
+		}
+
+		duplicate := false
+		for i, k := range kept {
+			if len(doc.Embedding) > 0 && len(k.Embedding) > 0 {
+				if embeddingThreshold > 0 && CosineSimilarity(doc.Embedding, k.Embedding) >= embeddingThreshold {
+					duplicate = true
+					break
+				}
+				continue
+			}
+			if tokenThreshold > 0 && JaccardSimilarity(tokens, keptTokens[i]) >= tokenThreshold {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		seen[hash] = struct{}{}
+		kept = append(kept, doc)
+		keptTokens = append(keptTokens, tokens)
+	}
+
+	return kept
+}
@@ -0,0 +1,35 @@
+package scores
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveNearDuplicates(t *testing.T) {
+	docs := []vs.Document{
+		{ID: "1", Content: "The quick brown fox jumps over the lazy dog."},
+		{ID: "2", Content: "the quick  brown fox jumps over the lazy dog."}, // exact duplicate after normalization
+		{ID: "3", Content: "The quick brown fox jumps over the lazy dog. And then"},
+		{ID: "4", Content: "Something completely different"},
+	}
+
+	ids := func(docs []vs.Document) []string {
+		var res []string
+		for _, d := range docs {
+			res = append(res, d.ID)
+		}
+		return res
+	}
+
+	require.Equal(t, []string{"1", "3", "4"}, ids(RemoveNearDuplicates(docs, 0, 0)))
+	require.Equal(t, []string{"1", "4"}, ids(RemoveNearDuplicates(docs, 0, 0.8)))
+
+	// embeddings take precedence over the tokens if both documents have one
+	docs[2].Embedding = []float32{1, 0}
+	docs[0].Embedding = []float32{0, 1}
+	require.Equal(t, []string{"1", "3", "4"}, ids(RemoveNearDuplicates(docs, 0.95, 0.8)))
+	docs[2].Embedding = []float32{0.1, 1}
+	require.Equal(t, []string{"1", "4"}, ids(RemoveNearDuplicates(docs, 0.95, 0.8)))
+}
@@ -0,0 +1,78 @@
+package scores
+
+import (
+	"math"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// MaximalMarginalRelevance selects up to k documents from the candidates, balancing relevance to the query (the documents' similarity scores)
+// against similarity to the documents selected so far: argmax(lambda * relevance - (1-lambda) * max(similarity to selected)).
+// lambda = 1 is pure relevance ranking, lambda = 0 is maximum diversity.
+// The similarity between documents is the cosine similarity of their embeddings or, if any candidate lacks an embedding,
+// the Jaccard similarity of their words.
+func MaximalMarginalRelevance(candidates []vs.Document, k int, lambda float32) []vs.Document {
+	if k <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	k = min(k, len(candidates))
+	lambda = max(0, min(1, lambda))
+
+	useEmbeddings := true
+	for _, c := range candidates {
+		if len(c.Embedding) == 0 {
+			useEmbeddings = false
+			break
+		}
+	}
+
+	var tokens []map[string]struct{}
+	if !useEmbeddings {
+		tokens = make([]map[string]struct{}, len(candidates))
+		for i, c := range candidates {
+			tokens[i] = Tokens(c.Content)
+		}
+	}
+
+	similarity := func(i, j int) float32 {
+		if useEmbeddings {
+			return CosineSimilarity(candidates[i].Embedding, candidates[j].Embedding)
+		}
+		return JaccardSimilarity(tokens[i], tokens[j])
+	}
+
+	selected := make([]int, 0, k)
+	// maxSim[i] is the maximum similarity of candidate i to any of the selected documents
+	maxSim := make([]float32, len(candidates))
+	used := make([]bool, len(candidates))
+
+	for len(selected) < k {
+		best, bestScore := -1, float32(-math.MaxFloat32)
+		for i, c := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda * c.SimilarityScore
+			if len(selected) > 0 {
+				score -= (1 - lambda) * maxSim[i]
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		used[best] = true
+		selected = append(selected, best)
+		for i := range candidates {
+			if !used[i] {
+				maxSim[i] = max(maxSim[i], similarity(i, best))
+			}
+		}
+	}
+
+	result := make([]vs.Document, len(selected))
+	for i, idx := range selected {
+		result[i] = candidates[idx]
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package scores
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaximalMarginalRelevance(t *testing.T) {
+	candidates := []vs.Document{
+		{ID: "a", SimilarityScore: 0.9, Embedding: []float32{1, 0}},
+		{ID: "a-dup", SimilarityScore: 0.89, Embedding: []float32{1, 0.01}},
+		{ID: "b", SimilarityScore: 0.7, Embedding: []float32{0, 1}},
+	}
+
+	ids := func(docs []vs.Document) []string {
+		r := make([]string, len(docs))
+		for i, d := range docs {
+			r[i] = d.ID
+		}
+		return r
+	}
+
+	assert.Equal(t, []string{"a", "b"}, ids(MaximalMarginalRelevance(candidates, 2, 0.5)))
+	assert.Equal(t, []string{"a", "a-dup"}, ids(MaximalMarginalRelevance(candidates, 2, 1)))
+
+	// without embeddings, the word overlap is used
+	candidates = []vs.Document{
+		{ID: "a", SimilarityScore: 0.9, Content: "error code E42 in module foo"},
+		{ID: "a-dup", SimilarityScore: 0.89, Content: "Error code E42 in module foo."},
+		{ID: "b", SimilarityScore: 0.7, Content: "how to configure the bar service"},
+	}
+	assert.Equal(t, []string{"a", "b", "a-dup"}, ids(MaximalMarginalRelevance(candidates, 5, 0.5)))
+}
@@ -0,0 +1,86 @@
+package scores
+
+import (
+	"slices"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// DefaultRRFK is the rank constant commonly used for reciprocal rank fusion (Cormack et al., 2009).
+// Higher values reduce the influence of the top ranks.
+const DefaultRRFK = 60
+
+// RankedList is a list of documents ordered by relevance (best first), e.g. the result of a single retriever
+type RankedList struct {
+	Name      string
+	Weight    float32 // 0 is treated as 1
+	Documents []vs.Document
+}
+
+// ReciprocalRankFusion fuses ranked document lists into a single list sorted by score(d) = sum(weight / (k + rank(d))) over all lists containing d (rank starts at 1).
+// Only the ranks are used, so lists with incomparable scores (e.g. BM25 and cosine similarity) can be fused.
+// Scores are normalized to [0,1] by the maximum possible score (ranked first in all lists).
+// Documents are deduplicated by ID and get the metadata fields "retriever" (names of the lists containing them) and "rrfRank::<name>".
+func ReciprocalRankFusion(k int, lists ...RankedList) []vs.Document {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	var maxScore float32
+	fused := make(map[string]*vs.Document)
+	var order []string
+	for _, l := range lists {
+		weight := l.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		maxScore += weight / float32(k+1)
+
+		seen := make(map[string]struct{}, len(l.Documents))
+		for i, doc := range l.Documents {
+			if _, ok := seen[doc.ID]; ok {
+				continue // only the best rank counts per list
+			}
+			seen[doc.ID] = struct{}{}
+
+			score := weight / float32(k+i+1)
+			d, ok := fused[doc.ID]
+			if !ok {
+				doc.Metadata = cloneMetadata(doc.Metadata)
+				doc.SimilarityScore = 0
+				d = &doc
+				fused[doc.ID] = d
+				order = append(order, doc.ID)
+			}
+			d.SimilarityScore += score
+			if l.Name != "" {
+				if r, ok := d.Metadata["retriever"].(string); ok && r != "" && !slices.Contains(strings.Split(r, ","), l.Name) {
+					d.Metadata["retriever"] = r + "," + l.Name
+				} else if !ok || r == "" {
+					d.Metadata["retriever"] = l.Name
+				}
+				d.Metadata["rrfRank::"+l.Name] = i + 1
+			}
+		}
+	}
+
+	result := make([]vs.Document, 0, len(order))
+	for _, id := range order {
+		d := fused[id]
+		if maxScore > 0 {
+			d.SimilarityScore /= maxScore
+		}
+		result = append(result, *d)
+	}
+	slices.SortStableFunc(result, SortBySimilarityScore)
+	return result
+}
+
+func cloneMetadata(m map[string]any) map[string]any {
+	c := make(map[string]any, len(m)+2)
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
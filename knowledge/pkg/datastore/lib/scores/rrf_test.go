@@ -0,0 +1,43 @@
+package scores
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReciprocalRankFusion(t *testing.T) {
+	docs := func(ids ...string) []vs.Document {
+		d := make([]vs.Document, len(ids))
+		for i, id := range ids {
+			d[i] = vs.Document{ID: id, SimilarityScore: float32(100 - i)}
+		}
+		return d
+	}
+
+	fused := ReciprocalRankFusion(60,
+		RankedList{Name: "vector", Documents: docs("a", "b", "c")},
+		RankedList{Name: "keyword", Documents: docs("c", "a", "d")},
+	)
+	require.Len(t, fused, 4)
+
+	ids := make([]string, len(fused))
+	for i, d := range fused {
+		ids[i] = d.ID
+	}
+	assert.Equal(t, []string{"a", "c", "b", "d"}, ids)
+	assert.InDelta(t, (1.0/61+1.0/62)/(2.0/61), fused[0].SimilarityScore, 1e-6)
+	assert.Equal(t, "vector,keyword", fused[0].Metadata["retriever"])
+	assert.Equal(t, 2, fused[0].Metadata["rrfRank::keyword"])
+	assert.Equal(t, "keyword", fused[3].Metadata["retriever"])
+
+	// weights shift the balance
+	fused = ReciprocalRankFusion(60,
+		RankedList{Name: "vector", Weight: 0.1, Documents: docs("a", "b")},
+		RankedList{Name: "keyword", Weight: 1, Documents: docs("b", "a")},
+	)
+	assert.Equal(t, "b", fused[0].ID)
+	assert.LessOrEqual(t, fused[0].SimilarityScore, float32(1))
+}
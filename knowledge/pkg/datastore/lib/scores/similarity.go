@@ -0,0 +1,50 @@
+package scores
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// CosineSimilarity returns the cosine similarity of two vectors or 0 if they have different lengths or one of them is a zero vector
+func CosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
+
+// Tokens returns the set of lowercased words in the text
+func Tokens(text string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// JaccardSimilarity returns the Jaccard similarity (intersection over union) of two token sets
+func JaccardSimilarity(a, b map[string]struct{}) float32 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			intersection++
+		}
+	}
+	return float32(intersection) / float32(len(a)+len(b)-intersection)
+}
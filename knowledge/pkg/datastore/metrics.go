@@ -0,0 +1,43 @@
+package datastore
+
+import (
+	"context"
+	"time"
+
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// metricsEmbeddingFunc records the requests of the embedding function in the embedding metrics of the provider
+func metricsEmbeddingFunc(provider string, embeddingFunc vs.EmbeddingFunc) vs.EmbeddingFunc {
+	return func(ctx context.Context, text string) ([]float32, error) {
+		startTime := time.Now()
+		embedding, err := embeddingFunc(ctx, text)
+		metrics.ObserveEmbedding(provider, []string{text}, startTime, err)
+		return embedding, err
+	}
+}
+
+// metricsBatchEmbeddingFunc is the batch variant of metricsEmbeddingFunc
+func metricsBatchEmbeddingFunc(provider string, batchEmbeddingFunc etypes.BatchEmbeddingFunc) etypes.BatchEmbeddingFunc {
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		startTime := time.Now()
+		embeddings, err := batchEmbeddingFunc(ctx, texts)
+		metrics.ObserveEmbedding(provider, texts, startTime, err)
+		return embeddings, err
+	}
+}
+
+// metricsEmbeddingModelProvider wraps the embedding function of the provider with the embedding metrics
+type metricsEmbeddingModelProvider struct {
+	etypes.EmbeddingModelProvider
+}
+
+func (p *metricsEmbeddingModelProvider) EmbeddingFunc() (vs.EmbeddingFunc, error) {
+	embeddingFunc, err := p.EmbeddingModelProvider.EmbeddingFunc()
+	if err != nil {
+		return nil, err
+	}
+	return metricsEmbeddingFunc(p.Name(), embeddingFunc), nil
+}
@@ -0,0 +1,70 @@
+package postprocessors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+)
+
+const MetadataBoostPostprocessorName = "metadata_boost"
+
+// MetadataBoostRule multiplies the similarity score of documents whose metadata matches the rule
+type MetadataBoostRule struct {
+	Key    string
+	Value  string  // Value to match (compared as string) - if empty, the rule matches all documents having the key
+	Factor float32 // > 1 boosts, < 1 demotes matching documents
+}
+
+func (r MetadataBoostRule) matches(metadata map[string]any) bool {
+	v, ok := metadata[r.Key]
+	if !ok {
+		return false
+	}
+	return r.Value == "" || fmt.Sprint(v) == r.Value
+}
+
+// MetadataBoostPostprocessor applies business rules on top of the similarity scores, e.g. boost source=handbook by 1.3
+// and demote draft=true by 0.5. The factors of all matching rules are multiplied and the documents are re-sorted.
+type MetadataBoostPostprocessor struct {
+	Rules []MetadataBoostRule
+}
+
+func (m *MetadataBoostPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	for _, rule := range m.Rules {
+		if rule.Key == "" {
+			return fmt.Errorf("metadata_boost: rule without key")
+		}
+		if rule.Factor <= 0 {
+			return fmt.Errorf("metadata_boost: factor of rule for %q must be positive, got %f", rule.Key, rule.Factor)
+		}
+	}
+
+	for i, resp := range response.Responses {
+		docs := resp.ResultDocuments
+		for j, doc := range docs {
+			factor := float32(1)
+			for _, rule := range m.Rules {
+				if rule.matches(doc.Metadata) {
+					factor *= rule.Factor
+				}
+			}
+			if factor == 1 {
+				continue
+			}
+			slog.Debug("Boosting document", "docID", doc.ID, "factor", factor, "score", doc.SimilarityScore)
+			docs[j].Metadata["boostFactor"] = factor
+			docs[j].SimilarityScore = doc.SimilarityScore * factor
+		}
+		slices.SortStableFunc(docs, scores.SortBySimilarityScore)
+		response.Responses[i].ResultDocuments = docs
+	}
+	return nil
+}
+
+func (m *MetadataBoostPostprocessor) Name() string {
+	return MetadataBoostPostprocessorName
+}
@@ -0,0 +1,112 @@
+package postprocessors
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const CitationsPostprocessorName = "citations"
+
+var defaultCitationSourceFields = []string{"canonicalURL", "url", "sourceURL", "source", "absPath"}
+
+// CitationsPostprocessor collects the sources of all result documents into a structured citation list attached to the
+// RetrievalResponse, so downstream tools can render footnotes without having to interpret the document metadata.
+// The citation number is also added to the metadata of each document ("citation").
+type CitationsPostprocessor struct {
+	SourceFields  []string // Metadata fields holding the source URL or path, the first non-empty one wins (default: canonicalURL, url, sourceURL, source, absPath)
+	GroupBySource bool     // Create one citation per source (e.g. file) instead of one per document
+}
+
+func (c *CitationsPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	sourceFields := c.SourceFields
+	if len(sourceFields) == 0 {
+		sourceFields = defaultCitationSourceFields
+	}
+
+	var citations []types.Citation
+	byKey := map[string]int{} // citation key -> index into citations
+
+	for i, resp := range response.Responses {
+		for j, doc := range resp.ResultDocuments {
+			source := firstMetadataString(doc.Metadata, sourceFields...)
+
+			key := "doc:" + doc.ID
+			if c.GroupBySource && source != "" {
+				key = "src:" + source
+			}
+
+			idx, ok := byKey[key]
+			if !ok {
+				citations = append(citations, types.Citation{
+					Index:    len(citations) + 1,
+					Filename: firstMetadataString(doc.Metadata, "filename"),
+					Title:    firstMetadataString(doc.Metadata, "deckTitle", "title"),
+					Source:   source,
+					Score:    doc.SimilarityScore,
+				})
+				idx = len(citations) - 1
+				byKey[key] = idx
+			}
+
+			citation := &citations[idx]
+			if !slices.Contains(citation.DocumentIDs, doc.ID) {
+				citation.DocumentIDs = append(citation.DocumentIDs, doc.ID)
+			}
+			citation.Score = max(citation.Score, doc.SimilarityScore)
+			if page := firstMetadataString(doc.Metadata, "page", "pages"); page != "" && !slices.Contains(citation.Pages, page) {
+				citation.Pages = append(citation.Pages, page)
+			}
+			if slide, ok := metadataInt(doc.Metadata, "slide"); ok && !slices.Contains(citation.Slides, slide) {
+				citation.Slides = append(citation.Slides, slide)
+			}
+			if chunkIdx, ok := metadataInt(doc.Metadata, vs.DocMetadataKeyDocIndex); ok && !slices.Contains(citation.ChunkIndices, chunkIdx) {
+				citation.ChunkIndices = append(citation.ChunkIndices, chunkIdx)
+			}
+
+			if doc.Metadata == nil {
+				response.Responses[i].ResultDocuments[j].Metadata = map[string]any{}
+			}
+			response.Responses[i].ResultDocuments[j].Metadata["citation"] = citation.Index
+		}
+	}
+
+	response.Citations = citations
+	return nil
+}
+
+func (c *CitationsPostprocessor) Name() string {
+	return CitationsPostprocessorName
+}
+
+// firstMetadataString returns the first non-empty value of the given metadata keys as a string
+func firstMetadataString(metadata map[string]any, keys ...string) string {
+	for _, k := range keys {
+		v, ok := metadata[k]
+		if !ok || v == nil {
+			continue
+		}
+		if s := fmt.Sprint(v); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// metadataInt returns the metadata value as int - numbers may have been decoded as float64 by the vector store
+func metadataInt(metadata map[string]any, key string) (int, bool) {
+	switch v := metadata[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case float32:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -1,8 +1,12 @@
 package postprocessors
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log/slog"
+	"os"
+	"slices"
 
 	"github.com/acorn-io/z"
 	cohere "github.com/cohere-ai/cohere-go/v2"
@@ -13,10 +17,17 @@ import (
 
 const CohereRerankPostprocessorName = "cohere_rerank"
 
+const (
+	// CohereRerankMaxBatchSize is the maximum number of documents Cohere accepts in a single rerank request
+	CohereRerankMaxBatchSize = 1000
+	CohereAPIKeyEnvVar       = "COHERE_API_KEY"
+)
+
 type CohereRerankPostprocessor struct {
-	ApiKey string `json:"apiKey" yaml:"apiKey"`
-	Model  string
-	TopN   int
+	ApiKey    string `json:"apiKey" yaml:"apiKey"` // defaults to the COHERE_API_KEY environment variable
+	Model     string
+	TopN      int // Number of documents to keep after reranking (0 = keep all)
+	BatchSize int // Number of documents sent per request (default and maximum: 1000)
 }
 
 func (c *CohereRerankPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
@@ -31,40 +42,84 @@ func (c *CohereRerankPostprocessor) Transform(ctx context.Context, response *typ
 	return nil
 }
 
+type cohereRerankResult struct {
+	index          int
+	relevanceScore float64
+}
+
 func (c *CohereRerankPostprocessor) transform(ctx context.Context, query string, docs []vs.Document) ([]vs.Document, error) {
-	slog.Debug("Reranking documents", "model", c.Model, "topN", c.TopN, "numDocs", len(docs))
-	client := cohereclient.NewClient(cohereclient.WithToken(c.ApiKey))
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	apiKey := c.ApiKey
+	if apiKey == "" {
+		apiKey = os.Getenv(CohereAPIKeyEnvVar)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("cohere_rerank: no API key configured - set apiKey or %s", CohereAPIKeyEnvVar)
+	}
+
+	batchSize := c.BatchSize
+	if batchSize <= 0 || batchSize > CohereRerankMaxBatchSize {
+		batchSize = CohereRerankMaxBatchSize
+	}
+
+	slog.Debug("Reranking documents", "model", c.Model, "topN", c.TopN, "numDocs", len(docs), "batchSize", batchSize)
+	client := cohereclient.NewClient(cohereclient.WithToken(apiKey))
 
-	docItems := make([]*cohere.RerankRequestDocumentsItem, len(docs))
+	// Rerank in batches - relevance scores are absolute, so results of different batches can be merged by score
+	var results []cohereRerankResult
+	for start := 0; start < len(docs); start += batchSize {
+		batch := docs[start:min(start+batchSize, len(docs))]
+
+		docItems := make([]*cohere.RerankRequestDocumentsItem, len(batch))
+		for i, doc := range batch {
+			docItems[i] = &cohere.RerankRequestDocumentsItem{
+				String: doc.Content,
+			}
+		}
+
+		req := &cohere.RerankRequest{
+			Documents:       docItems,
+			Query:           query,
+			ReturnDocuments: z.Pointer(false),
+		}
+		if c.Model != "" {
+			req.Model = z.Pointer(c.Model)
+		}
+		if c.TopN > 0 {
+			req.TopN = z.Pointer(min(c.TopN, len(batch)))
+		}
+
+		res, err := client.Rerank(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("cohere_rerank: failed to rerank documents %d-%d: %w", start, start+len(batch)-1, err)
+		}
 
-	for i, doc := range docs {
-		docItems[i] = &cohere.RerankRequestDocumentsItem{
-			String: doc.Content,
+		for _, result := range res.Results {
+			results = append(results, cohereRerankResult{index: start + result.Index, relevanceScore: result.RelevanceScore})
 		}
 	}
 
-	res, err := client.Rerank(ctx, &cohere.RerankRequest{
-		Model:           z.Pointer(c.Model),
-		Documents:       docItems,
-		Query:           query,
-		TopN:            z.Pointer(c.TopN),
-		ReturnDocuments: z.Pointer(false),
+	slices.SortStableFunc(results, func(a, b cohereRerankResult) int {
+		return cmp.Compare(b.relevanceScore, a.relevanceScore)
 	})
-	if err != nil {
-		return nil, err
+	if c.TopN > 0 && len(results) > c.TopN {
+		results = results[:c.TopN]
 	}
 
-	rerankedDocs := make([]vs.Document, len(res.Results))
+	rerankedDocs := make([]vs.Document, len(results))
 
-	for i, result := range res.Results {
-		rerankedDocs[i] = docs[result.Index]
-		slog.Debug("Reranked document", "index", i, "relevanceScore", result.RelevanceScore, "originalIndex", result.Index)
+	for i, result := range results {
+		rerankedDocs[i] = docs[result.index]
+		slog.Debug("Reranked document", "index", i, "relevanceScore", result.relevanceScore, "originalIndex", result.index)
 
 		if len(rerankedDocs[i].Metadata) > 0 {
-			rerankedDocs[i].Metadata["rerankRelevanceScore"] = result.RelevanceScore
+			rerankedDocs[i].Metadata["rerankRelevanceScore"] = result.relevanceScore
 		} else {
 			rerankedDocs[i].Metadata = map[string]interface{}{
-				"rerankRelevanceScore": result.RelevanceScore,
+				"rerankRelevanceScore": result.relevanceScore,
 			}
 		}
 	}
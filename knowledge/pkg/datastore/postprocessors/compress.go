@@ -0,0 +1,132 @@
+package postprocessors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const CompressPostprocessorName = "compress"
+
+const compressNoOutput = "NO_OUTPUT"
+
+// CompressPostprocessor asks the LLM to extract only the parts of each retrieved document that are relevant to the query
+// (contextual compression), which can cut the prompt size a lot for verbose source documents.
+// Documents that couldn't be compressed within the timeout keep their original content.
+type CompressPostprocessor struct {
+	Model          llm.LLMConfig
+	Concurrency    int    // Maximum number of parallel LLM requests (default: 5)
+	Timeout        string // Hard timeout for compressing all documents of a response, e.g. 30s (default: 60s)
+	DropIrrelevant bool   // Drop documents which don't contain anything relevant to the query, instead of keeping them unchanged
+}
+
+var compressPromptTpl = `Given the following question and context, extract any part of the context *AS IS* that is relevant to answer the question.
+Do not change or summarize the extracted parts. If none of the context is relevant, return {{.noOutput}}.
+
+> Question: {{.query}}
+> Context:
+>>>
+{{.content}}
+>>>
+Extracted relevant parts:`
+
+func (c *CompressPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	m, err := llm.NewFromConfig(c.Model)
+	if err != nil {
+		return err
+	}
+
+	timeout := 60 * time.Second
+	if c.Timeout != "" {
+		timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", c.Timeout, err)
+		}
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	for i, resp := range response.Responses {
+		docs, err := c.compress(ctx, m, resp.Query, resp.ResultDocuments, timeout, concurrency)
+		if err != nil {
+			return err
+		}
+		response.Responses[i].ResultDocuments = docs
+	}
+	return nil
+}
+
+func (c *CompressPostprocessor) compress(ctx context.Context, m *llm.LLM, query string, docs []vs.Document, timeout time.Duration, concurrency int) ([]vs.Document, error) {
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	compressed := make([]*string, len(docs))
+
+	g, gctx := errgroup.WithContext(tctx)
+	g.SetLimit(concurrency)
+	for i, doc := range docs {
+		g.Go(func() error {
+			res, err := m.Prompt(gctx, compressPromptTpl, map[string]any{
+				"query":    query,
+				"content":  doc.Content,
+				"noOutput": compressNoOutput,
+			})
+			if err != nil {
+				if errors.Is(tctx.Err(), context.DeadlineExceeded) {
+					return nil // keep the original content
+				}
+				return fmt.Errorf("failed to compress document %q: %w", doc.ID, err)
+			}
+			res = strings.TrimSpace(res)
+			compressed[i] = &res
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	if errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		slog.Warn("Timeout while compressing documents, keeping original content for the remaining ones", "timeout", timeout)
+	}
+
+	result := make([]vs.Document, 0, len(docs))
+	for i, doc := range docs {
+		if compressed[i] == nil {
+			result = append(result, doc)
+			continue
+		}
+		content := *compressed[i]
+		if content == "" || strings.Contains(content, compressNoOutput) {
+			if c.DropIrrelevant {
+				slog.Debug("Dropping irrelevant document", "docID", doc.ID)
+				continue
+			}
+			result = append(result, doc)
+			continue
+		}
+
+		slog.Debug("Compressed document", "docID", doc.ID, "originalLength", len(doc.Content), "compressedLength", len(content))
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]any{}
+		}
+		doc.Metadata["originalContentLength"] = len(doc.Content)
+		doc.Content = content
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+func (c *CompressPostprocessor) Name() string {
+	return CompressPostprocessorName
+}
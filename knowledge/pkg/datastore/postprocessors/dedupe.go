@@ -0,0 +1,81 @@
+package postprocessors
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const DedupePostprocessorName = "dedupe"
+
+// DedupePostprocessor removes exact and near-duplicate documents from the retrieval response, e.g. the same paragraph
+// returned twice due to overlapping chunks. The first (best ranked) occurrence is kept.
+// Embeddings are only compared if the retriever returned them (e.g. basic retriever with includeEmbeddings: true),
+// otherwise the token overlap (Jaccard similarity) of the contents is used.
+type DedupePostprocessor struct {
+	EmbeddingThreshold float32 // Minimum cosine similarity of the embeddings to consider documents duplicates (default: 0.95)
+	TokenThreshold     float32 // Minimum Jaccard similarity of the content tokens to consider documents duplicates (default: 0.9)
+	KeepEmbeddings     bool    // Keep the embeddings in the response - they're removed by default to keep the output small
+	AcrossQueries      bool    // Also remove documents found for multiple (sub)queries, keeping them only in the response where they scored best
+}
+
+func (d *DedupePostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	embeddingThreshold := d.EmbeddingThreshold
+	if embeddingThreshold == 0 {
+		embeddingThreshold = 0.95
+	}
+	tokenThreshold := d.TokenThreshold
+	if tokenThreshold == 0 {
+		tokenThreshold = 0.9
+	}
+
+	for i, resp := range response.Responses {
+		docs := scores.RemoveNearDuplicates(resp.ResultDocuments, embeddingThreshold, tokenThreshold)
+		slog.Debug("Removed duplicate documents", "query", resp.Query, "originalDocCount", len(resp.ResultDocuments), "remainingDocCount", len(docs))
+
+		if !d.KeepEmbeddings {
+			for j := range docs {
+				docs[j].Embedding = nil
+			}
+		}
+		response.Responses[i].ResultDocuments = docs
+	}
+
+	if d.AcrossQueries {
+		dedupeAcrossResponses(response)
+	}
+	return nil
+}
+
+// dedupeAcrossResponses keeps each document (by ID) only in the response where it has the highest similarity score
+func dedupeAcrossResponses(response *types.RetrievalResponse) {
+	type location struct {
+		response int
+		score    float32
+	}
+	best := map[string]location{}
+	for i, resp := range response.Responses {
+		for _, doc := range resp.ResultDocuments {
+			if l, ok := best[doc.ID]; !ok || doc.SimilarityScore > l.score {
+				best[doc.ID] = location{response: i, score: doc.SimilarityScore}
+			}
+		}
+	}
+
+	for i, resp := range response.Responses {
+		docs := make([]vs.Document, 0, len(resp.ResultDocuments))
+		for _, doc := range resp.ResultDocuments {
+			if best[doc.ID].response == i {
+				docs = append(docs, doc)
+			}
+		}
+		response.Responses[i].ResultDocuments = docs
+	}
+}
+
+func (d *DedupePostprocessor) Name() string {
+	return DedupePostprocessorName
+}
@@ -0,0 +1,88 @@
+package postprocessors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const MetadataFilterPostprocessorName = "metadata_filter"
+
+// MetadataFilterPostprocessor drops all documents whose metadata doesn't match the filters, e.g. markdown front matter
+// like {"tags": "kubernetes", "author": ["jane", "john"]}. All filters must match (AND), a list of values matches
+// if any of them matches (OR). If the metadata value is a list itself (e.g. tags), it matches if it contains the value.
+// Values are compared as strings.
+// Where is a filter with operators like {"year": {"$gte": 2020}} (see vectorstore/types.MetadataFilter), which must match as well.
+type MetadataFilterPostprocessor struct {
+	Filters map[string]any
+	Where   map[string]any
+}
+
+func (m *MetadataFilterPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	if len(m.Filters) == 0 && len(m.Where) == 0 {
+		return nil
+	}
+
+	var where *vs.MetadataFilter
+	if len(m.Where) > 0 {
+		var err error
+		where, err = vs.MetadataFilterFromMap(m.Where)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, resp := range response.Responses {
+		var docs []vs.Document
+		for _, doc := range resp.ResultDocuments {
+			if MetadataMatches(doc.Metadata, m.Filters) && where.Matches(doc.Metadata) {
+				docs = append(docs, doc)
+			}
+		}
+		slog.Debug("Filtered documents by metadata", "filters", m.Filters, "where", m.Where, "before", len(resp.ResultDocuments), "after", len(docs))
+		response.Responses[i].ResultDocuments = docs
+	}
+	return nil
+}
+
+func (m *MetadataFilterPostprocessor) Name() string {
+	return MetadataFilterPostprocessorName
+}
+
+// MetadataMatches reports whether the metadata matches all filters (see MetadataFilterPostprocessor)
+func MetadataMatches(metadata map[string]any, filters map[string]any) bool {
+	for key, want := range filters {
+		have, ok := metadata[key]
+		if !ok {
+			return false
+		}
+		wanted := stringValues(want)
+		if !slices.ContainsFunc(stringValues(have), func(v string) bool { return slices.Contains(wanted, v) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringValues(v any) []string {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.(string); ok {
+		return []string{s}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{fmt.Sprint(v)}
+	}
+	values := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, fmt.Sprint(rv.Index(i).Interface()))
+	}
+	return values
+}
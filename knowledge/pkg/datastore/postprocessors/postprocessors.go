@@ -61,6 +61,14 @@ var PostprocessorMap = map[string]Postprocessor{
 	ContentSubstringFilterPostprocessorName:      &ContentSubstringFilterPostprocessor{},
 	ContentFilterPostprocessorName:               &ContentFilterPostprocessor{},
 	CohereRerankPostprocessorName:                &CohereRerankPostprocessor{},
+	RerankerPostprocessorName:                    &RerankerPostprocessor{},
+	DedupePostprocessorName:                      &DedupePostprocessor{},
+	CompressPostprocessorName:                    &CompressPostprocessor{},
+	RecencyPostprocessorName:                     &RecencyPostprocessor{},
+	CitationsPostprocessorName:                   &CitationsPostprocessor{},
+	RedactPIIPostprocessorName:                   &RedactPIIPostprocessor{},
+	MetadataBoostPostprocessorName:               &MetadataBoostPostprocessor{},
+	MetadataFilterPostprocessorName:              &MetadataFilterPostprocessor{},
 	ReducePostprocessorName:                      &ReducePostprocessor{},
 	BM25PostprocessorName:                        &BM25Postprocessor{},
 }
@@ -0,0 +1,164 @@
+package postprocessors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+)
+
+const RecencyPostprocessorName = "recency"
+
+var recencyTimestampLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.DateTime,
+	time.DateOnly,
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// RecencyPostprocessor decays the similarity scores of documents based on the age of a timestamp in their metadata,
+// so that fresh documents outrank stale ones, e.g. in release notes or news.
+// The score is multiplied by (1 - Weight) + Weight * 0.5^(age/HalfLife), i.e. with the default Weight of 1,
+// a document that's HalfLife old gets half of its original score. With a Boost, fresh documents are additionally
+// multiplied by up to Boost, which decays towards 1 with the same half-life, e.g. Weight 0 and Boost 2 only promote fresh documents.
+// Documents without (parseable) timestamp are multiplied by UndatedFactor, by default as if they were HalfLife old.
+type RecencyPostprocessor struct {
+	Field         string   // Metadata field holding the timestamp (RFC3339, date or unix seconds)
+	HalfLife      string   // Age after which the score is halved (Go duration or days, e.g. 720h or 30d, default: 30d)
+	Weight        *float32 // Influence of the decay on the score between 0 (none) and 1 (full, default)
+	Boost         float32  // Multiplier (>= 1) for brand-new documents, decaying towards 1 with the half-life (default: 1, no boost)
+	UndatedFactor *float32 // Multiplier for documents without timestamp, e.g. 1 to keep their score (default: factor of a document HalfLife old)
+}
+
+func (r *RecencyPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	if r.Field == "" {
+		return fmt.Errorf("recency: field is required")
+	}
+
+	halfLife := 30 * 24 * time.Hour
+	if r.HalfLife != "" {
+		var err error
+		halfLife, err = parseHalfLife(r.HalfLife)
+		if err != nil {
+			return fmt.Errorf("recency: %w", err)
+		}
+	}
+
+	weight := float32(1)
+	if r.Weight != nil {
+		weight = *r.Weight
+	}
+	if weight < 0 || weight > 1 {
+		return fmt.Errorf("recency: weight must be between 0 and 1, got %f", weight)
+	}
+
+	boost := r.Boost
+	if boost == 0 {
+		boost = 1
+	}
+	if boost < 1 {
+		return fmt.Errorf("recency: boost must be at least 1, got %f", boost)
+	}
+
+	recencyFactor := func(decay float32) float32 {
+		return ((1 - weight) + weight*decay) * (1 + (boost-1)*decay)
+	}
+
+	undatedFactor := recencyFactor(0.5)
+	if r.UndatedFactor != nil {
+		undatedFactor = *r.UndatedFactor
+	}
+	if undatedFactor < 0 {
+		return fmt.Errorf("recency: undated factor must not be negative, got %f", undatedFactor)
+	}
+
+	now := time.Now()
+	for i, resp := range response.Responses {
+		docs := resp.ResultDocuments
+		for j, doc := range docs {
+			factor := undatedFactor
+			if ts, ok := parseTimestamp(doc.Metadata[r.Field]); ok {
+				age := max(now.Sub(ts), 0)
+				factor = recencyFactor(float32(math.Pow(0.5, float64(age)/float64(halfLife))))
+			} else {
+				slog.Debug("Document has no parseable timestamp, applying undated factor", "docID", doc.ID, "field", r.Field, "value", doc.Metadata[r.Field], "factor", undatedFactor)
+			}
+
+			if docs[j].Metadata == nil {
+				docs[j].Metadata = make(map[string]any)
+			}
+			docs[j].Metadata["recencyFactor"] = factor
+			docs[j].SimilarityScore = doc.SimilarityScore * factor
+		}
+		slices.SortStableFunc(docs, scores.SortBySimilarityScore)
+		response.Responses[i].ResultDocuments = docs
+	}
+	return nil
+}
+
+func (r *RecencyPostprocessor) Name() string {
+	return RecencyPostprocessorName
+}
+
+// parseHalfLife parses a Go duration, additionally supporting days (e.g. 30d)
+func parseHalfLife(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid half-life %q: %w", s, err)
+		}
+		d = time.Duration(n * float64(24*time.Hour))
+	} else {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid half-life %q: %w", s, err)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("half-life must be positive, got %q", s)
+	}
+	return d, nil
+}
+
+func parseTimestamp(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case int:
+		return time.Unix(int64(t), 0), true
+	case int64:
+		return time.Unix(t, 0), true
+	case float64:
+		return time.Unix(int64(t), 0), true
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return time.Unix(n, 0), true
+		}
+		if f, err := t.Float64(); err == nil {
+			return time.Unix(int64(f), 0), true
+		}
+	case string:
+		t = strings.TrimSpace(t)
+		for _, layout := range recencyTimestampLayouts {
+			if ts, err := time.Parse(layout, t); err == nil {
+				return ts, true
+			}
+		}
+		if n, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return time.Unix(n, 0), true
+		}
+	}
+	return time.Time{}, false
+}
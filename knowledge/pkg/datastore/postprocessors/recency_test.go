@@ -0,0 +1,132 @@
+package postprocessors
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recencyScores runs the postprocessor on documents with the given ages in half-lives (nil: undated) and score 1,
+// returning the resulting scores in the original order
+func recencyScores(t *testing.T, r *RecencyPostprocessor, ages ...*float64) []float32 {
+	t.Helper()
+	now := time.Now()
+	var docs []vs.Document
+	for i, age := range ages {
+		doc := vs.Document{ID: string(rune('a' + i)), SimilarityScore: 1, Metadata: map[string]any{}}
+		if age != nil {
+			doc.Metadata["date"] = now.Add(-time.Duration(*age * float64(24*time.Hour))).Format(time.RFC3339)
+		}
+		docs = append(docs, doc)
+	}
+
+	response := &types.RetrievalResponse{Responses: []types.Response{{ResultDocuments: docs}}}
+	require.NoError(t, r.Transform(context.Background(), response))
+
+	result := make([]float32, len(ages))
+	for _, doc := range response.Responses[0].ResultDocuments {
+		result[doc.ID[0]-'a'] = doc.SimilarityScore
+	}
+	return result
+}
+
+func halfLives(n float64) *float64 {
+	return &n
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRecencyPostprocessor(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		r    RecencyPostprocessor
+		want []float32 // scores of documents 0, 1 and 2 half-lives old and an undated one
+	}{
+		{
+			name: "default weight",
+			r:    RecencyPostprocessor{},
+			want: []float32{1, 0.5, 0.25, 0.5},
+		},
+		{
+			name: "half weight",
+			r:    RecencyPostprocessor{Weight: ptr[float32](0.5)},
+			want: []float32{1, 0.75, 0.625, 0.75},
+		},
+		{
+			name: "zero weight has no effect",
+			r:    RecencyPostprocessor{Weight: ptr[float32](0)},
+			want: []float32{1, 1, 1, 1},
+		},
+		{
+			name: "boost only",
+			r:    RecencyPostprocessor{Weight: ptr[float32](0), Boost: 2},
+			want: []float32{2, 1.5, 1.25, 1.5},
+		},
+		{
+			name: "undated factor",
+			r:    RecencyPostprocessor{UndatedFactor: ptr[float32](1)},
+			want: []float32{1, 0.5, 0.25, 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.r.Field = "date"
+			tc.r.HalfLife = "1d"
+			got := recencyScores(t, &tc.r, halfLives(0), halfLives(1), halfLives(2), nil)
+			assert.InDeltaSlice(t, tc.want, got, 0.001)
+		})
+	}
+}
+
+func TestRecencyPostprocessorInvalid(t *testing.T) {
+	for _, r := range []RecencyPostprocessor{
+		{},
+		{Field: "date", HalfLife: "-1d"},
+		{Field: "date", Weight: ptr[float32](1.5)},
+		{Field: "date", Boost: 0.5},
+		{Field: "date", UndatedFactor: ptr[float32](-1)},
+	} {
+		assert.Error(t, r.Transform(context.Background(), &types.RetrievalResponse{}), "%+v", r)
+	}
+}
+
+func TestRecencyPostprocessorDecode(t *testing.T) {
+	var r RecencyPostprocessor
+	require.NoError(t, mapstructure.Decode(map[string]any{"field": "date", "weight": 0, "boost": 1.5}, &r))
+	require.NotNil(t, r.Weight, "an explicit weight of 0 must be distinguishable from the default")
+	assert.Zero(t, *r.Weight)
+	assert.Equal(t, float32(1.5), r.Boost)
+	assert.Nil(t, r.UndatedFactor)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, v := range []any{
+		want,
+		int(want.Unix()),
+		want.Unix(),
+		float64(want.Unix()),
+		json.Number("1714521600"),
+		json.Number("1714521600.0"),
+		"2024-05-01T00:00:00Z",
+		"2024-05-01",
+		" 1714521600 ",
+	} {
+		ts, ok := parseTimestamp(v)
+		require.True(t, ok, "%T %v", v, v)
+		assert.True(t, want.Equal(ts), "%T %v: got %s", v, v, ts)
+	}
+
+	for _, v := range []any{nil, "", "yesterday", json.Number("soon"), true} {
+		_, ok := parseTimestamp(v)
+		assert.False(t, ok, "%T %v", v, v)
+	}
+}
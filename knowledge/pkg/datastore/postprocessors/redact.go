@@ -0,0 +1,47 @@
+package postprocessors
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/redact"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+)
+
+const RedactPIIPostprocessorName = "redact_pii"
+
+// RedactPIIPostprocessor masks personally identifiable information (emails, phone numbers, credit card numbers
+// and custom regex patterns) in the content of the retrieved documents before they're returned.
+type RedactPIIPostprocessor struct {
+	Types       []string          // Builtin PII types to redact: email, phone, credit_card (default: all)
+	Patterns    map[string]string // Additional patterns to redact (name -> regular expression)
+	Replacement string            // Replacement text, %s is replaced by the uppercased type/pattern name (default: [REDACTED_%s])
+}
+
+func (r *RedactPIIPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	piiTypes := r.Types
+	if len(piiTypes) == 0 {
+		piiTypes = redact.DefaultTypes
+	}
+
+	redactor, err := redact.New(piiTypes, r.Patterns, r.Replacement)
+	if err != nil {
+		return err
+	}
+
+	for i, resp := range response.Responses {
+		for j, doc := range resp.ResultDocuments {
+			content, counts := redactor.Redact(doc.Content)
+			if len(counts) == 0 {
+				continue
+			}
+			slog.Debug("Redacted PII from document", "docID", doc.ID, "counts", counts)
+			response.Responses[i].ResultDocuments[j].Content = content
+		}
+	}
+	return nil
+}
+
+func (r *RedactPIIPostprocessor) Name() string {
+	return RedactPIIPostprocessorName
+}
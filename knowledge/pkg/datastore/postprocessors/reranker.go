@@ -0,0 +1,195 @@
+package postprocessors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const RerankerPostprocessorName = "reranker"
+
+const (
+	RerankerAPIOpenAI = "openai" // OpenAI-compatible rerank API (e.g. vLLM, Infinity, LocalAI, Jina): {model, query, documents, top_n} -> {results: [{index, relevance_score}]}
+	RerankerAPITEI    = "tei"    // HuggingFace Text Embeddings Inference: {query, texts} -> [{index, score}]
+)
+
+// RerankerPostprocessor re-scores the retrieved documents using a cross-encoder model served via a rerank API
+// and keeps the TopN most relevant ones.
+// The similarity score of the documents is replaced by the relevance score returned by the reranker.
+type RerankerPostprocessor struct {
+	URL     string // Full URL of the rerank endpoint, e.g. http://localhost:8080/rerank (TEI) or http://localhost:8000/v1/rerank
+	API     string // API flavor, one of "openai" (default) or "tei"
+	ApiKey  string `json:"apiKey" yaml:"apiKey"` // Sent as Bearer token, if set
+	Model   string // Model name (ignored by TEI, which serves a single model)
+	TopN    int    // Number of documents to keep after reranking (0 = keep all)
+	Timeout string // Request timeout, e.g. 30s (default: 60s)
+}
+
+type openAIRerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type openAIRerankResponse struct {
+	Results []rerankResult `json:"results"`
+}
+
+type teiRerankRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Truncate bool     `json:"truncate"`
+}
+
+type rerankResult struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+	Score          float64 `json:"score"` // TEI
+}
+
+func (r *RerankerPostprocessor) Transform(ctx context.Context, response *types.RetrievalResponse) error {
+	for i, resp := range response.Responses {
+		docs, err := r.transform(ctx, resp.Query, resp.ResultDocuments)
+		if err != nil {
+			return err
+		}
+		response.Responses[i].ResultDocuments = docs
+	}
+
+	return nil
+}
+
+func (r *RerankerPostprocessor) transform(ctx context.Context, query string, docs []vs.Document) ([]vs.Document, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+	if r.URL == "" {
+		return nil, fmt.Errorf("reranker: url is required")
+	}
+
+	slog.Debug("Reranking documents", "url", r.URL, "api", r.API, "model", r.Model, "topN", r.TopN, "numDocs", len(docs))
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+
+	results, err := r.rerank(ctx, query, texts)
+	if err != nil {
+		return nil, fmt.Errorf("reranker: %w", err)
+	}
+
+	slices.SortStableFunc(results, func(a, b rerankResult) int {
+		if a.RelevanceScore > b.RelevanceScore {
+			return -1
+		} else if a.RelevanceScore < b.RelevanceScore {
+			return 1
+		}
+		return 0
+	})
+
+	rerankedDocs := make([]vs.Document, 0, len(results))
+	for _, result := range results {
+		if result.Index < 0 || result.Index >= len(docs) {
+			return nil, fmt.Errorf("reranker: result index %d out of range (%d documents)", result.Index, len(docs))
+		}
+		doc := docs[result.Index]
+		slog.Debug("Reranked document", "index", len(rerankedDocs), "relevanceScore", result.RelevanceScore, "originalIndex", result.Index)
+
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]any{}
+		}
+		doc.Metadata["rerankRelevanceScore"] = result.RelevanceScore
+		doc.SimilarityScore = float32(result.RelevanceScore)
+		rerankedDocs = append(rerankedDocs, doc)
+	}
+
+	if r.TopN > 0 && len(rerankedDocs) > r.TopN {
+		rerankedDocs = rerankedDocs[:r.TopN]
+	}
+
+	return rerankedDocs, nil
+}
+
+// rerank calls the rerank API and returns the results with the RelevanceScore set for all API flavors
+func (r *RerankerPostprocessor) rerank(ctx context.Context, query string, texts []string) ([]rerankResult, error) {
+	var payload any
+	api := strings.ToLower(r.API)
+	switch api {
+	case "", RerankerAPIOpenAI:
+		payload = openAIRerankRequest{Model: r.Model, Query: query, Documents: texts, TopN: r.TopN}
+	case RerankerAPITEI:
+		payload = teiRerankRequest{Query: query, Texts: texts, Truncate: true}
+	default:
+		return nil, fmt.Errorf("unsupported api %q, must be one of %s, %s", r.API, RerankerAPIOpenAI, RerankerAPITEI)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 60 * time.Second
+	if r.Timeout != "" {
+		timeout, err = time.ParseDuration(r.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %q: %w", r.Timeout, err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.ApiKey)
+	}
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("rerank API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var results []rerankResult
+	if api == RerankerAPITEI {
+		if err := json.Unmarshal(respBody, &results); err != nil {
+			return nil, fmt.Errorf("failed to decode rerank response: %w", err)
+		}
+		for i := range results {
+			results[i].RelevanceScore = results[i].Score
+		}
+	} else {
+		var res openAIRerankResponse
+		if err := json.Unmarshal(respBody, &res); err != nil {
+			return nil, fmt.Errorf("failed to decode rerank response: %w", err)
+		}
+		results = res.Results
+	}
+
+	return results, nil
+}
+
+func (r *RerankerPostprocessor) Name() string {
+	return RerankerPostprocessorName
+}
@@ -0,0 +1,129 @@
+package querymodifiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+)
+
+const ConversationQueryModifierName = "conversation"
+
+// ConversationTurn is a single prior message of a chat conversation
+type ConversationTurn struct {
+	Role    string `json:"role,omitempty"` // e.g. user or assistant
+	Content string `json:"content"`
+}
+
+// HistoryAwareQueryModifier is implemented by query modifiers which take the prior conversation into account
+type HistoryAwareQueryModifier interface {
+	QueryModifier
+	WithHistory(history []ConversationTurn) QueryModifier
+}
+
+// ParseConversationHistory parses a conversation history given as JSON array of strings (alternating user/assistant
+// turns, starting with the user) or of {"role", "content"} objects.
+func ParseConversationHistory(s string) ([]ConversationTurn, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var turns []ConversationTurn
+	if err := json.Unmarshal([]byte(s), &turns); err == nil {
+		return turns, nil
+	}
+
+	var messages []string
+	if err := json.Unmarshal([]byte(s), &messages); err != nil {
+		return nil, fmt.Errorf("conversation history must be a JSON array of strings or {\"role\", \"content\"} objects: %w", err)
+	}
+	for i, m := range messages {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		turns = append(turns, ConversationTurn{Role: role, Content: m})
+	}
+	return turns, nil
+}
+
+// ConversationQueryModifier rewrites follow-up queries like "what about the second one?" into standalone queries
+// using the prior conversation turns, which are passed at retrieval time (see datastore.RetrieveOpts.History).
+// Without history, the queries are passed through unchanged.
+type ConversationQueryModifier struct {
+	Model    llm.LLMConfig
+	MaxTurns int // Maximum number of most recent turns to take into account (default: 10)
+
+	history []ConversationTurn
+}
+
+func (s ConversationQueryModifier) Name() string {
+	return ConversationQueryModifierName
+}
+
+func (s ConversationQueryModifier) WithHistory(history []ConversationTurn) QueryModifier {
+	s.history = history
+	return s
+}
+
+var conversationPromptTpl = `Given the following conversation and a follow-up query, rephrase the follow-up query to be a standalone query,
+which can be understood without the conversation and will be used for a vector similarity search.
+Resolve references like pronouns or "the second one" using the conversation. If the query is already standalone, return it unchanged.
+
+Conversation:
+{{.history}}
+
+Follow-up query: "{{.query}}"
+Reply only with the JSON {"result": "<standalone-query>"}.
+Do not include anything else in your response and don't use markdown highlighting or formatting, just raw JSON.`
+
+type conversationResp struct {
+	Result string `json:"result"`
+}
+
+func (s ConversationQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	if len(s.history) == 0 {
+		return queries, nil
+	}
+
+	m, err := llm.NewFromConfig(s.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTurns := s.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = 10
+	}
+	turns := s.history[max(0, len(s.history)-maxTurns):]
+
+	var sb strings.Builder
+	for _, t := range turns {
+		role := t.Role
+		if role == "" {
+			role = "user"
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s\n", role, t.Content))
+	}
+
+	modifiedQueries := make([]string, len(queries))
+	for i, query := range queries {
+		result, err := m.Prompt(context.Background(), conversationPromptTpl, map[string]interface{}{"query": query, "history": sb.String()})
+		if err != nil {
+			return nil, err
+		}
+		var resp conversationResp
+		err = json.Unmarshal([]byte(result), &resp)
+		if err != nil {
+			return nil, err
+		}
+		modifiedQueries[i] = query
+		if r := strings.TrimSpace(resp.Result); r != "" {
+			modifiedQueries[i] = r
+		}
+	}
+	return modifiedQueries, nil
+}
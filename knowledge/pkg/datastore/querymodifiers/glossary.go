@@ -0,0 +1,130 @@
+package querymodifiers
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const GlossaryQueryModifierName = "glossary"
+
+// Glossary maps domain terms to their expansions, e.g. acronyms and synonyms
+type Glossary struct {
+	Terms    map[string][]string `json:"terms,omitempty"`    // term (e.g. acronym) -> expansions
+	Synonyms [][]string          `json:"synonyms,omitempty"` // sets of synonyms - each term is expanded with all others of its set
+}
+
+// LoadGlossary reads a glossary from a YAML or JSON file
+func LoadGlossary(path string) (*Glossary, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read glossary file: %w", err)
+	}
+	var g Glossary
+	if err := yaml.Unmarshal(content, &g); err != nil {
+		return nil, fmt.Errorf("failed to parse glossary file %q: %w", path, err)
+	}
+	return &g, nil
+}
+
+// expansions returns the lowercased term -> expansions mapping of terms and synonyms
+func (g *Glossary) expansions() map[string][]string {
+	res := map[string][]string{}
+	add := func(term string, exps ...string) {
+		key := strings.ToLower(strings.TrimSpace(term))
+		for _, e := range exps {
+			e = strings.TrimSpace(e)
+			if e != "" && !strings.EqualFold(e, key) && !slices.Contains(res[key], e) {
+				res[key] = append(res[key], e)
+			}
+		}
+	}
+	for term, exps := range g.Terms {
+		add(term, exps...)
+	}
+	for _, set := range g.Synonyms {
+		for _, term := range set {
+			add(term, set...)
+		}
+	}
+	return res
+}
+
+// Expand appends the expansions of all glossary terms found in the query (whole words, case-insensitive) in parentheses
+// after the term, e.g. "SLA for k8s" -> "SLA (service level agreement) for k8s (kubernetes)".
+// Expansions already contained in the query are skipped.
+func (g *Glossary) Expand(query string) string {
+	expansions := g.expansions()
+	if len(expansions) == 0 {
+		return query
+	}
+
+	// longest terms first, so multi-word terms win over contained single words
+	terms := make([]string, 0, len(expansions))
+	for t := range expansions {
+		terms = append(terms, regexp.QuoteMeta(t))
+	}
+	slices.SortFunc(terms, func(a, b string) int { return len(b) - len(a) })
+	re := regexp.MustCompile(`(?i)\b(` + strings.Join(terms, "|") + `)\b`)
+
+	lowerQuery := strings.ToLower(query)
+	expanded := map[string]bool{}
+	return re.ReplaceAllStringFunc(query, func(m string) string {
+		key := strings.ToLower(m)
+		if expanded[key] {
+			return m
+		}
+		expanded[key] = true
+
+		var missing []string
+		for _, e := range expansions[key] {
+			if !strings.Contains(lowerQuery, strings.ToLower(e)) {
+				missing = append(missing, e)
+			}
+		}
+		if len(missing) == 0 {
+			return m
+		}
+		return fmt.Sprintf("%s (%s)", m, strings.Join(missing, ", "))
+	})
+}
+
+// GlossaryQueryModifier expands domain jargon (acronyms, synonyms) in queries using a user-supplied glossary,
+// without an LLM call.
+type GlossaryQueryModifier struct {
+	File     string              // Path to a YAML/JSON glossary file with "terms" and/or "synonyms"
+	Terms    map[string][]string // Inline terms, merged with the file
+	Synonyms [][]string          // Inline synonym sets, merged with the file
+}
+
+func (s GlossaryQueryModifier) Name() string {
+	return GlossaryQueryModifierName
+}
+
+func (s GlossaryQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	g := &Glossary{Terms: map[string][]string{}}
+	if s.File != "" {
+		fg, err := LoadGlossary(s.File)
+		if err != nil {
+			return nil, err
+		}
+		g = fg
+		if g.Terms == nil {
+			g.Terms = map[string][]string{}
+		}
+	}
+	for t, exps := range s.Terms {
+		g.Terms[t] = append(g.Terms[t], exps...)
+	}
+	g.Synonyms = append(g.Synonyms, s.Synonyms...)
+
+	modifiedQueries := make([]string, len(queries))
+	for i, query := range queries {
+		modifiedQueries[i] = g.Expand(query)
+	}
+	return modifiedQueries, nil
+}
@@ -0,0 +1,26 @@
+package querymodifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlossaryExpand(t *testing.T) {
+	g := &Glossary{
+		Terms: map[string][]string{
+			"SLA":            {"service level agreement"},
+			"k8s":            {"kubernetes"},
+			"on-call":        {"pager duty"},
+			"error budget":   {"allowed downtime"},
+			"unused acronym": {"nothing"},
+		},
+		Synonyms: [][]string{{"laptop", "notebook"}},
+	}
+
+	require.Equal(t, "What is the SLA (service level agreement) for k8s (kubernetes)?", g.Expand("What is the SLA for k8s?"))
+	require.Equal(t, "sla (service level agreement) of the Kubernetes k8s cluster", g.Expand("sla of the Kubernetes k8s cluster"))
+	require.Equal(t, "order a Notebook (laptop) for the on-call (pager duty) engineer", g.Expand("order a Notebook for the on-call engineer"))
+	require.Equal(t, "how much error budget (allowed downtime) is left", g.Expand("how much error budget is left"))
+	require.Equal(t, "nothing to expand here", g.Expand("nothing to expand here"))
+}
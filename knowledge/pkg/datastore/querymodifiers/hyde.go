@@ -0,0 +1,57 @@
+package querymodifiers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+)
+
+const HydeQueryModifierName = "hyde"
+
+// HydeQueryModifier implements Hypothetical Document Embeddings (HyDE): instead of the (often short or vague) query,
+// a hypothetical answer generated by the LLM is used for the similarity search, as it's semantically closer to the
+// documents containing the actual answer.
+type HydeQueryModifier struct {
+	Model           llm.LLMConfig
+	IncludeOriginal bool // Keep the original query in addition to the hypothetical answer
+	MaxWords        int  // Approximate maximum length of the hypothetical answer (default: 150)
+}
+
+func (s HydeQueryModifier) Name() string {
+	return HydeQueryModifierName
+}
+
+var hydePromptTpl = `Write a short passage that answers the following question, as it could appear in a document or knowledge base.
+It doesn't matter if you don't know the exact answer, make up plausible facts - the passage will only be used to find similar documents.
+Use at most {{.maxWords}} words. Reply only with the passage, without any introduction or formatting.
+Question: "{{.query}}"`
+
+func (s HydeQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	m, err := llm.NewFromConfig(s.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	maxWords := s.MaxWords
+	if maxWords <= 0 {
+		maxWords = 150
+	}
+
+	var modifiedQueries []string
+	for _, query := range queries {
+		result, err := m.Prompt(context.Background(), hydePromptTpl, map[string]interface{}{"query": query, "maxWords": maxWords})
+		if err != nil {
+			return nil, err
+		}
+		if s.IncludeOriginal {
+			modifiedQueries = append(modifiedQueries, query)
+		}
+		if passage := strings.TrimSpace(result); passage != "" {
+			modifiedQueries = append(modifiedQueries, passage)
+		} else if !s.IncludeOriginal {
+			modifiedQueries = append(modifiedQueries, query)
+		}
+	}
+	return modifiedQueries, nil
+}
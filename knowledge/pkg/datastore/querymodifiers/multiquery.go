@@ -0,0 +1,78 @@
+package querymodifiers
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+)
+
+const MultiQueryModifierName = "multiquery"
+
+// MultiQueryModifier generates paraphrased variants of each query, which are all used for retrieval.
+// This helps with ambiguous questions, where a single query may miss relevant documents.
+// Use the dedupe postprocessor with acrossQueries: true to remove documents found by multiple variants.
+type MultiQueryModifier struct {
+	Model           llm.LLMConfig
+	NumQueries      int  // Number of variants to generate per query (default: 3)
+	IncludeOriginal bool // Keep the original query in addition to the variants
+}
+
+func (s MultiQueryModifier) Name() string {
+	return MultiQueryModifierName
+}
+
+var multiQueryPromptTpl = `The following query will be used for a vector similarity search.
+Generate {{.num}} different versions of it, which rephrase it or look at it from different perspectives, to overcome the limitations of distance-based similarity search.
+Query: "{{.query}}"
+Reply only with the JSON {"results": ["<query-1>", "<query-2>", ...]}.
+Do not include anything else in your response and don't use markdown highlighting or formatting, just raw JSON.`
+
+type multiQueryResp struct {
+	Results []string `json:"results"`
+}
+
+func (s MultiQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	m, err := llm.NewFromConfig(s.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	num := s.NumQueries
+	if num <= 0 {
+		num = 3
+	}
+
+	var modifiedQueries []string
+	add := func(q string) {
+		q = strings.TrimSpace(q)
+		if q != "" && !slices.Contains(modifiedQueries, q) {
+			modifiedQueries = append(modifiedQueries, q)
+		}
+	}
+
+	for _, query := range queries {
+		if s.IncludeOriginal {
+			add(query)
+		}
+		result, err := m.Prompt(context.Background(), multiQueryPromptTpl, map[string]interface{}{"query": query, "num": num})
+		if err != nil {
+			return nil, err
+		}
+		var resp multiQueryResp
+		err = json.Unmarshal([]byte(result), &resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range resp.Results[:min(num, len(resp.Results))] {
+			add(q)
+		}
+	}
+
+	if len(modifiedQueries) == 0 {
+		return queries, nil
+	}
+	return modifiedQueries, nil
+}
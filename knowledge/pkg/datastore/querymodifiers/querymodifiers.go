@@ -10,9 +10,15 @@ type QueryModifier interface {
 }
 
 var QueryModifiers = map[string]QueryModifier{
-	SpellcheckQueryModifierName: SpellcheckQueryModifier{},
-	EnhanceQueryModifierName:    EnhanceQueryModifier{},
-	GenericQueryModifierName:    GenericQueryModifier{},
+	SpellcheckQueryModifierName:   SpellcheckQueryModifier{},
+	EnhanceQueryModifierName:      EnhanceQueryModifier{},
+	GenericQueryModifierName:      GenericQueryModifier{},
+	HydeQueryModifierName:         HydeQueryModifier{},
+	MultiQueryModifierName:        MultiQueryModifier{},
+	ConversationQueryModifierName: ConversationQueryModifier{},
+	TranslateQueryModifierName:    TranslateQueryModifier{},
+	GlossaryQueryModifierName:     GlossaryQueryModifier{},
+	SpellCorrectQueryModifierName: SpellCorrectQueryModifier{},
 }
 
 func GetQueryModifier(name string) (QueryModifier, error) {
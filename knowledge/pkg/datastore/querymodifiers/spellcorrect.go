@@ -0,0 +1,144 @@
+package querymodifiers
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const SpellCorrectQueryModifierName = "spellcorrect"
+
+// VocabularyAwareQueryModifier is implemented by query modifiers which need the vocabulary (term frequencies) of the queried datasets
+type VocabularyAwareQueryModifier interface {
+	QueryModifier
+	WithVocabulary(vocabulary map[string]int) QueryModifier
+}
+
+var wordPattern = regexp.MustCompile(`\p{L}+`)
+
+// SpellCorrectQueryModifier corrects typos in queries by replacing unknown words with the most frequent word of the
+// dataset vocabulary within a small edit distance - no LLM roundtrip required.
+// The vocabulary is built at ingestion time (--build-vocabulary). Without vocabulary, queries are passed through unchanged.
+type SpellCorrectQueryModifier struct {
+	MaxDistance   int // Maximum edit distance for corrections (default: 1 for words up to 5 letters, 2 for longer words)
+	MinWordLength int // Words shorter than this are never corrected (default: 4)
+	MinFrequency  int // Minimum frequency of a vocabulary term to be used as correction (default: 1)
+
+	vocabulary map[string]int
+}
+
+func (s SpellCorrectQueryModifier) Name() string {
+	return SpellCorrectQueryModifierName
+}
+
+func (s SpellCorrectQueryModifier) WithVocabulary(vocabulary map[string]int) QueryModifier {
+	s.vocabulary = vocabulary
+	return s
+}
+
+func (s SpellCorrectQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	if len(s.vocabulary) == 0 {
+		slog.Debug("No vocabulary available, skipping spell correction - ingest with --build-vocabulary")
+		return queries, nil
+	}
+
+	modifiedQueries := make([]string, len(queries))
+	for i, query := range queries {
+		modifiedQueries[i] = s.Correct(query)
+	}
+	return modifiedQueries, nil
+}
+
+// Correct replaces all words of the query which are not in the vocabulary with their best correction, if any
+func (s SpellCorrectQueryModifier) Correct(query string) string {
+	minWordLength := s.MinWordLength
+	if minWordLength <= 0 {
+		minWordLength = 4
+	}
+
+	return wordPattern.ReplaceAllStringFunc(query, func(word string) string {
+		lower := strings.ToLower(word)
+		if utf8.RuneCountInString(lower) < minWordLength {
+			return word
+		}
+		if _, ok := s.vocabulary[lower]; ok {
+			return word
+		}
+
+		correction, ok := s.bestCorrection(lower)
+		if !ok {
+			return word
+		}
+		slog.Debug("Corrected word", "word", word, "correction", correction)
+
+		// keep the capitalization of the first letter
+		if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+			c, size := utf8.DecodeRuneInString(correction)
+			correction = string(unicode.ToUpper(c)) + correction[size:]
+		}
+		return correction
+	})
+}
+
+func (s SpellCorrectQueryModifier) bestCorrection(word string) (string, bool) {
+	wordRunes := []rune(word)
+
+	maxDistance := s.MaxDistance
+	if maxDistance <= 0 {
+		maxDistance = 1
+		if len(wordRunes) > 5 {
+			maxDistance = 2
+		}
+	}
+	minFrequency := max(s.MinFrequency, 1)
+
+	best, bestDistance, bestFrequency := "", maxDistance+1, 0
+	for term, frequency := range s.vocabulary {
+		if frequency < minFrequency {
+			continue
+		}
+		termRunes := []rune(term)
+		if abs(len(termRunes)-len(wordRunes)) > maxDistance {
+			continue
+		}
+		d := editDistance(wordRunes, termRunes)
+		if d < bestDistance || (d == bestDistance && (frequency > bestFrequency || (frequency == bestFrequency && term < best))) {
+			best, bestDistance, bestFrequency = term, d, frequency
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the Damerau-Levenshtein distance (optimal string alignment) of a and b
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
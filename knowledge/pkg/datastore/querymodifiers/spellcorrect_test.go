@@ -0,0 +1,25 @@
+package querymodifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpellCorrect(t *testing.T) {
+	m := SpellCorrectQueryModifier{}.WithVocabulary(map[string]int{
+		"kubernetes": 10,
+		"deployment": 5,
+		"deploy":     3,
+		"cluster":    7,
+		"clusters":   1,
+		"the":        100,
+	}).(SpellCorrectQueryModifier)
+
+	require.Equal(t, "How to deploy the Kubernetes cluster", m.Correct("How to deplyo the Kuberntes clustr"))
+	require.Equal(t, "teh deployment", m.Correct("teh deployments")) // "teh" is too short to be corrected
+	require.Equal(t, "xyzzy", m.Correct("xyzzy"))
+
+	require.Equal(t, 1, editDistance([]rune("ab"), []rune("ba")))
+	require.Equal(t, 3, editDistance([]rune("kitten"), []rune("sitting")))
+}
@@ -0,0 +1,110 @@
+package querymodifiers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+)
+
+const TranslateQueryModifierName = "translate"
+
+// DatasetMetadataKeyLanguage is the dataset metadata key holding the dominant language of a dataset,
+// e.g. set via `knowledge edit-dataset --update-metadata language=English`
+const DatasetMetadataKeyLanguage = "language"
+
+// DatasetAwareQueryModifier is implemented by query modifiers which take the queried datasets into account
+type DatasetAwareQueryModifier interface {
+	QueryModifier
+	WithDatasets(datasets []types.Dataset) QueryModifier
+}
+
+// TranslateQueryModifier translates queries into the dominant language of the queried datasets, so users can query
+// corpora in other languages. The target language is either configured or read from the dataset metadata ("language").
+// If the datasets have different languages, the query is translated into each of them.
+type TranslateQueryModifier struct {
+	Model           llm.LLMConfig
+	TargetLanguage  string // Language to translate into - overrides the dataset metadata
+	IncludeOriginal bool   // Keep the original query in addition to the translation(s)
+
+	datasetLanguages []string
+}
+
+func (s TranslateQueryModifier) Name() string {
+	return TranslateQueryModifierName
+}
+
+func (s TranslateQueryModifier) WithDatasets(datasets []types.Dataset) QueryModifier {
+	s.datasetLanguages = nil
+	for _, ds := range datasets {
+		lang, ok := ds.Metadata[DatasetMetadataKeyLanguage]
+		if !ok {
+			continue
+		}
+		if l := strings.TrimSpace(fmt.Sprint(lang)); l != "" && !slices.Contains(s.datasetLanguages, l) {
+			s.datasetLanguages = append(s.datasetLanguages, l)
+		}
+	}
+	return s
+}
+
+var translatePromptTpl = `The following query will be used for a vector similarity search over documents written in {{.language}}.
+Detect the language of the query. If it is not {{.language}}, translate it into {{.language}}, otherwise keep it unchanged.
+Keep names, product names, code and identifiers as they are.
+Query: "{{.query}}"
+Reply only with the JSON {"language": "<detected-language>", "result": "<translated-query>"}.
+Do not include anything else in your response and don't use markdown highlighting or formatting, just raw JSON.`
+
+type translateResp struct {
+	Language string `json:"language"`
+	Result   string `json:"result"`
+}
+
+func (s TranslateQueryModifier) ModifyQueries(queries []string) ([]string, error) {
+	languages := s.datasetLanguages
+	if s.TargetLanguage != "" {
+		languages = []string{s.TargetLanguage}
+	}
+	if len(languages) == 0 {
+		return queries, nil
+	}
+
+	m, err := llm.NewFromConfig(s.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	var modifiedQueries []string
+	add := func(q string) {
+		q = strings.TrimSpace(q)
+		if q != "" && !slices.Contains(modifiedQueries, q) {
+			modifiedQueries = append(modifiedQueries, q)
+		}
+	}
+
+	for _, query := range queries {
+		if s.IncludeOriginal {
+			add(query)
+		}
+		for _, lang := range languages {
+			result, err := m.Prompt(context.Background(), translatePromptTpl, map[string]interface{}{"query": query, "language": lang})
+			if err != nil {
+				return nil, err
+			}
+			var resp translateResp
+			err = json.Unmarshal([]byte(result), &resp)
+			if err != nil {
+				return nil, err
+			}
+			if resp.Result == "" {
+				resp.Result = query
+			}
+			add(resp.Result)
+		}
+	}
+	return modifiedQueries, nil
+}
@@ -0,0 +1,153 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
+	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+type ReEmbedOpts struct {
+	EmbeddingPreprocessing *preprocessing.Options // Embedding input preprocessing - should match the one used for ingestion
+}
+
+// shadowCollectionSuffix is appended to the dataset ID for the shadow collection used to re-embed a dataset,
+// if the vector store can't swap embeddings itself
+const shadowCollectionSuffix = "_reembed"
+
+// ReEmbedDataset re-embeds all documents of the dataset with the configured embedding model provider, e.g. to migrate the dataset
+// to another embedding model without re-ingesting the source files. The document contents are read back from the vector store and
+// the new embeddings replace the old ones only once all documents were embedded. Finally, the dataset's embeddings config is updated.
+// It returns the number of re-embedded documents.
+func (s *Datastore) ReEmbedDataset(ctx context.Context, datasetID string, opts ReEmbedOpts) (int, error) {
+	ds, err := s.GetDataset(ctx, datasetID, nil)
+	if err != nil {
+		return 0, err
+	}
+	if ds == nil {
+		return 0, fmt.Errorf("dataset %q not found", datasetID)
+	}
+
+	if lc, ok := s.EmbeddingModelProvider.(etypes.LateChunkingEmbeddingModelProvider); ok && lc.LateChunkingEnabled() {
+		return 0, errors.New("re-embedding with late chunking is not supported, as the documents have to be embedded per file - re-ingest the dataset instead")
+	}
+
+	ncfg, err := embeddings.AsEmbeddingModelProviderConfig(s.EmbeddingModelProvider, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get embedding model provider config: %w", err)
+	}
+
+	docs, err := s.Vectorstore.GetDocuments(ctx, datasetID, nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get documents of dataset %q: %w", datasetID, err)
+	}
+
+	slog.Info("Re-embedding dataset", "dataset", datasetID, "documents", len(docs), "provider", s.EmbeddingModelProvider.Name(), "model", s.EmbeddingModelProvider.EmbeddingModelName())
+	startTime := time.Now()
+
+	ctx = preprocessing.ToCtx(ctx, opts.EmbeddingPreprocessing)
+	ctx = ratelimit.ToCtx(ctx, s.embeddingLimiter)
+
+	batchEmbeddingFunc, batchSize := s.batchEmbeddingFunc, s.batchSize
+	if batchEmbeddingFunc == nil {
+		embeddingFunc, err := s.EmbeddingModelProvider.EmbeddingFunc()
+		if err != nil {
+			return 0, fmt.Errorf("failed to create embedding function: %w", err)
+		}
+		batchEmbeddingFunc, batchSize = singleEmbeddingBatchFunc(embeddingFunc, 1), 1
+	}
+
+	for i := range docs {
+		docs[i].Embedding = nil
+	}
+	if err := embedBatches(ctx, batchEmbeddingFunc, batchSize, docs); err != nil {
+		return 0, fmt.Errorf("failed to re-embed documents of dataset %q: %w", datasetID, err)
+	}
+	slog.Info("Embedded documents", "dataset", datasetID, "documents", len(docs), "duration", time.Since(startTime))
+
+	if swapper, ok := vectorstore.As[vectorstore.EmbeddingsSwapper](s.Vectorstore); ok {
+		newEmbeddings := make(map[string][]float32, len(docs))
+		for _, doc := range docs {
+			newEmbeddings[doc.ID] = doc.Embedding
+		}
+		if err := swapper.SwapEmbeddings(ctx, datasetID, newEmbeddings); err != nil {
+			return 0, fmt.Errorf("failed to swap embeddings of dataset %q: %w", datasetID, err)
+		}
+	} else if err := s.replaceCollection(ctx, datasetID, docs); err != nil {
+		return 0, err
+	}
+
+	if _, err := s.UpdateDataset(ctx, types.Dataset{ID: datasetID, EmbeddingsProviderConfig: &ncfg}, nil); err != nil {
+		return 0, fmt.Errorf("failed to update embeddings config of dataset %q: %w", datasetID, err)
+	}
+
+	slog.Info("Re-embedded dataset", "dataset", datasetID, "documents", len(docs), "duration", time.Since(startTime))
+	return len(docs), nil
+}
+
+// replaceCollection replaces the dataset's collection with the (re-embedded) documents for vector stores which can't swap embeddings:
+// the documents are written to a shadow collection first, which is kept in case replacing the dataset's collection fails.
+func (s *Datastore) replaceCollection(ctx context.Context, datasetID string, docs []vs.Document) error {
+	shadow := datasetID + shadowCollectionSuffix
+
+	if err := s.Vectorstore.RemoveCollection(ctx, shadow); err != nil {
+		slog.Debug("Failed to remove leftover shadow collection", "collection", shadow, "error", err)
+	}
+	if err := s.Vectorstore.CreateCollection(ctx, shadow, nil); err != nil {
+		return fmt.Errorf("failed to create shadow collection %q: %w", shadow, err)
+	}
+	if _, err := s.Vectorstore.AddDocuments(ctx, append([]vs.Document(nil), docs...), shadow); err != nil {
+		return fmt.Errorf("failed to add documents to shadow collection %q: %w", shadow, err)
+	}
+
+	slog.Warn("The vector store doesn't support swapping embeddings atomically - replacing the collection", "dataset", datasetID, "shadowCollection", shadow)
+	if err := s.Vectorstore.RemoveCollection(ctx, datasetID); err != nil {
+		return fmt.Errorf("failed to remove collection of dataset %q: %w", datasetID, err)
+	}
+	if err := s.Vectorstore.CreateCollection(ctx, datasetID, nil); err != nil {
+		return fmt.Errorf("failed to re-create collection of dataset %q (the re-embedded documents are kept in collection %q): %w", datasetID, shadow, err)
+	}
+	if _, err := s.Vectorstore.AddDocuments(ctx, docs, datasetID); err != nil {
+		return fmt.Errorf("failed to add re-embedded documents to dataset %q (they are kept in collection %q): %w", datasetID, shadow, err)
+	}
+
+	if err := s.Vectorstore.RemoveCollection(ctx, shadow); err != nil {
+		slog.Warn("Failed to remove shadow collection", "collection", shadow, "error", err)
+	}
+	return nil
+}
+
+// singleEmbeddingBatchFunc embeds the texts one by one with up to concurrency parallel requests,
+// for embedding model providers not supporting batches
+func singleEmbeddingBatchFunc(embeddingFunc vs.EmbeddingFunc, concurrency int) etypes.BatchEmbeddingFunc {
+	return func(ctx context.Context, texts []string) ([][]float32, error) {
+		embeddings := make([][]float32, len(texts))
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(concurrency)
+		for i, text := range texts {
+			g.Go(func() error {
+				emb, err := embeddingFunc(gctx, text)
+				if err != nil {
+					return err
+				}
+				embeddings[i] = emb
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+		return embeddings, nil
+	}
+}
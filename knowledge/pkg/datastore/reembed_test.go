@@ -0,0 +1,68 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVectorStore records the collection operations, all other methods panic
+type testVectorStore struct {
+	vectorstore.VectorStore
+	ops         []string
+	collections map[string][]vs.Document
+}
+
+func (v *testVectorStore) CreateCollection(_ context.Context, collection string, _ *dbtypes.DatasetCreateOpts) error {
+	v.ops = append(v.ops, "create "+collection)
+	v.collections[collection] = nil
+	return nil
+}
+
+func (v *testVectorStore) RemoveCollection(_ context.Context, collection string) error {
+	v.ops = append(v.ops, "remove "+collection)
+	delete(v.collections, collection)
+	return nil
+}
+
+func (v *testVectorStore) AddDocuments(_ context.Context, docs []vs.Document, collection string) ([]string, error) {
+	v.ops = append(v.ops, "add "+collection)
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	v.collections[collection] = append(v.collections[collection], docs...)
+	return ids, nil
+}
+
+func TestReplaceCollection(t *testing.T) {
+	store := &testVectorStore{collections: map[string][]vs.Document{
+		"foo": {{ID: "1", Content: "a", Embedding: []float32{1}}},
+	}}
+	s := &Datastore{Vectorstore: store}
+
+	docs := []vs.Document{{ID: "1", Content: "a", Embedding: []float32{2, 3}}}
+	require.NoError(t, s.replaceCollection(context.Background(), "foo", docs))
+
+	assert.Equal(t, []string{
+		"remove foo_reembed", "create foo_reembed", "add foo_reembed",
+		"remove foo", "create foo", "add foo",
+		"remove foo_reembed",
+	}, store.ops)
+	assert.Equal(t, map[string][]vs.Document{"foo": docs}, store.collections)
+}
+
+func TestSingleEmbeddingBatchFunc(t *testing.T) {
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		return []float32{float32(len(text))}, nil
+	}
+
+	embeddings, err := singleEmbeddingBatchFunc(embeddingFunc, 2)(context.Background(), []string{"a", "bbb", "cc"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1}, {3}, {2}}, embeddings)
+}
@@ -2,15 +2,24 @@ package datastore
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/ratelimit"
 	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/flows"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
 	types2 "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/mitchellh/copystructure"
 )
@@ -19,11 +28,33 @@ type RetrieveOpts struct {
 	TopK          int
 	Keywords      []string
 	RetrievalFlow *flows.RetrievalFlow
+	History       []querymodifiers.ConversationTurn // prior conversation turns for history-aware query modifiers
+	Filters       map[string][]string               // metadata the retrieved documents must match, e.g. markdown front matter like tags=kubernetes
+	Where         map[string]any                    // metadata filter with operators, e.g. {"year": {"$gte": 2020}} - see vectorstore/types.MetadataFilter
+	Offset        int                               // skip the most similar results of each dataset, to page through the results beyond the top k
+	Weights       types.DatasetWeights              // per-dataset top k and score weights in multi-dataset retrieval, overriding those of the flow
+	Tags          []string                          // tags the retrieved documents' files must all have (see TagFile)
 }
 
 func (s *Datastore) Retrieve(ctx context.Context, datasetIDs []string, query string, opts RetrieveOpts) (*types.RetrievalResponse, error) {
+	startTime := time.Now()
+	resp, err := s.retrieve(ctx, datasetIDs, query, opts)
+	if errors.Is(err, vserr.ErrCollectionEmpty) {
+		// not a failure, there's just nothing to retrieve yet
+		metrics.ObserveRetrieval(startTime, nil)
+	} else {
+		metrics.ObserveRetrieval(startTime, err)
+	}
+	return resp, err
+}
+
+func (s *Datastore) retrieve(ctx context.Context, datasetIDs []string, query string, opts RetrieveOpts) (*types.RetrievalResponse, error) {
 	slog.Debug("Retrieving content from dataset", "dataset", datasetIDs, "query", query)
 
+	// embedding providers may embed queries differently from documents
+	ctx = etypes.QueryToCtx(ctx)
+	ctx = ratelimit.ToCtx(ctx, s.embeddingLimiter)
+
 	retrievalFlow := opts.RetrievalFlow
 	if retrievalFlow == nil {
 		retrievalFlow = &flows.RetrievalFlow{}
@@ -34,6 +65,35 @@ func (s *Datastore) Retrieve(ctx context.Context, datasetIDs []string, query str
 	}
 	retrievalFlow.FillDefaults(topK)
 
+	if opts.Offset > 0 {
+		searchOpts := types2.SearchOptionsFromCtx(ctx)
+		searchOpts.Offset = opts.Offset
+		ctx = types2.SearchOptionsToCtx(ctx, searchOpts)
+	}
+
+	where := whereWithTags(opts.Where, opts.Tags)
+	if len(where) > 0 {
+		filter, err := types2.MetadataFilterFromMap(where)
+		if err != nil {
+			return nil, err
+		}
+		// vector stores supporting it apply the filter in the similarity search, so that the top k results all match
+		searchOpts := types2.SearchOptionsFromCtx(ctx)
+		searchOpts.Filter = filter
+		ctx = types2.SearchOptionsToCtx(ctx, searchOpts)
+	}
+
+	if len(opts.Filters) > 0 || len(where) > 0 {
+		// filter before any other postprocessor, so that e.g. rerankers and reducers only see matching documents
+		filters := make(map[string]any, len(opts.Filters))
+		for k, v := range opts.Filters {
+			filters[k] = v
+		}
+		filteredFlow := *retrievalFlow // don't modify the configured flow, which may be reused
+		filteredFlow.Postprocessors = append([]postprocessors.Postprocessor{&postprocessors.MetadataFilterPostprocessor{Filters: filters, Where: where}}, retrievalFlow.Postprocessors...)
+		retrievalFlow = &filteredFlow
+	}
+
 	var whereDocs []types2.WhereDocument
 	if len(opts.Keywords) > 0 {
 		whereDoc := types2.WhereDocument{
@@ -65,7 +125,22 @@ func (s *Datastore) Retrieve(ctx context.Context, datasetIDs []string, query str
 		}
 	}
 
-	return retrievalFlow.Run(ctx, s, query, datasetIDs, &flows.RetrievalFlowOpts{Where: nil, WhereDocument: whereDocs})
+	return retrievalFlow.Run(ctx, s, query, datasetIDs, &flows.RetrievalFlowOpts{Where: nil, WhereDocument: whereDocs, History: opts.History, DatasetWeights: opts.Weights})
+}
+
+// whereWithTags adds the condition that the documents have all the tags (stored as list in their metadata) to the metadata filter
+func whereWithTags(where map[string]any, tags []string) map[string]any {
+	if len(tags) == 0 {
+		return where
+	}
+	conditions := make([]any, 0, len(tags)+1)
+	if len(where) > 0 {
+		conditions = append(conditions, where)
+	}
+	for _, tag := range tags {
+		conditions = append(conditions, map[string]any{MetadataKeyFileTags: tag})
+	}
+	return map[string]any{"$and": conditions}
 }
 
 func (s *Datastore) SimilaritySearch(ctx context.Context, query string, numDocuments int, datasetID string, where map[string]string, whereDocument []types2.WhereDocument) ([]types2.Document, error) {
@@ -79,30 +154,82 @@ func (s *Datastore) SimilaritySearch(ctx context.Context, query string, numDocum
 		if err != nil {
 			return nil, err
 		}
+
+		var useModel, useDimensions bool
 		if s.EmbeddingModelProvider.EmbeddingModelName() != dsEmbeddingProvider.EmbeddingModelName() {
 			slog.Warn("Embeddings model mismatch", "dataset", datasetID, "attached", dsEmbeddingProvider.EmbeddingModelName(), "configured", s.EmbeddingModelProvider.EmbeddingModelName())
-			if os.Getenv("KNOW_PREFER_NEW_EMBEDDING_MODEL") == "" {
+			useModel = os.Getenv("KNOW_PREFER_NEW_EMBEDDING_MODEL") == ""
+		}
+
+		// The query embedding must have the same dimensions as the dataset's embeddings
+		dsDims := etypes.EmbeddingDimensions(dsEmbeddingProvider)
+		if _, ok := s.EmbeddingModelProvider.(etypes.DimensionsEmbeddingModelProvider); ok && s.EmbeddingModelProvider.Name() == ds.EmbeddingsProviderConfig.Type && dsDims != etypes.EmbeddingDimensions(s.EmbeddingModelProvider) {
+			slog.Warn("Embeddings dimensions mismatch", "dataset", datasetID, "attached", dsDims, "configured", etypes.EmbeddingDimensions(s.EmbeddingModelProvider))
+			useDimensions = true
+		}
+
+		if useModel || useDimensions {
+			copied, err := copystructure.Copy(s.EmbeddingModelProvider)
+			if err != nil {
+				return nil, err
+			}
+			if useModel {
 				slog.Info("Using dataset's embeddings model", "model", dsEmbeddingProvider.EmbeddingModelName())
-				copied, err := copystructure.Copy(s.EmbeddingModelProvider)
-				if err != nil {
-					return nil, err
-				}
 				copied.(etypes.EmbeddingModelProvider).UseEmbeddingModel(dsEmbeddingProvider.EmbeddingModelName())
-				ef, err = copied.(etypes.EmbeddingModelProvider).EmbeddingFunc()
-				if err != nil {
-					return nil, err
-				}
-				slog.Debug("Using dataset specific embedding function", "dataset", datasetID, "model", dsEmbeddingProvider.Name(), "newProviderConfig", output.RedactSensitive(copied.(etypes.EmbeddingModelProvider)))
 			}
+			if useDimensions {
+				slog.Info("Using dataset's embeddings dimensions", "dimensions", dsDims)
+				copied.(etypes.DimensionsEmbeddingModelProvider).UseEmbeddingDimensions(dsDims)
+			}
+			ef, err = copied.(etypes.EmbeddingModelProvider).EmbeddingFunc()
+			if err != nil {
+				return nil, err
+			}
+			ef = preprocessing.WrapEmbeddingFunc(metricsEmbeddingFunc(s.EmbeddingModelProvider.Name(), ef))
+			slog.Debug("Using dataset specific embedding function", "dataset", datasetID, "model", dsEmbeddingProvider.Name(), "newProviderConfig", output.RedactSensitive(copied.(etypes.EmbeddingModelProvider)))
 		}
 	}
-	docs, err := s.Vectorstore.SimilaritySearch(ctx, query, numDocuments, datasetID, where, whereDocument, ef)
+	// vector stores that can't skip results natively return the results before the offset as well, which are dropped here
+	var offset int
+	if pager, ok := vectorstore.As[vectorstore.Pager](s.Vectorstore); !ok || !pager.SupportsPaging() {
+		offset = max(types2.SearchOptionsFromCtx(ctx).Offset, 0)
+	}
+	docs, err := s.Vectorstore.SimilaritySearch(ctx, query, numDocuments+offset, datasetID, where, whereDocument, ef)
 	if err != nil {
+		if !errors.Is(err, vserr.ErrCollectionEmpty) {
+			metrics.ObserveVectorStoreError("similarity_search", err)
+		}
 		return nil, err
 	}
+	docs = docs[min(offset, len(docs)):]
 	for i, doc := range docs {
 		doc.Metadata["datasetID"] = datasetID
 		docs[i] = doc
 	}
 	return docs, nil
 }
+
+// KeywordSearch runs a full-text keyword search over the document contents stored in the Index.
+// Only documents ingested with IngestOpts.IndexContent can be found this way.
+func (s *Datastore) KeywordSearch(ctx context.Context, query string, numDocuments int, datasetID string) ([]types2.Document, error) {
+	results, err := s.Index.KeywordSearch(ctx, datasetID, query, numDocuments)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]types2.Document, 0, len(results))
+	for _, r := range results {
+		metadata := r.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadata["datasetID"] = datasetID
+		docs = append(docs, types2.Document{
+			ID:              r.ID,
+			Content:         r.Content,
+			Metadata:        metadata,
+			SimilarityScore: float32(r.Score),
+		})
+	}
+	return docs, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
@@ -22,6 +23,7 @@ type BM25Retriever struct {
 	B  float64 // B should be around 0.75 - controls the influence of document length normalization
 
 	CleanStopWords []string // list of stopwords to remove from the documents - if empty, no stopwords are removed, if only "auto" is present, the language is detected automatically
+	MetadataFields []string // metadata fields whose values are scored along with the content, e.g. "keyphrases"
 }
 
 func (r *BM25Retriever) Name() string {
@@ -36,10 +38,21 @@ func (r *BM25Retriever) DecodeConfig(cfg map[string]any) error {
 	return DefaultConfigDecoder(r, cfg)
 }
 
+func (r *BM25Retriever) GetTopK() int {
+	return r.TopN
+}
+
+func (r *BM25Retriever) SetTopK(topK int) {
+	r.TopN = topK
+}
+
 func (r *BM25Retriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
 	log := slog.With("component", "BM25Retriever")
 
+	// The documents of all datasets are scored together, so that the term statistics cover all of them,
+	// but the dataset weights are applied per dataset
 	var docs []vs.Document
+	var datasets []datasetDocs
 	for _, datasetID := range datasetIDs {
 		// TODO: make configurable via RetrieveOpts
 		// silently ignore non-existent datasets
@@ -61,6 +74,7 @@ func (r *BM25Retriever) Retrieve(ctx context.Context, store store.Store, query s
 			log.Error("Failed to retrieve documents from dataset", "dataset", datasetID, "error", err)
 			return nil, err
 		}
+		datasets = append(datasets, datasetDocs{datasetID: datasetID, start: len(docs), end: len(docs) + len(docsDataset)})
 		docs = append(docs, docsDataset...)
 	}
 
@@ -69,7 +83,7 @@ func (r *BM25Retriever) Retrieve(ctx context.Context, store store.Store, query s
 		return nil, nil
 	}
 
-	bm25scores, err := bm25.BM25Run(docs, query, r.K1, r.B, r.CleanStopWords)
+	bm25scores, err := bm25.BM25Run(docs, query, r.K1, r.B, r.CleanStopWords, r.MetadataFields)
 	if err != nil {
 		log.Error("Failed to run BM25", "error", err)
 		return nil, err
@@ -80,12 +94,28 @@ func (r *BM25Retriever) Retrieve(ctx context.Context, store store.Store, query s
 		// slog.Debug("BM25 score", "docID", docs[i].ID, "score", docs[i].SimilarityScore)
 	}
 
-	slices.SortFunc(docs, scores.SortBySimilarityScore)
+	weights := dstypes.DatasetWeightsFromCtx(ctx)
+
+	var results []vs.Document
+	for _, ds := range datasets {
+		dsDocs := docs[ds.start:ds.end]
+		weights.Apply(ds.datasetID, dsDocs)
+		slices.SortFunc(dsDocs, scores.SortBySimilarityScore)
+		results = append(results, dsDocs[:min(weights.TopK(ds.datasetID, r.TopN), len(dsDocs))]...)
+	}
+
+	slices.SortFunc(results, scores.SortBySimilarityScore)
 
 	topN := r.TopN
-	if topN > len(docs) {
-		topN = len(docs)
+	if topN > len(results) {
+		topN = len(results)
 	}
 
-	return docs[:topN], nil
+	return results[:topN], nil
+}
+
+// datasetDocs is the range of a dataset's documents in the BM25 corpus
+type datasetDocs struct {
+	datasetID  string
+	start, end int
 }
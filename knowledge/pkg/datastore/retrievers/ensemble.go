@@ -0,0 +1,143 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acorn-io/z"
+	"github.com/mitchellh/mapstructure"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const EnsembleRetrieverName = "ensemble"
+
+// EnsembleRetriever runs multiple retrievers in parallel and combines their results via weighted reciprocal rank fusion (RRF).
+// In contrast to the MergingRetriever, which adds up the (normalized) scores, only the ranks are used,
+// so retrievers with incomparable scores (e.g. BM25, routing and subquery) can be blended without skewing the results.
+type EnsembleRetriever struct {
+	TopK int
+
+	// RRFK is the rank constant of the reciprocal rank fusion (default: 60)
+	RRFK int
+
+	Retrievers []RetrieverToMerge `json:"retrievers" mapstructure:"retrievers" yaml:"retrievers"`
+	retrievers []Retriever
+
+	// baseTopK and baseSubTopK are the initial TopK values, used to scale the ensemble members' TopK in SetTopK
+	baseTopK    int
+	baseSubTopK []int
+}
+
+// NewEnsembleRetriever creates an EnsembleRetriever from the given retriever configurations
+func NewEnsembleRetriever(topK int, retrievers []RetrieverToMerge) (*EnsembleRetriever, error) {
+	r := &EnsembleRetriever{TopK: topK, RRFK: scores.DefaultRRFK, Retrievers: retrievers}
+	return r, r.init()
+}
+
+func (r *EnsembleRetriever) Name() string {
+	return EnsembleRetrieverName
+}
+
+func (r *EnsembleRetriever) NormalizedScores() bool {
+	return true
+}
+
+func (r *EnsembleRetriever) DecodeConfig(cfg map[string]any) error {
+	if err := mapstructure.Decode(cfg, &r); err != nil {
+		return fmt.Errorf("failed to decode ensemble retriever configuration: %w", err)
+	}
+	return r.init()
+}
+
+// init instantiates the configured ensemble members
+func (r *EnsembleRetriever) init() error {
+	if len(r.Retrievers) == 0 {
+		return fmt.Errorf("ensemble retriever requires at least one retriever")
+	}
+
+	r.retrievers = make([]Retriever, len(r.Retrievers))
+	for i, retrieverConfig := range r.Retrievers {
+		if retrieverConfig.Name == EnsembleRetrieverName {
+			return fmt.Errorf("ensemble retrievers can't be nested")
+		}
+		retriever, err := GetRetriever(retrieverConfig.Name)
+		if err != nil {
+			return err
+		}
+		if err := retriever.DecodeConfig(retrieverConfig.Options); err != nil {
+			return err
+		}
+		r.retrievers[i] = retriever
+	}
+	r.baseSubTopK = nil
+
+	return nil
+}
+
+func (r *EnsembleRetriever) GetTopK() int {
+	return r.TopK
+}
+
+// SetTopK sets the number of documents to return and scales the TopK of the ensemble members by the same factor.
+func (r *EnsembleRetriever) SetTopK(topK int) {
+	if r.baseSubTopK == nil {
+		r.baseTopK = max(r.TopK, 1)
+		r.baseSubTopK = make([]int, len(r.retrievers))
+		for i, ret := range r.retrievers {
+			if tr, ok := ret.(TopKRetriever); ok {
+				r.baseSubTopK[i] = tr.GetTopK()
+			}
+		}
+	}
+	r.TopK = topK
+	for i, ret := range r.retrievers {
+		if tr, ok := ret.(TopKRetriever); ok && r.baseSubTopK[i] > 0 {
+			tr.SetTopK(max(1, r.baseSubTopK[i]*topK/r.baseTopK))
+		}
+	}
+}
+
+func (r *EnsembleRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	log := slog.With("retriever", r.Name())
+
+	if r.TopK <= 0 {
+		log.Debug("[EnsembleRetriever] TopK not set, using default", "default", defaults.TopK)
+		r.TopK = defaults.TopK
+	}
+
+	lists := make([]scores.RankedList, len(r.retrievers))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, retriever := range r.retrievers {
+		cfg := r.Retrievers[i]
+		g.Go(func() error {
+			docs, err := retriever.Retrieve(gctx, store, query, datasetIDs, where, whereDocument)
+			if err != nil {
+				return fmt.Errorf("retriever %q failed: %w", cfg.Name, err)
+			}
+			log.Debug("Retrieved documents from ensemble member", "member", cfg.Name, "numDocs", len(docs))
+
+			// Name the list by position, so that the same retriever can be used multiple times with different options
+			name := cfg.Name
+			if len(r.retrievers) > 1 {
+				name = fmt.Sprintf("%s#%d", cfg.Name, i)
+			}
+			lists[i] = scores.RankedList{Name: name, Weight: z.Dereference(cfg.Weight), Documents: docs}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	results := scores.ReciprocalRankFusion(r.RRFK, lists...)
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results[:min(r.TopK, len(results))], nil
+}
@@ -0,0 +1,141 @@
+package retrievers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/bm25"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const HybridRetrieverName = "hybrid"
+
+// HybridRetriever runs a keyword search (BM25 over the stored document contents or full-text search in the Index) and a vector similarity search in parallel
+// and fuses the results using reciprocal rank fusion (RRF).
+// The keyword search helps with exact matches like error codes, IDs or part numbers, which pure semantic search tends to miss.
+type HybridRetriever struct {
+	TopK int
+
+	// CandidatesTopK is the number of documents to fetch from each of the searches before fusion (default: 2*TopK)
+	CandidatesTopK int
+
+	// RRFK is the rank constant of the reciprocal rank fusion (default: 60)
+	RRFK int
+
+	// VectorWeight and KeywordWeight weigh the searches in the fusion (default: 1 each)
+	VectorWeight  float32
+	KeywordWeight float32
+
+	// KeywordSource selects the keyword search: "bm25" (default) runs BM25 in memory over all documents of the datasets,
+	// "index" uses the full-text search of the Index database (requires ingestion with --index-content)
+	KeywordSource string
+
+	// BM25 parameters for the keyword search
+	K1             float64
+	B              float64
+	CleanStopWords []string // list of stopwords to remove from the documents - if empty, no stopwords are removed, if only "auto" is present, the language is detected automatically
+	MetadataFields []string // metadata fields whose values are scored along with the content, e.g. "keyphrases"
+}
+
+func (r *HybridRetriever) Name() string {
+	return HybridRetrieverName
+}
+
+func (r *HybridRetriever) NormalizedScores() bool {
+	return true
+}
+
+func (r *HybridRetriever) DecodeConfig(cfg map[string]any) error {
+	return DefaultConfigDecoder(r, cfg)
+}
+
+func (r *HybridRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *HybridRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
+func (r *HybridRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	if len(datasetIDs) == 0 {
+		return nil, fmt.Errorf("no dataset specified for retrieval")
+	}
+
+	log := slog.With("retriever", r.Name())
+
+	if r.TopK <= 0 {
+		log.Debug("[HybridRetriever] TopK not set, using default", "default", defaults.TopK)
+		r.TopK = defaults.TopK
+	}
+	candidates := r.CandidatesTopK
+	if candidates <= 0 {
+		candidates = 2 * r.TopK
+	}
+
+	vectorRetriever := &BasicRetriever{TopK: candidates}
+	var keywordRetriever Retriever
+	switch r.KeywordSource {
+	case "", "bm25":
+		bm25Retriever := &BM25Retriever{TopN: candidates, K1: r.K1, B: r.B, CleanStopWords: r.CleanStopWords, MetadataFields: r.MetadataFields}
+		if bm25Retriever.K1 == 0 {
+			bm25Retriever.K1 = bm25.DefaultK1
+		}
+		if bm25Retriever.B == 0 {
+			bm25Retriever.B = bm25.DefaultB
+		}
+		keywordRetriever = bm25Retriever
+	case "index":
+		keywordRetriever = &KeywordRetriever{TopK: candidates}
+	default:
+		return nil, fmt.Errorf("unknown keyword source %q, must be one of bm25, index", r.KeywordSource)
+	}
+
+	var vectorDocs, keywordDocs []vs.Document
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		vectorDocs, err = vectorRetriever.Retrieve(gctx, store, query, datasetIDs, where, whereDocument)
+		if err != nil {
+			return fmt.Errorf("vector search failed: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		keywordDocs, err = keywordRetriever.Retrieve(gctx, store, query, datasetIDs, where, whereDocument)
+		if err != nil {
+			return fmt.Errorf("keyword search failed: %w", err)
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// BM25 scores zero documents that don't contain any of the query terms - those aren't keyword matches
+	matches := keywordDocs[:0]
+	for _, d := range keywordDocs {
+		if d.SimilarityScore > 0 {
+			matches = append(matches, d)
+		}
+	}
+	keywordDocs = matches
+
+	log.Debug("Fusing results", "vectorDocs", len(vectorDocs), "keywordDocs", len(keywordDocs))
+
+	results := scores.ReciprocalRankFusion(r.RRFK,
+		scores.RankedList{Name: "vector", Weight: r.VectorWeight, Documents: vectorDocs},
+		scores.RankedList{Name: "keyword", Weight: r.KeywordWeight, Documents: keywordDocs},
+	)
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results[:min(r.TopK, len(results))], nil
+}
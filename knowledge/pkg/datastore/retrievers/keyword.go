@@ -1,10 +1,121 @@
 package retrievers
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
 
+const KeywordRetrieverName = "keyword"
+
+// KeywordRetriever performs a pure lexical full-text search (SQLite FTS5 / Postgres tsvector) over the document contents stored in the Index.
+// It doesn't need embeddings, but only finds documents that were ingested with the content stored in the Index (--index-content).
+// Compared to the BM25Retriever, it doesn't have to load all documents of a dataset into memory.
+type KeywordRetriever struct {
+	TopK int
+}
+
+func (r *KeywordRetriever) Name() string {
+	return KeywordRetrieverName
+}
+
+func (r *KeywordRetriever) NormalizedScores() bool {
+	return false
+}
+
+func (r *KeywordRetriever) DecodeConfig(cfg map[string]any) error {
+	return DefaultConfigDecoder(r, cfg)
+}
+
+func (r *KeywordRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *KeywordRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
+func (r *KeywordRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	if len(datasetIDs) == 0 {
+		return nil, fmt.Errorf("no dataset specified for retrieval")
+	}
+
+	log := slog.With("retriever", r.Name())
+	if r.TopK <= 0 {
+		log.Debug("[KeywordRetriever] TopK not set, using default", "default", defaults.TopK)
+		r.TopK = defaults.TopK
+	}
+
+	// Filters are applied after the search, so we fetch some more candidates to still end up with TopK results
+	limit := r.TopK
+	if len(where) > 0 || len(whereDocument) > 0 {
+		limit *= 4
+	}
+
+	weights := dstypes.DatasetWeightsFromCtx(ctx)
+
+	var results []vs.Document
+	for _, dataset := range datasetIDs {
+		// silently ignore non-existent datasets
+		ds, err := store.GetDataset(ctx, dataset, nil)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "dataset not found") {
+				continue
+			}
+			return nil, err
+		}
+		if ds == nil {
+			continue
+		}
+
+		docs, err := store.KeywordSearch(ctx, query, weights.TopK(dataset, limit), dataset)
+		if err != nil {
+			return nil, err
+		}
+		weights.Apply(dataset, docs)
+
+		for _, doc := range docs {
+			if matchesFilters(&doc, where, whereDocument) {
+				results = append(results, doc)
+			}
+		}
+	}
+
+	if len(results) == 0 {
+		log.Info("No documents found for keyword search - make sure to ingest with --index-content", "datasets", datasetIDs)
+		return nil, nil
+	}
+
+	slices.SortFunc(results, scores.SortBySimilarityScore)
+
+	return results[:min(r.TopK, len(results))], nil
+}
+
+// matchesFilters checks the document against the metadata (exact match) and content filters
+func matchesFilters(doc *vs.Document, where map[string]string, whereDocument []vs.WhereDocument) bool {
+	for k, v := range where {
+		mv, ok := doc.Metadata[k]
+		if !ok || fmt.Sprint(mv) != v {
+			return false
+		}
+	}
+	for _, wd := range whereDocument {
+		if !wd.Matches(doc) {
+			return false
+		}
+	}
+	return true
+}
+
 // regex pattern to match double-quoted substrings
 var doubleQuotePattern = regexp.MustCompile(`"([^"]*)"`)
 
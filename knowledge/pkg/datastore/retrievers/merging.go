@@ -21,6 +21,10 @@ type MergingRetriever struct {
 	TopK       int
 	Retrievers []RetrieverToMerge `json:"retrievers" mapstructure:"retrievers" yaml:"retrievers"`
 	retrievers []Retriever
+
+	// baseTopK and baseSubTopK are the initial TopK values, used to scale the merged retrievers' TopK in SetTopK
+	baseTopK    int
+	baseSubTopK []int
 }
 
 type RetrieverToMerge struct {
@@ -57,6 +61,29 @@ func (r *MergingRetriever) DecodeConfig(cfg map[string]any) error {
 	return nil
 }
 
+func (r *MergingRetriever) GetTopK() int {
+	return r.TopK
+}
+
+// SetTopK sets the number of documents to return and scales the TopK of the merged retrievers by the same factor.
+func (r *MergingRetriever) SetTopK(topK int) {
+	if r.baseSubTopK == nil {
+		r.baseTopK = max(r.TopK, 1)
+		r.baseSubTopK = make([]int, len(r.retrievers))
+		for i, ret := range r.retrievers {
+			if tr, ok := ret.(TopKRetriever); ok {
+				r.baseSubTopK[i] = tr.GetTopK()
+			}
+		}
+	}
+	r.TopK = topK
+	for i, ret := range r.retrievers {
+		if tr, ok := ret.(TopKRetriever); ok && r.baseSubTopK[i] > 0 {
+			tr.SetTopK(max(1, r.baseSubTopK[i]*topK/r.baseTopK))
+		}
+	}
+}
+
 func (r *MergingRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
 	log := slog.With("component", "MergingRetriever")
 
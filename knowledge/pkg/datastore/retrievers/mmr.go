@@ -0,0 +1,76 @@
+package retrievers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const MMRRetrieverName = "mmr"
+
+// MMRRetriever over-fetches candidates via similarity search and re-selects them using maximal marginal relevance (MMR),
+// so that the results are relevant, but not (near-)duplicates of each other.
+type MMRRetriever struct {
+	TopK int
+
+	// FetchK is the number of candidates to fetch via similarity search (default: 4*TopK)
+	FetchK int
+
+	// Lambda trades relevance (1) against diversity (0) - default: 0.5
+	Lambda float32
+}
+
+func (r *MMRRetriever) Name() string {
+	return MMRRetrieverName
+}
+
+func (r *MMRRetriever) NormalizedScores() bool {
+	return true
+}
+
+func (r *MMRRetriever) DecodeConfig(cfg map[string]any) error {
+	return DefaultConfigDecoder(r, cfg)
+}
+
+func (r *MMRRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *MMRRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
+func (r *MMRRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	log := slog.With("retriever", r.Name())
+
+	if r.TopK <= 0 {
+		log.Debug("[MMRRetriever] TopK not set, using default", "default", defaults.TopK)
+		r.TopK = defaults.TopK
+	}
+	fetchK := r.FetchK
+	if fetchK < r.TopK {
+		fetchK = 4 * r.TopK
+	}
+
+	// Embeddings are used to compare the candidates with each other - if the vector store doesn't return them, MMR falls back to comparing the words
+	opts := vs.SearchOptionsFromCtx(ctx)
+	opts.IncludeEmbeddings = true
+	ctx = vs.SearchOptionsToCtx(ctx, opts)
+
+	candidates, err := (&BasicRetriever{TopK: fetchK}).Retrieve(ctx, store, query, datasetIDs, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	results := scores.MaximalMarginalRelevance(candidates, r.TopK, r.Lambda)
+	for i := range results {
+		results[i].Embedding = nil // not needed anymore and would only bloat the output
+	}
+
+	log.Debug("Selected documents", "candidates", len(candidates), "selected", len(results), "lambda", r.Lambda)
+	return results, nil
+}
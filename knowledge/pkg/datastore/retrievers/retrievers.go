@@ -10,6 +10,7 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/mitchellh/mapstructure"
@@ -22,6 +23,13 @@ type Retriever interface {
 	NormalizedScores() bool // whether the retriever returns normalized scores
 }
 
+// TopKRetriever is implemented by retrievers with a configurable number of documents to retrieve,
+// so that the candidate pool can be adjusted at runtime (e.g. when results are sparse).
+type TopKRetriever interface {
+	GetTopK() int
+	SetTopK(topK int)
+}
+
 func GetRetriever(name string) (Retriever, error) {
 	switch name {
 	case BasicRetrieverName, "default":
@@ -34,6 +42,16 @@ func GetRetriever(name string) (Retriever, error) {
 		return &MergingRetriever{TopK: defaults.TopK}, nil
 	case BM25RetrieverName:
 		return &BM25Retriever{TopN: defaults.TopK, K1: 1.2, B: 0.75}, nil
+	case EnsembleRetrieverName:
+		return &EnsembleRetriever{TopK: defaults.TopK, RRFK: scores.DefaultRRFK}, nil
+	case SelfQueryRetrieverName:
+		return &SelfQueryRetriever{TopK: defaults.TopK}, nil
+	case MMRRetrieverName:
+		return &MMRRetriever{TopK: defaults.TopK, Lambda: 0.5}, nil
+	case HybridRetrieverName:
+		return &HybridRetriever{TopK: defaults.TopK, RRFK: scores.DefaultRRFK, VectorWeight: 1, KeywordWeight: 1, K1: 1.2, B: 0.75}, nil
+	case KeywordRetrieverName:
+		return &KeywordRetriever{TopK: defaults.TopK}, nil
 	default:
 		return nil, fmt.Errorf("unknown retriever %q", name)
 	}
@@ -61,6 +79,17 @@ const BasicRetrieverName = "basic"
 
 type BasicRetriever struct {
 	TopK int
+
+	// Hybrid requests server-side hybrid search (vector + full-text) from vector stores supporting it (e.g. OpenSearch).
+	// It's ignored by all other vector stores.
+	Hybrid bool
+
+	// EfSearch overrides the size of the HNSW candidate list for this search (e.g. hnsw.ef_search for pgvector) to trade recall for latency.
+	// 0 keeps the vector store's setting.
+	EfSearch int
+
+	// IncludeEmbeddings returns the document embeddings along with the results (if supported by the vector store), e.g. for the dedupe postprocessor.
+	IncludeEmbeddings bool
 }
 
 func (r *BasicRetriever) Name() string {
@@ -75,11 +104,31 @@ func (r *BasicRetriever) DecodeConfig(cfg map[string]any) error {
 	return DefaultConfigDecoder(r, cfg)
 }
 
+func (r *BasicRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *BasicRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
 func (r *BasicRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
 	if len(datasetIDs) == 0 {
 		return nil, fmt.Errorf("no dataset specified for retrieval")
 	}
 
+	if r.Hybrid || r.EfSearch > 0 || r.IncludeEmbeddings {
+		opts := vs.SearchOptionsFromCtx(ctx) // keep options set by wrapping retrievers
+		opts.Hybrid = opts.Hybrid || r.Hybrid
+		opts.IncludeEmbeddings = opts.IncludeEmbeddings || r.IncludeEmbeddings
+		if r.EfSearch > 0 {
+			opts.EfSearch = r.EfSearch
+		}
+		ctx = vs.SearchOptionsToCtx(ctx, opts)
+	}
+
+	weights := dstypes.DatasetWeightsFromCtx(ctx)
+
 	var results []vs.Document
 	for _, dataset := range datasetIDs {
 		// TODO: make configurable via RetrieveOpts
@@ -95,11 +144,12 @@ func (r *BasicRetriever) Retrieve(ctx context.Context, store store.Store, query
 			continue
 		}
 
-		docs, err := store.SimilaritySearch(ctx, query, r.TopK, dataset, where, whereDocument)
+		docs, err := store.SimilaritySearch(ctx, query, weights.TopK(dataset, r.TopK), dataset, where, whereDocument)
 		if err != nil {
 			return nil, err
 		}
 
+		weights.Apply(dataset, docs)
 		results = append(results, docs...)
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/llm"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
@@ -32,6 +33,14 @@ func (r *RoutingRetriever) DecodeConfig(cfg map[string]any) error {
 	return DefaultConfigDecoder(r, cfg)
 }
 
+func (r *RoutingRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *RoutingRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
 var routingPromptTpl = `The following query will be used for a vector similarity search.
 Please route it to the appropriate dataset. Choose the one that fits best to the query based on the metadata.
 Query: "{{.query}}"
@@ -47,27 +56,29 @@ type routingResp struct {
 func (r *RoutingRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
 	log := slog.With("component", "RoutingRetriever")
 
-	// TODO: properly handle the datasetIDs input
-	log.Debug("Ignoring input datasetIDs in routing retriever, as it chooses one by itself", "query", query, "inputDataset", datasetIDs)
-
 	if r.TopK <= 0 {
 		log.Debug("TopK not set, using default", "default", defaults.TopK)
 		r.TopK = defaults.TopK
 	}
 
-	if len(r.AvailableDatasets) == 0 {
+	// candidates: configured datasets, else the requested ones, else all datasets
+	availableDatasets := r.AvailableDatasets
+	if len(availableDatasets) == 0 {
+		availableDatasets = datasetIDs
+	}
+	if len(availableDatasets) == 0 {
 		allDatasets, err := store.ListDatasets(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for _, ds := range allDatasets {
-			r.AvailableDatasets = append(r.AvailableDatasets, ds.ID)
+			availableDatasets = append(availableDatasets, ds.ID)
 		}
 	}
-	slog.Debug("Available datasets", "datasets", r.AvailableDatasets)
+	log.Debug("Available datasets", "datasets", availableDatasets, "inputDatasets", datasetIDs)
 
 	datasets := map[string]map[string]any{}
-	for _, dsID := range r.AvailableDatasets {
+	for _, dsID := range availableDatasets {
 		dataset, err := store.GetDataset(ctx, dsID, nil)
 		if err != nil {
 			return nil, err
@@ -99,7 +110,17 @@ func (r *RoutingRetriever) Retrieve(ctx context.Context, store store.Store, quer
 		return nil, err
 	}
 
+	if _, ok := datasets[resp.Result]; !ok {
+		return nil, fmt.Errorf("routing selected unknown dataset %q", resp.Result)
+	}
+
 	slog.Debug("Routing query to dataset", "query", query, "dataset", resp.Result)
 
-	return store.SimilaritySearch(ctx, query, r.TopK, resp.Result, where, whereDocument)
+	weights := dstypes.DatasetWeightsFromCtx(ctx)
+	docs, err := store.SimilaritySearch(ctx, query, weights.TopK(resp.Result, r.TopK), resp.Result, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	weights.Apply(resp.Result, docs)
+	return docs, nil
 }
@@ -0,0 +1,163 @@
+package retrievers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const SelfQueryRetrieverName = "selfquery"
+
+// SelfQueryRetriever uses an LLM to extract metadata filters from natural-language constraints in the query
+// (e.g. "docs from 2023 about billing" -> where year=2023, query "billing") and runs a filtered similarity search with the cleaned query.
+// Only the configured metadata fields can be used for filtering.
+type SelfQueryRetriever struct {
+	Model          llm.LLMConfig
+	TopK           int
+	MetadataFields []SelfQueryMetadataField `json:"metadataFields" mapstructure:"metadataFields" yaml:"metadataFields"`
+
+	// FallbackUnfiltered retries without the extracted filters if the filtered search doesn't return any documents
+	FallbackUnfiltered bool `json:"fallbackUnfiltered" mapstructure:"fallbackUnfiltered" yaml:"fallbackUnfiltered"`
+}
+
+// SelfQueryMetadataField describes a metadata field the LLM may filter on
+type SelfQueryMetadataField struct {
+	Name        string   `json:"name" mapstructure:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" mapstructure:"description" yaml:"description"`
+	Values      []string `json:"values,omitempty" mapstructure:"values" yaml:"values"` // optional list of allowed values
+}
+
+func (r *SelfQueryRetriever) Name() string {
+	return SelfQueryRetrieverName
+}
+
+func (r *SelfQueryRetriever) NormalizedScores() bool {
+	return true
+}
+
+func (r *SelfQueryRetriever) DecodeConfig(cfg map[string]any) error {
+	return DefaultConfigDecoder(r, cfg)
+}
+
+func (r *SelfQueryRetriever) GetTopK() int {
+	return r.TopK
+}
+
+func (r *SelfQueryRetriever) SetTopK(topK int) {
+	r.TopK = topK
+}
+
+var selfQueryPromptTpl = `The following query will be used for a vector similarity search over documents with metadata.
+Extract constraints on the metadata fields listed below from the query and rewrite the query without those constraints.
+Only use the listed fields and only exact values (if a field lists allowed values, use one of them). If there are no constraints, return an empty filter.
+Metadata fields in a JSON list:
+{{ .fields }}
+Query: "{{ .query }}"
+Reply only in the following JSON format, without any styling or markdown syntax:
+{"query": "<query without the metadata constraints>", "filter": {"<field>": "<value>"}}`
+
+type selfQueryResp struct {
+	Query  string         `json:"query"`
+	Filter map[string]any `json:"filter"`
+}
+
+func (r *SelfQueryRetriever) Retrieve(ctx context.Context, store store.Store, query string, datasetIDs []string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	log := slog.With("retriever", r.Name())
+
+	if len(r.MetadataFields) == 0 {
+		return nil, fmt.Errorf("[retrievers/selfquery] no metadata fields configured")
+	}
+	if r.TopK <= 0 {
+		log.Debug("[SelfQueryRetriever] TopK not set, using default", "default", defaults.TopK)
+		r.TopK = defaults.TopK
+	}
+
+	m, err := llm.NewFromConfig(r.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsJSON, err := json.Marshal(r.MetadataFields)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.Prompt(ctx, selfQueryPromptTpl, map[string]any{"query": query, "fields": string(fieldsJSON)})
+	if err != nil {
+		return nil, err
+	}
+	var resp selfQueryResp
+	if err := json.Unmarshal([]byte(trimCodeFence(result)), &resp); err != nil {
+		log.Debug("llm response", "response", result)
+		return nil, fmt.Errorf("[retrievers/selfquery] failed to unmarshal llm response: %w", err)
+	}
+
+	filter := r.validFilter(resp.Filter)
+	cleanedQuery := strings.TrimSpace(resp.Query)
+	if cleanedQuery == "" {
+		cleanedQuery = query
+	}
+
+	// Filters passed in explicitly take precedence over extracted ones
+	filteredWhere := make(map[string]string, len(where)+len(filter))
+	maps.Copy(filteredWhere, filter)
+	maps.Copy(filteredWhere, where)
+
+	log.Debug("Self-query", "query", query, "cleanedQuery", cleanedQuery, "filter", filter)
+
+	basic := &BasicRetriever{TopK: r.TopK}
+	docs, err := basic.Retrieve(ctx, store, cleanedQuery, datasetIDs, filteredWhere, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(docs) == 0 && len(filter) > 0 && r.FallbackUnfiltered {
+		log.Debug("No documents found with the extracted filter, retrying without it", "filter", filter)
+		return basic.Retrieve(ctx, store, query, datasetIDs, where, whereDocument)
+	}
+	return docs, nil
+}
+
+// validFilter drops filters on unknown fields or with values that are not allowed
+func (r *SelfQueryRetriever) validFilter(filter map[string]any) map[string]string {
+	valid := make(map[string]string, len(filter))
+	for k, v := range filter {
+		idx := slices.IndexFunc(r.MetadataFields, func(f SelfQueryMetadataField) bool { return f.Name == k })
+		if idx < 0 || v == nil {
+			slog.Debug("Dropping filter on unknown metadata field", "field", k, "retriever", r.Name())
+			continue
+		}
+		value := strings.TrimSpace(fmt.Sprint(v))
+		if value == "" {
+			continue
+		}
+		if allowed := r.MetadataFields[idx].Values; len(allowed) > 0 && !slices.Contains(allowed, value) {
+			slog.Debug("Dropping filter with value that is not allowed", "field", k, "value", value, "retriever", r.Name())
+			continue
+		}
+		valid[k] = value
+	}
+	return valid
+}
+
+// trimCodeFence removes a markdown code fence around an LLM response, which some models add despite being told not to
+func trimCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:] // drop language tag
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/scores"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/llm"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 )
@@ -35,6 +36,14 @@ func (s *SubqueryRetriever) DecodeConfig(cfg map[string]any) error {
 	return DefaultConfigDecoder(s, cfg)
 }
 
+func (s *SubqueryRetriever) GetTopK() int {
+	return s.TopK
+}
+
+func (s *SubqueryRetriever) SetTopK(topK int) {
+	s.TopK = topK
+}
+
 var subqueryPrompt = `The following query will be used for a vector similarity search.
 If it is too complex or covering multiple topics or entities, please split it into multiple subqueries.
 I.e. a comparative query like "What are the differences between cats and dogs?" could be split into subqueries concerning cats and dogs separately.
@@ -86,6 +95,8 @@ func (s *SubqueryRetriever) Retrieve(ctx context.Context, store store.Store, que
 
 	slog.Debug("SubqueryQueryRetriever generated subqueries", "queries", strings.Join(queries, " | "))
 
+	weights := dstypes.DatasetWeightsFromCtx(ctx)
+
 	var resultDocs []vs.Document
 	for _, dataset := range datasetIDs {
 		// TODO: make configurable via RetrieveOpts
@@ -102,10 +113,11 @@ func (s *SubqueryRetriever) Retrieve(ctx context.Context, store store.Store, que
 		}
 
 		for _, q := range queries {
-			docs, err := store.SimilaritySearch(ctx, q, s.TopK, dataset, where, whereDocument)
+			docs, err := store.SimilaritySearch(ctx, q, weights.TopK(dataset, s.TopK), dataset, where, whereDocument)
 			if err != nil {
 				return nil, err
 			}
+			weights.Apply(dataset, docs)
 			slog.Debug("SubqueryQueryRetriever retrieved documents", "query", q, "len(docs)", len(docs))
 
 		docLoop:
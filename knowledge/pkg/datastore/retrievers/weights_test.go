@@ -0,0 +1,151 @@
+package retrievers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testWeightsStore serves the documents per dataset - the similarity search returns them in order with their preset scores
+type testWeightsStore struct {
+	docs     map[string][]vs.Document
+	searched map[string]int // number of documents requested by the similarity search per dataset
+}
+
+func (s *testWeightsStore) ListDatasets(context.Context) ([]types.Dataset, error) {
+	var datasets []types.Dataset
+	for id := range s.docs {
+		datasets = append(datasets, types.Dataset{ID: id})
+	}
+	return datasets, nil
+}
+
+func (s *testWeightsStore) GetDataset(_ context.Context, datasetID string, _ *types.DatasetGetOpts) (*types.Dataset, error) {
+	if _, ok := s.docs[datasetID]; !ok {
+		return nil, nil
+	}
+	return &types.Dataset{ID: datasetID}, nil
+}
+
+func (s *testWeightsStore) SimilaritySearch(_ context.Context, _ string, numDocuments int, collection string, _ map[string]string, _ []vs.WhereDocument) ([]vs.Document, error) {
+	if s.searched == nil {
+		s.searched = map[string]int{}
+	}
+	s.searched[collection] = numDocuments
+	docs := slices.Clone(s.docs[collection])
+	return docs[:min(numDocuments, len(docs))], nil
+}
+
+func (s *testWeightsStore) GetDocuments(_ context.Context, datasetID string, _ map[string]string, _ []vs.WhereDocument) ([]vs.Document, error) {
+	return slices.Clone(s.docs[datasetID]), nil
+}
+
+func (s *testWeightsStore) KeywordSearch(context.Context, string, int, string) ([]vs.Document, error) {
+	return nil, nil
+}
+
+func (s *testWeightsStore) GetVocabulary(context.Context, string) (map[string]int, error) {
+	return nil, nil
+}
+
+func docIDs(docs []vs.Document) []string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids
+}
+
+func TestBM25RetrieverDatasetWeights(t *testing.T) {
+	store := &testWeightsStore{docs: map[string][]vs.Document{
+		"archive": {
+			{ID: "archive-1", Content: "rate limit rate limit of the old gateway"},
+			{ID: "archive-2", Content: "rate limit of the old proxy"},
+			{ID: "archive-3", Content: "unrelated release notes"},
+		},
+		"runbooks": {
+			{ID: "runbooks-1", Content: "how to raise the rate limit"},
+			{ID: "runbooks-2", Content: "restarting the database"},
+		},
+	}}
+	r := &BM25Retriever{TopN: 3, K1: 1.2, B: 0.75}
+	query := "rate limit"
+
+	docs, err := r.Retrieve(context.Background(), store, query, []string{"archive", "runbooks"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"archive-1", "archive-2", "runbooks-1"}, docIDs(docs), "without weights, the archive ranks first")
+
+	ctx := dstypes.DatasetWeightsToCtx(context.Background(), dstypes.DatasetWeights{
+		"runbooks": {Weight: 3},
+		"archive":  {TopK: 1},
+	})
+	docs, err = r.Retrieve(ctx, store, query, []string{"archive", "runbooks"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"runbooks-1", "archive-1", "runbooks-2"}, docIDs(docs), "the weighted dataset outranks the other one, which contributes only its top document")
+}
+
+func TestHybridRetrieverDatasetWeights(t *testing.T) {
+	store := &testWeightsStore{docs: map[string][]vs.Document{
+		"archive": {
+			{ID: "archive-1", Content: "rate limit rate limit of the old gateway", SimilarityScore: 0.9},
+			{ID: "archive-2", Content: "unrelated release notes", SimilarityScore: 0.1},
+		},
+		"runbooks": {
+			{ID: "runbooks-1", Content: "how to raise the rate limit", SimilarityScore: 0.6},
+			{ID: "runbooks-2", Content: "restarting the database", SimilarityScore: 0.1},
+		},
+	}}
+	r := &HybridRetriever{TopK: 2, VectorWeight: 1, KeywordWeight: 2}
+
+	docs, err := r.Retrieve(context.Background(), store, "rate limit", []string{"archive", "runbooks"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"archive-1", "runbooks-1"}, docIDs(docs))
+
+	// both the vector and the keyword (BM25) search prefer the weighted dataset, not only the vector search
+	ctx := dstypes.DatasetWeightsToCtx(context.Background(), dstypes.DatasetWeights{"runbooks": {Weight: 2}})
+	docs, err = r.Retrieve(ctx, store, "rate limit", []string{"archive", "runbooks"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"runbooks-1", "archive-1"}, docIDs(docs))
+}
+
+func TestRoutingRetrieverDatasetWeights(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": `{"result": "runbooks"}`},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	store := &testWeightsStore{docs: map[string][]vs.Document{
+		"archive": {{ID: "archive-1", SimilarityScore: 0.9}},
+		"runbooks": {
+			{ID: "runbooks-1", SimilarityScore: 0.4},
+			{ID: "runbooks-2", SimilarityScore: 0.2},
+		},
+	}}
+	r := &RoutingRetriever{Model: llm.LLMConfig{OpenAI: openai.OpenAIConfig{APIKey: "test", BaseURL: srv.URL, Model: "test"}}, TopK: 5}
+
+	ctx := dstypes.DatasetWeightsToCtx(context.Background(), dstypes.DatasetWeights{"runbooks": {TopK: 1, Weight: 2}})
+	docs, err := r.Retrieve(ctx, store, "rate limit", []string{"archive", "runbooks"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.searched["runbooks"])
+	require.Len(t, docs, 1)
+	assert.Equal(t, "runbooks-1", docs[0].ID)
+	assert.InDelta(t, 0.8, docs[0].SimilarityScore, 1e-6)
+}
@@ -12,4 +12,6 @@ type Store interface {
 	GetDataset(ctx context.Context, datasetID string, opts *types.DatasetGetOpts) (*types.Dataset, error)
 	SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error)
 	GetDocuments(ctx context.Context, datasetID string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error)
+	KeywordSearch(ctx context.Context, query string, numDocuments int, datasetID string) ([]vs.Document, error)
+	GetVocabulary(ctx context.Context, datasetID string) (map[string]int, error)
 }
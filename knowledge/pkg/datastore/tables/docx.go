@@ -0,0 +1,223 @@
+package tables
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const docxDocumentPath = "word/document.xml"
+
+// docxMarkerRegex matches the markers inserted by FromDocx, which hold the index of the table
+var docxMarkerRegex = regexp.MustCompile(`\[\[knowledge:table:(\d+)]]`)
+
+func docxMarker(i int) string {
+	return fmt.Sprintf("[[knowledge:table:%d]]", i)
+}
+
+// FromDocx extracts all (top-level) tables from a Word (docx) document.
+// The first row of each table is used as the header row.
+//
+// Document loaders flatten the tables into the text, so it also returns the docx document with a marker paragraph
+// in place of each table (or in front of it, if keepInText is set), to be loaded instead of the original document:
+// DocxTableDocuments then cuts the markers out of the loaded documents and uses their metadata for the table documents.
+func FromDocx(data []byte, keepInText bool) ([]Table, []byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open docx archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if f.Name != docxDocumentPath {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		document, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read docx document: %w", err)
+		}
+
+		tbls, marked, err := markDocxTables(document, keepInText)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse docx document: %w", err)
+		}
+
+		out, err := replaceZipFile(zr, docxDocumentPath, marked)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to write docx archive: %w", err)
+		}
+		return tbls, out, nil
+	}
+	return nil, nil, fmt.Errorf("%s not found in docx archive", docxDocumentPath)
+}
+
+// DocxTableDocuments cuts the table markers out of the documents loaded from a docx document returned by FromDocx and
+// creates the table documents, using the metadata of the document that contained the marker of each table as base.
+// Documents that contained nothing but markers are dropped.
+func DocxTableDocuments(docs []vs.Document, tbls []Table, opts Options) ([]vs.Document, []vs.Document) {
+	metadata := make([]map[string]any, len(tbls))
+	textDocs := make([]vs.Document, 0, len(docs))
+	for _, doc := range docs {
+		for _, m := range docxMarkerRegex.FindAllStringSubmatch(doc.Content, -1) {
+			if i, err := strconv.Atoi(m[1]); err == nil && i < len(tbls) && metadata[i] == nil {
+				metadata[i] = doc.Metadata
+			}
+		}
+		doc.Content = strings.TrimSpace(docxMarkerRegex.ReplaceAllString(doc.Content, ""))
+		if doc.Content != "" {
+			textDocs = append(textDocs, doc)
+		}
+	}
+
+	var tableDocs []vs.Document
+	for i, t := range tbls {
+		if metadata[i] == nil && len(docs) > 0 {
+			metadata[i] = docs[0].Metadata // the loader dropped the marker
+		}
+		tableDocs = append(tableDocs, tableDocuments(t, i, metadata[i], opts)...)
+	}
+	return textDocs, tableDocs
+}
+
+// markDocxTables parses the tables of the body of the document XML and inserts a marker paragraph in place of,
+// or in front of, each of them
+func markDocxTables(document []byte, keepInText bool) ([]Table, []byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(document))
+
+	var (
+		tbls   []Table
+		out    bytes.Buffer
+		last   int64
+		depth  int
+		inBody bool
+	)
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 && t.Name.Local == "body" {
+				inBody = true
+			}
+			if !inBody || depth != 3 || t.Name.Local != "tbl" {
+				continue
+			}
+
+			var dt docxTable
+			if err := dec.DecodeElement(&dt, &t); err != nil {
+				return nil, nil, err
+			}
+			depth--
+			tbl, ok := dt.asTable()
+			if !ok {
+				continue
+			}
+
+			prefix := elementPrefix(document[offset:])
+			marker := fmt.Sprintf("<%[1]sp><%[1]sr><%[1]st>%[2]s</%[1]st></%[1]sr></%[1]sp>", prefix, docxMarker(len(tbls)))
+
+			out.Write(document[last:offset])
+			out.WriteString(marker)
+			if keepInText {
+				out.Write(document[offset:dec.InputOffset()])
+			}
+			last = dec.InputOffset()
+			tbls = append(tbls, tbl)
+		case xml.EndElement:
+			if depth == 2 {
+				inBody = false
+			}
+			depth--
+		}
+	}
+	out.Write(document[last:])
+	return tbls, out.Bytes(), nil
+}
+
+// elementPrefix returns the namespace prefix of the element starting the raw XML, e.g. "w:" for <w:tbl>,
+// as the inserted elements have to use the same prefix
+func elementPrefix(raw []byte) string {
+	name := bytes.TrimPrefix(raw, []byte("<"))
+	if end := bytes.IndexAny(name, " \t\r\n/>"); end >= 0 {
+		name = name[:end]
+	}
+	if i := bytes.IndexByte(name, ':'); i >= 0 {
+		return string(name[:i+1])
+	}
+	return ""
+}
+
+// replaceZipFile copies the zip archive with the content of the given file replaced
+func replaceZipFile(zr *zip.Reader, name string, content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if f.Name != name {
+			if err := zw.Copy(f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: f.Method, Modified: f.Modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type docxTable struct {
+	Rows []struct {
+		Cells []struct {
+			Paragraphs []struct {
+				Texts []string `xml:"r>t"`
+			} `xml:"p"`
+		} `xml:"tc"`
+	} `xml:"tr"`
+}
+
+func (t docxTable) asTable() (Table, bool) {
+	var rows [][]string
+	for _, r := range t.Rows {
+		row := make([]string, len(r.Cells))
+		for i, c := range r.Cells {
+			var paragraphs []string
+			for _, p := range c.Paragraphs {
+				if text := strings.TrimSpace(strings.Join(p.Texts, "")); text != "" {
+					paragraphs = append(paragraphs, text)
+				}
+			}
+			row[i] = strings.Join(paragraphs, " ")
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return Table{}, false
+	}
+	return Table{Header: rows[0], Rows: rows[1:]}, true
+}
@@ -0,0 +1,167 @@
+package tables
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// rowTolerance is the maximum vertical distance (in points) of text lines in the same table row
+	rowTolerance = 2.0
+	// columnTolerance is how far (in points) a cell may start left of its column
+	columnTolerance = 4.0
+	// maxAvgCellLength rejects "tables" made of running text, e.g. two-column page layouts
+	maxAvgCellLength = 40
+)
+
+// TextLine is a line of text with its position on the page, e.g. from the HTML output of MuPDF.
+type TextLine struct {
+	Top  float64
+	Left float64
+	Text string
+}
+
+// LayoutTable is a table found in positioned text lines.
+type LayoutTable struct {
+	Table Table
+	Lines []int // indices of the text lines that make up the table
+}
+
+type layoutRow struct {
+	top   float64
+	cells []int // line indices, sorted by left
+}
+
+// FindLayoutTables detects tables in text lines with position information (e.g. PDF pages), where every cell
+// is a separate line: a table is a run of at least three rows (including the header row) whose cells align
+// with the columns of the first row, which must have at least two columns.
+func FindLayoutTables(lines []TextLine) []LayoutTable {
+	rows := groupRows(lines)
+
+	var result []LayoutTable
+	for i := 0; i < len(rows); i++ {
+		if len(rows[i].cells) < 2 {
+			continue
+		}
+
+		columns := make([]float64, len(rows[i].cells))
+		for c, idx := range rows[i].cells {
+			columns[c] = lines[idx].Left
+		}
+
+		tableRows := [][]int{assignColumns(rows[i], lines, columns)}
+		end := i + 1
+		for ; end < len(rows); end++ {
+			if len(rows[end].cells) < 2 {
+				break
+			}
+			assigned := assignColumns(rows[end], lines, columns)
+			if assigned == nil {
+				break
+			}
+			tableRows = append(tableRows, assigned)
+		}
+
+		if len(tableRows) < 3 {
+			continue
+		}
+		if t, ok := layoutTable(tableRows, lines, len(columns)); ok {
+			result = append(result, t)
+			i = end - 1
+		}
+	}
+	return result
+}
+
+// groupRows groups the lines into rows of (about) the same vertical position, sorted top to bottom
+func groupRows(lines []TextLine) []layoutRow {
+	order := make([]int, 0, len(lines))
+	for i, l := range lines {
+		if strings.TrimSpace(l.Text) != "" {
+			order = append(order, i)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case lines[a].Top < lines[b].Top:
+			return -1
+		case lines[a].Top > lines[b].Top:
+			return 1
+		}
+		return 0
+	})
+
+	var rows []layoutRow
+	for _, idx := range order {
+		if n := len(rows); n > 0 && math.Abs(lines[idx].Top-rows[n-1].top) <= rowTolerance {
+			rows[n-1].cells = append(rows[n-1].cells, idx)
+			continue
+		}
+		rows = append(rows, layoutRow{top: lines[idx].Top, cells: []int{idx}})
+	}
+
+	for _, r := range rows {
+		slices.SortStableFunc(r.cells, func(a, b int) int {
+			switch {
+			case lines[a].Left < lines[b].Left:
+				return -1
+			case lines[a].Left > lines[b].Left:
+				return 1
+			}
+			return 0
+		})
+	}
+	return rows
+}
+
+// assignColumns returns the line index per column (-1 for empty cells), or nil if the cells don't fit the columns.
+// A cell belongs to the rightmost column starting before it, since numbers are often right-aligned.
+func assignColumns(row layoutRow, lines []TextLine, columns []float64) []int {
+	assigned := make([]int, len(columns))
+	for c := range assigned {
+		assigned[c] = -1
+	}
+	for _, idx := range row.cells {
+		col := -1
+		for c, left := range columns {
+			if lines[idx].Left+columnTolerance >= left {
+				col = c
+			}
+		}
+		if col < 0 || assigned[col] >= 0 {
+			return nil // left of the table, or two cells in the same column
+		}
+		assigned[col] = idx
+	}
+	return assigned
+}
+
+func layoutTable(rows [][]int, lines []TextLine, numColumns int) (LayoutTable, bool) {
+	var t LayoutTable
+	var totalLength, cells int
+
+	for r, row := range rows {
+		values := make([]string, numColumns)
+		for c, idx := range row {
+			if idx < 0 {
+				continue
+			}
+			values[c] = strings.TrimSpace(lines[idx].Text)
+			totalLength += len(values[c])
+			cells++
+			t.Lines = append(t.Lines, idx)
+		}
+		if r == 0 {
+			t.Table.Header = values
+		} else {
+			t.Table.Rows = append(t.Table.Rows, values)
+		}
+	}
+
+	if cells == 0 || totalLength/cells > maxAvgCellLength {
+		return LayoutTable{}, false
+	}
+	slices.Sort(t.Lines)
+	return t, true
+}
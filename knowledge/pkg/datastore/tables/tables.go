@@ -0,0 +1,271 @@
+package tables
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+
+	MetadataKeyContentType = "contentType"
+	ContentTypeTable       = "table"
+)
+
+// Options configure the extraction of tables from loaded documents into dedicated documents (chunks).
+type Options struct {
+	// Format is the output format of the table documents: markdown (default) or csv
+	Format string `json:"format,omitempty" yaml:"format" mapstructure:"format"`
+
+	// KeepInText keeps the tables in the text documents as well, instead of cutting them out
+	KeepInText bool `json:"keepInText,omitempty" yaml:"keepInText" mapstructure:"keepInText"`
+
+	// MaxRowsPerChunk splits large tables into multiple documents with at most this many rows each, repeating the header row (0 = no limit)
+	MaxRowsPerChunk int `json:"maxRowsPerChunk,omitempty" yaml:"maxRowsPerChunk" mapstructure:"maxRowsPerChunk"`
+}
+
+func (o *Options) Validate() error {
+	switch o.Format {
+	case "", FormatMarkdown, FormatCSV:
+	default:
+		return fmt.Errorf("invalid table format %q, must be one of %q or %q", o.Format, FormatMarkdown, FormatCSV)
+	}
+	if o.MaxRowsPerChunk < 0 {
+		return fmt.Errorf("maxRowsPerChunk must not be negative")
+	}
+	return nil
+}
+
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Markdown renders the table as a markdown table.
+func (t Table) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString(t.markdownHeader())
+	for _, row := range t.Rows {
+		sb.WriteString("\n" + markdownRow(row))
+	}
+	return sb.String()
+}
+
+// markdownHeader renders the header row and the separator row below it
+func (t Table) markdownHeader() string {
+	return markdownRow(t.Header) + "\n" + strings.TrimSpace(strings.Repeat("| --- ", len(t.Header))+"|")
+}
+
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(c, "\n", " "), "|", "\\|")
+	}
+	return strings.TrimSpace("| " + strings.Join(escaped, " | ") + " |")
+}
+
+// CSV renders the table as CSV.
+func (t Table) CSV() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(t.Header)
+	_ = w.WriteAll(t.Rows) // flushes
+	return strings.TrimSpace(buf.String())
+}
+
+func (t Table) Render(format string) string {
+	if format == FormatCSV {
+		return t.CSV()
+	}
+	return t.Markdown()
+}
+
+// Chunks splits the table into multiple tables with at most maxRows rows each, all sharing the same header.
+func (t Table) Chunks(maxRows int) []Table {
+	if maxRows <= 0 || len(t.Rows) <= maxRows {
+		return []Table{t}
+	}
+	var chunks []Table
+	for start := 0; start < len(t.Rows); start += maxRows {
+		end := min(start+maxRows, len(t.Rows))
+		chunks = append(chunks, Table{Header: t.Header, Rows: t.Rows[start:end]})
+	}
+	return chunks
+}
+
+// MarkdownChunks splits the table into multiple tables, all sharing the same header, whose markdown rendering
+// has at most the given size according to the length function. A row exceeding the size on its own
+// (together with the header) is a chunk of its own.
+func (t Table) MarkdownChunks(size int, length func(string) int) []Table {
+	headerLen := length(t.markdownHeader())
+	var chunks []Table
+	var rows [][]string
+	total := headerLen
+	for _, row := range t.Rows {
+		l := length("\n" + markdownRow(row))
+		if total+l > size && len(rows) > 0 {
+			chunks = append(chunks, Table{Header: t.Header, Rows: rows})
+			rows, total = nil, headerLen
+		}
+		rows = append(rows, row)
+		total += l
+	}
+	if len(rows) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, Table{Header: t.Header, Rows: rows})
+	}
+	return chunks
+}
+
+// Segment is a part of a text: either a markdown table or (if Table is nil) the text between tables.
+type Segment struct {
+	Text  string
+	Table *Table
+}
+
+// MarkdownSegments splits the content into the markdown tables it contains and the text around them, in order.
+// Content without tables yields a single text segment.
+func MarkdownSegments(content string) []Segment {
+	lines := strings.Split(content, "\n")
+	var segments []Segment
+	last := 0
+	for _, span := range findMarkdownTables(lines) {
+		if text := strings.Join(lines[last:span.startLine], "\n"); strings.TrimSpace(text) != "" {
+			segments = append(segments, Segment{Text: text})
+		}
+		segments = append(segments, Segment{Text: strings.Join(lines[span.startLine:span.endLine], "\n"), Table: &span.table})
+		last = span.endLine
+	}
+	if text := strings.Join(lines[last:], "\n"); strings.TrimSpace(text) != "" || len(segments) == 0 {
+		segments = append(segments, Segment{Text: text})
+	}
+	return segments
+}
+
+var markdownSeparatorRegex = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+
+type tableSpan struct {
+	startLine, endLine int // endLine is exclusive
+	table              Table
+}
+
+// findMarkdownTables finds all markdown tables in the given lines.
+func findMarkdownTables(lines []string) []tableSpan {
+	var spans []tableSpan
+	for i := 0; i+1 < len(lines); i++ {
+		if !strings.Contains(lines[i], "|") || !markdownSeparatorRegex.MatchString(lines[i+1]) {
+			continue
+		}
+		t := Table{Header: splitMarkdownRow(lines[i])}
+		end := i + 2
+		for ; end < len(lines); end++ {
+			if strings.TrimSpace(lines[end]) == "" || !strings.Contains(lines[end], "|") {
+				break
+			}
+			t.Rows = append(t.Rows, splitMarkdownRow(lines[end]))
+		}
+		spans = append(spans, tableSpan{startLine: i, endLine: end, table: t})
+		i = end - 1
+	}
+	return spans
+}
+
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// Extract cuts out tables from the content of the given documents and returns the remaining text documents
+// and the dedicated table documents tagged with contentType: table.
+// Table documents inherit the metadata of their source document, so they are sorted right next to them.
+func Extract(docs []vs.Document, opts Options) ([]vs.Document, []vs.Document) {
+	var textDocs, tableDocs []vs.Document
+	for _, doc := range docs {
+		lines := strings.Split(doc.Content, "\n")
+		spans := findMarkdownTables(lines)
+		if len(spans) == 0 {
+			textDocs = append(textDocs, doc)
+			continue
+		}
+
+		var tbls []Table
+		var remaining []string
+		last := 0
+		for _, span := range spans {
+			tbls = append(tbls, span.table)
+			if !opts.KeepInText {
+				remaining = append(remaining, lines[last:span.startLine]...)
+				last = span.endLine
+			}
+		}
+
+		if !opts.KeepInText {
+			remaining = append(remaining, lines[last:]...)
+			doc.Content = strings.TrimSpace(strings.Join(remaining, "\n"))
+		}
+
+		if doc.Content != "" {
+			textDocs = append(textDocs, doc)
+		}
+		tableDocs = append(tableDocs, TableDocuments(tbls, doc.Metadata, opts)...)
+	}
+	return textDocs, tableDocs
+}
+
+// TableDocuments creates documents from the given tables, using the given metadata as base.
+func TableDocuments(tbls []Table, baseMetadata map[string]any, opts Options) []vs.Document {
+	var docs []vs.Document
+	for i, t := range tbls {
+		docs = append(docs, tableDocuments(t, i, baseMetadata, opts)...)
+	}
+	return docs
+}
+
+// tableDocuments creates the documents of the table with the given index, split into chunks of opts.MaxRowsPerChunk rows
+func tableDocuments(t Table, index int, baseMetadata map[string]any, opts Options) []vs.Document {
+	var docs []vs.Document
+	chunks := t.Chunks(opts.MaxRowsPerChunk)
+	for j, chunk := range chunks {
+		metadata := maps.Clone(baseMetadata)
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadata[MetadataKeyContentType] = ContentTypeTable
+		metadata["tableIndex"] = index
+		metadata["tableColumns"] = len(chunk.Header)
+		metadata["tableRows"] = len(chunk.Rows)
+		if len(chunks) > 1 {
+			metadata["tablePart"] = fmt.Sprintf("%d/%d", j+1, len(chunks))
+		}
+		docs = append(docs, vs.Document{
+			Content:  chunk.Render(opts.Format),
+			Metadata: metadata,
+		})
+	}
+	return docs
+}
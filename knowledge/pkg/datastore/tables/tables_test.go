@@ -0,0 +1,160 @@
+package tables
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"code.sajari.com/docconv/v2"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testContent = `intro
+
+| a | b |
+|---|:---:|
+| 1 | x\|y |
+| 2 | z |
+
+outro`
+
+func TestExtract_MarkdownTable(t *testing.T) {
+	docs := []vs.Document{{Content: testContent, Metadata: map[string]any{"page": 1}}}
+
+	textDocs, tableDocs := Extract(docs, Options{})
+	require.Len(t, textDocs, 1)
+	require.Len(t, tableDocs, 1)
+
+	assert.Equal(t, "intro\n\n\noutro", textDocs[0].Content)
+	assert.Equal(t, ContentTypeTable, tableDocs[0].Metadata[MetadataKeyContentType])
+	assert.Equal(t, 1, tableDocs[0].Metadata["page"])
+	assert.Equal(t, "| a | b |\n| --- | --- |\n| 1 | x\\|y |\n| 2 | z |", tableDocs[0].Content)
+	assert.NotContains(t, docs[0].Metadata, MetadataKeyContentType, "source metadata must not be modified")
+}
+
+func TestExtract_KeepInTextCSVAndChunks(t *testing.T) {
+	docs := []vs.Document{{Content: testContent}}
+
+	textDocs, tableDocs := Extract(docs, Options{Format: FormatCSV, KeepInText: true, MaxRowsPerChunk: 1})
+	require.Len(t, textDocs, 1)
+	require.Len(t, tableDocs, 2)
+
+	assert.Equal(t, testContent, textDocs[0].Content)
+	assert.Equal(t, "a,b\n1,x|y", tableDocs[0].Content)
+	assert.Equal(t, "a,b\n2,z", tableDocs[1].Content)
+	assert.Equal(t, "2/2", tableDocs[1].Metadata["tablePart"])
+}
+
+func TestExtract_NoTable(t *testing.T) {
+	docs := []vs.Document{{Content: "no | table here\njust text"}}
+
+	textDocs, tableDocs := Extract(docs, Options{})
+	assert.Equal(t, docs, textDocs)
+	assert.Empty(t, tableDocs)
+}
+
+func TestMarkdownSegmentsAndChunks(t *testing.T) {
+	segments := MarkdownSegments(testContent)
+	require.Len(t, segments, 3)
+	assert.Equal(t, "intro\n", segments[0].Text)
+	assert.Nil(t, segments[0].Table)
+	require.NotNil(t, segments[1].Table)
+	assert.Equal(t, "| a | b |\n|---|:---:|\n| 1 | x\\|y |\n| 2 | z |", segments[1].Text)
+	assert.Equal(t, "\noutro", segments[2].Text)
+
+	chunks := segments[1].Table.MarkdownChunks(30, func(s string) int { return len(s) })
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "| a | b |\n| --- | --- |\n| 1 | x\\|y |", chunks[0].Markdown())
+	assert.Equal(t, "| a | b |\n| --- | --- |\n| 2 | z |", chunks[1].Markdown())
+
+	assert.Equal(t, []Segment{{Text: "no tables"}}, MarkdownSegments("no tables"))
+}
+
+func TestFindLayoutTables(t *testing.T) {
+	lines := []TextLine{
+		{Top: 50, Left: 72, Text: "Quarterly results"},
+		{Top: 100, Left: 72, Text: "Region"},
+		{Top: 100, Left: 200, Text: "Revenue"},
+		{Top: 100, Left: 300, Text: "Growth"},
+		{Top: 114.5, Left: 72, Text: "EMEA"},
+		{Top: 115, Left: 210, Text: "1,200"}, // right-aligned numbers
+		{Top: 115, Left: 305, Text: "4%"},
+		{Top: 130, Left: 72, Text: "APAC"},
+		{Top: 130, Left: 305, Text: "7%"}, // empty cell
+		{Top: 160, Left: 72, Text: "Revenue grew in all regions."},
+	}
+
+	found := FindLayoutTables(lines)
+	require.Len(t, found, 1)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, found[0].Lines)
+	assert.Equal(t, "| Region | Revenue | Growth |\n| --- | --- | --- |\n| EMEA | 1,200 | 4% |\n| APAC |  | 7% |", found[0].Table.Markdown())
+}
+
+func TestFindLayoutTables_TwoColumnText(t *testing.T) {
+	var lines []TextLine
+	for i := 0; i < 5; i++ {
+		lines = append(lines,
+			TextLine{Top: float64(100 + i*14), Left: 72, Text: "This is running text in the left column of a two-column page"},
+			TextLine{Top: float64(100 + i*14), Left: 320, Text: "and this is running text in the right column of the same page"},
+		)
+	}
+	assert.Empty(t, FindLayoutTables(lines))
+}
+
+const testDocxDocument = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly results</w:t></w:r></w:p>
+<w:tbl><w:tblPr/>
+<w:tr><w:tc><w:p><w:r><w:t>Region</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Revenue</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p><w:r><w:t>EMEA</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>1,200</w:t></w:r></w:p></w:tc></w:tr>
+</w:tbl>
+<w:p><w:r><w:t>Revenue grew in all regions.</w:t></w:r></w:p>
+</w:body></w:document>`
+
+func testDocx(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`,
+		"word/document.xml": testDocxDocument,
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestDocxTableDocuments(t *testing.T) {
+	for _, keepInText := range []bool{false, true} {
+		tbls, marked, err := FromDocx(testDocx(t), keepInText)
+		require.NoError(t, err)
+		require.Len(t, tbls, 1)
+		assert.Equal(t, Table{Header: []string{"Region", "Revenue"}, Rows: [][]string{{"EMEA", "1,200"}}}, tbls[0])
+
+		text, _, err := docconv.ConvertDocx(bytes.NewReader(marked))
+		require.NoError(t, err)
+		docs := []vs.Document{
+			{Content: "Cover page", Metadata: map[string]any{"page": 1}},
+			{Content: text, Metadata: map[string]any{"page": 2}},
+		}
+
+		textDocs, tableDocs := DocxTableDocuments(docs, tbls, Options{})
+		require.Len(t, textDocs, 2)
+		require.Len(t, tableDocs, 1)
+		assert.NotContains(t, textDocs[1].Content, "knowledge:table")
+		assert.Contains(t, textDocs[1].Content, "Revenue grew in all regions.")
+		if keepInText {
+			assert.Contains(t, textDocs[1].Content, "EMEA")
+		} else {
+			assert.NotContains(t, textDocs[1].Content, "EMEA", "the flattened table is cut out of the text")
+		}
+		assert.Equal(t, 2, tableDocs[0].Metadata["page"], "table documents get the metadata of the document containing the table")
+		assert.Equal(t, "| Region | Revenue |\n| --- | --- |\n| EMEA | 1,200 |", tableDocs[0].Content)
+	}
+}
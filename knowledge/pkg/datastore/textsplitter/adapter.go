@@ -50,5 +50,8 @@ func FromLangchain(splitter lcgosplitter.TextSplitter, name string) types.TextSp
 }
 
 func AsLangchain(splitter types.TextSplitter) lcgosplitter.TextSplitter {
+	if tableAware, ok := splitter.(*TableAwareSplitter); ok {
+		splitter = tableAware.splitter
+	}
 	return splitter.(*langchainSplitterAdapter).lc
 }
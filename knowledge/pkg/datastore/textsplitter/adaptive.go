@@ -0,0 +1,210 @@
+package textsplitter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mitchellh/mapstructure"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure AdaptiveSplitter passes the context on to splitters that need it.
+var _ dstypes.ContextTextSplitter = (*AdaptiveSplitter)(nil)
+
+// adaptiveChunkSizeStep is the granularity of adaptive chunk sizes, so that similar documents share a splitter
+const adaptiveChunkSizeStep = 64
+
+var markdownHeadingRegex = regexp.MustCompile(`(?m)^#{1,6}\s`)
+
+// AdaptiveChunkingOpts configure the chunk size to be picked per document instead of using a fixed one.
+type AdaptiveChunkingOpts struct {
+	// MinChunkSize is the lower bound of the chunk size (default: 256)
+	MinChunkSize int `json:"minChunkSize,omitempty" yaml:"minChunkSize" mapstructure:"minChunkSize"`
+
+	// MaxChunkSize is the upper bound of the chunk size (default: 4096)
+	MaxChunkSize int `json:"maxChunkSize,omitempty" yaml:"maxChunkSize" mapstructure:"maxChunkSize"`
+
+	// TargetChunks is the number of chunks a document should be split into, as long as the chunk size stays within the bounds,
+	// so that long documents get larger chunks (default: 32)
+	TargetChunks int `json:"targetChunks,omitempty" yaml:"targetChunks" mapstructure:"targetChunks"`
+
+	// WholeDocumentSize is the size up to which documents are not split at all (default: 1024)
+	WholeDocumentSize int `json:"wholeDocumentSize,omitempty" yaml:"wholeDocumentSize" mapstructure:"wholeDocumentSize"`
+
+	// LengthUnit is the unit of all sizes, "tokens" (default) or "characters" - it should match the unit of the text splitter
+	LengthUnit string `json:"lengthUnit,omitempty" yaml:"lengthUnit" mapstructure:"lengthUnit"`
+}
+
+func (o *AdaptiveChunkingOpts) Validate() error {
+	if o.MinChunkSize < 0 || o.MaxChunkSize < 0 || o.TargetChunks < 0 || o.WholeDocumentSize < 0 {
+		return fmt.Errorf("adaptive chunking sizes must not be negative")
+	}
+	if o.MinChunkSize > 0 && o.MaxChunkSize > 0 && o.MinChunkSize > o.MaxChunkSize {
+		return fmt.Errorf("adaptive chunking minChunkSize %d exceeds maxChunkSize %d", o.MinChunkSize, o.MaxChunkSize)
+	}
+	return nil
+}
+
+func (o *AdaptiveChunkingOpts) withDefaults() AdaptiveChunkingOpts {
+	opts := *o
+	if opts.MinChunkSize == 0 {
+		opts.MinChunkSize = min(256, max(opts.MaxChunkSize, 1))
+	}
+	if opts.MaxChunkSize == 0 {
+		opts.MaxChunkSize = max(4096, opts.MinChunkSize)
+	}
+	if opts.TargetChunks == 0 {
+		opts.TargetChunks = 32
+	}
+	if opts.WholeDocumentSize == 0 {
+		opts.WholeDocumentSize = 1024
+	}
+	return opts
+}
+
+// SplitterFactory creates a text splitter with the given chunk size.
+type SplitterFactory func(chunkSize int) (dstypes.TextSplitter, error)
+
+// NamedSplitterFactory returns a factory creating the named text splitter with the given configuration,
+// overriding its chunk size and scaling its chunk overlap accordingly.
+func NamedSplitterFactory(name string, config any) SplitterFactory {
+	return func(chunkSize int) (dstypes.TextSplitter, error) {
+		var sizes struct {
+			ChunkSize    int `mapstructure:"chunkSize"`
+			ChunkOverlap int `mapstructure:"chunkOverlap"`
+		}
+		if err := mapstructure.Decode(config, &sizes); err != nil {
+			return nil, fmt.Errorf("failed to decode text splitter configuration: %w", err)
+		}
+
+		overrides := map[string]any{"chunkSize": chunkSize}
+		if sizes.ChunkSize > 0 && sizes.ChunkOverlap > 0 {
+			overrides["chunkOverlap"] = sizes.ChunkOverlap * chunkSize / sizes.ChunkSize
+		}
+
+		cfg := config
+		if err := mapstructure.Decode(overrides, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to set adaptive chunk size: %w", err)
+		}
+		return GetTextSplitter(name, cfg)
+	}
+}
+
+// AdaptiveSplitter picks the chunk size per document (i.e. all documents loaded from a file) based on its length
+// and structure: small documents stay whole, long documents get larger chunks, and the chunk size is at least the
+// average size of the document's sections (markdown headings or paragraphs), so that they're not cut into pieces.
+// The chunk size stays within the configured bounds.
+type AdaptiveSplitter struct {
+	opts        AdaptiveChunkingOpts
+	name        string
+	newSplitter SplitterFactory
+	length      LengthFunc
+
+	mu        sync.Mutex
+	splitters map[int]dstypes.TextSplitter
+}
+
+func NewAdaptiveSplitter(opts AdaptiveChunkingOpts, name string, newSplitter SplitterFactory) (*AdaptiveSplitter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	length, err := NewLengthFunc(opts.LengthUnit, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &AdaptiveSplitter{
+		opts:        opts.withDefaults(),
+		name:        name,
+		newSplitter: newSplitter,
+		length:      length,
+		splitters:   map[int]dstypes.TextSplitter{},
+	}, nil
+}
+
+func (s *AdaptiveSplitter) Name() string {
+	return s.name
+}
+
+func (s *AdaptiveSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	return s.SplitDocumentsWithContext(context.Background(), docs)
+}
+
+func (s *AdaptiveSplitter) SplitDocumentsWithContext(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
+	var total int
+	var sections []string
+	for _, doc := range docs {
+		total += s.length(doc.Content)
+		sections = append(sections, documentSections(doc.Content)...)
+	}
+	if total <= s.opts.WholeDocumentSize {
+		return docs, nil
+	}
+
+	sectionSize := 0
+	if len(sections) > 0 {
+		for _, section := range sections {
+			sectionSize += s.length(section)
+		}
+		sectionSize /= len(sections)
+	}
+
+	splitter, err := s.splitter(s.chunkSize(total, sectionSize))
+	if err != nil {
+		return nil, err
+	}
+	if cs, ok := splitter.(dstypes.ContextTextSplitter); ok {
+		return cs.SplitDocumentsWithContext(ctx, docs)
+	}
+	return splitter.SplitDocuments(docs)
+}
+
+// chunkSize picks the chunk size for a document of the given total size and average section size
+func (s *AdaptiveSplitter) chunkSize(total, sectionSize int) int {
+	size := max(total/s.opts.TargetChunks, sectionSize)
+	size = (size + adaptiveChunkSizeStep - 1) / adaptiveChunkSizeStep * adaptiveChunkSizeStep // round up
+	return min(max(size, s.opts.MinChunkSize), s.opts.MaxChunkSize)
+}
+
+func (s *AdaptiveSplitter) splitter(chunkSize int) (dstypes.TextSplitter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if splitter, ok := s.splitters[chunkSize]; ok {
+		return splitter, nil
+	}
+	splitter, err := s.newSplitter(chunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create text splitter with adaptive chunk size %d: %w", chunkSize, err)
+	}
+	s.splitters[chunkSize] = splitter
+	return splitter, nil
+}
+
+// documentSections splits the text at markdown headings, or at paragraphs if there are none
+func documentSections(text string) []string {
+	var sections []string
+	if idx := markdownHeadingRegex.FindAllStringIndex(text, -1); len(idx) > 0 {
+		start := 0
+		for _, i := range idx {
+			sections = append(sections, text[start:i[0]])
+			start = i[0]
+		}
+		sections = append(sections, text[start:])
+	} else {
+		sections = strings.Split(text, "\n\n")
+	}
+
+	result := sections[:0]
+	for _, section := range sections {
+		if strings.TrimSpace(section) != "" {
+			result = append(result, section)
+		}
+	}
+	return result
+}
@@ -0,0 +1,226 @@
+package textsplitter
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"unicode"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/code"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const CodeSplitterName = "code"
+
+// Compile time check to ensure CodeSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*CodeSplitter)(nil)
+
+// blockContinuations start lines that belong to the preceding block, even if they're not indented
+var blockContinuations = []string{"}", ")", "]", "else", "elif", "except", "catch", "finally", "end"}
+
+type CodeSplitterOpts struct {
+	ChunkSize    int    `json:"chunkSize" mapstructure:"chunkSize"`
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// Language of the source code, e.g. "go" or "python" (default: the "language" metadata set by the code document loader)
+	Language string `json:"language" mapstructure:"language"`
+
+	// LengthUnit is the unit of chunkSize, "tokens" (default) or "characters"
+	LengthUnit string `json:"lengthUnit" mapstructure:"lengthUnit"`
+}
+
+// NewCodeSplitterOpts returns the default options for the code text splitter.
+func NewCodeSplitterOpts() CodeSplitterOpts {
+	return CodeSplitterOpts{
+		ChunkSize:    defaults.ChunkSizeTokens,
+		ModelName:    defaults.TokenModel,
+		EncodingName: defaults.TokenEncoding,
+		LengthUnit:   LengthUnitTokens,
+	}
+}
+
+// CodeSplitter splits source code along its declarations (functions, classes, ...), packing small neighbouring
+// declarations into one chunk. Declarations exceeding the chunk size are split along their indentation blocks
+// (e.g. the statements of a function body) and only then along lines, so that chunks are never cut mid-line.
+// The line range of each chunk is set in the startLine and endLine metadata.
+type CodeSplitter struct {
+	opts   CodeSplitterOpts
+	length LengthFunc
+}
+
+// codePiece is a range of lines [start, end] of a document
+type codePiece struct {
+	start, end   int
+	symbol, kind string
+}
+
+func NewCodeSplitter(opts CodeSplitterOpts) (*CodeSplitter, error) {
+	if opts.ChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunkSize %d", opts.ChunkSize)
+	}
+
+	length, err := NewLengthFunc(opts.LengthUnit, opts.EncodingName, opts.ModelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CodeSplitter{
+		opts:   opts,
+		length: length,
+	}, nil
+}
+
+func (s *CodeSplitter) Name() string {
+	return CodeSplitterName
+}
+
+func (s *CodeSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		if s.length(doc.Content) <= s.opts.ChunkSize {
+			result = append(result, doc)
+			continue
+		}
+
+		language := s.opts.Language
+		if l, ok := doc.Metadata["language"].(string); ok && language == "" {
+			language = l
+		}
+
+		// line numbers are relative to the document, which may be a part of a file already
+		lineOffset := 0
+		if startLine, ok := doc.Metadata["startLine"].(int); ok {
+			lineOffset = startLine - 1
+		}
+
+		content := strings.ReplaceAll(doc.Content, "\r\n", "\n")
+		lines := strings.Split(content, "\n")
+		for _, p := range s.splitCode(content, lines, language) {
+			metadata := maps.Clone(doc.Metadata)
+			if metadata == nil {
+				metadata = map[string]any{}
+			}
+			metadata["startLine"] = lineOffset + p.start + 1
+			metadata["endLine"] = lineOffset + p.end + 1
+			if _, ok := metadata["symbol"]; !ok && p.symbol != "" {
+				metadata["symbol"] = p.symbol
+				metadata["symbolKind"] = p.kind
+			}
+			result = append(result, vs.Document{
+				Content:  strings.Join(lines[p.start:p.end+1], "\n"),
+				Metadata: metadata,
+			})
+		}
+	}
+	return result, nil
+}
+
+func (s *CodeSplitter) splitCode(content string, lines []string, language string) []codePiece {
+	var pieces, pending []codePiece
+	for _, seg := range code.Segments(content, language, 0) {
+		p := codePiece{start: seg.StartLine - 1, end: seg.EndLine - 1, symbol: seg.Symbol, kind: seg.Kind}
+		if s.size(lines, p) <= s.opts.ChunkSize {
+			pending = append(pending, p)
+			continue
+		}
+		pieces = append(pieces, s.pack(lines, pending)...)
+		pending = nil
+		pieces = append(pieces, s.pack(lines, s.splitBlock(lines, p))...)
+	}
+	return append(pieces, s.pack(lines, pending)...)
+}
+
+// splitBlock splits the lines of a block into the blocks at its shallowest indentation inside, recursively
+// splitting those that are still too large. Blocks without inner structure are split into lines.
+func (s *CodeSplitter) splitBlock(lines []string, p codePiece) []codePiece {
+	minIndent := -1
+	for i := p.start + 1; i <= p.end; i++ {
+		if indent, ok := blockIndent(lines[i]); ok && (minIndent < 0 || indent < minIndent) {
+			minIndent = indent
+		}
+	}
+
+	var blocks []codePiece
+	cur := p.start
+	for i := p.start + 1; i <= p.end; i++ {
+		if indent, ok := blockIndent(lines[i]); ok && indent == minIndent {
+			blocks = append(blocks, codePiece{start: cur, end: i - 1})
+			cur = i
+		}
+	}
+	blocks = append(blocks, codePiece{start: cur, end: p.end})
+
+	if len(blocks) == 1 {
+		// no inner blocks - split into lines
+		blocks = blocks[:0]
+		for i := p.start; i <= p.end; i++ {
+			blocks = append(blocks, codePiece{start: i, end: i})
+		}
+	}
+
+	var result []codePiece
+	for _, b := range blocks {
+		if b.end > b.start && s.size(lines, b) > s.opts.ChunkSize {
+			result = append(result, s.splitBlock(lines, b)...)
+		} else {
+			result = append(result, b)
+		}
+	}
+	for i := range result {
+		result[i].symbol, result[i].kind = p.symbol, p.kind
+	}
+	return result
+}
+
+// pack merges adjacent pieces as long as they fit into the chunk size
+func (s *CodeSplitter) pack(lines []string, pieces []codePiece) []codePiece {
+	var packed []codePiece
+	for _, p := range pieces {
+		if n := len(packed); n > 0 {
+			merged := codePiece{start: packed[n-1].start, end: p.end, symbol: packed[n-1].symbol, kind: packed[n-1].kind}
+			if s.size(lines, merged) <= s.opts.ChunkSize {
+				if merged.symbol != p.symbol {
+					merged.symbol, merged.kind = "", "" // multiple declarations
+				}
+				packed[n-1] = merged
+				continue
+			}
+		}
+		packed = append(packed, p)
+	}
+
+	// drop pieces consisting of blank lines only
+	result := packed[:0]
+	for _, p := range packed {
+		if strings.TrimSpace(strings.Join(lines[p.start:p.end+1], "")) != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func (s *CodeSplitter) size(lines []string, p codePiece) int {
+	return s.length(strings.Join(lines[p.start:p.end+1], "\n"))
+}
+
+// blockIndent returns the indentation of a line that may start a block, i.e. a non-blank line that doesn't continue
+// the preceding block (like a closing brace)
+func blockIndent(line string) (int, bool) {
+	trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+	if trimmed == "" {
+		return 0, false
+	}
+	for _, c := range blockContinuations {
+		if strings.HasPrefix(trimmed, c) && (len(trimmed) == len(c) || !isIdentRune(rune(trimmed[len(c)]))) {
+			return 0, false
+		}
+	}
+	return len(line) - len(trimmed), true
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
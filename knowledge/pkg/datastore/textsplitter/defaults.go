@@ -1,7 +1,10 @@
 package textsplitter
 
 import (
+	"log/slog"
+
 	"github.com/acorn-io/z"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/code"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 )
 
@@ -12,10 +15,29 @@ func DefaultTextSplitter(filetype string, textSplitterOpts *TextSplitterOpts) ty
 	genericTextSplitter := FromLangchain(NewLcgoTextSplitter(*textSplitterOpts), "lcgo_text")
 	markdownTextSplitter := FromLangchain(NewLcgoMarkdownSplitter(*textSplitterOpts), "lcgo_markdown")
 
+	if language := code.LanguageFromFilename(filetype); language != "" {
+		codeTextSplitter, err := NewCodeSplitter(CodeSplitterOpts{
+			ChunkSize:    textSplitterOpts.ChunkSize,
+			ModelName:    textSplitterOpts.ModelName,
+			EncodingName: textSplitterOpts.EncodingName,
+			Language:     language,
+		})
+		if err == nil {
+			return codeTextSplitter
+		}
+		slog.Warn("Failed to create code text splitter, using the generic text splitter", "filetype", filetype, "error", err)
+	}
+
+	splitter := genericTextSplitter
 	switch filetype {
 	case ".md", "text/markdown":
-		return markdownTextSplitter
-	default:
-		return genericTextSplitter
+		splitter = markdownTextSplitter
+	}
+
+	tableAwareSplitter, err := NewTableAwareSplitter(splitter, *textSplitterOpts)
+	if err != nil {
+		slog.Warn("Failed to create table-aware text splitter, tables may be split", "filetype", filetype, "error", err)
+		return splitter
 	}
+	return tableAwareSplitter
 }
@@ -0,0 +1,254 @@
+package textsplitter
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const HTMLSplitterName = "html"
+
+// Compile time check to ensure HTMLSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*HTMLSplitter)(nil)
+
+const (
+	MetadataKeyHeading     = "heading"
+	MetadataKeyHeadingPath = "headingPath"
+
+	headingPathSeparator = " > "
+)
+
+var headingLevels = map[atom.Atom]int{atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6}
+
+var skippedElements = map[atom.Atom]bool{atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Svg: true}
+
+// blockElements are separated from their surroundings by a blank line
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true, atom.Header: true, atom.Footer: true,
+	atom.Aside: true, atom.Nav: true, atom.Blockquote: true, atom.Pre: true, atom.Ul: true, atom.Ol: true, atom.Dl: true,
+	atom.Table: true, atom.Figure: true, atom.Hr: true, atom.Form: true, atom.Fieldset: true, atom.Details: true, atom.Address: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// lineElements are put on a line of their own
+var lineElements = map[atom.Atom]bool{
+	atom.Li: true, atom.Tr: true, atom.Br: true, atom.Dt: true, atom.Dd: true, atom.Figcaption: true, atom.Summary: true,
+	atom.Caption: true,
+}
+
+var spacesRegex = regexp.MustCompile(`[ \t\r\n\f]+`)
+
+type HTMLSplitterOpts struct {
+	ChunkSize    int    `json:"chunkSize" mapstructure:"chunkSize"`
+	ChunkOverlap int    `json:"chunkOverlap" mapstructure:"chunkOverlap"`
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// LengthUnit is the unit of chunkSize and chunkOverlap, "tokens" (default) or "characters"
+	LengthUnit string `json:"lengthUnit" mapstructure:"lengthUnit"`
+
+	// MaxHeadingLevel is the deepest heading level that starts a new section, e.g. 3 to split on h1-h3 only (default: 6)
+	MaxHeadingLevel int `json:"maxHeadingLevel" mapstructure:"maxHeadingLevel"`
+
+	// PrependHeadingPath adds the heading path (e.g. "Install > Linux") as the first line of every chunk,
+	// so that it's embedded with the content (default: false, only the section's own heading is part of its content)
+	PrependHeadingPath bool `json:"prependHeadingPath" mapstructure:"prependHeadingPath"`
+}
+
+// NewHTMLSplitterOpts returns the default options for the HTML text splitter.
+func NewHTMLSplitterOpts() HTMLSplitterOpts {
+	return HTMLSplitterOpts{
+		ChunkSize:       defaults.ChunkSizeTokens,
+		ChunkOverlap:    defaults.ChunkOverlapTokens,
+		ModelName:       defaults.TokenModel,
+		EncodingName:    defaults.TokenEncoding,
+		LengthUnit:      LengthUnitTokens,
+		MaxHeadingLevel: 6,
+	}
+}
+
+// HTMLSplitter splits HTML documents into sections at their headings and converts them to text. The heading path
+// of each section (e.g. "Install > Linux > Debian") is set as headingPath metadata, analogous to the heading
+// hierarchy of the markdown splitter. Sections exceeding the chunk size are split further by the recursive
+// character splitter. Use it with the plaintext document loader, which keeps the HTML as it is.
+type HTMLSplitter struct {
+	opts      HTMLSplitterOpts
+	recursive *RecursiveCharacterSplitter
+}
+
+type htmlSection struct {
+	headings []string
+	text     string
+}
+
+func NewHTMLSplitter(opts HTMLSplitterOpts) (*HTMLSplitter, error) {
+	if opts.MaxHeadingLevel < 1 || opts.MaxHeadingLevel > 6 {
+		return nil, fmt.Errorf("invalid maxHeadingLevel %d, must be between 1 and 6", opts.MaxHeadingLevel)
+	}
+
+	recursive, err := NewRecursiveCharacterSplitter(RecursiveCharacterSplitterOpts{
+		ChunkSize:    opts.ChunkSize,
+		ChunkOverlap: opts.ChunkOverlap,
+		ModelName:    opts.ModelName,
+		EncodingName: opts.EncodingName,
+		Separators:   DefaultSeparators,
+		LengthUnit:   opts.LengthUnit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTMLSplitter{
+		opts:      opts,
+		recursive: recursive,
+	}, nil
+}
+
+func (s *HTMLSplitter) Name() string {
+	return HTMLSplitterName
+}
+
+func (s *HTMLSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		sections, err := s.sections(doc.Content)
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range sections {
+			headingPath := strings.Join(section.headings, headingPathSeparator)
+			for _, chunk := range s.recursive.SplitText(section.text) {
+				metadata := maps.Clone(doc.Metadata)
+				if metadata == nil {
+					metadata = map[string]any{}
+				}
+				if len(section.headings) > 0 {
+					metadata[MetadataKeyHeading] = section.headings[len(section.headings)-1]
+					metadata[MetadataKeyHeadingPath] = headingPath
+					if s.opts.PrependHeadingPath {
+						chunk = headingPath + "\n\n" + chunk
+					}
+				}
+				result = append(result, vs.Document{
+					Content:  chunk,
+					Metadata: metadata,
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// sections converts the HTML into text sections, starting a new one at every heading
+func (s *HTMLSplitter) sections(content string) ([]htmlSection, error) {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var sections []htmlSection
+	var headings []string
+	var levels []int
+	var text []byte
+
+	flush := func() {
+		if t := strings.TrimSpace(string(text)); t != "" {
+			sections = append(sections, htmlSection{headings: slices.Clone(headings), text: t})
+		}
+		text = text[:0]
+	}
+
+	// lineBreak ends the current line with n newlines (1 for a line, 2 for a paragraph), unless it's ended already
+	lineBreak := func(n int) {
+		if n == 0 || len(text) == 0 {
+			return
+		}
+		text = bytes.TrimRight(text, " \t")
+		for i := len(text) - 1; i >= 0 && text[i] == '\n' && n > 0; i-- {
+			n--
+		}
+		for ; n > 0; n-- {
+			text = append(text, '\n')
+		}
+	}
+
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			data := n.Data
+			if !pre {
+				data = spacesRegex.ReplaceAllString(data, " ")
+				if len(text) == 0 || text[len(text)-1] == '\n' || text[len(text)-1] == ' ' {
+					data = strings.TrimLeft(data, " ")
+				}
+			}
+			text = append(text, data...)
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] {
+				return
+			}
+			if level, ok := headingLevels[n.DataAtom]; ok && level <= s.opts.MaxHeadingLevel {
+				heading := strings.TrimSpace(spacesRegex.ReplaceAllString(nodeText(n), " "))
+				if heading != "" {
+					flush()
+					for len(levels) > 0 && levels[len(levels)-1] >= level {
+						levels = levels[:len(levels)-1]
+						headings = headings[:len(headings)-1]
+					}
+					levels = append(levels, level)
+					headings = append(headings, heading)
+					text = append(text, heading+"\n\n"...)
+				}
+				return
+			}
+		}
+
+		breaks := 0
+		if n.Type == html.ElementNode {
+			if blockElements[n.DataAtom] {
+				breaks = 2
+			} else if lineElements[n.DataAtom] {
+				breaks = 1
+			}
+		}
+		lineBreak(breaks)
+		switch n.DataAtom {
+		case atom.Li:
+			text = append(text, "- "...)
+		case atom.Td, atom.Th:
+			if n.PrevSibling != nil {
+				text = append(text, " | "...)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre || n.DataAtom == atom.Pre)
+		}
+		lineBreak(breaks)
+	}
+	walk(root, false)
+	flush()
+
+	return sections, nil
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package textsplitter
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+const (
+	LengthUnitTokens     = "tokens"
+	LengthUnitCharacters = "characters"
+)
+
+// LengthFunc measures text in the unit of the chunk size
+type LengthFunc func(text string) int
+
+// NewLengthFunc returns a function measuring text in tokens (using the encoding or the encoding of the model) or characters.
+func NewLengthFunc(unit, encodingName, modelName string) (LengthFunc, error) {
+	switch unit {
+	case LengthUnitCharacters:
+		return utf8.RuneCountInString, nil
+	case LengthUnitTokens, "":
+		tk, err := newTokenizer(encodingName, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return func(text string) int {
+			return len(tk.EncodeOrdinary(text)) // no panics on special tokens in the text
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown length unit %q, must be one of %q or %q", unit, LengthUnitTokens, LengthUnitCharacters)
+	}
+}
+
+// OffsetsFunc returns the byte offsets of the units (tokens or characters) of a text, followed by the length of the text,
+// so that unit i is text[offsets[i]:offsets[i+1]]
+type OffsetsFunc func(text string) []int
+
+// NewOffsetsFunc returns a function splitting text into tokens (using the encoding or the encoding of the model) or characters.
+// Token offsets are moved to the next character boundary, as a token may be a part of a multi-byte character.
+func NewOffsetsFunc(unit, encodingName, modelName string) (OffsetsFunc, error) {
+	switch unit {
+	case LengthUnitCharacters:
+		return func(text string) []int {
+			offsets := make([]int, 0, len(text)+1)
+			for i := range text {
+				offsets = append(offsets, i)
+			}
+			return append(offsets, len(text))
+		}, nil
+	case LengthUnitTokens, "":
+		tk, err := newTokenizer(encodingName, modelName)
+		if err != nil {
+			return nil, err
+		}
+		return func(text string) []int {
+			tokens := tk.EncodeOrdinary(text)
+			offsets := make([]int, 0, len(tokens)+1)
+			pos := 0
+			for _, token := range tokens {
+				offset := min(pos, len(text))
+				for offset < len(text) && !utf8.RuneStart(text[offset]) {
+					offset++
+				}
+				offsets = append(offsets, offset)
+				pos += len(tk.Decode([]int{token}))
+			}
+			return append(offsets, len(text))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown length unit %q, must be one of %q or %q", unit, LengthUnitTokens, LengthUnitCharacters)
+	}
+}
+
+func newTokenizer(encodingName, modelName string) (*tiktoken.Tiktoken, error) {
+	var tk *tiktoken.Tiktoken
+	var err error
+	if encodingName != "" {
+		tk, err = tiktoken.GetEncoding(encodingName)
+	} else if modelName != "" {
+		tk, err = tiktoken.EncodingForModel(modelName)
+	} else {
+		tk, err = tiktoken.GetEncoding(defaults.TokenEncoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokenizer: %w", err)
+	}
+	return tk, nil
+}
@@ -0,0 +1,154 @@
+package textsplitter
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const RecursiveCharacterSplitterName = "recursive_character"
+
+// Compile time check to ensure RecursiveCharacterSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*RecursiveCharacterSplitter)(nil)
+
+// DefaultSeparators split into paragraphs, lines, sentences and words - in that order
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " "}
+
+type RecursiveCharacterSplitterOpts struct {
+	ChunkSize    int    `json:"chunkSize" mapstructure:"chunkSize"`
+	ChunkOverlap int    `json:"chunkOverlap" mapstructure:"chunkOverlap"`
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// Separators are tried in order: the text is split at the first separator it contains and pieces that are still
+	// too large are split at the next one. Pieces without any separator are split between characters (default: DefaultSeparators).
+	Separators []string `json:"separators" mapstructure:"separators"`
+
+	// LengthUnit is the unit of chunkSize and chunkOverlap, "tokens" (default) or "characters"
+	LengthUnit string `json:"lengthUnit" mapstructure:"lengthUnit"`
+}
+
+// NewRecursiveCharacterSplitterOpts returns the default options for the recursive character text splitter.
+func NewRecursiveCharacterSplitterOpts() RecursiveCharacterSplitterOpts {
+	return RecursiveCharacterSplitterOpts{
+		ChunkSize:    defaults.ChunkSizeTokens,
+		ChunkOverlap: defaults.ChunkOverlapTokens,
+		ModelName:    defaults.TokenModel,
+		EncodingName: defaults.TokenEncoding,
+		Separators:   DefaultSeparators,
+		LengthUnit:   LengthUnitTokens,
+	}
+}
+
+// RecursiveCharacterSplitter splits text at natural boundaries (paragraphs, lines, sentences, words) instead of at
+// fixed token windows, so that chunks don't end mid-word. The separators stay with the text before them.
+type RecursiveCharacterSplitter struct {
+	opts   RecursiveCharacterSplitterOpts
+	length LengthFunc
+}
+
+func NewRecursiveCharacterSplitter(opts RecursiveCharacterSplitterOpts) (*RecursiveCharacterSplitter, error) {
+	if opts.ChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunkSize %d", opts.ChunkSize)
+	}
+	if opts.ChunkOverlap < 0 || opts.ChunkOverlap >= opts.ChunkSize {
+		return nil, fmt.Errorf("invalid chunkOverlap %d, must be smaller than the chunkSize %d", opts.ChunkOverlap, opts.ChunkSize)
+	}
+
+	length, err := NewLengthFunc(opts.LengthUnit, opts.EncodingName, opts.ModelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecursiveCharacterSplitter{
+		opts:   opts,
+		length: length,
+	}, nil
+}
+
+func (s *RecursiveCharacterSplitter) Name() string {
+	return RecursiveCharacterSplitterName
+}
+
+func (s *RecursiveCharacterSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		for _, chunk := range s.SplitText(doc.Content) {
+			result = append(result, vs.Document{
+				Content:  chunk,
+				Metadata: maps.Clone(doc.Metadata),
+			})
+		}
+	}
+	return result, nil
+}
+
+// SplitText splits the text into chunks of at most ChunkSize (unless a single character exceeds it).
+func (s *RecursiveCharacterSplitter) SplitText(text string) []string {
+	return s.splitText(text, s.opts.Separators)
+}
+
+func (s *RecursiveCharacterSplitter) splitText(text string, separators []string) []string {
+	var pieces, rest []string
+	for i, sep := range separators {
+		if sep != "" && strings.Contains(text, sep) {
+			pieces = strings.SplitAfter(text, sep)
+			rest = separators[i+1:]
+			break
+		}
+	}
+	byCharacter := pieces == nil
+	if byCharacter {
+		pieces = strings.Split(text, "")
+	}
+
+	var chunks, fitting []string
+	for _, piece := range pieces {
+		if piece == "" {
+			continue
+		}
+		if byCharacter || s.length(piece) <= s.opts.ChunkSize {
+			fitting = append(fitting, piece)
+			continue
+		}
+		chunks = append(chunks, mergeSplits(fitting, s.opts.ChunkSize, s.opts.ChunkOverlap, s.length)...)
+		fitting = nil
+		chunks = append(chunks, s.splitText(piece, rest)...)
+	}
+	return append(chunks, mergeSplits(fitting, s.opts.ChunkSize, s.opts.ChunkOverlap, s.length)...)
+}
+
+// mergeSplits combines the splits into chunks of at most chunkSize, where each chunk starts with (up to) chunkOverlap
+// of the previous chunk's splits
+func mergeSplits(splits []string, chunkSize, chunkOverlap int, length LengthFunc) []string {
+	var chunks, current []string
+	total := 0
+
+	appendChunk := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	for _, split := range splits {
+		l := length(split)
+		if total+l > chunkSize && len(current) > 0 {
+			appendChunk()
+			// keep the overlap, but make room for the new split
+			for len(current) > 0 && (total > chunkOverlap || total+l > chunkSize) {
+				total -= length(current[0])
+				current = current[1:]
+			}
+		}
+		current = append(current, split)
+		total += l
+	}
+	if len(current) > 0 {
+		appendChunk()
+	}
+	return chunks
+}
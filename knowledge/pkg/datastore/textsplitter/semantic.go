@@ -0,0 +1,184 @@
+package textsplitter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	lcgosplitter "github.com/tmc/langchaingo/textsplitter"
+)
+
+const SemanticSplitterName = "semantic"
+
+// Compile time check to ensure SemanticSplitter gets the context (and with it the embedding function) from the ingestion flow.
+var _ dstypes.ContextTextSplitter = (*SemanticSplitter)(nil)
+
+type SemanticSplitterOpts struct {
+	// ChunkSize is the maximum size of a chunk in tokens - larger topical groups are split by tokens (default: 2048)
+	ChunkSize    int    `json:"chunkSize" mapstructure:"chunkSize"`
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// BreakpointPercentile splits where the distance between adjacent sentences is above this percentile of all distances
+	// in the document (default: 95)
+	BreakpointPercentile float64 `json:"breakpointPercentile" mapstructure:"breakpointPercentile"`
+
+	// BreakpointThreshold is a fixed cosine distance (0-2) to split at, which takes precedence over the percentile
+	BreakpointThreshold float64 `json:"breakpointThreshold" mapstructure:"breakpointThreshold"`
+
+	// BufferSize is the number of sentences before and after a sentence that are embedded with it,
+	// which smooths out the distances of short sentences (default: 1)
+	BufferSize int `json:"bufferSize" mapstructure:"bufferSize"`
+}
+
+// NewSemanticSplitterOpts returns the default options for the semantic text splitter.
+func NewSemanticSplitterOpts() SemanticSplitterOpts {
+	return SemanticSplitterOpts{
+		ChunkSize:            defaults.ChunkSizeTokens,
+		ModelName:            defaults.TokenModel,
+		EncodingName:         defaults.TokenEncoding,
+		BreakpointPercentile: 95,
+		BufferSize:           1,
+	}
+}
+
+// SemanticSplitter embeds the sentences of a document and splits it where the meaning changes the most,
+// i.e. where the cosine distance between adjacent sentences exceeds the breakpoint, producing topically coherent chunks.
+// It requires the embedding function in the context (see dstypes.EmbeddingFuncToCtx), which is set during ingestion.
+type SemanticSplitter struct {
+	opts          SemanticSplitterOpts
+	tokenSplitter lcgosplitter.TextSplitter
+}
+
+func NewSemanticSplitter(opts SemanticSplitterOpts) (*SemanticSplitter, error) {
+	if opts.BreakpointPercentile <= 0 || opts.BreakpointPercentile > 100 {
+		return nil, fmt.Errorf("invalid breakpointPercentile %v, must be in (0, 100]", opts.BreakpointPercentile)
+	}
+	if opts.BreakpointThreshold < 0 || opts.BreakpointThreshold > 2 {
+		return nil, fmt.Errorf("invalid breakpointThreshold %v, must be a cosine distance in [0, 2]", opts.BreakpointThreshold)
+	}
+	if opts.BufferSize < 0 {
+		return nil, fmt.Errorf("invalid bufferSize %d", opts.BufferSize)
+	}
+
+	return &SemanticSplitter{
+		opts: opts,
+		tokenSplitter: NewLcgoTextSplitter(TextSplitterOpts{
+			ChunkSize:    opts.ChunkSize,
+			ModelName:    opts.ModelName,
+			EncodingName: opts.EncodingName,
+		}),
+	}, nil
+}
+
+func (s *SemanticSplitter) Name() string {
+	return SemanticSplitterName
+}
+
+func (s *SemanticSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	return s.SplitDocumentsWithContext(context.Background(), docs)
+}
+
+func (s *SemanticSplitter) SplitDocumentsWithContext(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
+	embeddingFunc := dstypes.EmbeddingFuncFromCtx(ctx)
+	if embeddingFunc == nil {
+		return nil, fmt.Errorf("semantic text splitter requires an embedding function")
+	}
+
+	var result []vs.Document
+	for _, doc := range docs {
+		chunks, err := s.splitText(ctx, embeddingFunc, doc.Content)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range chunks {
+			result = append(result, vs.Document{
+				Content:  chunk,
+				Metadata: maps.Clone(doc.Metadata),
+			})
+		}
+	}
+	return result, nil
+}
+
+func (s *SemanticSplitter) splitText(ctx context.Context, embeddingFunc vs.EmbeddingFunc, text string) ([]string, error) {
+	sentences := splitSentences(text, "")
+
+	var groups []string
+	if len(sentences) < 3 {
+		groups = []string{text} // nothing to compare
+	} else {
+		embeddings := make([][]float32, len(sentences))
+		for i := range sentences {
+			window := strings.Join(sentences[max(0, i-s.opts.BufferSize):min(len(sentences), i+s.opts.BufferSize+1)], "")
+			emb, err := embeddingFunc(ctx, strings.TrimSpace(window))
+			if err != nil {
+				return nil, fmt.Errorf("failed to embed sentence for semantic text splitting: %w", err)
+			}
+			embeddings[i] = emb
+		}
+
+		distances := make([]float64, len(sentences)-1)
+		for i := range distances {
+			distances[i] = 1 - cosineSimilarity(embeddings[i], embeddings[i+1])
+		}
+
+		threshold := s.opts.BreakpointThreshold
+		if threshold == 0 {
+			threshold = percentile(distances, s.opts.BreakpointPercentile)
+		}
+
+		start := 0
+		for i, d := range distances {
+			if d > threshold {
+				groups = append(groups, strings.Join(sentences[start:i+1], ""))
+				start = i + 1
+			}
+		}
+		groups = append(groups, strings.Join(sentences[start:], ""))
+	}
+
+	// topical groups may still exceed the chunk size
+	var chunks []string
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		if group == "" {
+			continue
+		}
+		split, err := s.tokenSplitter.SplitText(group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split semantic chunk by tokens: %w", err)
+		}
+		chunks = append(chunks, split...)
+	}
+	return chunks, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// percentile returns the p-th percentile of the values using linear interpolation between the closest ranks
+func percentile(values []float64, p float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,94 @@
+package textsplitter
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const SentenceSplitterName = "sentence"
+
+// Compile time check to ensure SentenceSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*SentenceSplitter)(nil)
+
+type SentenceSplitterOpts struct {
+	ChunkSize    int    `json:"chunkSize" mapstructure:"chunkSize"`
+	ChunkOverlap int    `json:"chunkOverlap" mapstructure:"chunkOverlap"`
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// Language of the text (ISO 639-1, e.g. "de"), which selects the abbreviations that don't end a sentence (default: "en").
+	// The "language" metadata of a document takes precedence, if set.
+	Language string `json:"language" mapstructure:"language"`
+
+	// LengthUnit is the unit of chunkSize and chunkOverlap, "tokens" (default) or "characters"
+	LengthUnit string `json:"lengthUnit" mapstructure:"lengthUnit"`
+}
+
+// NewSentenceSplitterOpts returns the default options for the sentence text splitter.
+func NewSentenceSplitterOpts() SentenceSplitterOpts {
+	return SentenceSplitterOpts{
+		ChunkSize:    defaults.ChunkSizeTokens,
+		ChunkOverlap: defaults.ChunkOverlapTokens,
+		ModelName:    defaults.TokenModel,
+		EncodingName: defaults.TokenEncoding,
+		Language:     "en",
+		LengthUnit:   LengthUnitTokens,
+	}
+}
+
+// SentenceSplitter packs whole sentences into chunks of up to ChunkSize. It never splits inside a sentence,
+// so a single sentence exceeding the chunk size becomes a chunk of its own.
+// The overlap consists of whole sentences as well.
+type SentenceSplitter struct {
+	opts   SentenceSplitterOpts
+	length LengthFunc
+}
+
+func NewSentenceSplitter(opts SentenceSplitterOpts) (*SentenceSplitter, error) {
+	if opts.ChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunkSize %d", opts.ChunkSize)
+	}
+	if opts.ChunkOverlap < 0 || opts.ChunkOverlap >= opts.ChunkSize {
+		return nil, fmt.Errorf("invalid chunkOverlap %d, must be smaller than the chunkSize %d", opts.ChunkOverlap, opts.ChunkSize)
+	}
+
+	length, err := NewLengthFunc(opts.LengthUnit, opts.EncodingName, opts.ModelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SentenceSplitter{
+		opts:   opts,
+		length: length,
+	}, nil
+}
+
+func (s *SentenceSplitter) Name() string {
+	return SentenceSplitterName
+}
+
+func (s *SentenceSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		language := s.opts.Language
+		if l, ok := doc.Metadata["language"].(string); ok && l != "" {
+			language = l
+		}
+		for _, chunk := range s.SplitText(doc.Content, language) {
+			result = append(result, vs.Document{
+				Content:  chunk,
+				Metadata: maps.Clone(doc.Metadata),
+			})
+		}
+	}
+	return result, nil
+}
+
+// SplitText splits the text into sentences and packs them into chunks.
+func (s *SentenceSplitter) SplitText(text string, language string) []string {
+	return mergeSplits(splitSentences(text, language), s.opts.ChunkSize, s.opts.ChunkOverlap, s.length)
+}
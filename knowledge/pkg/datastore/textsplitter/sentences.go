@@ -0,0 +1,112 @@
+package textsplitter
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sentenceTerminators end a sentence if they're followed by whitespace (or the end of the text)
+const sentenceTerminators = ".!?…。！？"
+
+// fullwidthTerminators end a sentence even without whitespace, as CJK text doesn't separate sentences by spaces
+const fullwidthTerminators = "。！？"
+
+// closingPunctuation may follow a sentence terminator, e.g. `."` or `.)`
+const closingPunctuation = `"')]”’»`
+
+// abbreviations per language (lowercase) - a period after these doesn't end the sentence
+var abbreviations = map[string][]string{
+	"en": {"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "vs", "etc", "e.g", "i.e", "approx", "no", "fig", "inc", "ltd", "co", "corp", "jan", "feb", "mar", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec"},
+	"de": {"z.b", "bzw", "usw", "ca", "dr", "prof", "nr", "str", "vgl", "d.h", "u.a", "evtl", "ggf", "inkl", "bzgl", "abs", "hr", "fr", "jan", "feb", "jun", "jul", "aug", "sep", "sept", "okt", "nov", "dez"},
+	"fr": {"m", "mme", "mlle", "dr", "etc", "p.ex", "c.-à-d", "env", "cf", "av", "bd", "janv", "févr", "avr", "juil", "sept", "oct", "nov", "déc"},
+	"es": {"sr", "sra", "srta", "dr", "dra", "etc", "p.ej", "ej", "aprox", "núm", "pág", "av", "ud", "uds", "ene", "feb", "abr", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	"it": {"sig", "sig.ra", "dott", "prof", "ecc", "es", "pag", "ca", "gen", "feb", "apr", "giu", "lug", "ago", "sett", "ott", "nov", "dic"},
+	"nl": {"dhr", "mevr", "dr", "prof", "bijv", "bv", "enz", "o.a", "d.w.z", "nr", "ca", "jan", "feb", "apr", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+	"pt": {"sr", "sra", "dr", "dra", "prof", "etc", "ex", "p.ex", "pág", "av", "nº", "jan", "fev", "abr", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+}
+
+// ordinalDigits are languages writing ordinal numbers with a trailing period, e.g. "am 3. Mai" in German
+var ordinalDigits = []string{"de", "da", "fi", "no", "sv", "cs", "pl", "hu", "tr"}
+
+// splitSentences splits the text into sentences at sentence-ending punctuation followed by whitespace and at paragraph breaks.
+// The language (ISO 639-1, e.g. "de") selects the abbreviations and number formats that don't end a sentence.
+// The sentences keep their trailing whitespace, so that joining them yields the original text.
+func splitSentences(text string, language string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		pos := i
+		i += size
+
+		end := false
+		switch {
+		case strings.ContainsRune(sentenceTerminators, r):
+			for i < len(text) {
+				next, nsize := utf8.DecodeRuneInString(text[i:])
+				if !strings.ContainsRune(sentenceTerminators, next) && !strings.ContainsRune(closingPunctuation, next) {
+					break
+				}
+				i += nsize
+			}
+			next, _ := utf8.DecodeRuneInString(text[i:])
+			end = i >= len(text) || unicode.IsSpace(next) || strings.ContainsRune(fullwidthTerminators, r)
+			if end && r == '.' && i < len(text) && !isSentenceEnd(text[start:pos], text[i:], language) {
+				end = false
+			}
+		case r == '\n':
+			end = strings.HasPrefix(strings.TrimLeft(text[i:], " \t\r"), "\n")
+		}
+		if !end {
+			continue
+		}
+
+		// keep the trailing whitespace with the sentence
+		for i < len(text) {
+			next, nsize := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			i += nsize
+		}
+		sentences = append(sentences, text[start:i])
+		start = i
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+// isSentenceEnd decides whether a period after the text before it ends the sentence, given the text after it
+func isSentenceEnd(before, after, language string) bool {
+	raw := strings.TrimLeft(before[strings.LastIndexFunc(before, unicode.IsSpace)+1:], `"'([“‘«`)
+	word := strings.ToLower(raw)
+
+	// continued in lowercase, e.g. "e.g. the" or "etc. and"
+	next := strings.TrimLeftFunc(after, unicode.IsSpace)
+	if r, _ := utf8.DecodeRuneInString(next); unicode.IsLower(r) {
+		return false
+	}
+	// initials, e.g. "J. R. R. Tolkien"
+	if r, size := utf8.DecodeRuneInString(raw); size == len(raw) && unicode.IsUpper(r) {
+		return false
+	}
+
+	if language == "" {
+		language = "en"
+	}
+	language = strings.ToLower(language)
+	if i := strings.IndexAny(language, "-_"); i > 0 {
+		language = language[:i] // e.g. "en-US"
+	}
+	if slices.Contains(abbreviations[language], word) {
+		return false
+	}
+	if slices.Contains(ordinalDigits, language) && word != "" && strings.TrimFunc(word, unicode.IsDigit) == "" {
+		return false
+	}
+	return true
+}
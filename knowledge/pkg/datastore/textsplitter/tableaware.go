@@ -0,0 +1,91 @@
+package textsplitter
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/tables"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// Compile time check to ensure TableAwareSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*TableAwareSplitter)(nil)
+
+// TableAwareSplitter keeps markdown tables intact, which other splitters would cut at arbitrary rows or even mid-row.
+// The text around tables is split by the wrapped splitter, while each table becomes a chunk of its own.
+// Tables exceeding the chunk size are split between rows, repeating the header row in every chunk, so that each
+// chunk can be understood on its own.
+type TableAwareSplitter struct {
+	splitter  dstypes.TextSplitter
+	chunkSize int
+	length    LengthFunc
+}
+
+func NewTableAwareSplitter(splitter dstypes.TextSplitter, opts TextSplitterOpts) (*TableAwareSplitter, error) {
+	length, err := NewLengthFunc(LengthUnitTokens, opts.EncodingName, opts.ModelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TableAwareSplitter{
+		splitter:  splitter,
+		chunkSize: opts.ChunkSize,
+		length:    length,
+	}, nil
+}
+
+func (s *TableAwareSplitter) Name() string {
+	return s.splitter.Name()
+}
+
+func (s *TableAwareSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		segments := tables.MarkdownSegments(doc.Content)
+		if len(segments) == 1 && segments[0].Table == nil {
+			split, err := s.splitter.SplitDocuments([]vs.Document{doc})
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, split...)
+			continue
+		}
+
+		for _, segment := range segments {
+			if segment.Table == nil {
+				split, err := s.splitter.SplitDocuments([]vs.Document{{Content: segment.Text, Metadata: maps.Clone(doc.Metadata)}})
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, split...)
+				continue
+			}
+
+			if s.length(segment.Text) <= s.chunkSize {
+				result = append(result, tableDocument(segment.Text, doc.Metadata, ""))
+				continue
+			}
+			chunks := segment.Table.MarkdownChunks(s.chunkSize, s.length)
+			for i, chunk := range chunks {
+				result = append(result, tableDocument(chunk.Markdown(), doc.Metadata, fmt.Sprintf("%d/%d", i+1, len(chunks))))
+			}
+		}
+	}
+	return result, nil
+}
+
+func tableDocument(content string, baseMetadata map[string]any, part string) vs.Document {
+	metadata := maps.Clone(baseMetadata)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata[tables.MetadataKeyContentType] = tables.ContentTypeTable
+	if part != "" {
+		metadata["tablePart"] = part
+	}
+	return vs.Document{
+		Content:  content,
+		Metadata: metadata,
+	}
+}
@@ -62,6 +62,18 @@ func GetTextSplitterConfig(name string) (any, error) {
 	switch name {
 	case "text", "markdown":
 		return TextSplitterOpts{}, nil
+	case SemanticSplitterName:
+		return SemanticSplitterOpts{}, nil
+	case RecursiveCharacterSplitterName, "recursive":
+		return RecursiveCharacterSplitterOpts{}, nil
+	case SentenceSplitterName:
+		return SentenceSplitterOpts{}, nil
+	case CodeSplitterName:
+		return CodeSplitterOpts{}, nil
+	case HTMLSplitterName:
+		return HTMLSplitterOpts{}, nil
+	case SlidingWindowSplitterName:
+		return SlidingWindowSplitterOpts{}, nil
 	default:
 		return nil, fmt.Errorf("unknown text splitter %q", name)
 	}
@@ -83,7 +95,7 @@ func GetTextSplitter(name string, config any) (dstypes.TextSplitter, error) {
 			cfg = customCfg
 		}
 		slog.Debug("TextSplitter", "config", cfg)
-		return FromLangchain(NewLcgoTextSplitter(cfg), "lcgo_text"), nil
+		return NewTableAwareSplitter(FromLangchain(NewLcgoTextSplitter(cfg), "lcgo_text"), cfg)
 	case "markdown":
 		cfg := NewTextSplitterOpts()
 		if config != nil {
@@ -98,7 +110,91 @@ func GetTextSplitter(name string, config any) (dstypes.TextSplitter, error) {
 			cfg = customCfg
 		}
 		slog.Debug("MarkdownSplitter", "config", cfg)
-		return FromLangchain(NewLcgoMarkdownSplitter(cfg), "lcgo_markdown"), nil
+		return NewTableAwareSplitter(FromLangchain(NewLcgoMarkdownSplitter(cfg), "lcgo_markdown"), cfg)
+	case SemanticSplitterName:
+		cfg := NewSemanticSplitterOpts()
+		if config != nil {
+			var customCfg SemanticSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode semantic text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge semantic text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("SemanticSplitter", "config", cfg)
+		return NewSemanticSplitter(cfg)
+	case RecursiveCharacterSplitterName, "recursive":
+		cfg := NewRecursiveCharacterSplitterOpts()
+		if config != nil {
+			var customCfg RecursiveCharacterSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode recursive character text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge recursive character text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("RecursiveCharacterSplitter", "config", cfg)
+		return NewRecursiveCharacterSplitter(cfg)
+	case SentenceSplitterName:
+		cfg := NewSentenceSplitterOpts()
+		if config != nil {
+			var customCfg SentenceSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode sentence text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge sentence text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("SentenceSplitter", "config", cfg)
+		return NewSentenceSplitter(cfg)
+	case CodeSplitterName:
+		cfg := NewCodeSplitterOpts()
+		if config != nil {
+			var customCfg CodeSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode code text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge code text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("CodeSplitter", "config", cfg)
+		return NewCodeSplitter(cfg)
+	case HTMLSplitterName:
+		cfg := NewHTMLSplitterOpts()
+		if config != nil {
+			var customCfg HTMLSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode HTML text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge HTML text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("HTMLSplitter", "config", cfg)
+		return NewHTMLSplitter(cfg)
+	case SlidingWindowSplitterName:
+		cfg := NewSlidingWindowSplitterOpts()
+		if config != nil {
+			var customCfg SlidingWindowSplitterOpts
+			if err := mapstructure.Decode(config, &customCfg); err != nil {
+				return nil, fmt.Errorf("failed to decode sliding window text splitter configuration: %w", err)
+			}
+			if err := mergo.Merge(&customCfg, cfg); err != nil {
+				return nil, fmt.Errorf("failed to merge sliding window text splitter configuration: %w", err)
+			}
+			cfg = customCfg
+		}
+		slog.Debug("SlidingWindowSplitter", "config", cfg)
+		return NewSlidingWindowSplitter(cfg)
 	default:
 		return nil, fmt.Errorf("unknown text splitter %q", name)
 	}
@@ -1,9 +1,14 @@
 package textsplitter
 
 import (
+	"context"
+	"strings"
 	"testing"
 
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetTextSplitterConfigWithValidName(t *testing.T) {
@@ -35,3 +40,315 @@ func TestGetTextSplitterFuncWithInvalidName(t *testing.T) {
 	_, err := GetTextSplitter("invalid", nil)
 	assert.Error(t, err)
 }
+
+func TestSplitSentences(t *testing.T) {
+	text := "Hello world. How are you?! I'm fine (really).\n\nNew paragraph without period\nsame paragraph. Version 1.2 is out… 終わり。次"
+	sentences := splitSentences(text, "")
+	assert.Equal(t, []string{
+		"Hello world. ",
+		"How are you?! ",
+		"I'm fine (really).\n\n",
+		"New paragraph without period\nsame paragraph. ",
+		"Version 1.2 is out… ",
+		"終わり。",
+		"次",
+	}, sentences)
+	assert.Equal(t, text, strings.Join(sentences, ""))
+}
+
+// wholeTextSplitter returns the text as a single chunk, so tests don't depend on the tokenizer
+type wholeTextSplitter struct{}
+
+func (wholeTextSplitter) SplitText(text string) ([]string, error) {
+	return []string{text}, nil
+}
+
+func TestSemanticSplitter(t *testing.T) {
+	// sentences about cats and cars point in different directions
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		switch {
+		case strings.Contains(text, "cat"):
+			return []float32{1, 0.1}, nil
+		case strings.Contains(text, "car"):
+			return []float32{0.1, 1}, nil
+		}
+		return []float32{1, 1}, nil
+	}
+
+	opts := NewSemanticSplitterOpts()
+	opts.BufferSize = 0
+	opts.BreakpointThreshold = 0.5
+	s := &SemanticSplitter{opts: opts, tokenSplitter: wholeTextSplitter{}}
+
+	docs := []vs.Document{{
+		Content:  "The cat sleeps. A cat purrs. My cat eats. The car is fast. A car needs fuel.",
+		Metadata: map[string]any{"filename": "pets.txt"},
+	}}
+
+	_, err := s.SplitDocuments(docs)
+	assert.Error(t, err, "embedding function is required")
+
+	chunks, err := s.SplitDocumentsWithContext(dstypes.EmbeddingFuncToCtx(context.Background(), embeddingFunc), docs)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "The cat sleeps. A cat purrs. My cat eats.", chunks[0].Content)
+	assert.Equal(t, "The car is fast. A car needs fuel.", chunks[1].Content)
+	assert.Equal(t, "pets.txt", chunks[1].Metadata["filename"])
+}
+
+func TestPercentile(t *testing.T) {
+	assert.InDelta(t, 0.5, percentile([]float64{0.1, 0.9, 0.5}, 50), 1e-9)
+	assert.InDelta(t, 0.86, percentile([]float64{0.1, 0.9, 0.5}, 95), 1e-9)
+}
+
+func TestRecursiveCharacterSplitter(t *testing.T) {
+	s, err := NewRecursiveCharacterSplitter(RecursiveCharacterSplitterOpts{
+		ChunkSize:    40,
+		ChunkOverlap: 10,
+		Separators:   DefaultSeparators,
+		LengthUnit:   LengthUnitCharacters,
+	})
+	require.NoError(t, err)
+
+	text := "Erster Absatz mit zwei Sätzen. Der zweite Satz.\n\nZweiter Absatz.\n\nDonaudampfschifffahrtsgesellschaftskapitänsmütze"
+	chunks := s.SplitText(text)
+	assert.Equal(t, []string{
+		"Erster Absatz mit zwei Sätzen.",
+		"Der zweite Satz.",
+		"Zweiter Absatz.",
+		"Donaudampfschifffahrtsgesellschaftskapit",
+		"haftskapitänsmütze", // overlap of 10 characters
+	}, chunks)
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, len([]rune(chunk)), 40)
+	}
+
+	_, err = NewRecursiveCharacterSplitter(RecursiveCharacterSplitterOpts{ChunkSize: 10, ChunkOverlap: 10, LengthUnit: LengthUnitCharacters})
+	assert.Error(t, err)
+}
+
+func TestSplitSentencesLanguage(t *testing.T) {
+	assert.Equal(t, []string{
+		"Dr. Smith met J. R. R. Tolkien, e.g. at the pub. ",
+		"Then they left.",
+	}, splitSentences("Dr. Smith met J. R. R. Tolkien, e.g. at the pub. Then they left.", "en"))
+
+	assert.Equal(t, []string{
+		"Wir treffen uns am 3. Mai, z.B. in Raum Nr. 5 im Büro. ",
+		"Danach geht es weiter.",
+	}, splitSentences("Wir treffen uns am 3. Mai, z.B. in Raum Nr. 5 im Büro. Danach geht es weiter.", "de-DE"))
+}
+
+func TestSentenceSplitter(t *testing.T) {
+	s, err := NewSentenceSplitter(SentenceSplitterOpts{ChunkSize: 40, ChunkOverlap: 15, LengthUnit: LengthUnitCharacters})
+	require.NoError(t, err)
+
+	docs, err := s.SplitDocuments([]vs.Document{{
+		Content:  "Erster Satz. Zweiter Satz. Dritter Satz. Ein sehr langer Satz, der nicht in einen Chunk passt. Ende.",
+		Metadata: map[string]any{"language": "de"},
+	}})
+	require.NoError(t, err)
+
+	var chunks []string
+	for _, doc := range docs {
+		chunks = append(chunks, doc.Content)
+		assert.Equal(t, "de", doc.Metadata["language"])
+	}
+	assert.Equal(t, []string{
+		"Erster Satz. Zweiter Satz.",
+		"Zweiter Satz. Dritter Satz.", // overlap of one sentence
+		"Ein sehr langer Satz, der nicht in einen Chunk passt.",
+		"Ende.",
+	}, chunks)
+}
+
+func TestCodeSplitter(t *testing.T) {
+	s, err := NewCodeSplitter(CodeSplitterOpts{ChunkSize: 120, LengthUnit: LengthUnitCharacters})
+	require.NoError(t, err)
+
+	source := `package main
+
+import "fmt"
+
+func small() int {
+	return 1
+}
+
+func large(items []string) {
+	for _, item := range items {
+		fmt.Println("item", item)
+	}
+	if len(items) == 0 {
+		fmt.Println("no items at all")
+	} else {
+		fmt.Println("done")
+	}
+}`
+
+	docs, err := s.SplitDocuments([]vs.Document{{Content: source, Metadata: map[string]any{"language": "go"}}})
+	require.NoError(t, err)
+
+	var chunks []string
+	for _, doc := range docs {
+		chunks = append(chunks, doc.Content)
+	}
+	assert.Equal(t, []string{
+		"package main\n\nimport \"fmt\"\n\nfunc small() int {\n\treturn 1\n}",
+		"func large(items []string) {\n\tfor _, item := range items {\n\t\tfmt.Println(\"item\", item)\n\t}",
+		"\tif len(items) == 0 {\n\t\tfmt.Println(\"no items at all\")\n\t} else {\n\t\tfmt.Println(\"done\")\n\t}\n}",
+	}, chunks)
+	assert.Equal(t, 9, docs[1].Metadata["startLine"])
+	assert.Equal(t, 12, docs[1].Metadata["endLine"])
+	assert.Equal(t, "large", docs[2].Metadata["symbol"])
+	assert.Equal(t, "go", docs[2].Metadata["language"])
+}
+
+func TestHTMLSplitter(t *testing.T) {
+	s, err := NewHTMLSplitter(HTMLSplitterOpts{ChunkSize: 200, LengthUnit: LengthUnitCharacters, MaxHeadingLevel: 6})
+	require.NoError(t, err)
+
+	content := `<html><head><title>Guide</title><style>p { color: red; }</style></head><body>
+<p>Welcome to the   guide.</p>
+<h1>Install</h1>
+<p>Pick your platform.</p>
+<h2>Linux</h2>
+<ul><li>Download the archive</li><li>Extract it</li></ul>
+<h3>Debian</h3>
+<pre>apt install tool
+tool --version</pre>
+<h2>macOS</h2>
+<p>Use <b>brew</b>.</p>
+<h1>Usage</h1>
+<table><tr><th>Flag</th><th>Meaning</th></tr><tr><td>-v</td><td>verbose</td></tr></table>
+</body></html>`
+
+	docs, err := s.SplitDocuments([]vs.Document{{Content: content, Metadata: map[string]any{"source": "guide.html"}}})
+	require.NoError(t, err)
+
+	var chunks, paths []string
+	for _, doc := range docs {
+		chunks = append(chunks, doc.Content)
+		path, _ := doc.Metadata[MetadataKeyHeadingPath].(string)
+		paths = append(paths, path)
+		assert.Equal(t, "guide.html", doc.Metadata["source"])
+	}
+	assert.Equal(t, []string{
+		"Welcome to the guide.",
+		"Install\n\nPick your platform.",
+		"Linux\n\n- Download the archive\n- Extract it",
+		"Debian\n\napt install tool\ntool --version",
+		"macOS\n\nUse brew.",
+		"Usage\n\nFlag | Meaning\n-v | verbose",
+	}, chunks)
+	assert.Equal(t, []string{"", "Install", "Install > Linux", "Install > Linux > Debian", "Install > macOS", "Usage"}, paths)
+	assert.Equal(t, "Debian", docs[3].Metadata[MetadataKeyHeading])
+
+	s, err = NewHTMLSplitter(HTMLSplitterOpts{ChunkSize: 200, LengthUnit: LengthUnitCharacters, MaxHeadingLevel: 1, PrependHeadingPath: true})
+	require.NoError(t, err)
+	docs, err = s.SplitDocuments([]vs.Document{{Content: content}})
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.True(t, strings.HasPrefix(docs[1].Content, "Install\n\nInstall\n\nPick your platform.\n\nLinux"))
+	assert.Equal(t, "Install", docs[1].Metadata[MetadataKeyHeadingPath])
+}
+
+func TestTableAwareSplitter(t *testing.T) {
+	inner, err := NewRecursiveCharacterSplitter(RecursiveCharacterSplitterOpts{ChunkSize: 40, Separators: DefaultSeparators, LengthUnit: LengthUnitCharacters})
+	require.NoError(t, err)
+	s := &TableAwareSplitter{splitter: inner, chunkSize: 70, length: func(s string) int { return len(s) }}
+
+	content := `Prices as of today.
+
+| Product | Price |
+|---------|-------|
+| Apple   | 1.00  |
+| Banana  | 0.50  |
+| Cherry  | 3.00  |
+
+Small table:
+
+| a | b |
+|---|---|
+| 1 | 2 |`
+
+	docs, err := s.SplitDocuments([]vs.Document{{Content: content, Metadata: map[string]any{"source": "prices.md"}}})
+	require.NoError(t, err)
+
+	var chunks []string
+	for _, doc := range docs {
+		chunks = append(chunks, doc.Content)
+		assert.Equal(t, "prices.md", doc.Metadata["source"])
+	}
+	assert.Equal(t, []string{
+		"Prices as of today.",
+		"| Product | Price |\n| --- | --- |\n| Apple | 1.00 |\n| Banana | 0.50 |",
+		"| Product | Price |\n| --- | --- |\n| Cherry | 3.00 |",
+		"Small table:",
+		"| a | b |\n|---|---|\n| 1 | 2 |",
+	}, chunks)
+	assert.Equal(t, "table", docs[1].Metadata["contentType"])
+	assert.Equal(t, "2/2", docs[2].Metadata["tablePart"])
+	assert.Nil(t, docs[4].Metadata["tablePart"])
+	assert.Nil(t, docs[3].Metadata["contentType"])
+}
+
+func TestSlidingWindowSplitter(t *testing.T) {
+	s, err := NewSlidingWindowSplitter(SlidingWindowSplitterOpts{WindowSize: 10, Stride: 6, LengthUnit: LengthUnitCharacters})
+	require.NoError(t, err)
+
+	content := "Grüße aus Köln, bis bald!"
+	docs, err := s.SplitDocuments([]vs.Document{{Content: content, Metadata: map[string]any{"source": "a.txt"}}})
+	require.NoError(t, err)
+
+	var chunks []string
+	for i, doc := range docs {
+		chunks = append(chunks, doc.Content)
+		assert.Equal(t, i, doc.Metadata[MetadataKeyWindowIndex])
+		assert.Equal(t, "a.txt", doc.Metadata["source"])
+		runes := []rune(content)
+		assert.Equal(t, doc.Content, string(runes[doc.Metadata[MetadataKeyStartOffset].(int):doc.Metadata[MetadataKeyEndOffset].(int)]))
+	}
+	assert.Equal(t, []string{"Grüße aus", "aus Köln,", "ln, bis ba", "s bald!"}, chunks)
+
+	_, err = NewSlidingWindowSplitter(SlidingWindowSplitterOpts{WindowSize: 10, Stride: 11, LengthUnit: LengthUnitCharacters})
+	assert.Error(t, err)
+}
+
+func TestAdaptiveSplitter(t *testing.T) {
+	var chunkSizes []int
+	s, err := NewAdaptiveSplitter(AdaptiveChunkingOpts{MinChunkSize: 64, MaxChunkSize: 512, TargetChunks: 4, WholeDocumentSize: 100, LengthUnit: LengthUnitCharacters}, "recursive_character", func(chunkSize int) (dstypes.TextSplitter, error) {
+		chunkSizes = append(chunkSizes, chunkSize)
+		return NewRecursiveCharacterSplitter(RecursiveCharacterSplitterOpts{ChunkSize: chunkSize, Separators: DefaultSeparators, LengthUnit: LengthUnitCharacters})
+	})
+	require.NoError(t, err)
+
+	// small documents stay whole
+	small := []vs.Document{{Content: "A short note.\n\nWith two paragraphs."}}
+	docs, err := s.SplitDocuments(small)
+	require.NoError(t, err)
+	assert.Equal(t, small, docs)
+	assert.Empty(t, chunkSizes)
+
+	// long documents get larger chunks, but stay within the bounds
+	assert.Equal(t, 64, s.chunkSize(200, 10))
+	assert.Equal(t, 256, s.chunkSize(1000, 10))
+	assert.Equal(t, 512, s.chunkSize(10000, 10))
+	// chunks fit whole sections
+	assert.Equal(t, 320, s.chunkSize(1000, 300))
+
+	long := strings.Repeat("Lorem ipsum dolor sit amet. ", 40) // 1120 characters, no sections
+	docs, err = s.SplitDocuments([]vs.Document{{Content: long}, {Content: long}})
+	require.NoError(t, err)
+	assert.Equal(t, []int{512}, chunkSizes) // the paragraphs exceed the maximum
+	for _, doc := range docs {
+		assert.LessOrEqual(t, len(doc.Content), 512)
+	}
+
+	assert.Equal(t, []string{"# A\ntext\n", "## B\nmore"}, documentSections("# A\ntext\n## B\nmore"))
+
+	splitter, err := NamedSplitterFactory(RecursiveCharacterSplitterName, RecursiveCharacterSplitterOpts{ChunkSize: 1000, ChunkOverlap: 100, LengthUnit: LengthUnitCharacters})(512)
+	require.NoError(t, err)
+	assert.Equal(t, 512, splitter.(*RecursiveCharacterSplitter).opts.ChunkSize)
+	assert.Equal(t, 51, splitter.(*RecursiveCharacterSplitter).opts.ChunkOverlap)
+	assert.Equal(t, LengthUnitCharacters, splitter.(*RecursiveCharacterSplitter).opts.LengthUnit)
+}
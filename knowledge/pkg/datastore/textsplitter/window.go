@@ -0,0 +1,124 @@
+package textsplitter
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const SlidingWindowSplitterName = "sliding_window"
+
+// Compile time check to ensure SlidingWindowSplitter satisfies the TextSplitter interface.
+var _ dstypes.TextSplitter = (*SlidingWindowSplitter)(nil)
+
+const (
+	MetadataKeyWindowIndex = "windowIndex"
+	MetadataKeyStartOffset = "startOffset"
+	MetadataKeyEndOffset   = "endOffset"
+)
+
+type SlidingWindowSplitterOpts struct {
+	// WindowSize is the size of each window (default: 512)
+	WindowSize int `json:"windowSize" mapstructure:"windowSize"`
+
+	// Stride is the distance between the starts of two consecutive windows, so that they overlap by windowSize - stride (default: 128)
+	Stride int `json:"stride" mapstructure:"stride"`
+
+	ModelName    string `json:"modelName" mapstructure:"modelName"`
+	EncodingName string `json:"encodingName" mapstructure:"encodingName"`
+
+	// LengthUnit is the unit of windowSize and stride, "tokens" (default) or "characters"
+	LengthUnit string `json:"lengthUnit" mapstructure:"lengthUnit"`
+}
+
+// NewSlidingWindowSplitterOpts returns the default options for the sliding window text splitter.
+func NewSlidingWindowSplitterOpts() SlidingWindowSplitterOpts {
+	return SlidingWindowSplitterOpts{
+		WindowSize:   512,
+		Stride:       128,
+		ModelName:    defaults.TokenModel,
+		EncodingName: defaults.TokenEncoding,
+		LengthUnit:   LengthUnitTokens,
+	}
+}
+
+// SlidingWindowSplitter slides a window of a fixed size over the text, moving it by the stride, regardless of the
+// text's structure. Every window gets its index and its character offsets in the document (startOffset, endOffset)
+// in the metadata, e.g. for dense retrieval experiments mapping hits back to the source text.
+type SlidingWindowSplitter struct {
+	opts    SlidingWindowSplitterOpts
+	offsets OffsetsFunc
+}
+
+func NewSlidingWindowSplitter(opts SlidingWindowSplitterOpts) (*SlidingWindowSplitter, error) {
+	if opts.WindowSize <= 0 {
+		return nil, fmt.Errorf("invalid windowSize %d", opts.WindowSize)
+	}
+	if opts.Stride <= 0 || opts.Stride > opts.WindowSize {
+		return nil, fmt.Errorf("invalid stride %d, must be between 1 and the windowSize %d", opts.Stride, opts.WindowSize)
+	}
+
+	offsets, err := NewOffsetsFunc(opts.LengthUnit, opts.EncodingName, opts.ModelName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlidingWindowSplitter{
+		opts:    opts,
+		offsets: offsets,
+	}, nil
+}
+
+func (s *SlidingWindowSplitter) Name() string {
+	return SlidingWindowSplitterName
+}
+
+func (s *SlidingWindowSplitter) SplitDocuments(docs []vs.Document) ([]vs.Document, error) {
+	var result []vs.Document
+	for _, doc := range docs {
+		offsets := s.offsets(doc.Content)
+		units := len(offsets) - 1
+
+		// character offsets of the unit boundaries
+		charOffsets := make([]int, len(offsets))
+		for i := 1; i < len(offsets); i++ {
+			charOffsets[i] = charOffsets[i-1] + utf8.RuneCountInString(doc.Content[offsets[i-1]:offsets[i]])
+		}
+
+		index := 0
+		for start := 0; start < units; start += s.opts.Stride {
+			end := min(start+s.opts.WindowSize, units)
+			window := doc.Content[offsets[start]:offsets[end]]
+
+			// trim whitespace, keeping the offsets exact
+			trimmed := strings.TrimLeftFunc(window, unicode.IsSpace)
+			startOffset := charOffsets[start] + utf8.RuneCountInString(window[:len(window)-len(trimmed)])
+			trimmed = strings.TrimRightFunc(trimmed, unicode.IsSpace)
+			if trimmed != "" {
+				metadata := maps.Clone(doc.Metadata)
+				if metadata == nil {
+					metadata = map[string]any{}
+				}
+				metadata[MetadataKeyWindowIndex] = index
+				metadata[MetadataKeyStartOffset] = startOffset
+				metadata[MetadataKeyEndOffset] = startOffset + utf8.RuneCountInString(trimmed)
+				result = append(result, vs.Document{
+					Content:  trimmed,
+					Metadata: metadata,
+				})
+				index++
+			}
+
+			if end == units {
+				break
+			}
+		}
+	}
+	return result, nil
+}
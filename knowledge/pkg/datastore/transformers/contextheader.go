@@ -0,0 +1,228 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const ContextualHeaderName = "contextual_header"
+
+// MetadataKeyContextHeader is the metadata key of the header describing where the document (chunk) sits in the file
+const MetadataKeyContextHeader = "contextHeader"
+
+const (
+	ContextHeaderTargetEmbedding = "embedding" // embed the header followed by the content, the stored content stays unchanged
+	ContextHeaderTargetContent   = "content"   // prepend the header to the stored content, so it's embedded and returned by retrieval
+)
+
+// ContextualHeader implements "contextual retrieval": each document (chunk) is prefixed with a one-line description of where
+// it sits in the file - the title, the section path and a summary of the file - before embedding, so that chunks of long files
+// which don't mention what they're about (e.g. "The limit is 10 per minute") can still be found.
+// The summary is taken from the "summary" metadata (see the summarize transformer with the file scope) or generated per chunk
+// with the LLM, if Generate is set.
+// With the embedding target, the documents must not be embedded already: combined with the summarize transformer, which
+// embeds the summary, the header is rejected instead of replacing the embedding of the summary - use the content target then.
+type ContextualHeader struct {
+	Target string // ContextHeaderTargetEmbedding (default) or ContextHeaderTargetContent
+
+	TitleKeys   []string // Metadata keys of the title, the first one set is used (default: title, deckTitle - falls back to the filename)
+	SectionKeys []string // Metadata keys of the section path, the first one set is used (default: headingPath, heading)
+	SummaryKey  string   // Metadata key of the summary of the file (default: summary) - set to "-" to omit the summary
+
+	// Generate lets the LLM write a short description of the chunk in the context of the whole file, which replaces the summary
+	Generate    bool
+	Model       llm.LLMConfig
+	MaxChars    int // Maximum number of characters of the file sent to the LLM (default: 16000)
+	Concurrency int // Maximum number of parallel LLM requests (default: 5)
+}
+
+var contextHeaderPromptTpl = `<document>
+{{.document}}
+</document>
+Here is a chunk of the document above:
+<chunk>
+{{.chunk}}
+</chunk>
+Write one short sentence that situates the chunk within the overall document, to improve search retrieval of the chunk.
+Reply only with the sentence, without any introduction or formatting.`
+
+func (c *ContextualHeader) Name() string {
+	return ContextualHeaderName
+}
+
+func (c *ContextualHeader) NeedsEmbeddingFunc() bool {
+	return c.Target == "" || c.Target == ContextHeaderTargetEmbedding
+}
+
+func (c *ContextualHeader) Transform(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
+	switch c.Target {
+	case "", ContextHeaderTargetEmbedding, ContextHeaderTargetContent:
+	default:
+		return nil, fmt.Errorf("invalid context header target %q, must be %q or %q", c.Target, ContextHeaderTargetEmbedding, ContextHeaderTargetContent)
+	}
+
+	var embedDocumentsFunc dstypes.EmbedDocumentsFunc
+	if c.NeedsEmbeddingFunc() {
+		embedDocumentsFunc = dstypes.EmbedDocumentsFuncFromCtx(ctx)
+		if embedDocumentsFunc == nil {
+			return nil, fmt.Errorf("no embedding function available to embed the context headers")
+		}
+		for _, doc := range docs {
+			if _, ok := doc.Metadata[MetadataKeyContextHeader]; !ok && len(doc.Embedding) > 0 {
+				return nil, fmt.Errorf("document %q is embedded already, e.g. with its summary - the context header can only be embedded with the %q target", doc.ID, ContextHeaderTargetContent)
+			}
+		}
+	}
+
+	var (
+		m        *llm.LLM
+		document string
+	)
+	if c.Generate {
+		var err error
+		m, err = llm.NewFromConfig(c.Model)
+		if err != nil {
+			return nil, err
+		}
+		document = c.document(docs)
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	filename := dstypes.FilenameFromCtx(ctx)
+
+	// The headers are generated in parallel and embedded in batches afterwards
+	headers := make([]string, len(docs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, doc := range docs {
+		if _, ok := doc.Metadata[MetadataKeyContextHeader]; ok {
+			continue // e.g. documents of a reused file
+		}
+		g.Go(func() error {
+			var description string
+			if m != nil {
+				var err error
+				description, err = m.Prompt(gctx, contextHeaderPromptTpl, map[string]any{"document": document, "chunk": strings.TrimSpace(doc.Content)})
+				if err != nil {
+					return fmt.Errorf("failed to generate context header of document %q: %w", doc.ID, err)
+				}
+			} else if c.SummaryKey != "-" {
+				description = metadataString(doc.Metadata, orDefault(c.SummaryKey, MetadataKeySummary))
+			}
+			headers[i] = c.header(doc.Metadata, filename, description)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var idx []int
+	var toEmbed []vs.Document
+	for i, header := range headers {
+		if header == "" {
+			continue
+		}
+		if embedDocumentsFunc != nil {
+			idx = append(idx, i)
+			toEmbed = append(toEmbed, vs.Document{ID: docs[i].ID, Content: header + "\n\n" + docs[i].Content})
+		} else {
+			docs[i].Content = header + "\n\n" + docs[i].Content
+		}
+		if docs[i].Metadata == nil {
+			docs[i].Metadata = make(map[string]any)
+		}
+		docs[i].Metadata[MetadataKeyContextHeader] = header
+	}
+	if len(toEmbed) > 0 {
+		if err := embedDocumentsFunc(ctx, toEmbed); err != nil {
+			return nil, fmt.Errorf("failed to embed context headers: %w", err)
+		}
+		for j, i := range idx {
+			docs[i].Embedding = toEmbed[j].Embedding
+		}
+	}
+	slog.Debug("Added context headers", "num_documents", len(docs))
+	return docs, nil
+}
+
+// header returns the one-line header, e.g. "Document: Admin Guide > Rate Limits. Configuring the API gateway of Acme Cloud."
+func (c *ContextualHeader) header(metadata map[string]any, filename, description string) string {
+	titleKeys := c.TitleKeys
+	if len(titleKeys) == 0 {
+		titleKeys = []string{"title", "deckTitle"}
+	}
+	sectionKeys := c.SectionKeys
+	if len(sectionKeys) == 0 {
+		sectionKeys = []string{"headingPath", "heading"}
+	}
+
+	var location []string
+	title := metadataString(metadata, titleKeys...)
+	if title == "" && filename != "" {
+		title = path.Base(filename)
+	}
+	if title != "" {
+		location = append(location, title)
+	}
+	if section := metadataString(metadata, sectionKeys...); section != "" {
+		location = append(location, section)
+	}
+
+	var parts []string
+	if len(location) > 0 {
+		parts = append(parts, "Document: "+strings.Join(location, " > ")+".")
+	}
+	if description = strings.Join(strings.Fields(description), " "); description != "" {
+		parts = append(parts, description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// document returns the combined content of the documents, cut to the maximum number of characters sent to the LLM
+func (c *ContextualHeader) document(docs []vs.Document) string {
+	maxChars := c.MaxChars
+	if maxChars <= 0 {
+		maxChars = 16000
+	}
+
+	var sb strings.Builder
+	for _, doc := range docs {
+		sb.WriteString(doc.Content)
+		sb.WriteString("\n\n")
+	}
+	document := strings.TrimSpace(sb.String())
+	if r := []rune(document); len(r) > maxChars {
+		document = string(r[:maxChars])
+	}
+	return document
+}
+
+// metadataString returns the first non-empty string value of the given metadata keys
+func metadataString(metadata map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if s, ok := metadata[k].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package transformers
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextualHeaderHeader(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		c           ContextualHeader
+		metadata    map[string]any
+		filename    string
+		description string
+		want        string
+	}{
+		{
+			name:        "title, section and description",
+			metadata:    map[string]any{"title": "Admin Guide", "headingPath": "Gateway > Rate Limits", "heading": "Rate Limits"},
+			filename:    "/docs/admin.pdf",
+			description: "Configuring the API gateway\n of  Acme Cloud.",
+			want:        "Document: Admin Guide > Gateway > Rate Limits. Configuring the API gateway of Acme Cloud.",
+		},
+		{
+			name:     "falls back to the filename and the heading",
+			metadata: map[string]any{"title": "  ", "heading": "Rate Limits"},
+			filename: "/docs/admin.pdf",
+			want:     "Document: admin.pdf > Rate Limits.",
+		},
+		{
+			name:     "deck title",
+			metadata: map[string]any{"deckTitle": "Roadmap"},
+			want:     "Document: Roadmap.",
+		},
+		{
+			name:        "custom keys",
+			c:           ContextualHeader{TitleKeys: []string{"name"}, SectionKeys: []string{"chapter"}},
+			metadata:    map[string]any{"title": "ignored", "name": "Handbook", "chapter": "Onboarding"},
+			description: "How new employees get started.",
+			want:        "Document: Handbook > Onboarding. How new employees get started.",
+		},
+		{
+			name:        "only the description",
+			description: "A summary.",
+			want:        "A summary.",
+		},
+		{
+			name: "nothing known",
+			want: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.c.header(tc.metadata, tc.filename, tc.description))
+		})
+	}
+}
+
+func TestContextualHeaderEmbeddingTarget(t *testing.T) {
+	var calls [][]string
+	ctx := dstypes.EmbedDocumentsFuncToCtx(context.Background(), stubEmbedDocumentsFunc(&calls))
+	ctx = dstypes.FilenameToCtx(ctx, "/docs/guide.md")
+
+	docs := []vs.Document{
+		{ID: "1", Content: "The limit is 10 per minute.", Metadata: map[string]any{"heading": "Rate Limits", MetadataKeySummary: "The guide of Acme Cloud."}},
+		{ID: "2", Content: "Reused", Metadata: map[string]any{MetadataKeyContextHeader: "Document: guide.md."}, Embedding: []float32{42}},
+		{ID: "3", Content: "Intro"},
+	}
+	c := &ContextualHeader{}
+	require.True(t, c.NeedsEmbeddingFunc())
+	docs, err := c.Transform(ctx, docs)
+	require.NoError(t, err)
+
+	want := []string{
+		"Document: guide.md > Rate Limits. The guide of Acme Cloud.\n\nThe limit is 10 per minute.",
+		"Document: guide.md.\n\nIntro",
+	}
+	assert.Equal(t, [][]string{want}, calls, "all headers are embedded in one call, reused documents are skipped")
+	assert.Equal(t, "The limit is 10 per minute.", docs[0].Content, "the stored content stays unchanged")
+	assert.Equal(t, "Document: guide.md > Rate Limits. The guide of Acme Cloud.", docs[0].Metadata[MetadataKeyContextHeader])
+	assert.Equal(t, []float32{float32(len(want[0]))}, docs[0].Embedding)
+	assert.Equal(t, []float32{42}, docs[1].Embedding)
+	assert.Equal(t, []float32{float32(len(want[1]))}, docs[2].Embedding)
+}
+
+func TestContextualHeaderContentTarget(t *testing.T) {
+	ctx := dstypes.FilenameToCtx(context.Background(), "/docs/guide.md")
+
+	docs := []vs.Document{
+		{ID: "1", Content: "The limit is 10 per minute.", Metadata: map[string]any{"heading": "Rate Limits", MetadataKeySummary: "Ignored."}},
+		{ID: "2", Content: "Summarized and embedded", Embedding: []float32{42}},
+	}
+	c := &ContextualHeader{Target: ContextHeaderTargetContent, SummaryKey: "-"}
+	require.False(t, c.NeedsEmbeddingFunc())
+	docs, err := c.Transform(ctx, docs)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Document: guide.md > Rate Limits.\n\nThe limit is 10 per minute.", docs[0].Content)
+	assert.Equal(t, "Document: guide.md > Rate Limits.", docs[0].Metadata[MetadataKeyContextHeader])
+	assert.Empty(t, docs[0].Embedding)
+	assert.Equal(t, "Document: guide.md.\n\nSummarized and embedded", docs[1].Content)
+	assert.Equal(t, []float32{42}, docs[1].Embedding, "an existing embedding is kept")
+}
+
+func TestContextualHeaderGenerate(t *testing.T) {
+	var requests atomic.Int32
+	ctx := dstypes.FilenameToCtx(context.Background(), "guide.md")
+
+	docs := []vs.Document{{ID: "1", Content: "alpha beta"}, {ID: "2", Content: "gamma delta"}}
+	c := &ContextualHeader{Target: ContextHeaderTargetContent, Generate: true, Model: stubLLM(t, &requests)}
+	docs, err := c.Transform(ctx, docs)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 2, requests.Load())
+	assert.Equal(t, "Document: guide.md. summary of alpha\n\nalpha beta", docs[0].Content)
+	assert.Equal(t, "Document: guide.md. summary of gamma", docs[1].Metadata[MetadataKeyContextHeader])
+}
+
+func TestContextualHeaderRejectsEmbeddedDocuments(t *testing.T) {
+	var calls [][]string
+	ctx := dstypes.EmbedDocumentsFuncToCtx(context.Background(), stubEmbedDocumentsFunc(&calls))
+
+	docs := []vs.Document{{ID: "1", Content: "alpha", Embedding: []float32{42}}}
+	_, err := (&ContextualHeader{}).Transform(ctx, docs)
+	require.ErrorContains(t, err, `document "1" is embedded already`)
+	assert.Empty(t, calls)
+	assert.Equal(t, []float32{42}, docs[0].Embedding)
+}
@@ -0,0 +1,237 @@
+package transformers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const EntityExtractorName = "entities"
+
+// MetadataKeyEntities is the metadata key of the list of all entities of a document.
+// The entities of each type are additionally stored as list in MetadataKeyEntities + "_" + type, e.g. "entities_person".
+const MetadataKeyEntities = "entities"
+
+// DefaultEntityTypes are the entity types that are extracted if none are configured
+var DefaultEntityTypes = []string{"person", "organization", "product"}
+
+// DefaultEntityLabels map the labels of common NER models (CoNLL, OntoNotes) to the default entity types
+var DefaultEntityLabels = map[string]string{
+	"PER":     "person",
+	"PERSON":  "person",
+	"ORG":     "organization",
+	"PRODUCT": "product",
+}
+
+// EntityExtractor extracts named entities (e.g. people, organizations and products) of each document into the metadata,
+// either with an LLM or with a NER (named entity recognition) endpoint. The entities can be used in retrieval filters,
+// e.g. {"entities_organization": "Acme Corp"}, or exported for knowledge graph experiments.
+type EntityExtractor struct {
+	Types []string // Entity types to extract (default: person, organization, product)
+
+	// LLM based extraction (used if no URL is set)
+	Model llm.LLMConfig
+
+	// NER endpoint based extraction, e.g. a HuggingFace token classification model served by the Inference API or an Inference Endpoint:
+	// {"inputs": "<text>"} -> [{"entity_group": "PER", "word": "Ada Lovelace", "score": 0.99}]
+	URL      string
+	ApiKey   string            `json:"apiKey" yaml:"apiKey"` // Sent as Bearer token, if set
+	Labels   map[string]string // Maps the labels returned by the NER model to entity types (default: DefaultEntityLabels) - entities with other labels are dropped
+	MinScore float64           // Minimum score of entities returned by the NER endpoint (default: 0)
+	Timeout  string            // Request timeout, e.g. 30s (default: 60s)
+
+	Concurrency int // Maximum number of parallel requests (default: 5)
+}
+
+var entitiesPromptTpl = `Extract the named entities of the following types from the text: {{.types}}.
+Only extract entities that are explicitly named in the text, using the name as written. If there are none of a type, return an empty list.
+Reply only in the following JSON format, without any styling or markdown syntax:
+{"<type>": ["<name>", ...]}
+>>>
+{{.content}}
+>>>`
+
+type nerEntity struct {
+	EntityGroup string  `json:"entity_group"`
+	Entity      string  `json:"entity"` // set instead of entity_group without aggregation
+	Word        string  `json:"word"`
+	Score       float64 `json:"score"`
+}
+
+func (e *EntityExtractor) Name() string {
+	return EntityExtractorName
+}
+
+func (e *EntityExtractor) Transform(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
+	types := e.Types
+	if len(types) == 0 {
+		types = DefaultEntityTypes
+	}
+
+	var extract func(ctx context.Context, text string) (map[string][]string, error)
+	if e.URL != "" {
+		timeout := 60 * time.Second
+		if e.Timeout != "" {
+			var err error
+			timeout, err = time.ParseDuration(e.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout %q: %w", e.Timeout, err)
+			}
+		}
+		client := &http.Client{Timeout: timeout}
+		extract = func(ctx context.Context, text string) (map[string][]string, error) {
+			return e.extractNER(ctx, client, text, types)
+		}
+	} else {
+		m, err := llm.NewFromConfig(e.Model)
+		if err != nil {
+			return nil, err
+		}
+		extract = func(ctx context.Context, text string) (map[string][]string, error) {
+			return extractLLM(ctx, m, text, types)
+		}
+	}
+
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, doc := range docs {
+		if _, ok := doc.Metadata[MetadataKeyEntities]; ok || strings.TrimSpace(doc.Content) == "" {
+			continue // e.g. documents of a reused file
+		}
+		g.Go(func() error {
+			entities, err := extract(gctx, strings.TrimSpace(doc.Content))
+			if err != nil {
+				return fmt.Errorf("failed to extract entities of document %q: %w", doc.ID, err)
+			}
+
+			if docs[i].Metadata == nil {
+				docs[i].Metadata = make(map[string]any)
+			}
+			all := []string{}
+			for _, t := range types {
+				names := uniqueNames(entities[t])
+				docs[i].Metadata[MetadataKeyEntities+"_"+t] = names
+				all = append(all, names...)
+			}
+			docs[i].Metadata[MetadataKeyEntities] = uniqueNames(all)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	slog.Debug("Extracted entities", "num_documents", len(docs))
+	return docs, nil
+}
+
+// extractLLM prompts the LLM for the entities of the given types
+func extractLLM(ctx context.Context, m *llm.LLM, text string, types []string) (map[string][]string, error) {
+	result, err := m.Prompt(ctx, entitiesPromptTpl, map[string]any{"types": strings.Join(types, ", "), "content": text})
+	if err != nil {
+		return nil, err
+	}
+
+	result = strings.TrimSpace(result)
+	if strings.HasPrefix(result, "```") {
+		result = strings.TrimPrefix(result, "```")
+		if i := strings.Index(result, "\n"); i >= 0 {
+			result = result[i+1:] // drop language tag
+		}
+		result = strings.TrimSuffix(strings.TrimSpace(result), "```")
+	}
+
+	var entities map[string][]string
+	if err := json.Unmarshal([]byte(result), &entities); err != nil {
+		slog.Debug("llm response", "response", result)
+		return nil, fmt.Errorf("failed to unmarshal llm response: %w", err)
+	}
+	return entities, nil
+}
+
+// extractNER calls the NER endpoint and maps the labels of the returned entities to the given types
+func (e *EntityExtractor) extractNER(ctx context.Context, client *http.Client, text string, types []string) (map[string][]string, error) {
+	body, err := json.Marshal(map[string]any{"inputs": text})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.ApiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.ApiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("NER API error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var results []nerEntity
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode NER response: %w", err)
+	}
+
+	labels := e.Labels
+	if len(labels) == 0 {
+		labels = DefaultEntityLabels
+	}
+
+	entities := make(map[string][]string)
+	for _, r := range results {
+		label := r.EntityGroup
+		if label == "" {
+			// without aggregation, labels are prefixed with the IOB tag, e.g. B-PER - such entities are single tokens
+			label = strings.TrimPrefix(strings.TrimPrefix(r.Entity, "B-"), "I-")
+		}
+		t, ok := labels[label]
+		if !ok || !slices.Contains(types, t) || r.Score < e.MinScore {
+			continue
+		}
+		entities[t] = append(entities[t], r.Word)
+	}
+	return entities, nil
+}
+
+// uniqueNames returns the trimmed, non-empty names without (case-insensitive) duplicates, in order of their first occurrence
+func uniqueNames(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	unique := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		k := strings.ToLower(n)
+		if _, ok := seen[k]; ok || n == "" {
+			continue
+		}
+		seen[k] = struct{}{}
+		unique = append(unique, n)
+	}
+	return unique
+}
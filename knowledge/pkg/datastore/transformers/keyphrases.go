@@ -0,0 +1,63 @@
+package transformers
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/keyphrases"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const KeyphraseExtractorName = "keyphrases"
+
+// MetadataKeyKeyphrases is the metadata key of the extracted key phrases, a list of lowercase phrases, best first
+const MetadataKeyKeyphrases = "keyphrases"
+
+// KeyphraseExtractor extracts the key phrases of each document with RAKE and writes them to the metadata.
+// Unlike the keywords transformer, it doesn't need an LLM, so it's cheap enough to run on every chunk.
+// The key phrases can be used in retrieval filters (e.g. {"keyphrases": {"$contains": "kubernetes cluster"}})
+// and are scored by the BM25 and hybrid retrievers if listed in their metadataFields.
+type KeyphraseExtractor struct {
+	NumKeyphrases int    // Maximum number of key phrases per document (default: 10)
+	MaxWords      int    // Maximum number of words of a key phrase (default: 3)
+	Language      string // Language of the stop words, an ISO 639-1 code - defaults to the "language" metadata (see the language transformer) or the detected language of the file
+}
+
+func (k *KeyphraseExtractor) Transform(_ context.Context, docs []vs.Document) ([]vs.Document, error) {
+	numKeyphrases := k.NumKeyphrases
+	if numKeyphrases <= 0 {
+		numKeyphrases = 10
+	}
+
+	var fileLanguage string
+	for i, doc := range docs {
+		lang := k.Language
+		if lang == "" {
+			if l, ok := doc.Metadata[MetadataKeyLanguage].(string); ok {
+				lang = l
+			} else {
+				if fileLanguage == "" {
+					fileLanguage = DetectLanguage(docs)
+				}
+				lang = fileLanguage
+			}
+		}
+
+		extracted := keyphrases.Extract(doc.Content, lang, numKeyphrases, k.MaxWords)
+		phrases := make([]string, 0, len(extracted))
+		for _, e := range extracted {
+			phrases = append(phrases, e.Phrase)
+		}
+
+		if doc.Metadata == nil {
+			docs[i].Metadata = make(map[string]any)
+		}
+		docs[i].Metadata[MetadataKeyKeyphrases] = phrases
+	}
+	slog.Debug("Extracted keyphrases", "num_documents", len(docs))
+	return docs, nil
+}
+
+func (k *KeyphraseExtractor) Name() string {
+	return KeyphraseExtractorName
+}
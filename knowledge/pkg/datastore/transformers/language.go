@@ -0,0 +1,48 @@
+package transformers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/lib/language"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const LanguageDetectorName = "language"
+
+// MetadataKeyLanguage is the metadata key of the detected language, an ISO 639-1 code like "en" or "ja"
+const MetadataKeyLanguage = "language"
+
+// LanguageDetector detects the language of the documents and writes it to their metadata.
+// By default, all documents (chunks of a file) get the language of the whole file, as short chunks may be misdetected.
+type LanguageDetector struct {
+	PerDocument bool // detect the language of each document separately, e.g. for files with sections in different languages
+}
+
+func (l *LanguageDetector) Transform(_ context.Context, docs []vs.Document) ([]vs.Document, error) {
+	lang := DetectLanguage(docs)
+	for i, doc := range docs {
+		if l.PerDocument {
+			lang = language.Detect(doc.Content)
+		}
+		if doc.Metadata == nil {
+			docs[i].Metadata = make(map[string]any)
+		}
+		docs[i].Metadata[MetadataKeyLanguage] = lang
+	}
+	return docs, nil
+}
+
+func (l *LanguageDetector) Name() string {
+	return LanguageDetectorName
+}
+
+// DetectLanguage returns the language of the documents' combined content
+func DetectLanguage(docs []vs.Document) string {
+	var sb strings.Builder
+	for _, doc := range docs {
+		sb.WriteString(doc.Content)
+		sb.WriteString("\n")
+	}
+	return language.Detect(sb.String())
+}
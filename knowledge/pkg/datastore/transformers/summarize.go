@@ -0,0 +1,192 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const SummarizerName = "summarize"
+
+// MetadataKeySummary is the metadata key of the generated summary
+const MetadataKeySummary = "summary"
+
+const (
+	SummaryScopeChunk = "chunk" // summarize each document (chunk)
+	SummaryScopeFile  = "file"  // summarize the whole file once and add the summary to all of its documents
+
+	SummaryEmbedContent = "content" // embed the content, as without summaries
+	SummaryEmbedSummary = "summary" // embed the summary instead of the content
+	SummaryEmbedBoth    = "both"    // embed the summary followed by the content
+)
+
+// Summarizer generates a short summary of each document (or of the whole file) with an LLM and stores it in the metadata.
+// Optionally, the summary is embedded instead of or along with the content, which helps to find long technical chunks
+// whose wording is far from the questions asked about them. The stored content is never changed.
+type Summarizer struct {
+	Model       llm.LLMConfig
+	Scope       string // SummaryScopeChunk (default) or SummaryScopeFile
+	Embed       string // SummaryEmbedContent (default), SummaryEmbedSummary or SummaryEmbedBoth - the summary is only embedded per chunk
+	MaxWords    int    // Approximate maximum length of the summary (default: 50)
+	MinLength   int    // Documents with less characters are not summarized, as they're short enough already (default: 0)
+	MaxChars    int    // Maximum number of characters of the file sent to the LLM with the file scope (default: 16000)
+	Concurrency int    // Maximum number of parallel LLM requests (default: 5)
+}
+
+var summarizePromptTpl = `Summarize the following {{.scope}} in at most {{.maxWords}} words. Mention the main topics, entities and terms,
+so that the summary can be used to find the {{.scope}}. Reply only with the summary, without any introduction or formatting.
+>>>
+{{.content}}
+>>>`
+
+func (s *Summarizer) Name() string {
+	return SummarizerName
+}
+
+func (s *Summarizer) NeedsEmbeddingFunc() bool {
+	return s.Embed == SummaryEmbedSummary || s.Embed == SummaryEmbedBoth
+}
+
+func (s *Summarizer) Transform(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
+	switch s.Scope {
+	case "", SummaryScopeChunk, SummaryScopeFile:
+	default:
+		return nil, fmt.Errorf("invalid summary scope %q, must be %q or %q", s.Scope, SummaryScopeChunk, SummaryScopeFile)
+	}
+	switch s.Embed {
+	case "", SummaryEmbedContent, SummaryEmbedSummary, SummaryEmbedBoth:
+	default:
+		return nil, fmt.Errorf("invalid summary embedding %q, must be %q, %q or %q", s.Embed, SummaryEmbedContent, SummaryEmbedSummary, SummaryEmbedBoth)
+	}
+	if s.Scope == SummaryScopeFile && s.NeedsEmbeddingFunc() {
+		return nil, fmt.Errorf("the summary can only be embedded with the %q scope", SummaryScopeChunk)
+	}
+
+	m, err := llm.NewFromConfig(s.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	maxWords := s.MaxWords
+	if maxWords <= 0 {
+		maxWords = 50
+	}
+
+	if s.Scope == SummaryScopeFile {
+		return s.summarizeFile(ctx, m, docs, maxWords)
+	}
+
+	var embedDocumentsFunc dstypes.EmbedDocumentsFunc
+	if s.NeedsEmbeddingFunc() {
+		embedDocumentsFunc = dstypes.EmbedDocumentsFuncFromCtx(ctx)
+		if embedDocumentsFunc == nil {
+			return nil, fmt.Errorf("no embedding function available to embed the summaries")
+		}
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	// The summaries are generated in parallel and embedded in batches afterwards
+	summaries := make([]string, len(docs))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, doc := range docs {
+		if len(doc.Content) < s.MinLength {
+			continue
+		}
+		if _, ok := doc.Metadata[MetadataKeySummary]; ok && (embedDocumentsFunc == nil || len(doc.Embedding) > 0) {
+			continue // e.g. documents of a reused file
+		}
+		g.Go(func() error {
+			summary, err := m.Prompt(gctx, summarizePromptTpl, map[string]any{"scope": "text", "maxWords": maxWords, "content": strings.TrimSpace(doc.Content)})
+			if err != nil {
+				return fmt.Errorf("failed to summarize document %q: %w", doc.ID, err)
+			}
+			summaries[i] = strings.TrimSpace(summary)
+
+			if docs[i].Metadata == nil {
+				docs[i].Metadata = make(map[string]any)
+			}
+			docs[i].Metadata[MetadataKeySummary] = summaries[i]
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	if embedDocumentsFunc != nil {
+		var idx []int
+		var toEmbed []vs.Document
+		for i, summary := range summaries {
+			if summary == "" {
+				continue
+			}
+			text := summary
+			if s.Embed == SummaryEmbedBoth {
+				text = summary + "\n\n" + docs[i].Content
+			}
+			idx = append(idx, i)
+			toEmbed = append(toEmbed, vs.Document{ID: docs[i].ID, Content: text})
+		}
+		if len(toEmbed) == 0 {
+			return docs, nil
+		}
+		if err := embedDocumentsFunc(ctx, toEmbed); err != nil {
+			return nil, fmt.Errorf("failed to embed summaries: %w", err)
+		}
+		for j, i := range idx {
+			docs[i].Embedding = toEmbed[j].Embedding
+		}
+	}
+
+	slog.Debug("Summarized documents", "num_documents", len(docs))
+	return docs, nil
+}
+
+// summarizeFile summarizes the content of all documents at once and adds the summary to each of them
+func (s *Summarizer) summarizeFile(ctx context.Context, m *llm.LLM, docs []vs.Document, maxWords int) ([]vs.Document, error) {
+	maxChars := s.MaxChars
+	if maxChars <= 0 {
+		maxChars = 16000
+	}
+
+	var sb strings.Builder
+	for _, doc := range docs {
+		if _, ok := doc.Metadata[MetadataKeySummary]; ok {
+			return docs, nil // e.g. documents of a reused file
+		}
+		sb.WriteString(doc.Content)
+		sb.WriteString("\n\n")
+	}
+	content := strings.TrimSpace(sb.String())
+	if len(content) < s.MinLength {
+		return docs, nil
+	}
+	if r := []rune(content); len(r) > maxChars {
+		content = string(r[:maxChars])
+	}
+
+	summary, err := m.Prompt(ctx, summarizePromptTpl, map[string]any{"scope": "document", "maxWords": maxWords, "content": content})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize file: %w", err)
+	}
+	summary = strings.TrimSpace(summary)
+
+	for i := range docs {
+		if docs[i].Metadata == nil {
+			docs[i].Metadata = make(map[string]any)
+		}
+		docs[i].Metadata[MetadataKeySummary] = summary
+	}
+	return docs, nil
+}
@@ -0,0 +1,159 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/openai"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLLM returns the config of an OpenAI compatible chat API replying "summary of <first word of the content or chunk>"
+// and counts the requests
+func stubLLM(t *testing.T, requests *atomic.Int32) llm.LLMConfig {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NotEmpty(t, req.Messages)
+		prompt := req.Messages[len(req.Messages)-1].Content
+		content := prompt
+		if _, chunk, ok := strings.Cut(prompt, "<chunk>"); ok {
+			content, _, _ = strings.Cut(chunk, "</chunk>")
+		} else if parts := strings.Split(prompt, ">>>"); len(parts) == 3 {
+			content = parts[1]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"index":         0,
+				"finish_reason": "stop",
+				"message":       map[string]any{"role": "assistant", "content": " summary of " + strings.Fields(content)[0] + "\n"},
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return llm.LLMConfig{OpenAI: openai.OpenAIConfig{APIKey: "test", BaseURL: srv.URL, Model: "test"}}
+}
+
+// stubEmbedDocumentsFunc embeds each document as its length and records the embedded texts per call
+func stubEmbedDocumentsFunc(calls *[][]string) dstypes.EmbedDocumentsFunc {
+	return func(_ context.Context, docs []vs.Document) error {
+		var texts []string
+		for i, doc := range docs {
+			if len(doc.Embedding) > 0 {
+				continue
+			}
+			texts = append(texts, doc.Content)
+			docs[i].Embedding = []float32{float32(len(doc.Content))}
+		}
+		*calls = append(*calls, texts)
+		return nil
+	}
+}
+
+func TestSummarizerNeedsEmbeddingFunc(t *testing.T) {
+	for embed, want := range map[string]bool{
+		"":                  false,
+		SummaryEmbedContent: false,
+		SummaryEmbedSummary: true,
+		SummaryEmbedBoth:    true,
+	} {
+		assert.Equal(t, want, (&Summarizer{Embed: embed}).NeedsEmbeddingFunc(), "embed %q", embed)
+	}
+}
+
+func TestSummarizerEmbed(t *testing.T) {
+	for _, tc := range []struct {
+		embed     string
+		wantTexts []string
+	}{
+		{embed: SummaryEmbedContent},
+		{embed: SummaryEmbedSummary, wantTexts: []string{"summary of alpha", "summary of gamma"}},
+		{embed: SummaryEmbedBoth, wantTexts: []string{"summary of alpha\n\nalpha beta", "summary of gamma\n\ngamma delta"}},
+	} {
+		t.Run(tc.embed, func(t *testing.T) {
+			var requests atomic.Int32
+			var calls [][]string
+			ctx := dstypes.EmbedDocumentsFuncToCtx(context.Background(), stubEmbedDocumentsFunc(&calls))
+
+			docs := []vs.Document{
+				{ID: "1", Content: "alpha beta"},
+				{ID: "2", Content: "tiny"},
+				{ID: "3", Content: "gamma delta"},
+			}
+			s := &Summarizer{Model: stubLLM(t, &requests), Embed: tc.embed, MinLength: 5}
+			docs, err := s.Transform(ctx, docs)
+			require.NoError(t, err)
+
+			assert.EqualValues(t, 2, requests.Load(), "short documents are not summarized")
+			assert.Equal(t, "summary of alpha", docs[0].Metadata[MetadataKeySummary])
+			assert.NotContains(t, docs[1].Metadata, MetadataKeySummary)
+			assert.Equal(t, "summary of gamma", docs[2].Metadata[MetadataKeySummary])
+			assert.Equal(t, "alpha beta", docs[0].Content, "the content is never changed")
+
+			if tc.wantTexts == nil {
+				assert.Empty(t, calls)
+				for _, doc := range docs {
+					assert.Empty(t, doc.Embedding)
+				}
+				return
+			}
+			assert.Equal(t, [][]string{tc.wantTexts}, calls, "all summaries are embedded in one call")
+			assert.Equal(t, []float32{float32(len(tc.wantTexts[0]))}, docs[0].Embedding)
+			assert.Empty(t, docs[1].Embedding, "left to be embedded with the content")
+			assert.Equal(t, []float32{float32(len(tc.wantTexts[1]))}, docs[2].Embedding)
+		})
+	}
+}
+
+func TestSummarizerSkipsReusedDocuments(t *testing.T) {
+	var requests atomic.Int32
+	var calls [][]string
+	ctx := dstypes.EmbedDocumentsFuncToCtx(context.Background(), stubEmbedDocumentsFunc(&calls))
+
+	docs := []vs.Document{
+		// reused with its summary and embedding
+		{ID: "1", Content: "alpha beta", Metadata: map[string]any{MetadataKeySummary: "old summary"}, Embedding: []float32{42}},
+		// reused with its summary, but the embedding couldn't be reused
+		{ID: "2", Content: "gamma delta", Metadata: map[string]any{MetadataKeySummary: "old summary"}},
+	}
+	s := &Summarizer{Model: stubLLM(t, &requests), Embed: SummaryEmbedBoth}
+	docs, err := s.Transform(ctx, docs)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 1, requests.Load())
+	assert.Equal(t, "old summary", docs[0].Metadata[MetadataKeySummary])
+	assert.Equal(t, []float32{42}, docs[0].Embedding)
+	assert.Equal(t, "summary of gamma", docs[1].Metadata[MetadataKeySummary])
+	assert.Equal(t, [][]string{{"summary of gamma\n\ngamma delta"}}, calls)
+
+	// without embedding the summary, reused summaries are kept as they are
+	requests.Store(0)
+	docs[1].Embedding = nil
+	_, err = (&Summarizer{Model: stubLLM(t, &requests)}).Transform(context.Background(), docs)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, requests.Load())
+}
+
+func TestSummarizerWithoutEmbeddingFunc(t *testing.T) {
+	var requests atomic.Int32
+	s := &Summarizer{Model: stubLLM(t, &requests), Embed: SummaryEmbedSummary}
+	_, err := s.Transform(context.Background(), []vs.Document{{ID: "1", Content: "alpha beta"}})
+	require.ErrorContains(t, err, "no embedding function available")
+	assert.EqualValues(t, 0, requests.Load())
+}
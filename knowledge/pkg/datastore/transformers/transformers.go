@@ -2,21 +2,29 @@ package transformers
 
 import (
 	"fmt"
+	"reflect"
 
 	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 )
 
 var TransformerMap = map[string]dstypes.DocumentTransformer{
+	ContextualHeaderName:            &ContextualHeader{},
+	EntityExtractorName:             &EntityExtractor{},
 	ExtraMetadataName:               &ExtraMetadata{},
 	FilterMarkdownDocsNoContentName: &FilterMarkdownDocsNoContent{},
+	KeyphraseExtractorName:          &KeyphraseExtractor{},
 	KeywordExtractorName:            &KeywordExtractor{},
+	LanguageDetectorName:            &LanguageDetector{},
 	MetadataManipulatorName:         &MetadataManipulator{},
+	SummarizerName:                  &Summarizer{},
 }
 
+// GetTransformer returns a new instance of the named transformer, so that it can be configured independently
+// of other instances, e.g. when it's used with different options in multiple ingestion flows
 func GetTransformer(name string) (dstypes.DocumentTransformer, error) {
 	transformer, ok := TransformerMap[name]
 	if !ok {
 		return nil, fmt.Errorf("unknown transformer %q", name)
 	}
-	return transformer, nil
+	return reflect.New(reflect.TypeOf(transformer).Elem()).Interface().(dstypes.DocumentTransformer), nil
 }
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"context"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+type embeddingFuncCtxKey struct{}
+
+// EmbeddingFuncToCtx adds the embedding function of the datastore to the context, e.g. for the semantic text splitter
+func EmbeddingFuncToCtx(ctx context.Context, embeddingFunc vs.EmbeddingFunc) context.Context {
+	return context.WithValue(ctx, embeddingFuncCtxKey{}, embeddingFunc)
+}
+
+// EmbeddingFuncFromCtx returns the embedding function from the context, if set
+func EmbeddingFuncFromCtx(ctx context.Context) vs.EmbeddingFunc {
+	if embeddingFunc, ok := ctx.Value(embeddingFuncCtxKey{}).(vs.EmbeddingFunc); ok {
+		return embeddingFunc
+	}
+	return nil
+}
+
+// EmbedDocumentsFunc embeds the documents which don't have an embedding yet, in place
+type EmbedDocumentsFunc func(ctx context.Context, docs []vs.Document) error
+
+type embedDocumentsFuncCtxKey struct{}
+
+// EmbedDocumentsFuncToCtx adds the function the datastore embeds documents with to the context, so that transformers
+// can embed documents in batches and sharing the rate limit of the embedding model provider
+func EmbedDocumentsFuncToCtx(ctx context.Context, embedDocumentsFunc EmbedDocumentsFunc) context.Context {
+	return context.WithValue(ctx, embedDocumentsFuncCtxKey{}, embedDocumentsFunc)
+}
+
+// EmbedDocumentsFuncFromCtx returns the function to embed documents with from the context, if set
+func EmbedDocumentsFuncFromCtx(ctx context.Context) EmbedDocumentsFunc {
+	if embedDocumentsFunc, ok := ctx.Value(embedDocumentsFuncCtxKey{}).(EmbedDocumentsFunc); ok {
+		return embedDocumentsFunc
+	}
+	return nil
+}
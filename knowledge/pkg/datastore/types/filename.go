@@ -0,0 +1,20 @@
+package types
+
+import (
+	"context"
+)
+
+type filenameCtxKey struct{}
+
+// FilenameToCtx adds the name of the file being loaded to the context, e.g. for document loaders handing the file to external tools
+func FilenameToCtx(ctx context.Context, filename string) context.Context {
+	return context.WithValue(ctx, filenameCtxKey{}, filename)
+}
+
+// FilenameFromCtx returns the name of the file being loaded from the context, if set
+func FilenameFromCtx(ctx context.Context) string {
+	if filename, ok := ctx.Value(filenameCtxKey{}).(string); ok {
+		return filename
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type filePasswordCtxKey struct{}
+
+// PasswordToCtx adds the password for an encrypted file to the context, so that document loaders supporting decryption can pick it up
+func PasswordToCtx(ctx context.Context, password string) context.Context {
+	return context.WithValue(ctx, filePasswordCtxKey{}, password)
+}
+
+// PasswordFromCtx returns the password for an encrypted file from the context, if set
+func PasswordFromCtx(ctx context.Context) string {
+	if password, ok := ctx.Value(filePasswordCtxKey{}).(string); ok {
+		return password
+	}
+	return ""
+}
+
+// DecryptionNotSupportedError is returned when a password was provided for an encrypted file,
+// but neither the file type nor the document loader supports decrypting it
+type DecryptionNotSupportedError struct {
+	FileType string
+}
+
+func (e *DecryptionNotSupportedError) Error() string {
+	return fmt.Sprintf("decryption not supported for %s", e.FileType)
+}
+
+func (e *DecryptionNotSupportedError) Is(err error) bool {
+	var decryptionNotSupportedError *DecryptionNotSupportedError
+	ok := errors.As(err, &decryptionNotSupportedError)
+	return ok
+}
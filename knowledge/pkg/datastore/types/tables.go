@@ -0,0 +1,19 @@
+package types
+
+import (
+	"context"
+)
+
+type detectTablesCtxKey struct{}
+
+// DetectTablesToCtx asks document loaders supporting table detection (e.g. for PDFs) to render detected tables as markdown tables,
+// so that they can be extracted into dedicated table documents
+func DetectTablesToCtx(ctx context.Context) context.Context {
+	return context.WithValue(ctx, detectTablesCtxKey{}, true)
+}
+
+// DetectTablesFromCtx returns whether table detection was requested via the context
+func DetectTablesFromCtx(ctx context.Context) bool {
+	detect, _ := ctx.Value(detectTablesCtxKey{}).(bool)
+	return detect
+}
@@ -17,6 +17,13 @@ type DocumentTransformer interface {
 	Name() string
 }
 
+// EmbeddingDocumentTransformer is a DocumentTransformer that may embed documents itself, with the embedding function from the context
+// (see EmbeddingFuncFromCtx) or in batches (see EmbedDocumentsFuncFromCtx). Documents it embedded are not embedded again.
+type EmbeddingDocumentTransformer interface {
+	DocumentTransformer
+	NeedsEmbeddingFunc() bool
+}
+
 type DocumentLoader interface {
 	Load(ctx context.Context) ([]vs.Document, error)
 }
@@ -26,10 +33,25 @@ type TextSplitter interface {
 	Name() string
 }
 
+// ContextTextSplitter is a TextSplitter that needs the context for splitting, e.g. to call the embedding model
+type ContextTextSplitter interface {
+	TextSplitter
+	SplitDocumentsWithContext(ctx context.Context, docs []vs.Document) ([]vs.Document, error)
+}
+
 type Response struct {
 	Query           string        `json:"subquery"`
 	NumDocs         int           `json:"numResultDocuments"`
 	ResultDocuments []vs.Document `json:"resultDocuments"`
+	Relaxation      *Relaxation   `json:"relaxation,omitempty"` // set if the retrieval was relaxed due to sparse results
+}
+
+// Relaxation describes how a retrieval was relaxed to avoid sparse results
+type Relaxation struct {
+	Strategy  string   `json:"strategy"`
+	Attempts  int      `json:"attempts"`
+	TopK      int      `json:"topK,omitempty"`      // candidate pool size used for the final result
+	Threshold *float32 `json:"threshold,omitempty"` // similarity threshold used for the final result
 }
 
 type Stats struct {
@@ -41,4 +63,18 @@ type RetrievalResponse struct {
 	Datasets  []string   `json:"queriedDatasets"`
 	Responses []Response `json:"subqueryResults"`
 	Stats     Stats      `json:"stats,omitempty"`
+	Citations []Citation `json:"citations,omitempty"` // set by the citations postprocessor
+}
+
+// Citation references the source of one or more result documents, e.g. to render footnotes
+type Citation struct {
+	Index        int      `json:"index"` // 1-based number of the citation, also set as "citation" in the metadata of the cited documents
+	Filename     string   `json:"filename,omitempty"`
+	Title        string   `json:"title,omitempty"`  // document title, e.g. the title of a slide deck
+	Source       string   `json:"source,omitempty"` // source URL or absolute path
+	Pages        []string `json:"pages,omitempty"`
+	Slides       []int    `json:"slides,omitempty"`
+	ChunkIndices []int    `json:"chunkIndices,omitempty"`
+	Score        float32  `json:"score"` // highest similarity score of the cited documents
+	DocumentIDs  []string `json:"documentIDs"`
 }
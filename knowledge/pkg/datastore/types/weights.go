@@ -0,0 +1,78 @@
+package types
+
+import (
+	"context"
+	"fmt"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// DatasetWeight prefers some datasets over others when retrieving from multiple datasets, e.g. runbooks over an archive
+type DatasetWeight struct {
+	TopK   int     `json:"topK,omitempty" yaml:"topK" mapstructure:"topK"`       // maximum number of documents retrieved from the dataset (default: the retriever's top k)
+	Weight float32 `json:"weight,omitempty" yaml:"weight" mapstructure:"weight"` // factor for the similarity scores of the dataset's documents in the merged ranking (default: 1)
+}
+
+// DatasetWeights by dataset ID - datasets without weight are retrieved as usual
+type DatasetWeights map[string]DatasetWeight
+
+func (w DatasetWeights) Validate() error {
+	for id, dw := range w {
+		if dw.TopK < 0 {
+			return fmt.Errorf("dataset %q: topK must not be negative", id)
+		}
+		if dw.Weight < 0 {
+			return fmt.Errorf("dataset %q: weight must not be negative", id)
+		}
+	}
+	return nil
+}
+
+// Merge returns the weights overridden by the other weights per dataset
+func (w DatasetWeights) Merge(other DatasetWeights) DatasetWeights {
+	if len(other) == 0 {
+		return w
+	}
+	merged := make(DatasetWeights, len(w)+len(other))
+	for id, dw := range w {
+		merged[id] = dw
+	}
+	for id, dw := range other {
+		merged[id] = dw
+	}
+	return merged
+}
+
+// TopK returns the number of documents to retrieve from the dataset, given the retriever's top k
+func (w DatasetWeights) TopK(datasetID string, topK int) int {
+	if dw, ok := w[datasetID]; ok && dw.TopK > 0 {
+		return dw.TopK
+	}
+	return topK
+}
+
+// Apply weighs the similarity scores of the dataset's documents, so that the documents of all datasets can be ranked together
+func (w DatasetWeights) Apply(datasetID string, docs []vs.Document) {
+	dw, ok := w[datasetID]
+	if !ok || dw.Weight == 0 || dw.Weight == 1 {
+		return
+	}
+	for i := range docs {
+		docs[i].SimilarityScore *= dw.Weight
+	}
+}
+
+type datasetWeightsCtxKey struct{}
+
+// DatasetWeightsToCtx adds the dataset weights to the context, so that they're picked up by the retrievers
+func DatasetWeightsToCtx(ctx context.Context, weights DatasetWeights) context.Context {
+	return context.WithValue(ctx, datasetWeightsCtxKey{}, weights)
+}
+
+// DatasetWeightsFromCtx returns the dataset weights from the context, if set
+func DatasetWeightsFromCtx(ctx context.Context) DatasetWeights {
+	if w, ok := ctx.Value(datasetWeightsCtxKey{}).(DatasetWeights); ok {
+		return w
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package types
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetWeightsMerge(t *testing.T) {
+	w := DatasetWeights{
+		"runbooks": {Weight: 1.5},
+		"archive":  {TopK: 2, Weight: 0.5},
+	}
+
+	assert.Equal(t, w, w.Merge(nil))
+
+	merged := w.Merge(DatasetWeights{
+		"archive": {Weight: 0.8},
+		"wiki":    {TopK: 3},
+	})
+	assert.Equal(t, DatasetWeights{
+		"runbooks": {Weight: 1.5},
+		"archive":  {Weight: 0.8}, // overridden as a whole
+		"wiki":     {TopK: 3},
+	}, merged)
+	assert.Equal(t, DatasetWeight{TopK: 2, Weight: 0.5}, w["archive"], "the original weights are not modified")
+
+	assert.Equal(t, DatasetWeights{"wiki": {TopK: 3}}, DatasetWeights(nil).Merge(DatasetWeights{"wiki": {TopK: 3}}))
+}
+
+func TestDatasetWeightsTopK(t *testing.T) {
+	w := DatasetWeights{
+		"archive":  {TopK: 2},
+		"runbooks": {Weight: 1.5},
+	}
+	assert.Equal(t, 2, w.TopK("archive", 10))
+	assert.Equal(t, 10, w.TopK("runbooks", 10), "no topK set for the dataset")
+	assert.Equal(t, 10, w.TopK("wiki", 10), "no weights for the dataset")
+	assert.Equal(t, 10, DatasetWeights(nil).TopK("archive", 10))
+}
+
+func TestDatasetWeightsApply(t *testing.T) {
+	w := DatasetWeights{
+		"runbooks": {Weight: 1.5},
+		"archive":  {TopK: 2},
+	}
+	docs := func() []vs.Document {
+		return []vs.Document{{ID: "a", SimilarityScore: 0.4}, {ID: "b", SimilarityScore: 0.2}}
+	}
+
+	weighted := docs()
+	w.Apply("runbooks", weighted)
+	assert.InDelta(t, 0.6, weighted[0].SimilarityScore, 1e-6)
+	assert.InDelta(t, 0.3, weighted[1].SimilarityScore, 1e-6)
+
+	for _, dataset := range []string{"archive", "wiki"} {
+		unweighted := docs()
+		w.Apply(dataset, unweighted)
+		assert.Equal(t, docs(), unweighted, "dataset %q has no weight", dataset)
+	}
+
+	unweighted := docs()
+	DatasetWeights(nil).Apply("runbooks", unweighted)
+	assert.Equal(t, docs(), unweighted)
+}
+
+func TestDatasetWeightsValidate(t *testing.T) {
+	assert.NoError(t, DatasetWeights{"a": {TopK: 1, Weight: 0.5}}.Validate())
+	assert.Error(t, DatasetWeights{"a": {TopK: -1}}.Validate())
+	assert.Error(t, DatasetWeights{"a": {Weight: -1}}.Validate())
+}
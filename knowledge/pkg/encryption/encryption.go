@@ -0,0 +1,80 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks encrypted strings, so that data written before encryption was enabled can still be read
+const Prefix = "enc:v1:"
+
+// Cipher encrypts data with AES-GCM. Every encryption uses a random nonce, which is prepended to the ciphertext.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher returns a cipher for the key, which must be 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256)
+func NewCipher(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (c *Cipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (wrong key?): %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts the string to the base64 encoded ciphertext with the Prefix
+func (c *Cipher) EncryptString(s string) (string, error) {
+	b, err := c.Encrypt([]byte(s))
+	if err != nil {
+		return "", err
+	}
+	return Prefix + base64.StdEncoding.EncodeToString(b), nil
+}
+
+// DecryptString decrypts a string encrypted by EncryptString - strings without the Prefix are returned as they are
+func (c *Cipher) DecryptString(s string) (string, error) {
+	if !IsEncrypted(s) {
+		return s, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, Prefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	plaintext, err := c.Decrypt(b)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
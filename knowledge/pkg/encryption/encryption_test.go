@@ -0,0 +1,68 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher([]byte(strings.Repeat("k", 32)))
+	require.NoError(t, err)
+
+	enc, err := c.EncryptString("top secret")
+	require.NoError(t, err)
+	assert.True(t, IsEncrypted(enc))
+	assert.NotContains(t, enc, "secret")
+
+	again, err := c.EncryptString("top secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, enc, again) // random nonce
+
+	dec, err := c.DecryptString(enc)
+	require.NoError(t, err)
+	assert.Equal(t, "top secret", dec)
+
+	// written before encryption was enabled
+	dec, err = c.DecryptString("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", dec)
+
+	other, err := NewCipher([]byte(strings.Repeat("o", 32)))
+	require.NoError(t, err)
+	_, err = other.DecryptString(enc)
+	assert.Error(t, err)
+
+	_, err = NewCipher([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	ctx := context.Background()
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 16)))
+
+	b, err := LoadKey(ctx, key, "")
+	require.NoError(t, err)
+	assert.Len(t, b, 16)
+
+	b, err = LoadKey(ctx, "", "")
+	require.NoError(t, err)
+	assert.Nil(t, b)
+
+	b, err = LoadKey(ctx, "", "echo "+key)
+	require.NoError(t, err)
+	assert.Len(t, b, 16)
+
+	_, err = LoadKey(ctx, key, "echo "+key)
+	assert.Error(t, err)
+
+	_, err = LoadKey(ctx, base64.StdEncoding.EncodeToString([]byte("short")), "")
+	assert.Error(t, err)
+
+	_, err = LoadKey(ctx, "not base64!", "")
+	assert.Error(t, err)
+}
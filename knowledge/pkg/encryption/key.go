@@ -0,0 +1,59 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LoadKey returns the base64 encoded key or, if a key command is given instead, the base64 encoded key printed by the command.
+// The key command is a KMS plugin, e.g. a script decrypting a data key with a cloud KMS, so that the key doesn't have to be
+// stored in the environment. Its arguments are separated by whitespace, it's not run in a shell.
+// It returns nil, if neither the key nor a key command is given.
+func LoadKey(ctx context.Context, key, keyCommand string) ([]byte, error) {
+	if key != "" && keyCommand != "" {
+		return nil, fmt.Errorf("only one of encryption key and encryption key command can be set")
+	}
+
+	if keyCommand != "" {
+		args := strings.Fields(keyCommand)
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("encryption key command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		key = string(out)
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		if keyCommand != "" {
+			return nil, fmt.Errorf("encryption key command didn't print a key")
+		}
+		return nil, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be base64 encoded: %w", err)
+	}
+	switch len(b) {
+	case 16, 24, 32:
+		return b, nil
+	}
+	return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes long, got %d", len(b))
+}
+
+// NewCipherFromConfig returns the cipher for the configured key (see LoadKey), or nil if encryption is not configured
+func NewCipherFromConfig(ctx context.Context, key, keyCommand string) (*Cipher, error) {
+	b, err := LoadKey(ctx, key, keyCommand)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return NewCipher(b)
+}
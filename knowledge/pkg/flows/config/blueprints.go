@@ -1,8 +1,16 @@
 package config
 
 import (
+	"bufio"
+	"bytes"
 	_ "embed"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 )
 
 //go:embed blueprints/default.yaml
@@ -20,10 +28,128 @@ var Blueprints = map[string][]byte{
 	"obot":    BlueprintObot,
 }
 
+// BlueprintDirsEnv is the environment variable listing directories with custom blueprints, separated like PATH entries.
+// A blueprint file is referenced by the name of its directory and its path within it without extension,
+// e.g. blueprint:myteam/default for /etc/knowledge/myteam/default.yaml with KNOW_BLUEPRINT_DIRS=/etc/knowledge/myteam
+const BlueprintDirsEnv = "KNOW_BLUEPRINT_DIRS"
+
+// BlueprintSourceEmbedded is the source of the blueprints built into the binary
+const BlueprintSourceEmbedded = "embedded"
+
+var blueprintExtensions = []string{".yaml", ".yml", ".json"}
+
+type BlueprintInfo struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`                // BlueprintSourceEmbedded or the path of the file
+	Description string `json:"description,omitempty"` // first line of the leading comment
+}
+
+// GetBlueprint returns the content of an embedded blueprint or of a custom blueprint from the directories in KNOW_BLUEPRINT_DIRS
 func GetBlueprint(name string) ([]byte, error) {
-	bp, ok := Blueprints[name]
-	if !ok {
-		return nil, fmt.Errorf("blueprint %q not found", name)
+	if bp, ok := Blueprints[name]; ok {
+		return bp, nil
+	}
+	if path := findBlueprintFile(name); path != "" {
+		return os.ReadFile(path)
+	}
+	return nil, fmt.Errorf("blueprint %q not found", name)
+}
+
+// ListBlueprints returns the embedded blueprints followed by the custom blueprints, sorted by name
+func ListBlueprints() ([]BlueprintInfo, error) {
+	var blueprints []BlueprintInfo
+	for name, bp := range Blueprints {
+		blueprints = append(blueprints, BlueprintInfo{Name: name, Source: BlueprintSourceEmbedded, Description: blueprintDescription(bp)})
+	}
+	slices.SortFunc(blueprints, func(a, b BlueprintInfo) int { return strings.Compare(a.Name, b.Name) })
+
+	var custom []BlueprintInfo
+	seen := map[string]struct{}{}
+	for _, dir := range blueprintDirs() {
+		namespace := filepath.Base(dir)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := filepath.Ext(path)
+			if d.IsDir() || !slices.Contains(blueprintExtensions, ext) {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			name := namespace + "/" + filepath.ToSlash(strings.TrimSuffix(rel, ext))
+			if _, ok := seen[name]; ok {
+				return nil // shadowed by a blueprint of a previous directory or with a preferred extension
+			}
+			if findBlueprintFile(name) != path {
+				return nil
+			}
+			seen[name] = struct{}{}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			custom = append(custom, BlueprintInfo{Name: name, Source: path, Description: blueprintDescription(content)})
+			return nil
+		})
+		if err != nil {
+			if os.IsNotExist(err) {
+				slog.Warn("Blueprint directory does not exist", "dir", dir)
+				continue
+			}
+			return nil, fmt.Errorf("failed to list blueprints in %q: %w", dir, err)
+		}
+	}
+	slices.SortFunc(custom, func(a, b BlueprintInfo) int { return strings.Compare(a.Name, b.Name) })
+
+	return append(blueprints, custom...), nil
+}
+
+func blueprintDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(BlueprintDirsEnv)) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	return dirs
+}
+
+// findBlueprintFile returns the path of the custom blueprint file, e.g. <dir>/default.yaml for myteam/default,
+// where <dir> is the first blueprint directory named myteam that contains it, or "" if there is none
+func findBlueprintFile(name string) string {
+	namespace, rel, ok := strings.Cut(name, "/")
+	if !ok || !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return ""
+	}
+	for _, dir := range blueprintDirs() {
+		if filepath.Base(dir) != namespace {
+			continue
+		}
+		for _, ext := range blueprintExtensions {
+			path := filepath.Join(dir, filepath.FromSlash(rel)+ext)
+			if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// blueprintDescription returns the first line of the leading YAML comment of the blueprint, if any
+func blueprintDescription(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			return ""
+		}
+		if line = strings.TrimSpace(strings.TrimLeft(line, "#")); line != "" {
+			return line
+		}
 	}
-	return bp, nil
+	return ""
 }
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomBlueprints(t *testing.T) {
+	dir := filepath.Join("testdata", "blueprints", "myteam")
+	t.Setenv(BlueprintDirsEnv, dir+string(filepath.ListSeparator)+filepath.Join("testdata", "nonexistent"))
+
+	cfg, err := Load("blueprint:myteam/default")
+	require.NoError(t, err)
+	assert.Equal(t, "hybrid", cfg.Flows["myteam"].Retrieval.Retriever.Name)
+
+	cfg, err = Load("blueprint:myteam/rag/fast")
+	require.NoError(t, err)
+	assert.Equal(t, "basic", cfg.Flows["fast"].Retrieval.Retriever.Name)
+
+	// embedded blueprints still take precedence for names without directory
+	_, err = Load("blueprint:default")
+	require.NoError(t, err)
+
+	for _, name := range []string{"myteam/nonexistent", "otherteam/default", "myteam/../myteam/default", "default.yaml"} {
+		_, err = GetBlueprint(name)
+		assert.Error(t, err, name)
+	}
+
+	blueprints, err := ListBlueprints()
+	require.NoError(t, err)
+	assert.Equal(t, []BlueprintInfo{
+		{Name: "context", Source: BlueprintSourceEmbedded},
+		{Name: "default", Source: BlueprintSourceEmbedded},
+		{Name: "obot", Source: BlueprintSourceEmbedded, Description: "Default Flows used for Obot."},
+		{Name: "myteam/default", Source: filepath.Join(dir, "default.yaml"), Description: "Team default: hybrid retrieval"},
+		{Name: "myteam/rag/fast", Source: filepath.Join(dir, "rag", "fast.yml")},
+	}, blueprints)
+}
@@ -10,12 +10,16 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/converter"
 	"github.com/obot-platform/tools/knowledge/pkg/output"
 
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/retrievers"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/tables"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/textsplitter"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/transformers"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
 	"github.com/obot-platform/tools/knowledge/pkg/flows"
 	"github.com/mitchellh/mapstructure"
 	"sigs.k8s.io/yaml"
@@ -43,7 +47,11 @@ type FlowConfigEntryGlobalOpts struct {
 }
 
 type FlowConfigGlobalsIngestion struct {
-	Textsplitter map[string]any `json:"textsplitter,omitempty" yaml:"textsplitter" mapstructure:"textsplitter"`
+	Textsplitter           map[string]any         `json:"textsplitter,omitempty" yaml:"textsplitter" mapstructure:"textsplitter"`
+	EmbeddingPreprocessing *preprocessing.Options `json:"embeddingPreprocessing,omitempty" yaml:"embeddingPreprocessing" mapstructure:"embeddingPreprocessing"`
+
+	// AdaptiveChunking picks the chunk size per document within min/max bounds, instead of using the fixed chunkSize.
+	AdaptiveChunking *textsplitter.AdaptiveChunkingOpts `json:"adaptiveChunking,omitempty" yaml:"adaptiveChunking" mapstructure:"adaptiveChunking"`
 }
 
 type IngestionFlowConfig struct {
@@ -52,6 +60,15 @@ type IngestionFlowConfig struct {
 	DocumentLoader DocumentLoaderConfig `json:"documentLoader,omitempty" yaml:"documentLoader" mapstructure:"documentLoader"`
 	TextSplitter   TextSplitterConfig   `json:"textSplitter,omitempty" yaml:"textSplitter" mapstructure:"textSplitter"`
 	Transformers   []TransformerConfig  `json:"transformers,omitempty" yaml:"transformers" mapstructure:"transformers"`
+	Tables         *tables.Options      `json:"tables,omitempty" yaml:"tables" mapstructure:"tables"`
+
+	// EmbeddingPreprocessing normalizes the text sent to the embedding model (not the stored content). Overrides the global setting.
+	EmbeddingPreprocessing *preprocessing.Options `json:"embeddingPreprocessing,omitempty" yaml:"embeddingPreprocessing" mapstructure:"embeddingPreprocessing"`
+
+	// Languages restricts this ingestion flow to documents in the given languages (ISO 639-1 codes, e.g. ["ja", "zh", "ko"]), detected after loading,
+	// e.g. to use a different text splitter for CJK text. Such flows can only set the text splitter and transformers,
+	// as the documents are loaded by the flow without languages for the filetype.
+	Languages []string `json:"languages,omitempty" yaml:"languages" mapstructure:"languages"`
 }
 
 type RetrievalFlowConfig struct {
@@ -61,8 +78,25 @@ type RetrievalFlowConfig struct {
 	// Retriever is the configuration for the retriever to be used. E.g. instead of using a naive retriever, you can use a recursive or refining retriever.
 	Retriever *RetrieverConfig `json:"retriever,omitempty" yaml:"retriever" mapstructure:"retriever"`
 
+	// Retrievers declares multiple (weighted) retrievers, whose results are combined via reciprocal rank fusion (ensemble retriever).
+	// This is a shorthand for the "ensemble" retriever and can't be combined with Retriever.
+	Retrievers []WeightedRetrieverConfig `json:"retrievers,omitempty" yaml:"retrievers" mapstructure:"retrievers"`
+
 	// Postprocessors are used to process the retrieved documents before they are returned. This may include stripping metadata or re-ranking.
 	Postprocessors []TransformerConfig `json:"postprocessors,omitempty" yaml:"postprocessors" mapstructure:"postprocessors"`
+
+	// EmbeddingPreprocessing normalizes the query before it is embedded. This should match the preprocessing used for ingestion.
+	EmbeddingPreprocessing *preprocessing.Options `json:"embeddingPreprocessing,omitempty" yaml:"embeddingPreprocessing" mapstructure:"embeddingPreprocessing"`
+
+	// Fallback re-runs the retrieval with a larger candidate pool and/or a relaxed similarity threshold if too few documents are left after postprocessing.
+	Fallback *flows.SparseResultsFallback `json:"fallback,omitempty" yaml:"fallback" mapstructure:"fallback"`
+
+	// DatasetRouting uses an LLM to select the datasets to search based on their metadata, if multiple datasets are requested.
+	DatasetRouting *flows.DatasetRouting `json:"datasetRouting,omitempty" yaml:"datasetRouting" mapstructure:"datasetRouting"`
+
+	// DatasetWeights prefers some datasets over others when retrieving from multiple datasets: the documents of all datasets are ranked
+	// by their similarity scores multiplied by the dataset's weight, and each dataset contributes at most its topK documents.
+	DatasetWeights dstypes.DatasetWeights `json:"datasetWeights,omitempty" yaml:"datasetWeights" mapstructure:"datasetWeights"`
 }
 
 type QueryModifierConfig struct {
@@ -73,6 +107,11 @@ type RetrieverConfig struct {
 	GenericBaseConfig
 }
 
+type WeightedRetrieverConfig struct {
+	GenericBaseConfig
+	Weight *float32 `json:"weight,omitempty" yaml:"weight" mapstructure:"weight"`
+}
+
 type DocumentLoaderConfig struct {
 	GenericBaseConfig
 }
@@ -164,6 +203,38 @@ func (f *FlowConfig) Validate() error {
 					return fmt.Errorf("flow %q.ingestion.[%d].converter.targetFormat is required", name, idx)
 				}
 			}
+
+			if ingestion.Tables != nil {
+				if err := ingestion.Tables.Validate(); err != nil {
+					return fmt.Errorf("flow %q.ingestion.[%d].tables: %w", name, idx, err)
+				}
+			}
+
+			if len(ingestion.Languages) > 0 && (ingestion.Converter.Name != "" || ingestion.DocumentLoader.Name != "" || ingestion.Tables != nil || ingestion.EmbeddingPreprocessing != nil) {
+				return fmt.Errorf("flow %q.ingestion.[%d]: flows with languages can only set the textSplitter and transformers, as the language is detected after loading", name, idx)
+			}
+		}
+
+		if flow.Retrieval != nil && flow.Retrieval.Retriever != nil && len(flow.Retrieval.Retrievers) > 0 {
+			return fmt.Errorf("flow %q.retrieval: retriever and retrievers are mutually exclusive", name)
+		}
+
+		if flow.Retrieval != nil && flow.Retrieval.Fallback != nil {
+			if err := flow.Retrieval.Fallback.Validate(); err != nil {
+				return fmt.Errorf("flow %q.retrieval.fallback: %w", name, err)
+			}
+		}
+
+		if flow.Retrieval != nil && flow.Retrieval.DatasetRouting != nil {
+			if err := flow.Retrieval.DatasetRouting.Validate(); err != nil {
+				return fmt.Errorf("flow %q.retrieval.datasetRouting: %w", name, err)
+			}
+		}
+
+		if flow.Retrieval != nil {
+			if err := flow.Retrieval.DatasetWeights.Validate(); err != nil {
+				return fmt.Errorf("flow %q.retrieval.datasetWeights: %w", name, err)
+			}
 		}
 	}
 	return nil
@@ -191,8 +262,16 @@ func (i *IngestionFlowConfig) AsIngestionFlow(globals *FlowConfigGlobalsIngestio
 	flow := &flows.IngestionFlow{
 		Filetypes: i.Filetypes,
 		Globals: flows.IngestionFlowGlobals{
-			SplitterOpts: globals.Textsplitter,
+			SplitterOpts:     globals.Textsplitter,
+			AdaptiveChunking: globals.AdaptiveChunking,
 		},
+		Tables:                 i.Tables,
+		EmbeddingPreprocessing: i.EmbeddingPreprocessing,
+		Languages:              i.Languages,
+	}
+
+	if flow.EmbeddingPreprocessing == nil {
+		flow.EmbeddingPreprocessing = globals.EmbeddingPreprocessing
 	}
 
 	if i.Converter.Name != "" {
@@ -281,6 +360,13 @@ func (i *IngestionFlowConfig) AsIngestionFlow(globals *FlowConfigGlobalsIngestio
 			return nil, err
 		}
 		flow.Splitter = splitterFunc
+
+		if globals.AdaptiveChunking != nil {
+			flow.Splitter, err = textsplitter.NewAdaptiveSplitter(*globals.AdaptiveChunking, splitterFunc.Name(), textsplitter.NamedSplitterFactory(name, cfg))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create adaptive text splitter: %w", err)
+			}
+		}
 	}
 
 	if len(i.Transformers) > 0 {
@@ -313,7 +399,12 @@ func (f *FlowConfig) ForDataset(name string) (*FlowConfigEntry, error) {
 }
 
 func (r *RetrievalFlowConfig) AsRetrievalFlow() (*flows.RetrievalFlow, error) {
-	flow := &flows.RetrievalFlow{}
+	flow := &flows.RetrievalFlow{
+		EmbeddingPreprocessing: r.EmbeddingPreprocessing,
+		Fallback:               r.Fallback,
+		DatasetRouting:         r.DatasetRouting,
+		DatasetWeights:         r.DatasetWeights,
+	}
 
 	if len(r.QueryModifiers) > 0 {
 		for _, qm := range r.QueryModifiers {
@@ -345,6 +436,18 @@ func (r *RetrievalFlowConfig) AsRetrievalFlow() (*flows.RetrievalFlow, error) {
 		flow.Retriever = ret
 	}
 
+	if len(r.Retrievers) > 0 {
+		members := make([]retrievers.RetrieverToMerge, len(r.Retrievers))
+		for i, rc := range r.Retrievers {
+			members[i] = retrievers.RetrieverToMerge{Name: rc.Name, Weight: rc.Weight, Options: rc.Options}
+		}
+		ret, err := retrievers.NewEnsembleRetriever(defaults.TopK, members)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ensemble retriever: %w", err)
+		}
+		flow.Retriever = ret
+	}
+
 	if len(r.Postprocessors) > 0 {
 		for _, pp := range r.Postprocessors {
 			postprocessor, err := postprocessors.GetPostprocessor(pp.Name)
@@ -43,3 +43,68 @@ func TestLoadConfigInvalidDoubleDefault(t *testing.T) {
 	_, err := FromFile("testdata/invalid_doubledefault.yaml")
 	assert.Error(t, err)
 }
+
+func TestLoadConfigEnsembleRetrievers(t *testing.T) {
+	cfg, err := FromBytes([]byte(`
+flows:
+  ensemble:
+    retrieval:
+      retrievers:
+        - name: basic
+          weight: 0.7
+        - name: bm25
+          options:
+            topN: 20
+`))
+	require.NoError(t, err)
+	rs := cfg.Flows["ensemble"].Retrieval.Retrievers
+	require.Len(t, rs, 2)
+	assert.Equal(t, "basic", rs[0].Name)
+	assert.Equal(t, float32(0.7), *rs[0].Weight)
+	assert.Nil(t, rs[1].Weight)
+	assert.Equal(t, 20.0, rs[1].Options["topN"])
+
+	_, err = FromBytes([]byte(`
+flows:
+  ensemble:
+    retrieval:
+      retriever:
+        name: basic
+      retrievers:
+        - name: bm25
+`))
+	assert.Error(t, err)
+}
+
+func TestLoadConfigLanguages(t *testing.T) {
+	cfg, err := FromBytes([]byte(`
+flows:
+  multilingual:
+    default: true
+    ingestion:
+      - filetypes: ["*"]
+        languages: ["ja", "zh", "ko"]
+        textSplitter:
+          name: text
+          options:
+            chunkSize: 400
+        transformers:
+          - name: language
+      - filetypes: ["*"]
+        transformers:
+          - name: language
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ja", "zh", "ko"}, cfg.Flows["multilingual"].Ingestion[0].Languages)
+
+	_, err = FromBytes([]byte(`
+flows:
+  multilingual:
+    ingestion:
+      - filetypes: [".pdf"]
+        languages: ["ja"]
+        documentLoader:
+          name: pdf
+`))
+	assert.Error(t, err)
+}
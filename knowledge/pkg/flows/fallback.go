@@ -0,0 +1,210 @@
+package flows
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acorn-io/z"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/retrievers"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+)
+
+const (
+	FallbackStrategyExpand = "expand" // re-query with a larger candidate pool (topK)
+	FallbackStrategyRelax  = "relax"  // lower the similarity threshold step by step
+	FallbackStrategyBoth   = "both"   // expand the candidate pool and relax the threshold at the same time
+)
+
+// SparseResultsFallback re-runs the retrieval with relaxed parameters if fewer than MinResults documents
+// are left after postprocessing (e.g. after the similarity threshold filtered out most of them).
+type SparseResultsFallback struct {
+	MinResults    int     `json:"minResults" yaml:"minResults" mapstructure:"minResults"`                    // target number of result documents
+	Strategy      string  `json:"strategy,omitempty" yaml:"strategy" mapstructure:"strategy"`                // expand (default), relax or both
+	MaxAttempts   int     `json:"maxAttempts,omitempty" yaml:"maxAttempts" mapstructure:"maxAttempts"`       // maximum number of re-queries (default: 3)
+	TopKFactor    int     `json:"topKFactor,omitempty" yaml:"topKFactor" mapstructure:"topKFactor"`          // factor to multiply topK with on each attempt (default: 2)
+	MaxTopK       int     `json:"maxTopK,omitempty" yaml:"maxTopK" mapstructure:"maxTopK"`                   // upper bound for topK (default: 100)
+	ThresholdStep float32 `json:"thresholdStep,omitempty" yaml:"thresholdStep" mapstructure:"thresholdStep"` // amount to lower the similarity threshold by on each attempt (default: 0.1)
+	MinThreshold  float32 `json:"minThreshold,omitempty" yaml:"minThreshold" mapstructure:"minThreshold"`    // lower bound for the similarity threshold (default: 0)
+}
+
+func (f *SparseResultsFallback) FillDefaults() {
+	if f.Strategy == "" {
+		f.Strategy = FallbackStrategyExpand
+	}
+	if f.MaxAttempts <= 0 {
+		f.MaxAttempts = 3
+	}
+	if f.TopKFactor <= 1 {
+		f.TopKFactor = 2
+	}
+	if f.MaxTopK <= 0 {
+		f.MaxTopK = 100
+	}
+	if f.ThresholdStep <= 0 {
+		f.ThresholdStep = 0.1
+	}
+}
+
+func (f *SparseResultsFallback) Validate() error {
+	switch f.Strategy {
+	case "", FallbackStrategyExpand, FallbackStrategyRelax, FallbackStrategyBoth:
+	default:
+		return fmt.Errorf("invalid fallback strategy %q, must be one of %q, %q or %q", f.Strategy, FallbackStrategyExpand, FallbackStrategyRelax, FallbackStrategyBoth)
+	}
+	if f.MinResults < 0 || f.MaxAttempts < 0 || f.MaxTopK < 0 || f.ThresholdStep < 0 {
+		return fmt.Errorf("fallback bounds must not be negative")
+	}
+	return nil
+}
+
+func (f *SparseResultsFallback) expand() bool {
+	return f.Strategy == FallbackStrategyExpand || f.Strategy == FallbackStrategyBoth
+}
+
+func (f *SparseResultsFallback) relax() bool {
+	return f.Strategy == FallbackStrategyRelax || f.Strategy == FallbackStrategyBoth
+}
+
+// runSparseResultsFallback re-queries all responses with fewer than MinResults documents with relaxed parameters.
+// The retriever and postprocessors are reset to their original configuration afterwards.
+func (f *RetrievalFlow) runSparseResultsFallback(ctx context.Context, store store.Store, datasetIDs []string, opts *RetrievalFlowOpts, response *dstypes.RetrievalResponse) error {
+	fb := *f.Fallback
+	fb.FillDefaults()
+
+	log := slog.With("component", "SparseResultsFallback", "strategy", fb.Strategy, "minResults", fb.MinResults)
+
+	var topKRetriever retrievers.TopKRetriever
+	if fb.expand() {
+		if tr, ok := f.Retriever.(retrievers.TopKRetriever); ok {
+			topKRetriever = tr
+		} else {
+			log.Warn("Retriever does not support expanding the candidate pool", "retriever", f.Retriever.Name())
+		}
+	}
+
+	var similarityPPs []*postprocessors.SimilarityPostprocessor
+	if fb.relax() {
+		for _, pp := range f.Postprocessors {
+			if spp, ok := pp.(*postprocessors.SimilarityPostprocessor); ok {
+				similarityPPs = append(similarityPPs, spp)
+			}
+		}
+		if len(similarityPPs) == 0 {
+			log.Warn("No similarity postprocessor configured - threshold cannot be relaxed")
+		}
+	}
+
+	if topKRetriever == nil && len(similarityPPs) == 0 {
+		return nil
+	}
+
+	var origTopK int
+	if topKRetriever != nil {
+		origTopK = topKRetriever.GetTopK()
+		defer topKRetriever.SetTopK(origTopK)
+	}
+	origThresholds := make([]float32, len(similarityPPs))
+	for i, spp := range similarityPPs {
+		origThresholds[i] = spp.Threshold
+	}
+	defer func() {
+		for i, spp := range similarityPPs {
+			spp.Threshold = origThresholds[i]
+		}
+	}()
+
+	for i, resp := range response.Responses {
+		if len(resp.ResultDocuments) >= fb.MinResults {
+			continue
+		}
+
+		// start from the original configuration for each query
+		topK := origTopK
+		var threshold float32
+		for j, spp := range similarityPPs {
+			spp.Threshold = origThresholds[j]
+			threshold = max(threshold, origThresholds[j])
+		}
+
+		for attempt := 1; attempt <= fb.MaxAttempts; attempt++ {
+			changed := false
+			if topKRetriever != nil && topK < fb.MaxTopK {
+				topK = min(max(topK, 1)*fb.TopKFactor, fb.MaxTopK)
+				topKRetriever.SetTopK(topK)
+				changed = true
+			}
+			if len(similarityPPs) > 0 && threshold > fb.MinThreshold {
+				threshold = max(threshold-fb.ThresholdStep, fb.MinThreshold)
+				for _, spp := range similarityPPs {
+					spp.Threshold = min(spp.Threshold, threshold)
+				}
+				changed = true
+			}
+			if !changed {
+				log.Debug("Fallback bounds reached", "query", resp.Query, "attempt", attempt)
+				break
+			}
+
+			log.Debug("Re-querying with relaxed parameters", "query", resp.Query, "attempt", attempt, "topK", topK, "threshold", threshold)
+
+			relaxed, err := f.retrieveAndPostprocess(ctx, store, response.Query, resp.Query, datasetIDs, opts)
+			if err != nil {
+				return err
+			}
+
+			if len(relaxed.ResultDocuments) < len(resp.ResultDocuments) {
+				continue
+			}
+
+			relaxed.Relaxation = &dstypes.Relaxation{
+				Strategy: fb.Strategy,
+				Attempts: attempt,
+			}
+			if topKRetriever != nil {
+				relaxed.Relaxation.TopK = topK
+			}
+			if len(similarityPPs) > 0 {
+				relaxed.Relaxation.Threshold = z.Pointer(threshold)
+			}
+			resp = relaxed
+			response.Responses[i] = resp
+
+			if len(resp.ResultDocuments) >= fb.MinResults {
+				break
+			}
+		}
+
+		if resp.Relaxation != nil {
+			log.Info("Relaxed retrieval due to sparse results", "query", resp.Query, "numDocs", len(resp.ResultDocuments), "attempts", resp.Relaxation.Attempts)
+		}
+	}
+
+	return nil
+}
+
+// retrieveAndPostprocess runs the retriever and all postprocessors for a single query
+func (f *RetrievalFlow) retrieveAndPostprocess(ctx context.Context, store store.Store, originalQuery, query string, datasetIDs []string, opts *RetrievalFlowOpts) (dstypes.Response, error) {
+	docs, err := f.Retriever.Retrieve(ctx, store, query, datasetIDs, opts.Where, opts.WhereDocument)
+	if err != nil {
+		return dstypes.Response{}, fmt.Errorf("failed to retrieve documents for query %q using retriever %q: %w", query, f.Retriever.Name(), err)
+	}
+
+	response := &dstypes.RetrievalResponse{
+		Query:    originalQuery,
+		Datasets: datasetIDs,
+		Responses: []dstypes.Response{{
+			Query:           query,
+			NumDocs:         len(docs),
+			ResultDocuments: docs,
+		}},
+	}
+	for _, pp := range f.Postprocessors {
+		if err := pp.Transform(ctx, response); err != nil {
+			return dstypes.Response{}, fmt.Errorf("failed to postprocess retrieval response with Postprocessor %q: %w", pp.Name(), err)
+		}
+	}
+	return response.Responses[0], nil
+}
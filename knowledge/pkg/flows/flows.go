@@ -1,31 +1,38 @@
 package flows
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"log/slog"
+	"path"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/acorn-io/z"
 	"github.com/google/uuid"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/documentloader/converter"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/postprocessors"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/retrievers"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/tables"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/textsplitter"
 	"github.com/obot-platform/tools/knowledge/pkg/datastore/transformers"
 	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	indextypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
 	"github.com/obot-platform/tools/knowledge/pkg/log"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/mitchellh/mapstructure"
 )
 
 type IngestionFlowGlobals struct {
-	SplitterOpts map[string]any
+	SplitterOpts     map[string]any
+	AdaptiveChunking *textsplitter.AdaptiveChunkingOpts // if set, the chunk size is picked per document within the configured bounds
 }
 
 type ConverterOpts struct {
@@ -45,6 +52,69 @@ type IngestionFlow struct {
 	Load            documentloader.LoaderFunc
 	Splitter        dstypes.TextSplitter
 	Transformations []dstypes.DocumentTransformer
+	Tables          *tables.Options // if set, tables are extracted into dedicated documents that are not split
+
+	EmbeddingPreprocessing *preprocessing.Options // normalization applied to the text sent to the embedding model only
+
+	// Languages restricts the flow to documents in these languages (ISO 639-1 codes, e.g. "ja"), detected after loading.
+	// Such flows only provide the text splitter and transformers - the documents are loaded by the flow without languages.
+	Languages []string
+
+	// LanguageFlows are the flows with languages for the same filetype, see SelectIngestionFlow
+	LanguageFlows []IngestionFlow
+}
+
+// SelectIngestionFlow returns the first flow supporting the filetype without languages (or an empty flow to be filled with defaults),
+// with the flows supporting the filetype with languages, which take over after loading if the documents are in one of their languages.
+func SelectIngestionFlow(ingestionFlows []IngestionFlow, filetype string) IngestionFlow {
+	var flow *IngestionFlow
+	var languageFlows []IngestionFlow
+	for i, f := range ingestionFlows {
+		if !f.SupportsFiletype(filetype) {
+			continue
+		}
+		if len(f.Languages) > 0 {
+			languageFlows = append(languageFlows, f)
+		} else if flow == nil {
+			flow = &ingestionFlows[i]
+		}
+	}
+	selected := IngestionFlow{}
+	if flow != nil {
+		selected = *flow
+	}
+	selected.LanguageFlows = languageFlows
+	return selected
+}
+
+// ForLanguage returns the language flow for the language of the documents, or the flow itself
+func (f *IngestionFlow) ForLanguage(ctx context.Context, docs []vs.Document) *IngestionFlow {
+	if len(f.LanguageFlows) == 0 {
+		return f
+	}
+	lang := transformers.DetectLanguage(docs)
+	for i, lf := range f.LanguageFlows {
+		if slices.Contains(lf.Languages, lang) {
+			log.FromCtx(ctx).Info("Using ingestion flow for detected language", "language", lang, "languages", lf.Languages)
+			return &f.LanguageFlows[i]
+		}
+	}
+	log.FromCtx(ctx).Debug("No ingestion flow for detected language", "language", lang)
+	return f
+}
+
+// NeedsEmbeddingFunc reports whether the text splitter or a transformer of the flow (or of its language flows) needs the embedding
+// function in the context, see dstypes.EmbeddingFuncToCtx
+func (f *IngestionFlow) NeedsEmbeddingFunc() bool {
+	if _, ok := f.Splitter.(dstypes.ContextTextSplitter); ok {
+		return true
+	}
+	for _, t := range f.Transformations {
+		if et, ok := t.(dstypes.EmbeddingDocumentTransformer); ok && et.NeedsEmbeddingFunc() {
+			return true
+		}
+	}
+	return slices.ContainsFunc(f.LanguageFlows, func(lf IngestionFlow) bool { return lf.NeedsEmbeddingFunc() })
 }
 
 func (f *IngestionFlow) Transform(ctx context.Context, docs []vs.Document) ([]vs.Document, error) {
@@ -104,10 +174,28 @@ func (f *IngestionFlow) FillDefaults(filetype string) error {
 			return fmt.Errorf("failed to configure text splitter options: %w", err)
 		}
 		f.Splitter = textsplitter.DefaultTextSplitter(filetype, textsplitterOpts)
+
+		if f.Globals.AdaptiveChunking != nil {
+			splitter, err := textsplitter.NewAdaptiveSplitter(*f.Globals.AdaptiveChunking, f.Splitter.Name(), func(chunkSize int) (dstypes.TextSplitter, error) {
+				opts := *textsplitterOpts
+				opts.ChunkOverlap = opts.ChunkOverlap * chunkSize / max(opts.ChunkSize, 1)
+				opts.ChunkSize = chunkSize
+				return textsplitter.DefaultTextSplitter(filetype, &opts), nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create adaptive text splitter: %w", err)
+			}
+			f.Splitter = splitter
+		}
 	}
 	if len(f.Transformations) == 0 {
 		f.Transformations = transformers.DefaultDocumentTransformers(filetype)
 	}
+	for i := range f.LanguageFlows {
+		if err := f.LanguageFlows[i].FillDefaults(filetype); err != nil {
+			return fmt.Errorf("failed to fill defaults of ingestion flow for languages %v: %w", f.LanguageFlows[i].Languages, err)
+		}
+	}
 	return nil
 }
 
@@ -144,29 +232,77 @@ func (f *IngestionFlow) Run(ctx context.Context, reader io.Reader, filename stri
 		return nil, nil
 	}
 
-	docs, err = f.Load(ctx, reader)
+	// Extract the tables from formats where the loader flattens them into the text before loading,
+	// which then loads the file with markers in place of the tables (see tables.FromDocx)
+	var docxTables []tables.Table
+	if f.Tables != nil && f.Converter.Converter == nil && strings.ToLower(path.Ext(filename)) == ".docx" {
+		rawData, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		var marked []byte
+		docxTables, marked, err = tables.FromDocx(rawData, f.Tables.KeepInText)
+		if err != nil {
+			phaseLog.With("stage", "tables").With("status", "failed").Error("Failed to extract tables from docx", "error", err)
+			return nil, fmt.Errorf("failed to extract tables: %w", err)
+		}
+		reader = bytes.NewReader(marked)
+	}
+
+	loadCtx := dstypes.FilenameToCtx(ctx, filename) // e.g. for external document loaders
+	if f.Tables != nil {
+		loadCtx = dstypes.DetectTablesToCtx(loadCtx) // e.g. PDF loaders render the tables they detect as markdown tables
+	}
+
+	docs, err = f.Load(loadCtx, reader)
 	if err != nil {
 		loaderLog.With("status", "failed").Error("Failed to load documents", "error", err)
 		return nil, fmt.Errorf("failed to load documents: %w", err)
 	}
 	loaderLog.With("status", "completed").Info("Loaded documents", "num_documents", len(docs))
 
+	/*
+	 * Extract tables into dedicated documents, so they don't get split up
+	 */
+	var tableDocs []vs.Document
+	if f.Tables != nil {
+		tablesLog := phaseLog.With("stage", "tables")
+		if docxTables != nil {
+			docs, tableDocs = tables.DocxTableDocuments(docs, docxTables, *f.Tables)
+		}
+		var markdownTableDocs []vs.Document
+		docs, markdownTableDocs = tables.Extract(docs, *f.Tables)
+		tableDocs = append(tableDocs, markdownTableDocs...)
+		tablesLog.With("status", "completed").Info("Extracted tables", "num_tables", len(tableDocs))
+	}
+
+	/*
+	 * Select the text splitter and transformers by the language of the documents, if configured
+	 */
+	lf := f.ForLanguage(ctx, docs)
+
 	/*
 	 * Split documents - Chunking
 	 */
-	splitterLog := phaseLog.With("stage", "textsplitter").With(slog.Int("num_documents", len(docs))).With("splitter", f.Splitter.Name())
+	splitterLog := phaseLog.With("stage", "textsplitter").With(slog.Int("num_documents", len(docs))).With("splitter", lf.Splitter.Name())
 	splitterLog.With("status", "starting").Info("Starting text splitter")
-	docs, err = f.Splitter.SplitDocuments(docs)
+	if cs, ok := lf.Splitter.(dstypes.ContextTextSplitter); ok {
+		docs, err = cs.SplitDocumentsWithContext(ctx, docs)
+	} else {
+		docs, err = lf.Splitter.SplitDocuments(docs)
+	}
 	if err != nil {
 		splitterLog.With("status", "failed").Error("Failed to split documents", "error", err)
 		return nil, fmt.Errorf("failed to split documents: %w", err)
 	}
 	splitterLog.With("status", "completed").Info("Split documents", "new_num_documents", len(docs))
 
+	docs = append(docs, tableDocs...)
+
 	/*
 	 * Transform documents
 	 */
-	docs, err = f.RunTransformers(ctx, docs, phaseLog)
+	docs, err = lf.RunTransformers(ctx, docs, phaseLog)
 	if err != nil {
 		return nil, err
 	}
@@ -197,9 +333,13 @@ func (f *IngestionFlow) AddDocIDs(docs []vs.Document) []vs.Document {
 }
 
 type RetrievalFlow struct {
-	QueryModifiers []querymodifiers.QueryModifier
-	Retriever      retrievers.Retriever
-	Postprocessors []postprocessors.Postprocessor
+	QueryModifiers         []querymodifiers.QueryModifier
+	Retriever              retrievers.Retriever
+	Postprocessors         []postprocessors.Postprocessor
+	EmbeddingPreprocessing *preprocessing.Options // normalization applied to the query before embedding it - should match the ingestion flow
+	Fallback               *SparseResultsFallback // re-query with relaxed parameters if results are sparse
+	DatasetRouting         *DatasetRouting        // select the datasets to search via LLM instead of searching all of them
+	DatasetWeights         dstypes.DatasetWeights // per-dataset top k and score weights in multi-dataset retrieval
 }
 
 func (f *RetrievalFlow) FillDefaults(topK int) {
@@ -210,8 +350,10 @@ func (f *RetrievalFlow) FillDefaults(topK int) {
 }
 
 type RetrievalFlowOpts struct {
-	Where         map[string]string
-	WhereDocument []vs.WhereDocument
+	Where          map[string]string
+	WhereDocument  []vs.WhereDocument
+	History        []querymodifiers.ConversationTurn // prior conversation turns, used by history-aware query modifiers
+	DatasetWeights dstypes.DatasetWeights            // per-dataset top k and score weights, overriding the flow's weights per dataset
 }
 
 func (f *RetrievalFlow) Run(ctx context.Context, store store.Store, query string, datasetIDs []string, opts *RetrievalFlowOpts) (*dstypes.RetrievalResponse, error) {
@@ -221,8 +363,58 @@ func (f *RetrievalFlow) Run(ctx context.Context, store store.Store, query string
 		opts = &RetrievalFlowOpts{}
 	}
 
+	if f.DatasetRouting != nil {
+		routed, err := f.DatasetRouting.route(ctx, store, query, datasetIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to route query to datasets: %w", err)
+		}
+		datasetIDs = routed
+	}
+
+	var datasets []indextypes.Dataset
+	for _, m := range f.QueryModifiers {
+		if _, ok := m.(querymodifiers.DatasetAwareQueryModifier); ok {
+			for _, id := range datasetIDs {
+				ds, err := store.GetDataset(ctx, id, nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get dataset %q: %w", id, err)
+				}
+				if ds != nil {
+					datasets = append(datasets, *ds)
+				}
+			}
+			break
+		}
+	}
+
+	var vocabulary map[string]int
+	for _, m := range f.QueryModifiers {
+		if _, ok := m.(querymodifiers.VocabularyAwareQueryModifier); ok {
+			vocabulary = map[string]int{}
+			for _, id := range datasetIDs {
+				v, err := store.GetVocabulary(ctx, id)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get vocabulary of dataset %q: %w", id, err)
+				}
+				for term, frequency := range v {
+					vocabulary[term] += frequency
+				}
+			}
+			break
+		}
+	}
+
 	queries := []string{query}
 	for _, m := range f.QueryModifiers {
+		if hm, ok := m.(querymodifiers.HistoryAwareQueryModifier); ok {
+			m = hm.WithHistory(opts.History)
+		}
+		if dm, ok := m.(querymodifiers.DatasetAwareQueryModifier); ok {
+			m = dm.WithDatasets(datasets)
+		}
+		if vm, ok := m.(querymodifiers.VocabularyAwareQueryModifier); ok {
+			m = vm.WithVocabulary(vocabulary)
+		}
 		mq, err := m.ModifyQueries(queries)
 		if err != nil {
 			return nil, fmt.Errorf("failed to modify queries %v with QueryModifier %q: %w", queries, m.Name(), err)
@@ -237,6 +429,13 @@ func (f *RetrievalFlow) Run(ctx context.Context, store store.Store, query string
 		Datasets:  datasetIDs,
 		Responses: make([]dstypes.Response, len(queries)),
 	}
+	ctx = preprocessing.ToCtx(ctx, f.EmbeddingPreprocessing)
+
+	// the retrievers rank the documents of all datasets by their weighted similarity scores
+	if weights := f.DatasetWeights.Merge(opts.DatasetWeights); len(weights) > 0 {
+		ctx = dstypes.DatasetWeightsToCtx(ctx, weights)
+	}
+
 	for i, q := range queries {
 		docs, err := f.Retriever.Retrieve(ctx, store, q, datasetIDs, opts.Where, opts.WhereDocument)
 		if err != nil {
@@ -258,6 +457,12 @@ func (f *RetrievalFlow) Run(ctx context.Context, store store.Store, query string
 	}
 	slog.Debug("Postprocessed RetrievalResponse", "num_responses", len(response.Responses), "original_query", query)
 
+	if f.Fallback != nil && f.Fallback.MinResults > 0 {
+		if err := f.runSparseResultsFallback(ctx, store, datasetIDs, opts, response); err != nil {
+			return nil, fmt.Errorf("failed to run sparse results fallback: %w", err)
+		}
+	}
+
 	response.Stats = dstypes.Stats{
 		RetrievalTimeSeconds: time.Since(retrievalFlowStartTime).Seconds(),
 	}
@@ -0,0 +1,125 @@
+package flows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/store"
+	indextypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/llm"
+)
+
+// DatasetRouting selects the datasets to search using an LLM based on their metadata (e.g. a description),
+// instead of always fanning out to all datasets passed to the retrieval.
+// It only kicks in if more than one dataset is requested and falls back to all of them if the LLM doesn't pick any.
+type DatasetRouting struct {
+	Model        llm.LLMConfig `json:"model" yaml:"model" mapstructure:"model"`
+	MaxDatasets  int           `json:"maxDatasets,omitempty" yaml:"maxDatasets" mapstructure:"maxDatasets"`    // maximum number of datasets to select (default: no limit)
+	MetadataKeys []string      `json:"metadataKeys,omitempty" yaml:"metadataKeys" mapstructure:"metadataKeys"` // dataset metadata fields shown to the LLM (default: all)
+}
+
+func (r *DatasetRouting) Validate() error {
+	if r.MaxDatasets < 0 {
+		return fmt.Errorf("maxDatasets must not be negative")
+	}
+	return nil
+}
+
+var datasetRoutingPromptTpl = `The following query will be used for a search across multiple datasets.
+Please select the datasets that are most likely to contain information relevant to the query, based on their metadata.
+{{.limit}}
+Query: "{{.query}}"
+Available datasets in a JSON map, where the key is the dataset ID and the value is a map of metadata fields:
+{{.datasets}}
+Reply only in the following JSON format, without any styling or markdown syntax:
+{"results": ["<dataset-id>", ...]}`
+
+type datasetRoutingResp struct {
+	Results []string `json:"results"`
+}
+
+// route returns the subset of datasetIDs that should be searched for the given query
+func (r *DatasetRouting) route(ctx context.Context, store store.Store, query string, datasetIDs []string) ([]string, error) {
+	if len(datasetIDs) <= 1 {
+		return datasetIDs, nil
+	}
+
+	log := slog.With("component", "DatasetRouting")
+
+	datasets := make(map[string]map[string]any, len(datasetIDs))
+	for _, id := range datasetIDs {
+		ds, err := store.GetDataset(ctx, id, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dataset %q: %w", id, err)
+		}
+		if ds == nil {
+			return nil, fmt.Errorf("dataset not found: %q", id)
+		}
+		datasets[id] = r.metadata(ds)
+	}
+
+	datasetsJSON, err := json.Marshal(datasets)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := llm.NewFromConfig(r.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	var limit string
+	if r.MaxDatasets > 0 {
+		limit = fmt.Sprintf("Select at most %d datasets.", r.MaxDatasets)
+	}
+
+	result, err := m.Prompt(ctx, datasetRoutingPromptTpl, map[string]any{"query": query, "datasets": string(datasetsJSON), "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Dataset routing result", "result", result)
+
+	var resp datasetRoutingResp
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result)), &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset routing result %q: %w", result, err)
+	}
+
+	var selected []string
+	for _, id := range resp.Results {
+		if !slices.Contains(datasetIDs, id) {
+			log.Warn("LLM selected unknown dataset, ignoring it", "dataset", id)
+			continue
+		}
+		if !slices.Contains(selected, id) {
+			selected = append(selected, id)
+		}
+	}
+	if r.MaxDatasets > 0 && len(selected) > r.MaxDatasets {
+		selected = selected[:r.MaxDatasets]
+	}
+
+	if len(selected) == 0 {
+		log.Warn("No dataset selected, searching all datasets", "query", query, "datasets", datasetIDs)
+		return datasetIDs, nil
+	}
+
+	log.Debug("Routing query to datasets", "query", query, "datasets", selected, "candidates", datasetIDs)
+	return selected, nil
+}
+
+func (r *DatasetRouting) metadata(ds *indextypes.Dataset) map[string]any {
+	if len(r.MetadataKeys) == 0 {
+		return ds.Metadata
+	}
+	md := make(map[string]any, len(r.MetadataKeys))
+	for _, k := range r.MetadataKeys {
+		if v, ok := ds.Metadata[k]; ok {
+			md[k] = v
+		}
+	}
+	return md
+}
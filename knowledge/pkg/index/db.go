@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/obot-platform/tools/knowledge/pkg/index/mysql"
 	"github.com/obot-platform/tools/knowledge/pkg/index/postgres"
 	"github.com/obot-platform/tools/knowledge/pkg/index/sqlite"
 	"gorm.io/gorm"
@@ -42,6 +43,10 @@ func New(ctx context.Context, dsn string, autoMigrate bool) (Index, error) {
 	)
 
 	dialect := strings.Split(dsn, "://")[0]
+	if mysql.IsDSN(dsn) {
+		// also plain DSNs in the MySQL driver's format, e.g. user:password@tcp(host:3306)/db
+		dialect = "mysql"
+	}
 
 	slog.Debug("indexdb", "dialect", dialect, "dsn", dsn)
 
@@ -50,6 +55,8 @@ func New(ctx context.Context, dsn string, autoMigrate bool) (Index, error) {
 		indexDB, err = sqlite.New(ctx, dsn, gormCfg, autoMigrate)
 	case "postgres":
 		indexDB, err = postgres.New(ctx, dsn, gormCfg, autoMigrate)
+	case "mysql":
+		indexDB, err = mysql.New(ctx, dsn, gormCfg, autoMigrate)
 	default:
 		err = fmt.Errorf("unsupported dialect: %q", dialect)
 	}
@@ -27,6 +27,7 @@ type Index interface {
 	FindFile(ctx context.Context, searchFile types.File) (*types.File, error)
 	FindFileByMetadata(ctx context.Context, dataset string, metadata types.FileMetadata, includeDocuments bool) (*types.File, error)
 	FindFilesByMetadata(ctx context.Context, dataset string, metadata types.FileMetadata, includeDocuments bool) ([]types.File, error)
+	UpdateFileTags(ctx context.Context, datasetID, fileID string, tags []string) error
 
 	// Advanced File Operations
 	PruneFiles(ctx context.Context, datasetID string, pathPrefix string, keep []string) ([]types.File, error)
@@ -35,5 +36,21 @@ type Index interface {
 	GetDocumentByID(ctx context.Context, documentID string) (*types.Document, error)
 	DeleteDocument(ctx context.Context, documentID, datasetID string) error
 
+	// Keyword Search over document contents stored in the Index (only for documents ingested with content)
+	KeywordSearch(ctx context.Context, datasetID string, query string, limit int) ([]types.KeywordSearchResult, error)
+
+	// Vocabulary of a dataset (term frequencies), only built for documents ingested with vocabulary building enabled
+	AddVocabulary(ctx context.Context, datasetID string, frequencies map[string]int) error
+	GetVocabulary(ctx context.Context, datasetID string) (map[string]int, error)
+
+	// Embedding cache keyed by embedding model and text hash, so that unchanged content doesn't have to be embedded again
+	GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error)
+	AddCachedEmbeddings(ctx context.Context, model string, embeddings map[string][]float32) error
+
+	// Ingestion checkpoints recording the progress of the ingestion of files, to resume an interrupted ingestion
+	GetIngestionCheckpoint(ctx context.Context, datasetID, absPath string) (*types.IngestionCheckpoint, error)
+	SaveIngestionCheckpoint(ctx context.Context, cp types.IngestionCheckpoint) error
+	DeleteIngestionCheckpoints(ctx context.Context, datasetID, pathPrefix string) error
+
 	Close() error
 }
@@ -0,0 +1,46 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+const fulltextIndex = "idx_documents_content_ft"
+
+// migrateKeywordSearch adds a FULLTEXT index on the document contents.
+func (i *Index) migrateKeywordSearch() error {
+	if i.DB.GormDB.Migrator().HasIndex(&types.Document{}, fulltextIndex) {
+		return nil
+	}
+	if err := i.DB.GormDB.Exec(fmt.Sprintf("CREATE FULLTEXT INDEX %s ON documents (content)", fulltextIndex)).Error; err != nil {
+		return fmt.Errorf("failed to set up keyword search: %w", err)
+	}
+	return nil
+}
+
+func (i *Index) KeywordSearch(ctx context.Context, datasetID string, query string, limit int) ([]types.KeywordSearchResult, error) {
+	terms := types.KeywordTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	// In natural language mode, any of the terms matches and there are no operators
+	against := strings.Join(terms, " ")
+
+	slog.Debug("Keyword search", "dataset", datasetID, "against", against, "limit", limit)
+
+	var results []types.KeywordSearchResult
+	err := i.DB.WithContext(ctx).Raw(`SELECT d.*, MATCH(d.content) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+FROM documents d
+WHERE d.dataset = ? AND MATCH(d.content) AGAINST (? IN NATURAL LANGUAGE MODE)
+ORDER BY score DESC
+LIMIT ?`, against, datasetID, against, limit).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyword search: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+// migrateKeywordSearch adds a generated tsvector column for the document contents and a GIN index on it.
+// The 'simple' configuration is used, as documents may be in any language.
+func (i *Index) migrateKeywordSearch() error {
+	stmts := []string{
+		`ALTER TABLE documents ADD COLUMN IF NOT EXISTS content_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_documents_content_tsv ON documents USING GIN (content_tsv)`,
+	}
+
+	for _, stmt := range stmts {
+		if err := i.DB.GormDB.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to set up keyword search: %w", err)
+		}
+	}
+	return nil
+}
+
+func (i *Index) KeywordSearch(ctx context.Context, datasetID string, query string, limit int) ([]types.KeywordSearchResult, error) {
+	terms := types.KeywordTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	tsquery := strings.Join(terms, " | ")
+
+	slog.Debug("Keyword search", "dataset", datasetID, "tsquery", tsquery, "limit", limit)
+
+	var results []types.KeywordSearchResult
+	err := i.DB.WithContext(ctx).Raw(`SELECT d.id, d.dataset, d.file_id, d.index, d.content, d.metadata, ts_rank_cd(d.content_tsv, q) AS score
+FROM documents d, to_tsquery('simple', ?) q
+WHERE d.dataset = ? AND d.content_tsv @@ q
+ORDER BY score DESC
+LIMIT ?`, tsquery, datasetID, limit).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyword search: %w", err)
+	}
+
+	return results, nil
+}
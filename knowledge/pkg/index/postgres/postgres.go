@@ -44,7 +44,13 @@ func (i *Index) Close() error {
 }
 
 func (i *Index) AutoMigrate() error {
-	return i.DB.DoAutoMigrate()
+	if err := i.DB.DoAutoMigrate(); err != nil {
+		return err
+	}
+	if !i.DB.AutoMigrate {
+		return nil
+	}
+	return i.migrateKeywordSearch()
 }
 
 func (i *Index) ExportDatasetsToFile(ctx context.Context, path string, ids ...string) error {
@@ -75,6 +81,10 @@ func (i *Index) DeleteFile(ctx context.Context, datasetID, fileID string) error
 	return i.DB.DeleteFile(ctx, datasetID, fileID)
 }
 
+func (i *Index) UpdateFileTags(ctx context.Context, datasetID, fileID string, tags []string) error {
+	return i.DB.UpdateFileTags(ctx, datasetID, fileID, tags)
+}
+
 func (i *Index) FindFile(ctx context.Context, searchFile types.File) (*types.File, error) {
 	return i.DB.FindFile(ctx, searchFile)
 }
@@ -98,3 +108,31 @@ func (i *Index) GetDocumentByID(ctx context.Context, documentID string) (*types.
 func (i *Index) DeleteDocument(ctx context.Context, documentID, datasetID string) error {
 	return i.DB.DeleteDocument(ctx, documentID, datasetID)
 }
+
+func (i *Index) AddVocabulary(ctx context.Context, datasetID string, frequencies map[string]int) error {
+	return i.DB.AddVocabulary(ctx, datasetID, frequencies)
+}
+
+func (i *Index) GetVocabulary(ctx context.Context, datasetID string) (map[string]int, error) {
+	return i.DB.GetVocabulary(ctx, datasetID)
+}
+
+func (i *Index) GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error) {
+	return i.DB.GetCachedEmbeddings(ctx, model, hashes)
+}
+
+func (i *Index) AddCachedEmbeddings(ctx context.Context, model string, embeddings map[string][]float32) error {
+	return i.DB.AddCachedEmbeddings(ctx, model, embeddings)
+}
+
+func (i *Index) GetIngestionCheckpoint(ctx context.Context, datasetID, absPath string) (*types.IngestionCheckpoint, error) {
+	return i.DB.GetIngestionCheckpoint(ctx, datasetID, absPath)
+}
+
+func (i *Index) SaveIngestionCheckpoint(ctx context.Context, cp types.IngestionCheckpoint) error {
+	return i.DB.SaveIngestionCheckpoint(ctx, cp)
+}
+
+func (i *Index) DeleteIngestionCheckpoints(ctx context.Context, datasetID, pathPrefix string) error {
+	return i.DB.DeleteIngestionCheckpoints(ctx, datasetID, pathPrefix)
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+const ftsTable = "documents_fts"
+
+// migrateKeywordSearch creates the FTS5 table used for keyword search and the triggers that keep it in sync with the documents table.
+// Only documents with stored content are indexed.
+func (i *Index) migrateKeywordSearch() error {
+	stmts := []string{
+		fmt.Sprintf(`CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(id UNINDEXED, dataset UNINDEXED, content)`, ftsTable),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_ai AFTER INSERT ON documents WHEN new.content IS NOT NULL AND new.content != '' BEGIN
+	INSERT INTO %[1]s(id, dataset, content) VALUES (new.id, new.dataset, new.content);
+END`, ftsTable),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_ad AFTER DELETE ON documents BEGIN
+	DELETE FROM %[1]s WHERE id = old.id AND dataset = old.dataset;
+END`, ftsTable),
+		fmt.Sprintf(`CREATE TRIGGER IF NOT EXISTS %[1]s_au AFTER UPDATE OF content ON documents BEGIN
+	DELETE FROM %[1]s WHERE id = old.id AND dataset = old.dataset;
+	INSERT INTO %[1]s(id, dataset, content) SELECT new.id, new.dataset, new.content WHERE new.content IS NOT NULL AND new.content != '';
+END`, ftsTable),
+	}
+
+	for _, stmt := range stmts {
+		if err := i.DB.GormDB.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to set up keyword search: %w", err)
+		}
+	}
+	return nil
+}
+
+func (i *Index) KeywordSearch(ctx context.Context, datasetID string, query string, limit int) ([]types.KeywordSearchResult, error) {
+	terms := types.KeywordTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	// Quote all terms, so FTS5 doesn't interpret them as operators or column filters
+	for idx, t := range terms {
+		terms[idx] = `"` + t + `"`
+	}
+	match := strings.Join(terms, " OR ")
+
+	slog.Debug("Keyword search", "dataset", datasetID, "match", match, "limit", limit)
+
+	// bm25() returns lower values for better matches
+	var results []types.KeywordSearchResult
+	err := i.DB.WithContext(ctx).Raw(fmt.Sprintf(`SELECT d.*, -bm25(%[1]s) AS score
+FROM %[1]s f
+JOIN documents d ON d.id = f.id AND d.dataset = f.dataset
+WHERE %[1]s MATCH ? AND f.dataset = ?
+ORDER BY bm25(%[1]s)
+LIMIT ?`, ftsTable), match, datasetID, limit).Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to run keyword search: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetIngestionCheckpoint returns the ingestion checkpoint of the file in the dataset or nil if there is none
+func (db *DB) GetIngestionCheckpoint(ctx context.Context, datasetID, absPath string) (*IngestionCheckpoint, error) {
+	var cp IngestionCheckpoint
+	err := db.WithContext(ctx).Where("dataset = ? AND absolute_path = ?", datasetID, absPath).First(&cp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get ingestion checkpoint of %q: %w", absPath, err)
+	}
+	return &cp, nil
+}
+
+// SaveIngestionCheckpoint creates or replaces the ingestion checkpoint of the file
+func (db *DB) SaveIngestionCheckpoint(ctx context.Context, cp IngestionCheckpoint) error {
+	err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dataset"}, {Name: "absolute_path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"checksum", "stage", "documents", "updated_at"}),
+	}).Create(&cp).Error
+	if err != nil {
+		return fmt.Errorf("failed to save ingestion checkpoint of %q: %w", cp.AbsolutePath, err)
+	}
+	return nil
+}
+
+// DeleteIngestionCheckpoints deletes the ingestion checkpoints of all files in the dataset under the path prefix
+func (db *DB) DeleteIngestionCheckpoints(ctx context.Context, datasetID, pathPrefix string) error {
+	err := db.WithContext(ctx).
+		Where("dataset = ?", datasetID).
+		Where("absolute_path LIKE ?", pathPrefix+"%").
+		Delete(&IngestionCheckpoint{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete ingestion checkpoints under %q: %w", pathPrefix, err)
+	}
+	return nil
+}
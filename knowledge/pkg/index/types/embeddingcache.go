@@ -0,0 +1,61 @@
+package types
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm/clause"
+)
+
+// GetCachedEmbeddings returns the cached embeddings of the model for the given text hashes, keyed by hash
+func (db *DB) GetCachedEmbeddings(ctx context.Context, model string, hashes []string) (map[string][]float32, error) {
+	embeddings := make(map[string][]float32, len(hashes))
+	if len(hashes) == 0 {
+		return embeddings, nil
+	}
+
+	var cached []CachedEmbedding
+	if err := db.WithContext(ctx).Where("model = ? AND hash IN ?", model, hashes).Find(&cached).Error; err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings: %w", err)
+	}
+
+	for _, c := range cached {
+		embeddings[c.Hash] = decodeEmbedding(c.Embedding)
+	}
+	return embeddings, nil
+}
+
+// AddCachedEmbeddings adds the embeddings of the model, keyed by text hash, to the cache
+func (db *DB) AddCachedEmbeddings(ctx context.Context, model string, embeddings map[string][]float32) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	cached := make([]CachedEmbedding, 0, len(embeddings))
+	for hash, emb := range embeddings {
+		cached = append(cached, CachedEmbedding{Model: model, Hash: hash, Embedding: encodeEmbedding(emb)})
+	}
+
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(cached, 500).Error; err != nil {
+		return fmt.Errorf("failed to add cached embeddings: %w", err)
+	}
+	return nil
+}
+
+func encodeEmbedding(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func decodeEmbedding(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,25 @@
+package types
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeywordTerms splits a search query into unique, lowercased terms consisting only of letters and digits,
+// so they can safely be used in the full-text query syntax of the different databases.
+func KeywordTerms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]struct{}, len(fields))
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		terms = append(terms, f)
+	}
+	return terms
+}
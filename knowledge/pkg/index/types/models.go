@@ -7,7 +7,8 @@ import (
 )
 
 type DatasetCreateOpts struct {
-	ErrOnExists bool
+	ErrOnExists      bool
+	DistanceFunction string // Distance function used for similarity search in this dataset (if supported by the vector store, e.g. pgvector: cosine, l2, inner_product)
 }
 
 type DatasetGetOpts struct {
@@ -20,6 +21,8 @@ type Dataset struct {
 	ID                       string                      `gorm:"primaryKey" json:"id"`
 	EmbeddingsProviderConfig *config.ModelProviderConfig `json:"embeddingsProviderConfig,omitempty" gorm:"serializer:json"`
 	Files                    []File                      `gorm:"foreignKey:Dataset;references:ID;constraint:OnDelete:CASCADE;"`
+	Vocabulary               []VocabularyTerm            `gorm:"foreignKey:Dataset;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	Checkpoints              []IngestionCheckpoint       `gorm:"foreignKey:Dataset;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
 	Metadata                 map[string]any              `json:"metadata,omitempty" gorm:"serializer:json"`
 }
 
@@ -27,6 +30,7 @@ type File struct {
 	ID        string     `gorm:"primaryKey" json:"id"`
 	Dataset   string     `gorm:"primaryKey" json:"dataset"` // Foreign key to Dataset
 	Documents []Document `gorm:"foreignKey:FileID,Dataset;references:ID,Dataset;constraint:OnDelete:CASCADE;"`
+	Tags      []string   `json:"tags,omitempty" gorm:"serializer:json"` // also stored in the metadata of the file's documents, so that retrieval can filter by tag
 	// File metadata, commonly used for deduplication
 	FileMetadata `json:",inline"`
 }
@@ -37,6 +41,7 @@ type FileMetadata struct {
 	Size         int64     `json:"size"`
 	ModifiedAt   time.Time `json:"modified_at"`
 	Checksum     string    `json:"checksum"`
+	ETag         string    `json:"etag,omitempty" gorm:"column:etag"` // entity tag of the source object, e.g. for files ingested from an object store
 }
 
 type Document struct {
@@ -44,4 +49,51 @@ type Document struct {
 	Dataset string `gorm:"primaryKey" json:"dataset"` // Foreign key to Dataset, part of composite primary key with FileID
 	FileID  string `gorm:"primaryKey" json:"file_id"` // Foreign key to File, part of composite primary key with Dataset
 	Index   int    `gorm:"index" json:"index"`        // Index of the document in the file (~ location within file, 0-based)
+
+	// Content and Metadata are only stored if requested at ingestion time (see datastore.IngestOpts.IndexContent) to enable keyword search via the Index
+	Content  string         `json:"content,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty" gorm:"serializer:json"`
+}
+
+// VocabularyTerm is a word occurring in the documents of a dataset, used e.g. for dictionary-based spell correction
+type VocabularyTerm struct {
+	Dataset   string `gorm:"primaryKey" json:"dataset"` // Foreign key to Dataset
+	Term      string `gorm:"primaryKey" json:"term"`
+	Frequency int    `json:"frequency"` // Number of occurrences across all ingested documents
+}
+
+// KeywordSearchResult is a document found by a full-text keyword search in the Index
+type KeywordSearchResult struct {
+	Document
+	Score float64 // higher is better, not normalized
+}
+
+// CachedEmbedding is an embedding computed during ingestion, keyed by the embedding model (incl. its configuration)
+// and the hash of the embedded text, so that unchanged content doesn't have to be embedded again
+type CachedEmbedding struct {
+	Model     string    `gorm:"primaryKey" json:"model"`
+	Hash      string    `gorm:"primaryKey" json:"hash"` // SHA-256 of the embedded text
+	Embedding []byte    `json:"-"`                      // little-endian float32 values
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IngestionStage is the last completed stage of the ingestion of a file
+type IngestionStage string
+
+const (
+	IngestionStagePending  IngestionStage = "pending"  // file picked up, not loaded yet
+	IngestionStageLoaded   IngestionStage = "loaded"   // file loaded and split into documents
+	IngestionStageEmbedded IngestionStage = "embedded" // documents embedded
+	IngestionStageIndexed  IngestionStage = "indexed"  // documents added to the vector store and file recorded in the index
+)
+
+// IngestionCheckpoint records the ingestion progress of a file, so that an interrupted ingestion can resume where it stopped.
+// It only applies to the file content it was recorded for, identified by the checksum.
+type IngestionCheckpoint struct {
+	Dataset      string         `gorm:"primaryKey" json:"dataset"` // Foreign key to Dataset
+	AbsolutePath string         `gorm:"primaryKey" json:"absolute_path"`
+	Checksum     string         `json:"checksum"`
+	Stage        IngestionStage `json:"stage"`
+	Documents    []byte         `json:"-"` // JSON-encoded documents to resume from (loaded and embedded stages)
+	UpdatedAt    time.Time      `json:"updated_at"`
 }
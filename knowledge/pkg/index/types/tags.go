@@ -0,0 +1,42 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NormalizeTags trims the tags and returns them sorted, without empty tags and duplicates
+func NormalizeTags(tags ...string) []string {
+	normalized := make([]string, 0, len(tags))
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// AddTags adds the tags to the file's tags
+func (f *File) AddTags(tags ...string) {
+	f.Tags = NormalizeTags(append(slices.Clone(f.Tags), tags...)...)
+}
+
+// RemoveTags removes the tags from the file's tags
+func (f *File) RemoveTags(tags ...string) {
+	remove := NormalizeTags(tags...)
+	f.Tags = slices.DeleteFunc(NormalizeTags(f.Tags...), func(t string) bool {
+		return slices.Contains(remove, t)
+	})
+}
+
+// UpdateFileTags replaces the tags of the file
+func (db *DB) UpdateFileTags(ctx context.Context, datasetID, fileID string, tags []string) error {
+	err := db.WithContext(ctx).Model(&File{ID: fileID, Dataset: datasetID}).Select("tags").Updates(&File{Tags: tags}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update tags of file %q: %w", fileID, err)
+	}
+	return nil
+}
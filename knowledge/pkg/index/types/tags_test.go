@@ -0,0 +1,21 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileTags(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, NormalizeTags(" b", "a", "", "b "))
+
+	f := File{Tags: []string{"runbook"}}
+	f.AddTags("oncall", "runbook", " k8s ")
+	assert.Equal(t, []string{"k8s", "oncall", "runbook"}, f.Tags)
+
+	f.RemoveTags("runbook", "unknown")
+	assert.Equal(t, []string{"k8s", "oncall"}, f.Tags)
+
+	f.RemoveTags("k8s", "oncall")
+	assert.Empty(t, f.Tags)
+}
@@ -25,6 +25,9 @@ func (db *DB) DoAutoMigrate() error {
 		&Dataset{},
 		&File{},
 		&Document{},
+		&VocabularyTerm{},
+		&CachedEmbedding{},
+		&IngestionCheckpoint{},
 	)
 }
 
@@ -220,6 +223,9 @@ func (db *DB) FindFilesByMetadata(ctx context.Context, dataset string, metadata
 	if metadata.Checksum != "" {
 		tx = tx.Where("checksum = ?", metadata.Checksum)
 	}
+	if metadata.ETag != "" {
+		tx = tx.Where("etag = ?", metadata.ETag)
+	}
 
 	if firstOnly {
 		var file File
@@ -0,0 +1,63 @@
+package types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddVocabulary adds the term frequencies to the vocabulary of the dataset
+func (db *DB) AddVocabulary(ctx context.Context, datasetID string, frequencies map[string]int) error {
+	if len(frequencies) == 0 {
+		return nil
+	}
+
+	terms := make([]VocabularyTerm, 0, len(frequencies))
+	for t, f := range frequencies {
+		terms = append(terms, VocabularyTerm{Dataset: datasetID, Term: t, Frequency: f})
+	}
+
+	added := "excluded.frequency"
+	if db.GormDB.Dialector.Name() == "mysql" {
+		// MySQL has no excluded table, but refers to the inserted values via VALUES() in ON DUPLICATE KEY UPDATE
+		added = "VALUES(frequency)"
+	}
+
+	err := db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dataset"}, {Name: "term"}},
+		DoUpdates: clause.Assignments(map[string]any{"frequency": gorm.Expr("vocabulary_terms.frequency + " + added)}),
+	}).CreateInBatches(terms, 500).Error
+	if err != nil {
+		return fmt.Errorf("failed to add vocabulary of dataset %q: %w", datasetID, err)
+	}
+	return nil
+}
+
+// GetVocabulary returns the term frequencies of the dataset
+func (db *DB) GetVocabulary(ctx context.Context, datasetID string) (map[string]int, error) {
+	var terms []VocabularyTerm
+	if err := db.WithContext(ctx).Where("dataset = ?", datasetID).Find(&terms).Error; err != nil {
+		return nil, fmt.Errorf("failed to get vocabulary of dataset %q: %w", datasetID, err)
+	}
+
+	frequencies := make(map[string]int, len(terms))
+	for _, t := range terms {
+		frequencies[t.Term] = t.Frequency
+	}
+	return frequencies, nil
+}
+
+// CountVocabularyTerms adds the frequencies of the words (letters only, lowercased) in the text to the frequencies map
+func CountVocabularyTerms(text string, frequencies map[string]int) {
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if n := utf8.RuneCountInString(w); n < 2 || n > 40 {
+			continue
+		}
+		frequencies[w]++
+	}
+}
@@ -0,0 +1,184 @@
+// Package metrics records Prometheus metrics of ingestion, embedding, retrieval and the vector store.
+// Nothing is recorded until the metrics are enabled, e.g. by the server exposing them.
+package metrics
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/defaults"
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "knowledge"
+
+const (
+	statusSuccess = "success"
+	statusError   = "error"
+)
+
+var (
+	enabled atomic.Bool
+
+	registry = prometheus.NewRegistry()
+
+	filesIngested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingested_files_total",
+		Help:      "Number of files ingested, by status (success, error).",
+	}, []string{"status"})
+
+	documentsIngested = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingested_documents_total",
+		Help:      "Number of documents (chunks) added to the vector store.",
+	})
+
+	ingestionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ingestion_duration_seconds",
+		Help:      "Duration of the ingestion of a file.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12), // 100ms - ~3.5m
+	})
+
+	embeddingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "embedding_request_duration_seconds",
+		Help:      "Duration of requests to the embedding model provider, by provider. A request may embed a batch of texts.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms - ~20s
+	}, []string{"provider"})
+
+	embeddingErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "embedding_request_errors_total",
+		Help:      "Number of failed requests to the embedding model provider, by provider.",
+	}, []string{"provider"})
+
+	embeddedTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "embedded_tokens_total",
+		Help:      "Number of tokens embedded, by provider. Estimated with the " + defaults.TokenEncoding + " encoding, since providers tokenize differently.",
+	}, []string{"provider"})
+
+	retrievals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retrievals_total",
+		Help:      "Number of retrievals, by status (success, error).",
+	}, []string{"status"})
+
+	retrievalDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "retrieval_duration_seconds",
+		Help:      "Duration of a retrieval, including query modifiers, embedding the queries and postprocessing.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12), // 10ms - ~20s
+	})
+
+	vectorStoreErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vectorstore_errors_total",
+		Help:      "Number of failed vector store operations, by operation.",
+	}, []string{"operation"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		filesIngested,
+		documentsIngested,
+		ingestionDuration,
+		embeddingDuration,
+		embeddingErrors,
+		embeddedTokens,
+		retrievals,
+		retrievalDuration,
+		vectorStoreErrors,
+	)
+}
+
+// Enable starts recording metrics
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled returns true if metrics are recorded
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Handler returns the HTTP handler exposing the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveIngestion records the ingestion of a file that started at the given time
+func ObserveIngestion(start time.Time, numDocuments int, err error) {
+	if !Enabled() {
+		return
+	}
+	filesIngested.WithLabelValues(status(err)).Inc()
+	if err == nil {
+		documentsIngested.Add(float64(numDocuments))
+		ingestionDuration.Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveEmbedding records a request to the embedding model provider that started at the given time
+func ObserveEmbedding(provider string, texts []string, start time.Time, err error) {
+	if !Enabled() {
+		return
+	}
+	embeddingDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		embeddingErrors.WithLabelValues(provider).Inc()
+		return
+	}
+	if tk, err := tokenizer(); err == nil {
+		var n int
+		for _, text := range texts {
+			n += len(tk.EncodeOrdinary(text))
+		}
+		embeddedTokens.WithLabelValues(provider).Add(float64(n))
+	}
+}
+
+// ObserveRetrieval records a retrieval that started at the given time
+func ObserveRetrieval(start time.Time, err error) {
+	if !Enabled() {
+		return
+	}
+	retrievals.WithLabelValues(status(err)).Inc()
+	if err == nil {
+		retrievalDuration.Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveVectorStoreError records a failed vector store operation, if err is not nil
+func ObserveVectorStoreError(operation string, err error) {
+	if !Enabled() || err == nil {
+		return
+	}
+	vectorStoreErrors.WithLabelValues(operation).Inc()
+}
+
+func status(err error) string {
+	if err != nil {
+		return statusError
+	}
+	return statusSuccess
+}
+
+var tokenizer = sync.OnceValues(func() (*tiktoken.Tiktoken, error) {
+	tk, err := tiktoken.GetEncoding(defaults.TokenEncoding)
+	if err != nil {
+		slog.Warn("Failed to load tokenizer, not counting embedded tokens", "error", err)
+		return nil, err
+	}
+	return tk, nil
+})
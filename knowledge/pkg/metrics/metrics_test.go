@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserve(t *testing.T) {
+	// nothing is recorded until the metrics are enabled
+	ObserveIngestion(time.Now(), 3, nil)
+	assert.Zero(t, testutil.ToFloat64(documentsIngested))
+
+	Enable()
+
+	ObserveIngestion(time.Now(), 3, nil)
+	ObserveIngestion(time.Now(), 0, errors.New("failed"))
+	assert.Equal(t, float64(3), testutil.ToFloat64(documentsIngested))
+	assert.Equal(t, float64(1), testutil.ToFloat64(filesIngested.WithLabelValues(statusSuccess)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(filesIngested.WithLabelValues(statusError)))
+
+	ObserveEmbedding("openai", []string{"foo"}, time.Now(), errors.New("rate limited"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(embeddingErrors.WithLabelValues("openai")))
+	assert.Equal(t, 1, testutil.CollectAndCount(embeddingDuration))
+
+	ObserveRetrieval(time.Now(), nil)
+	assert.Equal(t, float64(1), testutil.ToFloat64(retrievals.WithLabelValues(statusSuccess)))
+
+	ObserveVectorStoreError("add_documents", nil)
+	ObserveVectorStoreError("add_documents", errors.New("connection refused"))
+	assert.Equal(t, float64(1), testutil.ToFloat64(vectorStoreErrors.WithLabelValues("add_documents")))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `knowledge_ingested_files_total{status="error"} 1`)
+	assert.Contains(t, string(body), `knowledge_vectorstore_errors_total{operation="add_documents"} 1`)
+}
@@ -0,0 +1,83 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+type azureContainer struct {
+	client    *azblob.Client
+	container string
+}
+
+func openAzure(_ context.Context, container string) (Bucket, error) {
+	if cs := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); cs != "" {
+		client, err := azblob.NewClientFromConnectionString(cs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Blob Storage client from connection string: %w", err)
+		}
+		return &azureContainer{client: client, container: container}, nil
+	}
+
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	if account == "" {
+		return nil, fmt.Errorf("either AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_ACCOUNT must be set for az:// URLs")
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Azure credentials: %w", err)
+	}
+	client, err := azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", account), cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+	}
+	return &azureContainer{client: client, container: container}, nil
+}
+
+func (c *azureContainer) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	pager := c.client.NewListBlobsFlatPager(c.container, &azblob.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list blobs in Azure container %q: %w", c.container, err)
+		}
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			o := Object{Key: *item.Name}
+			if p := item.Properties; p != nil {
+				if p.ContentLength != nil {
+					o.Size = *p.ContentLength
+				}
+				if p.LastModified != nil {
+					o.ModifiedAt = *p.LastModified
+				}
+				if p.ETag != nil {
+					o.ETag = normalizeETag(string(*p.ETag))
+				}
+			}
+			if err := fn(o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *azureContainer) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.client.DownloadStream(ctx, c.container, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download blob %q from Azure container %q: %w", key, c.container, err)
+	}
+	return resp.Body, nil
+}
+
+func (c *azureContainer) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+type gcsBucket struct {
+	client *storage.Client
+	bucket *storage.BucketHandle
+	name   string
+}
+
+func openGCS(ctx context.Context, bucket string) (Bucket, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+	return &gcsBucket{client: client, bucket: client.Bucket(bucket), name: bucket}, nil
+}
+
+func (b *gcsBucket) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	it := b.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list objects in GCS bucket %q: %w", b.name, err)
+		}
+		err = fn(Object{
+			Key:        attrs.Name,
+			Size:       attrs.Size,
+			ModifiedAt: attrs.Updated,
+			ETag:       normalizeETag(attrs.Etag),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *gcsBucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := b.bucket.Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %q from GCS bucket %q: %w", key, b.name, err)
+	}
+	return r, nil
+}
+
+func (b *gcsBucket) Close() error {
+	return b.client.Close()
+}
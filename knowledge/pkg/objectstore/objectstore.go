@@ -0,0 +1,102 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported URL schemes, e.g. s3://bucket/prefix
+const (
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+	SchemeAzure = "az"
+)
+
+// Object is an object (file) in a bucket
+type Object struct {
+	Key        string
+	Size       int64
+	ModifiedAt time.Time
+	ETag       string // entity tag of the object's current version, without quotes - changes whenever the content changes
+}
+
+// Bucket is an object store bucket (S3 bucket, GCS bucket or Azure Blob Storage container)
+type Bucket interface {
+	// List calls fn for each object with the given key prefix
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+	// Open opens the object for reading
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Close() error
+}
+
+// Location is a parsed object store URL
+type Location struct {
+	Scheme string
+	Bucket string // bucket or, for Azure, container
+	Prefix string // key prefix, without leading slash
+}
+
+// IsURL reports whether the path is an object store URL with a supported scheme
+func IsURL(path string) bool {
+	for _, scheme := range []string{SchemeS3, SchemeGCS, SchemeAzure} {
+		if strings.HasPrefix(path, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseURL parses an object store URL, e.g. s3://bucket/prefix, gs://bucket/prefix or az://container/prefix
+func ParseURL(rawURL string) (*Location, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store URL %q: %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case SchemeS3, SchemeGCS, SchemeAzure:
+	default:
+		return nil, fmt.Errorf("unsupported object store URL %q (supported: s3://, gs://, az://)", rawURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid object store URL %q: missing bucket", rawURL)
+	}
+	return &Location{Scheme: u.Scheme, Bucket: u.Host, Prefix: strings.TrimPrefix(u.Path, "/")}, nil
+}
+
+// URL returns the URL of the object with the given key in the location's bucket
+func (l *Location) URL(key string) string {
+	return l.Scheme + "://" + l.Bucket + "/" + key
+}
+
+// String returns the URL of the location
+func (l *Location) String() string {
+	return l.URL(l.Prefix)
+}
+
+// Open connects to the location's bucket, using the standard credential chain of the provider:
+//   - S3: environment (AWS_ACCESS_KEY_ID, ...), shared config and credentials files (AWS_PROFILE), web identity, ECS and EC2 instance roles.
+//     AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) selects an S3-compatible store, e.g. MinIO, and AWS_S3_USE_PATH_STYLE=true path-style addressing.
+//   - GCS: application default credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud login, metadata server).
+//   - Azure: AZURE_STORAGE_CONNECTION_STRING or, for the account AZURE_STORAGE_ACCOUNT, the default Azure credential chain
+//     (environment, workload identity, managed identity, Azure CLI).
+func Open(ctx context.Context, loc *Location) (Bucket, error) {
+	switch loc.Scheme {
+	case SchemeS3:
+		return openS3(ctx, loc.Bucket)
+	case SchemeGCS:
+		return openGCS(ctx, loc.Bucket)
+	case SchemeAzure:
+		return openAzure(ctx, loc.Bucket)
+	default:
+		return nil, fmt.Errorf("unsupported object store scheme %q", loc.Scheme)
+	}
+}
+
+// normalizeETag removes the quotes around an ETag, as returned by S3 and Azure
+func normalizeETag(etag string) string {
+	return strings.TrimPrefix(strings.Trim(etag, `"`), `W/"`)
+}
@@ -0,0 +1,34 @@
+package objectstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURL(t *testing.T) {
+	loc, err := ParseURL("s3://my-bucket/docs/guides")
+	require.NoError(t, err)
+	assert.Equal(t, &Location{Scheme: SchemeS3, Bucket: "my-bucket", Prefix: "docs/guides"}, loc)
+	assert.Equal(t, "s3://my-bucket/docs/guides/a.md", loc.URL("docs/guides/a.md"))
+	assert.Equal(t, "s3://my-bucket/docs/guides", loc.String())
+
+	loc, err = ParseURL("az://container")
+	require.NoError(t, err)
+	assert.Equal(t, &Location{Scheme: SchemeAzure, Bucket: "container"}, loc)
+
+	assert.True(t, IsURL("gs://bucket/prefix"))
+	assert.False(t, IsURL("/data/s3://bucket"))
+
+	for _, u := range []string{"https://example.com/a", "s3:///prefix", "ftp://bucket/a"} {
+		_, err := ParseURL(u)
+		assert.Error(t, err, u)
+	}
+}
+
+func TestNormalizeETag(t *testing.T) {
+	assert.Equal(t, "9b2cf535f27731c974343645a3985328", normalizeETag(`"9b2cf535f27731c974343645a3985328"`))
+	assert.Equal(t, "0x8D4BCC2E4835CD0", normalizeETag(`W/"0x8D4BCC2E4835CD0"`))
+	assert.Equal(t, "CKih16GjycICEAE=", normalizeETag("CKih16GjycICEAE="))
+}
@@ -0,0 +1,73 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type s3Bucket struct {
+	client *s3.Client
+	bucket string
+}
+
+func openS3(ctx context.Context, bucket string) (Bucket, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	pathStyle, _ := strconv.ParseBool(os.Getenv("AWS_S3_USE_PATH_STYLE"))
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = pathStyle
+	})
+	return &s3Bucket{client: client, bucket: bucket}, nil
+}
+
+func (b *s3Bucket) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list objects in S3 bucket %q: %w", b.bucket, err)
+		}
+		for _, o := range page.Contents {
+			err := fn(Object{
+				Key:        aws.ToString(o.Key),
+				Size:       aws.ToInt64(o.Size),
+				ModifiedAt: aws.ToTime(o.LastModified),
+				ETag:       normalizeETag(aws.ToString(o.ETag)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *s3Bucket) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %q from S3 bucket %q: %w", key, b.bucket, err)
+	}
+	return out.Body, nil
+}
+
+func (b *s3Bucket) Close() error {
+	return nil
+}
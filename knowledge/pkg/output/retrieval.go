@@ -0,0 +1,203 @@
+package output
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"     // the full retrieval response
+	FormatMarkdown Format = "markdown" // the chunks with their sources and a numbered citation list, e.g. as context block of a prompt
+	FormatXML      Format = "xml"      // the chunks as <document> elements with their sources as attributes, followed by the citations
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatMarkdown, FormatXML:
+		return f, nil
+	case "md":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unsupported output format %q, expected one of json, markdown, xml", s)
+}
+
+// WriteRetrievalResponse writes the retrieval response in the format. Markdown and XML reference the citations of the response
+// (see the citations postprocessor) by their index - documents without citation are rendered without reference.
+func WriteRetrievalResponse(w io.Writer, resp *types.RetrievalResponse, format Format) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, resp)
+	case FormatXML:
+		return writeXML(w, resp)
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// resultDocuments returns the documents of all subqueries, without duplicates
+func resultDocuments(resp *types.RetrievalResponse) []vs.Document {
+	var docs []vs.Document
+	seen := map[string]struct{}{}
+	for _, r := range resp.Responses {
+		for _, doc := range r.ResultDocuments {
+			if _, ok := seen[doc.ID]; ok && doc.ID != "" {
+				continue
+			}
+			seen[doc.ID] = struct{}{}
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+func metadataString(metadata map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := metadata[k]; ok && v != nil {
+			if s := fmt.Sprint(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func citationIndex(doc vs.Document) int {
+	switch v := doc.Metadata["citation"].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+func writeMarkdown(w io.Writer, resp *types.RetrievalResponse) error {
+	var sb strings.Builder
+
+	for i, doc := range resultDocuments(resp) {
+		if i > 0 {
+			sb.WriteString("\n---\n\n")
+		}
+		sb.WriteString("###")
+		if idx := citationIndex(doc); idx > 0 {
+			fmt.Fprintf(&sb, " [%d]", idx)
+		}
+		source := metadataString(doc.Metadata, "filename", "title", "source")
+		if source == "" {
+			source = doc.ID
+		}
+		sb.WriteString(" " + source)
+		if page := metadataString(doc.Metadata, "page", "pages"); page != "" {
+			sb.WriteString(", page " + page)
+		}
+		sb.WriteString("\n\n" + strings.TrimSpace(doc.Content) + "\n")
+	}
+
+	if len(resp.Citations) > 0 {
+		sb.WriteString("\n## Sources\n\n")
+		for _, c := range resp.Citations {
+			fmt.Fprintf(&sb, "%d. %s\n", c.Index, citationLabel(c))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// citationLabel describes the citation as e.g. "Deployment Guide (guide.pdf, pages 3, 4) - /docs/guide.pdf"
+func citationLabel(c types.Citation) string {
+	label := c.Title
+	var details []string
+	if c.Filename != "" && c.Filename != c.Title {
+		details = append(details, c.Filename)
+	}
+	switch len(c.Pages) {
+	case 0:
+	case 1:
+		details = append(details, "page "+c.Pages[0])
+	default:
+		details = append(details, "pages "+strings.Join(c.Pages, ", "))
+	}
+	if label == "" && len(details) > 0 {
+		label, details = details[0], details[1:]
+	}
+	if len(details) > 0 {
+		label += " (" + strings.Join(details, ", ") + ")"
+	}
+	if c.Source != "" {
+		if label == "" {
+			return c.Source
+		}
+		label += " - " + c.Source
+	}
+	return label
+}
+
+type xmlRetrieval struct {
+	XMLName   xml.Name      `xml:"retrieval"`
+	Query     string        `xml:"query,attr"`
+	Documents []xmlDocument `xml:"documents>document"`
+	Citations []xmlCitation `xml:"citations>citation,omitempty"`
+}
+
+type xmlDocument struct {
+	ID       string `xml:"id,attr"`
+	Citation int    `xml:"citation,attr,omitempty"`
+	Filename string `xml:"filename,attr,omitempty"`
+	Page     string `xml:"page,attr,omitempty"`
+	Source   string `xml:"source,attr,omitempty"`
+	Content  string `xml:",chardata"`
+}
+
+type xmlCitation struct {
+	Index    int    `xml:"index,attr"`
+	Title    string `xml:"title,attr,omitempty"`
+	Filename string `xml:"filename,attr,omitempty"`
+	Pages    string `xml:"pages,attr,omitempty"`
+	Source   string `xml:"source,attr,omitempty"`
+}
+
+func writeXML(w io.Writer, resp *types.RetrievalResponse) error {
+	out := xmlRetrieval{Query: resp.Query}
+	for _, doc := range resultDocuments(resp) {
+		out.Documents = append(out.Documents, xmlDocument{
+			ID:       doc.ID,
+			Citation: citationIndex(doc),
+			Filename: metadataString(doc.Metadata, "filename"),
+			Page:     metadataString(doc.Metadata, "page", "pages"),
+			Source:   metadataString(doc.Metadata, "source", "url", "absPath"),
+			Content:  strings.TrimSpace(doc.Content),
+		})
+	}
+	for _, c := range resp.Citations {
+		out.Citations = append(out.Citations, xmlCitation{
+			Index:    c.Index,
+			Title:    c.Title,
+			Filename: c.Filename,
+			Pages:    strings.Join(c.Pages, ", "),
+			Source:   c.Source,
+		})
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
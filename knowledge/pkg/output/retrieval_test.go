@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRetrievalResponse() *types.RetrievalResponse {
+	guide := vs.Document{ID: "1", Content: "Install with brew.\n", Metadata: map[string]any{"filename": "guide.pdf", "page": 3, "citation": 1}}
+	readme := vs.Document{ID: "2", Content: "Supports <md> & pdf", Metadata: map[string]any{"filename": "README.md", "citation": 2}}
+	return &types.RetrievalResponse{
+		Query: "how to install",
+		Responses: []types.Response{
+			{Query: "how to install", ResultDocuments: []vs.Document{guide, readme}},
+			{Query: "installation", ResultDocuments: []vs.Document{guide}},
+		},
+		Citations: []types.Citation{
+			{Index: 1, Filename: "guide.pdf", Title: "Guide", Pages: []string{"3"}, Source: "/docs/guide.pdf", DocumentIDs: []string{"1"}},
+			{Index: 2, Filename: "README.md", Source: "/docs/README.md", DocumentIDs: []string{"2"}},
+		},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatJSON, "JSON": FormatJSON, "md": FormatMarkdown, "markdown": FormatMarkdown, "xml": FormatXML} {
+		f, err := ParseFormat(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, f)
+	}
+	_, err := ParseFormat("yaml")
+	assert.Error(t, err)
+}
+
+func TestWriteRetrievalResponseMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRetrievalResponse(&buf, testRetrievalResponse(), FormatMarkdown))
+	assert.Equal(t, `### [1] guide.pdf, page 3
+
+Install with brew.
+
+---
+
+### [2] README.md
+
+Supports <md> & pdf
+
+## Sources
+
+1. Guide (guide.pdf, page 3) - /docs/guide.pdf
+2. README.md - /docs/README.md
+`, buf.String())
+}
+
+func TestWriteRetrievalResponseXML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRetrievalResponse(&buf, testRetrievalResponse(), FormatXML))
+
+	var out xmlRetrieval
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "how to install", out.Query)
+	require.Len(t, out.Documents, 2) // deduplicated across subqueries
+	assert.Equal(t, xmlDocument{ID: "1", Citation: 1, Filename: "guide.pdf", Page: "3", Content: "Install with brew."}, out.Documents[0])
+	assert.Equal(t, "Supports <md> & pdf", out.Documents[1].Content)
+	require.Len(t, out.Citations, 2)
+	assert.Equal(t, "/docs/guide.pdf", out.Citations[0].Source)
+}
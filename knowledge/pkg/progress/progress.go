@@ -0,0 +1,79 @@
+// Package progress reports machine-readable events about the progress of an ingestion, e.g. for orchestrators
+// showing progress bars and per-file errors. The reporter is passed along in the context.
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventFileStarted    EventType = "file_started"    // the ingestion of a file started
+	EventChunksProduced EventType = "chunks_produced" // the file was loaded and split into chunks (documents)
+	EventEmbedded       EventType = "embedded"        // the chunks were embedded
+	EventStored         EventType = "stored"          // the chunks were stored and the file was recorded in the index - the file is done
+	EventSkipped        EventType = "skipped"         // the file was skipped, e.g. as duplicate or unsupported file type
+	EventFailed         EventType = "failed"          // the ingestion of the file failed
+)
+
+// Event is a single progress event of the ingestion of a file
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    EventType `json:"type"`
+	Dataset string    `json:"dataset"`
+	File    string    `json:"file"`             // absolute path of the file, if known, or its name
+	Chunks  int       `json:"chunks,omitempty"` // number of chunks, once known
+	Reason  string    `json:"reason,omitempty"` // reason for skipped and failed events
+}
+
+type Reporter interface {
+	Report(event Event)
+}
+
+// JSONReporter writes the events as newline-delimited JSON (NDJSON), one event per line
+type JSONReporter struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+func NewJSONReporter(w io.Writer) *JSONReporter {
+	return &JSONReporter{enc: json.NewEncoder(w)}
+}
+
+func (r *JSONReporter) Report(event Event) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_ = r.enc.Encode(event) // progress events are best effort, they must not fail the ingestion
+}
+
+type contextKey string
+
+const reporterKey = contextKey("progressReporter")
+
+func ToCtx(ctx context.Context, reporter Reporter) context.Context {
+	return context.WithValue(ctx, reporterKey, reporter)
+}
+
+func FromCtx(ctx context.Context) Reporter {
+	if r, ok := ctx.Value(reporterKey).(Reporter); ok {
+		return r
+	}
+	return nil
+}
+
+// Report reports the event to the reporter from the context, if any
+func Report(ctx context.Context, event Event) {
+	r := FromCtx(ctx)
+	if r == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	r.Report(event)
+}
@@ -0,0 +1,37 @@
+package progress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONReporter(t *testing.T) {
+	// no reporter in the context -> nothing happens
+	Report(context.Background(), Event{Type: EventFileStarted})
+
+	var buf bytes.Buffer
+	ctx := ToCtx(context.Background(), NewJSONReporter(&buf))
+
+	Report(ctx, Event{Type: EventFileStarted, Dataset: "foo", File: "/data/a.md"})
+	Report(ctx, Event{Type: EventChunksProduced, Dataset: "foo", File: "/data/a.md", Chunks: 3})
+	Report(ctx, Event{Type: EventFailed, Dataset: "foo", File: "/data/b.pdf", Reason: "rate limited"})
+
+	var events []Event
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		assert.False(t, e.Time.IsZero())
+		events = append(events, e)
+	}
+	require.Len(t, events, 3)
+	assert.Equal(t, EventChunksProduced, events[1].Type)
+	assert.Equal(t, 3, events[1].Chunks)
+	assert.Equal(t, "rate limited", events[2].Reason)
+}
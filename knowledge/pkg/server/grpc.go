@@ -0,0 +1,373 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/obot-platform/tools/knowledge/pkg/server/knowledgepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GRPCService implements the knowledgepb.KnowledgeServer on top of the same service functions as the REST API
+type GRPCService struct {
+	knowledgepb.UnimplementedKnowledgeServer
+
+	s *Server
+}
+
+// StartGRPC serves the gRPC API on the configured gRPC address until the context is canceled,
+// then waits for in-flight calls to complete
+func (s *Server) StartGRPC(ctx context.Context) error {
+	if err := s.cfg.Validate(); err != nil {
+		return err
+	}
+
+	lis, err := net.Listen("tcp", s.cfg.GRPCAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.GRPCAddress, err)
+	}
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(logUnaryCalls, s.authenticateUnary), grpc.ChainStreamInterceptor(logStreamCalls, s.authenticateStream))
+	knowledgepb.RegisterKnowledgeServer(srv, &GRPCService{s: s})
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Starting knowledge gRPC server", "address", s.cfg.GRPCAddress)
+		errCh <- srv.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down knowledge gRPC server")
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		srv.Stop()
+	}
+	return <-errCh
+}
+
+func (g *GRPCService) CreateDataset(ctx context.Context, req *knowledgepb.CreateDatasetRequest) (*knowledgepb.Dataset, error) {
+	ds, err := g.s.createDataset(ctx, CreateDatasetRequest{
+		ID:               req.GetId(),
+		DistanceFunction: req.GetDistanceFunction(),
+		ErrOnExists:      req.GetErrOnExists(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return datasetToProto(*ds)
+}
+
+func (g *GRPCService) GetDataset(ctx context.Context, req *knowledgepb.GetDatasetRequest) (*knowledgepb.Dataset, error) {
+	ds, err := g.s.getDataset(ctx, req.GetId(), &types.DatasetGetOpts{IncludeFiles: req.GetIncludeFiles()})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return datasetToProto(*ds)
+}
+
+func (g *GRPCService) ListDatasets(ctx context.Context, _ *knowledgepb.ListDatasetsRequest) (*knowledgepb.ListDatasetsResponse, error) {
+	datasets, err := g.s.Datastore.ListDatasets(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &knowledgepb.ListDatasetsResponse{Datasets: make([]*knowledgepb.Dataset, 0, len(datasets))}
+	for _, ds := range datasets {
+		pbds, err := datasetToProto(ds)
+		if err != nil {
+			return nil, err
+		}
+		resp.Datasets = append(resp.Datasets, pbds)
+	}
+	return resp, nil
+}
+
+func (g *GRPCService) DeleteDataset(ctx context.Context, req *knowledgepb.DeleteDatasetRequest) (*knowledgepb.DeleteDatasetResponse, error) {
+	if err := g.s.deleteDataset(ctx, req.GetId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &knowledgepb.DeleteDatasetResponse{}, nil
+}
+
+// Ingest receives the file info followed by the file content in chunks, then ingests the file once the client closed the stream
+func (g *GRPCService) Ingest(stream knowledgepb.Knowledge_IngestServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return status.Error(codes.InvalidArgument, "file info is required")
+		}
+		return err
+	}
+	info := first.GetInfo()
+	if info == nil {
+		return status.Error(codes.InvalidArgument, "the first message must contain the file info")
+	}
+
+	var content []byte
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if msg.GetInfo() != nil {
+			return status.Error(codes.InvalidArgument, "only the first message may contain the file info")
+		}
+		content = append(content, msg.GetChunk()...)
+		if g.s.cfg.MaxRequestBytes > 0 && int64(len(content)) > g.s.cfg.MaxRequestBytes {
+			return status.Errorf(codes.ResourceExhausted, "file exceeds the maximum size of %d bytes", g.s.cfg.MaxRequestBytes)
+		}
+	}
+
+	ids, err := g.s.ingestFile(stream.Context(), info.GetDatasetId(), IngestRequest{
+		Filename:        info.GetFilename(),
+		Content:         content,
+		AbsolutePath:    info.GetAbsolutePath(),
+		Metadata:        info.GetMetadata().AsMap(),
+		Flow:            info.GetFlow(),
+		Deduplication:   info.GetDeduplication(),
+		Password:        info.GetPassword(),
+		ReuseEmbeddings: info.GetReuseEmbeddings(),
+		ReuseFiles:      info.GetReuseFiles(),
+		IndexContent:    info.GetIndexContent(),
+		BuildVocabulary: info.GetBuildVocabulary(),
+	})
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.SendAndClose(&knowledgepb.IngestResponse{DocumentIds: ids})
+}
+
+func (g *GRPCService) DeleteFile(ctx context.Context, req *knowledgepb.DeleteFileRequest) (*knowledgepb.DeleteFileResponse, error) {
+	if err := g.s.Datastore.DeleteFile(ctx, req.GetDatasetId(), req.GetFileId()); err != nil {
+		return nil, grpcError(err)
+	}
+	return &knowledgepb.DeleteFileResponse{}, nil
+}
+
+func (g *GRPCService) Retrieve(ctx context.Context, req *knowledgepb.RetrieveRequest) (*knowledgepb.RetrieveResponse, error) {
+	var filters map[string][]string
+	if len(req.GetFilters()) > 0 {
+		filters = make(map[string][]string, len(req.GetFilters()))
+		for k, v := range req.GetFilters() {
+			filters[k] = v.GetValues()
+		}
+	}
+
+	var history []querymodifiers.ConversationTurn
+	for _, turn := range req.GetHistory() {
+		history = append(history, querymodifiers.ConversationTurn{Role: turn.GetRole(), Content: turn.GetContent()})
+	}
+
+	resp, err := g.s.retrieve(ctx, RetrieveRequest{
+		Datasets: req.GetDatasetIds(),
+		Query:    req.GetQuery(),
+		TopK:     int(req.GetTopK()),
+		Keywords: req.GetKeywords(),
+		Filters:  filters,
+		History:  history,
+		Flow:     req.GetFlow(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return retrievalResponseToProto(resp)
+}
+
+// grpcError maps errors of the service functions to gRPC status errors
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case isNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	case isAlreadyExists(err):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func logUnaryCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	startTime := time.Now()
+	l := slog.With("method", info.FullMethod)
+	resp, err := handler(log.ToCtx(ctx, l), req)
+	logCall(l, startTime, err)
+	return resp, err
+}
+
+func logStreamCalls(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	startTime := time.Now()
+	l := slog.With("method", info.FullMethod)
+	err := handler(srv, &loggingStream{ServerStream: ss, ctx: log.ToCtx(ss.Context(), l)})
+	logCall(l, startTime, err)
+	return err
+}
+
+// authenticateUnary rejects calls without the configured bearer token in the "authorization" metadata
+func (s *Server) authenticateUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.checkToken(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticateStream is the stream variant of authenticateUnary
+func (s *Server) authenticateStream(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.checkToken(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (s *Server) checkToken(ctx context.Context) error {
+	if s.cfg.Token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		if validToken(authorization, s.cfg.Token) {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+func logCall(l *slog.Logger, startTime time.Time, err error) {
+	code := status.Code(err)
+	if code == codes.Internal || code == codes.Unknown {
+		l.Error("Call failed", "code", code, "error", err)
+		return
+	}
+	l.Debug("Handled call", "code", code, "duration", time.Since(startTime))
+}
+
+// loggingStream overrides the stream's context to carry the call logger
+type loggingStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggingStream) Context() context.Context {
+	return s.ctx
+}
+
+func datasetToProto(ds types.Dataset) (*knowledgepb.Dataset, error) {
+	metadata, err := toStruct(ds.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	pbds := &knowledgepb.Dataset{
+		Id:       ds.ID,
+		Metadata: metadata,
+	}
+
+	if ds.EmbeddingsProviderConfig != nil {
+		cfg, err := toStruct(ds.EmbeddingsProviderConfig.Config)
+		if err != nil {
+			return nil, err
+		}
+		pbds.EmbeddingsProviderConfig = &knowledgepb.EmbeddingsProviderConfig{
+			Name:   ds.EmbeddingsProviderConfig.Name,
+			Type:   ds.EmbeddingsProviderConfig.Type,
+			Config: cfg,
+		}
+	}
+
+	for _, f := range ds.Files {
+		docIDs := make([]string, 0, len(f.Documents))
+		for _, doc := range f.Documents {
+			docIDs = append(docIDs, doc.ID)
+		}
+		pbds.Files = append(pbds.Files, &knowledgepb.File{
+			Id:           f.ID,
+			Name:         f.Name,
+			AbsolutePath: f.AbsolutePath,
+			Size:         f.Size,
+			ModifiedAt:   timestamppb.New(f.ModifiedAt),
+			Checksum:     f.Checksum,
+			DocumentIds:  docIDs,
+		})
+	}
+	return pbds, nil
+}
+
+func retrievalResponseToProto(resp *dstypes.RetrievalResponse) (*knowledgepb.RetrieveResponse, error) {
+	pbresp := &knowledgepb.RetrieveResponse{
+		Query:      resp.Query,
+		DatasetIds: resp.Datasets,
+		Results:    make([]*knowledgepb.SubqueryResult, 0, len(resp.Responses)),
+	}
+	for _, r := range resp.Responses {
+		result := &knowledgepb.SubqueryResult{
+			Subquery:  r.Query,
+			Documents: make([]*knowledgepb.Document, 0, len(r.ResultDocuments)),
+		}
+		for _, doc := range r.ResultDocuments {
+			metadata, err := toStruct(doc.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			result.Documents = append(result.Documents, &knowledgepb.Document{
+				Id:              doc.ID,
+				Content:         doc.Content,
+				Metadata:        metadata,
+				SimilarityScore: doc.SimilarityScore,
+			})
+		}
+		pbresp.Results = append(pbresp.Results, result)
+	}
+	return pbresp, nil
+}
+
+// toStruct converts metadata to a protobuf Struct, going through JSON since structpb only supports JSON-like types
+// while metadata may contain anything, e.g. string slices or timestamps
+func toStruct(m map[string]any) (*structpb.Struct, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal metadata: %v", err)
+	}
+	var jm map[string]any
+	if err := json.Unmarshal(b, &jm); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unmarshal metadata: %v", err)
+	}
+	s, err := structpb.NewStruct(jm)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to convert metadata: %v", err)
+	}
+	return s, nil
+}
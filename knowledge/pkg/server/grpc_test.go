@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/server/knowledgepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// The requests are rejected before the datastore is used, so it's not needed here
+func TestGRPCValidation(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	knowledgepb.RegisterKnowledgeServer(srv, &GRPCService{s: NewServer(nil, nil, Config{MaxRequestBytes: 8})})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := knowledgepb.NewKnowledgeClient(conn)
+	ctx := context.Background()
+
+	_, err = client.CreateDataset(ctx, &knowledgepb.CreateDatasetRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Retrieve(ctx, &knowledgepb.RetrieveRequest{DatasetIds: []string{"foo"}, Query: " "})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Retrieve(ctx, &knowledgepb.RetrieveRequest{DatasetIds: []string{"foo"}, Query: "foo", Flow: "x"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ingest := func(msgs ...*knowledgepb.IngestRequest) error {
+		stream, err := client.Ingest(ctx)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			if err := stream.Send(msg); err != nil {
+				break // the server already failed, the status is returned by CloseAndRecv
+			}
+		}
+		_, err = stream.CloseAndRecv()
+		return err
+	}
+	info := &knowledgepb.IngestRequest{Payload: &knowledgepb.IngestRequest_Info{Info: &knowledgepb.IngestFileInfo{DatasetId: "foo"}}}
+	chunk := func(s string) *knowledgepb.IngestRequest {
+		return &knowledgepb.IngestRequest{Payload: &knowledgepb.IngestRequest_Chunk{Chunk: []byte(s)}}
+	}
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(ingest()), "no file info")
+	assert.Equal(t, codes.InvalidArgument, status.Code(ingest(chunk("foo"))), "chunk before file info")
+	assert.Equal(t, codes.InvalidArgument, status.Code(ingest(info, info)), "repeated file info")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(ingest(info, chunk("1234"), chunk("56789"))), "too large")
+	assert.Equal(t, codes.InvalidArgument, status.Code(ingest(info, chunk("foo"))), "missing filename")
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	s := NewServer(nil, nil, Config{Token: "secret"})
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(s.authenticateUnary), grpc.ChainStreamInterceptor(s.authenticateStream))
+	knowledgepb.RegisterKnowledgeServer(srv, &GRPCService{s: s})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	client := knowledgepb.NewKnowledgeClient(conn)
+	ctx := context.Background()
+
+	_, err = client.CreateDataset(ctx, &knowledgepb.CreateDatasetRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateDataset(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong"), &knowledgepb.CreateDatasetRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream, err := client.Ingest(ctx)
+	require.NoError(t, err)
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	_, err = client.CreateDataset(authCtx, &knowledgepb.CreateDatasetRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "passes on to the validation of the (empty) request")
+}
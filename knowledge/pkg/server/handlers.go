@@ -0,0 +1,113 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+)
+
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleListDatasets(w http.ResponseWriter, r *http.Request) {
+	datasets, err := s.Datastore.ListDatasets(r.Context())
+	if err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, datasets)
+}
+
+func (s *Server) handleCreateDataset(w http.ResponseWriter, r *http.Request) {
+	var req CreateDatasetRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	ds, err := s.createDataset(r.Context(), req)
+	if err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, ds)
+}
+
+func (s *Server) handleGetDataset(w http.ResponseWriter, r *http.Request) {
+	ds, err := s.getDataset(r.Context(), r.PathValue("id"), &types.DatasetGetOpts{IncludeFiles: r.URL.Query().Get("files") == "true"})
+	if err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ds)
+}
+
+func (s *Server) handleDeleteDataset(w http.ResponseWriter, r *http.Request) {
+	if err := s.deleteDataset(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
+	var req IngestRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	ids, err := s.ingestFile(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, IngestResponse{DocumentIDs: ids})
+}
+
+func (s *Server) handleDeleteFile(w http.ResponseWriter, r *http.Request) {
+	if err := s.Datastore.DeleteFile(r.Context(), r.PathValue("id"), r.PathValue("fileID")); err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	if err := s.Datastore.DeleteDocument(r.Context(), r.PathValue("documentID"), r.PathValue("id")); err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
+	var req RetrieveRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if id := r.PathValue("id"); id != "" {
+		req.Datasets = []string{id}
+	}
+	resp, err := s.retrieve(r.Context(), req)
+	if err != nil {
+		writeError(w, r, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// httpStatus maps errors of the service functions to HTTP status codes
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case isNotFound(err):
+		return http.StatusNotFound
+	case isAlreadyExists(err):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
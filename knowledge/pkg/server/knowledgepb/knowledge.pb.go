@@ -0,0 +1,1427 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: knowledge.proto
+
+package knowledgepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Dataset struct {
+	state                    protoimpl.MessageState    `protogen:"open.v1"`
+	Id                       string                    `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Metadata                 *structpb.Struct          `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	EmbeddingsProviderConfig *EmbeddingsProviderConfig `protobuf:"bytes,3,opt,name=embeddings_provider_config,json=embeddingsProviderConfig,proto3" json:"embeddings_provider_config,omitempty"`
+	Files                    []*File                   `protobuf:"bytes,4,rep,name=files,proto3" json:"files,omitempty"` // only set if requested
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *Dataset) Reset() {
+	*x = Dataset{}
+	mi := &file_knowledge_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Dataset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dataset) ProtoMessage() {}
+
+func (x *Dataset) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dataset.ProtoReflect.Descriptor instead.
+func (*Dataset) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Dataset) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Dataset) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Dataset) GetEmbeddingsProviderConfig() *EmbeddingsProviderConfig {
+	if x != nil {
+		return x.EmbeddingsProviderConfig
+	}
+	return nil
+}
+
+func (x *Dataset) GetFiles() []*File {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type EmbeddingsProviderConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Config        *structpb.Struct       `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmbeddingsProviderConfig) Reset() {
+	*x = EmbeddingsProviderConfig{}
+	mi := &file_knowledge_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmbeddingsProviderConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmbeddingsProviderConfig) ProtoMessage() {}
+
+func (x *EmbeddingsProviderConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmbeddingsProviderConfig.ProtoReflect.Descriptor instead.
+func (*EmbeddingsProviderConfig) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *EmbeddingsProviderConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EmbeddingsProviderConfig) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EmbeddingsProviderConfig) GetConfig() *structpb.Struct {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type File struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	AbsolutePath  string                 `protobuf:"bytes,3,opt,name=absolute_path,json=absolutePath,proto3" json:"absolute_path,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	ModifiedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=modified_at,json=modifiedAt,proto3" json:"modified_at,omitempty"`
+	Checksum      string                 `protobuf:"bytes,6,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	DocumentIds   []string               `protobuf:"bytes,7,rep,name=document_ids,json=documentIds,proto3" json:"document_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	mi := &file_knowledge_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *File) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *File) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *File) GetAbsolutePath() string {
+	if x != nil {
+		return x.AbsolutePath
+	}
+	return ""
+}
+
+func (x *File) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *File) GetModifiedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ModifiedAt
+	}
+	return nil
+}
+
+func (x *File) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *File) GetDocumentIds() []string {
+	if x != nil {
+		return x.DocumentIds
+	}
+	return nil
+}
+
+type CreateDatasetRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DistanceFunction string                 `protobuf:"bytes,2,opt,name=distance_function,json=distanceFunction,proto3" json:"distance_function,omitempty"` // pgvector only: cosine, l2, inner_product
+	ErrOnExists      bool                   `protobuf:"varint,3,opt,name=err_on_exists,json=errOnExists,proto3" json:"err_on_exists,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CreateDatasetRequest) Reset() {
+	*x = CreateDatasetRequest{}
+	mi := &file_knowledge_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDatasetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDatasetRequest) ProtoMessage() {}
+
+func (x *CreateDatasetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDatasetRequest.ProtoReflect.Descriptor instead.
+func (*CreateDatasetRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateDatasetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateDatasetRequest) GetDistanceFunction() string {
+	if x != nil {
+		return x.DistanceFunction
+	}
+	return ""
+}
+
+func (x *CreateDatasetRequest) GetErrOnExists() bool {
+	if x != nil {
+		return x.ErrOnExists
+	}
+	return false
+}
+
+type GetDatasetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	IncludeFiles  bool                   `protobuf:"varint,2,opt,name=include_files,json=includeFiles,proto3" json:"include_files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDatasetRequest) Reset() {
+	*x = GetDatasetRequest{}
+	mi := &file_knowledge_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDatasetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDatasetRequest) ProtoMessage() {}
+
+func (x *GetDatasetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDatasetRequest.ProtoReflect.Descriptor instead.
+func (*GetDatasetRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetDatasetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetDatasetRequest) GetIncludeFiles() bool {
+	if x != nil {
+		return x.IncludeFiles
+	}
+	return false
+}
+
+type ListDatasetsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatasetsRequest) Reset() {
+	*x = ListDatasetsRequest{}
+	mi := &file_knowledge_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatasetsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatasetsRequest) ProtoMessage() {}
+
+func (x *ListDatasetsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatasetsRequest.ProtoReflect.Descriptor instead.
+func (*ListDatasetsRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{5}
+}
+
+type ListDatasetsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Datasets      []*Dataset             `protobuf:"bytes,1,rep,name=datasets,proto3" json:"datasets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDatasetsResponse) Reset() {
+	*x = ListDatasetsResponse{}
+	mi := &file_knowledge_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDatasetsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDatasetsResponse) ProtoMessage() {}
+
+func (x *ListDatasetsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDatasetsResponse.ProtoReflect.Descriptor instead.
+func (*ListDatasetsResponse) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListDatasetsResponse) GetDatasets() []*Dataset {
+	if x != nil {
+		return x.Datasets
+	}
+	return nil
+}
+
+type DeleteDatasetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDatasetRequest) Reset() {
+	*x = DeleteDatasetRequest{}
+	mi := &file_knowledge_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDatasetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDatasetRequest) ProtoMessage() {}
+
+func (x *DeleteDatasetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDatasetRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDatasetRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteDatasetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteDatasetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDatasetResponse) Reset() {
+	*x = DeleteDatasetResponse{}
+	mi := &file_knowledge_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDatasetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDatasetResponse) ProtoMessage() {}
+
+func (x *DeleteDatasetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDatasetResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDatasetResponse) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{8}
+}
+
+type IngestRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*IngestRequest_Info
+	//	*IngestRequest_Chunk
+	Payload       isIngestRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestRequest) Reset() {
+	*x = IngestRequest{}
+	mi := &file_knowledge_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestRequest) ProtoMessage() {}
+
+func (x *IngestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestRequest.ProtoReflect.Descriptor instead.
+func (*IngestRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IngestRequest) GetPayload() isIngestRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *IngestRequest) GetInfo() *IngestFileInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*IngestRequest_Info); ok {
+			return x.Info
+		}
+	}
+	return nil
+}
+
+func (x *IngestRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*IngestRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isIngestRequest_Payload interface {
+	isIngestRequest_Payload()
+}
+
+type IngestRequest_Info struct {
+	Info *IngestFileInfo `protobuf:"bytes,1,opt,name=info,proto3,oneof"` // first message
+}
+
+type IngestRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"` // following messages
+}
+
+func (*IngestRequest_Info) isIngestRequest_Payload() {}
+
+func (*IngestRequest_Chunk) isIngestRequest_Payload() {}
+
+type IngestFileInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	DatasetId       string                 `protobuf:"bytes,1,opt,name=dataset_id,json=datasetId,proto3" json:"dataset_id,omitempty"`
+	Filename        string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	AbsolutePath    string                 `protobuf:"bytes,3,opt,name=absolute_path,json=absolutePath,proto3" json:"absolute_path,omitempty"` // recorded in the index, defaults to the filename
+	Metadata        *structpb.Struct       `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Flow            string                 `protobuf:"bytes,5,opt,name=flow,proto3" json:"flow,omitempty"` // ingestion flow from the server's flows file (default: the dataset's flow)
+	Deduplication   string                 `protobuf:"bytes,6,opt,name=deduplication,proto3" json:"deduplication,omitempty"`
+	Password        string                 `protobuf:"bytes,7,opt,name=password,proto3" json:"password,omitempty"`
+	ReuseEmbeddings bool                   `protobuf:"varint,8,opt,name=reuse_embeddings,json=reuseEmbeddings,proto3" json:"reuse_embeddings,omitempty"`
+	ReuseFiles      bool                   `protobuf:"varint,9,opt,name=reuse_files,json=reuseFiles,proto3" json:"reuse_files,omitempty"`
+	IndexContent    bool                   `protobuf:"varint,10,opt,name=index_content,json=indexContent,proto3" json:"index_content,omitempty"`
+	BuildVocabulary bool                   `protobuf:"varint,11,opt,name=build_vocabulary,json=buildVocabulary,proto3" json:"build_vocabulary,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *IngestFileInfo) Reset() {
+	*x = IngestFileInfo{}
+	mi := &file_knowledge_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestFileInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestFileInfo) ProtoMessage() {}
+
+func (x *IngestFileInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestFileInfo.ProtoReflect.Descriptor instead.
+func (*IngestFileInfo) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IngestFileInfo) GetDatasetId() string {
+	if x != nil {
+		return x.DatasetId
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetAbsolutePath() string {
+	if x != nil {
+		return x.AbsolutePath
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *IngestFileInfo) GetFlow() string {
+	if x != nil {
+		return x.Flow
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetDeduplication() string {
+	if x != nil {
+		return x.Deduplication
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *IngestFileInfo) GetReuseEmbeddings() bool {
+	if x != nil {
+		return x.ReuseEmbeddings
+	}
+	return false
+}
+
+func (x *IngestFileInfo) GetReuseFiles() bool {
+	if x != nil {
+		return x.ReuseFiles
+	}
+	return false
+}
+
+func (x *IngestFileInfo) GetIndexContent() bool {
+	if x != nil {
+		return x.IndexContent
+	}
+	return false
+}
+
+func (x *IngestFileInfo) GetBuildVocabulary() bool {
+	if x != nil {
+		return x.BuildVocabulary
+	}
+	return false
+}
+
+type IngestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DocumentIds   []string               `protobuf:"bytes,1,rep,name=document_ids,json=documentIds,proto3" json:"document_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResponse) Reset() {
+	*x = IngestResponse{}
+	mi := &file_knowledge_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResponse) ProtoMessage() {}
+
+func (x *IngestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResponse.ProtoReflect.Descriptor instead.
+func (*IngestResponse) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IngestResponse) GetDocumentIds() []string {
+	if x != nil {
+		return x.DocumentIds
+	}
+	return nil
+}
+
+type DeleteFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DatasetId     string                 `protobuf:"bytes,1,opt,name=dataset_id,json=datasetId,proto3" json:"dataset_id,omitempty"`
+	FileId        string                 `protobuf:"bytes,2,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFileRequest) Reset() {
+	*x = DeleteFileRequest{}
+	mi := &file_knowledge_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFileRequest) ProtoMessage() {}
+
+func (x *DeleteFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFileRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFileRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteFileRequest) GetDatasetId() string {
+	if x != nil {
+		return x.DatasetId
+	}
+	return ""
+}
+
+func (x *DeleteFileRequest) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+type DeleteFileResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFileResponse) Reset() {
+	*x = DeleteFileResponse{}
+	mi := &file_knowledge_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFileResponse) ProtoMessage() {}
+
+func (x *DeleteFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFileResponse.ProtoReflect.Descriptor instead.
+func (*DeleteFileResponse) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{13}
+}
+
+type RetrieveRequest struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	DatasetIds    []string                 `protobuf:"bytes,1,rep,name=dataset_ids,json=datasetIds,proto3" json:"dataset_ids,omitempty"`
+	Query         string                   `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	TopK          int32                    `protobuf:"varint,3,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	Keywords      []string                 `protobuf:"bytes,4,rep,name=keywords,proto3" json:"keywords,omitempty"`
+	Filters       map[string]*FilterValues `protobuf:"bytes,5,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	History       []*ConversationTurn      `protobuf:"bytes,6,rep,name=history,proto3" json:"history,omitempty"`
+	Flow          string                   `protobuf:"bytes,7,opt,name=flow,proto3" json:"flow,omitempty"` // retrieval flow from the server's flows file (default: the dataset's flow)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetrieveRequest) Reset() {
+	*x = RetrieveRequest{}
+	mi := &file_knowledge_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrieveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveRequest) ProtoMessage() {}
+
+func (x *RetrieveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveRequest) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RetrieveRequest) GetDatasetIds() []string {
+	if x != nil {
+		return x.DatasetIds
+	}
+	return nil
+}
+
+func (x *RetrieveRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *RetrieveRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *RetrieveRequest) GetKeywords() []string {
+	if x != nil {
+		return x.Keywords
+	}
+	return nil
+}
+
+func (x *RetrieveRequest) GetFilters() map[string]*FilterValues {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *RetrieveRequest) GetHistory() []*ConversationTurn {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *RetrieveRequest) GetFlow() string {
+	if x != nil {
+		return x.Flow
+	}
+	return ""
+}
+
+type FilterValues struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []string               `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"` // any of the values matches
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterValues) Reset() {
+	*x = FilterValues{}
+	mi := &file_knowledge_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterValues) ProtoMessage() {}
+
+func (x *FilterValues) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterValues.ProtoReflect.Descriptor instead.
+func (*FilterValues) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FilterValues) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type ConversationTurn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConversationTurn) Reset() {
+	*x = ConversationTurn{}
+	mi := &file_knowledge_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationTurn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationTurn) ProtoMessage() {}
+
+func (x *ConversationTurn) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationTurn.ProtoReflect.Descriptor instead.
+func (*ConversationTurn) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ConversationTurn) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ConversationTurn) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type RetrieveResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	DatasetIds    []string               `protobuf:"bytes,2,rep,name=dataset_ids,json=datasetIds,proto3" json:"dataset_ids,omitempty"`
+	Results       []*SubqueryResult      `protobuf:"bytes,3,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetrieveResponse) Reset() {
+	*x = RetrieveResponse{}
+	mi := &file_knowledge_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetrieveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveResponse) ProtoMessage() {}
+
+func (x *RetrieveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveResponse.ProtoReflect.Descriptor instead.
+func (*RetrieveResponse) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RetrieveResponse) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *RetrieveResponse) GetDatasetIds() []string {
+	if x != nil {
+		return x.DatasetIds
+	}
+	return nil
+}
+
+func (x *RetrieveResponse) GetResults() []*SubqueryResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type SubqueryResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subquery      string                 `protobuf:"bytes,1,opt,name=subquery,proto3" json:"subquery,omitempty"`
+	Documents     []*Document            `protobuf:"bytes,2,rep,name=documents,proto3" json:"documents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubqueryResult) Reset() {
+	*x = SubqueryResult{}
+	mi := &file_knowledge_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubqueryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubqueryResult) ProtoMessage() {}
+
+func (x *SubqueryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubqueryResult.ProtoReflect.Descriptor instead.
+func (*SubqueryResult) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SubqueryResult) GetSubquery() string {
+	if x != nil {
+		return x.Subquery
+	}
+	return ""
+}
+
+func (x *SubqueryResult) GetDocuments() []*Document {
+	if x != nil {
+		return x.Documents
+	}
+	return nil
+}
+
+type Document struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content         string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Metadata        *structpb.Struct       `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	SimilarityScore float32                `protobuf:"fixed32,4,opt,name=similarity_score,json=similarityScore,proto3" json:"similarity_score,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Document) Reset() {
+	*x = Document{}
+	mi := &file_knowledge_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Document) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Document) ProtoMessage() {}
+
+func (x *Document) ProtoReflect() protoreflect.Message {
+	mi := &file_knowledge_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Document.ProtoReflect.Descriptor instead.
+func (*Document) Descriptor() ([]byte, []int) {
+	return file_knowledge_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Document) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Document) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Document) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Document) GetSimilarityScore() float32 {
+	if x != nil {
+		return x.SimilarityScore
+	}
+	return 0
+}
+
+var File_knowledge_proto protoreflect.FileDescriptor
+
+const file_knowledge_proto_rawDesc = "" +
+	"\n" +
+	"\x0fknowledge.proto\x12\fknowledge.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xde\x01\n" +
+	"\aDataset\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x123\n" +
+	"\bmetadata\x18\x02 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12d\n" +
+	"\x1aembeddings_provider_config\x18\x03 \x01(\v2&.knowledge.v1.EmbeddingsProviderConfigR\x18embeddingsProviderConfig\x12(\n" +
+	"\x05files\x18\x04 \x03(\v2\x12.knowledge.v1.FileR\x05files\"s\n" +
+	"\x18EmbeddingsProviderConfig\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12/\n" +
+	"\x06config\x18\x03 \x01(\v2\x17.google.protobuf.StructR\x06config\"\xdf\x01\n" +
+	"\x04File\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12#\n" +
+	"\rabsolute_path\x18\x03 \x01(\tR\fabsolutePath\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12;\n" +
+	"\vmodified_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"modifiedAt\x12\x1a\n" +
+	"\bchecksum\x18\x06 \x01(\tR\bchecksum\x12!\n" +
+	"\fdocument_ids\x18\a \x03(\tR\vdocumentIds\"w\n" +
+	"\x14CreateDatasetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12+\n" +
+	"\x11distance_function\x18\x02 \x01(\tR\x10distanceFunction\x12\"\n" +
+	"\rerr_on_exists\x18\x03 \x01(\bR\verrOnExists\"H\n" +
+	"\x11GetDatasetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12#\n" +
+	"\rinclude_files\x18\x02 \x01(\bR\fincludeFiles\"\x15\n" +
+	"\x13ListDatasetsRequest\"I\n" +
+	"\x14ListDatasetsResponse\x121\n" +
+	"\bdatasets\x18\x01 \x03(\v2\x15.knowledge.v1.DatasetR\bdatasets\"&\n" +
+	"\x14DeleteDatasetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15DeleteDatasetResponse\"f\n" +
+	"\rIngestRequest\x122\n" +
+	"\x04info\x18\x01 \x01(\v2\x1c.knowledge.v1.IngestFileInfoH\x00R\x04info\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\x97\x03\n" +
+	"\x0eIngestFileInfo\x12\x1d\n" +
+	"\n" +
+	"dataset_id\x18\x01 \x01(\tR\tdatasetId\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12#\n" +
+	"\rabsolute_path\x18\x03 \x01(\tR\fabsolutePath\x123\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x12\n" +
+	"\x04flow\x18\x05 \x01(\tR\x04flow\x12$\n" +
+	"\rdeduplication\x18\x06 \x01(\tR\rdeduplication\x12\x1a\n" +
+	"\bpassword\x18\a \x01(\tR\bpassword\x12)\n" +
+	"\x10reuse_embeddings\x18\b \x01(\bR\x0freuseEmbeddings\x12\x1f\n" +
+	"\vreuse_files\x18\t \x01(\bR\n" +
+	"reuseFiles\x12#\n" +
+	"\rindex_content\x18\n" +
+	" \x01(\bR\findexContent\x12)\n" +
+	"\x10build_vocabulary\x18\v \x01(\bR\x0fbuildVocabulary\"3\n" +
+	"\x0eIngestResponse\x12!\n" +
+	"\fdocument_ids\x18\x01 \x03(\tR\vdocumentIds\"K\n" +
+	"\x11DeleteFileRequest\x12\x1d\n" +
+	"\n" +
+	"dataset_id\x18\x01 \x01(\tR\tdatasetId\x12\x17\n" +
+	"\afile_id\x18\x02 \x01(\tR\x06fileId\"\x14\n" +
+	"\x12DeleteFileResponse\"\xe5\x02\n" +
+	"\x0fRetrieveRequest\x12\x1f\n" +
+	"\vdataset_ids\x18\x01 \x03(\tR\n" +
+	"datasetIds\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x13\n" +
+	"\x05top_k\x18\x03 \x01(\x05R\x04topK\x12\x1a\n" +
+	"\bkeywords\x18\x04 \x03(\tR\bkeywords\x12D\n" +
+	"\afilters\x18\x05 \x03(\v2*.knowledge.v1.RetrieveRequest.FiltersEntryR\afilters\x128\n" +
+	"\ahistory\x18\x06 \x03(\v2\x1e.knowledge.v1.ConversationTurnR\ahistory\x12\x12\n" +
+	"\x04flow\x18\a \x01(\tR\x04flow\x1aV\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.knowledge.v1.FilterValuesR\x05value:\x028\x01\"&\n" +
+	"\fFilterValues\x12\x16\n" +
+	"\x06values\x18\x01 \x03(\tR\x06values\"@\n" +
+	"\x10ConversationTurn\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\x81\x01\n" +
+	"\x10RetrieveResponse\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1f\n" +
+	"\vdataset_ids\x18\x02 \x03(\tR\n" +
+	"datasetIds\x126\n" +
+	"\aresults\x18\x03 \x03(\v2\x1c.knowledge.v1.SubqueryResultR\aresults\"b\n" +
+	"\x0eSubqueryResult\x12\x1a\n" +
+	"\bsubquery\x18\x01 \x01(\tR\bsubquery\x124\n" +
+	"\tdocuments\x18\x02 \x03(\v2\x16.knowledge.v1.DocumentR\tdocuments\"\x94\x01\n" +
+	"\bDocument\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x123\n" +
+	"\bmetadata\x18\x03 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12)\n" +
+	"\x10similarity_score\x18\x04 \x01(\x02R\x0fsimilarityScore2\xb1\x04\n" +
+	"\tKnowledge\x12J\n" +
+	"\rCreateDataset\x12\".knowledge.v1.CreateDatasetRequest\x1a\x15.knowledge.v1.Dataset\x12D\n" +
+	"\n" +
+	"GetDataset\x12\x1f.knowledge.v1.GetDatasetRequest\x1a\x15.knowledge.v1.Dataset\x12U\n" +
+	"\fListDatasets\x12!.knowledge.v1.ListDatasetsRequest\x1a\".knowledge.v1.ListDatasetsResponse\x12X\n" +
+	"\rDeleteDataset\x12\".knowledge.v1.DeleteDatasetRequest\x1a#.knowledge.v1.DeleteDatasetResponse\x12E\n" +
+	"\x06Ingest\x12\x1b.knowledge.v1.IngestRequest\x1a\x1c.knowledge.v1.IngestResponse(\x01\x12O\n" +
+	"\n" +
+	"DeleteFile\x12\x1f.knowledge.v1.DeleteFileRequest\x1a .knowledge.v1.DeleteFileResponse\x12I\n" +
+	"\bRetrieve\x12\x1d.knowledge.v1.RetrieveRequest\x1a\x1e.knowledge.v1.RetrieveResponseBAZ?github.com/obot-platform/tools/knowledge/pkg/server/knowledgepbb\x06proto3"
+
+var (
+	file_knowledge_proto_rawDescOnce sync.Once
+	file_knowledge_proto_rawDescData []byte
+)
+
+func file_knowledge_proto_rawDescGZIP() []byte {
+	file_knowledge_proto_rawDescOnce.Do(func() {
+		file_knowledge_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_knowledge_proto_rawDesc), len(file_knowledge_proto_rawDesc)))
+	})
+	return file_knowledge_proto_rawDescData
+}
+
+var file_knowledge_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_knowledge_proto_goTypes = []any{
+	(*Dataset)(nil),                  // 0: knowledge.v1.Dataset
+	(*EmbeddingsProviderConfig)(nil), // 1: knowledge.v1.EmbeddingsProviderConfig
+	(*File)(nil),                     // 2: knowledge.v1.File
+	(*CreateDatasetRequest)(nil),     // 3: knowledge.v1.CreateDatasetRequest
+	(*GetDatasetRequest)(nil),        // 4: knowledge.v1.GetDatasetRequest
+	(*ListDatasetsRequest)(nil),      // 5: knowledge.v1.ListDatasetsRequest
+	(*ListDatasetsResponse)(nil),     // 6: knowledge.v1.ListDatasetsResponse
+	(*DeleteDatasetRequest)(nil),     // 7: knowledge.v1.DeleteDatasetRequest
+	(*DeleteDatasetResponse)(nil),    // 8: knowledge.v1.DeleteDatasetResponse
+	(*IngestRequest)(nil),            // 9: knowledge.v1.IngestRequest
+	(*IngestFileInfo)(nil),           // 10: knowledge.v1.IngestFileInfo
+	(*IngestResponse)(nil),           // 11: knowledge.v1.IngestResponse
+	(*DeleteFileRequest)(nil),        // 12: knowledge.v1.DeleteFileRequest
+	(*DeleteFileResponse)(nil),       // 13: knowledge.v1.DeleteFileResponse
+	(*RetrieveRequest)(nil),          // 14: knowledge.v1.RetrieveRequest
+	(*FilterValues)(nil),             // 15: knowledge.v1.FilterValues
+	(*ConversationTurn)(nil),         // 16: knowledge.v1.ConversationTurn
+	(*RetrieveResponse)(nil),         // 17: knowledge.v1.RetrieveResponse
+	(*SubqueryResult)(nil),           // 18: knowledge.v1.SubqueryResult
+	(*Document)(nil),                 // 19: knowledge.v1.Document
+	nil,                              // 20: knowledge.v1.RetrieveRequest.FiltersEntry
+	(*structpb.Struct)(nil),          // 21: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),    // 22: google.protobuf.Timestamp
+}
+var file_knowledge_proto_depIdxs = []int32{
+	21, // 0: knowledge.v1.Dataset.metadata:type_name -> google.protobuf.Struct
+	1,  // 1: knowledge.v1.Dataset.embeddings_provider_config:type_name -> knowledge.v1.EmbeddingsProviderConfig
+	2,  // 2: knowledge.v1.Dataset.files:type_name -> knowledge.v1.File
+	21, // 3: knowledge.v1.EmbeddingsProviderConfig.config:type_name -> google.protobuf.Struct
+	22, // 4: knowledge.v1.File.modified_at:type_name -> google.protobuf.Timestamp
+	0,  // 5: knowledge.v1.ListDatasetsResponse.datasets:type_name -> knowledge.v1.Dataset
+	10, // 6: knowledge.v1.IngestRequest.info:type_name -> knowledge.v1.IngestFileInfo
+	21, // 7: knowledge.v1.IngestFileInfo.metadata:type_name -> google.protobuf.Struct
+	20, // 8: knowledge.v1.RetrieveRequest.filters:type_name -> knowledge.v1.RetrieveRequest.FiltersEntry
+	16, // 9: knowledge.v1.RetrieveRequest.history:type_name -> knowledge.v1.ConversationTurn
+	18, // 10: knowledge.v1.RetrieveResponse.results:type_name -> knowledge.v1.SubqueryResult
+	19, // 11: knowledge.v1.SubqueryResult.documents:type_name -> knowledge.v1.Document
+	21, // 12: knowledge.v1.Document.metadata:type_name -> google.protobuf.Struct
+	15, // 13: knowledge.v1.RetrieveRequest.FiltersEntry.value:type_name -> knowledge.v1.FilterValues
+	3,  // 14: knowledge.v1.Knowledge.CreateDataset:input_type -> knowledge.v1.CreateDatasetRequest
+	4,  // 15: knowledge.v1.Knowledge.GetDataset:input_type -> knowledge.v1.GetDatasetRequest
+	5,  // 16: knowledge.v1.Knowledge.ListDatasets:input_type -> knowledge.v1.ListDatasetsRequest
+	7,  // 17: knowledge.v1.Knowledge.DeleteDataset:input_type -> knowledge.v1.DeleteDatasetRequest
+	9,  // 18: knowledge.v1.Knowledge.Ingest:input_type -> knowledge.v1.IngestRequest
+	12, // 19: knowledge.v1.Knowledge.DeleteFile:input_type -> knowledge.v1.DeleteFileRequest
+	14, // 20: knowledge.v1.Knowledge.Retrieve:input_type -> knowledge.v1.RetrieveRequest
+	0,  // 21: knowledge.v1.Knowledge.CreateDataset:output_type -> knowledge.v1.Dataset
+	0,  // 22: knowledge.v1.Knowledge.GetDataset:output_type -> knowledge.v1.Dataset
+	6,  // 23: knowledge.v1.Knowledge.ListDatasets:output_type -> knowledge.v1.ListDatasetsResponse
+	8,  // 24: knowledge.v1.Knowledge.DeleteDataset:output_type -> knowledge.v1.DeleteDatasetResponse
+	11, // 25: knowledge.v1.Knowledge.Ingest:output_type -> knowledge.v1.IngestResponse
+	13, // 26: knowledge.v1.Knowledge.DeleteFile:output_type -> knowledge.v1.DeleteFileResponse
+	17, // 27: knowledge.v1.Knowledge.Retrieve:output_type -> knowledge.v1.RetrieveResponse
+	21, // [21:28] is the sub-list for method output_type
+	14, // [14:21] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_knowledge_proto_init() }
+func file_knowledge_proto_init() {
+	if File_knowledge_proto != nil {
+		return
+	}
+	file_knowledge_proto_msgTypes[9].OneofWrappers = []any{
+		(*IngestRequest_Info)(nil),
+		(*IngestRequest_Chunk)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_knowledge_proto_rawDesc), len(file_knowledge_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   21,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_knowledge_proto_goTypes,
+		DependencyIndexes: file_knowledge_proto_depIdxs,
+		MessageInfos:      file_knowledge_proto_msgTypes,
+	}.Build()
+	File_knowledge_proto = out.File
+	file_knowledge_proto_goTypes = nil
+	file_knowledge_proto_depIdxs = nil
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	"github.com/obot-platform/tools/knowledge/pkg/metrics"
+)
+
+// shutdownTimeout is the time in-flight requests get to complete when the server is stopped
+const shutdownTimeout = 30 * time.Second
+
+type Config struct {
+	Address         string // Listen address, e.g. 127.0.0.1:8000
+	GRPCAddress     string // Listen address of the gRPC API, e.g. 127.0.0.1:8001 (empty = disabled)
+	MetricsAddress  string // Listen address of the Prometheus metrics endpoint /metrics, e.g. :9090 (empty = disabled)
+	APIBase         string // Path prefix of all API routes, e.g. /v1
+	MaxRequestBytes int64  // Maximum size of a request body (0 = unlimited)
+
+	// Token is the bearer token required by all API requests (REST and gRPC), except for the health check (empty = no authentication)
+	Token string
+	// Insecure allows serving the API without a token on non-loopback addresses, where anyone on the network could modify the datasets
+	Insecure bool
+}
+
+// Validate checks that the API is not served without authentication on a non-loopback address, unless that's explicitly allowed
+func (c Config) Validate() error {
+	if c.Token != "" || c.Insecure {
+		return nil
+	}
+	for _, address := range []string{c.Address, c.GRPCAddress} {
+		if address != "" && !isLoopback(address) {
+			return fmt.Errorf("refusing to serve the API without authentication on the non-loopback address %q - set a token (KNOW_SERVER_TOKEN) or allow it with --insecure", address)
+		}
+	}
+	return nil
+}
+
+// isLoopback reports whether the listen address only accepts connections from the local host
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validToken compares the bearer token of the Authorization header value in constant time
+func validToken(authorization, token string) bool {
+	got, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// Server exposes a datastore via a JSON REST API and optionally gRPC, so that many clients (e.g. agents) share the datastore's connection pools
+// instead of each spawning the CLI and opening the databases per call.
+type Server struct {
+	Datastore *datastore.Datastore
+	FlowCfg   *flowconfig.FlowConfig // Ingestion and retrieval flows per dataset (nil = default flows)
+
+	cfg Config
+}
+
+func NewServer(ds *datastore.Datastore, flowCfg *flowconfig.FlowConfig, cfg Config) *Server {
+	if cfg.APIBase == "" {
+		cfg.APIBase = "/"
+	}
+	return &Server{
+		Datastore: ds,
+		FlowCfg:   flowCfg,
+		cfg:       cfg,
+	}
+}
+
+// Handler returns the HTTP handler serving the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	route := func(method, p string, h http.HandlerFunc) {
+		mux.HandleFunc(method+" "+path.Join(s.cfg.APIBase, p), h)
+	}
+
+	route(http.MethodGet, "/healthz", s.handleHealthz)
+
+	route(http.MethodGet, "/datasets", s.handleListDatasets)
+	route(http.MethodPost, "/datasets", s.handleCreateDataset)
+	route(http.MethodGet, "/datasets/{id}", s.handleGetDataset)
+	route(http.MethodDelete, "/datasets/{id}", s.handleDeleteDataset)
+
+	route(http.MethodPost, "/datasets/{id}/ingest", s.handleIngest)
+	route(http.MethodDelete, "/datasets/{id}/files/{fileID}", s.handleDeleteFile)
+	route(http.MethodDelete, "/datasets/{id}/documents/{documentID}", s.handleDeleteDocument)
+
+	route(http.MethodPost, "/datasets/{id}/retrieve", s.handleRetrieve)
+	route(http.MethodPost, "/retrieve", s.handleRetrieve)
+
+	return s.logRequests(s.authenticate(mux))
+}
+
+// authenticate rejects requests without the configured bearer token, except for the health check
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	if s.cfg.Token == "" {
+		return next
+	}
+	healthz := path.Join(s.cfg.APIBase, "/healthz")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != healthz && !validToken(r.Header.Get("Authorization"), s.cfg.Token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, r, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start serves the API until the context is canceled, then waits for in-flight requests to complete
+func (s *Server) Start(ctx context.Context) error {
+	if err := s.cfg.Validate(); err != nil {
+		return err
+	}
+	slog.Info("Starting knowledge server", "address", s.cfg.Address, "apiBase", s.cfg.APIBase)
+	return serveHTTP(ctx, s.cfg.Address, s.Handler())
+}
+
+// StartMetrics serves the Prometheus metrics on the configured metrics address until the context is canceled
+func (s *Server) StartMetrics(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	metrics.Enable()
+	slog.Info("Starting metrics server", "address", s.cfg.MetricsAddress)
+	return serveHTTP(ctx, s.cfg.MetricsAddress, mux)
+}
+
+// serveHTTP serves the handler until the context is canceled, then waits for in-flight requests to complete
+func serveHTTP(ctx context.Context, address string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down HTTP server", "address", address)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// logRequests adds a request logger to the context and logs the status and duration of each request
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startTime := time.Now()
+		l := slog.With("method", r.Method, "path", r.URL.Path)
+
+		if s.cfg.MaxRequestBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxRequestBytes)
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(log.ToCtx(r.Context(), l)))
+
+		l.Debug("Handled request", "status", sw.status, "duration", time.Since(startTime))
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// ErrorResponse is the body of all error responses
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.FromCtx(r.Context()).Error("Request failed", "status", status, "error", err)
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// readJSON decodes the request body into v, rejecting unknown fields so that typos don't go unnoticed
+func readJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("request body exceeds the maximum size of %d bytes", maxBytesErr.Limit)
+		}
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
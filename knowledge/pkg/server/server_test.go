@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The requests are rejected before the datastore is used, so it's not needed here
+func TestHandlerValidation(t *testing.T) {
+	h := NewServer(nil, nil, Config{APIBase: "/v1", MaxRequestBytes: 64}).Handler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		err    string
+	}{
+		{"health", http.MethodGet, "/v1/healthz", "", http.StatusOK, ""},
+		{"missing dataset id", http.MethodPost, "/v1/datasets", `{}`, http.StatusBadRequest, "dataset id is required"},
+		{"unknown field", http.MethodPost, "/v1/datasets", `{"name": "foo"}`, http.StatusBadRequest, "unknown field"},
+		{"missing filename", http.MethodPost, "/v1/datasets/foo/ingest", `{"content": "Zm9v"}`, http.StatusBadRequest, "filename is required"},
+		{"empty query", http.MethodPost, "/v1/datasets/foo/retrieve", `{"query": " "}`, http.StatusBadRequest, "query is required"},
+		{"no datasets", http.MethodPost, "/v1/retrieve", `{"query": "foo"}`, http.StatusBadRequest, "at least one dataset is required"},
+		{"too large", http.MethodPost, "/v1/retrieve", `{"query": "` + strings.Repeat("x", 100) + `"}`, http.StatusBadRequest, "exceeds the maximum size of 64 bytes"},
+		{"unknown flow", http.MethodPost, "/v1/retrieve", `{"query": "foo", "datasets": ["a"], "flow": "x"}`, http.StatusBadRequest, "no flows file configured"},
+		{"wrong method", http.MethodGet, "/v1/retrieve", "", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, rec.Code)
+
+			if tt.err != "" {
+				var resp ErrorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Contains(t, resp.Error, tt.err)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{Address: "127.0.0.1:8000"}.Validate())
+	assert.NoError(t, Config{Address: "localhost:8000", GRPCAddress: "[::1]:8001"}.Validate())
+	assert.ErrorContains(t, Config{Address: ":8000"}.Validate(), "refusing to serve the API without authentication")
+	assert.ErrorContains(t, Config{Address: "127.0.0.1:8000", GRPCAddress: "0.0.0.0:8001"}.Validate(), `"0.0.0.0:8001"`)
+	assert.NoError(t, Config{Address: ":8000", Token: "secret"}.Validate())
+	assert.NoError(t, Config{Address: ":8000", Insecure: true}.Validate())
+}
+
+func TestHandlerAuthentication(t *testing.T) {
+	h := NewServer(nil, nil, Config{APIBase: "/v1", Token: "secret"}).Handler()
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		status        int
+	}{
+		{"health check without token", "/v1/healthz", "", http.StatusOK},
+		{"missing token", "/v1/retrieve", "", http.StatusUnauthorized},
+		{"wrong token", "/v1/retrieve", "Bearer wrong", http.StatusUnauthorized},
+		{"not a bearer token", "/v1/retrieve", "secret", http.StatusUnauthorized},
+		{"valid token", "/v1/retrieve", "Bearer secret", http.StatusBadRequest}, // passes on to the validation of the (empty) request
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{}`))
+			if tt.path == "/v1/healthz" {
+				req = httptest.NewRequest(http.MethodGet, tt.path, nil)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
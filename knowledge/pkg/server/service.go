@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/acorn-io/z"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore"
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/querymodifiers"
+	dstypes "github.com/obot-platform/tools/knowledge/pkg/datastore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/flows"
+	flowconfig "github.com/obot-platform/tools/knowledge/pkg/flows/config"
+	"github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/log"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRequest  = errors.New("invalid request")
+	ErrDatasetNotFound = errors.New("dataset not found")
+)
+
+func invalidRequest(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// isNotFound reports whether the error is caused by a missing dataset, file or document
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrDatasetNotFound) || errors.Is(err, types.ErrDBFileNotFound) || errors.Is(err, types.ErrDBDocumentNotFound) || errors.Is(err, vserr.ErrCollectionNotFound)
+}
+
+// isAlreadyExists reports whether the error is caused by a dataset that already exists
+func isAlreadyExists(err error) bool {
+	return errors.Is(err, types.ErrDBDatasetExists) || errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+type CreateDatasetRequest struct {
+	ID               string `json:"id"`
+	DistanceFunction string `json:"distanceFunction,omitempty"` // pgvector only: cosine, l2, inner_product
+	ErrOnExists      bool   `json:"errOnExists,omitempty"`
+}
+
+type IngestRequest struct {
+	Filename        string         `json:"filename"`
+	Content         []byte         `json:"content"`                // base64 encoded file content
+	AbsolutePath    string         `json:"absolutePath,omitempty"` // recorded in the index, defaults to the filename
+	Metadata        map[string]any `json:"metadata,omitempty"`
+	Flow            string         `json:"flow,omitempty"` // ingestion flow from the server's flows file (default: the dataset's flow)
+	Deduplication   string         `json:"deduplication,omitempty"`
+	Password        string         `json:"password,omitempty"`
+	ReuseEmbeddings bool           `json:"reuseEmbeddings,omitempty"`
+	ReuseFiles      bool           `json:"reuseFiles,omitempty"`
+	IndexContent    bool           `json:"indexContent,omitempty"`
+	BuildVocabulary bool           `json:"buildVocabulary,omitempty"`
+}
+
+type IngestResponse struct {
+	DocumentIDs []string `json:"documentIDs"`
+}
+
+type RetrieveRequest struct {
+	Datasets []string                          `json:"datasets,omitempty"` // only for /retrieve, the dataset is part of the path otherwise
+	Query    string                            `json:"query"`
+	TopK     int                               `json:"topK,omitempty"`
+	Offset   int                               `json:"offset,omitempty"` // skip the most similar results, to page through the results beyond topK
+	Keywords []string                          `json:"keywords,omitempty"`
+	Filters  map[string][]string               `json:"filters,omitempty"`
+	Where    map[string]any                    `json:"where,omitempty"` // metadata filter with operators, e.g. {"year": {"$gte": 2020}}
+	Tags     []string                          `json:"tags,omitempty"`  // tags the files of the retrieved documents must all have
+	History  []querymodifiers.ConversationTurn `json:"history,omitempty"`
+	Flow     string                            `json:"flow,omitempty"`           // retrieval flow from the server's flows file (default: the dataset's flow)
+	Weights  dstypes.DatasetWeights            `json:"datasetWeights,omitempty"` // per-dataset topK and score weights, e.g. {"runbooks": {"weight": 1.5}}
+}
+
+func (s *Server) createDataset(ctx context.Context, req CreateDatasetRequest) (*types.Dataset, error) {
+	if req.ID == "" {
+		return nil, invalidRequest("dataset id is required")
+	}
+	ds := types.Dataset{ID: req.ID}
+	if err := s.Datastore.CreateDataset(ctx, ds, &types.DatasetCreateOpts{ErrOnExists: req.ErrOnExists, DistanceFunction: req.DistanceFunction}); err != nil {
+		return nil, err
+	}
+	return &ds, nil
+}
+
+// getDataset returns the dataset or ErrDatasetNotFound
+func (s *Server) getDataset(ctx context.Context, id string, opts *types.DatasetGetOpts) (*types.Dataset, error) {
+	ds, err := s.Datastore.GetDataset(ctx, id, opts)
+	if err != nil {
+		return nil, err
+	}
+	if ds == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDatasetNotFound, id)
+	}
+	return ds, nil
+}
+
+func (s *Server) deleteDataset(ctx context.Context, id string) error {
+	if _, err := s.getDataset(ctx, id, nil); err != nil {
+		return err
+	}
+	return s.Datastore.DeleteDataset(ctx, id)
+}
+
+func (s *Server) ingestFile(ctx context.Context, datasetID string, req IngestRequest) ([]string, error) {
+	if req.Filename == "" {
+		return nil, invalidRequest("filename is required")
+	}
+	if _, err := s.getDataset(ctx, datasetID, nil); err != nil {
+		return nil, err
+	}
+
+	ingestionFlows, err := s.ingestionFlows(datasetID, req.Flow)
+	if err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+
+	absPath := req.AbsolutePath
+	if absPath == "" {
+		absPath = req.Filename
+	}
+
+	opts := datastore.IngestOpts{
+		FileMetadata: &types.FileMetadata{
+			Name:         req.Filename,
+			AbsolutePath: absPath,
+			Size:         int64(len(req.Content)),
+			ModifiedAt:   time.Now(),
+		},
+		IsDuplicateFuncName: req.Deduplication,
+		IngestionFlows:      ingestionFlows,
+		ExtraMetadata:       req.Metadata,
+		ReuseEmbeddings:     req.ReuseEmbeddings,
+		ReuseFiles:          req.ReuseFiles,
+		Password:            req.Password,
+		IndexContent:        req.IndexContent,
+		BuildVocabulary:     req.BuildVocabulary,
+	}
+
+	ctx = log.ToCtx(ctx, log.FromCtx(ctx).With("dataset", datasetID, "filename", req.Filename))
+	return s.Datastore.Ingest(ctx, datasetID, req.Filename, req.Content, opts)
+}
+
+func (s *Server) retrieve(ctx context.Context, req RetrieveRequest) (*dstypes.RetrievalResponse, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return nil, invalidRequest("query is required")
+	}
+	if len(req.Datasets) == 0 {
+		return nil, invalidRequest("at least one dataset is required")
+	}
+	if req.Offset < 0 {
+		return nil, invalidRequest("offset must not be negative")
+	}
+	if err := req.Weights.Validate(); err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+
+	retrievalFlow, err := s.retrievalFlow(req.Datasets, req.Flow)
+	if err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+
+	if len(req.Where) > 0 {
+		if _, err := vs.MetadataFilterFromMap(req.Where); err != nil {
+			return nil, invalidRequest("%v", err)
+		}
+	}
+
+	resp, err := s.Datastore.Retrieve(ctx, req.Datasets, req.Query, datastore.RetrieveOpts{
+		TopK:          req.TopK,
+		Offset:        req.Offset,
+		Keywords:      req.Keywords,
+		RetrievalFlow: retrievalFlow,
+		History:       req.History,
+		Filters:       req.Filters,
+		Where:         req.Where,
+		Weights:       req.Weights,
+		Tags:          req.Tags,
+	})
+	// An empty dataset is not a hard error, like in the CLI
+	if errors.Is(err, vserr.ErrCollectionEmpty) {
+		return &dstypes.RetrievalResponse{Query: req.Query, Datasets: req.Datasets, Responses: []dstypes.Response{}}, nil
+	}
+	return resp, err
+}
+
+// flow returns the named flow or the dataset's flow from the flows config, or nil if there's no flows config
+func (s *Server) flow(datasetID, name string) (*flowconfig.FlowConfigEntry, error) {
+	if s.FlowCfg == nil {
+		if name != "" {
+			return nil, fmt.Errorf("flow %q not found: no flows file configured", name)
+		}
+		return nil, nil
+	}
+	if name != "" {
+		return s.FlowCfg.GetFlow(name)
+	}
+	if datasetID != "" {
+		return s.FlowCfg.ForDataset(datasetID)
+	}
+	return s.FlowCfg.GetDefaultFlowConfigEntry()
+}
+
+func (s *Server) ingestionFlows(datasetID, name string) ([]flows.IngestionFlow, error) {
+	flow, err := s.flow(datasetID, name)
+	if err != nil || flow == nil {
+		return nil, err
+	}
+
+	var ingestionFlows []flows.IngestionFlow
+	for _, ingestionFlowConfig := range flow.Ingestion {
+		ingestionFlow, err := ingestionFlowConfig.AsIngestionFlow(&flow.Globals.Ingestion)
+		if err != nil {
+			return nil, err
+		}
+		ingestionFlows = append(ingestionFlows, z.Dereference(ingestionFlow))
+	}
+	return ingestionFlows, nil
+}
+
+func (s *Server) retrievalFlow(datasetIDs []string, name string) (*flows.RetrievalFlow, error) {
+	datasetID := ""
+	if len(datasetIDs) == 1 {
+		datasetID = datasetIDs[0]
+	}
+	flow, err := s.flow(datasetID, name)
+	if err != nil || flow == nil || flow.Retrieval == nil {
+		return nil, err
+	}
+	return flow.Retrieval.AsRetrievalFlow()
+}
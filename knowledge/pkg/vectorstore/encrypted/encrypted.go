@@ -0,0 +1,323 @@
+package encrypted
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/obot-platform/tools/knowledge/pkg/encryption"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// MetadataKeyEncrypted holds the encrypted metadata of a document, except for the plaintext keys
+const MetadataKeyEncrypted = "_encrypted"
+
+const (
+	// searchOverfetchFactor is the factor by which the number of documents fetched by a similarity search is increased
+	// while filters applied after decryption leave fewer than the requested number of documents
+	searchOverfetchFactor = 4
+
+	// maxSearchFetch is the maximum number of documents fetched by a single similarity search with filters on encrypted keys
+	maxSearchFetch = 1000
+)
+
+// VectorStore encrypts the content and metadata of documents before they're written to the wrapped vector store
+// and decrypts them when they're read. The documents must be embedded before they're added (from the plaintext),
+// as the wrapped vector store would embed the encrypted content otherwise, so similarity search works as before.
+//
+// Metadata is stored as a single encrypted value, except for the plaintext keys, so that the vector store can't filter by it:
+// filters on encrypted metadata keys and document content filters are applied to the decrypted documents instead.
+// Similarity searches with such filters fetch more documents until enough match, but return fewer than requested,
+// if less than that many of the top maxSearchFetch documents match. Server-side hybrid search is disabled.
+// Documents written before encryption was enabled are returned as they are.
+type VectorStore struct {
+	vectorstore.VectorStore
+	cipher        *encryption.Cipher
+	embeddingFunc vs.EmbeddingFunc // embeds the query once for the repeated similarity searches with filters on encrypted keys
+	plaintextKeys []string
+}
+
+func New(store vectorstore.VectorStore, cipher *encryption.Cipher, embeddingFunc vs.EmbeddingFunc, plaintextKeys ...string) *VectorStore {
+	return &VectorStore{
+		VectorStore:   store,
+		cipher:        cipher,
+		embeddingFunc: embeddingFunc,
+		plaintextKeys: plaintextKeys,
+	}
+}
+
+// Unwrap returns the wrapped vector store (see vectorstore.As)
+func (v *VectorStore) Unwrap() vectorstore.VectorStore {
+	return v.VectorStore
+}
+
+// SupportsPaging reports that pages are cut from the decrypted results, as filters may have to be applied after decryption (see vectorstore.Pager)
+func (v *VectorStore) SupportsPaging() bool {
+	return false
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	encrypted := make([]vs.Document, len(docs))
+	for i, doc := range docs {
+		if len(doc.Embedding) == 0 {
+			return nil, fmt.Errorf("document %s has no embedding - documents have to be embedded before they're encrypted", doc.ID)
+		}
+		enc, err := v.encryptDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		encrypted[i] = enc
+	}
+	return v.VectorStore.AddDocuments(ctx, encrypted, collection)
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) { //nolint:lll
+	searchOpts := vs.SearchOptionsFromCtx(ctx)
+	searchOpts.Hybrid = false
+	searchOpts.Offset = 0
+	var filter *vs.MetadataFilter
+	if searchOpts.Filter != nil && !v.plaintext(searchOpts.Filter.Keys()...) {
+		// the vector store can't filter by encrypted keys, so the filter is applied after decryption
+		filter, searchOpts.Filter = searchOpts.Filter, nil
+	}
+	ctx = vs.SearchOptionsToCtx(ctx, searchOpts)
+
+	pushdown, local := v.splitWhere(where)
+	if filter == nil && len(local) == 0 && len(whereDocument) == 0 {
+		docs, err := v.VectorStore.SimilaritySearch(ctx, query, numDocuments, collection, pushdown, nil, embeddingFunc)
+		if err != nil {
+			return nil, err
+		}
+		return v.decryptDocuments(docs, nil, nil, nil)
+	}
+
+	// Documents not matching the filters are dropped after decryption, so more documents are fetched
+	// until enough of them match or there are no more
+	if embeddingFunc == nil {
+		embeddingFunc = v.embeddingFunc
+	}
+	var queryEmbedding []float32
+	embedQuery := func(ctx context.Context, text string) ([]float32, error) {
+		if queryEmbedding == nil {
+			emb, err := embeddingFunc(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			queryEmbedding = emb
+		}
+		return queryEmbedding, nil
+	}
+
+	fetch := max(numDocuments, 1) * searchOverfetchFactor
+	for {
+		fetch = min(fetch, max(maxSearchFetch, numDocuments))
+		docs, err := v.VectorStore.SimilaritySearch(ctx, query, fetch, collection, pushdown, nil, embedQuery)
+		if err != nil {
+			return nil, err
+		}
+		result, err := v.decryptDocuments(docs, filter, local, whereDocument)
+		if err != nil {
+			return nil, err
+		}
+		if len(result) >= numDocuments || len(docs) < fetch || fetch >= maxSearchFetch {
+			return result[:min(len(result), numDocuments)], nil
+		}
+		fetch *= searchOverfetchFactor
+	}
+}
+
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	ctx = vs.ListOptionsToCtx(ctx, vs.ListOptions{})
+
+	pushdown, local := v.splitWhere(where)
+	docs, err := v.VectorStore.GetDocuments(ctx, collection, pushdown, nil)
+	if err != nil {
+		return nil, err
+	}
+	return v.decryptDocuments(docs, nil, local, whereDocument)
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID string, collection string) (vs.Document, error) {
+	doc, err := v.VectorStore.GetDocument(ctx, documentID, collection)
+	if err != nil {
+		return doc, err
+	}
+	return v.decryptDocument(doc)
+}
+
+// RemoveDocument removes the document or, if no document ID is given, the documents matching the filters.
+// If the filters can't be applied by the vector store, the matching documents are removed one by one.
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []vs.WhereDocument) error {
+	pushdown, local := v.splitWhere(where)
+	if documentID != "" || (len(local) == 0 && len(whereDocument) == 0) {
+		return v.VectorStore.RemoveDocument(ctx, documentID, collection, pushdown, nil)
+	}
+
+	docs, err := v.GetDocuments(ctx, collection, where, whereDocument)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if err := v.VectorStore.RemoveDocument(ctx, doc.ID, collection, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateDocumentMetadata patches the plaintext keys directly - patching encrypted keys requires reading and re-encrypting the metadata
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID string, collection string, patch vs.MetadataPatch) error {
+	encryptedPatch := vs.MetadataPatch{}
+	var patchEncrypted bool
+	for k, val := range patch {
+		if v.plaintext(k) {
+			encryptedPatch[k] = val
+		} else {
+			patchEncrypted = true
+		}
+	}
+
+	if patchEncrypted {
+		doc, err := v.GetDocument(ctx, documentID, collection)
+		if err != nil {
+			return err
+		}
+		enc, err := v.encryptMetadata(patch.Apply(doc.Metadata))
+		if err != nil {
+			return err
+		}
+		encryptedPatch[MetadataKeyEncrypted] = enc[MetadataKeyEncrypted]
+	}
+
+	return v.VectorStore.UpdateDocumentMetadata(ctx, documentID, collection, encryptedPatch)
+}
+
+func (v *VectorStore) plaintext(keys ...string) bool {
+	for _, k := range keys {
+		if !slices.Contains(v.plaintextKeys, k) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitWhere splits the metadata filters into those the vector store can apply and those on encrypted keys
+func (v *VectorStore) splitWhere(where map[string]string) (map[string]string, map[string]string) {
+	var pushdown, local map[string]string
+	for k, val := range where {
+		if v.plaintext(k) {
+			if pushdown == nil {
+				pushdown = map[string]string{}
+			}
+			pushdown[k] = val
+		} else {
+			if local == nil {
+				local = map[string]string{}
+			}
+			local[k] = val
+		}
+	}
+	return pushdown, local
+}
+
+func (v *VectorStore) encryptDocument(doc vs.Document) (vs.Document, error) {
+	content, err := v.cipher.EncryptString(doc.Content)
+	if err != nil {
+		return doc, fmt.Errorf("failed to encrypt document %s: %w", doc.ID, err)
+	}
+	metadata, err := v.encryptMetadata(doc.Metadata)
+	if err != nil {
+		return doc, fmt.Errorf("failed to encrypt metadata of document %s: %w", doc.ID, err)
+	}
+	doc.Content, doc.Metadata = content, metadata
+	return doc, nil
+}
+
+func (v *VectorStore) encryptMetadata(metadata map[string]any) (map[string]any, error) {
+	encrypted := make(map[string]any, len(v.plaintextKeys)+1)
+	secret := make(map[string]any, len(metadata))
+	for k, val := range metadata {
+		if k == MetadataKeyEncrypted {
+			continue
+		}
+		if v.plaintext(k) {
+			encrypted[k] = val
+		} else {
+			secret[k] = val
+		}
+	}
+	if len(secret) == 0 {
+		return encrypted, nil
+	}
+
+	b, err := json.Marshal(secret)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := v.cipher.EncryptString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	encrypted[MetadataKeyEncrypted] = enc
+	return encrypted, nil
+}
+
+func (v *VectorStore) decryptDocument(doc vs.Document) (vs.Document, error) {
+	content, err := v.cipher.DecryptString(doc.Content)
+	if err != nil {
+		return doc, fmt.Errorf("failed to decrypt document %s: %w", doc.ID, err)
+	}
+	doc.Content = content
+
+	enc, ok := doc.Metadata[MetadataKeyEncrypted].(string)
+	if !ok {
+		return doc, nil
+	}
+	plaintext, err := v.cipher.DecryptString(enc)
+	if err != nil {
+		return doc, fmt.Errorf("failed to decrypt metadata of document %s: %w", doc.ID, err)
+	}
+	metadata := make(map[string]any, len(doc.Metadata))
+	if err := json.Unmarshal([]byte(plaintext), &metadata); err != nil {
+		return doc, fmt.Errorf("failed to decode metadata of document %s: %w", doc.ID, err)
+	}
+	for k, val := range doc.Metadata {
+		if k != MetadataKeyEncrypted {
+			metadata[k] = val
+		}
+	}
+	doc.Metadata = metadata
+	return doc, nil
+}
+
+// decryptDocuments decrypts the documents and drops those not matching the filters on encrypted metadata keys and content
+func (v *VectorStore) decryptDocuments(docs []vs.Document, filter *vs.MetadataFilter, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	result := make([]vs.Document, 0, len(docs))
+	for _, doc := range docs {
+		doc, err := v.decryptDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(doc.Metadata) && matches(doc, where, whereDocument) {
+			result = append(result, doc)
+		}
+	}
+	return result, nil
+}
+
+func matches(doc vs.Document, where map[string]string, whereDocument []vs.WhereDocument) bool {
+	for k, val := range where {
+		have, ok := doc.Metadata[k]
+		if !ok || fmt.Sprint(have) != val {
+			return false
+		}
+	}
+	for _, wd := range whereDocument {
+		if !wd.Matches(&doc) {
+			return false
+		}
+	}
+	return true
+}
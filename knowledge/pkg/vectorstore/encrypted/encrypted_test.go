@@ -0,0 +1,161 @@
+package encrypted
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/obot-platform/tools/knowledge/pkg/encryption"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVectorStore keeps the documents of a single collection as they're written, all other methods panic
+type testVectorStore struct {
+	vectorstore.VectorStore
+	docs map[string]vs.Document
+}
+
+func (v *testVectorStore) AddDocuments(_ context.Context, docs []vs.Document, _ string) ([]string, error) {
+	var ids []string
+	for _, doc := range docs {
+		v.docs[doc.ID] = doc
+		ids = append(ids, doc.ID)
+	}
+	return ids, nil
+}
+
+func (v *testVectorStore) GetDocuments(_ context.Context, _ string, where map[string]string, _ []vs.WhereDocument) ([]vs.Document, error) {
+	var docs []vs.Document
+	for _, doc := range v.docs {
+		if matches(doc, where, nil) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// SimilaritySearch returns the first numDocuments documents matching the filter, ordered by ID
+func (v *testVectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, _ string, where map[string]string, _ []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) { //nolint:lll
+	if _, err := embeddingFunc(ctx, query); err != nil {
+		return nil, err
+	}
+	var docs []vs.Document
+	for _, id := range slices.Sorted(maps.Keys(v.docs)) {
+		if matches(v.docs[id], where, nil) && len(docs) < numDocuments {
+			docs = append(docs, v.docs[id])
+		}
+	}
+	return docs, nil
+}
+
+func (v *testVectorStore) GetDocument(_ context.Context, documentID string, _ string) (vs.Document, error) {
+	return v.docs[documentID], nil
+}
+
+func (v *testVectorStore) RemoveDocument(_ context.Context, documentID string, _ string, where map[string]string, _ []vs.WhereDocument) error {
+	for id, doc := range v.docs {
+		if id == documentID || (documentID == "" && matches(doc, where, nil)) {
+			delete(v.docs, id)
+		}
+	}
+	return nil
+}
+
+func (v *testVectorStore) UpdateDocumentMetadata(_ context.Context, documentID string, _ string, patch vs.MetadataPatch) error {
+	doc := v.docs[documentID]
+	doc.Metadata = patch.Apply(doc.Metadata)
+	v.docs[documentID] = doc
+	return nil
+}
+
+func TestVectorStore(t *testing.T) {
+	ctx := context.Background()
+	c, err := encryption.NewCipher([]byte(strings.Repeat("k", 32)))
+	require.NoError(t, err)
+
+	var embedded []string
+	embeddingFunc := func(_ context.Context, text string) ([]float32, error) {
+		embedded = append(embedded, text)
+		return []float32{1}, nil
+	}
+
+	inner := &testVectorStore{docs: map[string]vs.Document{}}
+	store := New(inner, c, embeddingFunc, "absPath")
+
+	_, err = store.AddDocuments(ctx, []vs.Document{{ID: "1", Content: "the secret recipe"}}, "ds")
+	assert.ErrorContains(t, err, "has no embedding", "the wrapped vector store would embed the encrypted content")
+
+	_, err = store.AddDocuments(ctx, []vs.Document{
+		{ID: "1", Content: "the secret recipe", Metadata: map[string]any{"absPath": "/a.md", "title": "Recipe"}, Embedding: []float32{1}},
+		{ID: "2", Content: "public notes", Metadata: map[string]any{"absPath": "/b.md", "title": "Notes"}, Embedding: []float32{2}},
+	}, "ds")
+	require.NoError(t, err)
+	assert.Empty(t, embedded)
+
+	stored := inner.docs["1"]
+	assert.True(t, encryption.IsEncrypted(stored.Content))
+	assert.Equal(t, "/a.md", stored.Metadata["absPath"])
+	assert.NotContains(t, stored.Metadata, "title")
+	assert.Contains(t, stored.Metadata, MetadataKeyEncrypted)
+
+	doc, err := store.GetDocument(ctx, "1", "ds")
+	require.NoError(t, err)
+	assert.Equal(t, "the secret recipe", doc.Content)
+	assert.Equal(t, map[string]any{"absPath": "/a.md", "title": "Recipe"}, doc.Metadata)
+
+	// filters on encrypted keys and content are applied after decryption
+	docs, err := store.GetDocuments(ctx, "ds", map[string]string{"title": "Notes"}, nil)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "2", docs[0].ID)
+
+	docs, err = store.GetDocuments(ctx, "ds", nil, []vs.WhereDocument{{Operator: vs.WhereDocumentOperatorContains, Value: "secret"}})
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "1", docs[0].ID)
+
+	// similarity searches with filters on encrypted keys fetch more documents until enough match
+	for _, id := range []string{"4", "5", "6", "7", "8", "9"} {
+		_, err = store.AddDocuments(ctx, []vs.Document{{ID: id, Content: "more notes", Metadata: map[string]any{"title": "Notes"}, Embedding: []float32{3}}}, "ds")
+		require.NoError(t, err)
+	}
+	docs, err = store.SimilaritySearch(ctx, "recipe", 1, "ds", map[string]string{"title": "Recipe"}, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "1", docs[0].ID)
+
+	filter, err := vs.MetadataFilterFromMap(map[string]any{"title": "Notes"})
+	require.NoError(t, err)
+	docs, err = store.SimilaritySearch(vs.SearchOptionsToCtx(ctx, vs.SearchOptions{Filter: filter}), "notes", 3, "ds", nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+	assert.Equal(t, []string{"2", "4", "5"}, []string{docs[0].ID, docs[1].ID, docs[2].ID})
+	assert.Equal(t, []string{"recipe", "notes"}, embedded, "the query is embedded once per search")
+	for _, id := range []string{"4", "5", "6", "7", "8", "9"} {
+		delete(inner.docs, id)
+	}
+
+	require.NoError(t, store.UpdateDocumentMetadata(ctx, "1", "ds", vs.MetadataPatch{"title": nil, "label": "x", "absPath": "/c.md"}))
+	doc, err = store.GetDocument(ctx, "1", "ds")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"absPath": "/c.md", "label": "x"}, doc.Metadata)
+	assert.NotContains(t, inner.docs["1"].Metadata, "label")
+
+	require.NoError(t, store.RemoveDocument(ctx, "", "ds", map[string]string{"title": "Notes"}, nil))
+	assert.Len(t, inner.docs, 1)
+
+	// documents written before encryption was enabled
+	inner.docs["3"] = vs.Document{ID: "3", Content: "plain", Metadata: map[string]any{"title": "Old"}}
+	doc, err = store.GetDocument(ctx, "3", "ds")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", doc.Content)
+	assert.Equal(t, "Old", doc.Metadata["title"])
+
+	_, ok := vectorstore.As[vectorstore.Pager](store)
+	assert.True(t, ok)
+}
@@ -7,4 +7,6 @@ import (
 var (
 	ErrCollectionNotFound = errors.New("collection not found")
 	ErrCollectionEmpty    = errors.New("collection is empty")
+	ErrDocumentNotFound   = errors.New("document not found")
+	ErrNotSupported       = errors.New("not supported by this vector store")
 )
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// importBatchSize is the number of documents passed to AddDocuments at once when importing a collection
+const importBatchSize = 500
+
+// CollectionStore is the part of a vector store used by the generic collection import and export
+type CollectionStore interface {
+	CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error
+	AddDocuments(ctx context.Context, docs []types.Document, collection string) ([]string, error)
+	GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []types.WhereDocument) ([]types.Document, error)
+}
+
+// ImportCollections imports collections from a file in the portable export format (see types.Export) into a vector store
+// without a native bulk import, via CreateCollection and AddDocuments. The documents keep their embeddings, so the embedding
+// model the store creates missing collections with must have the same dimensions - otherwise the store rejects the documents.
+func ImportCollections(ctx context.Context, store CollectionStore, storeName, path string, collections ...string) error {
+	export, err := types.ReadExportFile(path)
+	if err != nil {
+		return err
+	}
+
+	toImport := make([]types.ExportCollection, 0, len(export.Collections))
+	for _, c := range export.Collections {
+		if len(collections) == 0 || slices.Contains(collections, c.Name) {
+			toImport = append(toImport, c)
+		}
+	}
+	for _, name := range collections {
+		if !slices.ContainsFunc(toImport, func(c types.ExportCollection) bool { return c.Name == name }) {
+			return fmt.Errorf("collection %s not found in export file %s", name, path)
+		}
+	}
+
+	// Validate everything before writing anything
+	for _, c := range toImport {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range toImport {
+		slog.Info("Importing collection", "collection", c.Name, "documents", len(c.Documents), "dimensions", c.Dimensions, "store", storeName)
+
+		if err := store.CreateCollection(ctx, c.Name, nil); err != nil {
+			return err
+		}
+
+		for start := 0; start < len(c.Documents); start += importBatchSize {
+			batch := c.Documents[start:min(start+importBatchSize, len(c.Documents))]
+			docs := make([]types.Document, 0, len(batch))
+			for _, d := range batch {
+				doc, err := d.Document()
+				if err != nil {
+					return err
+				}
+				docs = append(docs, doc)
+			}
+			if _, err := store.AddDocuments(ctx, docs, c.Name); err != nil {
+				return fmt.Errorf("failed to import collection %s: %w", c.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExportCollections exports the given collections (or all returned by listCollections, if none are given) in the portable
+// export format (see types.Export). If path is a directory, the file is created in there as types.ExportFileName.
+func ExportCollections(ctx context.Context, store CollectionStore, storeName, path string, listCollections func(ctx context.Context) ([]string, error), collections ...string) error {
+	if finfo, err := os.Stat(path); err == nil && finfo.IsDir() {
+		path = filepath.Join(path, types.ExportFileName)
+	}
+
+	if len(collections) == 0 {
+		var err error
+		collections, err = listCollections(ctx)
+		if err != nil {
+			return err
+		}
+		slices.Sort(collections)
+	}
+
+	export := &types.Export{Version: types.ExportFormatVersion}
+	for _, collection := range collections {
+		docs, err := store.GetDocuments(ctx, collection, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get documents of collection %s: %w", collection, err)
+		}
+
+		c := types.ExportCollection{Name: collection, Documents: make([]types.ExportDocument, 0, len(docs))}
+		if len(docs) > 0 {
+			c.Dimensions = len(docs[0].Embedding)
+		}
+		for _, doc := range docs {
+			d, err := types.NewExportDocument(doc)
+			if err != nil {
+				return err
+			}
+			c.Documents = append(c.Documents, d)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		slog.Debug("Exporting collection", "collection", collection, "documents", len(c.Documents), "store", storeName)
+		export.Collections = append(export.Collections, c)
+	}
+
+	return types.WriteExportFile(path, export)
+}
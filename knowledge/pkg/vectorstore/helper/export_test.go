@@ -0,0 +1,62 @@
+package helper
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore is an in-memory CollectionStore
+type memStore map[string][]vs.Document
+
+func (m memStore) CreateCollection(_ context.Context, collection string, _ *dbtypes.DatasetCreateOpts) error {
+	if _, ok := m[collection]; !ok {
+		m[collection] = nil
+	}
+	return nil
+}
+
+func (m memStore) AddDocuments(_ context.Context, docs []vs.Document, collection string) ([]string, error) {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		m[collection] = append(m[collection], doc)
+		ids = append(ids, doc.ID)
+	}
+	return ids, nil
+}
+
+func (m memStore) GetDocuments(_ context.Context, collection string, _ map[string]string, _ []vs.WhereDocument) ([]vs.Document, error) {
+	return m[collection], nil
+}
+
+func (m memStore) list(context.Context) ([]string, error) {
+	return slices.Collect(maps.Keys(m)), nil
+}
+
+func TestExportImportCollections(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	src := memStore{
+		"b": {{ID: "b1", Content: "bar", Metadata: map[string]any{"page": float64(2)}, Embedding: []float32{0.3, 0.4}}},
+		"a": {{ID: "a1", Content: "foo", Metadata: map[string]any{"tags": []any{"x", "y"}}, Embedding: []float32{0.1, 0.2, 0.3}}},
+	}
+	require.NoError(t, ExportCollections(ctx, src, "mem", dir, src.list))
+
+	dst := memStore{}
+	require.NoError(t, ImportCollections(ctx, dst, "mem", filepath.Join(dir, vs.ExportFileName)))
+	assert.Equal(t, src, dst)
+
+	only := memStore{}
+	require.NoError(t, ImportCollections(ctx, only, "mem", filepath.Join(dir, vs.ExportFileName), "b"))
+	assert.Equal(t, memStore{"b": src["b"]}, only)
+
+	assert.ErrorContains(t, ImportCollections(ctx, memStore{}, "mem", filepath.Join(dir, vs.ExportFileName), "c"), "collection c not found")
+}
@@ -0,0 +1,87 @@
+package milvus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// client is a minimal client for the Milvus RESTful API v2 (https://milvus.io/api-reference/restful/v2.4.x/About.md),
+// which is also served by Zilliz Cloud.
+type client struct {
+	baseURL    string
+	token      string
+	dbName     string
+	httpClient *http.Client
+}
+
+type apiError struct {
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("milvus API error (code %d): %s", e.Code, e.Message)
+}
+
+// do sends a request to the Milvus API and decodes the "data" field of the response into result (if not nil)
+func (c *client) do(ctx context.Context, path string, body map[string]any, result any) error {
+	if body == nil {
+		body = map[string]any{}
+	}
+	if c.dbName != "" {
+		body["dbName"] = c.dbName
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v2/vectordb"+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to milvus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read milvus response: %w", err)
+	}
+
+	// Milvus reports errors via the "code" field, usually with HTTP status 200
+	var r struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &r); err != nil {
+		return &apiError{Code: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+	}
+	if r.Code != 0 && r.Code != http.StatusOK {
+		return &apiError{Code: r.Code, Message: r.Message}
+	}
+	if resp.StatusCode >= 300 {
+		return &apiError{Code: resp.StatusCode, Message: r.Message}
+	}
+
+	if result != nil && len(r.Data) > 0 {
+		if err := json.Unmarshal(r.Data, result); err != nil {
+			return fmt.Errorf("failed to decode milvus result: %w", err)
+		}
+	}
+	return nil
+}
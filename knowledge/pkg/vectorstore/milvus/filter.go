@@ -0,0 +1,117 @@
+package milvus
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const (
+	fieldID        = "id"
+	fieldContent   = "content"
+	fieldMetadata  = "metadata"
+	fieldEmbedding = "embedding"
+
+	// matchAllFilter is used for queries without filters, as Milvus requires either a filter or a limit
+	matchAllFilter = `id != ""`
+)
+
+// quote renders a string literal for a Milvus boolean expression
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// literal renders a string, number or boolean literal for a Milvus boolean expression
+func literal(v any) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return quote(fmt.Sprint(val))
+	}
+}
+
+// metadataCondition matches the metadata values equal to the where filter value or to the number or boolean with the
+// same literal (see vs.WhereValues), as well as lists containing any of them
+func metadataCondition(key, value string) string {
+	field := fieldMetadata + "[" + quote(key) + "]"
+	var exprs []string
+	for _, v := range vs.WhereValues(value) {
+		lit := literal(v)
+		exprs = append(exprs, field+" == "+lit, "json_contains("+field+", "+lit+")")
+	}
+	return "(" + strings.Join(exprs, " or ") + ")"
+}
+
+// buildFilter translates the metadata (where) and content (whereDocument) filters into a Milvus boolean expression,
+// see https://milvus.io/docs/boolean.md.
+// Content filters that cannot be expressed safely (substrings containing LIKE wildcards) are returned separately, so they can be applied in memory.
+func buildFilter(where map[string]string, whereDocument []vs.WhereDocument) (string, []vs.WhereDocument) {
+	keys := make([]string, 0, len(where))
+	for k := range where {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var exprs []string
+	for _, k := range keys {
+		exprs = append(exprs, metadataCondition(k, where[k]))
+	}
+
+	var remaining []vs.WhereDocument
+	for _, wd := range whereDocument {
+		if expr, ok := translateWhereDocument(wd); ok {
+			exprs = append(exprs, expr)
+		} else {
+			remaining = append(remaining, wd)
+		}
+	}
+
+	return strings.Join(exprs, " and "), remaining
+}
+
+func translateWhereDocument(wd vs.WhereDocument) (string, bool) {
+	switch wd.Operator {
+	case vs.WhereDocumentOperatorEquals:
+		return fieldContent + " == " + quote(wd.Value), true
+	case vs.WhereDocumentOperatorContains, vs.WhereDocumentOperatorNotContains:
+		if strings.ContainsAny(wd.Value, "%_") {
+			return "", false
+		}
+		expr := fieldContent + " like " + quote("%"+wd.Value+"%")
+		if wd.Operator == vs.WhereDocumentOperatorNotContains {
+			expr = "not (" + expr + ")"
+		}
+		return expr, true
+	case vs.WhereDocumentOperatorOr, vs.WhereDocumentOperatorAnd:
+		exprs := make([]string, 0, len(wd.WhereDocuments))
+		for _, sub := range wd.WhereDocuments {
+			expr, ok := translateWhereDocument(sub)
+			if !ok {
+				return "", false
+			}
+			exprs = append(exprs, "("+expr+")")
+		}
+		if wd.Operator == vs.WhereDocumentOperatorOr {
+			return "(" + strings.Join(exprs, " or ") + ")", true
+		}
+		return "(" + strings.Join(exprs, " and ") + ")", true
+	default:
+		return "", false
+	}
+}
+
+// matchesAll checks whether the document matches all given content filters (used for filters not translated to Milvus)
+func matchesAll(doc *vs.Document, whereDocument []vs.WhereDocument) bool {
+	for _, wd := range whereDocument {
+		if !wd.Matches(doc) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package milvus
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFilter(t *testing.T) {
+	filter, remaining := buildFilter(map[string]string{"absPath": `/data/"a".pdf`}, []vs.WhereDocument{
+		{
+			Operator: vs.WhereDocumentOperatorOr,
+			WhereDocuments: []vs.WhereDocument{
+				{Operator: vs.WhereDocumentOperatorContains, Value: "foo"},
+				{Operator: vs.WhereDocumentOperatorNotContains, Value: "bar"},
+			},
+		},
+		{Operator: vs.WhereDocumentOperatorContains, Value: "100%"},
+	})
+
+	assert.Equal(t, `(metadata["absPath"] == "/data/\"a\".pdf" or json_contains(metadata["absPath"], "/data/\"a\".pdf")) and ((content like "%foo%") or (not (content like "%bar%")))`, filter)
+	assert.Equal(t, []vs.WhereDocument{{Operator: vs.WhereDocumentOperatorContains, Value: "100%"}}, remaining)
+
+	filter, _ = buildFilter(map[string]string{"page": "3"}, nil)
+	assert.Equal(t, `(metadata["page"] == "3" or json_contains(metadata["page"], "3") or metadata["page"] == 3 or json_contains(metadata["page"], 3))`, filter)
+
+	filter, remaining = buildFilter(nil, nil)
+	assert.Empty(t, filter)
+	assert.Empty(t, remaining)
+}
@@ -0,0 +1,528 @@
+package milvus
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// VsMilvusEmbeddingConcurrency can be set as an environment variable to control the number of parallel API calls to create embedding for documents. Default is 100
+	VsMilvusEmbeddingConcurrency = "VS_MILVUS_EMBEDDING_CONCURRENCY"
+
+	// VsMilvusToken can be set as an environment variable to provide the token (API key or user:password), if it's not part of the DSN
+	VsMilvusToken = "VS_MILVUS_TOKEN"
+
+	defaultCollectionPrefix = "knowledge_"
+
+	insertBatchSize = 500
+	queryPageSize   = 1000
+	maxIDLength     = 256
+	maxContentBytes = 65535
+
+	// searchOverfetchFactor is used to fetch more candidates if content filters have to be applied in memory
+	searchOverfetchFactor = 4
+)
+
+var invalidNameCharsRegex = regexp.MustCompile(`[^_0-9A-Za-z]`)
+
+type VectorStore struct {
+	embeddingFunc        vs.EmbeddingFunc
+	embeddingConcurrency int
+	client               *client
+	collectionPrefix     string
+}
+
+// New creates a new Milvus (or Zilliz Cloud) vector store from a DSN of the form
+//
+//	milvus://[token@|user:password@]host[:port][?tls=true&db=default&prefix=knowledge_]
+//
+// The RESTful API v2 is used (default port 19530).
+func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse milvus DSN: %w", err)
+	}
+
+	q := u.Query()
+
+	scheme := "http"
+	if q.Get("tls") == "true" {
+		scheme = "https"
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host += ":19530"
+	}
+
+	token := os.Getenv(VsMilvusToken)
+	if u.User != nil && u.User.Username() != "" {
+		token = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			token += ":" + password
+		}
+	}
+
+	prefix := q.Get("prefix")
+	if prefix == "" {
+		prefix = defaultCollectionPrefix
+	}
+
+	store := &VectorStore{
+		embeddingFunc:        embeddingFunc,
+		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsMilvusEmbeddingConcurrency, 100),
+		client: &client{
+			baseURL:    scheme + "://" + host + strings.TrimSuffix(u.Path, "/"),
+			token:      token,
+			dbName:     q.Get("db"),
+			httpClient: &http.Client{Timeout: 60 * time.Second},
+		},
+		collectionPrefix: prefix,
+	}
+
+	slog.Debug("milvus", "url", store.client.baseURL, "db", store.client.dbName, "collectionPrefix", store.collectionPrefix)
+
+	// Check connectivity
+	if _, err := store.listCollections(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to milvus: %w", err)
+	}
+
+	return store, nil
+}
+
+func (v *VectorStore) Close() error {
+	v.client.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// collectionName maps a collection name to a valid Milvus collection name.
+// If the collection name contains invalid characters, a short hash is appended to avoid collisions.
+func (v *VectorStore) collectionName(collection string) string {
+	sanitized := invalidNameCharsRegex.ReplaceAllString(collection, "_")
+	if sanitized != collection {
+		h := sha1.Sum([]byte(collection))
+		sanitized += "_" + hex.EncodeToString(h[:4])
+	}
+	return v.collectionPrefix + sanitized
+}
+
+func (v *VectorStore) hasCollection(ctx context.Context, collection string) (bool, error) {
+	var res struct {
+		Has bool `json:"has"`
+	}
+	if err := v.client.do(ctx, "/collections/has", map[string]any{"collectionName": v.collectionName(collection)}, &res); err != nil {
+		return false, err
+	}
+	return res.Has, nil
+}
+
+func (v *VectorStore) ensureCollection(ctx context.Context, collection string) error {
+	exists, err := v.hasCollection(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+	return nil
+}
+
+// listCollections returns the names of all collections with the configured prefix
+func (v *VectorStore) listCollections(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := v.client.do(ctx, "/collections/list", nil, &names); err != nil {
+		return nil, fmt.Errorf("failed to list milvus collections: %w", err)
+	}
+
+	var collections []string
+	for _, name := range names {
+		if strings.HasPrefix(name, v.collectionPrefix) {
+			collections = append(collections, name)
+		}
+	}
+	return collections, nil
+}
+
+func (v *VectorStore) CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error {
+	if opts == nil {
+		opts = &dbtypes.DatasetCreateOpts{}
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "milvusCollection", v.collectionName(collection), "store", "milvus")
+
+	exists, err := v.hasCollection(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("failed to check if collection %s exists: %w", collection, err)
+	}
+	if exists {
+		if opts.ErrOnExists {
+			return fmt.Errorf("collection %s already exists", collection)
+		}
+		slog.Debug("Collection already exists but that's fine", "collection", collection)
+		return nil
+	}
+
+	emb, err := v.embeddingFunc(ctx, "dummy text")
+	if err != nil {
+		return fmt.Errorf("failed to get embedding: %w", err)
+	}
+	dimensionality := len(emb)
+
+	body := map[string]any{
+		"collectionName": v.collectionName(collection),
+		"schema": map[string]any{
+			"autoId":             false,
+			"enableDynamicField": false,
+			"fields": []map[string]any{
+				{"fieldName": fieldID, "dataType": "VarChar", "isPrimary": true, "elementTypeParams": map[string]any{"max_length": strconv.Itoa(maxIDLength)}},
+				{"fieldName": fieldContent, "dataType": "VarChar", "elementTypeParams": map[string]any{"max_length": strconv.Itoa(maxContentBytes)}},
+				{"fieldName": fieldMetadata, "dataType": "JSON"},
+				{"fieldName": fieldEmbedding, "dataType": "FloatVector", "elementTypeParams": map[string]any{"dim": strconv.Itoa(dimensionality)}},
+			},
+		},
+		// Creating the collection with an index also loads it, so it's ready for search
+		"indexParams": []map[string]any{
+			{"fieldName": fieldEmbedding, "indexName": fieldEmbedding, "metricType": "COSINE", "indexType": "AUTOINDEX"},
+		},
+	}
+	if err := v.client.do(ctx, "/collections/create", body, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	if err := v.ensureCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(docs))
+	rows := make([]map[string]any, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.embeddingConcurrency)
+
+	for docIdx, doc := range docs {
+		ids[docIdx] = doc.ID
+
+		if len(doc.ID) > maxIDLength {
+			return nil, fmt.Errorf("document ID %q exceeds the maximum length of %d", doc.ID, maxIDLength)
+		}
+		if len(doc.Content) > maxContentBytes {
+			return nil, fmt.Errorf("content of document %s exceeds the maximum size of %d bytes supported by milvus - use a smaller chunk size", doc.ID, maxContentBytes)
+		}
+
+		g.Go(func() error {
+			vec := doc.Embedding
+			if len(vec) == 0 {
+				var err error
+				vec, err = v.embeddingFunc(gctx, doc.Content)
+				if err != nil {
+					slog.Error("failed to embed document", "documentID", doc.ID, "error", err)
+					return fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+				}
+			}
+			metadata := doc.Metadata
+			if metadata == nil {
+				metadata = map[string]any{}
+			}
+			rows[docIdx] = map[string]any{
+				fieldID:        doc.ID,
+				fieldContent:   doc.Content,
+				fieldMetadata:  metadata,
+				fieldEmbedding: vec,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(rows); start += insertBatchSize {
+		batch := rows[start:min(start+insertBatchSize, len(rows))]
+		slog.Debug("Sending batch to milvus", "store", "milvus", "collection", collection, "batchSize", len(batch))
+		body := map[string]any{
+			"collectionName": v.collectionName(collection),
+			"data":           batch,
+		}
+		if err := v.client.do(ctx, "/entities/upsert", body, nil); err != nil {
+			return nil, fmt.Errorf("failed to add documents to milvus: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+type entity struct {
+	ID        string         `json:"id"`
+	Content   string         `json:"content"`
+	Metadata  map[string]any `json:"metadata"`
+	Embedding []float32      `json:"embedding"`
+	Distance  float32        `json:"distance"`
+}
+
+func (e entity) toDocument() vs.Document {
+	doc := vs.Document{
+		ID:        e.ID,
+		Content:   e.Content,
+		Metadata:  e.Metadata,
+		Embedding: e.Embedding,
+		// with the COSINE metric, Milvus returns the cosine similarity as distance
+		SimilarityScore: e.Distance,
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]any{}
+	}
+	return doc
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
+	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", where, "whereDocument", whereDocument, "store", "milvus")
+
+	if err := v.ensureCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+
+	ef := v.embeddingFunc
+	if embeddingFunc != nil {
+		ef = embeddingFunc
+	}
+
+	queryEmbedding, err := ef(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, inMemoryFilters := buildFilter(where, whereDocument)
+
+	limit := numDocuments
+	if len(inMemoryFilters) > 0 {
+		limit *= searchOverfetchFactor
+	}
+
+	body := map[string]any{
+		"collectionName": v.collectionName(collection),
+		"data":           [][]float32{queryEmbedding},
+		"annsField":      fieldEmbedding,
+		"limit":          limit,
+		"outputFields":   []string{fieldID, fieldContent, fieldMetadata},
+	}
+	if filter != "" {
+		body["filter"] = filter
+	}
+
+	var entities []entity
+	if err := v.client.do(ctx, "/entities/search", body, &entities); err != nil {
+		return nil, fmt.Errorf("failed to search milvus: %w", err)
+	}
+
+	docs := make([]vs.Document, 0, len(entities))
+	for _, e := range entities {
+		doc := e.toDocument()
+		if !matchesAll(&doc, inMemoryFilters) {
+			continue
+		}
+		docs = append(docs, doc)
+		if len(docs) >= numDocuments {
+			break
+		}
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) RemoveCollection(ctx context.Context, collection string) error {
+	slog.Debug("Removing collection", "collection", collection, "store", "milvus")
+	if err := v.client.do(ctx, "/collections/drop", map[string]any{"collectionName": v.collectionName(collection)}, nil); err != nil {
+		return fmt.Errorf("failed to remove collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []vs.WhereDocument) error {
+	slog.Info("Removing document", "documentID", documentID, "collection", collection, "where", where, "store", "milvus")
+
+	if err := v.ensureCollection(ctx, collection); err != nil {
+		return err
+	}
+
+	// Where clause takes precedence over documentID for consistency with the other vector stores
+	filter := fieldID + " in [" + quote(documentID) + "]"
+	if len(where) > 0 {
+		var inMemoryFilters []vs.WhereDocument
+		filter, inMemoryFilters = buildFilter(where, whereDocument)
+		if len(inMemoryFilters) > 0 {
+			// Resolve the matching documents first and delete them by ID
+			docs, err := v.GetDocuments(ctx, collection, where, whereDocument)
+			if err != nil {
+				return err
+			}
+			if len(docs) == 0 {
+				return nil
+			}
+			quoted := make([]string, len(docs))
+			for i, doc := range docs {
+				quoted[i] = quote(doc.ID)
+			}
+			filter = fieldID + " in [" + strings.Join(quoted, ", ") + "]"
+		}
+	}
+
+	body := map[string]any{
+		"collectionName": v.collectionName(collection),
+		"filter":         filter,
+	}
+	if err := v.client.do(ctx, "/entities/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to remove document(s) from milvus: %w", err)
+	}
+	return nil
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
+	body := map[string]any{
+		"collectionName": v.collectionName(collection),
+		"id":             []string{documentID},
+		"outputFields":   []string{fieldID, fieldContent, fieldMetadata, fieldEmbedding},
+	}
+	var entities []entity
+	if err := v.client.do(ctx, "/entities/get", body, &entities); err != nil {
+		return vs.Document{}, fmt.Errorf("failed to get document %s from milvus: %w", documentID, err)
+	}
+	if len(entities) == 0 {
+		return vs.Document{}, fmt.Errorf("document %s not found in collection %s", documentID, collection)
+	}
+	return entities[0].toDocument(), nil
+}
+
+// GetDocuments returns all documents matching the filters. If no collection is given, all collections (with the configured prefix) are searched.
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	var collectionNames []string
+	if collection != "" {
+		if err := v.ensureCollection(ctx, collection); err != nil {
+			return nil, err
+		}
+		collectionNames = []string{v.collectionName(collection)}
+	} else {
+		var err error
+		collectionNames, err = v.listCollections(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter, inMemoryFilters := buildFilter(where, whereDocument)
+	if filter == "" {
+		filter = matchAllFilter
+	}
+
+	docs := make([]vs.Document, 0)
+	for _, name := range collectionNames {
+		for offset := 0; ; offset += queryPageSize {
+			body := map[string]any{
+				"collectionName": name,
+				"filter":         filter,
+				"outputFields":   []string{fieldID, fieldContent, fieldMetadata, fieldEmbedding},
+				"limit":          queryPageSize,
+				"offset":         offset,
+			}
+			var entities []entity
+			if err := v.client.do(ctx, "/entities/query", body, &entities); err != nil {
+				return nil, fmt.Errorf("failed to get documents from milvus: %w", err)
+			}
+			for _, e := range entities {
+				doc := e.toDocument()
+				if matchesAll(&doc, inMemoryFilters) {
+					docs = append(docs, doc)
+				}
+			}
+			if len(entities) < queryPageSize {
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+// UpdateDocumentMetadata applies the metadata patch to the document without re-embedding it.
+// Milvus can't update single fields, so the entity is upserted with the patched metadata and its existing embedding.
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	if err := v.ensureCollection(ctx, collection); err != nil {
+		return err
+	}
+
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "patch", patch, "store", "milvus")
+
+	body := map[string]any{
+		"collectionName": v.collectionName(collection),
+		"id":             []string{documentID},
+		"outputFields":   []string{fieldID, fieldContent, fieldMetadata, fieldEmbedding},
+	}
+	var entities []entity
+	if err := v.client.do(ctx, "/entities/get", body, &entities); err != nil {
+		return fmt.Errorf("failed to get document %s from milvus: %w", documentID, err)
+	}
+	if len(entities) == 0 {
+		return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+	}
+	e := entities[0]
+
+	body = map[string]any{
+		"collectionName": v.collectionName(collection),
+		"data": []map[string]any{{
+			fieldID:        e.ID,
+			fieldContent:   e.Content,
+			fieldMetadata:  patch.Apply(e.Metadata),
+			fieldEmbedding: e.Embedding,
+		}},
+	}
+	if err := v.client.do(ctx, "/entities/upsert", body, nil); err != nil {
+		return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats for vectorstore milvus", vserr.ErrNotSupported)
+}
+
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
+func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ImportCollections(ctx, v, "milvus", path, collections...)
+}
+
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
+func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ExportCollections(ctx, v, "milvus", path, v.collectionNames, collections...)
+}
+
+// collectionNames returns the names of the collections with the configured prefix, which are valid collection names
+// themselves (already sanitized), but differ from the original names if those had to be sanitized
+func (v *VectorStore) collectionNames(ctx context.Context) ([]string, error) {
+	names, err := v.listCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = strings.TrimPrefix(name, v.collectionPrefix)
+	}
+	return names, nil
+}
@@ -0,0 +1,51 @@
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentMetadata(t *testing.T) {
+	var upserted []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.URL.Path {
+		case "/v2/vectordb/collections/has":
+			_, _ = w.Write([]byte(`{"code": 0, "data": {"has": true}}`))
+		case "/v2/vectordb/entities/get":
+			if ids, _ := body["id"].([]any); len(ids) == 1 && ids[0] == "doc1" {
+				_, _ = w.Write([]byte(`{"code": 0, "data": [{"id": "doc1", "content": "foo", "metadata": {"source": "a.pdf", "draft": true}, "embedding": [0.5, 0.25]}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"code": 0, "data": []}`))
+		case "/v2/vectordb/entities/upsert":
+			upserted, _ = body["data"].([]any)
+			_, _ = w.Write([]byte(`{"code": 0, "data": {"upsertCount": 1}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	v := &VectorStore{client: &client{baseURL: srv.URL, httpClient: srv.Client()}, collectionPrefix: defaultCollectionPrefix}
+	ctx := context.Background()
+
+	require.NoError(t, v.UpdateDocumentMetadata(ctx, "doc1", "ds", vs.MetadataPatch{"tags": []string{"a"}, "draft": nil}))
+	assert.Equal(t, []any{map[string]any{
+		"id":        "doc1",
+		"content":   "foo",
+		"metadata":  map[string]any{"source": "a.pdf", "tags": []any{"a"}},
+		"embedding": []any{0.5, 0.25},
+	}}, upserted)
+
+	assert.ErrorIs(t, v.UpdateDocumentMetadata(ctx, "doc2", "ds", vs.MetadataPatch{"draft": nil}), vserr.ErrDocumentNotFound)
+}
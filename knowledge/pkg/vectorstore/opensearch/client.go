@@ -0,0 +1,113 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// client is a minimal client for the OpenSearch REST API (https://opensearch.org/docs/latest/api-reference/)
+type client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+type apiError struct {
+	StatusCode int
+	Type       string
+	Reason     string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("opensearch API error (status %d): %s: %s", e.StatusCode, e.Type, e.Reason)
+}
+
+func isNotFound(err error) bool {
+	var e *apiError
+	if errors.As(err, &e) {
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+func isIndexNotFound(err error) bool {
+	var e *apiError
+	if errors.As(err, &e) {
+		return e.Type == "index_not_found_exception"
+	}
+	return false
+}
+
+// do sends a JSON request to the OpenSearch API and decodes the response into result (if not nil)
+func (c *client) do(ctx context.Context, method, path string, body any, result any) error {
+	var b []byte
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+	return c.doRaw(ctx, method, path, "application/json", b, result)
+}
+
+// doRaw sends a request with a pre-encoded body (e.g. NDJSON for the bulk API)
+func (c *client) doRaw(ctx context.Context, method, path, contentType string, body []byte, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to opensearch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read opensearch response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode, Reason: strings.TrimSpace(string(respBody))}
+		var r struct {
+			Error json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(respBody, &r); err == nil && len(r.Error) > 0 {
+			var e struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			}
+			if err := json.Unmarshal(r.Error, &e); err == nil {
+				apiErr.Type, apiErr.Reason = e.Type, e.Reason
+			}
+		}
+		return apiErr
+	}
+
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to decode opensearch response: %w", err)
+		}
+	}
+	return nil
+}
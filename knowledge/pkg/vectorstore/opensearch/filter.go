@@ -0,0 +1,56 @@
+package opensearch
+
+import (
+	"slices"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const (
+	fieldDocumentID = "document_id"
+	fieldContent    = "content"
+	fieldMetadata   = "metadata"
+	fieldEmbedding  = "embedding"
+
+	// fieldMetadataValues holds the stringified metadata values as keywords for exact matching,
+	// while fieldMetadata keeps the original (typed) metadata, but is not indexed.
+	fieldMetadataValues = "meta"
+)
+
+// metadataValues converts the metadata into the keyword values used for filtering - lists are stored as one keyword per element
+func metadataValues(metadata map[string]any) map[string][]string {
+	values := make(map[string][]string, len(metadata))
+	for k, v := range metadata {
+		values[k] = vs.MetadataStrings(v)
+	}
+	return values
+}
+
+// buildFilter translates the metadata (where) filter into a list of OpenSearch terms queries.
+// Each where value matches its string, number and boolean representations (see vs.WhereStrings) and list elements.
+// Content (whereDocument) filters are applied in memory, since full-text matching is token-based and doesn't support exact substring matching.
+func buildFilter(where map[string]string) []map[string]any {
+	keys := make([]string, 0, len(where))
+	for k := range where {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	filter := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		filter = append(filter, map[string]any{
+			"terms": map[string]any{fieldMetadataValues + "." + k: vs.WhereStrings(where[k])},
+		})
+	}
+	return filter
+}
+
+// matchesAll checks whether the document matches all given content filters
+func matchesAll(doc *vs.Document, whereDocument []vs.WhereDocument) bool {
+	for _, wd := range whereDocument {
+		if !wd.Matches(doc) {
+			return false
+		}
+	}
+	return true
+}
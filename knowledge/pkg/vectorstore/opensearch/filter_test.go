@@ -0,0 +1,25 @@
+package opensearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildFilter(t *testing.T) {
+	assert.Equal(t, []map[string]any{
+		{"terms": map[string]any{"meta.absPath": []string{"/data/a.pdf"}}},
+		{"terms": map[string]any{"meta.page": []string{"2"}}},
+	}, buildFilter(map[string]string{"page": "2", "absPath": "/data/a.pdf"}))
+	assert.Empty(t, buildFilter(nil))
+
+	assert.Equal(t, map[string][]string{"page": {"2"}, "absPath": {"/data/a.pdf"}, "ocr": {"true"}, "tags": {"a", "b"}},
+		metadataValues(map[string]any{"page": 2, "absPath": "/data/a.pdf", "ocr": true, "tags": []any{"a", "b"}}))
+}
+
+func TestIndexName(t *testing.T) {
+	v := &VectorStore{prefix: defaultPrefix}
+	assert.Equal(t, "knowledge_my-dataset", v.indexName("my-dataset"))
+	assert.Regexp(t, `^knowledge_my_dataset_[0-9a-f]{8}$`, v.indexName("My Dataset"))
+	assert.NotEqual(t, v.indexName("my-Dataset"), v.indexName("my-dataset"))
+}
@@ -0,0 +1,690 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// VsOpenSearchEmbeddingConcurrency can be set as an environment variable to control the number of parallel API calls to create embedding for documents. Default is 100
+	VsOpenSearchEmbeddingConcurrency = "VS_OPENSEARCH_EMBEDDING_CONCURRENCY"
+
+	// VsOpenSearchUsername and VsOpenSearchPassword can be set as environment variables to provide the credentials, if they're not part of the DSN
+	VsOpenSearchUsername = "VS_OPENSEARCH_USERNAME"
+	VsOpenSearchPassword = "VS_OPENSEARCH_PASSWORD"
+
+	defaultPrefix             = "knowledge_"
+	defaultHybridVectorWeight = 0.7
+
+	bulkBatchSize = 500
+	pageSize      = 1000
+
+	// searchOverfetchFactor is used to fetch more candidates if content filters have to be applied in memory
+	searchOverfetchFactor = 4
+)
+
+var invalidIndexCharsRegex = regexp.MustCompile(`[^_0-9a-z-]`)
+
+// VectorStore stores documents in one OpenSearch index per collection, with the raw text indexed for full-text search
+// next to the embeddings (k-NN plugin, Lucene HNSW). That enables server-side hybrid search (k-NN + BM25) using
+// the hybrid query with a normalization search pipeline (neural-search plugin, OpenSearch >= 2.10).
+type VectorStore struct {
+	embeddingFunc        vs.EmbeddingFunc
+	embeddingConcurrency int
+	client               *client
+	prefix               string
+
+	// hybrid enables hybrid search for all similarity searches. Otherwise, it can be requested per search via vs.SearchOptionsToCtx.
+	hybrid             bool
+	hybridVectorWeight float64
+
+	pipelineLock    sync.Mutex
+	pipelineCreated bool
+}
+
+// New creates a new OpenSearch vector store from a DSN of the form
+//
+//	opensearch://[user:password@]host[:port][?tls=true&insecure=true&prefix=knowledge_&hybrid=true&hybridVectorWeight=0.7]
+//
+// hybridVectorWeight is the weight of the vector similarity score in hybrid search, the rest goes to the full-text (BM25) score.
+func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse opensearch DSN: %w", err)
+	}
+
+	q := u.Query()
+
+	scheme := "http"
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if q.Get("tls") == "true" {
+		scheme = "https"
+		if q.Get("insecure") == "true" {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicitly requested, e.g. for self-signed certificates of local clusters
+		}
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host += ":9200"
+	}
+
+	username, password := os.Getenv(VsOpenSearchUsername), os.Getenv(VsOpenSearchPassword)
+	if u.User != nil {
+		username = u.User.Username()
+		if p, ok := u.User.Password(); ok {
+			password = p
+		}
+	}
+
+	prefix := q.Get("prefix")
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	prefix = strings.ToLower(prefix)
+
+	hybridVectorWeight := defaultHybridVectorWeight
+	if w := q.Get("hybridVectorWeight"); w != "" {
+		hybridVectorWeight, err = strconv.ParseFloat(w, 64)
+		if err != nil || hybridVectorWeight < 0 || hybridVectorWeight > 1 {
+			return nil, fmt.Errorf("invalid hybridVectorWeight %q: must be a number between 0 and 1", w)
+		}
+	}
+
+	store := &VectorStore{
+		embeddingFunc:        embeddingFunc,
+		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsOpenSearchEmbeddingConcurrency, 100),
+		client: &client{
+			baseURL:    scheme + "://" + host + strings.TrimSuffix(u.Path, "/"),
+			username:   username,
+			password:   password,
+			httpClient: &http.Client{Timeout: 60 * time.Second, Transport: transport},
+		},
+		prefix:             prefix,
+		hybrid:             q.Get("hybrid") == "true",
+		hybridVectorWeight: hybridVectorWeight,
+	}
+
+	slog.Debug("opensearch", "url", store.client.baseURL, "prefix", store.prefix, "hybrid", store.hybrid, "hybridVectorWeight", store.hybridVectorWeight)
+
+	// Check connectivity
+	if err := store.client.do(ctx, http.MethodGet, "/", nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to connect to opensearch: %w", err)
+	}
+
+	return store, nil
+}
+
+func (v *VectorStore) Close() error {
+	v.client.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// indexName returns the index name for the collection. Index names must be lowercase and may only contain a limited set of characters,
+// so a hash suffix is added if sanitizing changed the name to avoid collisions.
+func (v *VectorStore) indexName(collection string) string {
+	sanitized := invalidIndexCharsRegex.ReplaceAllString(strings.ToLower(collection), "_")
+	if sanitized != collection {
+		h := sha1.Sum([]byte(collection))
+		sanitized += "_" + hex.EncodeToString(h[:4])
+	}
+	return v.prefix + sanitized
+}
+
+func (v *VectorStore) pipelineName() string {
+	return v.prefix + "hybrid"
+}
+
+func (v *VectorStore) indexExists(ctx context.Context, collection string) (bool, error) {
+	err := v.client.do(ctx, http.MethodHead, "/"+url.PathEscape(v.indexName(collection)), nil, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (v *VectorStore) CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error {
+	if opts == nil {
+		opts = &dbtypes.DatasetCreateOpts{}
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "index", v.indexName(collection), "store", "opensearch")
+
+	exists, err := v.indexExists(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("failed to check if collection %s exists: %w", collection, err)
+	}
+	if exists {
+		if opts.ErrOnExists {
+			return fmt.Errorf("collection %s already exists", collection)
+		}
+		slog.Debug("Collection already exists but that's fine", "collection", collection)
+		return nil
+	}
+
+	emb, err := v.embeddingFunc(ctx, "dummy text")
+	if err != nil {
+		return fmt.Errorf("failed to get embedding: %w", err)
+	}
+	dimensionality := len(emb)
+
+	body := map[string]any{
+		"settings": map[string]any{
+			"index": map[string]any{"knn": true},
+		},
+		"mappings": map[string]any{
+			"dynamic_templates": []map[string]any{
+				{
+					"metadata_values": map[string]any{
+						"path_match": fieldMetadataValues + ".*",
+						"mapping":    map[string]any{"type": "keyword"},
+					},
+				},
+			},
+			"properties": map[string]any{
+				fieldDocumentID: map[string]any{"type": "keyword"},
+				fieldContent:    map[string]any{"type": "text"},
+				fieldMetadata:   map[string]any{"type": "object", "enabled": false},
+				fieldEmbedding: map[string]any{
+					"type":      "knn_vector",
+					"dimension": dimensionality,
+					"method": map[string]any{
+						"name":       "hnsw",
+						"space_type": "cosinesimil",
+						"engine":     "lucene",
+					},
+				},
+			},
+		},
+	}
+
+	if err := v.client.do(ctx, http.MethodPut, "/"+url.PathEscape(v.indexName(collection)), body, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk sends a bulk request (alternating action and source lines) and returns the first item error, if any
+func (v *VectorStore) bulk(ctx context.Context, lines []any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("failed to encode bulk request: %w", err)
+		}
+	}
+
+	var res bulkResponse
+	if err := v.client.doRaw(ctx, http.MethodPost, "/_bulk?refresh=wait_for", "application/x-ndjson", buf.Bytes(), &res); err != nil {
+		return err
+	}
+	if res.Errors {
+		for _, item := range res.Items {
+			for op, result := range item {
+				// deleting a non-existent document is fine
+				if result.Error != nil && !(op == "delete" && result.Status == http.StatusNotFound) {
+					return fmt.Errorf("bulk %s of document %s failed: %s: %s", op, result.ID, result.Error.Type, result.Error.Reason)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	exists, err := v.indexExists(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+
+	ids := make([]string, len(docs))
+	sources := make([]map[string]any, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.embeddingConcurrency)
+
+	for docIdx, doc := range docs {
+		ids[docIdx] = doc.ID
+
+		g.Go(func() error {
+			vec := doc.Embedding
+			if len(vec) == 0 {
+				var err error
+				vec, err = v.embeddingFunc(gctx, doc.Content)
+				if err != nil {
+					slog.Error("failed to embed document", "documentID", doc.ID, "error", err)
+					return fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+				}
+			}
+
+			sources[docIdx] = map[string]any{
+				fieldDocumentID:     doc.ID,
+				fieldContent:        doc.Content,
+				fieldMetadata:       doc.Metadata,
+				fieldMetadataValues: metadataValues(doc.Metadata),
+				fieldEmbedding:      vec,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	index := v.indexName(collection)
+	for start := 0; start < len(sources); start += bulkBatchSize {
+		batch := sources[start:min(start+bulkBatchSize, len(sources))]
+		lines := make([]any, 0, 2*len(batch))
+		for _, src := range batch {
+			lines = append(lines, map[string]any{"index": map[string]any{"_index": index, "_id": src[fieldDocumentID]}}, src)
+		}
+		slog.Debug("Sending batch to opensearch", "store", "opensearch", "collection", collection, "batchSize", len(batch))
+		if err := v.bulk(ctx, lines); err != nil {
+			return nil, fmt.Errorf("failed to add documents to opensearch: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+// ensureHybridPipeline creates the search pipeline used to normalize and combine the scores of the hybrid query
+func (v *VectorStore) ensureHybridPipeline(ctx context.Context) error {
+	v.pipelineLock.Lock()
+	defer v.pipelineLock.Unlock()
+
+	if v.pipelineCreated {
+		return nil
+	}
+
+	body := map[string]any{
+		"description": "Hybrid search for knowledge (BM25 + k-NN)",
+		"phase_results_processors": []map[string]any{
+			{
+				"normalization-processor": map[string]any{
+					"normalization": map[string]any{"technique": "min_max"},
+					"combination": map[string]any{
+						"technique": "arithmetic_mean",
+						"parameters": map[string]any{
+							// same order as the queries in the hybrid query: full-text, k-NN
+							"weights": []float64{math.Round((1-v.hybridVectorWeight)*1000) / 1000, v.hybridVectorWeight},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := v.client.do(ctx, http.MethodPut, "/_search/pipeline/"+url.PathEscape(v.pipelineName()), body, nil); err != nil {
+		return fmt.Errorf("failed to create hybrid search pipeline: %w", err)
+	}
+	v.pipelineCreated = true
+	return nil
+}
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			ID     string          `json:"_id"`
+			Score  float32         `json:"_score"`
+			Source json.RawMessage `json:"_source"`
+			Sort   []any           `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+type source struct {
+	DocumentID string         `json:"document_id"`
+	Content    string         `json:"content"`
+	Metadata   map[string]any `json:"metadata"`
+	Embedding  []float32      `json:"embedding"`
+}
+
+func (s *source) toDocument() vs.Document {
+	doc := vs.Document{
+		ID:        s.DocumentID,
+		Content:   s.Content,
+		Metadata:  s.Metadata,
+		Embedding: s.Embedding,
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]any{}
+	}
+	return doc
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
+	hybrid := v.hybrid || vs.SearchOptionsFromCtx(ctx).Hybrid
+
+	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", where, "whereDocument", whereDocument, "hybrid", hybrid, "store", "opensearch")
+
+	ef := v.embeddingFunc
+	if embeddingFunc != nil {
+		ef = embeddingFunc
+	}
+
+	queryEmbedding, err := ef(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	k := numDocuments
+	if len(whereDocument) > 0 {
+		k *= searchOverfetchFactor
+	}
+
+	filter := buildFilter(where)
+
+	knn := map[string]any{
+		"vector": queryEmbedding,
+		"k":      k,
+	}
+	if len(filter) > 0 {
+		knn["filter"] = map[string]any{"bool": map[string]any{"filter": filter}}
+	}
+	knnQuery := map[string]any{"knn": map[string]any{fieldEmbedding: knn}}
+
+	body := map[string]any{
+		"size":    k,
+		"_source": map[string]any{"excludes": []string{fieldEmbedding, fieldMetadataValues}},
+		"query":   knnQuery,
+	}
+
+	path := "/" + url.PathEscape(v.indexName(collection)) + "/_search"
+	if hybrid {
+		if err := v.ensureHybridPipeline(ctx); err != nil {
+			return nil, err
+		}
+		textQuery := map[string]any{
+			"bool": map[string]any{
+				"must":   []map[string]any{{"match": map[string]any{fieldContent: query}}},
+				"filter": filter,
+			},
+		}
+		body["query"] = map[string]any{
+			"hybrid": map[string]any{"queries": []map[string]any{textQuery, knnQuery}},
+		}
+		path += "?search_pipeline=" + url.QueryEscape(v.pipelineName())
+	}
+
+	var res searchResponse
+	if err := v.client.do(ctx, http.MethodPost, path, body, &res); err != nil {
+		if isIndexNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		return nil, fmt.Errorf("failed to search opensearch: %w", err)
+	}
+
+	docs := make([]vs.Document, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		var src source
+		if err := json.Unmarshal(hit.Source, &src); err != nil {
+			return nil, fmt.Errorf("failed to decode document %s: %w", hit.ID, err)
+		}
+		doc := src.toDocument()
+		if hybrid {
+			// already normalized to [0, 1] by the search pipeline
+			doc.SimilarityScore = hit.Score
+		} else {
+			// Lucene scores cosine similarity as (1 + cos) / 2 - convert back for consistency with the other vector stores
+			doc.SimilarityScore = 2*hit.Score - 1
+		}
+		if !matchesAll(&doc, whereDocument) {
+			continue
+		}
+		docs = append(docs, doc)
+		if len(docs) >= numDocuments {
+			break
+		}
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) RemoveCollection(ctx context.Context, collection string) error {
+	slog.Debug("Removing collection", "collection", collection, "store", "opensearch")
+	if err := v.client.do(ctx, http.MethodDelete, "/"+url.PathEscape(v.indexName(collection)), nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []vs.WhereDocument) error {
+	slog.Info("Removing document", "documentID", documentID, "collection", collection, "where", where, "store", "opensearch")
+
+	index := "/" + url.PathEscape(v.indexName(collection))
+
+	// Where clause takes precedence over documentID for consistency with the other vector stores
+	if len(where) == 0 {
+		if err := v.client.do(ctx, http.MethodDelete, index+"/_doc/"+url.PathEscape(documentID)+"?refresh=wait_for", nil, nil); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to remove document %s from opensearch: %w", documentID, err)
+		}
+		return nil
+	}
+
+	if len(whereDocument) == 0 {
+		body := map[string]any{"query": map[string]any{"bool": map[string]any{"filter": buildFilter(where)}}}
+		if err := v.client.do(ctx, http.MethodPost, index+"/_delete_by_query?refresh=true", body, nil); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to remove documents from opensearch: %w", err)
+		}
+		return nil
+	}
+
+	docs, err := v.GetDocuments(ctx, collection, where, whereDocument)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(docs); start += bulkBatchSize {
+		batch := docs[start:min(start+bulkBatchSize, len(docs))]
+		lines := make([]any, 0, len(batch))
+		for _, doc := range batch {
+			lines = append(lines, map[string]any{"delete": map[string]any{"_index": v.indexName(collection), "_id": doc.ID}})
+		}
+		if err := v.bulk(ctx, lines); err != nil {
+			return fmt.Errorf("failed to remove documents from opensearch: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
+	var res struct {
+		Found  bool   `json:"found"`
+		Source source `json:"_source"`
+	}
+	path := "/" + url.PathEscape(v.indexName(collection)) + "/_doc/" + url.PathEscape(documentID) + "?_source_excludes=" + fieldMetadataValues
+	if err := v.client.do(ctx, http.MethodGet, path, nil, &res); err != nil {
+		if isNotFound(err) {
+			return vs.Document{}, fmt.Errorf("document %s not found in collection %s", documentID, collection)
+		}
+		return vs.Document{}, fmt.Errorf("failed to get document %s from opensearch: %w", documentID, err)
+	}
+	if !res.Found {
+		return vs.Document{}, fmt.Errorf("document %s not found in collection %s", documentID, collection)
+	}
+	return res.Source.toDocument(), nil
+}
+
+// GetDocuments returns all documents matching the filters. If no collection is given, all collections (indexes with the configured prefix) are searched.
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	var indexes []string
+	if collection != "" {
+		indexes = []string{v.indexName(collection)}
+	} else {
+		var err error
+		indexes, err = v.listIndexes(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	query := map[string]any{"match_all": map[string]any{}}
+	if filter := buildFilter(where); len(filter) > 0 {
+		query = map[string]any{"bool": map[string]any{"filter": filter}}
+	}
+
+	docs := make([]vs.Document, 0)
+	for _, index := range indexes {
+		// document IDs are unique within an index, so they can be used for pagination
+		var searchAfter []any
+		for {
+			body := map[string]any{
+				"size":    pageSize,
+				"query":   query,
+				"_source": map[string]any{"excludes": []string{fieldMetadataValues}},
+				"sort":    []map[string]any{{fieldDocumentID: "asc"}},
+			}
+			if searchAfter != nil {
+				body["search_after"] = searchAfter
+			}
+
+			var res searchResponse
+			if err := v.client.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", body, &res); err != nil {
+				if isIndexNotFound(err) {
+					if collection == "" {
+						break // removed in the meantime
+					}
+					return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+				}
+				return nil, fmt.Errorf("failed to get documents from opensearch: %w", err)
+			}
+
+			for _, hit := range res.Hits.Hits {
+				var src source
+				if err := json.Unmarshal(hit.Source, &src); err != nil {
+					return nil, fmt.Errorf("failed to decode document %s: %w", hit.ID, err)
+				}
+				doc := src.toDocument()
+				if matchesAll(&doc, whereDocument) {
+					docs = append(docs, doc)
+				}
+			}
+
+			if len(res.Hits.Hits) < pageSize {
+				break
+			}
+			searchAfter = res.Hits.Hits[len(res.Hits.Hits)-1].Sort
+		}
+	}
+	return docs, nil
+}
+
+// listIndexes returns the indexes with the configured prefix
+func (v *VectorStore) listIndexes(ctx context.Context) ([]string, error) {
+	var res []struct {
+		Index string `json:"index"`
+	}
+	if err := v.client.do(ctx, http.MethodGet, "/_cat/indices/"+url.PathEscape(v.prefix)+"*?format=json&h=index", nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to list opensearch indexes: %w", err)
+	}
+	indexes := make([]string, 0, len(res))
+	for _, r := range res {
+		indexes = append(indexes, r.Index)
+	}
+	return indexes, nil
+}
+
+// collectionNames returns the collection names of the indexes with the configured prefix, which are valid collection names
+// themselves (already sanitized), but differ from the original names if those had to be sanitized
+func (v *VectorStore) collectionNames(ctx context.Context) ([]string, error) {
+	indexes, err := v.listIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		names = append(names, strings.TrimPrefix(index, v.prefix))
+	}
+	return names, nil
+}
+
+// updateMetadataScript applies a metadata patch to the typed metadata and the stringified metadata values of a document
+var updateMetadataScript = fmt.Sprintf(`
+if (ctx._source['%[1]s'] == null) { ctx._source['%[1]s'] = new HashMap(); }
+if (ctx._source['%[2]s'] == null) { ctx._source['%[2]s'] = new HashMap(); }
+for (entry in params.set.entrySet()) { ctx._source['%[1]s'][entry.getKey()] = entry.getValue(); }
+for (entry in params.values.entrySet()) { ctx._source['%[2]s'][entry.getKey()] = entry.getValue(); }
+for (key in params.remove) { ctx._source['%[1]s'].remove(key); ctx._source['%[2]s'].remove(key); }
+`, fieldMetadata, fieldMetadataValues)
+
+// UpdateDocumentMetadata applies the metadata patch to the document in place (update API with a script) without re-embedding it
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	set, remove := patch.Split()
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "set", set, "remove", remove, "store", "opensearch")
+
+	body := map[string]any{
+		"script": map[string]any{
+			"lang":   "painless",
+			"source": updateMetadataScript,
+			"params": map[string]any{
+				"set":    set,
+				"values": metadataValues(set),
+				"remove": remove,
+			},
+		},
+	}
+	path := "/" + url.PathEscape(v.indexName(collection)) + "/_update/" + url.PathEscape(documentID) + "?refresh=wait_for"
+	if err := v.client.do(ctx, http.MethodPost, path, body, nil); err != nil {
+		if isIndexNotFound(err) {
+			return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+		}
+		return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats for vectorstore opensearch", vserr.ErrNotSupported)
+}
+
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
+func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ImportCollections(ctx, v, "opensearch", path, collections...)
+}
+
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
+func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ExportCollections(ctx, v, "opensearch", path, v.collectionNames, collections...)
+}
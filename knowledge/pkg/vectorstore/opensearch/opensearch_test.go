@@ -0,0 +1,51 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentMetadata(t *testing.T) {
+	var params map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/knowledge_ds/_update/doc1":
+			var body struct {
+				Script struct {
+					Params map[string]any `json:"params"`
+				} `json:"script"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			params = body.Script.Params
+			_, _ = w.Write([]byte(`{"result": "updated"}`))
+		case "/knowledge_missing/_update/doc1":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"type": "index_not_found_exception", "reason": "no such index"}, "status": 404}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": {"type": "document_missing_exception", "reason": "document missing"}, "status": 404}`))
+		}
+	}))
+	defer srv.Close()
+
+	v := &VectorStore{client: &client{baseURL: srv.URL, httpClient: srv.Client()}, prefix: "knowledge_"}
+	ctx := context.Background()
+
+	require.NoError(t, v.UpdateDocumentMetadata(ctx, "doc1", "ds", vs.MetadataPatch{"tags": []string{"a", "b"}, "page": 3, "draft": nil}))
+	assert.Equal(t, map[string]any{
+		"set":    map[string]any{"tags": []any{"a", "b"}, "page": float64(3)},
+		"values": map[string]any{"tags": []any{"a", "b"}, "page": []any{"3"}},
+		"remove": []any{"draft"},
+	}, params)
+
+	assert.ErrorIs(t, v.UpdateDocumentMetadata(ctx, "doc2", "ds", vs.MetadataPatch{"draft": nil}), vserr.ErrDocumentNotFound)
+	assert.ErrorIs(t, v.UpdateDocumentMetadata(ctx, "doc1", "missing", vs.MetadataPatch{"draft": nil}), vserr.ErrCollectionNotFound)
+}
@@ -0,0 +1,69 @@
+package pgvector
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// VsPgvectorDistance can be set as an environment variable to select the default distance function for new collections: cosine (default), l2 or inner_product
+	VsPgvectorDistance = "VS_PGVECTOR_DISTANCE"
+
+	DistanceCosine       = "cosine"
+	DistanceL2           = "l2"
+	DistanceInnerProduct = "inner_product"
+
+	// collectionMetadataKeyDistance is the key in the collection metadata (cmetadata) holding the collection's distance function
+	collectionMetadataKeyDistance = "distance"
+)
+
+// distanceFunction describes how to compute and index a distance function
+type distanceFunction struct {
+	operator    string // distance operator
+	opsSuffix   string // operator class suffix, prefixed by the vector type (e.g. vector_cosine_ops)
+	similarity  string // format string converting the distance into a similarity score (higher is better), consistent with cosine similarity for normalized vectors
+	description string
+}
+
+var distanceFunctions = map[string]distanceFunction{
+	DistanceCosine:       {operator: "<=>", opsSuffix: "cosine_ops", similarity: "1 - (%s)", description: "cosine distance"},
+	DistanceL2:           {operator: "<->", opsSuffix: "l2_ops", similarity: "1 - power(%s, 2) / 2", description: "euclidean (L2) distance"},
+	DistanceInnerProduct: {operator: "<#>", opsSuffix: "ip_ops", similarity: "(%s) * -1", description: "(negative) inner product"},
+}
+
+// normalizeDistance validates the distance function name and returns its canonical form (empty means cosine)
+func normalizeDistance(distance string) (string, error) {
+	d := strings.ToLower(strings.TrimSpace(distance))
+	switch d {
+	case "":
+		return DistanceCosine, nil
+	case "ip", "innerproduct", "dot":
+		return DistanceInnerProduct, nil
+	case "euclidean":
+		return DistanceL2, nil
+	}
+	if _, ok := distanceFunctions[d]; !ok {
+		supported := make([]string, 0, len(distanceFunctions))
+		for k := range distanceFunctions {
+			supported = append(supported, k)
+		}
+		slices.Sort(supported)
+		return "", fmt.Errorf("unsupported pgvector distance function %q (supported: %s)", distance, strings.Join(supported, ", "))
+	}
+	return d, nil
+}
+
+// getCollectionDistance returns the distance function configured for the collection (cosine, if none was configured)
+func (v VectorStore) getCollectionDistance(ctx context.Context, collection string) (string, error) {
+	var distance *string
+	err := v.conn.QueryRow(ctx, fmt.Sprintf(`SELECT cmetadata ->> '%s' FROM %s WHERE name = $1`, collectionMetadataKeyDistance, v.collectionTableName), collection).Scan(&distance)
+	if err != nil {
+		return "", err
+	}
+	if distance == nil {
+		return DistanceCosine, nil
+	}
+	return normalizeDistance(*distance)
+}
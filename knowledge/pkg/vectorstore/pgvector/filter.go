@@ -0,0 +1,120 @@
+package pgvector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// buildMetadataFilterClause translates the metadata filter to a condition on the jsonb cmetadata column, appending its parameters to args.
+// Equality is checked via containment (@>), which can use the GIN index on cmetadata.
+func buildMetadataFilterClause(f *vs.MetadataFilter, args []any) (string, []any, error) {
+	b := &filterBuilder{args: args}
+	clause, err := b.build(f)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause, b.args, nil
+}
+
+type filterBuilder struct {
+	args []any
+}
+
+func (b *filterBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *filterBuilder) build(f *vs.MetadataFilter) (string, error) {
+	switch f.Operator {
+	case vs.FilterOperatorAnd, vs.FilterOperatorOr:
+		if len(f.Filters) == 0 {
+			if f.Operator == vs.FilterOperatorAnd {
+				return "TRUE", nil
+			}
+			return "FALSE", nil
+		}
+		clauses := make([]string, 0, len(f.Filters))
+		for i := range f.Filters {
+			c, err := b.build(&f.Filters[i])
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, c)
+		}
+		return "(" + strings.Join(clauses, " "+strings.ToUpper(strings.TrimPrefix(string(f.Operator), "$"))+" ") + ")", nil
+	case vs.FilterOperatorEq:
+		return b.equals(f.Key, f.Value)
+	case vs.FilterOperatorNe:
+		c, err := b.equals(f.Key, f.Value)
+		return "NOT " + c, err
+	case vs.FilterOperatorIn, vs.FilterOperatorNin:
+		values, _ := f.Value.([]any)
+		clauses := make([]string, 0, len(values))
+		for _, v := range values {
+			c, err := b.equals(f.Key, v)
+			if err != nil {
+				return "", err
+			}
+			clauses = append(clauses, c)
+		}
+		clause := "FALSE"
+		if len(clauses) > 0 {
+			clause = "(" + strings.Join(clauses, " OR ") + ")"
+		}
+		if f.Operator == vs.FilterOperatorNin {
+			return "NOT " + clause, nil
+		}
+		return clause, nil
+	case vs.FilterOperatorGt, vs.FilterOperatorGte, vs.FilterOperatorLt, vs.FilterOperatorLte:
+		op := map[vs.FilterOperator]string{vs.FilterOperatorGt: ">", vs.FilterOperatorGte: ">=", vs.FilterOperatorLt: "<", vs.FilterOperatorLte: "<="}[f.Operator]
+		key := b.arg(f.Key)
+		// the CASE guards the cast, as AND doesn't guarantee the evaluation order
+		if s, ok := f.Value.(string); ok {
+			return fmt.Sprintf(`COALESCE((CASE WHEN jsonb_typeof(cmetadata -> %[1]s::text) = 'string' THEN cmetadata ->> %[1]s::text END) COLLATE "C" %[2]s %[3]s::text, FALSE)`, key, op, b.arg(s)), nil
+		}
+		return fmt.Sprintf(`COALESCE((CASE WHEN jsonb_typeof(cmetadata -> %[1]s::text) = 'number' THEN (cmetadata ->> %[1]s::text)::float8 END) %[2]s %[3]s::float8, FALSE)`, key, op, b.arg(f.Value)), nil
+	case vs.FilterOperatorContains:
+		element, err := b.containment(f.Key, []any{f.Value})
+		if err != nil {
+			return "", err
+		}
+		s, ok := f.Value.(string)
+		if !ok {
+			return element, nil
+		}
+		key := b.arg(f.Key)
+		return fmt.Sprintf(`(COALESCE(jsonb_typeof(cmetadata -> %[1]s::text) = 'string' AND strpos(cmetadata ->> %[1]s::text, %[2]s::text) > 0, FALSE) OR %[3]s)`, key, b.arg(s), element), nil
+	case vs.FilterOperatorExists:
+		clause := fmt.Sprintf("cmetadata ? %s::text", b.arg(f.Key))
+		if exists, _ := f.Value.(bool); !exists {
+			return "NOT " + clause, nil
+		}
+		return clause, nil
+	}
+	return "", fmt.Errorf("unsupported metadata filter operator %q", f.Operator)
+}
+
+// equals matches the value or, if the metadata value is a list, its elements
+func (b *filterBuilder) equals(key string, value any) (string, error) {
+	scalar, err := b.containment(key, value)
+	if err != nil {
+		return "", err
+	}
+	element, err := b.containment(key, []any{value})
+	if err != nil {
+		return "", err
+	}
+	return "(" + scalar + " OR " + element + ")", nil
+}
+
+func (b *filterBuilder) containment(key string, value any) (string, error) {
+	j, err := json.Marshal(map[string]any{key: value})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cmetadata @> %s::jsonb", b.arg(string(j))), nil
+}
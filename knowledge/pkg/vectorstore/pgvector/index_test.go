@@ -0,0 +1,99 @@
+package pgvector
+
+import (
+	"context"
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	dsn, opts, err := parseOptions("postgres://u:p@localhost:5432/db?sslmode=disable&index=ivfflat&ivfflatLists=50&ivfflatProbes=5")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://u:p@localhost:5432/db?sslmode=disable", dsn)
+	assert.Equal(t, &options{
+		ivfflatIndex: &IVFFlatIndex{lists: 50, probes: 5},
+		vectorType:   VectorTypeVector,
+		quantization: QuantizationNone,
+		distance:     DistanceCosine,
+	}, opts)
+
+	t.Setenv(VsPgvectorIndex, IndexTypeHNSW)
+	t.Setenv(VsPgvectorHNSWM, "32")
+	dsn, opts, err = parseOptions("postgres://localhost/db?vectorType=halfvec&quantization=binary&distance=ip")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://localhost/db", dsn)
+	assert.Equal(t, &options{
+		hnswIndex:    &HNSWIndex{m: 32, efConstruction: 64},
+		vectorType:   VectorTypeHalfvec,
+		quantization: QuantizationBinary,
+		distance:     DistanceInnerProduct,
+	}, opts)
+
+	_, opts, err = parseOptions("postgres://localhost/db?index=none")
+	require.NoError(t, err)
+	assert.Nil(t, opts.hnswIndex)
+	assert.Nil(t, opts.ivfflatIndex)
+
+	_, _, err = parseOptions("postgres://localhost/db?index=none&quantization=binary")
+	assert.Error(t, err)
+
+	_, _, err = parseOptions("postgres://localhost/db?index=diskann")
+	assert.Error(t, err)
+
+	_, _, err = parseOptions("postgres://localhost/db?distance=manhattan")
+	assert.Error(t, err)
+
+	_, opts, err = parseOptions("postgres://localhost/db?index=hnsw&hnswEfSearch=200")
+	require.NoError(t, err)
+	assert.Equal(t, 200, opts.hnswIndex.efSearch)
+
+	_, _, err = parseOptions("postgres://localhost/db?index=hnsw&hnswEfSearch=5000")
+	assert.Error(t, err)
+}
+
+func TestIndexExpressions(t *testing.T) {
+	v := VectorStore{embeddingTableName: "knowledge_embeddings", vectorType: VectorTypeHalfvec, quantization: QuantizationNone}
+	assert.Equal(t, "knowledge_embeddings_embedding_hnsw_halfvec_cosine_3072", v.indexName(IndexTypeHNSW, 3072, DistanceCosine))
+	assert.Equal(t, "knowledge_embeddings_embedding_hnsw_halfvec_l2_3072", v.indexName(IndexTypeHNSW, 3072, DistanceL2))
+	assert.Equal(t, "(embedding::halfvec(3072))", v.indexedEmbedding(3072))
+	assert.Equal(t, "halfvec_cosine_ops", v.opclass(DistanceCosine))
+	assert.Equal(t, "halfvec_ip_ops", v.opclass(DistanceInnerProduct))
+	assert.Equal(t, `knowledge\_embeddings\_embedding\_%\_3072`, v.indexNamePattern(3072))
+
+	v.quantization = QuantizationBinary
+	assert.Equal(t, "knowledge_embeddings_embedding_ivfflat_bit_hamming_3072", v.indexName(IndexTypeIVFFlat, 3072, DistanceL2))
+	assert.Equal(t, "(binary_quantize(embedding)::bit(3072))", v.indexedEmbedding(3072))
+	assert.Equal(t, "(embedding::halfvec(3072))", v.embeddingExpr(3072))
+	assert.Equal(t, "bit_hamming_ops", v.opclass(DistanceCosine))
+}
+
+func TestNormalizeDistance(t *testing.T) {
+	for in, expected := range map[string]string{"": DistanceCosine, "COSINE": DistanceCosine, "l2": DistanceL2, "euclidean": DistanceL2, "ip": DistanceInnerProduct, "inner_product": DistanceInnerProduct} {
+		d, err := normalizeDistance(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, expected, d, in)
+	}
+	_, err := normalizeDistance("l1")
+	assert.Error(t, err)
+}
+
+func TestEfSearch(t *testing.T) {
+	ctx := context.Background()
+	v := VectorStore{}
+	assert.Equal(t, 0, v.efSearch(ctx))
+
+	v.hnswIndex = &HNSWIndex{efSearch: 100}
+	assert.Equal(t, 100, v.efSearch(ctx))
+	assert.Equal(t, 250, v.efSearch(vs.SearchOptionsToCtx(ctx, vs.SearchOptions{EfSearch: 250})))
+	assert.Equal(t, maxEfSearch, v.efSearch(vs.SearchOptionsToCtx(ctx, vs.SearchOptions{EfSearch: 5000})))
+}
+
+func TestIVFFlatListsForRows(t *testing.T) {
+	assert.Equal(t, 1, ivfflatListsForRows(0))
+	assert.Equal(t, 100, ivfflatListsForRows(100_000))
+	assert.Equal(t, 2000, ivfflatListsForRows(4_000_000))
+}
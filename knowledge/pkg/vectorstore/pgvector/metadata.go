@@ -0,0 +1,57 @@
+package pgvector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// migrateMetadataToJSONB converts the embedding table's cmetadata column from json (used by older versions) to jsonb
+// and creates a GIN index on it, so that metadata filters can use containment (@>) queries instead of sequential scans.
+func (v VectorStore) migrateMetadataToJSONB(ctx context.Context, tx pgx.Tx) error {
+	var current string
+	err := tx.QueryRow(ctx, `SELECT format_type(atttypid, atttypmod) FROM pg_attribute WHERE attrelid = $1::regclass AND attname = 'cmetadata'`, v.embeddingTableName).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to get type of the cmetadata column: %w", err)
+	}
+
+	if current != "jsonb" {
+		slog.Info("Migrating metadata column", "from", current, "to", "jsonb", "table", v.embeddingTableName, "store", "pgvector")
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN cmetadata TYPE jsonb USING cmetadata::jsonb`, v.embeddingTableName)); err != nil {
+			return fmt.Errorf("failed to migrate cmetadata column to jsonb: %w", err)
+		}
+	}
+
+	// jsonb_path_ops only supports the containment operators, but is smaller and faster than the default jsonb_ops
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_cmetadata ON %s USING gin (cmetadata jsonb_path_ops)`, v.embeddingTableName, v.embeddingTableName)); err != nil {
+		return fmt.Errorf("failed to create metadata index: %w", err)
+	}
+	return nil
+}
+
+// metadataContainments returns the jsonb documents to check for containment (@>) to match metadata key = value.
+// The where filters are string-typed, but the metadata may hold numbers or booleans (e.g. page numbers),
+// so those are matched as well - equivalent to the previous text comparison (cmetadata ->> key) = value.
+func metadataContainments(key, value string) ([]string, error) {
+	candidates := []any{value}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+		candidates = append(candidates, json.RawMessage(value))
+	}
+	if b, err := strconv.ParseBool(value); err == nil && strconv.FormatBool(b) == value {
+		candidates = append(candidates, b)
+	}
+
+	containments := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		j, err := json.Marshal(map[string]any{key: c})
+		if err != nil {
+			return nil, err
+		}
+		containments = append(containments, string(j))
+	}
+	return containments, nil
+}
@@ -0,0 +1,41 @@
+package pgvector
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildWhereClause(t *testing.T) {
+	clause, args, err := buildWhereClause([]any{"cid"}, map[string]string{"source": "a.pdf", "page": "3", "draft": "false"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "(cmetadata @> $2::jsonb OR cmetadata @> $3::jsonb) AND (cmetadata @> $4::jsonb OR cmetadata @> $5::jsonb) AND (cmetadata @> $6::jsonb)", clause)
+	assert.Equal(t, []any{"cid", `{"draft":"false"}`, `{"draft":false}`, `{"page":"3"}`, `{"page":3}`, `{"source":"a.pdf"}`}, args)
+
+	clause, args, err = buildWhereClause([]any{"cid"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "TRUE", clause)
+	assert.Equal(t, []any{"cid"}, args)
+}
+
+func TestMetadataContainments(t *testing.T) {
+	c, err := metadataContainments("version", "01")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"version":"01"}`}, c)
+
+	c, err = metadataContainments("score", "NaN")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"score":"NaN"}`}, c)
+}
+
+func TestBuildMetadataFilterClause(t *testing.T) {
+	f, err := vs.ParseMetadataFilter([]byte(`{"year": {"$gte": 2020}, "$or": [{"tags": "k8s"}, {"draft": {"$exists": false}}]}`))
+	require.NoError(t, err)
+
+	clause, args, err := buildMetadataFilterClause(f, []any{"vec", 10, "collection"})
+	require.NoError(t, err)
+	assert.Equal(t, `(((cmetadata @> $4::jsonb OR cmetadata @> $5::jsonb) OR NOT cmetadata ? $6::text) AND COALESCE((CASE WHEN jsonb_typeof(cmetadata -> $7::text) = 'number' THEN (cmetadata ->> $7::text)::float8 END) >= $8::float8, FALSE))`, clause)
+	assert.Equal(t, []any{"vec", 10, "collection", `{"tags":"k8s"}`, `{"tags":["k8s"]}`, "draft", "year", 2020.0}, args)
+}
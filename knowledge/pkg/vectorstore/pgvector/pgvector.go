@@ -4,9 +4,13 @@ package pgvector
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
@@ -14,6 +18,7 @@ import (
 	"github.com/obot-platform/tools/knowledge/pkg/env"
 	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -70,6 +75,15 @@ type VectorStore struct {
 	collectionTableName  string
 	vectorDimensions     int
 	hnswIndex            *HNSWIndex
+	ivfflatIndex         *IVFFlatIndex
+	vectorType           string // column type: vector or halfvec
+	quantization         string // index quantization: none or binary
+
+	// distance is the default distance function for new collections
+	distance string
+
+	// indexedDims keeps track of the embedding dimensions for which the vector index was already ensured
+	indexedDims *sync.Map
 }
 
 // HNSWIndex lets you specify the HNSW index parameters.
@@ -77,31 +91,42 @@ type VectorStore struct {
 //
 // m: he max number of connections per layer (16 by default)
 // efConstruction: the size of the dynamic candidate list for constructing the graph (64 by default)
-// distanceFunction: the distance function to use (l2 by default).
+// efSearch: the size of the dynamic candidate list for search (0 keeps the Postgres setting, which defaults to 40) - higher means better recall, but slower queries.
+// It can be overridden per query via the search options (see vs.SearchOptions).
+//
+// The operator class is derived from the collection's distance function.
 type HNSWIndex struct {
-	m                int
-	efConstruction   int
-	distanceFunction string
+	m              int
+	efConstruction int
+	efSearch       int
 }
 
 var DefaultHNSWIndex = &HNSWIndex{
-	m:                16,
-	efConstruction:   64,
-	distanceFunction: "vector_l2_ops",
+	m:              16,
+	efConstruction: 64,
 }
 
 func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
 	dsn = "postgres://" + strings.TrimPrefix(dsn, "pgvector://")
 
+	dsn, opts, err := parseOptions(dsn)
+	if err != nil {
+		return nil, err
+	}
+
 	store := &VectorStore{
 		embeddingTableName:   "knowledge_embeddings",
 		collectionTableName:  "knowledge_collections",
 		embeddingFunc:        embeddingFunc,
 		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsPgvectorEmbeddingConcurrency, 100),
-		hnswIndex:            nil,
+		hnswIndex:            opts.hnswIndex,
+		ivfflatIndex:         opts.ivfflatIndex,
+		vectorType:           opts.vectorType,
+		quantization:         opts.quantization,
+		distance:             opts.distance,
+		indexedDims:          &sync.Map{},
 	}
 
-	var err error
 	store.conn, err = pgxpool.New(ctx, dsn)
 	if err != nil {
 		return nil, err
@@ -129,8 +154,23 @@ func (v VectorStore) init(ctx context.Context) error {
 	if err := v.createEmbeddingTableIfNotExists(ctx, tx); err != nil {
 		return err
 	}
+	if err := v.migrateMetadataToJSONB(ctx, tx); err != nil {
+		return err
+	}
+	migrated, err := v.migrateVectorType(ctx, tx)
+	if err != nil {
+		return err
+	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	// The vector indexes were dropped with the migration, so re-create them for the existing embeddings
+	if migrated && (v.hnswIndex != nil || v.ivfflatIndex != nil) {
+		return v.RebuildIndexes(ctx)
+	}
+	return nil
 }
 
 func (v VectorStore) createVectorExtensionIfNotExists(ctx context.Context, tx pgx.Tx) error {
@@ -192,13 +232,13 @@ func (v VectorStore) createEmbeddingTableIfNotExists(ctx context.Context, tx pgx
 
 	sql := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
 	collection_id uuid,
-	embedding vector%s,
+	embedding %s%s,
 	document bytea,
-	cmetadata json,
+	cmetadata jsonb,
 	"uuid" uuid NOT NULL,
 	CONSTRAINT knowledge_pg_embedding_collection_id_fkey
 	FOREIGN KEY (collection_id) REFERENCES %s (uuid) ON DELETE CASCADE,
-	PRIMARY KEY (uuid))`, v.embeddingTableName, vectorDimensions, v.collectionTableName)
+	PRIMARY KEY (uuid))`, v.embeddingTableName, v.vectorType, vectorDimensions, v.collectionTableName)
 	if _, err := tx.Exec(ctx, sql); err != nil {
 		return err
 	}
@@ -207,19 +247,7 @@ func (v VectorStore) createEmbeddingTableIfNotExists(ctx context.Context, tx pgx
 		return err
 	}
 
-	// See this for more details on HNSW indexes: https://github.com/pgvector/pgvector#hnsw
-	if v.hnswIndex != nil {
-		sql = fmt.Sprintf(
-			`CREATE INDEX IF NOT EXISTS %s_embedding_hnsw ON %s USING hnsw (embedding %s)`,
-			v.embeddingTableName, v.embeddingTableName, v.hnswIndex.distanceFunction,
-		)
-		if v.hnswIndex.m > 0 && v.hnswIndex.efConstruction > 0 {
-			sql = fmt.Sprintf("%s WITH (m=%d, ef_construction = %d)", sql, v.hnswIndex.m, v.hnswIndex.efConstruction)
-		}
-		if _, err := tx.Exec(ctx, sql); err != nil {
-			return err
-		}
-	}
+	// Vector indexes (HNSW, IVFFlat) are created per embedding dimensions, see ensureIndex
 
 	return nil
 }
@@ -245,7 +273,19 @@ func (v VectorStore) CreateCollection(ctx context.Context, collection string, op
 		opts = &dbtypes.DatasetCreateOpts{}
 	}
 
-	slog.Debug("Creating collection", "collection", collection, "store", "pgvector")
+	distance := v.distance
+	if opts.DistanceFunction != "" {
+		var err error
+		if distance, err = normalizeDistance(opts.DistanceFunction); err != nil {
+			return err
+		}
+	}
+	cmetadata, err := json.Marshal(map[string]string{collectionMetadataKeyDistance: distance})
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "distance", distance, "store", "pgvector")
 	tx, err := v.conn.Begin(ctx)
 	if err != nil {
 		return err
@@ -258,7 +298,7 @@ func (v VectorStore) CreateCollection(ctx context.Context, collection string, op
 		return fmt.Errorf("failed to acquire advisory lock: %w", err)
 	}
 
-	_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (uuid, name) VALUES($1, $2)`, v.collectionTableName), uuid.New().String(), collection)
+	_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s (uuid, name, cmetadata) VALUES($1, $2, $3)`, v.collectionTableName), uuid.New().String(), collection, cmetadata)
 	var pgErr *pgconn.PgError
 	if err != nil {
 		if ok := errors.As(err, &pgErr); ok && pgErr != nil && pgErr.Code == "23505" {
@@ -307,6 +347,7 @@ func (v VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, colle
 
 	var wg sync.WaitGroup
 	qqLock := sync.Mutex{} // lock for the pgx batch queue
+	dims := map[int]struct{}{}
 	semaphore := make(chan struct{}, v.embeddingConcurrency)
 	wg.Add(len(docs))
 	for docIdx, doc := range docs {
@@ -338,6 +379,7 @@ func (v VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, colle
 
 			qqLock.Lock()
 			b.Queue(sql, doc.ID, []byte(doc.Content), pgvector.NewVector(vec), doc.Metadata, cid)
+			dims[len(vec)] = struct{}{}
 			qqLock.Unlock()
 			slog.Debug("Adding document to pgvector", "documentID", doc.ID, "collection", collection, "queueSize", b.Len())
 		}(doc)
@@ -360,7 +402,23 @@ func (v VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, colle
 		}
 	}
 
-	return ids, results.Close()
+	if err := results.Close(); err != nil {
+		return nil, err
+	}
+
+	if len(dims) > 0 {
+		distance, err := v.getCollectionDistance(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		for d := range dims {
+			if err := v.ensureIndex(ctx, d, distance); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ids, nil
 }
 
 /*
@@ -373,6 +431,7 @@ SimilaritySearch performs a similarity search on the given query and returns the
 *   - `<+>` - L1 distance (added in 0.7.0)
 *   - `<~>` - Hamming distance (binary vectors, added in 0.7.0)
 *   - `<%>` - Jaccard distance (binary vectors, added in 0.7.0)
+* The operator is chosen by the collection's distance function (cosine, l2 or inner_product, see CreateCollection).
 */
 func (v VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
 	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", where, "whereDocument", whereDocument, "store", "pgvector")
@@ -388,41 +447,103 @@ func (v VectorStore) SimilaritySearch(ctx context.Context, query string, numDocu
 	}
 	dims := len(queryEmbedding)
 
-	whereClause, args, err := buildWhereClause([]any{dims, pgvector.NewVector(queryEmbedding), numDocuments}, where, whereDocument)
+	distance, err := v.getCollectionDistance(ctx, collection)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
 		return nil, err
 	}
-	sql := fmt.Sprintf(`WITH filtered_embedding_dims AS MATERIALIZED (
-    SELECT
-        *
-    FROM
-        %s
-    WHERE
-        vector_dims (
-                embedding
-        ) = $1
-)
-SELECT
-	data.uuid,
-	data.document,
-	data.cmetadata,
-	data.similarity
+	df := distanceFunctions[distance]
+
+	whereClause, args, err := buildWhereClause([]any{pgvector.NewVector(queryEmbedding), numDocuments, collection}, where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+	if filter := vs.SearchOptionsFromCtx(ctx).Filter; filter != nil {
+		// filtering in the query, so that the limit applies to the matching documents
+		filterClause, filterArgs, err := buildMetadataFilterClause(filter, args)
+		if err != nil {
+			return nil, err
+		}
+		whereClause, args = whereClause+" AND "+filterClause, filterArgs
+	}
+
+	// The embedding is cast to the query's dimensions (a literal, not a parameter), so that the partial vector index for those dimensions can be used.
+	// Mixing vector dimensions is possible, e.g. if datasets were ingested with different embedding models.
+	queryVec := fmt.Sprintf("$1::%s(%d)", v.vectorType, dims)
+	searchOpts := vs.SearchOptionsFromCtx(ctx)
+	includeEmbeddings := searchOpts.IncludeEmbeddings
+	offset := max(searchOpts.Offset, 0)
+	embeddingCol := ""
+	if includeEmbeddings {
+		embeddingCol = ",\n\tembedding::vector"
+	}
+	distanceExpr := fmt.Sprintf("%s %s %s", v.embeddingExpr(dims), df.operator, queryVec)
+	sql := fmt.Sprintf(`SELECT
+	uuid,
+	document,
+	cmetadata,
+	%[1]s AS similarity%[7]s
+FROM
+	%[3]s
+WHERE
+	vector_dims(embedding) = %[4]d
+	AND collection_id = (SELECT uuid FROM %[5]s WHERE name = $3)
+	AND %[6]s
+ORDER BY
+	%[2]s
+LIMIT $2
+OFFSET %[8]d`, fmt.Sprintf(df.similarity, distanceExpr), distanceExpr, v.embeddingTableName, dims, v.collectionTableName, whereClause, embeddingCol, offset)
+
+	if v.quantization == QuantizationBinary {
+		// Fetch candidates via the binary quantized index (hamming distance) and re-rank them using the full vectors
+		sql = fmt.Sprintf(`SELECT
+	uuid,
+	document,
+	cmetadata,
+	%[1]s AS similarity%[10]s
 FROM (
 	SELECT
-		filtered_embedding_dims.*,
-		1 - (embedding <=> $2) AS similarity
+		*
 	FROM
-		filtered_embedding_dims
-		JOIN %s ON filtered_embedding_dims.collection_id=%s.uuid WHERE %s.name='%s') AS data
-WHERE %s
+		%[3]s
+	WHERE
+		vector_dims(embedding) = %[4]d
+		AND collection_id = (SELECT uuid FROM %[5]s WHERE name = $3)
+		AND %[6]s
+	ORDER BY
+		%[7]s <~> binary_quantize(%[2]s)
+	LIMIT ($2 + %[11]d) * %[8]d
+) AS candidates
 ORDER BY
-	data.similarity DESC
-LIMIT $3`, v.embeddingTableName,
-		v.collectionTableName, v.collectionTableName, v.collectionTableName, collection,
-		whereClause)
+	%[9]s
+LIMIT $2
+OFFSET %[11]d`, fmt.Sprintf(df.similarity, distanceExpr), queryVec, v.embeddingTableName, dims, v.collectionTableName, whereClause, v.indexedEmbedding(dims), binaryQuantizationRerankFactor, distanceExpr, embeddingCol, offset)
+	}
 
 	slog.Debug("SimilaritySearch", "sql", sql, "store", "pgvector")
-	rows, err := v.conn.Query(ctx, sql, args...)
+
+	// Query-time index options are set for the current transaction only
+	tx, err := v.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // read-only, so rollback is fine
+
+	if v.ivfflatIndex != nil && v.ivfflatIndex.probes > 0 {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL ivfflat.probes = %d", v.ivfflatIndex.probes)); err != nil {
+			return nil, fmt.Errorf("failed to set ivfflat.probes: %w", err)
+		}
+	}
+
+	if efSearch := v.efSearch(ctx); efSearch > 0 {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", efSearch)); err != nil {
+			return nil, fmt.Errorf("failed to set hnsw.ef_search: %w", err)
+		}
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query: %w", err)
 	}
@@ -432,15 +553,35 @@ LIMIT $3`, v.embeddingTableName,
 	for rows.Next() {
 		doc := vs.Document{}
 		var contentB []byte
-		if err := rows.Scan(&doc.ID, &contentB, &doc.Metadata, &doc.SimilarityScore); err != nil {
+		dest := []any{&doc.ID, &contentB, &doc.Metadata, &doc.SimilarityScore}
+		var vec pgvector.Vector
+		if includeEmbeddings {
+			dest = append(dest, &vec)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
+		if includeEmbeddings {
+			doc.Embedding = vec.Slice()
+		}
 		doc.Content = string(contentB)
 		docs = append(docs, doc)
 	}
 	return docs, rows.Err()
 }
 
+// efSearch returns the hnsw.ef_search value to use for a similarity search: the per-query search option takes precedence over the store setting.
+// 0 means the Postgres setting is left unchanged.
+func (v VectorStore) efSearch(ctx context.Context) int {
+	if efSearch := vs.SearchOptionsFromCtx(ctx).EfSearch; efSearch > 0 {
+		return min(efSearch, maxEfSearch)
+	}
+	if v.hnswIndex != nil {
+		return v.hnswIndex.efSearch
+	}
+	return 0
+}
+
 func (v VectorStore) RemoveCollection(ctx context.Context, collection string) error {
 	slog.Debug("Removing collection", "collection", collection, "store", "pgvector")
 
@@ -491,6 +632,35 @@ func (v VectorStore) RemoveDocument(ctx context.Context, documentID string, coll
 	return err
 }
 
+// UpdateDocumentMetadata applies the metadata patch to the document without re-embedding it
+func (v VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	cid, err := v.getCollectionUUID(ctx, collection)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		return err
+	}
+
+	set, remove := patch.Split()
+	setJSON, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata patch: %w", err)
+	}
+
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "set", set, "remove", remove, "store", "pgvector")
+
+	tag, err := v.conn.Exec(ctx, fmt.Sprintf(`UPDATE %s SET cmetadata = (COALESCE(cmetadata, '{}'::jsonb) || $1::jsonb) - $2::text[] WHERE uuid = $3 AND collection_id = $4`, v.embeddingTableName),
+		string(setJSON), remove, documentID, cid)
+	if err != nil {
+		return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+	}
+	return nil
+}
+
 func (v VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
 	cid, err := v.getCollectionUUID(ctx, collection)
 	if err != nil {
@@ -500,7 +670,7 @@ func (v VectorStore) GetDocument(ctx context.Context, documentID, collection str
 	var doc vs.Document
 	var content []byte
 	var vec pgvector.Vector
-	err = v.conn.QueryRow(ctx, fmt.Sprintf(`SELECT document, cmetadata, embedding FROM %s WHERE uuid = $1 AND collection_id = $2`, v.embeddingTableName), documentID, cid).Scan(&content, &doc.Metadata, &vec)
+	err = v.conn.QueryRow(ctx, fmt.Sprintf(`SELECT document, cmetadata, embedding::vector FROM %s WHERE uuid = $1 AND collection_id = $2`, v.embeddingTableName), documentID, cid).Scan(&content, &doc.Metadata, &vec)
 	if err != nil {
 		return vs.Document{}, err
 	}
@@ -527,7 +697,13 @@ func (v VectorStore) GetDocuments(ctx context.Context, collection string, where
 		return nil, err
 	}
 
-	sql := fmt.Sprintf(`SELECT uuid, document, cmetadata, embedding FROM %s WHERE %s %s`, v.embeddingTableName, whereCol, whereClause)
+	sql := fmt.Sprintf(`SELECT uuid, document, cmetadata, embedding::vector FROM %s WHERE %s %s`, v.embeddingTableName, whereCol, whereClause)
+	if page := vs.ListOptionsFromCtx(ctx); page.IsSet() {
+		sql += fmt.Sprintf(" ORDER BY uuid OFFSET %d", max(page.Offset, 0))
+		if page.Limit > 0 {
+			sql += fmt.Sprintf(" LIMIT %d", page.Limit)
+		}
+	}
 	rows, err := v.conn.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
@@ -549,12 +725,205 @@ func (v VectorStore) GetDocuments(ctx context.Context, collection string, where
 	return docs, rows.Err()
 }
 
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
 func (v VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
-	return fmt.Errorf("function ImportCollectionsFromFile not implemented for vectorstore pgvector")
+	export, err := vs.ReadExportFile(path)
+	if err != nil {
+		return err
+	}
+
+	toImport := make([]vs.ExportCollection, 0, len(export.Collections))
+	for _, c := range export.Collections {
+		if len(collections) == 0 || slices.Contains(collections, c.Name) {
+			toImport = append(toImport, c)
+		}
+	}
+	for _, name := range collections {
+		if !slices.ContainsFunc(toImport, func(c vs.ExportCollection) bool { return c.Name == name }) {
+			return fmt.Errorf("collection %s not found in export file %s", name, path)
+		}
+	}
+
+	// Validate everything before writing anything
+	for _, c := range toImport {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if len(c.Documents) == 0 {
+			continue
+		}
+		if v.vectorDimensions > 0 && c.Dimensions != v.vectorDimensions {
+			return fmt.Errorf("collection %s has %d embedding dimensions, but the embedding table only supports %d", c.Name, c.Dimensions, v.vectorDimensions)
+		}
+		dims, err := v.getCollectionDimensions(ctx, c.Name)
+		if err != nil {
+			return err
+		}
+		if dims > 0 && dims != c.Dimensions {
+			return fmt.Errorf("collection %s already exists with %d embedding dimensions, but the export has %d", c.Name, dims, c.Dimensions)
+		}
+	}
+
+	sql := fmt.Sprintf(`INSERT INTO %s (uuid, document, embedding, cmetadata, collection_id)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (uuid) DO UPDATE SET document = EXCLUDED.document, embedding = EXCLUDED.embedding, cmetadata = EXCLUDED.cmetadata, collection_id = EXCLUDED.collection_id`, v.embeddingTableName)
+
+	for _, c := range toImport {
+		slog.Info("Importing collection", "collection", c.Name, "documents", len(c.Documents), "dimensions", c.Dimensions, "store", "pgvector")
+
+		if err := v.CreateCollection(ctx, c.Name, nil); err != nil {
+			return err
+		}
+		cid, err := v.getCollectionUUID(ctx, c.Name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := v.conn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		b := &pgx.Batch{}
+		for _, d := range c.Documents {
+			doc, err := d.Document()
+			if err != nil {
+				_ = tx.Rollback(ctx)
+				return err
+			}
+			b.Queue(sql, doc.ID, []byte(doc.Content), pgvector.NewVector(doc.Embedding), doc.Metadata, cid)
+		}
+		if err := tx.SendBatch(ctx, b).Close(); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("failed to import collection %s: %w", c.Name, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+
+		if len(c.Documents) > 0 {
+			distance, err := v.getCollectionDistance(ctx, c.Name)
+			if err != nil {
+				return err
+			}
+			if err := v.ensureIndex(ctx, c.Dimensions, distance); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
 func (v VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
-	return fmt.Errorf("function ExportCollectionsToFile not implemented for vectorstore pgvector")
+	if finfo, err := os.Stat(path); err == nil && finfo.IsDir() {
+		path = filepath.Join(path, vs.ExportFileName)
+	}
+
+	if len(collections) == 0 {
+		rows, err := v.conn.Query(ctx, fmt.Sprintf(`SELECT name FROM %s ORDER BY name`, v.collectionTableName))
+		if err != nil {
+			return err
+		}
+		collections, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+	}
+
+	export := &vs.Export{Version: vs.ExportFormatVersion}
+	for _, collection := range collections {
+		if _, err := v.getCollectionUUID(ctx, collection); err != nil {
+			return fmt.Errorf("collection %s not found: %w", collection, err)
+		}
+
+		docs, err := v.GetDocuments(ctx, collection, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get documents of collection %s: %w", collection, err)
+		}
+
+		c := vs.ExportCollection{Name: collection, Documents: make([]vs.ExportDocument, 0, len(docs))}
+		if len(docs) > 0 {
+			c.Dimensions = len(docs[0].Embedding)
+		}
+		for _, doc := range docs {
+			d, err := vs.NewExportDocument(doc)
+			if err != nil {
+				return err
+			}
+			c.Documents = append(c.Documents, d)
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+
+		slog.Debug("Exporting collection", "collection", collection, "documents", len(c.Documents), "store", "pgvector")
+		export.Collections = append(export.Collections, c)
+	}
+
+	return vs.WriteExportFile(path, export)
+}
+
+// Stats returns the number of documents, the (approximate) storage size and the embedding dimensions per collection.
+// The size is the sum of the row sizes (content, metadata, embedding), excluding indexes and storage overhead.
+func (v VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	if collections == nil {
+		collections = []string{}
+	}
+
+	rows, err := v.conn.Query(ctx, fmt.Sprintf(`SELECT
+	c.name,
+	COUNT(e.uuid),
+	COALESCE(SUM(pg_column_size(e.*)), 0),
+	COALESCE(array_agg(DISTINCT vector_dims(e.embedding)) FILTER (WHERE e.embedding IS NOT NULL), '{}')
+FROM
+	%s c
+	LEFT JOIN %s e ON e.collection_id = c.uuid
+WHERE
+	cardinality($1::text[]) = 0 OR c.name = ANY($1)
+GROUP BY
+	c.name
+ORDER BY
+	c.name`, v.collectionTableName, v.embeddingTableName), collections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]vs.CollectionStats, 0, len(collections))
+	for rows.Next() {
+		var s vs.CollectionStats
+		if err := rows.Scan(&s.Name, &s.Documents, &s.SizeBytes, &s.Dimensions); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range collections {
+		if !slices.ContainsFunc(stats, func(s vs.CollectionStats) bool { return s.Name == c }) {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, c)
+		}
+	}
+	return stats, nil
+}
+
+// getCollectionDimensions returns the number of embedding dimensions of the existing documents in the collection or 0 if there are none
+func (v VectorStore) getCollectionDimensions(ctx context.Context, collection string) (int, error) {
+	var dims int
+	err := v.conn.QueryRow(ctx, fmt.Sprintf(`SELECT vector_dims(e.embedding) FROM %s e JOIN %s c ON e.collection_id = c.uuid WHERE c.name = $1 LIMIT 1`,
+		v.embeddingTableName, v.collectionTableName), collection).Scan(&dims)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return dims, nil
 }
 
 func buildWhereClause(args []any, where map[string]string, whereDocument []vs.WhereDocument) (string, []any, error) {
@@ -568,10 +937,24 @@ func buildWhereClause(args []any, where map[string]string, whereDocument []vs.Wh
 	}
 
 	argIndex := len(args) + 1 // Usually we start with index 2 because $1 is for cid
-	for k, v := range where {
-		whereClauses = append(whereClauses, fmt.Sprintf("(cmetadata ->> $%d) = $%d", argIndex, argIndex+1))
-		args = append(args, k, v)
-		argIndex += 2
+	keys := make([]string, 0, len(where))
+	for k := range where {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys) // stable query text
+	for _, k := range keys {
+		// Containment queries (@>) can use the GIN index on cmetadata
+		containments, err := metadataContainments(k, where[k])
+		if err != nil {
+			return "", nil, err
+		}
+		clauses := make([]string, 0, len(containments))
+		for _, c := range containments {
+			clauses = append(clauses, fmt.Sprintf("cmetadata @> $%d::jsonb", argIndex))
+			args = append(args, c)
+			argIndex++
+		}
+		whereClauses = append(whereClauses, "("+strings.Join(clauses, " OR ")+")")
 	}
 
 	if len(whereDocument) > 0 {
@@ -589,3 +972,78 @@ func buildWhereClause(args []any, where map[string]string, whereDocument []vs.Wh
 	}
 	return whereClause, args, nil
 }
+
+// SupportsPaging reports that the offset of similarity searches and the pages of GetDocuments are applied in the queries (see vectorstore.Pager)
+func (v VectorStore) SupportsPaging() bool {
+	return true
+}
+
+// SwapEmbeddings replaces the embeddings of all documents in the collection: they're written to a temporary shadow table first,
+// which replaces the collection's embeddings in the same transaction, so that the old embeddings remain searchable until it's committed.
+func (v VectorStore) SwapEmbeddings(ctx context.Context, collection string, embeddings map[string][]float32) error {
+	cid, err := v.getCollectionUUID(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	dims := 0
+	for id, emb := range embeddings {
+		if dims == 0 {
+			dims = len(emb)
+		}
+		if len(emb) == 0 || len(emb) != dims {
+			return fmt.Errorf("embedding of document %s has %d dimensions, expected %d", id, len(emb), dims)
+		}
+	}
+	if v.vectorDimensions > 0 && dims > 0 && dims != v.vectorDimensions {
+		return fmt.Errorf("embeddings have %d dimensions, but the embedding table only supports %d dimensions", dims, v.vectorDimensions)
+	}
+
+	tx, err := v.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // rollback on error (noop after commit)
+
+	shadow := "knowledge_pg_embedding_shadow"
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`CREATE TEMPORARY TABLE %s (uuid uuid PRIMARY KEY, embedding %s) ON COMMIT DROP`, shadow, v.vectorType)); err != nil {
+		return fmt.Errorf("failed to create shadow table: %w", err)
+	}
+
+	b := &pgx.Batch{}
+	for id, emb := range embeddings {
+		b.Queue(fmt.Sprintf(`INSERT INTO %s (uuid, embedding) VALUES($1, $2)`, shadow), id, pgvector.NewVector(emb))
+	}
+	if err := tx.SendBatch(ctx, b).Close(); err != nil {
+		return fmt.Errorf("failed to insert into shadow table: %w", err)
+	}
+
+	var missing int
+	if err := tx.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s e WHERE e.collection_id = $1 AND NOT EXISTS (SELECT 1 FROM %s s WHERE s.uuid = e.uuid)`, v.embeddingTableName, shadow), cid).Scan(&missing); err != nil {
+		return fmt.Errorf("failed to check shadow table: %w", err)
+	}
+	if missing > 0 {
+		return fmt.Errorf("missing embeddings for %d documents in collection %s", missing, collection)
+	}
+
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`UPDATE %s e SET embedding = s.embedding FROM %s s WHERE e.uuid = s.uuid AND e.collection_id = $1`, v.embeddingTableName, shadow), cid)
+	if err != nil {
+		return fmt.Errorf("failed to swap embeddings: %w", err)
+	}
+	if int(tag.RowsAffected()) != len(embeddings) {
+		return fmt.Errorf("got %d embeddings for %d documents in collection %s", len(embeddings), tag.RowsAffected(), collection)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	if dims == 0 {
+		return nil
+	}
+	distance, err := v.getCollectionDistance(ctx, collection)
+	if err != nil {
+		return err
+	}
+	return v.ensureIndex(ctx, dims, distance)
+}
@@ -0,0 +1,94 @@
+package qdrant
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// client is a minimal client for the Qdrant REST API (https://api.qdrant.tech/api-reference)
+type client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type apiResponse struct {
+	Result json.RawMessage `json:"result"`
+	Status any             `json:"status"` // "ok" or {"error": "..."}
+}
+
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("qdrant API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*apiError); ok {
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// do sends a request to the Qdrant API and decodes the "result" field of the response into result (if not nil)
+func (c *client) do(ctx context.Context, method, path string, body any, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to qdrant: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read qdrant response: %w", err)
+	}
+
+	var r apiResponse
+	if err := json.Unmarshal(respBody, &r); err != nil {
+		if resp.StatusCode >= 300 {
+			return &apiError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(respBody))}
+		}
+		return fmt.Errorf("failed to decode qdrant response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		msg := fmt.Sprintf("%v", r.Status)
+		if s, ok := r.Status.(map[string]any); ok {
+			msg = fmt.Sprintf("%v", s["error"])
+		}
+		return &apiError{StatusCode: resp.StatusCode, Message: msg}
+	}
+
+	if result != nil && len(r.Result) > 0 {
+		if err := json.Unmarshal(r.Result, result); err != nil {
+			return fmt.Errorf("failed to decode qdrant result: %w", err)
+		}
+	}
+	return nil
+}
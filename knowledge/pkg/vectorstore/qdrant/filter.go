@@ -0,0 +1,115 @@
+package qdrant
+
+import (
+	"fmt"
+	"slices"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const (
+	payloadKeyContent    = "content"
+	payloadKeyMetadata   = "metadata"
+	payloadKeyDocumentID = "document_id"
+)
+
+// filter is a Qdrant filter, see https://qdrant.tech/documentation/concepts/filtering/
+// Filters can be used as conditions themselves to build nested clauses.
+type filter struct {
+	Must    []any `json:"must,omitempty"`
+	Should  []any `json:"should,omitempty"`
+	MustNot []any `json:"must_not,omitempty"`
+}
+
+type fieldCondition struct {
+	Key   string         `json:"key"`
+	Match map[string]any `json:"match,omitempty"`
+	Range map[string]any `json:"range,omitempty"`
+}
+
+func matchValue(key string, value any) fieldCondition {
+	return fieldCondition{Key: key, Match: map[string]any{"value": value}}
+}
+
+// matchNumber matches integer and float values (match only supports integers)
+func matchNumber(key string, value float64) fieldCondition {
+	return fieldCondition{Key: key, Range: map[string]any{"gte": value, "lte": value}}
+}
+
+// matchText is a substring match if there is no full-text index on the field
+func matchText(key string, text string) fieldCondition {
+	return fieldCondition{Key: key, Match: map[string]any{"text": text}}
+}
+
+func (f *filter) isEmpty() bool {
+	return f == nil || len(f.Must)+len(f.Should)+len(f.MustNot) == 0
+}
+
+// buildFilter translates the metadata (where) and content (whereDocument) filters into a Qdrant filter.
+// Metadata values also match numbers and booleans with the same literal (see vs.WhereValues) and elements of lists.
+func buildFilter(where map[string]string, whereDocument []vs.WhereDocument) (*filter, error) {
+	f := &filter{}
+
+	keys := make([]string, 0, len(where))
+	for k := range where {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		key := payloadKeyMetadata + "." + k
+		var alternatives []any
+		for _, value := range vs.WhereValues(where[k]) {
+			if n, ok := value.(float64); ok {
+				alternatives = append(alternatives, matchNumber(key, n))
+			} else {
+				alternatives = append(alternatives, matchValue(key, value))
+			}
+		}
+		if len(alternatives) == 1 {
+			f.Must = append(f.Must, alternatives[0])
+		} else {
+			f.Must = append(f.Must, &filter{Should: alternatives})
+		}
+	}
+
+	for _, wd := range whereDocument {
+		cond, err := buildWhereDocumentCondition(wd)
+		if err != nil {
+			return nil, err
+		}
+		f.Must = append(f.Must, cond)
+	}
+
+	if f.isEmpty() {
+		return nil, nil
+	}
+	return f, nil
+}
+
+func buildWhereDocumentCondition(wd vs.WhereDocument) (any, error) {
+	switch wd.Operator {
+	case vs.WhereDocumentOperatorEquals:
+		return matchValue(payloadKeyContent, wd.Value), nil
+	case vs.WhereDocumentOperatorContains:
+		return matchText(payloadKeyContent, wd.Value), nil
+	case vs.WhereDocumentOperatorNotContains:
+		return &filter{MustNot: []any{matchText(payloadKeyContent, wd.Value)}}, nil
+	case vs.WhereDocumentOperatorOr, vs.WhereDocumentOperatorAnd:
+		sub := &filter{}
+		for _, subWd := range wd.WhereDocuments {
+			cond, err := buildWhereDocumentCondition(subWd)
+			if err != nil {
+				return nil, err
+			}
+			if wd.Operator == vs.WhereDocumentOperatorOr {
+				sub.Should = append(sub.Should, cond)
+			} else {
+				sub.Must = append(sub.Must, cond)
+			}
+		}
+		return sub, nil
+	default:
+		return nil, fmt.Errorf("unsupported where document operator %q", wd.Operator)
+	}
+}
@@ -0,0 +1,41 @@
+package qdrant
+
+import (
+	"encoding/json"
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFilter(t *testing.T) {
+	f, err := buildFilter(nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	f, err = buildFilter(map[string]string{"filename": "a.pdf", "page": "3"}, []vs.WhereDocument{
+		{
+			Operator: vs.WhereDocumentOperatorOr,
+			WhereDocuments: []vs.WhereDocument{
+				{Operator: vs.WhereDocumentOperatorContains, Value: "foo"},
+				{Operator: vs.WhereDocumentOperatorNotContains, Value: "bar"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	b, err := json.Marshal(f)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"must":[
+		{"key":"metadata.filename","match":{"value":"a.pdf"}},
+		{"should":[
+			{"key":"metadata.page","match":{"value":"3"}},
+			{"key":"metadata.page","range":{"gte":3,"lte":3}}
+		]},
+		{"should":[
+			{"key":"content","match":{"text":"foo"}},
+			{"must_not":[{"key":"content","match":{"text":"bar"}}]}
+		]}
+	]}`, string(b))
+}
@@ -0,0 +1,458 @@
+package qdrant
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// VsQdrantEmbeddingConcurrency can be set as an environment variable to control the number of parallel API calls to create embedding for documents. Default is 100
+	VsQdrantEmbeddingConcurrency = "VS_QDRANT_EMBEDDING_CONCURRENCY"
+
+	// VsQdrantAPIKey can be set as an environment variable to provide the API key, if it's not part of the DSN
+	VsQdrantAPIKey = "VS_QDRANT_API_KEY"
+
+	upsertBatchSize = 256
+	scrollPageSize  = 256
+)
+
+type VectorStore struct {
+	embeddingFunc        vs.EmbeddingFunc
+	embeddingConcurrency int
+	client               *client
+	collectionPrefix     string
+	distance             string
+}
+
+// New creates a new Qdrant vector store from a DSN of the form
+//
+//	qdrant://[apikey@]host[:port][?tls=true&prefix=knowledge_&distance=Cosine]
+//
+// The REST API is used (default port 6333).
+func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse qdrant DSN: %w", err)
+	}
+
+	q := u.Query()
+
+	scheme := "http"
+	if q.Get("tls") == "true" {
+		scheme = "https"
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host += ":6333"
+	}
+
+	apiKey := os.Getenv(VsQdrantAPIKey)
+	if u.User != nil && u.User.Username() != "" {
+		apiKey = u.User.Username()
+	}
+
+	distance := q.Get("distance")
+	if distance == "" {
+		distance = "Cosine"
+	}
+
+	store := &VectorStore{
+		embeddingFunc:        embeddingFunc,
+		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsQdrantEmbeddingConcurrency, 100),
+		client: &client{
+			baseURL:    scheme + "://" + host + strings.TrimSuffix(u.Path, "/"),
+			apiKey:     apiKey,
+			httpClient: &http.Client{Timeout: 60 * time.Second},
+		},
+		collectionPrefix: q.Get("prefix"),
+		distance:         distance,
+	}
+
+	slog.Debug("qdrant", "url", store.client.baseURL, "collectionPrefix", store.collectionPrefix, "distance", store.distance)
+
+	// Check connectivity
+	if err := store.client.do(ctx, http.MethodGet, "/collections", nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to connect to qdrant: %w", err)
+	}
+
+	return store, nil
+}
+
+func (v *VectorStore) Close() error {
+	v.client.httpClient.CloseIdleConnections()
+	return nil
+}
+
+func (v *VectorStore) collectionName(collection string) string {
+	return v.collectionPrefix + collection
+}
+
+func (v *VectorStore) collectionPath(collection string) string {
+	return "/collections/" + url.PathEscape(v.collectionName(collection))
+}
+
+func (v *VectorStore) collectionExists(ctx context.Context, collection string) (bool, error) {
+	var res struct {
+		Exists bool `json:"exists"`
+	}
+	if err := v.client.do(ctx, http.MethodGet, v.collectionPath(collection)+"/exists", nil, &res); err != nil {
+		return false, err
+	}
+	return res.Exists, nil
+}
+
+func (v *VectorStore) CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error {
+	if opts == nil {
+		opts = &dbtypes.DatasetCreateOpts{}
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "store", "qdrant")
+
+	exists, err := v.collectionExists(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("failed to check if collection %s exists: %w", collection, err)
+	}
+	if exists {
+		if opts.ErrOnExists {
+			return fmt.Errorf("collection %s already exists", collection)
+		}
+		slog.Debug("Collection already exists but that's fine", "collection", collection)
+		return nil
+	}
+
+	emb, err := v.embeddingFunc(ctx, "dummy text")
+	if err != nil {
+		return fmt.Errorf("failed to get embedding: %w", err)
+	}
+	dimensionality := len(emb)
+
+	body := map[string]any{
+		"vectors": map[string]any{
+			"size":     dimensionality,
+			"distance": v.distance,
+		},
+	}
+	if err := v.client.do(ctx, http.MethodPut, v.collectionPath(collection), body, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+type point struct {
+	ID      string         `json:"id"`
+	Vector  []float32      `json:"vector,omitempty"`
+	Payload map[string]any `json:"payload,omitempty"`
+	Score   float32        `json:"score,omitempty"`
+}
+
+// pointID returns a valid Qdrant point ID (UUID) for the given document ID
+func pointID(documentID string) string {
+	if _, err := uuid.Parse(documentID); err == nil {
+		return documentID
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(documentID)).String()
+}
+
+func (p point) toDocument() vs.Document {
+	doc := vs.Document{
+		ID:              p.ID,
+		SimilarityScore: p.Score,
+		Embedding:       p.Vector,
+	}
+	if id, ok := p.Payload[payloadKeyDocumentID].(string); ok && id != "" {
+		doc.ID = id
+	}
+	if content, ok := p.Payload[payloadKeyContent].(string); ok {
+		doc.Content = content
+	}
+	if metadata, ok := p.Payload[payloadKeyMetadata].(map[string]any); ok {
+		doc.Metadata = metadata
+	} else {
+		doc.Metadata = map[string]any{}
+	}
+	return doc
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	ids := make([]string, len(docs))
+	points := make([]point, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.embeddingConcurrency)
+
+	for docIdx, doc := range docs {
+		ids[docIdx] = doc.ID
+
+		g.Go(func() error {
+			vec := doc.Embedding
+			if len(vec) == 0 {
+				var err error
+				vec, err = v.embeddingFunc(gctx, doc.Content)
+				if err != nil {
+					slog.Error("failed to embed document", "documentID", doc.ID, "error", err)
+					return fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+				}
+			}
+			points[docIdx] = point{
+				ID:     pointID(doc.ID),
+				Vector: vec,
+				Payload: map[string]any{
+					payloadKeyDocumentID: doc.ID,
+					payloadKeyContent:    doc.Content,
+					payloadKeyMetadata:   doc.Metadata,
+				},
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(points); start += upsertBatchSize {
+		batch := points[start:min(start+upsertBatchSize, len(points))]
+		slog.Debug("Sending batch to qdrant", "store", "qdrant", "collection", collection, "batchSize", len(batch))
+		if err := v.client.do(ctx, http.MethodPut, v.collectionPath(collection)+"/points?wait=true", map[string]any{"points": batch}, nil); err != nil {
+			if isNotFound(err) {
+				return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+			}
+			return nil, fmt.Errorf("failed to upsert documents into qdrant: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
+	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", where, "whereDocument", whereDocument, "store", "qdrant")
+
+	ef := v.embeddingFunc
+	if embeddingFunc != nil {
+		ef = embeddingFunc
+	}
+
+	queryEmbedding, err := ef(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := buildFilter(where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]any{
+		"vector":       queryEmbedding,
+		"limit":        numDocuments,
+		"with_payload": true,
+	}
+	if f != nil {
+		body["filter"] = f
+	}
+
+	var points []point
+	if err := v.client.do(ctx, http.MethodPost, v.collectionPath(collection)+"/points/search", body, &points); err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		return nil, fmt.Errorf("failed to search qdrant: %w", err)
+	}
+
+	docs := make([]vs.Document, 0, len(points))
+	for _, p := range points {
+		docs = append(docs, p.toDocument())
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) RemoveCollection(ctx context.Context, collection string) error {
+	slog.Debug("Removing collection", "collection", collection, "store", "qdrant")
+	if err := v.client.do(ctx, http.MethodDelete, v.collectionPath(collection), nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []vs.WhereDocument) error {
+	slog.Info("Removing document", "documentID", documentID, "collection", collection, "where", where, "store", "qdrant")
+
+	// Where clause takes precedence over documentID for consistency with the other vector stores
+	var body map[string]any
+	if len(where) > 0 {
+		f, err := buildFilter(where, whereDocument)
+		if err != nil {
+			return err
+		}
+		body = map[string]any{"filter": f}
+	} else {
+		body = map[string]any{"points": []string{pointID(documentID)}}
+	}
+
+	if err := v.client.do(ctx, http.MethodPost, v.collectionPath(collection)+"/points/delete?wait=true", body, nil); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		return fmt.Errorf("failed to remove document(s) from qdrant: %w", err)
+	}
+	return nil
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
+	var p point
+	path := fmt.Sprintf("%s/points/%s", v.collectionPath(collection), pointID(documentID))
+	if err := v.client.do(ctx, http.MethodGet, path, nil, &p); err != nil {
+		return vs.Document{}, fmt.Errorf("failed to get document %s from qdrant: %w", documentID, err)
+	}
+	return p.toDocument(), nil
+}
+
+// GetDocuments returns all documents matching the filters. If no collection is given, all collections (with the configured prefix) are searched.
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	f, err := buildFilter(where, whereDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	collections := []string{collection}
+	if collection == "" {
+		collections, err = v.listCollections(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var docs []vs.Document
+	for _, c := range collections {
+		cdocs, err := v.scroll(ctx, c, f)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, cdocs...)
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) scroll(ctx context.Context, collection string, f *filter) ([]vs.Document, error) {
+	docs := make([]vs.Document, 0)
+
+	var offset any
+	for {
+		body := map[string]any{
+			"limit":        scrollPageSize,
+			"with_payload": true,
+			"with_vector":  true,
+		}
+		if f != nil {
+			body["filter"] = f
+		}
+		if offset != nil {
+			body["offset"] = offset
+		}
+
+		var res struct {
+			Points         []point `json:"points"`
+			NextPageOffset any     `json:"next_page_offset"`
+		}
+		if err := v.client.do(ctx, http.MethodPost, v.collectionPath(collection)+"/points/scroll", body, &res); err != nil {
+			if isNotFound(err) {
+				return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+			}
+			return nil, fmt.Errorf("failed to get documents from qdrant: %w", err)
+		}
+
+		for _, p := range res.Points {
+			docs = append(docs, p.toDocument())
+		}
+
+		if res.NextPageOffset == nil {
+			break
+		}
+		offset = res.NextPageOffset
+	}
+	return docs, nil
+}
+
+// listCollections returns the names of all collections with the configured prefix (with the prefix removed)
+func (v *VectorStore) listCollections(ctx context.Context) ([]string, error) {
+	var res struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	}
+	if err := v.client.do(ctx, http.MethodGet, "/collections", nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to list qdrant collections: %w", err)
+	}
+
+	var collections []string
+	for _, c := range res.Collections {
+		if name, ok := strings.CutPrefix(c.Name, v.collectionPrefix); ok {
+			collections = append(collections, name)
+		}
+	}
+	return collections, nil
+}
+
+// UpdateDocumentMetadata applies the metadata patch to the payload of the point without re-embedding it
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	set, remove := patch.Split()
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "set", set, "remove", remove, "store", "qdrant")
+
+	points := []string{pointID(documentID)}
+	if len(set) > 0 {
+		// key sets the values in the nested metadata object instead of the top level of the payload
+		body := map[string]any{"payload": set, "points": points, "key": payloadKeyMetadata}
+		if err := v.client.do(ctx, http.MethodPost, v.collectionPath(collection)+"/points/payload?wait=true", body, nil); err != nil {
+			return updateMetadataError(err, documentID)
+		}
+	}
+	if len(remove) > 0 {
+		keys := make([]string, len(remove))
+		for i, k := range remove {
+			keys[i] = payloadKeyMetadata + "." + k
+		}
+		body := map[string]any{"keys": keys, "points": points}
+		if err := v.client.do(ctx, http.MethodPost, v.collectionPath(collection)+"/points/payload/delete?wait=true", body, nil); err != nil {
+			return updateMetadataError(err, documentID)
+		}
+	}
+	return nil
+}
+
+// updateMetadataError maps the not found errors of the payload updates - Qdrant returns 404 for missing points as well as missing collections
+func updateMetadataError(err error, documentID string) error {
+	if isNotFound(err) {
+		return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+	}
+	return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+}
+
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats for vectorstore qdrant", vserr.ErrNotSupported)
+}
+
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
+func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ImportCollections(ctx, v, "qdrant", path, collections...)
+}
+
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
+func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ExportCollections(ctx, v, "qdrant", path, v.listCollections, collections...)
+}
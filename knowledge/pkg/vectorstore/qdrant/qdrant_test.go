@@ -0,0 +1,47 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentMetadata(t *testing.T) {
+	requests := map[string]map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if points, _ := body["points"].([]any); len(points) == 0 || points[0] != pointID("doc1") {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status": {"error": "Not found: No point with id found"}}`))
+			return
+		}
+		requests[r.URL.Path] = body
+		_, _ = w.Write([]byte(`{"status": "ok", "result": {"status": "completed"}}`))
+	}))
+	defer srv.Close()
+
+	v := &VectorStore{client: &client{baseURL: srv.URL, httpClient: srv.Client()}, collectionPrefix: "knowledge_"}
+	ctx := context.Background()
+
+	require.NoError(t, v.UpdateDocumentMetadata(ctx, "doc1", "ds", vs.MetadataPatch{"tags": []string{"a"}, "draft": nil}))
+	assert.Equal(t, map[string]any{
+		"payload": map[string]any{"tags": []any{"a"}},
+		"points":  []any{pointID("doc1")},
+		"key":     "metadata",
+	}, requests["/collections/knowledge_ds/points/payload"])
+	assert.Equal(t, map[string]any{
+		"keys":   []any{"metadata.draft"},
+		"points": []any{pointID("doc1")},
+	}, requests["/collections/knowledge_ds/points/payload/delete"])
+
+	err := v.UpdateDocumentMetadata(ctx, "doc2", "ds", vs.MetadataPatch{"tags": nil})
+	assert.ErrorIs(t, err, vserr.ErrDocumentNotFound)
+}
@@ -0,0 +1,748 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// VsRedisEmbeddingConcurrency can be set as an environment variable to control the number of parallel API calls to create embedding for documents. Default is 100
+	VsRedisEmbeddingConcurrency = "VS_REDIS_EMBEDDING_CONCURRENCY"
+
+	// VsRedisPassword can be set as an environment variable to provide the password, if it's not part of the DSN
+	VsRedisPassword = "VS_REDIS_PASSWORD"
+
+	defaultPrefix = "knowledge:"
+
+	fieldContent    = "content"
+	fieldMetadata   = "metadata"
+	fieldDocumentID = "document_id"
+	fieldEmbedding  = "embedding"
+	fieldScore      = "vector_score"
+
+	// metadataFieldPrefix is the prefix for the TAG fields used for metadata filtering.
+	// The field values are hashes of the string forms of the metadata values (one per element of lists, see vs.MetadataStrings),
+	// so they don't have to be escaped and are matched exactly.
+	metadataFieldPrefix = "meta_"
+
+	pipelineBatchSize = 256
+	pageSize          = 1000
+
+	// updateMetadataRetries is the number of attempts to update the metadata of a document that's modified concurrently
+	updateMetadataRetries = 3
+
+	// searchOverfetchFactor is used to fetch more candidates if content filters have to be applied in memory
+	searchOverfetchFactor = 4
+)
+
+var (
+	invalidNameCharsRegex  = regexp.MustCompile(`[^_0-9A-Za-z-]`)
+	invalidFieldCharsRegex = regexp.MustCompile(`[^_0-9A-Za-z]`)
+)
+
+// VectorStore stores documents as hashes, indexed by a RediSearch HNSW index per collection.
+// Requires Redis Stack or Redis >= 8 (with the query engine).
+type VectorStore struct {
+	embeddingFunc        vs.EmbeddingFunc
+	embeddingConcurrency int
+	client               *redis.Client
+	prefix               string
+
+	// schemaLock guards the schema updates for the metadata fields
+	schemaLock sync.Mutex
+}
+
+// New creates a new Redis vector store from a DSN of the form
+//
+//	redis://[[user]:password@]host[:port][/db][?tls=true&prefix=knowledge:&pool_size=10]
+//
+// Besides tls and prefix, the connection options of go-redis are supported as query parameters (e.g. pool_size, dial_timeout).
+func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
+	opts, prefix, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &VectorStore{
+		embeddingFunc:        embeddingFunc,
+		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsRedisEmbeddingConcurrency, 100),
+		client:               redis.NewClient(opts),
+		prefix:               prefix,
+	}
+
+	slog.Debug("redis", "addr", opts.Addr, "tls", opts.TLSConfig != nil, "prefix", prefix, "poolSize", opts.PoolSize)
+
+	// Check connectivity and that the query engine is available
+	if err := store.client.Do(ctx, "FT._LIST").Err(); err != nil {
+		_ = store.client.Close()
+		return nil, fmt.Errorf("failed to connect to redis (RediSearch module required): %w", err)
+	}
+
+	return store, nil
+}
+
+// parseDSN returns the go-redis client options and the key prefix
+func parseDSN(dsn string) (*redis.Options, string, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse redis DSN: %w", err)
+	}
+
+	// strip our own parameters, which go-redis would reject
+	q := u.Query()
+	prefix := q.Get("prefix")
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	useTLS := u.Scheme == "rediss" || q.Get("tls") == "true"
+	q.Del("prefix")
+	q.Del("tls")
+	u.RawQuery = q.Encode()
+
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse redis DSN: %w", err)
+	}
+	if opts.Password == "" {
+		if opts.Username != "" {
+			// redis://password@host
+			opts.Password, opts.Username = opts.Username, ""
+		} else {
+			opts.Password = os.Getenv(VsRedisPassword)
+		}
+	}
+	if useTLS && opts.TLSConfig == nil {
+		opts.TLSConfig = &tls.Config{ServerName: u.Hostname()}
+	}
+	opts.Protocol = 2 // the reply parsing expects the RESP2 shape of the FT.* replies
+
+	return opts, prefix, nil
+}
+
+func (v *VectorStore) Close() error {
+	return v.client.Close()
+}
+
+func asString(reply any) string {
+	switch v := reply.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+func isUnknownIndexError(err error) bool {
+	var e redis.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	msg := strings.ToLower(e.Error())
+	return strings.Contains(msg, "unknown index") || strings.Contains(msg, "no such index")
+}
+
+func sanitizeName(name string) string {
+	sanitized := invalidNameCharsRegex.ReplaceAllString(name, "_")
+	if sanitized != name {
+		h := sha1.Sum([]byte(name))
+		sanitized += "_" + hex.EncodeToString(h[:4])
+	}
+	return sanitized
+}
+
+func (v *VectorStore) indexName(collection string) string {
+	return v.prefix + "idx:" + sanitizeName(collection)
+}
+
+func (v *VectorStore) keyPrefix(collection string) string {
+	return v.prefix + "doc:" + sanitizeName(collection) + ":"
+}
+
+func (v *VectorStore) key(collection, documentID string) string {
+	return v.keyPrefix(collection) + documentID
+}
+
+func metadataField(key string) string {
+	return metadataFieldPrefix + invalidFieldCharsRegex.ReplaceAllString(key, "_")
+}
+
+func metadataTagValue(s string) string {
+	h := sha1.Sum([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// metadataTagValues returns the TAG field value for a metadata value, multiple tags being separated by commas
+func metadataTagValues(value any) string {
+	strs := vs.MetadataStrings(value)
+	tags := make([]string, len(strs))
+	for i, s := range strs {
+		tags[i] = metadataTagValue(s)
+	}
+	return strings.Join(tags, ",")
+}
+
+// indexFields returns the attribute names of the given index or nil if the index doesn't exist
+func (v *VectorStore) indexFields(ctx context.Context, collection string) ([]string, error) {
+	reply, err := v.client.Do(ctx, "FT.INFO", v.indexName(collection)).Result()
+	if err != nil {
+		if isUnknownIndexError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	info, _ := reply.([]any)
+	fields := []string{}
+	for i := 0; i+1 < len(info); i += 2 {
+		if asString(info[i]) != "attributes" {
+			continue
+		}
+		attrs, _ := info[i+1].([]any)
+		for _, attr := range attrs {
+			kv, _ := attr.([]any)
+			for j := 0; j+1 < len(kv); j += 2 {
+				if asString(kv[j]) == "attribute" {
+					fields = append(fields, asString(kv[j+1]))
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+func (v *VectorStore) CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error {
+	if opts == nil {
+		opts = &dbtypes.DatasetCreateOpts{}
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "index", v.indexName(collection), "store", "redis")
+
+	fields, err := v.indexFields(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("failed to check if collection %s exists: %w", collection, err)
+	}
+	if fields != nil {
+		if opts.ErrOnExists {
+			return fmt.Errorf("collection %s already exists", collection)
+		}
+		slog.Debug("Collection already exists but that's fine", "collection", collection)
+		return nil
+	}
+
+	emb, err := v.embeddingFunc(ctx, "dummy text")
+	if err != nil {
+		return fmt.Errorf("failed to get embedding: %w", err)
+	}
+	dimensionality := len(emb)
+
+	err = v.client.Do(ctx, "FT.CREATE", v.indexName(collection),
+		"ON", "HASH",
+		"PREFIX", 1, v.keyPrefix(collection),
+		"SCHEMA",
+		fieldDocumentID, "TAG", "CASESENSITIVE",
+		fieldContent, "TEXT",
+		fieldEmbedding, "VECTOR", "HNSW", 6, "TYPE", "FLOAT32", "DIM", dimensionality, "DISTANCE_METRIC", "COSINE",
+	).Err()
+	if err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+// ensureMetadataFields adds TAG fields for the given metadata keys to the index schema if they're missing
+func (v *VectorStore) ensureMetadataFields(ctx context.Context, collection string, keys []string) error {
+	v.schemaLock.Lock()
+	defer v.schemaLock.Unlock()
+
+	fields, err := v.indexFields(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if fields == nil {
+		return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+
+	for _, k := range keys {
+		field := metadataField(k)
+		if slices.Contains(fields, field) {
+			continue
+		}
+		if err := v.client.Do(ctx, "FT.ALTER", v.indexName(collection), "SCHEMA", "ADD", field, "TAG", "CASESENSITIVE").Err(); err != nil {
+			return fmt.Errorf("failed to add metadata field %q to index: %w", field, err)
+		}
+		fields = append(fields, field)
+	}
+	return nil
+}
+
+func serializeEmbedding(vec []float32) []byte {
+	b := make([]byte, 4*len(vec))
+	for i, f := range vec {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+func deserializeEmbedding(b []byte) []float32 {
+	vec := make([]float32, len(b)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return vec
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	var metadataKeys []string
+	for _, doc := range docs {
+		for k := range doc.Metadata {
+			if !slices.Contains(metadataKeys, k) {
+				metadataKeys = append(metadataKeys, k)
+			}
+		}
+	}
+	if err := v.ensureMetadataFields(ctx, collection, metadataKeys); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(docs))
+	cmds := make([][]any, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.embeddingConcurrency)
+
+	for docIdx, doc := range docs {
+		ids[docIdx] = doc.ID
+
+		g.Go(func() error {
+			vec := doc.Embedding
+			if len(vec) == 0 {
+				var err error
+				vec, err = v.embeddingFunc(gctx, doc.Content)
+				if err != nil {
+					slog.Error("failed to embed document", "documentID", doc.ID, "error", err)
+					return fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+				}
+			}
+
+			metadataJSON, err := json.Marshal(doc.Metadata)
+			if err != nil {
+				return fmt.Errorf("failed to marshal metadata of document %s: %w", doc.ID, err)
+			}
+
+			cmd := []any{"HSET", v.key(collection, doc.ID),
+				fieldDocumentID, doc.ID,
+				fieldContent, doc.Content,
+				fieldMetadata, metadataJSON,
+				fieldEmbedding, serializeEmbedding(vec),
+			}
+			for k, val := range doc.Metadata {
+				cmd = append(cmd, metadataField(k), metadataTagValues(val))
+			}
+			cmds[docIdx] = cmd
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(cmds); start += pipelineBatchSize {
+		batch := cmds[start:min(start+pipelineBatchSize, len(cmds))]
+		slog.Debug("Sending batch to redis", "store", "redis", "collection", collection, "batchSize", len(batch))
+		pipe := v.client.Pipeline()
+		for _, cmd := range batch {
+			pipe.Do(ctx, cmd...)
+		}
+		// Exec returns the error of the first failed command
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("failed to add documents to redis: %w", err)
+		}
+	}
+
+	return ids, nil
+}
+
+// buildQuery translates the metadata filter into a RediSearch query, matching numbers and booleans with the same literal
+// as well (see vs.WhereStrings). Content filters are always applied in memory,
+// as RediSearch full-text matching is token-based and doesn't support exact substring matching.
+func buildQuery(where map[string]string) string {
+	if len(where) == 0 {
+		return "*"
+	}
+	keys := make([]string, 0, len(where))
+	for k := range where {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	clauses := make([]string, len(keys))
+	for i, k := range keys {
+		values := vs.WhereStrings(where[k])
+		tags := make([]string, len(values))
+		for j, value := range values {
+			tags[j] = metadataTagValue(value)
+		}
+		clauses[i] = fmt.Sprintf("@%s:{%s}", metadataField(k), strings.Join(tags, " | "))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// hasMetadataFields checks whether the index has fields for all the given metadata keys
+// (querying unknown fields is an error in RediSearch - but nothing could match anyway)
+func hasMetadataFields(fields []string, where map[string]string) bool {
+	for k := range where {
+		if !slices.Contains(fields, metadataField(k)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSearchReply parses an FT.SEARCH reply into documents
+func parseSearchReply(reply any) (int64, []vs.Document, error) {
+	arr, ok := reply.([]any)
+	if !ok || len(arr) == 0 {
+		return 0, nil, fmt.Errorf("unexpected FT.SEARCH reply: %v", reply)
+	}
+	total, _ := arr[0].(int64)
+
+	var docs []vs.Document
+	for i := 1; i+1 < len(arr); i += 2 {
+		fields, _ := arr[i+1].([]any)
+		doc := vs.Document{Metadata: map[string]any{}}
+		for j := 0; j+1 < len(fields); j += 2 {
+			switch asString(fields[j]) {
+			case fieldDocumentID:
+				doc.ID = asString(fields[j+1])
+			case fieldContent:
+				doc.Content = asString(fields[j+1])
+			case fieldMetadata:
+				if err := json.Unmarshal([]byte(asString(fields[j+1])), &doc.Metadata); err != nil || doc.Metadata == nil {
+					doc.Metadata = map[string]any{}
+				}
+			case fieldEmbedding:
+				doc.Embedding = deserializeEmbedding([]byte(asString(fields[j+1])))
+			case fieldScore:
+				// cosine distance -> cosine similarity (consistent with the other vector stores)
+				if d, err := strconv.ParseFloat(asString(fields[j+1]), 32); err == nil {
+					doc.SimilarityScore = float32(1 - d)
+				}
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return total, docs, nil
+}
+
+func matchesAll(doc *vs.Document, whereDocument []vs.WhereDocument) bool {
+	for _, wd := range whereDocument {
+		if !wd.Matches(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, where map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
+	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", where, "whereDocument", whereDocument, "store", "redis")
+
+	fields, err := v.indexFields(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+	if !hasMetadataFields(fields, where) {
+		return []vs.Document{}, nil
+	}
+
+	ef := v.embeddingFunc
+	if embeddingFunc != nil {
+		ef = embeddingFunc
+	}
+
+	queryEmbedding, err := ef(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	k := numDocuments
+	if len(whereDocument) > 0 {
+		k *= searchOverfetchFactor
+	}
+
+	q := fmt.Sprintf("(%s)=>[KNN %d @%s $vec AS %s]", buildQuery(where), k, fieldEmbedding, fieldScore)
+	reply, err := v.client.Do(ctx, "FT.SEARCH", v.indexName(collection), q,
+		"PARAMS", 2, "vec", serializeEmbedding(queryEmbedding),
+		"SORTBY", fieldScore, "ASC",
+		"RETURN", 4, fieldDocumentID, fieldContent, fieldMetadata, fieldScore,
+		"LIMIT", 0, k,
+		"DIALECT", 2,
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to search redis: %w", err)
+	}
+
+	_, results, err := parseSearchReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]vs.Document, 0, len(results))
+	for _, doc := range results {
+		if !matchesAll(&doc, whereDocument) {
+			continue
+		}
+		docs = append(docs, doc)
+		if len(docs) >= numDocuments {
+			break
+		}
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) RemoveCollection(ctx context.Context, collection string) error {
+	slog.Debug("Removing collection", "collection", collection, "store", "redis")
+	// DD also deletes the documents (hashes)
+	if err := v.client.Do(ctx, "FT.DROPINDEX", v.indexName(collection), "DD").Err(); err != nil && !isUnknownIndexError(err) {
+		return fmt.Errorf("failed to remove collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []vs.WhereDocument) error {
+	slog.Info("Removing document", "documentID", documentID, "collection", collection, "where", where, "store", "redis")
+
+	// Where clause takes precedence over documentID for consistency with the other vector stores
+	if len(where) == 0 {
+		if err := v.client.Del(ctx, v.key(collection, documentID)).Err(); err != nil {
+			return fmt.Errorf("failed to remove document %s from redis: %w", documentID, err)
+		}
+		return nil
+	}
+
+	docs, err := v.GetDocuments(ctx, collection, where, whereDocument)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(docs); start += pipelineBatchSize {
+		batch := docs[start:min(start+pipelineBatchSize, len(docs))]
+		keys := make([]string, 0, len(batch))
+		for _, doc := range batch {
+			keys = append(keys, v.key(collection, doc.ID))
+		}
+		if err := v.client.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to remove documents from redis: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
+	values, err := v.client.HMGet(ctx, v.key(collection, documentID), fieldDocumentID, fieldContent, fieldMetadata, fieldEmbedding).Result()
+	if err != nil {
+		return vs.Document{}, fmt.Errorf("failed to get document %s from redis: %w", documentID, err)
+	}
+	if len(values) != 4 || values[0] == nil {
+		return vs.Document{}, fmt.Errorf("document %s not found in collection %s", documentID, collection)
+	}
+
+	// re-use the search reply parser
+	_, docs, err := parseSearchReply([]any{int64(1), v.key(collection, documentID), []any{
+		fieldDocumentID, values[0],
+		fieldContent, values[1],
+		fieldMetadata, values[2],
+		fieldEmbedding, values[3],
+	}})
+	if err != nil {
+		return vs.Document{}, err
+	}
+	return docs[0], nil
+}
+
+// GetDocuments returns all documents matching the filters. If no collection is given, all collections (with the configured prefix) are searched.
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	var collections []string
+	if collection != "" {
+		collections = []string{collection}
+	} else {
+		var err error
+		collections, err = v.collectionNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	docs := make([]vs.Document, 0)
+	for _, c := range collections {
+		fields, err := v.indexFields(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil {
+			if collection != "" {
+				return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+			}
+			continue
+		}
+		if !hasMetadataFields(fields, where) {
+			continue
+		}
+
+		for offset := 0; ; offset += pageSize {
+			reply, err := v.client.Do(ctx, "FT.SEARCH", v.indexName(c), buildQuery(where),
+				"RETURN", 4, fieldDocumentID, fieldContent, fieldMetadata, fieldEmbedding,
+				"LIMIT", offset, pageSize,
+				"DIALECT", 2,
+			).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get documents from redis: %w", err)
+			}
+			total, results, err := parseSearchReply(reply)
+			if err != nil {
+				return nil, err
+			}
+			for _, doc := range results {
+				if matchesAll(&doc, whereDocument) {
+					docs = append(docs, doc)
+				}
+			}
+			if len(results) == 0 || int64(offset+pageSize) >= total {
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+// collectionNames returns the collection names of the indexes with the configured prefix
+func (v *VectorStore) collectionNames(ctx context.Context) ([]string, error) {
+	reply, err := v.client.Do(ctx, "FT._LIST").Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list redis indexes: %w", err)
+	}
+	indexes, _ := reply.([]any)
+	var collections []string
+	for _, idx := range indexes {
+		if name, ok := strings.CutPrefix(asString(idx), v.prefix+"idx:"); ok {
+			// index names are already sanitized, so they can be used as collection names as-is
+			collections = append(collections, name)
+		}
+	}
+	return collections, nil
+}
+
+// UpdateDocumentMetadata applies the metadata patch to the hash of the document (the metadata JSON and the TAG fields) without re-embedding it.
+// The metadata is read and written in a transaction, which is retried if the document is modified concurrently.
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	set, remove := patch.Split()
+	if err := v.ensureMetadataFields(ctx, collection, slices.Sorted(maps.Keys(set))); err != nil {
+		return err
+	}
+
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "set", set, "remove", remove, "store", "redis")
+
+	key := v.key(collection, documentID)
+	update := func(tx *redis.Tx) error {
+		values, err := tx.HMGet(ctx, key, fieldDocumentID, fieldMetadata).Result()
+		if err != nil {
+			return err
+		}
+		if len(values) != 2 || values[0] == nil {
+			return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+		}
+		hset, hdel, err := metadataPatchFields(asString(values[1]), patch)
+		if err != nil {
+			return fmt.Errorf("failed to patch metadata of document %s: %w", documentID, err)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, hset...)
+			if len(hdel) > 0 {
+				pipe.HDel(ctx, key, hdel...)
+			}
+			return nil
+		})
+		return err
+	}
+
+	var err error
+	for range updateMetadataRetries {
+		if err = v.client.Watch(ctx, update, key); !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+	if err != nil && !errors.Is(err, vserr.ErrDocumentNotFound) {
+		return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+	}
+	return err
+}
+
+// metadataPatchFields returns the hash fields to set (field-value pairs) and to delete to apply the patch to the given metadata JSON
+func metadataPatchFields(metadataJSON string, patch vs.MetadataPatch) ([]any, []string, error) {
+	var metadata map[string]any
+	if metadataJSON != "" && metadataJSON != "null" {
+		if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	patched, err := json.Marshal(patch.Apply(metadata))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	set, remove := patch.Split()
+	hset := []any{fieldMetadata, string(patched)}
+	for _, k := range slices.Sorted(maps.Keys(set)) {
+		hset = append(hset, metadataField(k), metadataTagValues(set[k]))
+	}
+	hdel := make([]string, len(remove))
+	for i, k := range remove {
+		hdel[i] = metadataField(k)
+	}
+	return hset, hdel, nil
+}
+
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats for vectorstore redis", vserr.ErrNotSupported)
+}
+
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
+func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ImportCollections(ctx, v, "redis", path, collections...)
+}
+
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
+func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ExportCollections(ctx, v, "redis", path, v.collectionNames, collections...)
+}
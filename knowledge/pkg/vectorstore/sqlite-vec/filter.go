@@ -0,0 +1,121 @@
+package sqlite_vec
+
+import (
+	"fmt"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+// buildMetadataFilterClause translates the metadata filter to a condition on the JSON metadata column of the embeddings table
+func buildMetadataFilterClause(f *vs.MetadataFilter) (string, []any, error) {
+	switch f.Operator {
+	case vs.FilterOperatorAnd, vs.FilterOperatorOr:
+		if len(f.Filters) == 0 {
+			if f.Operator == vs.FilterOperatorAnd {
+				return "TRUE", nil, nil
+			}
+			return "FALSE", nil, nil
+		}
+		var clauses []string
+		var args []any
+		for i := range f.Filters {
+			c, a, err := buildMetadataFilterClause(&f.Filters[i])
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, c)
+			args = append(args, a...)
+		}
+		sep := " AND "
+		if f.Operator == vs.FilterOperatorOr {
+			sep = " OR "
+		}
+		return "(" + strings.Join(clauses, sep) + ")", args, nil
+	}
+
+	path := jsonPath(f.Key)
+	switch f.Operator {
+	case vs.FilterOperatorEq:
+		return elementEquals(path, f.Value, false)
+	case vs.FilterOperatorNe:
+		c, args, err := elementEquals(path, f.Value, false)
+		return "NOT " + c, args, err
+	case vs.FilterOperatorIn, vs.FilterOperatorNin:
+		values, _ := f.Value.([]any)
+		var clauses []string
+		var args []any
+		for _, v := range values {
+			c, a, err := elementEquals(path, v, false)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, c)
+			args = append(args, a...)
+		}
+		clause := "FALSE"
+		if len(clauses) > 0 {
+			clause = "(" + strings.Join(clauses, " OR ") + ")"
+		}
+		if f.Operator == vs.FilterOperatorNin {
+			clause = "NOT " + clause
+		}
+		return clause, args, nil
+	case vs.FilterOperatorGt, vs.FilterOperatorGte, vs.FilterOperatorLt, vs.FilterOperatorLte:
+		op := map[vs.FilterOperator]string{vs.FilterOperatorGt: ">", vs.FilterOperatorGte: ">=", vs.FilterOperatorLt: "<", vs.FilterOperatorLte: "<="}[f.Operator]
+		types, err := jsonTypes(f.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(json_type(metadata, ?) IN (%s) AND json_extract(metadata, ?) %s ?)", types, op), []any{path, path, f.Value}, nil
+	case vs.FilterOperatorContains:
+		element, args, err := elementEquals(path, f.Value, true)
+		if err != nil {
+			return "", nil, err
+		}
+		s, ok := f.Value.(string)
+		if !ok {
+			return element, args, nil
+		}
+		return fmt.Sprintf("((json_type(metadata, ?) = 'text' AND instr(json_extract(metadata, ?), ?) > 0) OR %s)", element), append([]any{path, path, s}, args...), nil
+	case vs.FilterOperatorExists:
+		clause := "json_type(metadata, ?) IS NOT NULL"
+		if exists, _ := f.Value.(bool); !exists {
+			clause = "json_type(metadata, ?) IS NULL"
+		}
+		return clause, []any{path}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported metadata filter operator %q", f.Operator)
+}
+
+// elementEquals matches the value or, if the metadata value is a list, its elements - only the elements if listOnly is set.
+// json_each returns the value itself if it's not a list.
+func elementEquals(path string, value any, listOnly bool) (string, []any, error) {
+	types, err := jsonTypes(value)
+	if err != nil {
+		return "", nil, err
+	}
+	clause := fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(metadata, ?) WHERE type IN (%s) AND value = ?)", types)
+	if listOnly {
+		return "(json_type(metadata, ?) = 'array' AND " + clause + ")", []any{path, path, value}, nil
+	}
+	return clause, []any{path, value}, nil
+}
+
+// jsonTypes returns the SQLite JSON types matching the type of the value, so that e.g. true doesn't match 1
+func jsonTypes(value any) (string, error) {
+	switch value.(type) {
+	case string:
+		return "'text'", nil
+	case float64:
+		return "'integer', 'real'", nil
+	case bool:
+		return "'true', 'false'", nil
+	}
+	return "", fmt.Errorf("unsupported metadata filter value %v (%T)", value, value)
+}
+
+// jsonPath returns the JSON path of the metadata key - keys are quoted, so that they may contain dots (keys with quotes are rejected by the filter parser)
+func jsonPath(key string) string {
+	return `$."` + key + `"`
+}
@@ -2,14 +2,18 @@ package sqlite_vec
 
 import (
 	"context"
+	"database/sql"
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 
 	sqlitevec "github.com/asg017/sqlite-vec-go-bindings/ncruces"
 	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
 	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
 	"gorm.io/gorm"
@@ -190,16 +194,45 @@ func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDoc
 		return nil, fmt.Errorf("failed to serialize query embedding: %w", err)
 	}
 
-	var docs []vs.Document
-	err = v.db.Transaction(func(tx *gorm.DB) error {
-		// Query matching document IDs and distances
-		rows, err := tx.Raw(fmt.Sprintf(`
-            SELECT document_id, distance 
+	searchOpts := vs.SearchOptionsFromCtx(ctx)
+	includeEmbeddings := searchOpts.IncludeEmbeddings
+	offset := max(searchOpts.Offset, 0)
+	embeddingCol := ""
+	if includeEmbeddings {
+		embeddingCol = ", embedding"
+	}
+
+	// Query matching document IDs and distances
+	sqlQuery := fmt.Sprintf(`
+            SELECT document_id, distance%s
             FROM [%s_vec]
             WHERE embedding MATCH ? 
             ORDER BY distance 
             LIMIT ?
-        `, collection), qv, numDocuments).Rows()
+        `, embeddingCol, collection)
+	// the KNN search only supports a limit (k), so the results before the offset are dropped after the query
+	args := []any{qv, numDocuments + offset}
+
+	if searchOpts.Filter != nil {
+		// The KNN search can't be restricted to the documents matching the metadata filter, so the distances
+		// of all matching documents are computed instead - this returns the top k matching documents, unlike filtering the KNN results.
+		filterClause, filterArgs, err := buildMetadataFilterClause(searchOpts.Filter)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery = fmt.Sprintf(`
+            SELECT document_id, vec_distance_cosine(embedding, ?) AS distance%s
+            FROM [%s_vec]
+            WHERE document_id IN (SELECT id FROM [%s] WHERE collection_id = ? AND %s)
+            ORDER BY distance
+            LIMIT ?
+        `, embeddingCol, collection, v.embeddingsTableName, filterClause)
+		args = append(append([]any{qv, collection}, filterArgs...), numDocuments+offset)
+	}
+
+	var docs []vs.Document
+	err = v.db.Transaction(func(tx *gorm.DB) error {
+		rows, err := tx.Raw(sqlQuery, args...).Rows()
 		if err != nil {
 			return fmt.Errorf("failed to query vector table: %w", err)
 		}
@@ -208,14 +241,26 @@ func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDoc
 		for rows.Next() {
 			var docID string
 			var distance float32
-			if err := rows.Scan(&docID, &distance); err != nil {
+			var emb []byte
+			dest := []any{&docID, &distance}
+			if includeEmbeddings {
+				dest = append(dest, &emb)
+			}
+			if err := rows.Scan(dest...); err != nil {
 				return fmt.Errorf("failed to scan row: %w", err)
 			}
-			docs = append(docs, vs.Document{
+			doc := vs.Document{
 				ID:              docID,
 				SimilarityScore: 1 - distance, // Higher score means closer match
-			})
+			}
+			if includeEmbeddings {
+				if doc.Embedding, err = DeserializeFloat32(emb); err != nil {
+					return fmt.Errorf("failed to deserialize embedding of document %s: %w", docID, err)
+				}
+			}
+			docs = append(docs, doc)
 		}
+		docs = docs[min(offset, len(docs)):]
 
 		// Fetch content and metadata for each document
 		for i, doc := range docs {
@@ -355,7 +400,38 @@ func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection st
 	return doc, nil
 }
 
-func (v *VectorStore) GetDocuments(_ context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+// UpdateDocumentMetadata applies the metadata patch to the document without re-embedding it
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	return v.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var metadataJSON sql.NullString
+		err := tx.Raw(fmt.Sprintf(`SELECT metadata FROM [%s] WHERE id = ? AND collection_id = ?`, v.embeddingsTableName), documentID, collection).Row().Scan(&metadataJSON)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+			}
+			return fmt.Errorf("failed to query document: %w", err)
+		}
+
+		var metadata map[string]any
+		if metadataJSON.Valid && metadataJSON.String != "" {
+			if err := json.Unmarshal([]byte(metadataJSON.String), &metadata); err != nil {
+				return fmt.Errorf("failed to parse metadata for document %s: %w", documentID, err)
+			}
+		}
+
+		updated, err := json.Marshal(patch.Apply(metadata))
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata for document %s: %w", documentID, err)
+		}
+
+		if err := tx.Exec(fmt.Sprintf(`UPDATE [%s] SET metadata = ? WHERE id = ? AND collection_id = ?`, v.embeddingsTableName), string(updated), documentID, collection).Error; err != nil {
+			return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+		}
+		return nil
+	})
+}
+
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
 	var docs []vs.Document
 
 	// Build metadata filter query
@@ -384,11 +460,21 @@ func (v *VectorStore) GetDocuments(_ context.Context, collection string, where m
 		whereQuery = " AND " + whereQuery
 	}
 
+	var pageQuery string
+	if page := vs.ListOptionsFromCtx(ctx); page.IsSet() {
+		limit := -1 // no limit
+		if page.Limit > 0 {
+			limit = page.Limit
+		}
+		pageQuery = "\n        ORDER BY id LIMIT ? OFFSET ?"
+		args = append(args, limit, max(page.Offset, 0))
+	}
+
 	query := fmt.Sprintf(`
         SELECT id, content, metadata
         FROM [%s]
-        WHERE collection_id = ?%s;
-    `, v.embeddingsTableName, whereQuery)
+        WHERE collection_id = ?%s%s;
+    `, v.embeddingsTableName, whereQuery, pageQuery)
 
 	rows, err := v.db.Raw(query, args...).Rows()
 	if err != nil {
@@ -415,6 +501,53 @@ func (v *VectorStore) GetDocuments(_ context.Context, collection string, where m
 	return docs, nil
 }
 
+// Stats returns the number of documents, the (approximate) storage size and the embedding dimensions per collection.
+// The size is the sum of the content, metadata and embedding sizes, excluding indexes and storage overhead.
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	db := v.db.WithContext(ctx)
+
+	// Every collection has its own vector table
+	var vecTables []string
+	if err := db.Raw(`SELECT name FROM sqlite_master WHERE sql LIKE 'CREATE VIRTUAL TABLE%' AND name LIKE '%\_vec' ESCAPE '\' ORDER BY name`).Scan(&vecTables).Error; err != nil {
+		return nil, fmt.Errorf("failed to list vector tables: %w", err)
+	}
+	existing := make([]string, 0, len(vecTables))
+	for _, t := range vecTables {
+		existing = append(existing, strings.TrimSuffix(t, "_vec"))
+	}
+
+	if len(collections) == 0 {
+		collections = existing
+	}
+
+	stats := make([]vs.CollectionStats, 0, len(collections))
+	for _, collection := range collections {
+		if !slices.Contains(existing, collection) {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+
+		s := vs.CollectionStats{Name: collection}
+		err := db.Raw(fmt.Sprintf(`SELECT COUNT(*), COALESCE(SUM(LENGTH(CAST(content AS BLOB)) + COALESCE(LENGTH(metadata), 0)), 0) FROM [%s] WHERE collection_id = ?`, v.embeddingsTableName), collection).
+			Row().Scan(&s.Documents, &s.SizeBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query stats for collection %s: %w", collection, err)
+		}
+
+		var dims int
+		err = db.Raw(fmt.Sprintf(`SELECT vec_length(embedding) FROM [%s_vec] LIMIT 1`, collection)).Row().Scan(&dims)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to query embedding dimensions for collection %s: %w", collection, err)
+		}
+		if dims > 0 {
+			s.Dimensions = []int{dims}
+			s.SizeBytes += int64(s.Documents * dims * 4) // float32
+		}
+
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
 func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
 	return fmt.Errorf("not implemented")
 }
@@ -422,3 +555,96 @@ func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string
 func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
 	return fmt.Errorf("not implemented")
 }
+
+// shadowInsertBatchSize is the number of rows inserted into the shadow vector table per statement
+const shadowInsertBatchSize = 500
+
+// SupportsPaging reports that the offset of similarity searches and the pages of GetDocuments are applied in the queries (see vectorstore.Pager)
+func (v *VectorStore) SupportsPaging() bool {
+	return true
+}
+
+// SwapEmbeddings replaces the embeddings of all documents in the collection: they're written to a shadow vector table first,
+// which replaces the collection's vector table in a single transaction, so that the old embeddings remain searchable until then.
+func (v *VectorStore) SwapEmbeddings(ctx context.Context, collection string, embeddings map[string][]float32) error {
+	db := v.db.WithContext(ctx)
+
+	var ids []string
+	if err := db.Table(v.embeddingsTableName).Where("collection_id = ?", collection).Pluck("id", &ids).Error; err != nil {
+		return fmt.Errorf("failed to list documents of collection %s: %w", collection, err)
+	}
+	if len(ids) != len(embeddings) {
+		return fmt.Errorf("got %d embeddings for %d documents in collection %s", len(embeddings), len(ids), collection)
+	}
+
+	dimensionality := 0
+	for _, id := range ids {
+		emb, ok := embeddings[id]
+		if !ok {
+			return fmt.Errorf("missing embedding for document %s", id)
+		}
+		if dimensionality == 0 {
+			dimensionality = len(emb)
+		}
+		if len(emb) == 0 || len(emb) != dimensionality {
+			return fmt.Errorf("embedding of document %s has %d dimensions, expected %d", id, len(emb), dimensionality)
+		}
+	}
+	if dimensionality == 0 {
+		return nil // empty collection
+	}
+
+	shadow := collection + "_vec_shadow"
+	createVecTable := `CREATE VIRTUAL TABLE [%s] USING
+	vec0(
+		document_id TEXT PRIMARY KEY,
+		embedding float[%d] distance_metric=cosine
+	)`
+
+	if err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS [%s]`, shadow)).Error; err != nil {
+		return fmt.Errorf("failed to drop leftover shadow table: %w", err)
+	}
+	if err := db.Exec(fmt.Sprintf(createVecTable, shadow, dimensionality)).Error; err != nil {
+		return fmt.Errorf("failed to create shadow table: %w", err)
+	}
+	defer func() {
+		// noop after a successful swap
+		if err := v.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS [%s]`, shadow)).Error; err != nil {
+			slog.Warn("Failed to drop shadow table", "table", shadow, "error", err)
+		}
+	}()
+
+	for start := 0; start < len(ids); start += shadowInsertBatchSize {
+		end := min(start+shadowInsertBatchSize, len(ids))
+		valuePlaceholders := make([]string, 0, end-start)
+		args := make([]interface{}, 0, 2*(end-start))
+		for _, id := range ids[start:end] {
+			serializedEmb, err := sqlitevec.SerializeFloat32(embeddings[id])
+			if err != nil {
+				return fmt.Errorf("failed to serialize embedding for document %s: %w", id, err)
+			}
+			valuePlaceholders = append(valuePlaceholders, "(?, ?)")
+			args = append(args, id, serializedEmb)
+		}
+		query := fmt.Sprintf(`INSERT INTO [%s] (document_id, embedding) VALUES %s`, shadow, strings.Join(valuePlaceholders, ", "))
+		if err := db.Exec(query, args...).Error; err != nil {
+			return fmt.Errorf("failed to insert into shadow table: %w", err)
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS [%s_vec]`, collection)).Error; err != nil {
+			return fmt.Errorf("failed to drop vector table: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf(createVecTable, collection+"_vec", dimensionality)).Error; err != nil {
+			return fmt.Errorf("failed to create vector table: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf(`INSERT INTO [%s_vec] (document_id, embedding) SELECT document_id, embedding FROM [%s]`, collection, shadow)).Error; err != nil {
+			return fmt.Errorf("failed to copy embeddings from shadow table: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf(`DROP TABLE [%s]`, shadow)).Error; err != nil {
+			return fmt.Errorf("failed to drop shadow table: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	// ExportFileName is the name of the file written by vector stores using the portable export format.
+	// The .gob extension is what the dataset archive import looks for.
+	ExportFileName = "vectorstore.gob"
+
+	ExportFormatVersion = 1
+)
+
+// Export is a portable (vector store independent) export of collections, gob-encoded.
+type Export struct {
+	Version     int
+	Collections []ExportCollection
+}
+
+type ExportCollection struct {
+	Name       string
+	Dimensions int
+	Documents  []ExportDocument
+}
+
+type ExportDocument struct {
+	ID        string
+	Content   string
+	Metadata  []byte // JSON-encoded, since gob can't handle arbitrary interface values
+	Embedding []float32
+}
+
+func NewExportDocument(doc Document) (ExportDocument, error) {
+	metadata, err := json.Marshal(doc.Metadata)
+	if err != nil {
+		return ExportDocument{}, fmt.Errorf("failed to marshal metadata of document %s: %w", doc.ID, err)
+	}
+	return ExportDocument{
+		ID:        doc.ID,
+		Content:   doc.Content,
+		Metadata:  metadata,
+		Embedding: doc.Embedding,
+	}, nil
+}
+
+func (d ExportDocument) Document() (Document, error) {
+	doc := Document{
+		ID:        d.ID,
+		Content:   d.Content,
+		Embedding: d.Embedding,
+	}
+	if len(d.Metadata) > 0 {
+		if err := json.Unmarshal(d.Metadata, &doc.Metadata); err != nil {
+			return Document{}, fmt.Errorf("failed to unmarshal metadata of document %s: %w", d.ID, err)
+		}
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]any{}
+	}
+	return doc, nil
+}
+
+// Validate checks that all documents of a collection have embeddings with the declared number of dimensions
+func (c ExportCollection) Validate() error {
+	for _, doc := range c.Documents {
+		if len(doc.Embedding) != c.Dimensions {
+			return fmt.Errorf("document %s in collection %s has %d embedding dimensions, expected %d", doc.ID, c.Name, len(doc.Embedding), c.Dimensions)
+		}
+	}
+	return nil
+}
+
+func WriteExportFile(path string, export *Export) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	if err := gob.NewEncoder(f).Encode(export); err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	return f.Close()
+}
+
+func ReadExportFile(path string) (*Export, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	defer f.Close()
+
+	var export Export
+	if err := gob.NewDecoder(f).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to decode export file %s: %w", path, err)
+	}
+	if export.Version != ExportFormatVersion {
+		return nil, fmt.Errorf("unsupported export format version %d (expected %d)", export.Version, ExportFormatVersion)
+	}
+	return &export, nil
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFileRoundtrip(t *testing.T) {
+	doc := Document{ID: "a", Content: "hello", Metadata: map[string]any{"page": float64(1), "tags": []any{"x"}, "empty": nil}, Embedding: []float32{0.1, 0.2}}
+	d, err := NewExportDocument(doc)
+	require.NoError(t, err)
+
+	c := ExportCollection{Name: "ds", Dimensions: 2, Documents: []ExportDocument{d}}
+	require.NoError(t, c.Validate())
+
+	path := filepath.Join(t.TempDir(), ExportFileName)
+	require.NoError(t, WriteExportFile(path, &Export{Version: ExportFormatVersion, Collections: []ExportCollection{c}}))
+
+	export, err := ReadExportFile(path)
+	require.NoError(t, err)
+	require.Len(t, export.Collections, 1)
+	got, err := export.Collections[0].Documents[0].Document()
+	require.NoError(t, err)
+	assert.Equal(t, doc, got)
+
+	c.Dimensions = 3
+	assert.Error(t, c.Validate())
+}
@@ -0,0 +1,400 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type FilterOperator string
+
+const (
+	FilterOperatorAnd      FilterOperator = "$and"
+	FilterOperatorOr       FilterOperator = "$or"
+	FilterOperatorEq       FilterOperator = "$eq"
+	FilterOperatorNe       FilterOperator = "$ne"
+	FilterOperatorGt       FilterOperator = "$gt"
+	FilterOperatorGte      FilterOperator = "$gte"
+	FilterOperatorLt       FilterOperator = "$lt"
+	FilterOperatorLte      FilterOperator = "$lte"
+	FilterOperatorIn       FilterOperator = "$in"
+	FilterOperatorNin      FilterOperator = "$nin"
+	FilterOperatorContains FilterOperator = "$contains"
+	FilterOperatorExists   FilterOperator = "$exists"
+)
+
+// MetadataFilter is a metadata filter with operators, parsed from a MongoDB-like syntax, e.g.
+//
+//	{"year": {"$gte": 2020}, "$or": [{"tags": {"$contains": "kubernetes"}}, {"draft": {"$exists": false}}]}
+//
+// All conditions of an object must match (AND). A plain value is short for $eq, a list of values for $in.
+// Values are typed: numbers only match numbers, strings only match strings (compared bytewise) and booleans only match booleans.
+// If the metadata value is a list (e.g. tags), $eq, $ne, $in and $nin apply to its elements: it equals a value if it contains it.
+// $contains matches substrings of string values and elements of lists, $exists matches keys that are set (even to null).
+type MetadataFilter struct {
+	Operator FilterOperator
+	Filters  []MetadataFilter // operands of $and and $or
+	Key      string           // metadata key of conditions
+	Value    any              // string, float64 or bool - a list of those for $in and $nin
+}
+
+// ParseMetadataFilter parses a metadata filter from JSON (see MetadataFilter)
+func ParseMetadataFilter(data []byte) (*MetadataFilter, error) {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid metadata filter, expected a JSON object: %w", err)
+	}
+	return MetadataFilterFromMap(m)
+}
+
+// MetadataFilterFromMap parses a metadata filter from its decoded JSON (or YAML) form (see MetadataFilter)
+func MetadataFilterFromMap(m map[string]any) (*MetadataFilter, error) {
+	f, err := parseFilterObject(m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata filter: %w", err)
+	}
+	return &f, nil
+}
+
+func parseFilterObject(m map[string]any) (MetadataFilter, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys) // stable order, e.g. for the generated queries
+
+	filters := make([]MetadataFilter, 0, len(keys))
+	for _, k := range keys {
+		switch op := FilterOperator(k); {
+		case op == FilterOperatorAnd || op == FilterOperatorOr:
+			operands, ok := m[k].([]any)
+			if !ok {
+				return MetadataFilter{}, fmt.Errorf("%s expects a list of filters", op)
+			}
+			f := MetadataFilter{Operator: op, Filters: make([]MetadataFilter, 0, len(operands))}
+			for _, operand := range operands {
+				om, ok := operand.(map[string]any)
+				if !ok {
+					return MetadataFilter{}, fmt.Errorf("%s expects a list of filters", op)
+				}
+				of, err := parseFilterObject(om)
+				if err != nil {
+					return MetadataFilter{}, err
+				}
+				f.Filters = append(f.Filters, of)
+			}
+			filters = append(filters, f)
+		case strings.HasPrefix(k, "$"):
+			return MetadataFilter{}, fmt.Errorf("unsupported operator %q at the top level of a filter", k)
+		default:
+			conditions, err := parseConditions(k, m[k])
+			if err != nil {
+				return MetadataFilter{}, err
+			}
+			filters = append(filters, conditions...)
+		}
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return MetadataFilter{Operator: FilterOperatorAnd, Filters: filters}, nil
+}
+
+func parseConditions(key string, value any) ([]MetadataFilter, error) {
+	if key == "" || strings.Contains(key, `"`) {
+		return nil, fmt.Errorf("invalid metadata key %q", key)
+	}
+
+	ops, ok := value.(map[string]any)
+	if !ok {
+		// shorthand for $eq, or $in for a list of values
+		op := FilterOperatorEq
+		if _, isList := listValues(value); isList {
+			op = FilterOperatorIn
+		}
+		ops = map[string]any{string(op): value}
+	}
+
+	opNames := make([]string, 0, len(ops))
+	for op := range ops {
+		opNames = append(opNames, op)
+	}
+	slices.Sort(opNames)
+
+	conditions := make([]MetadataFilter, 0, len(ops))
+	for _, name := range opNames {
+		op := FilterOperator(name)
+		v := normalizeValue(ops[name])
+		switch op {
+		case FilterOperatorEq, FilterOperatorNe:
+			if !isScalar(v) {
+				return nil, fmt.Errorf("%s on %q expects a string, number or boolean", op, key)
+			}
+		case FilterOperatorGt, FilterOperatorGte, FilterOperatorLt, FilterOperatorLte:
+			switch v.(type) {
+			case string, float64:
+			default:
+				return nil, fmt.Errorf("%s on %q expects a string or number", op, key)
+			}
+		case FilterOperatorIn, FilterOperatorNin:
+			values, ok := v.([]any)
+			if !ok || slices.ContainsFunc(values, func(e any) bool { return !isScalar(e) }) {
+				return nil, fmt.Errorf("%s on %q expects a list of strings, numbers or booleans", op, key)
+			}
+		case FilterOperatorContains:
+			if !isScalar(v) {
+				return nil, fmt.Errorf("%s on %q expects a string, number or boolean", op, key)
+			}
+		case FilterOperatorExists:
+			if _, ok := v.(bool); !ok {
+				return nil, fmt.Errorf("%s on %q expects a boolean", op, key)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported operator %q on %q", name, key)
+		}
+		conditions = append(conditions, MetadataFilter{Operator: op, Key: key, Value: v})
+	}
+	return conditions, nil
+}
+
+// normalizeValue converts numbers to float64 and lists to []any, as decoded from JSON - e.g. YAML decodes integers as int
+func normalizeValue(v any) any {
+	if _, isString := v.(string); isString {
+		return v
+	}
+	if f, ok := toFloat(v); ok {
+		return f
+	}
+	if elems, isList := listValues(v); isList {
+		values := make([]any, 0, len(elems))
+		for _, e := range elems {
+			values = append(values, normalizeValue(e))
+		}
+		return values
+	}
+	return v
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, float64, bool:
+		return true
+	}
+	return false
+}
+
+// Keys returns the metadata keys the filter (incl. its operands) has conditions on
+func (f *MetadataFilter) Keys() []string {
+	if f == nil {
+		return nil
+	}
+	if f.Operator != FilterOperatorAnd && f.Operator != FilterOperatorOr {
+		return []string{f.Key}
+	}
+	var keys []string
+	for i := range f.Filters {
+		for _, k := range f.Filters[i].Keys() {
+			if !slices.Contains(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// Matches reports whether the metadata matches the filter
+func (f *MetadataFilter) Matches(metadata map[string]any) bool {
+	if f == nil {
+		return true
+	}
+
+	switch f.Operator {
+	case FilterOperatorAnd:
+		for i := range f.Filters {
+			if !f.Filters[i].Matches(metadata) {
+				return false
+			}
+		}
+		return true
+	case FilterOperatorOr:
+		for i := range f.Filters {
+			if f.Filters[i].Matches(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+
+	have, ok := metadata[f.Key]
+	switch f.Operator {
+	case FilterOperatorExists:
+		return ok == f.Value.(bool)
+	case FilterOperatorEq:
+		return containsValue(have, f.Value)
+	case FilterOperatorNe:
+		return !containsValue(have, f.Value)
+	case FilterOperatorIn:
+		return slices.ContainsFunc(f.Value.([]any), func(v any) bool { return containsValue(have, v) })
+	case FilterOperatorNin:
+		return !slices.ContainsFunc(f.Value.([]any), func(v any) bool { return containsValue(have, v) })
+	case FilterOperatorContains:
+		if s, ok := have.(string); ok {
+			want, ok := f.Value.(string)
+			return ok && strings.Contains(s, want)
+		}
+		elems, isList := listValues(have)
+		return isList && slices.ContainsFunc(elems, func(e any) bool { return valuesEqual(e, f.Value) })
+	case FilterOperatorGt, FilterOperatorGte, FilterOperatorLt, FilterOperatorLte:
+		c, ok := compareValues(have, f.Value)
+		if !ok {
+			return false
+		}
+		switch f.Operator {
+		case FilterOperatorGt:
+			return c > 0
+		case FilterOperatorGte:
+			return c >= 0
+		case FilterOperatorLt:
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}
+	return false
+}
+
+// containsValue reports whether the metadata value equals the filter value or, if it's a list, contains it
+func containsValue(have, want any) bool {
+	if elems, isList := listValues(have); isList {
+		return slices.ContainsFunc(elems, func(e any) bool { return valuesEqual(e, want) })
+	}
+	return valuesEqual(have, want)
+}
+
+func listValues(v any) ([]any, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if l, ok := v.([]any); ok {
+		return l, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	values := make([]any, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		values = append(values, rv.Index(i).Interface())
+	}
+	return values, true
+}
+
+func valuesEqual(have, want any) bool {
+	c, ok := compareValues(have, want)
+	if ok {
+		return c == 0
+	}
+	hb, ok := have.(bool)
+	wb, wok := want.(bool)
+	return ok && wok && hb == wb
+}
+
+// compareValues compares numbers numerically and strings bytewise - other values and mixed types aren't comparable
+func compareValues(have, want any) (int, bool) {
+	if hs, ok := have.(string); ok {
+		ws, ok := want.(string)
+		return strings.Compare(hs, ws), ok
+	}
+	hf, ok := toFloat(have)
+	if !ok {
+		return 0, false
+	}
+	wf, ok := toFloat(want)
+	if !ok {
+		return 0, false
+	}
+	switch {
+	case hf < wf:
+		return -1, true
+	case hf > wf:
+		return 1, true
+	}
+	return 0, true
+}
+
+func toFloat(v any) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	}
+	return 0, false
+}
+
+// WhereValues returns the values matched by a value of the (string-typed) where filter: the string itself and, if it's
+// a number or boolean literal, the number (float64) or boolean - e.g. {"page": "3"} matches the page number 3,
+// like with the containment filter of the pgvector store.
+func WhereValues(value string) []any {
+	values := []any{value}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+		values = append(values, f)
+	}
+	if b, err := strconv.ParseBool(value); err == nil && strconv.FormatBool(b) == value {
+		values = append(values, b)
+	}
+	return values
+}
+
+// MetadataStrings returns the string forms of a metadata value, one per element if it's a list, for vector stores
+// that can only filter on strings. Numbers are formatted without exponent, so that e.g. 3 and 3.0 are the same.
+func MetadataStrings(v any) []string {
+	if elems, isList := listValues(v); isList {
+		strs := make([]string, 0, len(elems))
+		for _, e := range elems {
+			strs = append(strs, MetadataStrings(e)...)
+		}
+		return strs
+	}
+
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case bool:
+		return []string{strconv.FormatBool(val)}
+	}
+	if f, ok := toFloat(v); ok {
+		return []string{strconv.FormatFloat(f, 'f', -1, 64)}
+	}
+	if b, err := json.Marshal(v); err == nil {
+		return []string{string(b)}
+	}
+	return []string{fmt.Sprint(v)}
+}
+
+// WhereStrings returns the string forms (see MetadataStrings) of the values matched by a value of the where filter (see WhereValues)
+func WhereStrings(value string) []string {
+	var strs []string
+	for _, v := range WhereValues(value) {
+		for _, s := range MetadataStrings(v) {
+			if !slices.Contains(strs, s) {
+				strs = append(strs, s)
+			}
+		}
+	}
+	return strs
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	doc := map[string]any{
+		"year":   2021,
+		"title":  "Kubernetes Operators",
+		"tags":   []any{"k8s", "operators"},
+		"draft":  false,
+		"rating": 4.5,
+		"parent": nil,
+	}
+
+	tests := []struct {
+		filter string
+		match  bool
+	}{
+		{`{"year": 2021}`, true},
+		{`{"year": "2021"}`, false}, // typed
+		{`{"year": {"$gt": 2020, "$lte": 2021}}`, true},
+		{`{"year": {"$lt": 2021}}`, false},
+		{`{"title": {"$gte": "K"}}`, true},
+		{`{"title": {"$gt": 2020}}`, false},
+		{`{"tags": "k8s"}`, true},
+		{`{"tags": {"$ne": "k8s"}}`, false},
+		{`{"tags": ["docker", "operators"]}`, true},
+		{`{"tags": {"$nin": ["docker", "helm"]}}`, true},
+		{`{"tags": {"$contains": "operators"}}`, true},
+		{`{"title": {"$contains": "Operator"}}`, true},
+		{`{"title": {"$contains": "operator"}}`, false},
+		{`{"draft": false}`, true},
+		{`{"draft": 0}`, false},
+		{`{"parent": {"$exists": true}, "author": {"$exists": false}}`, true},
+		{`{"author": {"$ne": "jane"}}`, true},
+		{`{"$or": [{"rating": {"$gte": 5}}, {"tags": "operators"}]}`, true},
+		{`{"$or": [{"rating": {"$gte": 5}}, {"$and": [{"year": 2021}, {"draft": true}]}]}`, false},
+		{`{"$and": []}`, true},
+		{`{"$or": []}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			f, err := ParseMetadataFilter([]byte(tt.filter))
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, f.Matches(doc))
+		})
+	}
+
+	for _, invalid := range []string{
+		`[]`,
+		`{"$not": {"year": 2021}}`,
+		`{"year": {"$regex": "20.*"}}`,
+		`{"year": {"$gt": true}}`,
+		`{"tags": {"$in": "k8s"}}`,
+		`{"draft": {"$exists": "no"}}`,
+		`{"$or": {"year": 2021}}`,
+		`{"a\"b": 1}`,
+	} {
+		_, err := ParseMetadataFilter([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMetadataFilterFromMap(t *testing.T) {
+	// e.g. decoded from YAML, with integers and typed lists
+	f, err := MetadataFilterFromMap(map[string]any{"year": map[string]any{"$in": []int{2020, 2021}}})
+	require.NoError(t, err)
+	assert.Equal(t, &MetadataFilter{Operator: FilterOperatorIn, Key: "year", Value: []any{2020.0, 2021.0}}, f)
+	assert.True(t, f.Matches(map[string]any{"year": 2021}))
+
+	f, err = ParseMetadataFilter([]byte(`{"$or": [{"year": 2021}, {"tags": "k8s", "year": {"$gt": 2020}}]}`))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"year", "tags"}, f.Keys())
+}
+
+func TestWhereValues(t *testing.T) {
+	assert.Equal(t, []any{"foo"}, WhereValues("foo"))
+	assert.Equal(t, []any{"3", float64(3)}, WhereValues("3"))
+	assert.Equal(t, []any{"true", true}, WhereValues("true"))
+	assert.Equal(t, []any{"NaN"}, WhereValues("NaN"))
+	assert.Equal(t, []any{"True"}, WhereValues("True"))
+
+	assert.Equal(t, []string{"a", "3", "true"}, MetadataStrings([]any{"a", 3, true}))
+	assert.Equal(t, []string{"1000000"}, MetadataStrings(1e6))
+	assert.Equal(t, []string{"3.0", "3"}, WhereStrings("3.0"))
+	assert.Equal(t, []string{"3"}, WhereStrings("3"))
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// SearchOptions are optional similarity search settings, passed via context so that the VectorStore interface stays the same for all backends.
+// Backends ignore options they don't support.
+type SearchOptions struct {
+	// Hybrid enables server-side hybrid search (vector similarity + full-text), e.g. for OpenSearch
+	Hybrid bool
+
+	// EfSearch sets the size of the dynamic candidate list for HNSW searches (higher = better recall, but slower), e.g. hnsw.ef_search for pgvector.
+	// 0 keeps the vector store's setting.
+	EfSearch int
+
+	// IncludeEmbeddings requests the embeddings of the returned documents (e.g. for MMR), if the backend supports it
+	IncludeEmbeddings bool
+
+	// Filter is a metadata filter with operators, translated to a native query by backends that support it (pgvector, sqlite-vec),
+	// so that the top k results are the most similar matching documents. Other backends ignore it and the results are filtered afterwards.
+	Filter *MetadataFilter
+
+	// Offset skips the most similar results, to page through the results beyond the top k: the numDocuments results after the offset are returned
+	Offset int
+}
+
+type searchOptionsCtxKey struct{}
+
+// SearchOptionsToCtx adds the search options to the context, so that they're picked up by the vector store on SimilaritySearch
+func SearchOptionsToCtx(ctx context.Context, opts SearchOptions) context.Context {
+	return context.WithValue(ctx, searchOptionsCtxKey{}, opts)
+}
+
+// SearchOptionsFromCtx returns the search options from the context, if set
+func SearchOptionsFromCtx(ctx context.Context) SearchOptions {
+	if opts, ok := ctx.Value(searchOptionsCtxKey{}).(SearchOptions); ok {
+		return opts
+	}
+	return SearchOptions{}
+}
+
+// ListOptions select a page of the documents returned by GetDocuments, passed via context like the SearchOptions.
+// The documents are ordered by ID, so that the pages are stable.
+type ListOptions struct {
+	Limit  int // maximum number of documents, 0 for all
+	Offset int // number of documents to skip
+}
+
+// IsSet reports whether the options select a page at all
+func (o ListOptions) IsSet() bool {
+	return o.Limit > 0 || o.Offset > 0
+}
+
+// Apply cuts the page from all documents, for vector stores that don't support paging natively
+func (o ListOptions) Apply(docs []Document) []Document {
+	if !o.IsSet() {
+		return docs
+	}
+	slices.SortFunc(docs, func(a, b Document) int { return strings.Compare(a.ID, b.ID) })
+	docs = docs[min(max(o.Offset, 0), len(docs)):]
+	if o.Limit > 0 && o.Limit < len(docs) {
+		docs = docs[:o.Limit]
+	}
+	return docs
+}
+
+type listOptionsCtxKey struct{}
+
+// ListOptionsToCtx adds the list options to the context, so that they're picked up by the vector store on GetDocuments
+func ListOptionsToCtx(ctx context.Context, opts ListOptions) context.Context {
+	return context.WithValue(ctx, listOptionsCtxKey{}, opts)
+}
+
+// ListOptionsFromCtx returns the list options from the context, if set
+func ListOptionsFromCtx(ctx context.Context) ListOptions {
+	if opts, ok := ctx.Value(listOptionsCtxKey{}).(ListOptions); ok {
+		return opts
+	}
+	return ListOptions{}
+}
@@ -0,0 +1,9 @@
+package types
+
+// CollectionStats holds statistics about a collection in the vector store
+type CollectionStats struct {
+	Name       string `json:"name"`
+	Documents  int    `json:"documents"`
+	SizeBytes  int64  `json:"sizeBytes"`            // (approximate) storage size of the documents, including content, metadata and embeddings, excluding indexes
+	Dimensions []int  `json:"dimensions,omitempty"` // embedding dimensions - usually a single value, unless the collection was ingested with different embedding models
+}
@@ -21,6 +21,39 @@ type Document struct {
 	Embedding       []float32      `json:"embedding,omitempty"`
 }
 
+// MetadataPatch is a partial update of a document's (top-level) metadata: keys are set to the given values, keys with a nil value are removed.
+type MetadataPatch map[string]any
+
+// Apply applies the patch to the given metadata in-place and returns it (a new map, if metadata is nil)
+func (p MetadataPatch) Apply(metadata map[string]any) map[string]any {
+	if metadata == nil {
+		metadata = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(metadata, k)
+			continue
+		}
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// Split returns the keys to set (with their values) and the keys to remove
+func (p MetadataPatch) Split() (map[string]any, []string) {
+	set := make(map[string]any, len(p))
+	remove := make([]string, 0)
+	for k, v := range p {
+		if v == nil {
+			remove = append(remove, k)
+			continue
+		}
+		set[k] = v
+	}
+	slices.Sort(remove)
+	return set, remove
+}
+
 const (
 	DocMetadataKeyDocIndex  = "docIndex"
 	DocMetadataKeyDocsTotal = "docsTotal"
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataPatch(t *testing.T) {
+	patch := MetadataPatch{"tags": []string{"a", "b"}, "acl": "team-x", "draft": nil, "obsolete": nil}
+
+	set, remove := patch.Split()
+	assert.Equal(t, map[string]any{"tags": []string{"a", "b"}, "acl": "team-x"}, set)
+	assert.Equal(t, []string{"draft", "obsolete"}, remove)
+
+	metadata := patch.Apply(map[string]any{"source": "a.pdf", "acl": "public", "draft": true})
+	assert.Equal(t, map[string]any{"source": "a.pdf", "acl": "team-x", "tags": []string{"a", "b"}}, metadata)
+
+	assert.Equal(t, map[string]any{"acl": "team-x", "tags": []string{"a", "b"}}, patch.Apply(nil))
+}
+
+func TestListOptions(t *testing.T) {
+	docs := func() []Document {
+		return []Document{{ID: "c"}, {ID: "a"}, {ID: "d"}, {ID: "b"}}
+	}
+	ids := func(docs []Document) []string {
+		var ids []string
+		for _, d := range docs {
+			ids = append(ids, d.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"c", "a", "d", "b"}, ids(ListOptions{}.Apply(docs())))
+	assert.Equal(t, []string{"a", "b"}, ids(ListOptions{Limit: 2}.Apply(docs())))
+	assert.Equal(t, []string{"c", "d"}, ids(ListOptions{Limit: 2, Offset: 2}.Apply(docs())))
+	assert.Equal(t, []string{"d"}, ids(ListOptions{Offset: 3}.Apply(docs())))
+	assert.Empty(t, ListOptions{Limit: 2, Offset: 5}.Apply(docs()))
+}
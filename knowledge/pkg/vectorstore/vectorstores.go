@@ -6,11 +6,17 @@ import (
 	"log/slog"
 	"strings"
 
+	"github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/preprocessing"
 	etypes "github.com/obot-platform/tools/knowledge/pkg/datastore/embeddings/types"
 	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/milvus"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/opensearch"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/pgvector"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/qdrant"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/redis"
 	sqlitevec "github.com/obot-platform/tools/knowledge/pkg/vectorstore/sqlite-vec"
 	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/weaviate"
 )
 
 type VectorStore interface {
@@ -21,6 +27,9 @@ type VectorStore interface {
 	RemoveDocument(ctx context.Context, documentID string, collection string, where map[string]string, whereDocument []types.WhereDocument) error
 	GetDocuments(ctx context.Context, collection string, where map[string]string, whereDocument []types.WhereDocument) ([]types.Document, error)
 	GetDocument(ctx context.Context, documentID string, collection string) (types.Document, error)
+	UpdateDocumentMetadata(ctx context.Context, documentID string, collection string, patch types.MetadataPatch) error // update metadata without re-embedding
+
+	Stats(ctx context.Context, collections ...string) ([]types.CollectionStats, error) // all collections if none are specified
 
 	ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error
 	ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error
@@ -28,12 +37,54 @@ type VectorStore interface {
 	Close() error
 }
 
+// IndexRebuilder is implemented by vector stores with a configurable vector index that can be (re)built on existing data, e.g. pgvector
+type IndexRebuilder interface {
+	RebuildIndexes(ctx context.Context) error
+}
+
+// EmbeddingsSwapper is implemented by vector stores that can replace the embeddings of all documents in a collection atomically,
+// e.g. to migrate a collection to another embedding model: the new embeddings (by document ID) are written to a shadow table,
+// which replaces the collection's embeddings in a single transaction. The embeddings may have different dimensions than before.
+type EmbeddingsSwapper interface {
+	SwapEmbeddings(ctx context.Context, collection string, embeddings map[string][]float32) error
+}
+
+// Pager is implemented by vector stores that apply the offset of similarity searches (types.SearchOptions)
+// and the pages of GetDocuments (types.ListOptions) in their queries. For other vector stores, the pages are cut from the full results.
+type Pager interface {
+	SupportsPaging() bool
+}
+
+// Wrapper is implemented by vector stores wrapping another one, e.g. to encrypt the documents (see encrypted.VectorStore)
+type Wrapper interface {
+	Unwrap() VectorStore
+}
+
+// As returns the first vector store in the chain of wrapped vector stores that implements T, e.g. one of the capability interfaces above
+func As[T any](store VectorStore) (T, bool) {
+	for store != nil {
+		if t, ok := store.(T); ok {
+			return t, true
+		}
+		w, ok := store.(Wrapper)
+		if !ok {
+			break
+		}
+		store = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
 func New(ctx context.Context, dsn string, embeddingProvider etypes.EmbeddingModelProvider) (VectorStore, error) {
 	embeddingFunc, err := embeddingProvider.EmbeddingFunc()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding function: %w", err)
 	}
 
+	// Apply embedding input preprocessing configured for the current flow (via context)
+	embeddingFunc = preprocessing.WrapEmbeddingFunc(embeddingFunc)
+
 	dialect := strings.Split(dsn, "://")[0]
 
 	slog.Debug("vectordb", "dialect", dialect, "dsn", dsn)
@@ -43,6 +94,16 @@ func New(ctx context.Context, dsn string, embeddingProvider etypes.EmbeddingMode
 		return pgvector.New(ctx, dsn, embeddingFunc)
 	case "sqlite-vec":
 		return sqlitevec.New(ctx, dsn, embeddingFunc)
+	case "qdrant":
+		return qdrant.New(ctx, dsn, embeddingFunc)
+	case "weaviate":
+		return weaviate.New(ctx, dsn, embeddingFunc)
+	case "milvus":
+		return milvus.New(ctx, dsn, embeddingFunc)
+	case "opensearch":
+		return opensearch.New(ctx, dsn, embeddingFunc)
+	case "redis", "rediss":
+		return redis.New(ctx, dsn, embeddingFunc)
 	default:
 		return nil, fmt.Errorf("unsupported dialect: %q", dialect)
 	}
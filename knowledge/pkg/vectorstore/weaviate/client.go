@@ -0,0 +1,114 @@
+package weaviate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// client is a minimal client for the Weaviate REST and GraphQL API (https://weaviate.io/developers/weaviate/api)
+type client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type apiError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("weaviate API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	if e, ok := err.(*apiError); ok {
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// do sends a request to the Weaviate API and decodes the response into result (if not nil)
+func (c *client) do(ctx context.Context, method, path string, body any, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to weaviate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read weaviate response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		var errResp struct {
+			Error []struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		msg := strings.TrimSpace(string(respBody))
+		if err := json.Unmarshal(respBody, &errResp); err == nil && len(errResp.Error) > 0 {
+			msgs := make([]string, len(errResp.Error))
+			for i, e := range errResp.Error {
+				msgs[i] = e.Message
+			}
+			msg = strings.Join(msgs, "; ")
+		}
+		return &apiError{StatusCode: resp.StatusCode, Message: msg}
+	}
+
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("failed to decode weaviate response: %w", err)
+		}
+	}
+	return nil
+}
+
+// graphql runs a GraphQL query and decodes the "data" field of the response into result
+func (c *client) graphql(ctx context.Context, query string, result any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/graphql", map[string]any{"query": query}, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return fmt.Errorf("weaviate GraphQL error: %s", strings.Join(msgs, "; "))
+	}
+	if err := json.Unmarshal(resp.Data, result); err != nil {
+		return fmt.Errorf("failed to decode weaviate GraphQL result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package weaviate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+)
+
+const (
+	propContent      = "content"
+	propDocumentID   = "documentId"
+	propMetadataJSON = "metadataJson"
+
+	// metadataPropPrefix is the prefix for the flattened metadata properties used for filtering
+	metadataPropPrefix = "meta_"
+)
+
+var invalidNameCharsRegex = regexp.MustCompile(`[^_0-9A-Za-z]`)
+
+// metadataProperty returns the name of the (flattened) property for the given metadata key
+func metadataProperty(key string) string {
+	return metadataPropPrefix + invalidNameCharsRegex.ReplaceAllString(key, "_")
+}
+
+// where is a Weaviate where filter in its JSON form, see https://weaviate.io/developers/weaviate/api/graphql/filters
+type where map[string]any
+
+func equal(property, value string) where {
+	return where{"operator": "Equal", "path": []string{property}, "valueText": value}
+}
+
+func combine(operator string, operands []where) where {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	return where{"operator": operator, "operands": operands}
+}
+
+// buildWhere translates the metadata (where) and content (whereDocument) filters into a Weaviate where filter.
+// Content filters that cannot be expressed in Weaviate (e.g. $not_contains) are returned separately, so they can be applied in memory.
+// Metadata values are matched by their string forms, as that's how they're stored in the flattened metadata properties,
+// so they match numbers and booleans with the same literal as well (see vs.WhereStrings).
+func buildWhere(whereMetadata map[string]string, whereDocument []vs.WhereDocument) (where, []vs.WhereDocument) {
+	keys := make([]string, 0, len(whereMetadata))
+	for k := range whereMetadata {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var operands []where
+	for _, k := range keys {
+		var alternatives []where
+		for _, value := range vs.WhereStrings(whereMetadata[k]) {
+			alternatives = append(alternatives, equal(metadataProperty(k), value))
+		}
+		operands = append(operands, combine("Or", alternatives))
+	}
+
+	var remaining []vs.WhereDocument
+	for _, wd := range whereDocument {
+		if w, ok := translateWhereDocument(wd); ok {
+			operands = append(operands, w)
+		} else {
+			remaining = append(remaining, wd)
+		}
+	}
+
+	if len(operands) == 0 {
+		return nil, remaining
+	}
+	return combine("And", operands), remaining
+}
+
+func translateWhereDocument(wd vs.WhereDocument) (where, bool) {
+	switch wd.Operator {
+	case vs.WhereDocumentOperatorEquals:
+		return equal(propContent, wd.Value), true
+	case vs.WhereDocumentOperatorContains:
+		if strings.ContainsAny(wd.Value, "*?") {
+			return nil, false // wildcards cannot be escaped in Like filters
+		}
+		return where{"operator": "Like", "path": []string{propContent}, "valueText": "*" + wd.Value + "*"}, true
+	case vs.WhereDocumentOperatorOr, vs.WhereDocumentOperatorAnd:
+		operands := make([]where, 0, len(wd.WhereDocuments))
+		for _, sub := range wd.WhereDocuments {
+			w, ok := translateWhereDocument(sub)
+			if !ok {
+				return nil, false
+			}
+			operands = append(operands, w)
+		}
+		if wd.Operator == vs.WhereDocumentOperatorOr {
+			return combine("Or", operands), true
+		}
+		return combine("And", operands), true
+	default:
+		return nil, false
+	}
+}
+
+// matchesAll checks whether the document matches all given content filters (used for filters not supported by Weaviate)
+func matchesAll(doc *vs.Document, whereDocument []vs.WhereDocument) bool {
+	for _, wd := range whereDocument {
+		if !wd.Matches(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// graphQLValue renders a value as GraphQL input value. Operators are rendered as enums.
+func graphQLValue(v any) string {
+	switch val := v.(type) {
+	case where:
+		return graphQLValue(map[string]any(val))
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		fields := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if s, ok := val[k].(string); ok && k == "operator" {
+				fields = append(fields, fmt.Sprintf("%s: %s", k, s))
+				continue
+			}
+			fields = append(fields, fmt.Sprintf("%s: %s", k, graphQLValue(val[k])))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []where:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = graphQLValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []string:
+		items := make([]string, len(val))
+		for i, item := range val {
+			items[i] = graphQLValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		// JSON encoding is valid GraphQL for strings, numbers, booleans and float arrays
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
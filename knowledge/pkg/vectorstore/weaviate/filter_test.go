@@ -0,0 +1,29 @@
+package weaviate
+
+import (
+	"testing"
+
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildWhere(t *testing.T) {
+	w, remaining := buildWhere(map[string]string{"absPath": "/data/a.pdf"}, []vs.WhereDocument{
+		{Operator: vs.WhereDocumentOperatorContains, Value: "foo"},
+		{Operator: vs.WhereDocumentOperatorNotContains, Value: "bar"},
+	})
+
+	assert.Equal(t, []vs.WhereDocument{{Operator: vs.WhereDocumentOperatorNotContains, Value: "bar"}}, remaining)
+	assert.Equal(t,
+		`{operands: [{operator: Equal, path: ["meta_absPath"], valueText: "/data/a.pdf"}, {operator: Like, path: ["content"], valueText: "*foo*"}], operator: And}`,
+		graphQLValue(w))
+
+	w, _ = buildWhere(map[string]string{"page": "3.0"}, nil)
+	assert.Equal(t,
+		`{operands: [{operator: Equal, path: ["meta_page"], valueText: "3.0"}, {operator: Equal, path: ["meta_page"], valueText: "3"}], operator: Or}`,
+		graphQLValue(w))
+
+	w, remaining = buildWhere(nil, nil)
+	assert.Nil(t, w)
+	assert.Empty(t, remaining)
+}
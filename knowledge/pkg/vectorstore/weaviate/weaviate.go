@@ -0,0 +1,680 @@
+package weaviate
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/obot-platform/tools/knowledge/pkg/env"
+	dbtypes "github.com/obot-platform/tools/knowledge/pkg/index/types"
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	"github.com/obot-platform/tools/knowledge/pkg/vectorstore/helper"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// VsWeaviateEmbeddingConcurrency can be set as an environment variable to control the number of parallel API calls to create embedding for documents. Default is 100
+	VsWeaviateEmbeddingConcurrency = "VS_WEAVIATE_EMBEDDING_CONCURRENCY"
+
+	// VsWeaviateAPIKey can be set as an environment variable to provide the API key, if it's not part of the DSN
+	VsWeaviateAPIKey = "VS_WEAVIATE_API_KEY"
+
+	defaultClassPrefix = "Knowledge_"
+
+	batchSize = 100
+	pageSize  = 100
+
+	// searchOverfetchFactor is used to fetch more candidates if content filters have to be applied in memory
+	searchOverfetchFactor = 4
+)
+
+type VectorStore struct {
+	embeddingFunc        vs.EmbeddingFunc
+	embeddingConcurrency int
+	client               *client
+	classPrefix          string
+
+	// propertiesLock guards the schema updates for the flattened metadata properties
+	propertiesLock sync.Mutex
+}
+
+// New creates a new Weaviate vector store from a DSN of the form
+//
+//	weaviate://[apikey@]host[:port][?tls=true&prefix=Knowledge_]
+//
+// Each collection (dataset) is mapped to its own class, named <prefix><collection>.
+func New(ctx context.Context, dsn string, embeddingFunc vs.EmbeddingFunc) (*VectorStore, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse weaviate DSN: %w", err)
+	}
+
+	q := u.Query()
+
+	scheme := "http"
+	if q.Get("tls") == "true" {
+		scheme = "https"
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host += ":8080"
+	}
+
+	apiKey := os.Getenv(VsWeaviateAPIKey)
+	if u.User != nil && u.User.Username() != "" {
+		apiKey = u.User.Username()
+	}
+
+	prefix := q.Get("prefix")
+	if prefix == "" {
+		prefix = defaultClassPrefix
+	}
+	if prefix[0] < 'A' || prefix[0] > 'Z' || invalidNameCharsRegex.MatchString(prefix) {
+		return nil, fmt.Errorf("invalid weaviate class prefix %q: must start with an uppercase letter and only contain alphanumeric characters and underscores", prefix)
+	}
+
+	store := &VectorStore{
+		embeddingFunc:        embeddingFunc,
+		embeddingConcurrency: env.GetIntFromEnvOrDefault(VsWeaviateEmbeddingConcurrency, 100),
+		client: &client{
+			baseURL:    scheme + "://" + host + strings.TrimSuffix(u.Path, "/"),
+			apiKey:     apiKey,
+			httpClient: &http.Client{Timeout: 60 * time.Second},
+		},
+		classPrefix: prefix,
+	}
+
+	slog.Debug("weaviate", "url", store.client.baseURL, "classPrefix", store.classPrefix)
+
+	// Check connectivity
+	if err := store.client.do(ctx, http.MethodGet, "/v1/meta", nil, nil); err != nil {
+		return nil, fmt.Errorf("failed to connect to weaviate: %w", err)
+	}
+
+	return store, nil
+}
+
+func (v *VectorStore) Close() error {
+	v.client.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// className maps a collection name to a valid Weaviate class name.
+// If the collection name contains invalid characters, a short hash is appended to avoid collisions.
+func (v *VectorStore) className(collection string) string {
+	sanitized := invalidNameCharsRegex.ReplaceAllString(collection, "_")
+	if sanitized != collection {
+		h := sha1.Sum([]byte(collection))
+		sanitized += "_" + hex.EncodeToString(h[:4])
+	}
+	return v.classPrefix + sanitized
+}
+
+type class struct {
+	Class      string     `json:"class"`
+	Properties []property `json:"properties,omitempty"`
+}
+
+type property struct {
+	Name         string   `json:"name"`
+	DataType     []string `json:"dataType"`
+	Tokenization string   `json:"tokenization,omitempty"`
+}
+
+func textProperty(name string) property {
+	// field tokenization, so that Equal matches the whole value and Like works on substrings
+	return property{Name: name, DataType: []string{"text"}, Tokenization: "field"}
+}
+
+// metadataTextProperty is a flattened metadata property, holding the string forms of the value (see vs.MetadataStrings) -
+// Equal on an array property matches any of its elements, so list values (e.g. tags) match their elements.
+func metadataTextProperty(name string) property {
+	return property{Name: name, DataType: []string{"text[]"}, Tokenization: "field"}
+}
+
+func (v *VectorStore) getClass(ctx context.Context, collection string) (*class, error) {
+	var c class
+	if err := v.client.do(ctx, http.MethodGet, "/v1/schema/"+v.className(collection), nil, &c); err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (v *VectorStore) CreateCollection(ctx context.Context, collection string, opts *dbtypes.DatasetCreateOpts) error {
+	if opts == nil {
+		opts = &dbtypes.DatasetCreateOpts{}
+	}
+
+	slog.Debug("Creating collection", "collection", collection, "class", v.className(collection), "store", "weaviate")
+
+	existing, err := v.getClass(ctx, collection)
+	if err != nil {
+		return fmt.Errorf("failed to check if collection %s exists: %w", collection, err)
+	}
+	if existing != nil {
+		if opts.ErrOnExists {
+			return fmt.Errorf("collection %s already exists", collection)
+		}
+		slog.Debug("Collection already exists but that's fine", "collection", collection)
+		return nil
+	}
+
+	body := map[string]any{
+		"class":       v.className(collection),
+		"description": "knowledge collection " + collection,
+		"vectorizer":  "none", // we bring our own vectors
+		"vectorIndexConfig": map[string]any{
+			"distance": "cosine",
+		},
+		"properties": []property{
+			textProperty(propContent),
+			textProperty(propDocumentID),
+			{Name: propMetadataJSON, DataType: []string{"text"}},
+		},
+	}
+	if err := v.client.do(ctx, http.MethodPost, "/v1/schema", body, nil); err != nil {
+		return fmt.Errorf("failed to create collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+// ensureMetadataProperties adds the flattened metadata properties for the given keys to the class schema if they're missing.
+// We're not relying on auto-schema here, as we need field tokenization for exact matches.
+func (v *VectorStore) ensureMetadataProperties(ctx context.Context, collection string, keys []string) error {
+	v.propertiesLock.Lock()
+	defer v.propertiesLock.Unlock()
+
+	c, err := v.getClass(ctx, collection)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+
+	existing := make(map[string]struct{}, len(c.Properties))
+	for _, p := range c.Properties {
+		existing[strings.ToLower(p.Name)] = struct{}{}
+	}
+
+	for _, k := range keys {
+		name := metadataProperty(k)
+		if _, ok := existing[strings.ToLower(name)]; ok {
+			continue
+		}
+		if err := v.client.do(ctx, http.MethodPost, "/v1/schema/"+c.Class+"/properties", metadataTextProperty(name), nil); err != nil {
+			return fmt.Errorf("failed to add metadata property %q to class %s: %w", name, c.Class, err)
+		}
+		existing[strings.ToLower(name)] = struct{}{}
+	}
+	return nil
+}
+
+// hasMetadataProperties checks whether the class has properties for all the given metadata keys
+func hasMetadataProperties(c *class, whereMetadata map[string]string) bool {
+	for k := range whereMetadata {
+		name := metadataProperty(k)
+		if !slices.ContainsFunc(c.Properties, func(p property) bool { return strings.EqualFold(p.Name, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectID returns a valid Weaviate object ID (UUID) for the given document ID
+func objectID(documentID string) string {
+	if _, err := uuid.Parse(documentID); err == nil {
+		return documentID
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(documentID)).String()
+}
+
+func documentProperties(doc vs.Document) (map[string]any, error) {
+	metadataJSON, err := json.Marshal(doc.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata of document %s: %w", doc.ID, err)
+	}
+	props := map[string]any{
+		propContent:      doc.Content,
+		propDocumentID:   doc.ID,
+		propMetadataJSON: string(metadataJSON),
+	}
+	for k, val := range doc.Metadata {
+		props[metadataProperty(k)] = vs.MetadataStrings(val)
+	}
+	return props, nil
+}
+
+func documentFromProperties(id string, props map[string]any, vector []float32) vs.Document {
+	doc := vs.Document{
+		ID:        id,
+		Metadata:  map[string]any{},
+		Embedding: vector,
+	}
+	if docID, ok := props[propDocumentID].(string); ok && docID != "" {
+		doc.ID = docID
+	}
+	if content, ok := props[propContent].(string); ok {
+		doc.Content = content
+	}
+	if metadataJSON, ok := props[propMetadataJSON].(string); ok && metadataJSON != "" {
+		if err := json.Unmarshal([]byte(metadataJSON), &doc.Metadata); err != nil {
+			slog.Debug("failed to unmarshal document metadata", "documentID", doc.ID, "error", err)
+		}
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]any{}
+		}
+	}
+	return doc
+}
+
+type batchObject struct {
+	Class      string         `json:"class"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Vector     []float32      `json:"vector"`
+}
+
+func (v *VectorStore) AddDocuments(ctx context.Context, docs []vs.Document, collection string) ([]string, error) {
+	var metadataKeys []string
+	for _, doc := range docs {
+		for k := range doc.Metadata {
+			if !slices.Contains(metadataKeys, k) {
+				metadataKeys = append(metadataKeys, k)
+			}
+		}
+	}
+	if err := v.ensureMetadataProperties(ctx, collection, metadataKeys); err != nil {
+		return nil, err
+	}
+
+	className := v.className(collection)
+	ids := make([]string, len(docs))
+	objects := make([]batchObject, len(docs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(v.embeddingConcurrency)
+
+	for docIdx, doc := range docs {
+		ids[docIdx] = doc.ID
+
+		g.Go(func() error {
+			// Reuse existing embeddings if available
+			vec := doc.Embedding
+			if len(vec) == 0 {
+				var err error
+				vec, err = v.embeddingFunc(gctx, doc.Content)
+				if err != nil {
+					slog.Error("failed to embed document", "documentID", doc.ID, "error", err)
+					return fmt.Errorf("failed to embed document %s: %w", doc.ID, err)
+				}
+			}
+			props, err := documentProperties(doc)
+			if err != nil {
+				return err
+			}
+			objects[docIdx] = batchObject{
+				Class:      className,
+				ID:         objectID(doc.ID),
+				Properties: props,
+				Vector:     vec,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(objects); start += batchSize {
+		batch := objects[start:min(start+batchSize, len(objects))]
+		slog.Debug("Sending batch to weaviate", "store", "weaviate", "class", className, "batchSize", len(batch))
+
+		var results []struct {
+			ID     string `json:"id"`
+			Result struct {
+				Errors *struct {
+					Error []struct {
+						Message string `json:"message"`
+					} `json:"error"`
+				} `json:"errors"`
+			} `json:"result"`
+		}
+		if err := v.client.do(ctx, http.MethodPost, "/v1/batch/objects", map[string]any{"objects": batch}, &results); err != nil {
+			return nil, fmt.Errorf("failed to add documents to weaviate: %w", err)
+		}
+		for _, r := range results {
+			if r.Result.Errors != nil && len(r.Result.Errors.Error) > 0 {
+				return nil, fmt.Errorf("failed to add document %s to weaviate: %s", r.ID, r.Result.Errors.Error[0].Message)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+type graphQLObject struct {
+	Content      string `json:"content"`
+	DocumentID   string `json:"documentId"`
+	MetadataJSON string `json:"metadataJson"`
+	Additional   struct {
+		ID       string    `json:"id"`
+		Distance *float32  `json:"distance"`
+		Vector   []float32 `json:"vector"`
+	} `json:"_additional"`
+}
+
+func (o graphQLObject) toDocument() vs.Document {
+	doc := documentFromProperties(o.Additional.ID, map[string]any{
+		propContent:      o.Content,
+		propDocumentID:   o.DocumentID,
+		propMetadataJSON: o.MetadataJSON,
+	}, o.Additional.Vector)
+	if o.Additional.Distance != nil {
+		// cosine distance -> cosine similarity (consistent with the other vector stores)
+		doc.SimilarityScore = 1 - *o.Additional.Distance
+	}
+	return doc
+}
+
+// get runs a GraphQL Get query on the given class, requesting the given _additional fields
+func (v *VectorStore) get(ctx context.Context, className string, args []string, additional string) ([]graphQLObject, error) {
+	query := fmt.Sprintf("{ Get { %s(%s) { %s %s %s _additional { %s } } } }",
+		className, strings.Join(args, ", "), propContent, propDocumentID, propMetadataJSON, additional)
+
+	var res struct {
+		Get map[string][]graphQLObject `json:"Get"`
+	}
+	if err := v.client.graphql(ctx, query, &res); err != nil {
+		return nil, err
+	}
+	return res.Get[className], nil
+}
+
+func (v *VectorStore) SimilaritySearch(ctx context.Context, query string, numDocuments int, collection string, whereMetadata map[string]string, whereDocument []vs.WhereDocument, embeddingFunc vs.EmbeddingFunc) ([]vs.Document, error) {
+	slog.Debug("Similarity search", "query", query, "numDocuments", numDocuments, "collection", collection, "where", whereMetadata, "whereDocument", whereDocument, "store", "weaviate")
+
+	c, err := v.getClass(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+	}
+	if !hasMetadataProperties(c, whereMetadata) {
+		return []vs.Document{}, nil
+	}
+
+	ef := v.embeddingFunc
+	if embeddingFunc != nil {
+		ef = embeddingFunc
+	}
+
+	queryEmbedding, err := ef(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	w, inMemoryFilters := buildWhere(whereMetadata, whereDocument)
+
+	limit := numDocuments
+	if len(inMemoryFilters) > 0 {
+		limit *= searchOverfetchFactor
+	}
+
+	args := []string{
+		fmt.Sprintf("nearVector: {vector: %s}", graphQLValue(queryEmbedding)),
+		fmt.Sprintf("limit: %d", limit),
+	}
+	if w != nil {
+		args = append(args, "where: "+graphQLValue(w))
+	}
+
+	objects, err := v.get(ctx, c.Class, args, "id distance")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search weaviate: %w", err)
+	}
+
+	docs := make([]vs.Document, 0, len(objects))
+	for _, o := range objects {
+		doc := o.toDocument()
+		if !matchesAll(&doc, inMemoryFilters) {
+			continue
+		}
+		docs = append(docs, doc)
+		if len(docs) >= numDocuments {
+			break
+		}
+	}
+	return docs, nil
+}
+
+func (v *VectorStore) RemoveCollection(ctx context.Context, collection string) error {
+	slog.Debug("Removing collection", "collection", collection, "store", "weaviate")
+	if err := v.client.do(ctx, http.MethodDelete, "/v1/schema/"+v.className(collection), nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove collection %s: %w", collection, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) RemoveDocument(ctx context.Context, documentID string, collection string, whereMetadata map[string]string, whereDocument []vs.WhereDocument) error {
+	slog.Info("Removing document", "documentID", documentID, "collection", collection, "where", whereMetadata, "store", "weaviate")
+
+	className := v.className(collection)
+
+	// Where clause takes precedence over documentID for consistency with the other vector stores
+	if len(whereMetadata) > 0 {
+		c, err := v.getClass(ctx, collection)
+		if err != nil {
+			return err
+		}
+		if c == nil || !hasMetadataProperties(c, whereMetadata) {
+			// filtering on unknown properties is an error in Weaviate - but nothing can match anyway
+			return nil
+		}
+
+		w, inMemoryFilters := buildWhere(whereMetadata, whereDocument)
+		if len(inMemoryFilters) > 0 {
+			// Resolve the matching documents first and delete them one by one
+			docs, err := v.GetDocuments(ctx, collection, whereMetadata, whereDocument)
+			if err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				if err := v.RemoveDocument(ctx, doc.ID, collection, nil, nil); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		body := map[string]any{
+			"match": map[string]any{
+				"class": className,
+				"where": w,
+			},
+			"output": "minimal",
+		}
+		if err := v.client.do(ctx, http.MethodDelete, "/v1/batch/objects", body, nil); err != nil {
+			return fmt.Errorf("failed to remove documents from weaviate: %w", err)
+		}
+		return nil
+	}
+
+	if err := v.client.do(ctx, http.MethodDelete, fmt.Sprintf("/v1/objects/%s/%s", className, objectID(documentID)), nil, nil); err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove document %s from weaviate: %w", documentID, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) GetDocument(ctx context.Context, documentID, collection string) (vs.Document, error) {
+	var obj struct {
+		ID         string         `json:"id"`
+		Properties map[string]any `json:"properties"`
+		Vector     []float32      `json:"vector"`
+	}
+	path := fmt.Sprintf("/v1/objects/%s/%s?include=vector", v.className(collection), objectID(documentID))
+	if err := v.client.do(ctx, http.MethodGet, path, nil, &obj); err != nil {
+		return vs.Document{}, fmt.Errorf("failed to get document %s from weaviate: %w", documentID, err)
+	}
+	return documentFromProperties(obj.ID, obj.Properties, obj.Vector), nil
+}
+
+// GetDocuments returns all documents matching the filters. If no collection is given, all classes with the configured prefix are searched.
+func (v *VectorStore) GetDocuments(ctx context.Context, collection string, whereMetadata map[string]string, whereDocument []vs.WhereDocument) ([]vs.Document, error) {
+	var classes []class
+	if collection != "" {
+		c, err := v.getClass(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("%w: %s", vserr.ErrCollectionNotFound, collection)
+		}
+		classes = []class{*c}
+	} else {
+		var err error
+		classes, err = v.listClasses(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w, inMemoryFilters := buildWhere(whereMetadata, whereDocument)
+
+	docs := make([]vs.Document, 0)
+	for _, c := range classes {
+		if !hasMetadataProperties(&c, whereMetadata) {
+			continue
+		}
+		for offset := 0; ; offset += pageSize {
+			args := []string{fmt.Sprintf("limit: %d", pageSize), fmt.Sprintf("offset: %d", offset)}
+			if w != nil {
+				args = append(args, "where: "+graphQLValue(w))
+			}
+			objects, err := v.get(ctx, c.Class, args, "id vector")
+			if err != nil {
+				return nil, fmt.Errorf("failed to get documents from weaviate: %w", err)
+			}
+			for _, o := range objects {
+				doc := o.toDocument()
+				if matchesAll(&doc, inMemoryFilters) {
+					docs = append(docs, doc)
+				}
+			}
+			if len(objects) < pageSize {
+				break
+			}
+		}
+	}
+	return docs, nil
+}
+
+// listClasses returns the classes with the configured prefix
+func (v *VectorStore) listClasses(ctx context.Context) ([]class, error) {
+	var schema struct {
+		Classes []class `json:"classes"`
+	}
+	if err := v.client.do(ctx, http.MethodGet, "/v1/schema", nil, &schema); err != nil {
+		return nil, fmt.Errorf("failed to list weaviate classes: %w", err)
+	}
+	var classes []class
+	for _, c := range schema.Classes {
+		if strings.HasPrefix(c.Class, v.classPrefix) {
+			classes = append(classes, c)
+		}
+	}
+	return classes, nil
+}
+
+// collectionNames returns the collection names of the classes with the configured prefix, which are valid collection names
+// themselves (already sanitized), but differ from the original names if those had to be sanitized
+func (v *VectorStore) collectionNames(ctx context.Context) ([]string, error) {
+	classes, err := v.listClasses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(classes))
+	for _, c := range classes {
+		names = append(names, strings.TrimPrefix(c.Class, v.classPrefix))
+	}
+	return names, nil
+}
+
+// UpdateDocumentMetadata applies the metadata patch to the document without re-embedding it.
+// The object is merged (PATCH) with the patched metadata JSON and flattened properties, which keeps its vector -
+// the flattened properties of removed keys are emptied, as merging can't remove properties.
+func (v *VectorStore) UpdateDocumentMetadata(ctx context.Context, documentID, collection string, patch vs.MetadataPatch) error {
+	set, remove := patch.Split()
+	if err := v.ensureMetadataProperties(ctx, collection, slices.Sorted(maps.Keys(set))); err != nil {
+		return err
+	}
+
+	slog.Debug("Updating document metadata", "documentID", documentID, "collection", collection, "set", set, "remove", remove, "store", "weaviate")
+
+	className := v.className(collection)
+	path := fmt.Sprintf("/v1/objects/%s/%s", className, objectID(documentID))
+	var obj struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := v.client.do(ctx, http.MethodGet, path, nil, &obj); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+		}
+		return fmt.Errorf("failed to get document %s from weaviate: %w", documentID, err)
+	}
+
+	doc := documentFromProperties(documentID, obj.Properties, nil)
+	metadataJSON, err := json.Marshal(patch.Apply(doc.Metadata))
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata of document %s: %w", documentID, err)
+	}
+	props := map[string]any{propMetadataJSON: string(metadataJSON)}
+	for k, val := range set {
+		props[metadataProperty(k)] = vs.MetadataStrings(val)
+	}
+	for _, k := range remove {
+		props[metadataProperty(k)] = []string{}
+	}
+
+	if err := v.client.do(ctx, http.MethodPatch, path, map[string]any{"class": className, "properties": props}, nil); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %s", vserr.ErrDocumentNotFound, documentID)
+		}
+		return fmt.Errorf("failed to update metadata of document %s: %w", documentID, err)
+	}
+	return nil
+}
+
+func (v *VectorStore) Stats(ctx context.Context, collections ...string) ([]vs.CollectionStats, error) {
+	return nil, fmt.Errorf("%w: stats for vectorstore weaviate", vserr.ErrNotSupported)
+}
+
+// ImportCollectionsFromFile imports collections from a file in the portable export format (see vs.Export).
+// Existing documents with the same IDs are overwritten.
+func (v *VectorStore) ImportCollectionsFromFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ImportCollections(ctx, v, "weaviate", path, collections...)
+}
+
+// ExportCollectionsToFile exports the given collections (or all, if none are given) in the portable export format (see vs.Export).
+// If path is a directory, the file is created in there as vs.ExportFileName.
+func (v *VectorStore) ExportCollectionsToFile(ctx context.Context, path string, collections ...string) error {
+	return helper.ExportCollections(ctx, v, "weaviate", path, v.collectionNames, collections...)
+}
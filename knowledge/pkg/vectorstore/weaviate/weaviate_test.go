@@ -0,0 +1,53 @@
+package weaviate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vserr "github.com/obot-platform/tools/knowledge/pkg/vectorstore/errors"
+	vs "github.com/obot-platform/tools/knowledge/pkg/vectorstore/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDocumentMetadata(t *testing.T) {
+	var (
+		addedProperties []string
+		patched         map[string]any
+	)
+	objectPath := "/v1/objects/Knowledge_ds/" + objectID("doc1")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/schema/Knowledge_ds":
+			_, _ = w.Write([]byte(`{"class": "Knowledge_ds", "properties": [{"name": "content"}, {"name": "meta_draft"}]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/schema/Knowledge_ds/properties":
+			var p property
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			addedProperties = append(addedProperties, p.Name)
+		case r.Method == http.MethodGet && r.URL.Path == objectPath:
+			_, _ = w.Write([]byte(`{"properties": {"documentId": "doc1", "content": "foo", "metadataJson": "{\"source\":\"a.pdf\",\"draft\":true}"}}`))
+		case r.Method == http.MethodPatch && r.URL.Path == objectPath:
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&patched))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	v := &VectorStore{client: &client{baseURL: srv.URL, httpClient: srv.Client()}, classPrefix: defaultClassPrefix}
+	ctx := context.Background()
+
+	require.NoError(t, v.UpdateDocumentMetadata(ctx, "doc1", "ds", vs.MetadataPatch{"tags": []string{"a", "b"}, "draft": nil}))
+	assert.Equal(t, []string{metadataProperty("tags")}, addedProperties)
+	require.NotNil(t, patched)
+	props := patched["properties"].(map[string]any)
+	assert.JSONEq(t, `{"source": "a.pdf", "tags": ["a", "b"]}`, props[propMetadataJSON].(string))
+	assert.Equal(t, []any{"a", "b"}, props[metadataProperty("tags")])
+	assert.Equal(t, []any{}, props[metadataProperty("draft")])
+
+	assert.ErrorIs(t, v.UpdateDocumentMetadata(ctx, "doc2", "ds", vs.MetadataPatch{"draft": nil}), vserr.ErrDocumentNotFound)
+}